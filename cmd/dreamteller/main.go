@@ -5,19 +5,28 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/azyu/dreamteller/internal/app"
+	"github.com/azyu/dreamteller/internal/batch"
+	"github.com/azyu/dreamteller/internal/chatimport"
+	"github.com/azyu/dreamteller/internal/i18n"
 	"github.com/azyu/dreamteller/internal/llm"
 	"github.com/azyu/dreamteller/internal/llm/adapters"
 	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/scaffold"
 	"github.com/azyu/dreamteller/internal/search"
-	"github.com/azyu/dreamteller/internal/token"
+	"github.com/azyu/dreamteller/internal/storage"
 	"github.com/azyu/dreamteller/internal/tui"
 	"github.com/azyu/dreamteller/pkg/types"
 	tea "github.com/charmbracelet/bubbletea"
@@ -27,13 +36,79 @@ import (
 
 var version = "0.1.0"
 
+// projectsDirFlag holds the --projects-dir override for this invocation.
+var projectsDirFlag string
+
+// offlineFlag disables all network access for this invocation.
+var offlineFlag bool
+
+// langFlag holds the --lang override of the CLI's language.
+var langFlag string
+
+// localizedError is a sentinel error translated when it is shown, since
+// sentinels are made before the CLI's language is known.
+type localizedError string
+
+func (e localizedError) Error() string {
+	return i18n.T(string(e))
+}
+
+var errOffline error = localizedError("network access is disabled (--offline)")
+
+// newApp creates the application, honoring the --projects-dir override.
+func newApp() (*app.App, error) {
+	var opts []app.Option
+	if projectsDirFlag != "" {
+		opts = append(opts, app.WithProjectsDir(projectsDirFlag))
+	}
+	return app.New(opts...)
+}
+
 func main() {
+	i18n.SetLanguage(cliLanguage(os.Args[1:]))
+	i18n.LocalizeCommand(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, i18n.T("Error:"), i18n.LocalizeError(err))
 		os.Exit(1)
 	}
 }
 
+// cliLanguage picks the language of the CLI's help, messages and prompts:
+// --lang, or else the language of the global config. Commands with a --lang
+// flag of their own, such as export, leave it to the config.
+func cliLanguage(args []string) i18n.Language {
+	if cmd, _, err := rootCmd.Find(args); err != nil || cmd.LocalNonPersistentFlags().Lookup("lang") == nil {
+		if lang, err := i18n.ParseLanguage(langArg(args)); err == nil {
+			return lang
+		}
+	}
+	if config, err := app.NewConfigManager(); err == nil {
+		if global, err := config.LoadGlobalConfig(); err == nil {
+			if lang, err := i18n.ParseLanguage(global.Language); err == nil {
+				return lang
+			}
+		}
+	}
+	return i18n.English
+}
+
+// langArg returns the value of --lang in args, which cobra has not parsed
+// yet when the help is translated.
+func langArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--lang="); ok {
+			return value
+		}
+		if arg == "--lang" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "dreamteller",
 	Short: "A TUI application for writing novels with AI assistance",
@@ -41,6 +116,16 @@ var rootCmd = &cobra.Command{
 with AI assistance. It provides context-aware suggestions based on your
 characters, settings, and plot points.`,
 	Version: version,
+
+	// main prints errors in the CLI's language.
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if langFlag == "" {
+			return nil
+		}
+		_, err := i18n.ParseLanguage(langFlag)
+		return err
+	},
 }
 
 var newCmd = &cobra.Command{
@@ -53,33 +138,39 @@ var newCmd = &cobra.Command{
 func runNewCmd(cmd *cobra.Command, args []string) error {
 	name := args[0]
 	fromPrompt, _ := cmd.Flags().GetString("from-prompt")
+	fromChat, _ := cmd.Flags().GetString("from-chat")
+	conversation, _ := cmd.Flags().GetString("conversation")
 	genre, _ := cmd.Flags().GetString("genre")
 
-	application, err := app.New()
+	application, err := newApp()
 	if err != nil {
-		return fmt.Errorf("failed to initialize app: %w", err)
+		return i18n.Errorf("failed to initialize app: %w", err)
 	}
 	defer application.Close()
 
 	if application.ProjectManager.Exists(name) {
-		return fmt.Errorf("project '%s' already exists", name)
+		return i18n.Errorf("project '%s' already exists", name)
 	}
 
 	// Handle --from-prompt flag
 	if fromPrompt != "" {
 		promptContent, err := readPromptFile(fromPrompt)
 		if err != nil {
-			return fmt.Errorf("failed to read prompt file: %w", err)
+			return i18n.Errorf("failed to read prompt file: %w", err)
 		}
 		return createProjectFromPrompt(application, name, promptContent)
 	}
 
+	if fromChat != "" {
+		return createProjectFromChat(application, name, fromChat, conversation)
+	}
+
 	// Handle --genre flag for quick creation
 	if genre != "" {
 		if err := application.CreateProject(name, genre); err != nil {
-			return fmt.Errorf("failed to create project: %w", err)
+			return i18n.Errorf("failed to create project: %w", err)
 		}
-		fmt.Printf("Created project '%s' with genre '%s' at %s\n", name, genre, application.CurrentProject.Path())
+		i18n.Printf("Created project '%s' with genre '%s' at %s\n", name, genre, application.CurrentProject.Path())
 		return nil
 	}
 
@@ -96,175 +187,95 @@ const (
 	SetupModeTemplate SetupMode = "template"
 )
 
-type Language string
+// genreKeys, povKeys, tenseKeys and ratingKeys are the wizard's choices in
+// order; the labels are translated when shown.
+var (
+	genreKeys  = []string{"fantasy", "scifi", "mystery", "romance", "thriller", "horror", "historical", "literary", "other"}
+	povKeys    = []string{"first-person", "third-person-limited", "third-person-omniscient", "second-person"}
+	tenseKeys  = []string{"past", "present"}
+	ratingKeys = []string{"", types.RatingYA, types.RatingNoGraphicViolence, types.RatingAdult}
+)
 
-const (
-	LangEnglish  Language = "en"
-	LangKorean   Language = "ko"
-	LangJapanese Language = "ja"
+var (
+	genreLabels = map[string]string{
+		"fantasy":    "Fantasy",
+		"scifi":      "Science Fiction",
+		"mystery":    "Mystery",
+		"romance":    "Romance",
+		"thriller":   "Thriller",
+		"horror":     "Horror",
+		"historical": "Historical Fiction",
+		"literary":   "Literary Fiction",
+		"other":      "Other",
+	}
+	povLabels = map[string]string{
+		"first-person":            "First Person",
+		"third-person-limited":    "Third Person Limited",
+		"third-person-omniscient": "Third Person Omniscient",
+		"second-person":           "Second Person",
+	}
+	tenseLabels = map[string]string{
+		"past":    "Past Tense",
+		"present": "Present Tense",
+	}
+	ratingLabels = map[string]string{
+		"":                            "No rating",
+		types.RatingYA:                "Young Adult",
+		types.RatingNoGraphicViolence: "No Graphic Violence",
+		types.RatingAdult:             "Adult",
+	}
 )
 
-type i18nStrings struct {
-	SetupTitle       string
-	SetupWizard      string
-	SetupPrompt      string
-	SetupTemplate    string
-	SelectGenre      string
-	WritingStyle     string
-	StylePlaceholder string
-	PointOfView      string
-	Tense            string
-	Genres           map[string]string
-	POVs             map[string]string
-	Tenses           map[string]string
-	CreatedProject   string
-	RunToStart       string
-}
-
-var translations = map[Language]i18nStrings{
-	LangEnglish: {
-		SetupTitle:       "How would you like to set up your project?",
-		SetupWizard:      "Wizard - Guided step-by-step setup",
-		SetupPrompt:      "Prompt - Describe your story and auto-create",
-		SetupTemplate:    "Template - Start from a preset (coming soon)",
-		SelectGenre:      "Select your genre",
-		WritingStyle:     "Describe your writing style",
-		StylePlaceholder: "e.g., descriptive, immersive, fast-paced",
-		PointOfView:      "Point of View",
-		Tense:            "Tense",
-		Genres: map[string]string{
-			"fantasy":    "Fantasy",
-			"scifi":      "Science Fiction",
-			"mystery":    "Mystery",
-			"romance":    "Romance",
-			"thriller":   "Thriller",
-			"horror":     "Horror",
-			"historical": "Historical Fiction",
-			"literary":   "Literary Fiction",
-			"other":      "Other",
-		},
-		POVs: map[string]string{
-			"first-person":            "First Person",
-			"third-person-limited":    "Third Person Limited",
-			"third-person-omniscient": "Third Person Omniscient",
-			"second-person":           "Second Person",
-		},
-		Tenses: map[string]string{
-			"past":    "Past Tense",
-			"present": "Present Tense",
-		},
-		CreatedProject: "Created project '%s' at %s",
-		RunToStart:     "Run 'dreamteller open %s' to start writing!",
-	},
-	LangKorean: {
-		SetupTitle:       "프로젝트를 어떻게 설정하시겠습니까?",
-		SetupWizard:      "마법사 - 단계별 안내 설정",
-		SetupPrompt:      "프롬프트 - 스토리 설명으로 자동 생성",
-		SetupTemplate:    "템플릿 - 프리셋으로 시작 (준비 중)",
-		SelectGenre:      "장르를 선택하세요",
-		WritingStyle:     "작문 스타일을 설명하세요",
-		StylePlaceholder: "예: 묘사적, 몰입감 있는, 빠른 전개",
-		PointOfView:      "시점",
-		Tense:            "시제",
-		Genres: map[string]string{
-			"fantasy":    "판타지",
-			"scifi":      "SF (과학 소설)",
-			"mystery":    "미스터리",
-			"romance":    "로맨스",
-			"thriller":   "스릴러",
-			"horror":     "호러",
-			"historical": "역사 소설",
-			"literary":   "순수 문학",
-			"other":      "기타",
-		},
-		POVs: map[string]string{
-			"first-person":            "1인칭",
-			"third-person-limited":    "3인칭 제한",
-			"third-person-omniscient": "3인칭 전지",
-			"second-person":           "2인칭",
-		},
-		Tenses: map[string]string{
-			"past":    "과거 시제",
-			"present": "현재 시제",
-		},
-		CreatedProject: "'%s' 프로젝트가 %s에 생성되었습니다",
-		RunToStart:     "'dreamteller open %s' 명령으로 시작하세요!",
-	},
-	LangJapanese: {
-		SetupTitle:       "プロジェクトの設定方法を選んでください",
-		SetupWizard:      "ウィザード - ステップバイステップのガイド設定",
-		SetupPrompt:      "プロンプト - ストーリーを説明して自動作成",
-		SetupTemplate:    "テンプレート - プリセットから開始（準備中）",
-		SelectGenre:      "ジャンルを選択してください",
-		WritingStyle:     "文体を説明してください",
-		StylePlaceholder: "例：描写的、没入感のある、テンポが速い",
-		PointOfView:      "視点",
-		Tense:            "時制",
-		Genres: map[string]string{
-			"fantasy":    "ファンタジー",
-			"scifi":      "SF（サイエンスフィクション）",
-			"mystery":    "ミステリー",
-			"romance":    "ロマンス",
-			"thriller":   "スリラー",
-			"horror":     "ホラー",
-			"historical": "歴史小説",
-			"literary":   "純文学",
-			"other":      "その他",
-		},
-		POVs: map[string]string{
-			"first-person":            "一人称",
-			"third-person-limited":    "三人称限定",
-			"third-person-omniscient": "三人称全知",
-			"second-person":           "二人称",
-		},
-		Tenses: map[string]string{
-			"past":    "過去形",
-			"present": "現在形",
-		},
-		CreatedProject: "プロジェクト '%s' を %s に作成しました",
-		RunToStart:     "'dreamteller open %s' で開始してください！",
-	},
+// selectOptions builds the translated options of a select from keys and their
+// English labels.
+func selectOptions(keys []string, labels map[string]string) []huh.Option[string] {
+	opts := make([]huh.Option[string], len(keys))
+	for i, key := range keys {
+		opts[i] = huh.NewOption(i18n.T(labels[key]), key)
+	}
+	return opts
 }
 
-// runInteractiveSetup shows the setup mode selection UI.
+// runInteractiveSetup shows the setup mode selection UI. The language
+// chosen first is used for the rest of the setup and for the project.
 func runInteractiveSetup(application *app.App, name string) error {
-	var lang Language
+	lang := i18n.Current()
 
 	langForm := huh.NewForm(
 		huh.NewGroup(
-			huh.NewSelect[Language]().
+			huh.NewSelect[i18n.Language]().
 				Title("Select your language / 언어 선택 / 言語を選択").
 				Options(
-					huh.NewOption("English", LangEnglish),
-					huh.NewOption("한국어", LangKorean),
-					huh.NewOption("日本語", LangJapanese),
+					huh.NewOption("English", i18n.English),
+					huh.NewOption("한국어", i18n.Korean),
+					huh.NewOption("日本語", i18n.Japanese),
 				).
 				Value(&lang),
 		),
 	)
 
 	if err := langForm.Run(); err != nil {
-		return fmt.Errorf("language selection failed: %w", err)
+		return i18n.Errorf("language selection failed: %w", err)
 	}
+	i18n.SetLanguage(lang)
 
-	t := translations[lang]
 	var mode SetupMode
 
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewSelect[SetupMode]().
-				Title(t.SetupTitle).
+				Title(i18n.T("How would you like to set up your project?")).
 				Options(
-					huh.NewOption(t.SetupWizard, SetupModeWizard),
-					huh.NewOption(t.SetupPrompt, SetupModePrompt),
-					huh.NewOption(t.SetupTemplate, SetupModeTemplate),
+					huh.NewOption(i18n.T("Wizard - Guided step-by-step setup"), SetupModeWizard),
+					huh.NewOption(i18n.T("Prompt - Describe your story and auto-create"), SetupModePrompt),
+					huh.NewOption(i18n.T("Template - Start from a preset (coming soon)"), SetupModeTemplate),
 				).
 				Value(&mode),
 		),
 	)
 
 	if err := form.Run(); err != nil {
-		return fmt.Errorf("setup mode selection failed: %w", err)
+		return i18n.Errorf("setup mode selection failed: %w", err)
 	}
 
 	switch mode {
@@ -273,87 +284,78 @@ func runInteractiveSetup(application *app.App, name string) error {
 	case SetupModePrompt:
 		return runPromptSetup(application, name)
 	case SetupModeTemplate:
-		fmt.Println("Template mode is coming soon!")
-		fmt.Println("Please use Wizard or Prompt mode for now.")
+		i18n.Println("Template mode is coming soon!")
+		i18n.Println("Please use Wizard or Prompt mode for now.")
 		return nil
 	default:
-		return fmt.Errorf("unknown setup mode: %s", mode)
+		return i18n.Errorf("unknown setup mode: %s", mode)
 	}
 }
 
 // runWizardSetup runs the guided step-by-step wizard.
-func runWizardSetup(application *app.App, name string, lang Language) error {
-	t := translations[lang]
+func runWizardSetup(application *app.App, name string, lang i18n.Language) error {
 	var genre string
 	var writingStyle string
 	var pov string
 	var tense string
-
-	genreKeys := []string{"fantasy", "scifi", "mystery", "romance", "thriller", "horror", "historical", "literary", "other"}
-	genres := make([]huh.Option[string], len(genreKeys))
-	for i, key := range genreKeys {
-		genres[i] = huh.NewOption(t.Genres[key], key)
-	}
-
-	povKeys := []string{"first-person", "third-person-limited", "third-person-omniscient", "second-person"}
-	povOptions := make([]huh.Option[string], len(povKeys))
-	for i, key := range povKeys {
-		povOptions[i] = huh.NewOption(t.POVs[key], key)
-	}
-
-	tenseKeys := []string{"past", "present"}
-	tenseOptions := make([]huh.Option[string], len(tenseKeys))
-	for i, key := range tenseKeys {
-		tenseOptions[i] = huh.NewOption(t.Tenses[key], key)
-	}
+	var rating string
 
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewSelect[string]().
-				Title(t.SelectGenre).
-				Options(genres...).
+				Title(i18n.T("Select your genre")).
+				Options(selectOptions(genreKeys, genreLabels)...).
 				Value(&genre),
 		),
 		huh.NewGroup(
 			huh.NewInput().
-				Title(t.WritingStyle).
-				Placeholder(t.StylePlaceholder).
+				Title(i18n.T("Describe your writing style")).
+				Placeholder(i18n.T("e.g., descriptive, immersive, fast-paced")).
 				Value(&writingStyle),
 		),
 		huh.NewGroup(
 			huh.NewSelect[string]().
-				Title(t.PointOfView).
-				Options(povOptions...).
+				Title(i18n.T("Point of View")).
+				Options(selectOptions(povKeys, povLabels)...).
 				Value(&pov),
 		),
 		huh.NewGroup(
 			huh.NewSelect[string]().
-				Title(t.Tense).
-				Options(tenseOptions...).
+				Title(i18n.T("Tense")).
+				Options(selectOptions(tenseKeys, tenseLabels)...).
 				Value(&tense),
 		),
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(i18n.T("Content Rating")).
+				Options(selectOptions(ratingKeys, ratingLabels)...).
+				Value(&rating),
+		),
 	)
 
 	if err := form.Run(); err != nil {
-		return fmt.Errorf("wizard setup failed: %w", err)
+		return i18n.Errorf("wizard setup failed: %w", err)
 	}
 
 	config := types.DefaultProjectConfig(name, genre)
 	config.Writing.Style = writingStyle
 	config.Writing.POV = pov
 	config.Writing.Tense = tense
+	config.Writing.Rating = rating
+	config.Language = string(lang)
 
 	proj, err := application.ProjectManager.Create(name, config)
 	if err != nil {
-		return fmt.Errorf("failed to create project: %w", err)
+		return i18n.Errorf("failed to create project: %w", err)
 	}
 	application.CurrentProject = proj
 
-	fmt.Printf("\n"+t.CreatedProject+"\n", name, proj.Path())
-	fmt.Printf("Genre: %s\n", t.Genres[genre])
-	fmt.Printf("Style: %s\n", writingStyle)
-	fmt.Printf("POV: %s, Tense: %s\n", t.POVs[pov], t.Tenses[tense])
-	fmt.Printf("\n"+t.RunToStart+"\n", name)
+	i18n.Printf("\nCreated project '%s' at %s\n", name, proj.Path())
+	i18n.Printf("Genre: %s\n", i18n.T(genreLabels[genre]))
+	i18n.Printf("Style: %s\n", writingStyle)
+	i18n.Printf("POV: %s, Tense: %s\n", i18n.T(povLabels[pov]), i18n.T(tenseLabels[tense]))
+	i18n.Printf("Content Rating: %s\n", i18n.T(ratingLabels[rating]))
+	i18n.Printf("\nRun 'dreamteller open %s' to start writing!\n", name)
 
 	return nil
 }
@@ -365,20 +367,20 @@ func runPromptSetup(application *app.App, name string) error {
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewText().
-				Title("Describe your story").
-				Description("Include details about genre, setting, characters, and plot ideas.").
-				Placeholder("Write a detailed description of your novel idea...").
+				Title(i18n.T("Describe your story")).
+				Description(i18n.T("Include details about genre, setting, characters, and plot ideas.")).
+				Placeholder(i18n.T("Write a detailed description of your novel idea...")).
 				CharLimit(4000).
 				Value(&prompt),
 		),
 	)
 
 	if err := form.Run(); err != nil {
-		return fmt.Errorf("prompt setup failed: %w", err)
+		return i18n.Errorf("prompt setup failed: %w", err)
 	}
 
 	if strings.TrimSpace(prompt) == "" {
-		return fmt.Errorf("prompt cannot be empty")
+		return i18n.Errorf("prompt cannot be empty")
 	}
 
 	return createProjectFromPrompt(application, name, prompt)
@@ -392,10 +394,11 @@ func readPromptFile(path string) (string, error) {
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to read file %s: %w", path, err)
+		return "", i18n.Errorf("failed to read file %s: %w", path, err)
 	}
 
-	return strings.TrimSpace(string(data)), nil
+	text, _ := storage.DecodeText(data)
+	return strings.TrimSpace(text), nil
 }
 
 // readFromStdin reads all content from stdin.
@@ -410,7 +413,7 @@ func readFromStdin() (string, error) {
 				builder.WriteString(line)
 				break
 			}
-			return "", fmt.Errorf("error reading stdin: %w", err)
+			return "", i18n.Errorf("error reading stdin: %w", err)
 		}
 		builder.WriteString(line)
 	}
@@ -418,12 +421,12 @@ func readFromStdin() (string, error) {
 	return strings.TrimSpace(builder.String()), nil
 }
 
-var errNoProvider = fmt.Errorf("no LLM provider configured")
+var errNoProvider error = localizedError("no LLM provider configured")
 
 func checkLLMProvider(application *app.App) (*types.ProviderConfig, string, error) {
 	globalConfig, err := application.Config.LoadGlobalConfig()
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to load config: %w", err)
+		return nil, "", i18n.Errorf("failed to load config: %w", err)
 	}
 
 	providerName := globalConfig.Defaults.Provider
@@ -433,14 +436,14 @@ func checkLLMProvider(application *app.App) (*types.ProviderConfig, string, erro
 
 	providerConfig, err := application.Config.GetProviderConfig(providerName)
 	if err != nil {
-		fmt.Println("\n⚠ No LLM provider configured.")
-		fmt.Println("Run 'dreamteller auth' to set up a provider.")
+		i18n.Println("\n⚠ No LLM provider configured.")
+		i18n.Println("Run 'dreamteller auth' to set up a provider.")
 		return nil, "", errNoProvider
 	}
 
 	if providerName != "local" && providerConfig.APIKey == "" {
-		fmt.Printf("\n⚠ No API key configured for %s.\n", providerName)
-		fmt.Println("Run 'dreamteller auth' to set up a provider.")
+		i18n.Printf("\n⚠ No API key configured for %s.\n", providerName)
+		i18n.Println("Run 'dreamteller auth' to set up a provider.")
 		return nil, "", errNoProvider
 	}
 
@@ -448,7 +451,11 @@ func checkLLMProvider(application *app.App) (*types.ProviderConfig, string, erro
 }
 
 func createProjectFromPrompt(application *app.App, name, promptContent string) error {
-	fmt.Println("Analyzing your story description...")
+	if offlineFlag {
+		return i18n.Errorf("--from-prompt requires an LLM provider: %w", errOffline)
+	}
+
+	i18n.Println("Analyzing your story description...")
 
 	providerConfig, providerName, err := checkLLMProvider(application)
 	if err != nil {
@@ -458,16 +465,27 @@ func createProjectFromPrompt(application *app.App, name, promptContent string) e
 	ctx := context.Background()
 	provider, err := initLLMProvider(ctx, providerName, providerConfig)
 	if err != nil {
-		return fmt.Errorf("failed to initialize LLM provider: %w", err)
+		return i18n.Errorf("failed to initialize LLM provider: %w", err)
 	}
 	defer provider.Close()
 
 	parseResult, err := parsePromptWithAI(ctx, provider, promptContent)
 	if err != nil {
-		return fmt.Errorf("failed to parse prompt: %w", err)
+		return i18n.Errorf("failed to parse prompt: %w", err)
 	}
 
-	fmt.Println("Creating project structure...")
+	if _, err := createProjectFromSetup(application, name, parseResult); err != nil {
+		return err
+	}
+	i18n.Printf("\nRun 'dreamteller open %s' to start writing!\n", name)
+
+	return nil
+}
+
+// createProjectFromSetup creates a project with the context files of an
+// extracted setup and prints what it made.
+func createProjectFromSetup(application *app.App, name string, parseResult *types.ParsePromptResult) (*project.Project, error) {
+	i18n.Println("Creating project structure...")
 
 	// Create project config from parsed result
 	config := types.DefaultProjectConfig(name, parseResult.Genre)
@@ -478,30 +496,110 @@ func createProjectFromPrompt(application *app.App, name, promptContent string) e
 	// Create the project
 	proj, err := application.ProjectManager.Create(name, config)
 	if err != nil {
-		return fmt.Errorf("failed to create project: %w", err)
+		return nil, i18n.Errorf("failed to create project: %w", err)
 	}
 	application.CurrentProject = proj
 
 	// Generate initial context files
 	if err := generateInitialContext(proj, parseResult); err != nil {
-		fmt.Printf("Warning: failed to generate some context files: %v\n", err)
+		i18n.Printf("Warning: failed to generate some context files: %v\n", err)
 	}
 
-	fmt.Printf("\nCreated project '%s' at %s\n", name, proj.Path())
-	fmt.Printf("Genre: %s\n", parseResult.Genre)
+	i18n.Printf("\nCreated project '%s' at %s\n", name, proj.Path())
+	i18n.Printf("Genre: %s\n", parseResult.Genre)
 
 	if len(parseResult.Characters) > 0 {
-		fmt.Printf("Characters: %d created\n", len(parseResult.Characters))
+		i18n.Printf("Characters: %d created\n", len(parseResult.Characters))
 	}
 	if parseResult.Setting.Location != "" {
-		fmt.Println("Setting: created")
+		i18n.Println("Setting: created")
 	}
 	if len(parseResult.PlotHints) > 0 {
-		fmt.Printf("Plot hints: %d created\n", len(parseResult.PlotHints))
+		i18n.Printf("Plot hints: %d created\n", len(parseResult.PlotHints))
+	}
+	return proj, nil
+}
+
+// createProjectFromChat seeds a project from brainstorming conversations
+// in a ChatGPT or Claude export: the model extracts the story decisions,
+// and the transcripts are kept as research notes.
+func createProjectFromChat(application *app.App, name, exportPath, query string) error {
+	if offlineFlag {
+		return i18n.Errorf("--from-chat requires an LLM provider: %w", errOffline)
+	}
+
+	data, err := os.ReadFile(exportPath)
+	if err != nil {
+		return i18n.Errorf("failed to read export: %w", err)
+	}
+	conversations, err := chatimport.Parse(data)
+	if err != nil {
+		return err
+	}
+	matched := chatimport.Filter(conversations, query)
+	switch {
+	case len(matched) == 0 && query != "":
+		return i18n.Errorf("no conversation title contains %q", query)
+	case len(matched) == 0:
+		return i18n.Errorf("the export has no conversations with text")
+	case len(matched) > 1 && query == "":
+		i18n.Printf("The export has %d conversations:\n", len(matched))
+		for _, title := range chatimport.Titles(matched) {
+			fmt.Printf("  %s\n", title)
+		}
+		return i18n.Errorf("choose the conversations to import with --conversation <title>")
 	}
 
-	fmt.Println("\nRun 'dreamteller open " + name + "' to start writing!")
+	providerConfig, providerName, err := checkLLMProvider(application)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	provider, err := initLLMProvider(ctx, providerName, providerConfig)
+	if err != nil {
+		return i18n.Errorf("failed to initialize LLM provider: %w", err)
+	}
+	defer provider.Close()
+
+	i18n.Printf("Reading %d conversation(s)...\n", len(matched))
+	parseResult, err := chatimport.Extract(ctx, provider, matched, chatimport.DefaultPartRunes, func(part, total int) {
+		if total > 1 {
+			i18n.Printf("  part %d of %d\n", part, total)
+		}
+	})
+	if err != nil {
+		return i18n.Errorf("failed to extract the story: %w", err)
+	}
+	if parseResult.Genre == "" {
+		parseResult.Genre = "literary"
+	}
+
+	proj, err := createProjectFromSetup(application, name, parseResult)
+	if err != nil {
+		return err
+	}
 
+	dir := filepath.Join(search.ResearchDir, "brainstorm")
+	if err := proj.FS.EnsureDir(dir); err != nil {
+		return i18n.Errorf("failed to create %s: %w", dir, err)
+	}
+	for _, c := range matched {
+		slug := project.FileSlug(c.Title)
+		if slug == "" {
+			slug = "conversation"
+		}
+		path := filepath.Join(dir, slug+".md")
+		for i := 2; proj.FS.Exists(path); i++ {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d.md", slug, i))
+		}
+		if err := proj.FS.WriteMarkdown(path, c.Markdown()); err != nil {
+			i18n.Printf("Warning: failed to save the transcript of %q: %v\n", c.Title, err)
+		}
+	}
+	i18n.Printf("Transcripts: %d saved to %s/\n", len(matched), filepath.ToSlash(dir))
+
+	i18n.Printf("\nRun 'dreamteller open %s' to start writing!\n", name)
 	return nil
 }
 
@@ -517,6 +615,9 @@ func initLLMProvider(ctx context.Context, providerName string, config *types.Pro
 		if config.BaseURL != "" {
 			opts = append(opts, adapters.WithOpenAIBaseURL(config.BaseURL))
 		}
+		if config.Timeout > 0 {
+			opts = append(opts, adapters.WithOpenAITimeout(config.Timeout))
+		}
 		return adapters.NewOpenAIAdapter(config.APIKey, model, opts...)
 
 	case "gemini":
@@ -535,67 +636,34 @@ func initLLMProvider(ctx context.Context, providerName string, config *types.Pro
 		if model == "" {
 			model = "llama3"
 		}
-		return adapters.NewLocalAdapter(baseURL, model), nil
+		if config.Protocol == "ollama" {
+			opts := []adapters.OllamaOption{
+				adapters.WithOllamaKeepAlive(config.KeepAlive),
+				adapters.WithOllamaNumCtx(config.NumCtx),
+			}
+			if config.Timeout > 0 {
+				opts = append(opts, adapters.WithOllamaTimeout(config.Timeout))
+			}
+			return adapters.NewOllamaAdapter(baseURL, model, opts...), nil
+		}
+		var opts []adapters.LocalAdapterOption
+		if config.Timeout > 0 {
+			opts = append(opts, adapters.WithTimeout(config.Timeout))
+		}
+		return adapters.NewLocalAdapter(baseURL, model, opts...), nil
 
 	default:
-		return nil, fmt.Errorf("unsupported provider: %s", providerName)
+		return nil, i18n.Errorf("unsupported provider: %s", providerName)
 	}
 }
 
 // parsePromptWithAI uses the LLM to parse the story prompt and extract structured data.
+// The parser picks native structured output, tool calling, or JSON-in-text
+// based on the provider's capabilities and repairs invalid responses.
 func parsePromptWithAI(ctx context.Context, provider llm.Provider, promptContent string) (*types.ParsePromptResult, error) {
-	systemPrompt := `You are a creative writing assistant. Analyze the user's story description and extract structured information.
-
-Return a JSON object with the following structure:
-{
-  "genre": "the primary genre (fantasy, scifi, mystery, romance, thriller, horror, historical, literary)",
-  "setting": {
-    "time_period": "when the story takes place",
-    "location": "where the story takes place",
-    "description": "detailed setting description"
-  },
-  "characters": [
-    {
-      "name": "character name",
-      "role": "protagonist/antagonist/supporting",
-      "description": "character description",
-      "traits": {"key": "value"}
-    }
-  ],
-  "plot_hints": ["key plot point or theme"],
-  "style_guide": {
-    "tone": "the overall tone (dark, humorous, romantic, etc.)",
-    "pacing": "slow/medium/fast",
-    "dialogue": "description of dialogue style",
-    "vocabulary_notes": ["notes about word choices or style"]
-  }
-}
-
-Be creative in filling in details based on the user's description. If something isn't mentioned, make reasonable inferences based on the genre and context.`
-
-	messages := []llm.ChatMessage{
-		llm.NewSystemMessage(systemPrompt),
-		llm.NewUserMessage(promptContent),
-	}
-
-	resp, err := provider.Chat(ctx, llm.ChatRequest{
-		Messages:    messages,
-		MaxTokens:   2000,
-		Temperature: 0.7,
-	})
+	result, err := llm.NewPromptParser(provider).ParseSetupPrompt(ctx, promptContent)
 	if err != nil {
-		return nil, fmt.Errorf("LLM request failed: %w", err)
-	}
-
-	// Parse the JSON response
-	content := strings.TrimSpace(resp.Message.Content)
-
-	// Try to extract JSON from the response (in case it's wrapped in markdown)
-	content = extractJSON(content)
-
-	var result types.ParsePromptResult
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse LLM response as JSON: %w\nResponse: %s", err, content)
+		return nil, i18n.Errorf("LLM request failed: %w", err)
 	}
 
 	// Default genre if not detected
@@ -603,32 +671,7 @@ Be creative in filling in details based on the user's description. If something
 		result.Genre = "literary"
 	}
 
-	return &result, nil
-}
-
-// extractJSON attempts to extract JSON from a response that might be wrapped in markdown.
-func extractJSON(content string) string {
-	// Try to find JSON block in markdown
-	if idx := strings.Index(content, "```json"); idx != -1 {
-		content = content[idx+7:]
-		if endIdx := strings.Index(content, "```"); endIdx != -1 {
-			content = content[:endIdx]
-		}
-	} else if idx := strings.Index(content, "```"); idx != -1 {
-		content = content[idx+3:]
-		if endIdx := strings.Index(content, "```"); endIdx != -1 {
-			content = content[:endIdx]
-		}
-	}
-
-	// Try to find JSON object boundaries
-	start := strings.Index(content, "{")
-	end := strings.LastIndex(content, "}")
-	if start != -1 && end != -1 && end > start {
-		content = content[start : end+1]
-	}
-
-	return strings.TrimSpace(content)
+	return result, nil
 }
 
 // generateInitialContext creates initial context files from parsed data.
@@ -661,7 +704,7 @@ func generateInitialContext(proj *project.Project, result *types.ParsePromptResu
 			}
 		}
 
-		filename := sanitizeFilename(char.Name)
+		filename := proj.UniqueContextFileName("characters", char.Name, "character")
 		if err := proj.CreateContextFile("characters", filename, content); err != nil {
 			errs = append(errs, fmt.Sprintf("character %s: %v", char.Name, err))
 		}
@@ -680,49 +723,32 @@ func generateInitialContext(proj *project.Project, result *types.ParsePromptResu
 	}
 
 	if len(errs) > 0 {
-		return fmt.Errorf("errors: %s", strings.Join(errs, "; "))
+		return i18n.Errorf("errors: %s", strings.Join(errs, "; "))
 	}
 
 	return nil
 }
 
-// sanitizeFilename converts a name to a safe filename.
-func sanitizeFilename(name string) string {
-	// Convert to lowercase and replace spaces with hyphens
-	result := strings.ToLower(name)
-	result = strings.ReplaceAll(result, " ", "-")
-
-	// Remove any characters that aren't alphanumeric, hyphens, or underscores
-	var sanitized strings.Builder
-	for _, r := range result {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
-			sanitized.WriteRune(r)
-		}
-	}
-
-	return sanitized.String()
-}
-
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all novel projects",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		application, err := app.New()
+		application, err := newApp()
 		if err != nil {
-			return fmt.Errorf("failed to initialize app: %w", err)
+			return i18n.Errorf("failed to initialize app: %w", err)
 		}
 
 		projects, err := application.ListProjects()
 		if err != nil {
-			return fmt.Errorf("failed to list projects: %w", err)
+			return i18n.Errorf("failed to list projects: %w", err)
 		}
 
 		if len(projects) == 0 {
-			fmt.Println("No projects found. Create one with: dreamteller new <name>")
+			i18n.Println("No projects found. Create one with: dreamteller new <name>")
 			return nil
 		}
 
-		fmt.Println("Projects:")
+		i18n.Printf("Projects in %s:\n", application.ProjectManager.ProjectsDir())
 		for _, p := range projects {
 			fmt.Printf("  - %s (%s) - %s\n", p.Name, p.Genre, p.Path)
 		}
@@ -731,34 +757,174 @@ var listCmd = &cobra.Command{
 }
 
 var openCmd = &cobra.Command{
-	Use:   "open <name>",
+	Use:   "open <name|path>",
 	Short: "Open a novel project in TUI mode",
-	Args:  cobra.ExactArgs(1),
+	Long: `Open a novel project in TUI mode.
+
+The argument is either a project name inside the projects directory or a
+path to a project directory (absolute, ~/..., ./... or ../...).`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
-		application, err := app.New()
+		application, err := newApp()
 		if err != nil {
-			return fmt.Errorf("failed to initialize app: %w", err)
+			return i18n.Errorf("failed to initialize app: %w", err)
 		}
 		defer application.Close()
 
 		if err := application.OpenProject(name); err != nil {
-			return fmt.Errorf("failed to open project: %w", err)
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+
+		replayPath, _ := cmd.Flags().GetString("replay")
+		recordPath, _ := cmd.Flags().GetString("record")
+		explainRanking, _ := cmd.Flags().GetBool("explain-ranking")
+		opts := tuiOptions{
+			replayPath:     replayPath,
+			recordPath:     recordPath,
+			explainRanking: explainRanking,
+			listProjects:   application.ListProjects,
+		}
+
+		// /projects ends the session with the project to open next. The
+		// current one is closed first, releasing its database and index,
+		// and the next starts a session of its own with its own history,
+		// config and provider.
+		for {
+			next, err := runTUI(application.CurrentProject, opts)
+			if err != nil || next == "" {
+				return err
+			}
+			if err := application.CloseProject(); err != nil {
+				return i18n.Errorf("failed to close project: %w", err)
+			}
+			if err := application.OpenProject(next); err != nil {
+				return i18n.Errorf("failed to open project: %w", err)
+			}
+		}
+	},
+}
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt <name|path>",
+	Short: "Show the request a chat message would send",
+	Long: `Assemble the request the chat would send for a message after the project's
+saved conversation, as opening the project and sending it would, and print it
+without calling the provider: the system prompt, the retrieved chunks and why
+each was chosen, the history that fits the budget, and token counts. Use it to
+debug prompts or attach to a bug report.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		message, _ := cmd.Flags().GetString("message")
+		modeName, _ := cmd.Flags().GetString("mode")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if !dryRun {
+			return i18n.Errorf("prompt only assembles requests; pass --dry-run (use 'dreamteller open %s' to chat)", args[0])
+		}
+		if strings.TrimSpace(message) == "" {
+			return i18n.Errorf("--message is required")
+		}
+		mode, err := tui.ParseContextMode(modeName)
+		if err != nil {
+			return err
+		}
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+		proj := application.CurrentProject
+
+		// The provider is only asked for its capabilities.
+		providerConfig, providerName, err := checkLLMProvider(application)
+		if err != nil {
+			return err
+		}
+		providerConfig, providerName, err = draftModelConfig(application, proj, providerConfig, providerName)
+		if err != nil {
+			return err
+		}
+		provider, err := initLLMProvider(context.Background(), providerName, providerConfig)
+		if err != nil {
+			return i18n.Errorf("failed to initialize LLM provider: %w", err)
+		}
+		defer provider.Close()
+		modelName := providerConfig.DefaultModel
+		if modelName == "" {
+			modelName = providerName
 		}
 
-		return runTUI(application.CurrentProject)
+		if _, err := proj.SyncIndex(); err != nil {
+			i18n.Fprintf(os.Stderr, "Warning: search index not updated: %v\n", err)
+		}
+		dry, err := tui.AssembleDryRun(proj, provider, modelName, mode, search.NewFTSEngine(proj.DB), message)
+		if err != nil {
+			return err
+		}
+		printDryRun(dry, providerName, modelName, mode)
+		return nil
 	},
 }
 
+// printDryRun prints an assembled request section by section.
+func printDryRun(dry *tui.DryRun, providerName, modelName string, mode tui.ContextMode) {
+	b := dry.Budget
+	i18n.Printf("Model: %s (%s), context mode: %s\n", modelName, providerName, mode)
+	i18n.Printf("Budget: %d tokens (system %d, context %d, history %d, response %d); max output %d\n",
+		b.Total, b.SystemPrompt, b.Context, b.History, b.Response, dry.Request.MaxTokens)
+
+	total := 0
+	for _, n := range dry.Tokens {
+		total += n
+	}
+	i18n.Printf("Request: %d message(s), %d tokens; %d saved message(s) loaded\n", len(dry.Request.Messages), total, dry.SavedMessages)
+
+	i18n.Printf("\n=== System prompt ===\n%s\n", dry.SystemPrompt)
+
+	i18n.Printf("\n=== Retrieved chunks (%d) ===\n", len(dry.Retrieval))
+	if len(dry.Retrieval) == 0 {
+		i18n.Println("(none; only hybrid mode retrieves chunks for the message)")
+	}
+	for _, c := range dry.Retrieval {
+		location := c.SourcePath
+		if c.Section != "" {
+			location += " § " + c.Section
+		}
+		i18n.Printf("%s %s (%s, %d tokens)\n   %s\n", llm.CitationTag(c.ID), location, c.SourceType, c.Tokens, c.Explain())
+	}
+
+	// The system prompt is printed above.
+	i18n.Printf("\n=== Messages after the system prompt ===\n")
+	for i, msg := range dry.Request.Messages {
+		if i == 0 && msg.Role == llm.RoleSystem {
+			continue
+		}
+		i18n.Printf("\n--- %s (%d tokens) ---\n%s\n", msg.Role, dry.Tokens[i], msg.Content)
+	}
+
+	if len(dry.Request.Tools) > 0 {
+		names := make([]string, len(dry.Request.Tools))
+		for i, tool := range dry.Request.Tools {
+			names[i] = tool.Function.Name
+		}
+		i18n.Printf("\n=== Tools ===\n%s\n", strings.Join(names, ", "))
+	}
+}
+
 var reindexCmd = &cobra.Command{
-	Use:   "reindex [name]",
+	Use:   "reindex [name|path]",
 	Short: "Rebuild the search index for a project",
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		application, err := app.New()
+		application, err := newApp()
 		if err != nil {
-			return fmt.Errorf("failed to initialize app: %w", err)
+			return i18n.Errorf("failed to initialize app: %w", err)
 		}
 		defer application.Close()
 
@@ -767,45 +933,709 @@ var reindexCmd = &cobra.Command{
 			name = args[0]
 		} else {
 			// Try to detect project from current directory
-			return fmt.Errorf("please specify a project name")
+			return i18n.Errorf("please specify a project name")
 		}
 
 		if err := application.OpenProject(name); err != nil {
-			return fmt.Errorf("failed to open project: %w", err)
+			return i18n.Errorf("failed to open project: %w", err)
 		}
 
 		proj := application.CurrentProject
-		fmt.Printf("Reindexing project '%s'...\n", name)
 
-		// Initialize the search engine and indexer
-		ftsEngine := search.NewFTSEngine(proj.DB)
+		// Files saved with CRLF line endings, a byte order mark or a legacy
+		// encoding are indexed as UTF-8 either way; --normalize also
+		// rewrites them so diffs and other tools agree.
+		normalize, _ := cmd.Flags().GetBool("normalize")
+		var issues []project.EncodingIssue
+		if normalize {
+			issues, err = proj.NormalizeEncodings()
+		} else {
+			issues, err = proj.CheckEncodings()
+		}
+		if err != nil {
+			return i18n.Errorf("encoding check failed: %w", err)
+		}
+		if len(issues) > 0 {
+			if normalize {
+				i18n.Printf("Converted %d file(s) to UTF-8 with LF line endings:\n", len(issues))
+			} else {
+				i18n.Printf("%d file(s) are not UTF-8 with LF line endings (rewrite them with --normalize):\n", len(issues))
+			}
+			for _, issue := range issues {
+				fmt.Printf("  %s (%s)\n", filepath.ToSlash(issue.Path), issue.Format)
+			}
+		}
 
-		// Initialize token counter for chunking
-		counter, err := token.NewCounter("cl100k_base")
+		i18n.Printf("Reindexing project '%s'...\n", name)
+
+		count, err := proj.RebuildIndex()
 		if err != nil {
-			return fmt.Errorf("failed to initialize token counter: %w", err)
+			return i18n.Errorf("reindex failed: %w", err)
 		}
 
-		indexer := search.NewIndexer(
-			ftsEngine,
-			counter,
-			proj.Config.Context.ChunkSize,
-			proj.Config.Context.ChunkOverlap,
-		)
+		i18n.Printf("Reindex complete. Indexed %d chunks.\n", count)
+		return nil
+	},
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Inspect a project's search index",
+}
+
+var indexInspectCmd = &cobra.Command{
+	Use:   "inspect <name|path> [file]",
+	Short: "Show what the search index holds for a project or one file",
+	Long: `List every indexed file with its chunk count, tokens and the modification
+time it was indexed at, flagging files changed or removed since. Given a file,
+show each of its chunks with its tokens and section, to see why a passage is
+or is not retrieved.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+		proj := application.CurrentProject
+		engine := search.NewFTSEngine(proj.DB)
+
+		if len(args) == 2 {
+			return inspectIndexedFile(proj, engine, args[0], filepath.Clean(filepath.FromSlash(args[1])))
+		}
+
+		sources, err := engine.ListSources()
+		if err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			i18n.Printf("The index is empty. Run 'dreamteller reindex %s' to build it.\n", args[0])
+			return nil
+		}
+		changes, err := proj.IndexFormatChanges()
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			i18n.Printf("The index was built with other settings (%s); run 'dreamteller reindex %s' to rebuild it.\n\n",
+				strings.Join(changes, ", "), args[0])
+		}
+		chunks, tokens, stale := 0, 0, 0
+		for _, s := range sources {
+			note := ""
+			if info, err := proj.FS.GetFileInfo(s.SourcePath); err != nil {
+				note, stale = "  "+i18n.T("(removed since)"), stale+1
+			} else if info.ModTime.Unix() > s.MTime.Unix() {
+				note, stale = "  "+i18n.T("(changed since)"), stale+1
+			}
+			i18n.Printf("%-48s %-10s %3d chunk(s) %6d tokens  %s%s\n",
+				filepath.ToSlash(s.SourcePath), s.SourceType, s.Chunks, s.Tokens, s.MTime.Format("2006-01-02 15:04"), note)
+			chunks += s.Chunks
+			tokens += s.Tokens
+		}
+		i18n.Printf("\n%d file(s), %d chunk(s), %d tokens.\n", len(sources), chunks, tokens)
+		if stale > 0 {
+			i18n.Printf("%d file(s) changed since indexing; run 'dreamteller reindex %s'.\n", stale, args[0])
+		}
+		return nil
+	},
+}
+
+// inspectIndexedFile prints every chunk indexed for one file.
+func inspectIndexedFile(proj *project.Project, engine *search.FTSEngine, name, path string) error {
+	chunks, err := engine.GetChunksBySource(path)
+	if err != nil {
+		return err
+	}
+	info, statErr := proj.FS.GetFileInfo(path)
+	if len(chunks) == 0 {
+		if statErr != nil {
+			return i18n.Errorf("%s is not in the project", filepath.ToSlash(path))
+		}
+		i18n.Printf("%s is not indexed. Run 'dreamteller reindex %s' to index it.\n", filepath.ToSlash(path), name)
+		return nil
+	}
+
+	tokens := 0
+	for _, c := range chunks {
+		tokens += c.TokenCount
+	}
+	i18n.Printf("%s (%s): %d chunk(s), %d tokens, indexed as of %s\n",
+		filepath.ToSlash(path), chunks[0].SourceType, len(chunks), tokens, chunks[0].MTime.Format("2006-01-02 15:04"))
+	switch {
+	case statErr != nil:
+		i18n.Printf("The file was removed since; run 'dreamteller reindex %s'.\n", name)
+	case info.ModTime.Unix() > chunks[0].MTime.Unix():
+		i18n.Printf("The file changed since (%s); run 'dreamteller reindex %s'.\n", info.ModTime.Format("2006-01-02 15:04"), name)
+	}
+
+	for i, c := range chunks {
+		i18n.Printf("\n#%d %s %d tokens", i+1, llm.CitationTag(c.ID), c.TokenCount)
+		if c.Section != "" {
+			fmt.Printf(" § %s", c.Section)
+		}
+		fmt.Println()
+		for _, line := range strings.Split(strings.TrimSpace(c.Content), "\n") {
+			fmt.Println(strings.TrimRight("  "+line, " "))
+		}
+	}
+	return nil
+}
+
+var glossaryCmd = &cobra.Command{
+	Use:   "glossary <name|path>",
+	Short: "Check chapters for misspelled glossary terms",
+	Long:  "Compare words in every chapter against the invented terms in context/glossary and report likely misspellings.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+
+		issues, err := application.CurrentProject.CheckGlossary()
+		if err != nil {
+			return i18n.Errorf("glossary check failed: %w", err)
+		}
+
+		if len(issues) == 0 {
+			i18n.Println("No misspelled glossary terms found.")
+			return nil
+		}
+
+		for _, issue := range issues {
+			i18n.Printf("%s:%d: %q → did you mean %q?\n", issue.FilePath, issue.Line, issue.Word, issue.Term)
+		}
+		i18n.Printf("\n%d possible misspelling(s).\n", len(issues))
+		return nil
+	},
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report <name|path>",
+	Short: "Find unused, stale or empty story bible entries",
+	Long:  "List context files no chapter references, characters missing from the latest chapters, and settings with empty descriptions.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		recent, _ := cmd.Flags().GetInt("recent")
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+
+		report, err := application.CurrentProject.BibleReport(recent)
+		if err != nil {
+			return i18n.Errorf("report failed: %w", err)
+		}
+		fmt.Println(report.String())
+		return nil
+	},
+}
+
+var wordsCmd = &cobra.Command{
+	Use:   "words <name|path>",
+	Short: "Report crutch words and repeated phrases in chapters",
+	Long:  "Count crutch words (built-in plus writing.crutch_words) and the most repeated phrases in every chapter, with a per-chapter heatmap.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+
+		report, err := application.CurrentProject.WordFrequency()
+		if err != nil {
+			return i18n.Errorf("word frequency failed: %w", err)
+		}
+		fmt.Println(report.String())
+		return nil
+	},
+}
+
+var arcsCmd = &cobra.Command{
+	Use:   "arcs <name|path>",
+	Short: "Chart character arcs and long absences",
+	Long:  "Show each character's recorded state and goal per chapter (the Arc section of their file) as a chart, and warn about characters missing from many chapters in a row.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gap, _ := cmd.Flags().GetInt("gap")
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+
+		report, err := application.CurrentProject.ArcReport(gap)
+		if err != nil {
+			return i18n.Errorf("arc report failed: %w", err)
+		}
+		fmt.Println(report.String())
+		return nil
+	},
+}
+
+var subplotsCmd = &cobra.Command{
+	Use:   "subplots <name|path>",
+	Short: "Report subplot coverage and interleaving",
+	Long:  "Chart which chapters touch each subplot (tagged with <!-- subplot: id --> comments) and suggest where to weave back subplots left untouched for many chapters.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gap, _ := cmd.Flags().GetInt("gap")
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+
+		report, err := application.CurrentProject.SubplotReport(gap)
+		if err != nil {
+			return i18n.Errorf("subplot report failed: %w", err)
+		}
+		fmt.Println(report.String())
+		return nil
+	},
+}
+
+var pacingCmd = &cobra.Command{
+	Use:   "pacing <name|path>",
+	Short: "Chart scene lengths, reading time and action vs. reflection",
+	Long: `Split every chapter into scenes at scene breaks (***, ---, #) and chart their
+lengths, reading times and, once 'batch --op pacing' has classified them,
+the share of action against reflection, flagging sagging middle chapters.
+--svg also writes the chart to exports/pacing.svg.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svg, _ := cmd.Flags().GetBool("svg")
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+
+		report, err := application.CurrentProject.PacingReport()
+		if err != nil {
+			return i18n.Errorf("pacing report failed: %w", err)
+		}
+		fmt.Println(report.String())
+		if svg {
+			path, err := application.CurrentProject.ExportPacingSVG()
+			if err != nil {
+				return i18n.Errorf("pacing export failed: %w", err)
+			}
+			i18n.Printf("\nExported the chart to %s\n", filepath.Join(application.CurrentProject.Path(), path))
+		}
+		return nil
+	},
+}
+
+var feedbackCmd = &cobra.Command{
+	Use:   "feedback <name|path>",
+	Short: "Export annotations, lint findings and reviewer feedback",
+	Long: `Gather the reviewer persona annotations, the results of 'batch --op lint' and
+'batch --op rules' and the glossary misspellings into one document under exports/, as a Markdown
+checklist or CSV, for an offline editing pass.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		chapter, _ := cmd.Flags().GetInt("chapter")
+		format, _ := cmd.Flags().GetString("format")
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+
+		path, count, err := application.CurrentProject.ExportFeedback(chapter, format)
+		if err != nil {
+			return i18n.Errorf("feedback export failed: %w", err)
+		}
+		i18n.Printf("Exported %d item(s) to %s\n", count, filepath.Join(application.CurrentProject.Path(), path))
+		return nil
+	},
+}
+
+var continuityCmd = &cobra.Command{
+	Use:   "continuity <name|path>",
+	Short: "Snapshot character facts and the timeline, then report what a rewrite changed",
+	Long: `Compare the character facts, remembered facts and plot timeline against a
+continuity snapshot, and list the chapters that still refer to facts that
+changed since.
+
+Take a snapshot with --snapshot before a major rewrite. Without --snapshot,
+compare against the snapshot named by --name, or the latest one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		save, _ := cmd.Flags().GetBool("snapshot")
+		name, _ := cmd.Flags().GetString("name")
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+		proj := application.CurrentProject
+
+		if save {
+			snapshot, err := proj.SaveContinuitySnapshot(name)
+			if err != nil {
+				return i18n.Errorf("snapshot failed: %w", err)
+			}
+			i18n.Printf("Saved continuity snapshot '%s': %d fact(s), %d timeline event(s).\n", snapshot.Name, len(snapshot.Facts), len(snapshot.Timeline))
+			return nil
+		}
+
+		report, err := proj.CompareContinuity(name)
+		if err != nil {
+			return i18n.Errorf("continuity comparison failed: %w", err)
+		}
+		fmt.Println(report.String())
+		return nil
+	},
+}
+
+var seriesCmd = &cobra.Command{
+	Use:   "series <series>",
+	Short: "Group books into a series sharing a world bible",
+	Long: `Show the books of a series and check them for inconsistencies.
+
+With --join or --leave, add a project to the series or remove it. Books in a
+series share the characters, settings and glossary under
+<projects-dir>/.series/<series>/, which each book sees as series/. With
+--search, search every book's index at once.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		join, _ := cmd.Flags().GetString("join")
+		leave, _ := cmd.Flags().GetString("leave")
+		query, _ := cmd.Flags().GetString("search")
+		name := args[0]
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
 
-		// Perform full reindex
-		if err := indexer.FullReindexWithDB(proj.FS, proj.DB); err != nil {
-			return fmt.Errorf("reindex failed: %w", err)
+		switch {
+		case join != "":
+			if err := application.OpenProject(join); err != nil {
+				return i18n.Errorf("failed to open project: %w", err)
+			}
+			if err := application.CurrentProject.JoinSeries(name); err != nil {
+				return i18n.Errorf("failed to join series: %w", err)
+			}
+			i18n.Printf("'%s' joined series '%s'. Shared files live in %s.\n", join, name,
+				project.SeriesDir(application.ProjectManager.ProjectsDir(), name))
+			i18n.Printf("Run 'dreamteller reindex %s' to index them.\n", join)
+			return nil
+		case leave != "":
+			if err := application.OpenProject(leave); err != nil {
+				return i18n.Errorf("failed to open project: %w", err)
+			}
+			if application.CurrentProject.Config.Series != name {
+				return i18n.Errorf("'%s' is not in series '%s'", leave, name)
+			}
+			if err := application.CurrentProject.LeaveSeries(); err != nil {
+				return i18n.Errorf("failed to leave series: %w", err)
+			}
+			i18n.Printf("'%s' left series '%s'. Run 'dreamteller reindex %s' to drop the shared files from its index.\n", leave, name, leave)
+			return nil
 		}
 
-		// Get stats
-		count, err := ftsEngine.GetChunkCount()
+		series, err := application.ProjectManager.OpenSeries(name)
 		if err != nil {
-			fmt.Println("Reindex complete.")
+			return err
+		}
+
+		if query != "" {
+			results, err := series.Search(query, 20)
+			if err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				i18n.Println("No results.")
+				return nil
+			}
+			for _, r := range results {
+				fmt.Printf("[%s] %s\n  %s\n", r.Book, r.Location(), strings.Join(strings.Fields(r.Content), " "))
+			}
 			return nil
 		}
-
-		fmt.Printf("Reindex complete. Indexed %d chunks.\n", count)
+
+		i18n.Printf("Series '%s': %d book(s)\n", series.Name, len(series.Books))
+		for _, book := range series.Books {
+			fmt.Printf("  %s\n", filepath.Base(book))
+		}
+
+		issues, err := series.Check()
+		if err != nil {
+			return i18n.Errorf("series check failed: %w", err)
+		}
+		if len(issues) == 0 {
+			i18n.Println("\nNo inconsistencies found.")
+			return nil
+		}
+		fmt.Println()
+		for _, issue := range issues {
+			fmt.Printf("[%s] %s: %s\n", issue.Book, issue.Path, issue.Message)
+		}
+		i18n.Printf("\n%d issue(s).\n", len(issues))
+		return nil
+	},
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <name>",
+	Short: "Run an LLM operation over many chapters",
+	Long: `Summarize, lint, check against the rulebook, classify scenes for pacing or
+translate a range of chapters, writing one result per chapter under batch/<op>/
+in the project. Progress is checkpointed after every chapter, so an interrupted run resumes where it stopped when the same command
+is run again. Chapters edited since they were processed are done again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBatchCmd,
+}
+
+func runBatchCmd(cmd *cobra.Command, args []string) error {
+	opName, _ := cmd.Flags().GetString("op")
+	chapterSpec, _ := cmd.Flags().GetString("chapters")
+	language, _ := cmd.Flags().GetString("to")
+	rpm, _ := cmd.Flags().GetInt("rpm")
+	restart, _ := cmd.Flags().GetBool("restart")
+
+	op, err := batch.ParseOp(opName)
+	if err != nil {
+		return err
+	}
+	job := batch.Job{Op: op, Language: language}
+	if chapterSpec != "" {
+		if job.Chapters, err = batch.ParseChapterRange(chapterSpec); err != nil {
+			return err
+		}
+	}
+	if op == batch.OpTranslate && language == "" {
+		return i18n.Errorf("--op translate needs a target language (--to ja)")
+	}
+	return runBatchJob(args[0], job, rpm, restart)
+}
+
+var translateCmd = &cobra.Command{
+	Use:   "translate <name>",
+	Short: "Translate the manuscript into another language",
+	Long: `Translate chapters into translations/<language>/, one file per chapter, leaving
+the originals untouched. Names and invented terms are kept consistent through a
+translation memory at translations/<language>/memory.md, which you can edit.
+Like batch, an interrupted run resumes where it stopped.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		language, _ := cmd.Flags().GetString("to")
+		chapterSpec, _ := cmd.Flags().GetString("chapters")
+		rpm, _ := cmd.Flags().GetInt("rpm")
+		restart, _ := cmd.Flags().GetBool("restart")
+
+		job := batch.Job{Op: batch.OpTranslate, Language: language}
+		if chapterSpec != "" {
+			var err error
+			if job.Chapters, err = batch.ParseChapterRange(chapterSpec); err != nil {
+				return err
+			}
+		}
+		return runBatchJob(args[0], job, rpm, restart)
+	},
+}
+
+// runBatchJob runs a batch job on a project with the default provider,
+// printing progress. Ctrl+C stops after saving progress.
+func runBatchJob(projectName string, job batch.Job, rpm int, restart bool) error {
+	if offlineFlag {
+		return i18n.Errorf("batch requires an LLM provider: %w", errOffline)
+	}
+
+	application, err := newApp()
+	if err != nil {
+		return i18n.Errorf("failed to initialize app: %w", err)
+	}
+	defer application.Close()
+
+	if err := application.OpenProject(projectName); err != nil {
+		return i18n.Errorf("failed to open project: %w", err)
+	}
+
+	providerConfig, providerName, err := checkLLMProvider(application)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	redact, err := projectRedaction(application.CurrentProject)
+	if err != nil {
+		return err
+	}
+	provider, err := initLLMProvider(ctx, providerName, providerConfig)
+	if err != nil {
+		return i18n.Errorf("failed to initialize LLM provider: %w", err)
+	}
+	defer provider.Close()
+	provider = redact(providerName, providerConfig, meterProvider(application.CurrentProject, providerName, providerConfig, provider))
+
+	runner := &batch.Runner{
+		Project:  application.CurrentProject,
+		Provider: provider,
+		Progress: func(e batch.Event) {
+			switch e.Status {
+			case batch.StatusDone:
+				i18n.Printf("✓ Chapter %d (%s) → %s\n", e.Chapter, e.Title, e.Output)
+				for _, warning := range e.Warnings {
+					i18n.Printf("  ⚠ formatting: %s\n", warning)
+				}
+			case batch.StatusSkipped:
+				i18n.Printf("- Chapter %d (%s) already done\n", e.Chapter, e.Title)
+			case batch.StatusFailed:
+				i18n.Printf("✗ Chapter %d (%s): %v\n", e.Chapter, e.Title, e.Err)
+			}
+		},
+	}
+	if rpm > 0 {
+		runner.Interval = time.Minute / time.Duration(rpm)
+	}
+	if restart {
+		if err := runner.ResetCheckpoint(job); err != nil {
+			return err
+		}
+	}
+
+	i18n.Printf("Running %s with %s...\n", job.ID(), providerName)
+	if _, err := runner.Run(ctx, job); err != nil {
+		if errors.Is(err, context.Canceled) {
+			i18n.Println("\nInterrupted. Run the same command again to resume.")
+			return nil
+		}
+		i18n.Println("\nStopped. Run the same command again to resume.")
+		return err
+	}
+
+	i18n.Printf("\nDone. Results are in %s\n", job.OutputDir())
+	if job.Op == batch.OpTranslate {
+		i18n.Printf("Translation memory: %s\n", filepath.Join(job.OutputDir(), "memory.md"))
+	}
+	return nil
+}
+
+var draftCmd = &cobra.Command{
+	Use:   "draft <name>",
+	Short: "Draft a scene from a beat of the outline",
+	Long: `Plan a scene for a beat of the plot outline: its goal, conflict, disaster and
+sequel, written with the characters and places the beat mentions and the end of
+the manuscript so far. --beat 2 names the second plot file, --beat 2.3 the third
+section or list item in it. With --prose, a first draft of the scene follows
+the scaffold. The result is written to a new file under scenes/; chapters are
+never changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, _ := cmd.Flags().GetString("beat")
+		prose, _ := cmd.Flags().GetBool("prose")
+		if offlineFlag {
+			return i18n.Errorf("draft requires an LLM provider: %w", errOffline)
+		}
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+		proj := application.CurrentProject
+		beat, err := proj.FindOutlineBeat(ref)
+		if err != nil {
+			return err
+		}
+
+		providerConfig, providerName, err := checkLLMProvider(application)
+		if err != nil {
+			return err
+		}
+		providerConfig, providerName, err = draftModelConfig(application, proj, providerConfig, providerName)
+		if err != nil {
+			return err
+		}
+		redact, err := projectRedaction(proj)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		provider, err := initLLMProvider(ctx, providerName, providerConfig)
+		if err != nil {
+			return i18n.Errorf("failed to initialize LLM provider: %w", err)
+		}
+		defer provider.Close()
+
+		generator := &scaffold.Generator{Project: proj, Provider: redact(providerName, providerConfig, meterProvider(proj, providerName, providerConfig, provider))}
+		i18n.Printf("Planning beat %s (%s) with %s...\n", beat.Ref, beat.Title, providerName)
+		scene, err := generator.Scaffold(ctx, beat)
+		if err != nil {
+			return i18n.Errorf("scaffold failed: %w", err)
+		}
+		if prose {
+			i18n.Println("Drafting the scene...")
+			if err := generator.WriteProse(ctx, scene); err != nil {
+				return i18n.Errorf("draft failed: %w", err)
+			}
+		}
+
+		path, err := generator.Save(scene)
+		if err != nil {
+			return err
+		}
+		i18n.Printf("\nGoal: %s\nConflict: %s\nDisaster: %s\nSequel: %s\n", scene.Goal, scene.Conflict, scene.Disaster, scene.Sequel)
+		i18n.Printf("\nScene written to %s\n", filepath.ToSlash(path))
 		return nil
 	},
 }
@@ -820,13 +1650,90 @@ var exportCmd = &cobra.Command{
 		format := args[1]
 
 		switch format {
-		case "epub", "pdf", "txt":
+		case "txt":
+			lang, _ := cmd.Flags().GetString("lang")
+
+			application, err := newApp()
+			if err != nil {
+				return i18n.Errorf("failed to initialize app: %w", err)
+			}
+			defer application.Close()
+
+			if err := application.OpenProject(name); err != nil {
+				return i18n.Errorf("failed to open project: %w", err)
+			}
+			path, err := application.CurrentProject.ExportText(lang)
+			if err != nil {
+				return i18n.Errorf("export failed: %w", err)
+			}
+			i18n.Printf("Exported '%s' to %s\n", name, filepath.Join(application.CurrentProject.Path(), path))
+			return nil
+		case "epub", "pdf":
 			// TODO: Implement export
-			fmt.Printf("Exporting '%s' to %s format...\n", name, format)
-			return fmt.Errorf("export not yet implemented")
+			i18n.Printf("Exporting '%s' to %s format...\n", name, format)
+			return i18n.Errorf("export not yet implemented")
 		default:
-			return fmt.Errorf("unsupported format: %s (use epub, pdf, or txt)", format)
+			return i18n.Errorf("unsupported format: %s (use epub, pdf, or txt)", format)
+		}
+	},
+}
+
+var fixTypographyCmd = &cobra.Command{
+	Use:   "fix-typography <name|path>",
+	Short: "Replace straight quotes, double hyphens and dots with typographic punctuation",
+	Long: `Normalize the punctuation of the chapters: curly quotes, em dashes and
+ellipses, with the rules of the manuscript's language. French uses guillemets
+and non-breaking spaces, Korean drops stray spaces before punctuation, and
+Japanese uses corner brackets and full-width marks. Code, comments and links
+are left alone.
+
+The language defaults to the project's language setting.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lang, _ := cmd.Flags().GetString("lang")
+		spec, _ := cmd.Flags().GetString("chapters")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		var numbers []int
+		if spec != "" {
+			var err error
+			if numbers, err = batch.ParseChapterRange(spec); err != nil {
+				return err
+			}
+		}
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
 		}
+		defer application.Close()
+
+		if err := application.OpenProject(args[0]); err != nil {
+			return i18n.Errorf("failed to open project: %w", err)
+		}
+		proj := application.CurrentProject
+		if lang == "" {
+			lang = proj.TypographyLanguage()
+		}
+
+		changed, err := proj.FixTypography(numbers, lang, dryRun)
+		if err != nil {
+			return i18n.Errorf("typography fix failed: %w", err)
+		}
+		if len(changed) == 0 {
+			i18n.Println("Typography is already clean.")
+			return nil
+		}
+		format := "Fixed typography (%s) in %d chapter(s): %s\n"
+		if dryRun {
+			format = "Would fix typography (%s) in %d chapter(s): %s\n"
+		}
+		list := make([]string, len(changed))
+		for i, n := range changed {
+			list[i] = strconv.Itoa(n)
+		}
+		i18n.Printf(format, lang, len(changed), strings.Join(list, ", "))
+		return nil
 	},
 }
 
@@ -834,9 +1741,43 @@ var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Edit global configuration",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		setProjectsDir, _ := cmd.Flags().GetString("set-projects-dir")
+		setLanguage, _ := cmd.Flags().GetString("set-language")
+
+		application, err := newApp()
+		if err != nil {
+			return i18n.Errorf("failed to initialize app: %w", err)
+		}
+
+		if setProjectsDir != "" {
+			if err := application.Config.SetProjectsDir(setProjectsDir); err != nil {
+				return i18n.Errorf("failed to update projects directory: %w", err)
+			}
+			projectsDir, _ := application.Config.GetProjectsDir()
+			i18n.Printf("Projects directory set to %s\n", projectsDir)
+			return nil
+		}
+
+		if setLanguage != "" {
+			lang, err := i18n.ParseLanguage(setLanguage)
+			if err != nil {
+				return err
+			}
+			if err := application.Config.SetLanguage(string(lang)); err != nil {
+				return i18n.Errorf("failed to update language: %w", err)
+			}
+			i18n.SetLanguage(lang)
+			i18n.Printf("Language set to %s\n", lang)
+			return nil
+		}
+
 		// TODO: Open config in editor or show interactive config
-		fmt.Println("Configuration editor not yet implemented.")
-		fmt.Println("Edit ~/.config/dreamteller/config.yaml manually.")
+		i18n.Printf("Config file: %s\n", application.Config.ConfigPath())
+		i18n.Printf("Projects directory: %s\n", application.ProjectManager.ProjectsDir())
+		i18n.Printf("Language: %s\n", i18n.Current())
+		fmt.Println()
+		i18n.Println("Configuration editor not yet implemented.")
+		i18n.Printf("Edit %s manually.\n", application.Config.ConfigPath())
 		return nil
 	},
 }
@@ -849,50 +1790,70 @@ var deleteCmd = &cobra.Command{
 		name := args[0]
 		force, _ := cmd.Flags().GetBool("force")
 
-		application, err := app.New()
+		application, err := newApp()
 		if err != nil {
-			return fmt.Errorf("failed to initialize app: %w", err)
+			return i18n.Errorf("failed to initialize app: %w", err)
 		}
 
 		if !application.ProjectManager.Exists(name) {
-			return fmt.Errorf("project '%s' not found", name)
+			return i18n.Errorf("project '%s' not found", name)
 		}
 
 		if !force {
 			var confirm string
-			fmt.Printf("This will permanently delete project '%s' and all its files.\n", name)
-			fmt.Printf("Type the project name to confirm: ")
+			i18n.Printf("This will permanently delete project '%s' and all its files.\n", name)
+			i18n.Printf("Type the project name to confirm: ")
 			fmt.Scanln(&confirm)
 
 			if confirm != name {
-				fmt.Println("Deletion cancelled.")
+				i18n.Println("Deletion cancelled.")
 				return nil
 			}
 		}
 
 		if err := application.ProjectManager.Delete(name); err != nil {
-			return fmt.Errorf("failed to delete project: %w", err)
+			return i18n.Errorf("failed to delete project: %w", err)
 		}
 
-		fmt.Printf("Project '%s' deleted.\n", name)
+		i18n.Printf("Project '%s' deleted.\n", name)
 		return nil
 	},
 }
 
 var authCmd = &cobra.Command{
-	Use:   "auth",
+	Use:   "auth [provider]",
 	Short: "Configure LLM provider authentication",
-	RunE:  runAuthCmd,
+	Long: `Configure LLM provider authentication.
+
+A provider given as an argument is the same as --provider.
+
+With --test, send a tiny request to the given provider (or the default one)
+and report latency, model availability, and tool-call support.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAuthCmd,
 }
 
 func runAuthCmd(cmd *cobra.Command, args []string) error {
 	listFlag, _ := cmd.Flags().GetBool("list")
 	removeFlag, _ := cmd.Flags().GetString("remove")
 	providerFlag, _ := cmd.Flags().GetString("provider")
+	presetFlag, _ := cmd.Flags().GetString("preset")
+	testFlag, _ := cmd.Flags().GetBool("test")
+
+	if len(args) > 0 {
+		if providerFlag != "" && providerFlag != args[0] {
+			return i18n.Errorf("conflicting providers: %s and --provider %s", args[0], providerFlag)
+		}
+		providerFlag = args[0]
+	}
 
-	application, err := app.New()
+	application, err := newApp()
 	if err != nil {
-		return fmt.Errorf("failed to initialize app: %w", err)
+		return i18n.Errorf("failed to initialize app: %w", err)
+	}
+
+	if testFlag {
+		return testProvider(application, providerFlag)
 	}
 
 	if listFlag {
@@ -903,8 +1864,20 @@ func runAuthCmd(cmd *cobra.Command, args []string) error {
 		return removeProvider(application, removeFlag)
 	}
 
+	if presetFlag != "" {
+		if providerFlag == "" {
+			providerFlag = "local"
+		}
+		if providerFlag != "local" {
+			return i18n.Errorf("--preset only applies to the local provider")
+		}
+		if _, ok := findLocalPreset(presetFlag); !ok {
+			return i18n.Errorf("unknown preset: %s (available: %s)", presetFlag, strings.Join(localPresetNames(), ", "))
+		}
+	}
+
 	if providerFlag != "" {
-		return configureProvider(application, providerFlag)
+		return configureProvider(application, providerFlag, presetFlag)
 	}
 
 	return interactiveAuth(application)
@@ -913,10 +1886,10 @@ func runAuthCmd(cmd *cobra.Command, args []string) error {
 func listProviders(application *app.App) error {
 	config, err := application.Config.LoadGlobalConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf("failed to load config: %w", err)
 	}
 
-	fmt.Println("Configured providers:")
+	i18n.Println("Configured providers:")
 	fmt.Println()
 
 	providers := []struct {
@@ -939,28 +1912,31 @@ func listProviders(application *app.App) error {
 		isDefault := config.Defaults.Provider == p.name
 		defaultMark := ""
 		if isDefault {
-			defaultMark = " (default)"
+			defaultMark = " " + i18n.T("(default)")
 		}
 
 		fmt.Printf("  %s%s\n", p.label, defaultMark)
 
 		if providerConfig.APIKey != "" {
 			masked := maskAPIKey(providerConfig.APIKey)
-			fmt.Printf("    API Key: %s\n", masked)
+			i18n.Printf("    API Key: %s\n", masked)
 		}
 		if providerConfig.DefaultModel != "" {
-			fmt.Printf("    Model: %s\n", providerConfig.DefaultModel)
+			i18n.Printf("    Model: %s\n", providerConfig.DefaultModel)
 		}
 		if providerConfig.BaseURL != "" {
-			fmt.Printf("    Base URL: %s\n", providerConfig.BaseURL)
+			i18n.Printf("    Base URL: %s\n", providerConfig.BaseURL)
+		}
+		if preset, ok := findLocalPreset(providerConfig.Preset); ok {
+			i18n.Printf("    Preset: %s\n", preset.Label)
 		}
 		fmt.Println()
 	}
 
 	if !hasAny {
-		fmt.Println("  No providers configured.")
+		i18n.Println("  No providers configured.")
 		fmt.Println()
-		fmt.Println("Run 'dreamteller auth' to configure a provider.")
+		i18n.Println("Run 'dreamteller auth' to configure a provider.")
 	}
 
 	return nil
@@ -976,11 +1952,11 @@ func maskAPIKey(key string) string {
 func removeProvider(application *app.App, providerName string) error {
 	config, err := application.Config.LoadGlobalConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf("failed to load config: %w", err)
 	}
 
 	if _, exists := config.Providers[providerName]; !exists {
-		return fmt.Errorf("provider '%s' is not configured", providerName)
+		return i18n.Errorf("provider '%s' is not configured", providerName)
 	}
 
 	delete(config.Providers, providerName)
@@ -994,19 +1970,132 @@ func removeProvider(application *app.App, providerName string) error {
 	}
 
 	if err := application.Config.SaveGlobalConfig(config); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+		return i18n.Errorf("failed to save config: %w", err)
+	}
+
+	i18n.Printf("Provider '%s' removed.\n", providerName)
+	return nil
+}
+
+// healthCheckTimeout bounds each request made by `auth --test`.
+const healthCheckTimeout = 30 * time.Second
+
+// testProvider performs a tiny round-trip against a configured provider and
+// reports latency, model availability, and tool-call support.
+func testProvider(application *app.App, providerName string) error {
+	if offlineFlag {
+		return i18n.Errorf("cannot test provider: %w", errOffline)
+	}
+
+	config, err := application.Config.LoadGlobalConfig()
+	if err != nil {
+		return i18n.Errorf("failed to load config: %w", err)
+	}
+	if providerName == "" {
+		providerName = config.Defaults.Provider
+		if providerName == "" {
+			providerName = "openai"
+		}
+	}
+
+	providerConfig, err := application.Config.GetProviderConfig(providerName)
+	if err != nil {
+		return i18n.Errorf("%w. Run 'dreamteller auth --provider %s' first", err, providerName)
+	}
+
+	provider, err := initLLMProvider(context.Background(), providerName, providerConfig)
+	if err != nil {
+		return i18n.Errorf("failed to initialize LLM provider: %w", err)
+	}
+	defer provider.Close()
+
+	caps := provider.Capabilities()
+	model := providerConfig.DefaultModel
+	if len(caps.Models) > 0 && caps.Models[0] != "" {
+		model = caps.Models[0]
+	}
+
+	i18n.Printf("Testing %s", providerName)
+	if model != "" {
+		fmt.Printf(" (%s)", model)
+	}
+	if providerConfig.BaseURL != "" {
+		i18n.Printf(" at %s", providerConfig.BaseURL)
+	}
+	fmt.Println()
+	fmt.Println()
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := provider.Chat(ctx, llm.ChatRequest{
+		Messages:  []llm.ChatMessage{{Role: llm.RoleUser, Content: "Reply with the single word OK."}},
+		MaxTokens: 16,
+	})
+	latency := time.Since(start)
+	if err != nil {
+		switch {
+		case errors.Is(err, llm.ErrModelNotFound):
+			i18n.Printf("  ✗ Model: %s is not available\n", model)
+		case errors.Is(err, llm.ErrInvalidAPIKey):
+			i18n.Println("  ✗ Authentication: API key rejected")
+		default:
+			i18n.Printf("  ✗ Chat: %v\n", err)
+		}
+		return i18n.Errorf("%s health check failed", providerName)
+	}
+
+	i18n.Printf("  ✓ Chat: responded in %s\n", latency.Round(time.Millisecond))
+	if resp.Model != "" {
+		model = resp.Model
+	}
+	i18n.Printf("  ✓ Model: %s is available\n", model)
+
+	if !caps.SupportsTools {
+		i18n.Println("  - Tools: not supported natively (suggestions use the text fallback)")
+		return nil
+	}
+
+	toolCtx, toolCancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer toolCancel()
+
+	toolResp, err := provider.Chat(toolCtx, llm.ChatRequest{
+		Messages: []llm.ChatMessage{{Role: llm.RoleUser, Content: "Ask me which point of view the story uses."}},
+		Tools: []llm.ToolDefinition{
+			toolByName(llm.ToolAskUserClarification),
+		},
+		ToolChoice: llm.ToolAskUserClarification,
+		MaxTokens:  128,
+	})
+	switch {
+	case err != nil:
+		i18n.Printf("  ⚠ Tools: request failed: %v\n", err)
+	case len(toolResp.Message.ToolCalls) == 0:
+		i18n.Println("  ⚠ Tools: advertised but the model did not call the tool")
+	default:
+		i18n.Println("  ✓ Tools: tool calls work")
 	}
 
-	fmt.Printf("Provider '%s' removed.\n", providerName)
 	return nil
 }
 
-func configureProvider(application *app.App, providerName string) error {
+// toolByName returns the predefined tool with the given name.
+func toolByName(name string) llm.ToolDefinition {
+	for _, tool := range llm.PredefinedTools() {
+		if tool.Function.Name == name {
+			return tool
+		}
+	}
+	return llm.ToolDefinition{}
+}
+
+func configureProvider(application *app.App, providerName, preset string) error {
 	switch providerName {
 	case "openai", "gemini", "local":
-		return setupProvider(application, providerName)
+		return setupProvider(application, providerName, preset)
 	default:
-		return fmt.Errorf("unknown provider: %s (supported: openai, gemini, local)", providerName)
+		return i18n.Errorf("unknown provider: %s (supported: openai, gemini, local)", providerName)
 	}
 }
 
@@ -1016,27 +2105,29 @@ func interactiveAuth(application *app.App) error {
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewSelect[string]().
-				Title("Select provider to configure").
+				Title(i18n.T("Select provider to configure")).
 				Options(
 					huh.NewOption("OpenAI", "openai"),
 					huh.NewOption("Google Gemini", "gemini"),
-					huh.NewOption("Local (Ollama/LM Studio)", "local"),
+					huh.NewOption(i18n.T("Local (Ollama/LM Studio)"), "local"),
 				).
 				Value(&providerName),
 		),
 	)
 
 	if err := form.Run(); err != nil {
-		return fmt.Errorf("provider selection failed: %w", err)
+		return i18n.Errorf("provider selection failed: %w", err)
 	}
 
-	return setupProvider(application, providerName)
+	return setupProvider(application, providerName, "")
 }
 
-func setupProvider(application *app.App, providerName string) error {
+// setupProvider runs the configuration flow for a provider. preset names a
+// local server preset and is ignored for other providers.
+func setupProvider(application *app.App, providerName, preset string) error {
 	config, err := application.Config.LoadGlobalConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf("failed to load config: %w", err)
 	}
 
 	if config.Providers == nil {
@@ -1058,7 +2149,7 @@ func setupProvider(application *app.App, providerName string) error {
 			return err
 		}
 	case "local":
-		if err := setupLocal(providerConfig); err != nil {
+		if err := setupLocal(providerConfig, preset); err != nil {
 			return err
 		}
 	}
@@ -1069,13 +2160,13 @@ func setupProvider(application *app.App, providerName string) error {
 	defaultForm := huh.NewForm(
 		huh.NewGroup(
 			huh.NewConfirm().
-				Title("Set as default provider?").
+				Title(i18n.T("Set as default provider?")).
 				Value(&setDefault),
 		),
 	)
 
 	if err := defaultForm.Run(); err != nil {
-		return fmt.Errorf("default selection failed: %w", err)
+		return i18n.Errorf("default selection failed: %w", err)
 	}
 
 	if setDefault {
@@ -1083,10 +2174,10 @@ func setupProvider(application *app.App, providerName string) error {
 	}
 
 	if err := application.Config.SaveGlobalConfig(config); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+		return i18n.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("\n✓ %s configured successfully\n", providerName)
+	i18n.Printf("\n✓ %s configured successfully\n", providerName)
 	return nil
 }
 
@@ -1095,7 +2186,7 @@ func setupOpenAI(config *types.ProviderConfig) error {
 
 	currentKey := ""
 	if config.APIKey != "" {
-		currentKey = " (current: " + maskAPIKey(config.APIKey) + ")"
+		currentKey = " " + i18n.Sprintf("(current: %s)", maskAPIKey(config.APIKey))
 	}
 
 	form := huh.NewForm(
@@ -1105,9 +2196,9 @@ func setupOpenAI(config *types.ProviderConfig) error {
 				Placeholder("sk-...").
 				Value(&apiKey),
 			huh.NewSelect[string]().
-				Title("Default model").
+				Title(i18n.T("Default model")).
 				Options(
-					huh.NewOption("GPT-4o (recommended)", "gpt-4o"),
+					huh.NewOption("GPT-4o "+i18n.T("(recommended)"), "gpt-4o"),
 					huh.NewOption("GPT-4o Mini", "gpt-4o-mini"),
 					huh.NewOption("GPT-4 Turbo", "gpt-4-turbo"),
 					huh.NewOption("GPT-4", "gpt-4"),
@@ -1118,7 +2209,7 @@ func setupOpenAI(config *types.ProviderConfig) error {
 	)
 
 	if err := form.Run(); err != nil {
-		return fmt.Errorf("OpenAI setup failed: %w", err)
+		return i18n.Errorf("OpenAI setup failed: %w", err)
 	}
 
 	if apiKey != "" {
@@ -1136,19 +2227,19 @@ func setupGemini(config *types.ProviderConfig) error {
 
 	currentKey := ""
 	if config.APIKey != "" {
-		currentKey = " (current: " + maskAPIKey(config.APIKey) + ")"
+		currentKey = " " + i18n.Sprintf("(current: %s)", maskAPIKey(config.APIKey))
 	}
 
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
 				Title("Gemini API Key"+currentKey).
-				Placeholder("Get from ai.google.dev").
+				Placeholder(i18n.T("Get from ai.google.dev")).
 				Value(&apiKey),
 			huh.NewSelect[string]().
-				Title("Default model").
+				Title(i18n.T("Default model")).
 				Options(
-					huh.NewOption("Gemini 2.5 Flash (recommended)", "gemini-2.5-flash"),
+					huh.NewOption("Gemini 2.5 Flash "+i18n.T("(recommended)"), "gemini-2.5-flash"),
 					huh.NewOption("Gemini 2.5 Pro", "gemini-2.5-pro"),
 					huh.NewOption("Gemini 2.0 Flash", "gemini-2.0-flash"),
 				).
@@ -1157,7 +2248,7 @@ func setupGemini(config *types.ProviderConfig) error {
 	)
 
 	if err := form.Run(); err != nil {
-		return fmt.Errorf("Gemini setup failed: %w", err)
+		return i18n.Errorf("Gemini setup failed: %w", err)
 	}
 
 	if apiKey != "" {
@@ -1170,9 +2261,86 @@ func setupGemini(config *types.ProviderConfig) error {
 	return nil
 }
 
-func setupLocal(config *types.ProviderConfig) error {
-	var baseURL string
-	var protocol string
+// localPreset describes a well-known local inference server.
+type localPreset struct {
+	Name     string
+	Label    string
+	BaseURL  string
+	Protocol string
+	// ModelsPath lists installed models; parseModels decodes its response.
+	ModelsPath  string
+	parseModels func([]byte) ([]modelInfo, error)
+	// PullHint tells the user how to make a model available.
+	PullHint string
+}
+
+// localPresets are the servers offered by `auth --provider local`.
+var localPresets = []localPreset{
+	{"ollama", "Ollama", "http://localhost:11434", "ollama", "/api/tags", parseOllamaModels, "ollama pull llama3.2"},
+	{"lmstudio", "LM Studio", "http://localhost:1234", "openai", "/v1/models", parseOpenAIModels, "load a model in LM Studio's Developer tab"},
+	{"llamacpp", "llama.cpp server", "http://localhost:8080", "openai", "/v1/models", parseOpenAIModels, "llama-server -m <model.gguf>"},
+	{"vllm", "vLLM", "http://localhost:8000", "openai", "/v1/models", parseOpenAIModels, "vllm serve <model>"},
+	{"textgen", "text-generation-webui", "http://localhost:5000", "openai", "/v1/internal/model/list", parseTextGenModels, "load a model in the Model tab"},
+}
+
+// findLocalPreset returns the preset with the given name.
+func findLocalPreset(name string) (localPreset, bool) {
+	for _, p := range localPresets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return localPreset{}, false
+}
+
+// localPresetNames lists the names accepted by --preset.
+func localPresetNames() []string {
+	names := make([]string, len(localPresets))
+	for i, p := range localPresets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// customPreset is the preset option for servers without a preset.
+const customPreset = "custom"
+
+func setupLocal(config *types.ProviderConfig, presetName string) error {
+	if presetName == "" {
+		presetName = config.Preset
+		if presetName == "" {
+			presetName = customPreset
+		}
+
+		options := make([]huh.Option[string], 0, len(localPresets)+1)
+		for _, p := range localPresets {
+			options = append(options, huh.NewOption(fmt.Sprintf("%s (%s)", p.Label, p.BaseURL), p.Name))
+		}
+		options = append(options, huh.NewOption(i18n.T("Custom server"), customPreset))
+
+		presetForm := huh.NewForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title(i18n.T("Server")).
+					Options(options...).
+					Value(&presetName),
+			),
+		)
+		if err := presetForm.Run(); err != nil {
+			return i18n.Errorf("Local setup failed: %w", err)
+		}
+	}
+
+	preset, hasPreset := findLocalPreset(presetName)
+	if hasPreset {
+		if config.Preset != preset.Name {
+			config.BaseURL = preset.BaseURL
+		}
+		config.Preset = preset.Name
+		config.Protocol = preset.Protocol
+	} else {
+		config.Preset = ""
+	}
 
 	if config.BaseURL == "" {
 		config.BaseURL = "http://localhost:11434"
@@ -1181,28 +2349,29 @@ func setupLocal(config *types.ProviderConfig) error {
 		config.Protocol = "openai"
 	}
 
-	protocols := []huh.Option[string]{
-		huh.NewOption("OpenAI Compatible", "openai"),
-		huh.NewOption("Anthropic Compatible", "anthropic"),
-		huh.NewOption("Gemini Compatible", "gemini"),
-		huh.NewOption("Ollama", "ollama"),
-	}
+	baseURL := config.BaseURL
+	protocol := config.Protocol
 
-	setupForm := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Protocol").
-				Options(protocols...).
-				Value(&protocol),
-			huh.NewInput().
-				Title("Base URL").
-				Placeholder("http://localhost:11434").
-				Value(&baseURL),
-		),
-	)
+	var fields []huh.Field
+	if !hasPreset {
+		protocols := []huh.Option[string]{
+			huh.NewOption(i18n.T("OpenAI Compatible"), "openai"),
+			huh.NewOption(i18n.T("Anthropic Compatible"), "anthropic"),
+			huh.NewOption(i18n.T("Gemini Compatible"), "gemini"),
+			huh.NewOption("Ollama", "ollama"),
+		}
+		fields = append(fields, huh.NewSelect[string]().
+			Title(i18n.T("Protocol")).
+			Options(protocols...).
+			Value(&protocol))
+	}
+	fields = append(fields, huh.NewInput().
+		Title(i18n.T("Base URL")).
+		Placeholder(config.BaseURL).
+		Value(&baseURL))
 
-	if err := setupForm.Run(); err != nil {
-		return fmt.Errorf("Local setup failed: %w", err)
+	if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return i18n.Errorf("Local setup failed: %w", err)
 	}
 
 	if protocol != "" {
@@ -1212,20 +2381,26 @@ func setupLocal(config *types.ProviderConfig) error {
 		config.BaseURL = baseURL
 	}
 
-	models, err := fetchLocalModels(config.BaseURL, config.Protocol)
+	var models []modelInfo
+	var err error
+	if hasPreset {
+		models, err = fetchModelList(config.BaseURL, preset.ModelsPath, preset.parseModels)
+	} else {
+		models, err = fetchLocalModels(config.BaseURL, config.Protocol)
+	}
 	if err != nil {
-		fmt.Printf("\n⚠ Could not fetch models from %s: %v\n", config.BaseURL, err)
-		fmt.Println("Please enter model name manually.")
+		i18n.Printf("\n⚠ Could not fetch models from %s: %v\n", config.BaseURL, err)
+		i18n.Println("Please enter model name manually.")
 
 		var model string
 		manualForm := huh.NewForm(
 			huh.NewGroup(
 				huh.NewInput().
-					Title("Model name").
-					Placeholder("llama3, mistral, etc.").
+					Title(i18n.T("Model name")).
+					Placeholder(i18n.T("llama3, mistral, etc.")).
 					Validate(func(s string) error {
 						if strings.TrimSpace(s) == "" {
-							return fmt.Errorf("model name is required")
+							return i18n.Errorf("model name is required")
 						}
 						return nil
 					}).
@@ -1233,16 +2408,20 @@ func setupLocal(config *types.ProviderConfig) error {
 			),
 		)
 		if err := manualForm.Run(); err != nil {
-			return fmt.Errorf("model input failed: %w", err)
+			return i18n.Errorf("model input failed: %w", err)
 		}
 		config.DefaultModel = model
 		return nil
 	}
 
 	if len(models) == 0 {
-		fmt.Println("\n⚠ No models found. Please pull a model first:")
-		fmt.Println("  ollama pull llama3.2")
-		return fmt.Errorf("no models available")
+		hint := "ollama pull llama3.2"
+		if hasPreset {
+			hint = preset.PullHint
+		}
+		i18n.Println("\n⚠ No models found. Please make a model available first:")
+		fmt.Println("  " + hint)
+		return i18n.Errorf("no models available")
 	}
 
 	var selectedModel string
@@ -1254,29 +2433,63 @@ func setupLocal(config *types.ProviderConfig) error {
 	modelForm := huh.NewForm(
 		huh.NewGroup(
 			huh.NewSelect[string]().
-				Title("Select model").
+				Title(i18n.T("Select model")).
 				Options(options...).
 				Value(&selectedModel),
 		),
 	)
 
 	if err := modelForm.Run(); err != nil {
-		return fmt.Errorf("model selection failed: %w", err)
+		return i18n.Errorf("model selection failed: %w", err)
 	}
 
 	config.DefaultModel = selectedModel
 	return nil
 }
 
+// ensureOllamaModel pulls the configured model when the Ollama server does
+// not have it, printing download progress.
+func ensureOllamaModel(ctx context.Context, adapter *adapters.OllamaAdapter) error {
+	ok, err := adapter.HasModel(ctx)
+	if err != nil {
+		// Leave connection problems to the first request in the TUI.
+		return nil
+	}
+	if ok {
+		return nil
+	}
+
+	i18n.Printf("Model %s not found on %s, pulling...\n", adapter.ModelName(), adapter.BaseURL())
+	lastStatus := ""
+	err = adapter.Pull(ctx, func(p adapters.OllamaPullProgress) {
+		if pct := p.Percent(); pct >= 0 {
+			fmt.Printf("\r  %s: %3d%%", p.Status, pct)
+			lastStatus = p.Status
+			return
+		}
+		if p.Status != lastStatus {
+			if lastStatus != "" {
+				fmt.Println()
+			}
+			fmt.Printf("  %s", p.Status)
+			lastStatus = p.Status
+		}
+	})
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+
+	i18n.Printf("✓ Pulled %s\n", adapter.ModelName())
+	return nil
+}
+
 type modelInfo struct {
 	Name    string
 	Display string
 }
 
 func fetchLocalModels(baseURL, protocol string) ([]modelInfo, error) {
-	baseURL = strings.TrimSuffix(baseURL, "/")
-	client := &http.Client{Timeout: 5 * time.Second}
-
 	endpointMap := map[string]struct {
 		path  string
 		parse func([]byte) ([]modelInfo, error)
@@ -1289,17 +2502,28 @@ func fetchLocalModels(baseURL, protocol string) ([]modelInfo, error) {
 
 	ep, ok := endpointMap[protocol]
 	if !ok {
-		return nil, fmt.Errorf("unknown protocol: %s", protocol)
+		return nil, i18n.Errorf("unknown protocol: %s", protocol)
+	}
+
+	return fetchModelList(baseURL, ep.path, ep.parse)
+}
+
+// fetchModelList GETs a model-list endpoint and decodes it with parse.
+func fetchModelList(baseURL, path string, parse func([]byte) ([]modelInfo, error)) ([]modelInfo, error) {
+	if offlineFlag {
+		return nil, errOffline
 	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	client := &http.Client{Timeout: 5 * time.Second}
 
-	resp, err := client.Get(baseURL + ep.path)
+	resp, err := client.Get(baseURL + path)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s returned %d", ep.path, resp.StatusCode)
+		return nil, i18n.Errorf("%s returned %d", path, resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -1307,7 +2531,7 @@ func fetchLocalModels(baseURL, protocol string) ([]modelInfo, error) {
 		return nil, err
 	}
 
-	return ep.parse(body)
+	return parse(body)
 }
 
 func parseOllamaModels(body []byte) ([]modelInfo, error) {
@@ -1340,6 +2564,23 @@ func parseOllamaModels(body []byte) ([]modelInfo, error) {
 	return models, nil
 }
 
+// parseTextGenModels decodes text-generation-webui's internal model list.
+func parseTextGenModels(body []byte) ([]modelInfo, error) {
+	var result struct {
+		ModelNames []string `json:"model_names"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	models := make([]modelInfo, len(result.ModelNames))
+	for i, name := range result.ModelNames {
+		models[i] = modelInfo{Name: name, Display: name}
+	}
+	return models, nil
+}
+
 func parseOpenAIModels(body []byte) ([]modelInfo, error) {
 	var result struct {
 		Data []struct {
@@ -1383,44 +2624,199 @@ func parseGeminiModels(body []byte) ([]modelInfo, error) {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&projectsDirFlag, "projects-dir", "",
+		"Projects root directory (overrides $"+app.ProjectsDirEnv+" and config)")
+	rootCmd.PersistentFlags().BoolVar(&offlineFlag, "offline", false,
+		"Disable all network calls; AI features are unavailable")
+	rootCmd.PersistentFlags().StringVar(&langFlag, "lang", "",
+		"Language of help, messages and prompts: en, ko or ja (overrides the language config)")
+
+	configCmd.Flags().String("set-projects-dir", "", "Persist a new projects root directory")
+	configCmd.Flags().String("set-language", "", "Persist the language of help, messages and prompts: en, ko or ja")
+
 	newCmd.Flags().String("from-prompt", "", "Path to prompt file for one-shot setup (use '-' for stdin)")
 	newCmd.Flags().String("genre", "", "Genre for quick project creation without wizard")
+	newCmd.Flags().String("from-chat", "", "Path to a ChatGPT or Claude export (conversations.json) to seed the project from")
+	newCmd.Flags().String("conversation", "", "Import only conversations whose title contains this text")
+
+	openCmd.Flags().String("replay", "", "Serve canned responses from a replay log (JSON Lines) instead of a provider")
+	openCmd.Flags().String("record", "", "Append every LLM exchange to a replay log (JSON Lines)")
+	openCmd.Flags().Bool("explain-ranking", false, "Show under each reply why each retrieved context chunk was chosen (same as /explain)")
+
+	promptCmd.Flags().StringP("message", "m", "", "The chat message to assemble a request for")
+	promptCmd.Flags().String("mode", "hybrid", "Context mode: essential, hybrid or full")
+	promptCmd.Flags().Bool("dry-run", false, "Print the request instead of sending it (required)")
 
 	deleteCmd.Flags().BoolP("force", "f", false, "Delete without confirmation")
 
+	arcsCmd.Flags().Int("gap", project.DefaultArcGap, "Warn about characters missing from this many chapters in a row")
+
+	subplotsCmd.Flags().Int("gap", project.DefaultSubplotGap, "Warn about subplots untouched for this many chapters in a row")
+	pacingCmd.Flags().Bool("svg", false, "Also export the chart to exports/pacing.svg")
+
+	feedbackCmd.Flags().Int("chapter", 0, "Export one chapter's feedback (default: the whole book)")
+	feedbackCmd.Flags().String("format", "md", "Document format: md or csv")
+
+	reindexCmd.Flags().Bool("normalize", false, "Rewrite files with CRLF line endings, a byte order mark or a legacy encoding as UTF-8 with LF")
+
+	fixTypographyCmd.Flags().String("lang", "", "Typography rules: en, fr, ko or ja (default: the project's language)")
+	fixTypographyCmd.Flags().String("chapters", "", "Chapters to fix, e.g. 1-3,7 (default: all)")
+	fixTypographyCmd.Flags().Bool("dry-run", false, "List the chapters that would change without writing them")
+
+	exportCmd.Flags().String("lang", "", "Typography rules applied to the text (default: the project's language)")
+
+	continuityCmd.Flags().Bool("snapshot", false, "Save a continuity snapshot instead of comparing against one")
+	continuityCmd.Flags().String("name", "", "Snapshot name (default: the current time when saving, the latest when comparing)")
+
+	seriesCmd.Flags().String("join", "", "Add a project to the series")
+	seriesCmd.Flags().String("leave", "", "Remove a project from the series")
+	seriesCmd.Flags().String("search", "", "Search every book in the series")
+
+	reportCmd.Flags().Int("recent", project.DefaultRecentChapters, "Flag characters missing from this many of the latest chapters")
+
+	batchCmd.Flags().String("op", "", "Operation to run: summarize, lint, rules, pacing or translate")
+	batchCmd.Flags().String("chapters", "", "Chapters to process, e.g. 1-10 or 1-3,7 (default: all)")
+	batchCmd.Flags().String("to", "", "Target language for --op translate, e.g. ja")
+	batchCmd.Flags().Int("rpm", 20, "Maximum requests per minute (0 for no limit)")
+	batchCmd.Flags().Bool("restart", false, "Discard saved progress and process every chapter again")
+	_ = batchCmd.MarkFlagRequired("op")
+
+	translateCmd.Flags().String("to", "", "Target language, e.g. ja or en")
+	translateCmd.Flags().String("chapters", "", "Chapters to translate, e.g. 1-10 (default: all)")
+	translateCmd.Flags().Int("rpm", 20, "Maximum requests per minute (0 for no limit)")
+	translateCmd.Flags().Bool("restart", false, "Discard saved progress and translate every chapter again")
+	_ = translateCmd.MarkFlagRequired("to")
+
+	draftCmd.Flags().String("beat", "", "Outline beat to draft, e.g. 2 or 2.3")
+	draftCmd.Flags().Bool("prose", false, "Also write a first draft of the scene's prose")
+	_ = draftCmd.MarkFlagRequired("beat")
+
 	authCmd.Flags().BoolP("list", "l", false, "List configured providers")
 	authCmd.Flags().StringP("remove", "r", "", "Remove a provider configuration")
 	authCmd.Flags().StringP("provider", "p", "", "Configure a specific provider")
+	authCmd.Flags().Bool("test", false, "Send a test request to a provider and report latency and capabilities")
+	authCmd.Flags().String("preset", "", "Local server preset: "+strings.Join(localPresetNames(), ", "))
 
 	rootCmd.AddCommand(newCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(promptCmd)
 	rootCmd.AddCommand(reindexCmd)
+	indexCmd.AddCommand(indexInspectCmd)
+	rootCmd.AddCommand(indexCmd)
+	rootCmd.AddCommand(glossaryCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(wordsCmd)
+	rootCmd.AddCommand(arcsCmd)
+	rootCmd.AddCommand(subplotsCmd)
+	rootCmd.AddCommand(pacingCmd)
+	rootCmd.AddCommand(continuityCmd)
+	rootCmd.AddCommand(feedbackCmd)
+	rootCmd.AddCommand(seriesCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(translateCmd)
+	rootCmd.AddCommand(draftCmd)
+	rootCmd.AddCommand(fixTypographyCmd)
 	rootCmd.AddCommand(exportCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(authCmd)
 }
 
-func runTUI(proj *project.Project) error {
+// tuiOptions holds per-invocation settings for the TUI session.
+type tuiOptions struct {
+	// replayPath serves canned responses from a replay log instead of a real provider.
+	replayPath string
+	// recordPath appends every exchange with the real provider to a replay log.
+	recordPath string
+	// explainRanking shows why each retrieved context chunk was chosen.
+	explainRanking bool
+	// listProjects lets /projects switch to another project.
+	listProjects tui.ProjectLister
+}
+
+// runTUI runs a session on proj. It returns the path of the project chosen
+// with /projects, or "" when the session ended without switching; the
+// session's provider is closed by then, and proj is left to the caller.
+func runTUI(proj *project.Project, opts tuiOptions) (string, error) {
 	searchEngine := search.NewFTSEngine(proj.DB)
 
-	application, err := app.New()
+	// Catch the index up on files edited outside dreamteller. A failed sync
+	// leaves the old index, which still serves, so it only warns.
+	synced, err := proj.SyncIndex()
+	if err != nil {
+		i18n.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if opts.replayPath != "" {
+		provider, err := adapters.LoadReplayFile(opts.replayPath, adapters.WithReplayLoop())
+		if err != nil {
+			return "", err
+		}
+		model := tui.New(proj, provider, searchEngine, "replay", "replay", "")
+		model.SetIndexSync(synced)
+		model.SetExplainRanking(opts.explainRanking)
+		model.SetProjectSwitching(opts.listProjects)
+		err = runProgram(model)
+		return model.NextProject(), err
+	}
+
+	if offlineFlag {
+		model := tui.New(proj, nil, searchEngine, "offline", "", "")
+		model.SetIndexSync(synced)
+		model.SetExplainRanking(opts.explainRanking)
+		model.SetOffline(true)
+		model.SetProjectSwitching(opts.listProjects)
+		err := runProgram(model)
+		return model.NextProject(), err
+	}
+
+	application, err := newApp()
 	if err != nil {
-		return fmt.Errorf("failed to initialize app: %w", err)
+		return "", i18n.Errorf("failed to initialize app: %w", err)
 	}
 
 	providerConfig, providerName, err := checkLLMProvider(application)
 	if err != nil {
-		return err
+		return "", err
+	}
+	providerConfig, providerName, err = draftModelConfig(application, proj, providerConfig, providerName)
+	if err != nil {
+		return "", err
+	}
+
+	redact, err := projectRedaction(proj)
+	if err != nil {
+		return "", err
 	}
 
 	ctx := context.Background()
 	provider, err := initLLMProvider(ctx, providerName, providerConfig)
 	if err != nil {
-		return fmt.Errorf("failed to initialize LLM provider: %w", err)
+		return "", i18n.Errorf("failed to initialize LLM provider: %w", err)
 	}
-	defer provider.Close()
+	// /models may switch the session to another provider, closing this one;
+	// once the session runs, the provider it ends on is closed instead.
+	closeProvider := provider.Close
+	defer func() { _ = closeProvider() }()
+
+	if ollama, ok := provider.(*adapters.OllamaAdapter); ok {
+		if err := ensureOllamaModel(ctx, ollama); err != nil {
+			return "", err
+		}
+	}
+
+	var recordLog *os.File
+	if opts.recordPath != "" {
+		logFile, err := os.OpenFile(opts.recordPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return "", i18n.Errorf("failed to open replay log: %w", err)
+		}
+		defer logFile.Close()
+		provider = adapters.NewRecordingProvider(provider, logFile)
+		recordLog = logFile
+	}
+	provider = redact(providerName, providerConfig, meterProvider(proj, providerName, providerConfig, provider))
 
 	modelName := providerConfig.DefaultModel
 	if modelName == "" {
@@ -1433,11 +2829,128 @@ func runTUI(proj *project.Project) error {
 	}
 
 	model := tui.New(proj, provider, searchEngine, modelName, providerName, baseURL)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	closeProvider = model.CloseProvider
+	model.SetIndexSync(synced)
+	model.SetExplainRanking(opts.explainRanking)
+	model.SetStreamTimeout(providerConfig.Timeout)
+	model.SetProjectSwitching(opts.listProjects)
+	if globalConfig, err := application.Config.LoadGlobalConfig(); err == nil {
+		model.SetAutoContinue(globalConfig.Defaults.AutoContinue)
+		model.SetProviderSwitching(switchableProviders(globalConfig), func(name, modelName string) (llm.Provider, error) {
+			config, err := application.Config.GetProviderConfig(name)
+			if err != nil {
+				return nil, err
+			}
+			switched := *config
+			if modelName != "" {
+				switched.DefaultModel = modelName
+			}
+			p, err := initLLMProvider(ctx, name, &switched)
+			if err != nil {
+				return nil, err
+			}
+			if recordLog != nil {
+				p = adapters.NewRecordingProvider(p, recordLog)
+			}
+			return redact(name, &switched, meterProvider(proj, name, &switched, p)), nil
+		})
+	}
+	err = runProgram(model)
+	return model.NextProject(), err
+}
+
+// meterProvider wraps a provider to count the tokens it uses toward the
+// project's monthly quota.
+func meterProvider(proj *project.Project, providerName string, config *types.ProviderConfig, provider llm.Provider) llm.Provider {
+	if proj == nil {
+		return provider
+	}
+	modelName := config.DefaultModel
+	if modelName == "" {
+		modelName = providerName
+	}
+	return adapters.NewMeteringProvider(provider, modelName, func(model string, usage llm.TokenUsage) {
+		_ = proj.RecordTokenUsage(model, usage.PromptTokens, usage.CompletionTokens, time.Now())
+	})
+}
+
+// projectRedaction returns a function that wraps providers to apply the
+// project's redaction rules. The rules cover local providers only when
+// redaction.local is set. Every wrapped provider shares one Redactor, so a
+// string keeps its alias when the session switches models.
+func projectRedaction(proj *project.Project) (func(providerName string, providerConfig *types.ProviderConfig, provider llm.Provider) llm.Provider, error) {
+	none := func(_ string, _ *types.ProviderConfig, provider llm.Provider) llm.Provider { return provider }
+	if proj == nil || proj.Config == nil {
+		return none, nil
+	}
+	config := proj.Config.Redaction
+	redactor, err := llm.NewRedactor(config.Rules)
+	if err != nil {
+		return nil, i18n.Errorf("invalid redaction rules: %w", err)
+	}
+	if redactor == nil {
+		return none, nil
+	}
+	return func(providerName string, providerConfig *types.ProviderConfig, provider llm.Provider) llm.Provider {
+		if types.IsLocalProvider(providerName, providerConfig) && !config.Local {
+			return provider
+		}
+		return adapters.NewRedactingProvider(provider, redactor)
+	}, nil
+}
 
-	if _, err := p.Run(); err != nil {
-		return fmt.Errorf("TUI error: %w", err)
+// draftModelConfig applies the project's llm.draft_model, which starts the
+// session on the drafting model instead of the provider's default.
+func draftModelConfig(application *app.App, proj *project.Project, providerConfig *types.ProviderConfig, providerName string) (*types.ProviderConfig, string, error) {
+	if proj.Config == nil || proj.Config.LLM.DraftModel == "" {
+		return providerConfig, providerName, nil
 	}
+	globalConfig, err := application.Config.LoadGlobalConfig()
+	if err != nil {
+		return nil, "", i18n.Errorf("failed to load config: %w", err)
+	}
+
+	name, model := tui.ResolveModelRef(proj.Config.LLM.DraftModel, providerName, switchableProviders(globalConfig))
+	config, err := application.Config.GetProviderConfig(name)
+	if err != nil {
+		return nil, "", i18n.Errorf("failed to use draft model %s: %w", proj.Config.LLM.DraftModel, err)
+	}
+	draft := *config
+	draft.DefaultModel = model
+	return &draft, name, nil
+}
+
+// switchableProviders returns the configured providers that can be used,
+// sorted by name: local, and the others when they have an API key.
+func switchableProviders(globalConfig *types.GlobalConfig) []string {
+	var names []string
+	for name, config := range globalConfig.Providers {
+		if config == nil || (name != "local" && config.APIKey == "") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
+// runProgram runs the TUI. When the session crashes, the terminal has been
+// restored by the time Run returns and the crash report path is reported.
+func runProgram(model *tui.Model) error {
+	_, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if report := model.CrashReport(); report != "" {
+		return i18n.Errorf("dreamteller crashed; a crash report was saved to %s. Reopen the project to restore your session", report)
+	}
+	if errors.Is(err, tea.ErrProgramPanic) {
+		// A panic in a command's goroutine bypasses the model's recover;
+		// bubbletea has printed the stack, so only the session is left to save.
+		if saveErr := model.SaveCrashSession(); saveErr != nil {
+			return i18n.Errorf("dreamteller crashed and the session could not be saved: %w", saveErr)
+		}
+		return i18n.Errorf("dreamteller crashed. Reopen the project to restore your session")
+	}
+	if err != nil {
+		return i18n.Errorf("TUI error: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/azyu/dreamteller/internal/i18n"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// walkCommands calls fn for cmd and every command under it.
+func walkCommands(cmd *cobra.Command, fn func(*cobra.Command)) {
+	fn(cmd)
+	for _, sub := range cmd.Commands() {
+		walkCommands(sub, fn)
+	}
+}
+
+func TestCommandHelpIsTranslated(t *testing.T) {
+	type help struct{ short, long string }
+	english := make(map[*cobra.Command]help)
+	walkCommands(rootCmd, func(cmd *cobra.Command) {
+		english[cmd] = help{cmd.Short, cmd.Long}
+	})
+
+	t.Cleanup(func() { i18n.SetLanguage(i18n.English) })
+	for _, lang := range []i18n.Language{i18n.Korean, i18n.Japanese} {
+		for cmd, h := range english {
+			cmd.Short, cmd.Long = h.short, h.long
+		}
+		i18n.SetLanguage(lang)
+		i18n.LocalizeCommand(rootCmd)
+
+		for cmd, h := range english {
+			if h.short != "" {
+				assert.NotEqual(t, h.short, cmd.Short, "%s: no translation for the Short of %q", lang, cmd.CommandPath())
+			}
+			if h.long != "" {
+				assert.NotEqual(t, h.long, cmd.Long, "%s: no translation for the Long of %q", lang, cmd.CommandPath())
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package app
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/azyu/dreamteller/internal/project"
 	"github.com/azyu/dreamteller/pkg/types"
@@ -15,8 +16,32 @@ type App struct {
 	CurrentProject *project.Project
 }
 
+// ProjectsDirEnv is the environment variable that overrides the configured
+// projects directory.
+const ProjectsDirEnv = "DREAMTELLER_PROJECTS_DIR"
+
+// Option configures an App.
+type Option func(*options)
+
+type options struct {
+	projectsDir string
+}
+
+// WithProjectsDir overrides the projects root for this application instance.
+// It takes precedence over both the environment and the global config.
+func WithProjectsDir(dir string) Option {
+	return func(o *options) {
+		o.projectsDir = dir
+	}
+}
+
 // New creates a new application instance.
-func New() (*App, error) {
+func New(opts ...Option) (*App, error) {
+	cfg := &options{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	configManager, err := NewConfigManager()
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize config manager: %w", err)
@@ -28,7 +53,7 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("failed to load global config: %w", err)
 	}
 
-	projectManager, err := project.NewManager(globalConfig.ProjectsDir)
+	projectManager, err := project.NewManager(resolveProjectsDir(cfg.projectsDir, globalConfig))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize project manager: %w", err)
 	}
@@ -39,9 +64,28 @@ func New() (*App, error) {
 	}, nil
 }
 
-// OpenProject opens an existing project by name.
-func (a *App) OpenProject(name string) error {
-	proj, err := a.ProjectManager.Open(name)
+// resolveProjectsDir picks the projects root: explicit override first,
+// then the environment, then the global config.
+func resolveProjectsDir(override string, config *types.GlobalConfig) string {
+	if override != "" {
+		return expandPath(override)
+	}
+	if dir := os.Getenv(ProjectsDirEnv); dir != "" {
+		return expandPath(dir)
+	}
+	return config.ProjectsDir
+}
+
+// OpenProject opens an existing project by name, or by path when ref is
+// an absolute or explicitly relative path.
+func (a *App) OpenProject(ref string) error {
+	var proj *project.Project
+	var err error
+	if project.IsPathReference(ref) {
+		proj, err = a.ProjectManager.OpenPath(ref)
+	} else {
+		proj, err = a.ProjectManager.Open(ref)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open project: %w", err)
 	}
@@ -65,6 +109,16 @@ func (a *App) ListProjects() ([]*types.Project, error) {
 	return a.ProjectManager.List()
 }
 
+// CloseProject closes the current project, if any.
+func (a *App) CloseProject() error {
+	if a.CurrentProject == nil {
+		return nil
+	}
+	err := a.CurrentProject.Close()
+	a.CurrentProject = nil
+	return err
+}
+
 // Close cleans up application resources.
 func (a *App) Close() error {
 	if a.CurrentProject != nil {
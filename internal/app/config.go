@@ -165,6 +165,38 @@ func (cm *ConfigManager) GetProjectsDir() (string, error) {
 	return config.ProjectsDir, nil
 }
 
+// SetProjectsDir persists a new projects root in the global configuration.
+func (cm *ConfigManager) SetProjectsDir(dir string) error {
+	config, err := cm.LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+
+	abs, err := filepath.Abs(expandPath(dir))
+	if err != nil {
+		return fmt.Errorf("failed to resolve projects directory: %w", err)
+	}
+
+	config.ProjectsDir = abs
+	return cm.SaveGlobalConfig(config)
+}
+
+// SetLanguage persists the language of the command line's help, messages
+// and prompts in the global configuration.
+func (cm *ConfigManager) SetLanguage(lang string) error {
+	config, err := cm.LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	config.Language = lang
+	return cm.SaveGlobalConfig(config)
+}
+
+// ConfigPath returns the path of the global configuration file.
+func (cm *ConfigManager) ConfigPath() string {
+	return cm.globalConfigPath
+}
+
 // GetProviderConfig returns the configuration for a specific provider.
 func (cm *ConfigManager) GetProviderConfig(providerName string) (*types.ProviderConfig, error) {
 	config, err := cm.LoadGlobalConfig()
@@ -0,0 +1,492 @@
+// Package batch runs an LLM operation over many chapters with rate
+// limiting and a checkpoint, so an interrupted run resumes where it stopped.
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// Op is an operation run on each chapter.
+type Op string
+
+const (
+	OpSummarize Op = "summarize"
+	OpLint      Op = "lint"
+	OpTranslate Op = "translate"
+	OpRules     Op = "rules"
+	OpPacing    Op = "pacing"
+)
+
+// Ops lists the supported operations.
+var Ops = []Op{OpSummarize, OpLint, OpTranslate, OpRules, OpPacing}
+
+const (
+	// defaultRetryDelay is the first backoff after a rate-limit error when
+	// the runner has no request interval.
+	defaultRetryDelay = 5 * time.Second
+
+	// defaultMaxRetries is how often a rate-limited chapter is retried.
+	defaultMaxRetries = 3
+)
+
+// ParseOp parses an operation name.
+func ParseOp(name string) (Op, error) {
+	for _, op := range Ops {
+		if strings.EqualFold(name, string(op)) {
+			return op, nil
+		}
+	}
+	names := make([]string, len(Ops))
+	for i, op := range Ops {
+		names[i] = string(op)
+	}
+	return "", fmt.Errorf("unknown operation %q (use %s)", name, strings.Join(names, ", "))
+}
+
+// ParseChapterRange parses a chapter list such as "1-10" or "1-3,7,9-10"
+// into sorted, unique chapter numbers.
+func ParseChapterRange(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("invalid chapter %q", part)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(to))
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid chapter range %q", part)
+			}
+		}
+		for n := start; n <= end; n++ {
+			seen[n] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("no chapters in %q", spec)
+	}
+
+	chapters := make([]int, 0, len(seen))
+	for n := range seen {
+		chapters = append(chapters, n)
+	}
+	sort.Ints(chapters)
+	return chapters, nil
+}
+
+// Job describes a batch run.
+type Job struct {
+	Op Op
+
+	// Chapters lists the chapter numbers to process. Empty means all.
+	Chapters []int
+
+	// Language is the target language of OpTranslate, e.g. "ja".
+	Language string
+}
+
+// ID names the job's checkpoint, e.g. "summarize" or "translate-ja".
+func (j Job) ID() string {
+	if j.Op == OpTranslate {
+		return string(j.Op) + "-" + strings.ToLower(j.Language)
+	}
+	return string(j.Op)
+}
+
+// OutputDir returns the directory, relative to the project root, that the
+// job writes its results to. Translations go to translations/<language>,
+// parallel to chapters/, which is never modified.
+func (j Job) OutputDir() string {
+	if j.Op == OpTranslate {
+		return filepath.Join("translations", strings.ToLower(j.Language))
+	}
+	return filepath.Join("batch", j.ID())
+}
+
+// Checkpoint records the chapters a job has finished.
+type Checkpoint struct {
+	Op        Op               `json:"op"`
+	Language  string           `json:"language,omitempty"`
+	Done      map[int]DoneItem `json:"done"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// DoneItem is a finished chapter. Hash identifies the chapter text it was
+// made from, so an edited chapter is processed again.
+type DoneItem struct {
+	Output string `json:"output"`
+	Hash   string `json:"hash"`
+}
+
+// Status is what happened to a chapter.
+type Status string
+
+const (
+	StatusDone    Status = "done"
+	StatusSkipped Status = "skipped"
+	StatusFailed  Status = "failed"
+)
+
+// Event reports progress on one chapter.
+type Event struct {
+	Chapter int
+	Title   string
+	Status  Status
+	Output  string
+	Err     error
+
+	// Warnings describes formatting a translation did not preserve.
+	Warnings []string
+}
+
+// Runner runs batch jobs on a project.
+type Runner struct {
+	Project  *project.Project
+	Provider llm.Provider
+
+	// Interval is the minimum time between requests. Zero sends them
+	// back to back.
+	Interval time.Duration
+
+	// MaxRetries is how often a rate-limited chapter is retried with
+	// backoff. Zero uses the default.
+	MaxRetries int
+
+	// Progress, if set, is called after each chapter.
+	Progress func(Event)
+
+	lastRequest time.Time
+}
+
+// CheckpointPath returns the checkpoint file of a job.
+func (r *Runner) CheckpointPath(job Job) string {
+	return filepath.Join(r.Project.Path(), ".dreamteller", "batch", job.ID()+".json")
+}
+
+// MemoryPath returns the translation memory file of a translate job.
+func (r *Runner) MemoryPath(job Job) string {
+	return filepath.Join(r.Project.Path(), job.OutputDir(), "memory.md")
+}
+
+// LoadCheckpoint loads a job's checkpoint, or returns an empty one if the
+// job has not run yet.
+func (r *Runner) LoadCheckpoint(job Job) (*Checkpoint, error) {
+	data, err := os.ReadFile(r.CheckpointPath(job))
+	if os.IsNotExist(err) {
+		return &Checkpoint{Op: job.Op, Language: job.Language, Done: make(map[int]DoneItem)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	if cp.Done == nil {
+		cp.Done = make(map[int]DoneItem)
+	}
+	return &cp, nil
+}
+
+// ResetCheckpoint discards a job's progress so the next run starts over.
+func (r *Runner) ResetCheckpoint(job Job) error {
+	if err := os.Remove(r.CheckpointPath(job)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (r *Runner) saveCheckpoint(job Job, cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	path := r.CheckpointPath(job)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	if err := storage.AtomicWriteFile(path, data); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Run processes the job's chapters in order, skipping those finished by an
+// earlier run whose text has not changed since. The checkpoint is saved
+// after every chapter, so a run stopped by an error or by cancelling ctx
+// resumes from the first unfinished chapter.
+func (r *Runner) Run(ctx context.Context, job Job) (*Checkpoint, error) {
+	if job.Op == OpTranslate && strings.TrimSpace(job.Language) == "" {
+		return nil, fmt.Errorf("translate needs a target language")
+	}
+
+	chapters, err := r.Project.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	byNumber := make(map[int]int, len(chapters))
+	for i, ch := range chapters {
+		byNumber[ch.Number] = i
+	}
+
+	numbers := job.Chapters
+	if len(numbers) == 0 {
+		for _, ch := range chapters {
+			numbers = append(numbers, ch.Number)
+		}
+	}
+	for _, n := range numbers {
+		if _, ok := byNumber[n]; !ok {
+			return nil, fmt.Errorf("chapter %d does not exist (the project has %d chapters)", n, len(chapters))
+		}
+	}
+
+	cp, err := r.LoadCheckpoint(job)
+	if err != nil {
+		return nil, err
+	}
+
+	// A rules check depends on the rulebook as well as the chapter, so a
+	// changed rulebook checks every chapter again.
+	var rulebook string
+	if job.Op == OpRules {
+		rules, err := r.Project.LoadRules()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load rules: %w", err)
+		}
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("the rulebook is empty: add rules as list items to a file in context/%s", project.RulesDir)
+		}
+		rulebook = project.RulebookMarkdown(rules)
+	}
+
+	var mem *Memory
+	var names []string
+	if job.Op == OpTranslate {
+		if mem, err = LoadMemory(r.MemoryPath(job)); err != nil {
+			return nil, err
+		}
+		names = r.contextNames()
+	}
+
+	for _, n := range numbers {
+		ch := chapters[byNumber[n]]
+		hash := contentHash(ch.Content + rulebook)
+		if item, ok := cp.Done[n]; ok && item.Hash == hash {
+			r.report(Event{Chapter: n, Title: ch.Title, Status: StatusSkipped, Output: item.Output})
+			continue
+		}
+
+		system := instruction(job)
+		if rulebook != "" {
+			system += "\n\nRules:\n" + rulebook
+		}
+		if mem != nil {
+			system = translationInstruction(job, ch.Content, mem, names)
+		}
+		content := ch.Content
+		if job.Op == OpPacing {
+			content = project.MarkScenes(content)
+		}
+		result, err := r.process(ctx, system, content)
+		if err != nil {
+			r.report(Event{Chapter: n, Title: ch.Title, Status: StatusFailed, Err: err})
+			if saveErr := r.saveCheckpoint(job, cp); saveErr != nil {
+				return cp, errors.Join(err, saveErr)
+			}
+			return cp, fmt.Errorf("chapter %d: %w", n, err)
+		}
+
+		var warnings []string
+		if mem != nil {
+			var pairs [][2]string
+			result, pairs = splitTerms(result)
+			for _, pair := range pairs {
+				mem.Add(pair[0], pair[1])
+			}
+			warnings = formattingWarnings(ch.Content, result)
+		}
+
+		output := filepath.Join(job.OutputDir(), fmt.Sprintf("chapter-%03d.md", n))
+		if err := r.Project.FS.WriteMarkdown(output, result+"\n"); err != nil {
+			return cp, fmt.Errorf("chapter %d: failed to write result: %w", n, err)
+		}
+		if mem != nil {
+			if err := mem.Save(); err != nil {
+				return cp, err
+			}
+		}
+		cp.Done[n] = DoneItem{Output: output, Hash: hash}
+		if err := r.saveCheckpoint(job, cp); err != nil {
+			return cp, err
+		}
+		r.report(Event{Chapter: n, Title: ch.Title, Status: StatusDone, Output: output, Warnings: warnings})
+	}
+
+	return cp, nil
+}
+
+// process sends one chapter with the operation's instructions, retrying
+// with backoff when the provider is rate limited.
+func (r *Runner) process(ctx context.Context, system, content string) (string, error) {
+	req := llm.ChatRequest{
+		Messages: []llm.ChatMessage{
+			llm.NewSystemMessage(system),
+			llm.NewUserMessage(content),
+		},
+		Temperature: 0.3,
+	}
+
+	maxRetries := r.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	delay := r.Interval
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := r.wait(ctx); err != nil {
+			return "", err
+		}
+		resp, err := r.Provider.Chat(ctx, req)
+		if err == nil {
+			text := strings.TrimSpace(resp.Message.Content)
+			if text == "" {
+				return "", fmt.Errorf("the model returned an empty reply")
+			}
+			return text, nil
+		}
+		if !errors.Is(err, llm.ErrRateLimited) || attempt >= maxRetries {
+			return "", err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay << attempt):
+		}
+	}
+}
+
+// wait spaces requests at least Interval apart.
+func (r *Runner) wait(ctx context.Context) error {
+	if !r.lastRequest.IsZero() && r.Interval > 0 {
+		if d := time.Until(r.lastRequest.Add(r.Interval)); d > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d):
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.lastRequest = time.Now()
+	return nil
+}
+
+func (r *Runner) report(e Event) {
+	if r.Progress != nil {
+		r.Progress(e)
+	}
+}
+
+// instruction returns the system prompt for the job's operation.
+func instruction(job Job) string {
+	switch job.Op {
+	case OpSummarize:
+		return "You summarize chapters of a novel for its author. Write a concise summary of the chapter in 150-250 words: the events in order, how the characters change, and the threads left open. Write in the chapter's language and reply with the summary only."
+	case OpLint:
+		return "You are a line editor. List the problems in the chapter: typos, grammar, repeated words, unclear sentences, inconsistent names, and slips in point of view or tense. Write one issue per line as `- \"quoted text\" — problem — suggestion`. If there are none, reply `No issues found.`"
+	case OpRules:
+		return "You check a chapter of a novel against the hard rules of its world's magic or technology, listed below with their IDs. List each passage that breaks a rule, as written rather than as a character's lie, belief or mistake, one per line as `- \"quoted text\" — rule ID — how it breaks the rule`. Cite only the listed IDs. If nothing breaks a rule, reply `No issues found.`"
+	case OpPacing:
+		return "You classify the scenes of a chapter of a novel for a pacing chart. Each scene starts with a [Scene N] marker. Reply with one line per scene, in order, as `- Scene N: mode — what happens in a few words`, where mode is action for scenes driven by events, conflict or decisions, reflection for introspection, memory, description or quiet aftermath, and mixed for scenes that balance both."
+	case OpTranslate:
+		return fmt.Sprintf("Translate the chapter into %s. Keep the Markdown formatting, headings and paragraph breaks, use the target language's dialogue punctuation, and keep character and place names consistent. Reply with the translation only.", job.Language)
+	}
+	return ""
+}
+
+// translationInstruction extends the translate instructions with the
+// established translations of terms in the chapter and asks the model to
+// report the names and terms it translated.
+func translationInstruction(job Job, content string, mem *Memory, names []string) string {
+	var sb strings.Builder
+	sb.WriteString(instruction(job))
+
+	if known := mem.Find(content); len(known) > 0 {
+		sb.WriteString("\n\nUse these established translations:\n")
+		for _, source := range known {
+			target, _ := mem.Get(source)
+			sb.WriteString(fmt.Sprintf("- %s → %s\n", source, target))
+		}
+	}
+
+	var pending []string
+	for _, name := range names {
+		if _, ok := mem.Get(name); !ok && strings.Contains(content, name) {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) > 0 {
+		sb.WriteString("\n\nNames and terms from the story bible in this chapter: " + strings.Join(pending, ", ") + ".")
+	}
+
+	sb.WriteString("\n\nAfter the translation, write a line containing only " + termsMarker +
+		" and then one line per character name, place name or invented term you translated, as `source → translation`.")
+	return sb.String()
+}
+
+// contextNames returns the names of the project's characters and settings
+// and its glossary terms.
+func (r *Runner) contextNames() []string {
+	var names []string
+	if characters, err := r.Project.LoadCharacters(); err == nil {
+		for _, c := range characters {
+			names = append(names, c.Name)
+		}
+	}
+	if settings, err := r.Project.LoadSettings(); err == nil {
+		for _, s := range settings {
+			names = append(names, s.Name)
+		}
+	}
+	if glossary, err := r.Project.LoadGlossary(); err == nil {
+		for _, g := range glossary {
+			names = append(names, g.Term)
+		}
+	}
+	return names
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,236 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider answers Chat with the chapter's first line, failing
+// the calls listed in errs.
+type scriptedProvider struct {
+	llm.Provider
+	calls    []string
+	systems  []string
+	errs     map[int]error
+	requests int
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.requests++
+	if err := p.errs[p.requests]; err != nil {
+		return nil, err
+	}
+	content := req.Messages[len(req.Messages)-1].Content
+	first, _, _ := strings.Cut(content, "\n")
+	p.calls = append(p.calls, first)
+	p.systems = append(p.systems, req.Messages[0].Content)
+	return &llm.ChatResponse{Message: llm.NewAssistantMessage("result for " + first)}, nil
+}
+
+func createProjectWithChapters(t *testing.T, n int) *project.Project {
+	t.Helper()
+
+	mgr, err := project.NewManager(t.TempDir())
+	require.NoError(t, err)
+	proj, err := mgr.Create("batch-novel", types.DefaultProjectConfig("Batch Novel", "fantasy"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = proj.Close() })
+
+	for i := 1; i <= n; i++ {
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: i, Content: fmt.Sprintf("# Chapter %d\n\nText of chapter %d.\n", i, i)}))
+	}
+	return proj
+}
+
+func TestParseChapterRange(t *testing.T) {
+	chapters, err := ParseChapterRange("1-3, 7,9-10,2")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 7, 9, 10}, chapters)
+
+	for _, bad := range []string{"", "0", "3-1", "a-b", "1-"} {
+		_, err := ParseChapterRange(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseOp(t *testing.T) {
+	op, err := ParseOp("Summarize")
+	require.NoError(t, err)
+	assert.Equal(t, OpSummarize, op)
+
+	_, err = ParseOp("rewrite")
+	assert.Error(t, err)
+}
+
+func TestRunner(t *testing.T) {
+	t.Run("processes the chapter range and writes results", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 4)
+		provider := &scriptedProvider{}
+		var events []Event
+		runner := &Runner{Project: proj, Provider: provider, Progress: func(e Event) { events = append(events, e) }}
+
+		job := Job{Op: OpSummarize, Chapters: []int{2, 3}}
+		cp, err := runner.Run(context.Background(), job)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"# Chapter 2", "# Chapter 3"}, provider.calls)
+		assert.Len(t, cp.Done, 2)
+		require.Len(t, events, 2)
+		assert.Equal(t, StatusDone, events[0].Status)
+
+		data, err := os.ReadFile(filepath.Join(proj.Path(), "batch", "summarize", "chapter-002.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "result for # Chapter 2\n", string(data))
+	})
+
+	t.Run("resumes after a failure", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 3)
+		provider := &scriptedProvider{errs: map[int]error{2: errors.New("connection reset")}}
+		runner := &Runner{Project: proj, Provider: provider}
+		job := Job{Op: OpLint}
+
+		_, err := runner.Run(context.Background(), job)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "chapter 2")
+
+		cp, err := runner.LoadCheckpoint(job)
+		require.NoError(t, err)
+		assert.Len(t, cp.Done, 1)
+
+		var events []Event
+		runner.Progress = func(e Event) { events = append(events, e) }
+		_, err = runner.Run(context.Background(), job)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"# Chapter 1", "# Chapter 2", "# Chapter 3"}, provider.calls)
+		require.Len(t, events, 3)
+		assert.Equal(t, StatusSkipped, events[0].Status)
+		assert.Equal(t, StatusDone, events[1].Status)
+	})
+
+	t.Run("redoes edited chapters", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 2)
+		provider := &scriptedProvider{}
+		runner := &Runner{Project: proj, Provider: provider}
+		job := Job{Op: OpSummarize}
+
+		_, err := runner.Run(context.Background(), job)
+		require.NoError(t, err)
+
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "# Chapter 2 revised\n"}))
+		_, err = runner.Run(context.Background(), job)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"# Chapter 1", "# Chapter 2", "# Chapter 2 revised"}, provider.calls)
+
+		require.NoError(t, runner.ResetCheckpoint(job))
+		_, err = runner.Run(context.Background(), job)
+		require.NoError(t, err)
+		assert.Len(t, provider.calls, 5)
+	})
+
+	t.Run("retries rate-limited requests", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 1)
+		provider := &scriptedProvider{errs: map[int]error{1: llm.ErrRateLimited, 2: llm.ErrRateLimited}}
+		runner := &Runner{Project: proj, Provider: provider, Interval: time.Millisecond}
+
+		_, err := runner.Run(context.Background(), Job{Op: OpSummarize})
+		require.NoError(t, err)
+		assert.Equal(t, 3, provider.requests)
+	})
+
+	t.Run("spaces requests by the interval", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 3)
+		runner := &Runner{Project: proj, Provider: &scriptedProvider{}, Interval: 20 * time.Millisecond}
+
+		start := time.Now()
+		_, err := runner.Run(context.Background(), Job{Op: OpSummarize})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 2)
+		provider := &scriptedProvider{}
+		runner := &Runner{Project: proj, Provider: provider}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runner.Progress = func(Event) { cancel() }
+		_, err := runner.Run(ctx, Job{Op: OpSummarize})
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Len(t, provider.calls, 1)
+	})
+
+	t.Run("translate writes per-language output", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 1)
+		provider := &scriptedProvider{}
+		runner := &Runner{Project: proj, Provider: provider}
+
+		_, err := runner.Run(context.Background(), Job{Op: OpTranslate})
+		require.Error(t, err)
+
+		job := Job{Op: OpTranslate, Language: "ja"}
+		_, err = runner.Run(context.Background(), job)
+		require.NoError(t, err)
+		assert.Contains(t, provider.systems[0], "into ja")
+		assert.FileExists(t, filepath.Join(proj.Path(), "translations", "ja", "chapter-001.md"))
+		assert.FileExists(t, runner.CheckpointPath(job))
+	})
+
+	t.Run("rules checks against the rulebook", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 2)
+		provider := &scriptedProvider{}
+		runner := &Runner{Project: proj, Provider: provider}
+		job := Job{Op: OpRules}
+
+		_, err := runner.Run(context.Background(), job)
+		require.Error(t, err, "an empty rulebook has nothing to check")
+
+		rulesPath := filepath.Join("context", project.RulesDir, "magic.md")
+		require.NoError(t, proj.FS.WriteMarkdown(rulesPath, "# Blood Magic\n\n- M1: Every spell costs blood.\n"))
+		_, err = runner.Run(context.Background(), job)
+		require.NoError(t, err)
+		assert.Contains(t, provider.systems[0], "- [M1] Blood Magic: Every spell costs blood.")
+		assert.FileExists(t, filepath.Join(proj.Path(), "batch", "rules", "chapter-001.md"))
+
+		// A changed rulebook checks the chapters again.
+		_, err = runner.Run(context.Background(), job)
+		require.NoError(t, err)
+		assert.Len(t, provider.calls, 2)
+		require.NoError(t, proj.FS.WriteMarkdown(rulesPath, "# Blood Magic\n\n- M1: Every spell costs blood.\n- M2: The dead stay dead.\n"))
+		_, err = runner.Run(context.Background(), job)
+		require.NoError(t, err)
+		assert.Len(t, provider.calls, 4)
+	})
+
+	t.Run("pacing numbers the scenes", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 1)
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# Chapter 1\n\nThe ship burns.\n\n***\n\nHana remembers.\n"}))
+		provider := &scriptedProvider{}
+		runner := &Runner{Project: proj, Provider: provider}
+
+		_, err := runner.Run(context.Background(), Job{Op: OpPacing})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"[Scene 1]"}, provider.calls)
+		assert.Contains(t, provider.systems[0], "- Scene N: mode")
+		assert.FileExists(t, filepath.Join(proj.Path(), "batch", "pacing", "chapter-001.md"))
+	})
+
+	t.Run("rejects missing chapters", func(t *testing.T) {
+		proj := createProjectWithChapters(t, 2)
+		runner := &Runner{Project: proj, Provider: &scriptedProvider{}}
+		_, err := runner.Run(context.Background(), Job{Op: OpSummarize, Chapters: []int{1, 5}})
+		assert.Error(t, err)
+	})
+}
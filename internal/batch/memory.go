@@ -0,0 +1,159 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// termsMarker separates a translation from the terms the model reports
+// having translated.
+const termsMarker = "%%TERMS%%"
+
+// memoryLinePattern matches a memory entry: "- source → target". "=>" is
+// accepted as well so the file is easy to edit by hand.
+var memoryLinePattern = regexp.MustCompile(`^\s*[-*]\s*(.+?)\s*(?:→|=>)\s*(.+?)\s*$`)
+
+// Memory is a translation memory: the established translation of names and
+// invented terms in one target language, so every chapter uses the same
+// ones. It is stored as a Markdown list the author can edit.
+type Memory struct {
+	path  string
+	terms map[string]string
+}
+
+// LoadMemory loads the translation memory at path, or returns an empty one
+// if the file does not exist yet.
+func LoadMemory(path string) (*Memory, error) {
+	mem := &Memory{path: path, terms: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return mem, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read translation memory: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if match := memoryLinePattern.FindStringSubmatch(line); match != nil {
+			mem.terms[match[1]] = match[2]
+		}
+	}
+	return mem, nil
+}
+
+// Len returns the number of terms.
+func (m *Memory) Len() int {
+	return len(m.terms)
+}
+
+// Get returns the translation of a term.
+func (m *Memory) Get(source string) (string, bool) {
+	target, ok := m.terms[source]
+	return target, ok
+}
+
+// Add records a translation unless the term already has one; the
+// established translation wins. It reports whether the term was new.
+func (m *Memory) Add(source, target string) bool {
+	source, target = strings.TrimSpace(source), strings.TrimSpace(target)
+	if source == "" || target == "" {
+		return false
+	}
+	if _, ok := m.terms[source]; ok {
+		return false
+	}
+	m.terms[source] = target
+	return true
+}
+
+// Find returns the terms that occur in text, sorted.
+func (m *Memory) Find(text string) []string {
+	var found []string
+	for source := range m.terms {
+		if strings.Contains(text, source) {
+			found = append(found, source)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// Save writes the memory as a sorted Markdown list.
+func (m *Memory) Save() error {
+	sources := make([]string, 0, len(m.terms))
+	for source := range m.terms {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var sb strings.Builder
+	sb.WriteString("# Translation Memory\n\n")
+	for _, source := range sources {
+		sb.WriteString(fmt.Sprintf("- %s → %s\n", source, m.terms[source]))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create translation directory: %w", err)
+	}
+	if err := storage.AtomicWriteFile(m.path, []byte(sb.String())); err != nil {
+		return fmt.Errorf("failed to write translation memory: %w", err)
+	}
+	return nil
+}
+
+// splitTerms separates a translation from the term list after termsMarker
+// and returns the reported source → target pairs.
+func splitTerms(reply string) (string, [][2]string) {
+	text, terms, ok := strings.Cut(reply, termsMarker)
+	if !ok {
+		return reply, nil
+	}
+
+	var pairs [][2]string
+	for _, line := range strings.Split(terms, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "*") {
+			line = "- " + line
+		}
+		if match := memoryLinePattern.FindStringSubmatch(line); match != nil {
+			pairs = append(pairs, [2]string{match[1], match[2]})
+		}
+	}
+	return strings.TrimSpace(text), pairs
+}
+
+// headingPattern matches a Markdown heading line.
+var headingPattern = regexp.MustCompile(`(?m)^#{1,6}\s`)
+
+// formattingWarnings compares the structure of a translation with its
+// source and describes what was lost or added.
+func formattingWarnings(source, translation string) []string {
+	var warnings []string
+	if want, got := len(headingPattern.FindAllString(source, -1)), len(headingPattern.FindAllString(translation, -1)); want != got {
+		warnings = append(warnings, fmt.Sprintf("%d headings in the original, %d in the translation", want, got))
+	}
+	if want, got := countParagraphs(source), countParagraphs(translation); want != got {
+		warnings = append(warnings, fmt.Sprintf("%d paragraphs in the original, %d in the translation", want, got))
+	}
+	return warnings
+}
+
+// countParagraphs counts blank-line separated blocks.
+func countParagraphs(text string) int {
+	count := 0
+	for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if strings.TrimSpace(block) != "" {
+			count++
+		}
+	}
+	return count
+}
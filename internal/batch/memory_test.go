@@ -0,0 +1,109 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cannedProvider replies with its responses in order and records the
+// system prompts it was sent.
+type cannedProvider struct {
+	llm.Provider
+	replies []string
+	systems []string
+}
+
+func (p *cannedProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.systems = append(p.systems, req.Messages[0].Content)
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return &llm.ChatResponse{Message: llm.NewAssistantMessage(reply)}, nil
+}
+
+func TestMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ja", "memory.md")
+	mem, err := LoadMemory(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, mem.Len())
+
+	assert.True(t, mem.Add("하나", "ハナ"))
+	assert.False(t, mem.Add("하나", "ハンナ"), "the established translation wins")
+	assert.True(t, mem.Add("서울", "ソウル"))
+	require.NoError(t, mem.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Translation Memory\n\n- 서울 → ソウル\n- 하나 → ハナ\n", string(data))
+
+	require.NoError(t, os.WriteFile(path, append(data, []byte("* 아스란 => アスラン\nnot an entry\n")...), 0644))
+	mem, err = LoadMemory(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, mem.Len())
+	assert.Equal(t, []string{"아스란", "하나"}, mem.Find("하나는 아스란으로 떠났다."))
+}
+
+func TestSplitTerms(t *testing.T) {
+	text, pairs := splitTerms("# 第1章\n\nハナは走った。\n%%TERMS%%\n- 하나 → ハナ\n서울 => ソウル\n\n")
+	assert.Equal(t, "# 第1章\n\nハナは走った。", text)
+	assert.Equal(t, [][2]string{{"하나", "ハナ"}, {"서울", "ソウル"}}, pairs)
+
+	text, pairs = splitTerms("No terms here.")
+	assert.Equal(t, "No terms here.", text)
+	assert.Empty(t, pairs)
+}
+
+func TestFormattingWarnings(t *testing.T) {
+	source := "# One\n\nFirst.\n\nSecond.\n"
+	assert.Empty(t, formattingWarnings(source, "# 一\n\n最初。\n\n次。"))
+
+	warnings := formattingWarnings(source, "一\n\n最初。次。")
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "headings")
+	assert.Contains(t, warnings[1], "paragraphs")
+}
+
+func TestTranslation(t *testing.T) {
+	proj := createProjectWithChapters(t, 0)
+	require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), "context", "characters", "hana.md"), []byte("# 하나\n\n주인공.\n"), 0644))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1장\n\n하나는 달렸다.\n"}))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "# 2장\n\n하나는 웃었다.\n\n끝.\n"}))
+
+	provider := &cannedProvider{replies: []string{
+		"# 第1章\n\nハナは走った。\n%%TERMS%%\n- 하나 → ハナ",
+		"# 第2章\n\nハナは笑った。終わり。",
+	}}
+	var events []Event
+	runner := &Runner{Project: proj, Provider: provider, Progress: func(e Event) { events = append(events, e) }}
+	job := Job{Op: OpTranslate, Language: "ja"}
+
+	_, err := runner.Run(context.Background(), job)
+	require.NoError(t, err)
+
+	assert.Contains(t, provider.systems[0], "story bible in this chapter: 하나")
+	assert.Contains(t, provider.systems[1], "- 하나 → ハナ", "later chapters reuse the memory")
+	assert.NotContains(t, provider.systems[1], "story bible")
+
+	data, err := os.ReadFile(filepath.Join(proj.Path(), "translations", "ja", "chapter-001.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# 第1章\n\nハナは走った。\n", string(data))
+
+	original, err := os.ReadFile(filepath.Join(proj.Path(), "chapters", "chapter-001.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# 1장\n\n하나는 달렸다.\n", string(original))
+
+	memory, err := os.ReadFile(runner.MemoryPath(job))
+	require.NoError(t, err)
+	assert.Contains(t, string(memory), "- 하나 → ハナ")
+
+	require.Len(t, events, 2)
+	assert.Empty(t, events[0].Warnings)
+	require.Len(t, events[1].Warnings, 1)
+	assert.Contains(t, events[1].Warnings[0], "paragraphs")
+}
@@ -0,0 +1,387 @@
+// Package chatimport reads brainstorming conversations from ChatGPT and
+// Claude data exports and extracts the story decisions in them, so an
+// author can seed a project from talks they already had.
+package chatimport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// Export formats.
+const (
+	FormatChatGPT = "chatgpt"
+	FormatClaude  = "claude"
+)
+
+// DefaultPartRunes is how much transcript is sent per extraction request.
+// Longer transcripts are split at turn boundaries and the results merged.
+const DefaultPartRunes = 24000
+
+// Turn is one message of a conversation.
+type Turn struct {
+	Role string // "user" or "assistant"
+	Text string
+}
+
+// Conversation is one chat from an export.
+type Conversation struct {
+	Title     string
+	Format    string
+	CreatedAt time.Time
+	Turns     []Turn
+}
+
+// Parse reads a ChatGPT or Claude export: the conversations.json file
+// of the data export, or a single conversation from it. Conversations
+// without text are dropped.
+func Parse(data []byte) ([]Conversation, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		data = append(append([]byte{'['}, data...), ']')
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("not a ChatGPT or Claude export: %w", err)
+	}
+
+	var conversations []Conversation
+	for i, item := range raw {
+		var probe struct {
+			Mapping      json.RawMessage `json:"mapping"`
+			ChatMessages json.RawMessage `json:"chat_messages"`
+		}
+		if err := json.Unmarshal(item, &probe); err != nil {
+			return nil, fmt.Errorf("conversation %d: %w", i+1, err)
+		}
+
+		var c Conversation
+		var err error
+		switch {
+		case probe.Mapping != nil:
+			c, err = parseChatGPT(item)
+		case probe.ChatMessages != nil:
+			c, err = parseClaude(item)
+		default:
+			return nil, fmt.Errorf("conversation %d: not a ChatGPT or Claude conversation", i+1)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("conversation %d: %w", i+1, err)
+		}
+		if len(c.Turns) > 0 {
+			conversations = append(conversations, c)
+		}
+	}
+	return conversations, nil
+}
+
+// chatGPTNode is a message in the tree of a ChatGPT conversation; edits
+// and regenerated replies branch it.
+type chatGPTNode struct {
+	Parent  string `json:"parent"`
+	Message *struct {
+		Author struct {
+			Role string `json:"role"`
+		} `json:"author"`
+		Content struct {
+			ContentType string            `json:"content_type"`
+			Parts       []json.RawMessage `json:"parts"`
+		} `json:"content"`
+	} `json:"message"`
+}
+
+// parseChatGPT reads the branch of a ChatGPT conversation that was shown
+// last, from its current node back to the root.
+func parseChatGPT(data []byte) (Conversation, error) {
+	var export struct {
+		Title       string                 `json:"title"`
+		CreateTime  float64                `json:"create_time"`
+		CurrentNode string                 `json:"current_node"`
+		Mapping     map[string]chatGPTNode `json:"mapping"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return Conversation{}, err
+	}
+
+	c := Conversation{Title: export.Title, Format: FormatChatGPT}
+	if export.CreateTime > 0 {
+		c.CreatedAt = time.Unix(int64(export.CreateTime), 0)
+	}
+
+	var turns []Turn
+	seen := make(map[string]bool)
+	for id := export.CurrentNode; id != "" && !seen[id]; id = export.Mapping[id].Parent {
+		seen[id] = true
+		msg := export.Mapping[id].Message
+		if msg == nil || (msg.Author.Role != "user" && msg.Author.Role != "assistant") || msg.Content.ContentType != "text" {
+			continue
+		}
+		var parts []string
+		for _, raw := range msg.Content.Parts {
+			var part string
+			// Images and other attachments are objects; only text is kept.
+			if json.Unmarshal(raw, &part) == nil && strings.TrimSpace(part) != "" {
+				parts = append(parts, strings.TrimSpace(part))
+			}
+		}
+		if len(parts) > 0 {
+			turns = append(turns, Turn{Role: msg.Author.Role, Text: strings.Join(parts, "\n\n")})
+		}
+	}
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	c.Turns = turns
+	return c, nil
+}
+
+// parseClaude reads a Claude conversation, whose messages are in order.
+func parseClaude(data []byte) (Conversation, error) {
+	var export struct {
+		Name         string    `json:"name"`
+		CreatedAt    time.Time `json:"created_at"`
+		ChatMessages []struct {
+			Sender  string `json:"sender"`
+			Text    string `json:"text"`
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"chat_messages"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return Conversation{}, err
+	}
+
+	c := Conversation{Title: export.Name, Format: FormatClaude, CreatedAt: export.CreatedAt}
+	for _, msg := range export.ChatMessages {
+		role := msg.Sender
+		if role == "human" {
+			role = "user"
+		}
+		if role != "user" && role != "assistant" {
+			continue
+		}
+		// Newer exports split a message into content blocks; older ones
+		// only have text.
+		var parts []string
+		for _, block := range msg.Content {
+			if block.Type == "text" && strings.TrimSpace(block.Text) != "" {
+				parts = append(parts, strings.TrimSpace(block.Text))
+			}
+		}
+		text := strings.Join(parts, "\n\n")
+		if text == "" {
+			text = strings.TrimSpace(msg.Text)
+		}
+		if text != "" {
+			c.Turns = append(c.Turns, Turn{Role: role, Text: text})
+		}
+	}
+	return c, nil
+}
+
+// Filter returns the conversations whose title contains query, ignoring
+// case. An empty query matches every conversation.
+func Filter(conversations []Conversation, query string) []Conversation {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matched []Conversation
+	for _, c := range conversations {
+		if strings.Contains(strings.ToLower(c.Title), query) {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// Markdown renders a conversation as a transcript.
+func (c Conversation) Markdown() string {
+	var sb strings.Builder
+	title := c.Title
+	if title == "" {
+		title = "Untitled conversation"
+	}
+	fmt.Fprintf(&sb, "# %s\n", title)
+	for _, turn := range c.Turns {
+		fmt.Fprintf(&sb, "\n**%s:** %s\n", speaker(turn.Role), turn.Text)
+	}
+	return sb.String()
+}
+
+func speaker(role string) string {
+	if role == "user" {
+		return "Author"
+	}
+	return "Assistant"
+}
+
+// Split renders conversations as transcripts in parts of at most
+// maxRunes, breaking only between turns. A turn longer than maxRunes is a
+// part of its own, cut to size.
+func Split(conversations []Conversation, maxRunes int) []string {
+	var parts []string
+	var current strings.Builder
+	size := 0
+	add := func(text string) {
+		n := len([]rune(text))
+		if size > 0 && size+n > maxRunes {
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+			size = 0
+		}
+		if n > maxRunes {
+			text = string([]rune(text)[:maxRunes])
+			n = maxRunes
+		}
+		current.WriteString(text)
+		size += n
+	}
+
+	for _, c := range conversations {
+		for i, turn := range c.Turns {
+			text := fmt.Sprintf("\n%s: %s\n", speaker(turn.Role), turn.Text)
+			if i == 0 {
+				// The title stays with the first turn.
+				text = fmt.Sprintf("\n\n## %s\n", c.Title) + text
+			}
+			add(text)
+		}
+	}
+	if size > 0 {
+		parts = append(parts, strings.TrimSpace(current.String()))
+	}
+	return parts
+}
+
+// extractionLead tells the model the setup description is a transcript,
+// where ideas are floated and dropped.
+const extractionLead = "The following is a brainstorming conversation between a novelist (Author) and an AI assistant about a story. Extract the story as the author settled on it: prefer what the author chose or confirmed over ideas that were only suggested, and later decisions over earlier ones. Do not invent anything the conversation does not say.\n\n"
+
+// Extract asks the model for the genre, setting, characters, plot
+// decisions and style the conversations settle on, one request per part
+// of at most partRunes, and merges the results. Progress, if set, is
+// called before each part.
+func Extract(ctx context.Context, provider llm.Provider, conversations []Conversation, partRunes int, progress func(part, total int)) (*types.ParsePromptResult, error) {
+	if partRunes <= 0 {
+		partRunes = DefaultPartRunes
+	}
+	parts := Split(conversations, partRunes)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("the conversations have no text")
+	}
+
+	parser := llm.NewPromptParser(provider)
+	var results []*types.ParsePromptResult
+	for i, part := range parts {
+		if progress != nil {
+			progress(i+1, len(parts))
+		}
+		result, err := parser.ParseSetupPrompt(ctx, extractionLead+part)
+		if err != nil {
+			return nil, fmt.Errorf("part %d of %d: %w", i+1, len(parts), err)
+		}
+		results = append(results, result)
+	}
+	return Merge(results), nil
+}
+
+// Merge combines extractions of consecutive parts of a transcript. Later
+// parts win where they disagree, since decisions made later in a
+// brainstorm replace earlier ones; characters are matched by name and
+// plot points are kept in order without repeats.
+func Merge(results []*types.ParsePromptResult) *types.ParsePromptResult {
+	merged := &types.ParsePromptResult{}
+	characters := make(map[string]int)
+	plotSeen := make(map[string]bool)
+	vocabSeen := make(map[string]bool)
+
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		merged.Genre = latest(merged.Genre, r.Genre)
+		merged.Setting.TimePeriod = latest(merged.Setting.TimePeriod, r.Setting.TimePeriod)
+		merged.Setting.Location = latest(merged.Setting.Location, r.Setting.Location)
+		merged.Setting.Description = latest(merged.Setting.Description, r.Setting.Description)
+		merged.StyleGuide.Tone = latest(merged.StyleGuide.Tone, r.StyleGuide.Tone)
+		merged.StyleGuide.Pacing = latest(merged.StyleGuide.Pacing, r.StyleGuide.Pacing)
+		merged.StyleGuide.Dialogue = latest(merged.StyleGuide.Dialogue, r.StyleGuide.Dialogue)
+		for _, note := range r.StyleGuide.Vocabulary {
+			if key := strings.ToLower(strings.TrimSpace(note)); key != "" && !vocabSeen[key] {
+				vocabSeen[key] = true
+				merged.StyleGuide.Vocabulary = append(merged.StyleGuide.Vocabulary, note)
+			}
+		}
+
+		for _, ch := range r.Characters {
+			key := strings.ToLower(strings.TrimSpace(ch.Name))
+			if key == "" {
+				continue
+			}
+			i, ok := characters[key]
+			if !ok {
+				characters[key] = len(merged.Characters)
+				ch.Traits = copyTraits(ch.Traits)
+				merged.Characters = append(merged.Characters, ch)
+				continue
+			}
+			existing := &merged.Characters[i]
+			existing.Role = latest(existing.Role, ch.Role)
+			existing.Description = latest(existing.Description, ch.Description)
+			if len(ch.Traits) > 0 && existing.Traits == nil {
+				existing.Traits = make(map[string]string)
+			}
+			for k, v := range ch.Traits {
+				existing.Traits[k] = v
+			}
+		}
+
+		for _, hint := range r.PlotHints {
+			if key := strings.ToLower(strings.TrimSpace(hint)); key != "" && !plotSeen[key] {
+				plotSeen[key] = true
+				merged.PlotHints = append(merged.PlotHints, hint)
+			}
+		}
+	}
+	return merged
+}
+
+// latest returns next unless it is empty.
+func latest(current, next string) string {
+	if strings.TrimSpace(next) != "" {
+		return next
+	}
+	return current
+}
+
+func copyTraits(traits map[string]string) map[string]string {
+	if traits == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(traits))
+	for k, v := range traits {
+		copied[k] = v
+	}
+	return copied
+}
+
+// Titles lists the titles of conversations, oldest first, for choosing
+// which to import.
+func Titles(conversations []Conversation) []string {
+	sorted := append([]Conversation(nil), conversations...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+	titles := make([]string, len(sorted))
+	for i, c := range sorted {
+		titles[i] = c.Title
+	}
+	return titles
+}
@@ -0,0 +1,156 @@
+package chatimport
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chatGPTExport has a regenerated reply: the branch through "b2" was
+// shown last, so "b1" is dropped.
+const chatGPTExport = `[{
+	"title": "Lighthouse novel",
+	"create_time": 1700000000.5,
+	"current_node": "c",
+	"mapping": {
+		"root": {"parent": null, "message": null},
+		"sys": {"parent": "root", "message": {"author": {"role": "system"}, "content": {"content_type": "text", "parts": ["You are ChatGPT"]}}},
+		"a": {"parent": "sys", "message": {"author": {"role": "user"}, "content": {"content_type": "text", "parts": ["A keeper named Hana?"]}}},
+		"b1": {"parent": "a", "message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["First try."]}}},
+		"b2": {"parent": "a", "message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["Yes, Hana keeps the light.", {"asset_pointer": "file-1"}]}}},
+		"c": {"parent": "b2", "message": {"author": {"role": "user"}, "content": {"content_type": "text", "parts": ["Great, keep it."]}}}
+	}
+}]`
+
+const claudeExport = `[{
+	"uuid": "1",
+	"name": "Storm plot",
+	"created_at": "2024-05-01T10:00:00Z",
+	"chat_messages": [
+		{"sender": "human", "text": "The storm hits in act two.", "content": []},
+		{"sender": "assistant", "text": "", "content": [{"type": "text", "text": "Then the ferry sinks."}, {"type": "tool_use"}]}
+	]
+}, {
+	"uuid": "2",
+	"name": "Empty",
+	"created_at": "2024-04-01T10:00:00Z",
+	"chat_messages": []
+}]`
+
+func TestParse(t *testing.T) {
+	conversations, err := Parse([]byte(chatGPTExport))
+	require.NoError(t, err)
+	require.Len(t, conversations, 1)
+	c := conversations[0]
+	assert.Equal(t, "Lighthouse novel", c.Title)
+	assert.Equal(t, FormatChatGPT, c.Format)
+	assert.Equal(t, []Turn{
+		{Role: "user", Text: "A keeper named Hana?"},
+		{Role: "assistant", Text: "Yes, Hana keeps the light."},
+		{Role: "user", Text: "Great, keep it."},
+	}, c.Turns)
+
+	conversations, err = Parse([]byte(claudeExport))
+	require.NoError(t, err)
+	require.Len(t, conversations, 1, "conversations without text are dropped")
+	assert.Equal(t, FormatClaude, conversations[0].Format)
+	assert.Equal(t, []Turn{
+		{Role: "user", Text: "The storm hits in act two."},
+		{Role: "assistant", Text: "Then the ferry sinks."},
+	}, conversations[0].Turns)
+	assert.Contains(t, conversations[0].Markdown(), "**Author:** The storm hits in act two.")
+
+	// A single conversation object is accepted too.
+	var one []json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(claudeExport), &one))
+	conversations, err = Parse(one[0])
+	require.NoError(t, err)
+	require.Len(t, conversations, 1)
+
+	_, err = Parse([]byte(`[{"title": "?"}]`))
+	assert.Error(t, err)
+	_, err = Parse([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestFilterAndSplit(t *testing.T) {
+	conversations := []Conversation{
+		{Title: "Lighthouse novel", Turns: []Turn{{Role: "user", Text: strings.Repeat("a", 40)}, {Role: "assistant", Text: strings.Repeat("b", 40)}}},
+		{Title: "Recipes", Turns: []Turn{{Role: "user", Text: "soup"}}},
+	}
+	assert.Len(t, Filter(conversations, "LIGHTHOUSE"), 1)
+	assert.Len(t, Filter(conversations, ""), 2)
+
+	parts := Split(conversations[:1], 80)
+	require.Len(t, parts, 2, "parts break between turns")
+	assert.Contains(t, parts[0], "Author: "+strings.Repeat("a", 40))
+	assert.Contains(t, parts[1], "Assistant: "+strings.Repeat("b", 40))
+}
+
+func TestMerge(t *testing.T) {
+	merged := Merge([]*types.ParsePromptResult{
+		{
+			Genre:      "fantasy",
+			Setting:    types.SettingInfo{Location: "Harbor town", Description: "Foggy."},
+			Characters: []types.CharacterInfo{{Name: "Hana", Role: "protagonist", Description: "A keeper.", Traits: map[string]string{"fear": "storms"}}},
+			PlotHints:  []string{"The light fails", "A ship is lost"},
+		},
+		{
+			Setting:    types.SettingInfo{Description: "Foggy and cold."},
+			Characters: []types.CharacterInfo{{Name: "hana", Description: "A retired keeper.", Traits: map[string]string{"hope": "the sea"}}, {Name: "Jun"}},
+			PlotHints:  []string{"a ship is lost", "Hana rows out"},
+		},
+	})
+
+	assert.Equal(t, "fantasy", merged.Genre)
+	assert.Equal(t, "Harbor town", merged.Setting.Location)
+	assert.Equal(t, "Foggy and cold.", merged.Setting.Description, "later decisions win")
+	require.Len(t, merged.Characters, 2)
+	assert.Equal(t, "protagonist", merged.Characters[0].Role)
+	assert.Equal(t, "A retired keeper.", merged.Characters[0].Description)
+	assert.Equal(t, map[string]string{"fear": "storms", "hope": "the sea"}, merged.Characters[0].Traits)
+	assert.Equal(t, []string{"The light fails", "A ship is lost", "Hana rows out"}, merged.PlotHints)
+}
+
+// setupProvider answers every extraction with the next canned setup as
+// structured output, recording the prompts.
+type setupProvider struct {
+	llm.Provider
+	replies []string
+	prompts []string
+}
+
+func (p *setupProvider) Capabilities() llm.Capabilities {
+	return llm.Capabilities{SupportsStructuredOutput: true}
+}
+
+func (p *setupProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.prompts = append(p.prompts, req.Messages[len(req.Messages)-1].Content)
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return &llm.ChatResponse{Message: llm.NewAssistantMessage(reply)}, nil
+}
+
+func TestExtract(t *testing.T) {
+	conversations, err := Parse([]byte(chatGPTExport))
+	require.NoError(t, err)
+
+	provider := &setupProvider{replies: []string{
+		`{"genre": "mystery", "setting": {"time_period": "", "location": "Cape", "description": ""}, "characters": [{"name": "Hana", "role": "protagonist", "description": "Keeper", "traits": {}}], "plot_hints": ["The light fails"], "style_guide": {"tone": "quiet", "pacing": "", "dialogue": "", "vocabulary_notes": []}}`,
+		`{"genre": "mystery", "setting": {"time_period": "", "location": "", "description": ""}, "characters": [], "plot_hints": ["Hana rows out"], "style_guide": {"tone": "", "pacing": "", "dialogue": "", "vocabulary_notes": []}}`,
+	}}
+	var seen []int
+	result, err := Extract(context.Background(), provider, conversations, 80, func(part, total int) { seen = append(seen, part) })
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, seen)
+	assert.Equal(t, "mystery", result.Genre)
+	assert.Equal(t, []string{"The light fails", "Hana rows out"}, result.PlotHints)
+	assert.True(t, strings.HasPrefix(provider.prompts[0], extractionLead))
+	assert.Contains(t, provider.prompts[0], "Author: A keeper named Hana?")
+}
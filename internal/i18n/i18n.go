@@ -0,0 +1,199 @@
+// Package i18n translates the command line's help, messages and prompts.
+// The catalogs are keyed by the English text, so a message without a
+// translation is shown in English.
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Language is a language the command line speaks.
+type Language string
+
+const (
+	English  Language = "en"
+	Korean   Language = "ko"
+	Japanese Language = "ja"
+)
+
+// Languages lists the supported languages.
+var Languages = []Language{English, Korean, Japanese}
+
+// catalogs holds the translations of each language but English.
+var catalogs = map[Language]map[string]string{
+	Korean:   korean,
+	Japanese: japanese,
+}
+
+// current is the language messages are translated into.
+var current = English
+
+// ParseLanguage parses a language code such as "ko", also accepting a
+// locale such as "ko_KR.UTF-8".
+func ParseLanguage(name string) (Language, error) {
+	code := strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexAny(code, "-_."); i >= 0 {
+		code = code[:i]
+	}
+	for _, lang := range Languages {
+		if code == string(lang) {
+			return lang, nil
+		}
+	}
+	return "", Errorf("unsupported language %q (use en, ko or ja)", name)
+}
+
+// SetLanguage sets the language messages are translated into.
+func SetLanguage(lang Language) {
+	current = lang
+}
+
+// Current returns the language messages are translated into.
+func Current() Language {
+	return current
+}
+
+// T translates a message. Whitespace around the message, such as the line
+// breaks of a Printf format, is kept and is not part of the key.
+func T(msg string) string {
+	catalog := catalogs[current]
+	key := strings.TrimSpace(msg)
+	translated, ok := catalog[key]
+	if !ok || key == "" {
+		return msg
+	}
+	start := strings.Index(msg, key)
+	return msg[:start] + translated + msg[start+len(key):]
+}
+
+// Sprintf formats the translation of format.
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Errorf is fmt.Errorf with the translation of format; %w wraps as usual.
+func Errorf(format string, args ...any) error {
+	return fmt.Errorf(T(format), args...)
+}
+
+// Printf prints the translation of format to standard output.
+func Printf(format string, args ...any) {
+	fmt.Printf(T(format), args...)
+}
+
+// Println prints the translation of msg and a line break to standard
+// output.
+func Println(msg string) {
+	fmt.Println(T(msg))
+}
+
+// Fprintf prints the translation of format to w.
+func Fprintf(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, T(format), args...)
+}
+
+// LocalizeCommand translates the help of root and every command under it:
+// the descriptions, examples, flag usages and the usage template, along
+// with cobra's own help and completion commands and flags.
+func LocalizeCommand(root *cobra.Command) {
+	if catalogs[current] == nil {
+		return
+	}
+	root.InitDefaultHelpCmd()
+	root.InitDefaultCompletionCmd()
+	root.InitDefaultVersionFlag()
+	if f := root.Flags().Lookup("version"); f != nil {
+		f.Usage = Sprintf("version for %s", root.DisplayName())
+	}
+	localize(root, make(map[*pflag.Flag]bool))
+	root.SetUsageTemplate(usageTemplate(root.UsageTemplate()))
+}
+
+// localize translates the help of cmd and its subcommands. Flags shared
+// through persistent flags are translated once.
+func localize(cmd *cobra.Command, seen map[*pflag.Flag]bool) {
+	cmd.Short = T(cmd.Short)
+	cmd.Long = T(cmd.Long)
+	cmd.Example = T(cmd.Example)
+
+	cmd.InitDefaultHelpFlag()
+	if f := cmd.Flags().Lookup("help"); f != nil && !seen[f] {
+		seen[f] = true
+		f.Usage = Sprintf("help for %s", cmd.DisplayName())
+	}
+	translate := func(f *pflag.Flag) {
+		if !seen[f] {
+			seen[f] = true
+			f.Usage = T(f.Usage)
+		}
+	}
+	cmd.Flags().VisitAll(translate)
+	cmd.PersistentFlags().VisitAll(translate)
+
+	for _, sub := range cmd.Commands() {
+		localize(sub, seen)
+	}
+}
+
+// usageTemplate translates the headings of cobra's usage template.
+func usageTemplate(tmpl string) string {
+	return strings.NewReplacer(
+		"Usage:", T("Usage:"),
+		"Aliases:", T("Aliases:"),
+		"Examples:", T("Examples:"),
+		"Available Commands:", T("Available Commands:"),
+		"Additional Commands:", T("Additional Commands:"),
+		"Global Flags:", T("Global Flags:"),
+		"Flags:", T("Flags:"),
+		"Additional help topics:", T("Additional help topics:"),
+		`Use "{{.CommandPath}} [command] --help" for more information about a command.`,
+		Sprintf(`Use "%s [command] --help" for more information about a command.`, "{{.CommandPath}}"),
+	).Replace(tmpl)
+}
+
+// cobraErrors match the errors cobra and pflag return for bad arguments
+// and flags, with the catalog key of each.
+var cobraErrors = []struct {
+	pattern *regexp.Regexp
+	format  string
+}{
+	{regexp.MustCompile(`^unknown command "(.*?)" for "(.*?)"`), `unknown command "%s" for "%s"`},
+	{regexp.MustCompile(`^accepts (\d+) arg\(s\), received (\d+)`), "accepts %s arg(s), received %s"},
+	{regexp.MustCompile(`^accepts at most (\d+) arg\(s\), received (\d+)`), "accepts at most %s arg(s), received %s"},
+	{regexp.MustCompile(`^accepts between (\d+) and (\d+) arg\(s\), received (\d+)`), "accepts between %s and %s arg(s), received %s"},
+	{regexp.MustCompile(`^requires at least (\d+) arg\(s\), only received (\d+)`), "requires at least %s arg(s), only received %s"},
+	{regexp.MustCompile(`^required flag\(s\) (.*) not set`), "required flag(s) %s not set"},
+	{regexp.MustCompile(`^unknown flag: (\S+)`), "unknown flag: %s"},
+	{regexp.MustCompile(`^unknown shorthand flag: '(.+?)' in (\S+)`), "unknown shorthand flag: '%s' in %s"},
+	{regexp.MustCompile(`^flag needs an argument: (.+)`), "flag needs an argument: %s"},
+	{regexp.MustCompile(`^invalid argument "(.*?)" for "(.*?)" flag: `), `invalid argument "%s" for "%s" flag: `},
+}
+
+// LocalizeError returns err's message, translating the errors cobra and
+// pflag return for bad arguments and flags. The messages of other errors
+// are translated where they are made.
+func LocalizeError(err error) string {
+	msg := err.Error()
+	if catalogs[current] == nil {
+		return msg
+	}
+	for _, e := range cobraErrors {
+		m := e.pattern.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		args := make([]any, len(m)-1)
+		for i, arg := range m[1:] {
+			args[i] = arg
+		}
+		msg = Sprintf(e.format, args...) + msg[len(m[0]):]
+		break
+	}
+	return strings.Replace(msg, "Did you mean this?", T("Did you mean this?"), 1)
+}
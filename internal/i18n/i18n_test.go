@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// use sets the language for the rest of the test.
+func use(t *testing.T, lang Language) {
+	t.Helper()
+	SetLanguage(lang)
+	t.Cleanup(func() { SetLanguage(English) })
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Deletion cancelled.", T("Deletion cancelled."))
+
+	use(t, Korean)
+	assert.Equal(t, "삭제를 취소했습니다.", T("Deletion cancelled."))
+	assert.Equal(t, "\n'dreamteller open %s' 명령으로 시작하세요!\n", T("\nRun 'dreamteller open %s' to start writing!\n"))
+	assert.Equal(t, "확인하려면 프로젝트 이름을 입력하세요: ", T("Type the project name to confirm: "))
+	assert.Equal(t, "Not in the catalog", T("Not in the catalog"))
+	assert.Equal(t, "  ", T("  "))
+
+	use(t, Japanese)
+	assert.Equal(t, "プロジェクト 'a' を /p に作成しました", Sprintf("Created project '%s' at %s", "a", "/p"))
+	assert.Equal(t, "ジャンル 'fantasy' のプロジェクト 'a' を /p に作成しました",
+		Sprintf("Created project '%s' with genre '%s' at %s", "a", "fantasy", "/p"))
+}
+
+func TestParseLanguage(t *testing.T) {
+	for name, want := range map[string]Language{
+		"en":          English,
+		"KO":          Korean,
+		"ko_KR.UTF-8": Korean,
+		"ja-JP":       Japanese,
+	} {
+		lang, err := ParseLanguage(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, lang, name)
+	}
+
+	_, err := ParseLanguage("fr")
+	assert.EqualError(t, err, `unsupported language "fr" (use en, ko or ja)`)
+	_, err = ParseLanguage("")
+	assert.Error(t, err)
+}
+
+func TestErrorfWraps(t *testing.T) {
+	use(t, Korean)
+	cause := errors.New("disk full")
+	err := Errorf("failed to create project: %w", cause)
+
+	assert.EqualError(t, err, "프로젝트를 만들지 못했습니다: disk full")
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestLocalizeCommand(t *testing.T) {
+	use(t, Korean)
+	root := &cobra.Command{Use: "dreamteller", Short: "A TUI application for writing novels with AI assistance"}
+	root.PersistentFlags().Bool("offline", false, "Disable all network calls; AI features are unavailable")
+	list := &cobra.Command{Use: "list", Short: "List all novel projects", Run: func(*cobra.Command, []string) {}}
+	list.Flags().Bool("force", false, "Delete without confirmation")
+	root.AddCommand(list)
+
+	LocalizeCommand(root)
+
+	assert.Equal(t, "모든 소설 프로젝트 나열", list.Short)
+	assert.Equal(t, "확인 없이 삭제", list.Flags().Lookup("force").Usage)
+	assert.Equal(t, "list 도움말", list.Flags().Lookup("help").Usage)
+	assert.Equal(t, "모든 네트워크 호출 끄기. AI 기능은 쓸 수 없습니다", root.PersistentFlags().Lookup("offline").Usage)
+
+	var out strings.Builder
+	root.SetOut(&out)
+	root.SetArgs([]string{"--help"})
+	require.NoError(t, root.Execute())
+	assert.Contains(t, out.String(), "사용 가능한 명령:")
+	assert.Contains(t, out.String(), "명령에 대한 도움말")
+	assert.Contains(t, out.String(), `명령에 대한 자세한 정보는 "dreamteller [command] --help"를 사용하세요.`)
+	assert.NotContains(t, out.String(), "Usage:")
+}
+
+func TestLocalizeError(t *testing.T) {
+	err := fmt.Errorf("accepts 1 arg(s), received 0")
+	assert.Equal(t, "accepts 1 arg(s), received 0", LocalizeError(err))
+
+	use(t, Korean)
+	assert.Equal(t, "인자 1개가 필요하지만 0개를 받았습니다", LocalizeError(err))
+	assert.Equal(t, "알 수 없는 플래그: --nope", LocalizeError(errors.New("unknown flag: --nope")))
+	assert.Equal(t, `"--limit" 플래그의 값 "x"이(가) 잘못되었습니다: strconv.ParseInt: parsing "x": invalid syntax`,
+		LocalizeError(errors.New(`invalid argument "x" for "--limit" flag: strconv.ParseInt: parsing "x": invalid syntax`)))
+
+	use(t, Japanese)
+	assert.Equal(t, "\"dreamteller\" に \"nope\" というコマンドはありません\n\nもしかして:\n\topen\n",
+		LocalizeError(errors.New("unknown command \"nope\" for \"dreamteller\"\n\nDid you mean this?\n\topen\n")))
+	assert.Equal(t, "プロジェクト 'x' が見つかりません", LocalizeError(Errorf("project '%s' not found", "x")))
+}
+
+// verbPattern matches a printf verb, with its explicit argument index.
+var verbPattern = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0]*\d*(?:\.\d+)?([a-zA-Z%])`)
+
+// verbs lists the verbs of a format by the argument each one formats.
+func verbs(format string) []string {
+	var list []string
+	arg := 0
+	for _, m := range verbPattern.FindAllStringSubmatch(format, -1) {
+		if m[2] == "%" {
+			continue
+		}
+		if m[1] != "" {
+			fmt.Sscan(m[1], &arg)
+		} else {
+			arg++
+		}
+		list = append(list, fmt.Sprintf("%d:%s", arg, m[2]))
+	}
+	sort.Strings(list)
+	return list
+}
+
+func TestCatalogs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, msg := range catalog {
+			assert.Equal(t, strings.TrimSpace(key), key, "%s: key has surrounding whitespace", lang)
+			assert.NotEmpty(t, msg, "%s: %q", lang, key)
+			assert.Equal(t, verbs(key), verbs(msg), "%s: verbs of %q", lang, key)
+		}
+		for key := range korean {
+			assert.Contains(t, catalog, key, "%s: missing", lang)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package i18n
+
+// japanese is the Japanese catalog.
+var japanese = map[string]string{
+	// Cobra's usage template, help and completion commands
+	"Usage:":                  "使い方:",
+	"Aliases:":                "別名:",
+	"Examples:":               "例:",
+	"Available Commands:":     "利用できるコマンド:",
+	"Additional Commands:":    "その他のコマンド:",
+	"Flags:":                  "フラグ:",
+	"Global Flags:":           "グローバルフラグ:",
+	"Additional help topics:": "その他のヘルプトピック:",
+	"Use \"%s [command] --help\" for more information about a command.": "コマンドの詳細は \"%s [command] --help\" で確認できます。",
+	"help for %s":            "%s のヘルプ",
+	"version for %s":         "%s のバージョン",
+	"Help about any command": "コマンドのヘルプ",
+	"Help provides help for any command in the application.\nSimply type dreamteller help [path to command] for full details.": "アプリケーションのすべてのコマンドのヘルプを表示します。\n詳しくは dreamteller help [コマンドのパス] と入力してください。",
+	"Generate the autocompletion script for the specified shell":                                                               "指定したシェルの補完スクリプトを生成",
+	"Generate the autocompletion script for bash":                                                                              "bash の補完スクリプトを生成",
+	"Generate the autocompletion script for zsh":                                                                               "zsh の補完スクリプトを生成",
+	"Generate the autocompletion script for fish":                                                                              "fish の補完スクリプトを生成",
+	"Generate the autocompletion script for powershell":                                                                        "powershell の補完スクリプトを生成",
+	"disable completion descriptions":                                                                                          "補完の説明を表示しない",
+
+	// Cobra's and pflag's errors
+	"Error:":                                        "エラー:",
+	"unknown command \"%s\" for \"%s\"":             "\"%[2]s\" に \"%[1]s\" というコマンドはありません",
+	"accepts %s arg(s), received %s":                "引数は %s 個必要ですが %s 個渡されました",
+	"accepts at most %s arg(s), received %s":        "引数は最大 %s 個ですが %s 個渡されました",
+	"accepts between %s and %s arg(s), received %s": "引数は %s～%s 個必要ですが %s 個渡されました",
+	"requires at least %s arg(s), only received %s": "引数は %s 個以上必要ですが %s 個しか渡されていません",
+	"required flag(s) %s not set":                   "必須フラグ %s が指定されていません",
+	"unknown flag: %s":                              "不明なフラグ: %s",
+	"unknown shorthand flag: '%s' in %s":            "%[2]s の不明な短縮フラグ '%[1]s'",
+	"flag needs an argument: %s":                    "フラグに値が必要です: %s",
+	"invalid argument \"%s\" for \"%s\" flag:":      "\"%[2]s\" フラグの値 \"%[1]s\" が不正です:",
+	"Did you mean this?":                            "もしかして:",
+	"unsupported language %q (use en, ko or ja)":    "未対応の言語 %q（en、ko、ja のいずれか）",
+
+	// Commands
+	"A TUI application for writing novels with AI assistance": "AI と一緒に小説を書く TUI アプリケーション",
+	"Dreamteller is a terminal-based application that helps you write novels\nwith AI assistance. It provides context-aware suggestions based on your\ncharacters, settings, and plot points.": "Dreamteller は AI の助けを借りて小説を書くターミナルアプリケーションです。\n登場人物、舞台設定、プロットをもとに文脈に沿った提案をします。",
+	"Create a new novel project":       "新しい小説プロジェクトを作成",
+	"List all novel projects":          "すべての小説プロジェクトを一覧表示",
+	"Open a novel project in TUI mode": "小説プロジェクトを TUI モードで開く",
+	"Open a novel project in TUI mode.\n\nThe argument is either a project name inside the projects directory or a\npath to a project directory (absolute, ~/..., ./... or ../...).": "小説プロジェクトを TUI モードで開きます。\n\n引数はプロジェクトディレクトリ内のプロジェクト名か、プロジェクト\nディレクトリへのパス（絶対パス、~/...、./...、../...）です。",
+	"Delete a novel project":                      "小説プロジェクトを削除",
+	"Edit global configuration":                   "グローバル設定を編集",
+	"Export a novel to a specific format":         "小説を指定した形式でエクスポート",
+	"Export a novel to epub, pdf, or txt format.": "小説を epub、pdf、txt 形式でエクスポートします。",
+	"Rebuild the search index for a project":      "プロジェクトの検索インデックスを再構築",
+	"Configure LLM provider authentication":       "LLM プロバイダーの認証を設定",
+	"Configure LLM provider authentication.\n\nA provider given as an argument is the same as --provider.\n\nWith --test, send a tiny request to the given provider (or the default one)\nand report latency, model availability, and tool-call support.": "LLM プロバイダーの認証を設定します。\n\n引数で指定したプロバイダーは --provider と同じです。\n\n--test を付けると、指定したプロバイダー（省略時は既定のもの）に小さな\nリクエストを送り、応答時間、モデルの有無、ツール呼び出し対応を報告します。",
+	"List configured providers":               "設定済みのプロバイダーを一覧表示",
+	"Remove a provider configuration":         "プロバイダーの設定を削除",
+	"Run an LLM operation over many chapters": "複数の章に LLM の処理を実行",
+	"Summarize, lint, check against the rulebook, classify scenes for pacing or\ntranslate a range of chapters, writing one result per chapter under batch/<op>/\nin the project. Progress is checkpointed after every chapter, so an interrupted run resumes where it stopped when the same command\nis run again. Chapters edited since they were processed are done again.": "章の範囲を要約、チェック、ルールブックとの照合、ペーシング用のシーン分類、\nまたは翻訳し、章ごとに結果を一つプロジェクトの batch/<op>/ に書き出します。\n章ごとに進捗を保存するため、中断した実行は同じコマンドを再実行すると止まった\nところから再開します。処理後に編集された章は処理し直します。",
+	"Translate the manuscript into another language": "原稿を別の言語に翻訳",
+	"Translate chapters into translations/<language>/, one file per chapter, leaving\nthe originals untouched. Names and invented terms are kept consistent through a\ntranslation memory at translations/<language>/memory.md, which you can edit.\nLike batch, an interrupted run resumes where it stopped.": "章を translations/<language>/ に章ごとに一つのファイルとして翻訳し、原文は\nそのまま残します。名前や造語は編集できる翻訳メモリ\ntranslations/<language>/memory.md で統一します。\nbatch と同じく、中断した実行は止まったところから再開します。",
+	"Check chapters for misspelled glossary terms":                                                                                                                         "章の用語集の表記ゆれを確認",
+	"Compare words in every chapter against the invented terms in context/glossary and report likely misspellings.":                                                        "すべての章の単語を context/glossary の造語と照らし合わせ、誤記らしきものを報告します。",
+	"Report crutch words and repeated phrases in chapters":                                                                                                                 "章の口癖語と繰り返し表現を報告",
+	"Count crutch words (built-in plus writing.crutch_words) and the most repeated phrases in every chapter, with a per-chapter heatmap.":                                  "すべての章の口癖語（組み込みの一覧と writing.crutch_words）と最も多く繰り返された表現を数え、章ごとのヒートマップで示します。",
+	"Find unused, stale or empty story bible entries":                                                                                                                      "使われていない、古い、または空の設定項目を探す",
+	"List context files no chapter references, characters missing from the latest chapters, and settings with empty descriptions.":                                         "どの章からも参照されないコンテキストファイル、最近の章に登場しない人物、説明が空の舞台設定を一覧表示します。",
+	"Chart character arcs and long absences":                                                                                                                               "登場人物のアークと長い不在をチャートで表示",
+	"Show each character's recorded state and goal per chapter (the Arc section of their file) as a chart, and warn about characters missing from many chapters in a row.": "登場人物ごとに章別に記録された状態と目標（ファイルの Arc セクション）をチャートで表示し、何章も続けて登場しない人物を警告します。",
+	"Report subplot coverage and interleaving":                                                                                                                             "サブプロットの分布と織り交ぜ方を報告",
+	"Chart which chapters touch each subplot (tagged with <!-- subplot: id --> comments) and suggest where to weave back subplots left untouched for many chapters.":       "各サブプロット（<!-- subplot: id --> コメントで印を付けたもの）がどの章に出てくるかをチャートで示し、何章も触れられていないサブプロットを再び織り込む場所を提案します。",
+	"Chart scene lengths, reading time and action vs. reflection":                                                                                                          "シーンの長さ、読了時間、アクションと内省の比率をチャートで表示",
+	"Split every chapter into scenes at scene breaks (***, ---, #) and chart their\nlengths, reading times and, once 'batch --op pacing' has classified them,\nthe share of action against reflection, flagging sagging middle chapters.\n--svg also writes the chart to exports/pacing.svg.": "すべての章をシーン区切り（***、---、#）でシーンに分け、長さと読了時間、\nさらに 'batch --op pacing' で分類した後はアクションと内省の比率をチャートで示し、\n中盤のたるんだ章に印を付けます。\n--svg を付けるとチャートを exports/pacing.svg にも書き出します。",
+	"Snapshot character facts and the timeline, then report what a rewrite changed": "登場人物の事実とタイムラインのスナップショットを取り、改稿で変わった点を報告",
+	"Compare the character facts, remembered facts and plot timeline against a\ncontinuity snapshot, and list the chapters that still refer to facts that\nchanged since.\n\nTake a snapshot with --snapshot before a major rewrite. Without --snapshot,\ncompare against the snapshot named by --name, or the latest one.": "登場人物の事実、記憶された事実、プロットのタイムラインを連続性スナップショットと\n比較し、その後変わった事実をまだ参照している章を一覧表示します。\n\n大きな改稿の前に --snapshot でスナップショットを取ってください。--snapshot なしでは\n--name で指定したスナップショット、または最新のものと比較します。",
+	"Export annotations, lint findings and reviewer feedback": "注釈、チェック結果、レビュアーのフィードバックをエクスポート",
+	"Gather the reviewer persona annotations, the results of 'batch --op lint' and\n'batch --op rules' and the glossary misspellings into one document under exports/, as a Markdown\nchecklist or CSV, for an offline editing pass.": "レビュアーペルソナの注釈、'batch --op lint' と 'batch --op rules' の結果、用語集の誤記を\nexports/ の下の一つの文書に Markdown のチェックリストか CSV としてまとめ、オフラインの推敲に使います。",
+	"Draft a scene from a beat of the outline": "アウトラインのビートからシーンの下書きを作成",
+	"Plan a scene for a beat of the plot outline: its goal, conflict, disaster and\nsequel, written with the characters and places the beat mentions and the end of\nthe manuscript so far. --beat 2 names the second plot file, --beat 2.3 the third\nsection or list item in it. With --prose, a first draft of the scene follows\nthe scaffold. The result is written to a new file under scenes/; chapters are\nnever changed.": "プロットのアウトラインのビートに沿って、シーンの目標、葛藤、破局、余波を計画します。\nビートに出てくる登場人物と場所、これまでの原稿の終わりも踏まえます。--beat 2 は\n二番目のプロットファイル、--beat 2.3 はその中の三番目のセクションかリスト項目を指します。\n--prose を付けると、骨組みの後にシーンの初稿が続きます。結果は scenes/ の下の新しい\nファイルに書き出し、章は変更しません。",
+	"Show what the search index holds for a project or one file": "プロジェクトまたは一つのファイルの検索インデックスの内容を表示",
+	"Inspect a project's search index":                           "プロジェクトの検索インデックスを調べる",
+	"List every indexed file with its chunk count, tokens and the modification\ntime it was indexed at, flagging files changed or removed since. Given a file,\nshow each of its chunks with its tokens and section, to see why a passage is\nor is not retrieved.": "インデックス済みのすべてのファイルをチャンク数、トークン数、インデックス時の更新時刻と\nともに一覧表示し、その後変更または削除されたファイルに印を付けます。ファイルを指定すると\n各チャンクをトークン数とセクションとともに表示し、ある文章が検索される理由、されない理由がわかります。",
+	"Show the request a chat message would send": "チャットメッセージが送るリクエストを表示",
+	"Assemble the request the chat would send for a message after the project's\nsaved conversation, as opening the project and sending it would, and print it\nwithout calling the provider: the system prompt, the retrieved chunks and why\neach was chosen, the history that fits the budget, and token counts. Use it to\ndebug prompts or attach to a bug report.": "プロジェクトを開いて保存済みの会話の後にメッセージを送るときと同じように、チャットが\n送るリクエストを組み立て、プロバイダーを呼ばずに表示します。システムプロンプト、検索された\nチャンクとそれぞれが選ばれた理由、予算に収まる履歴、トークン数を示します。プロンプトの\nデバッグやバグ報告への添付に使ってください。",
+	"Group books into a series sharing a world bible": "世界設定を共有するシリーズに本をまとめる",
+	"Show the books of a series and check them for inconsistencies.\n\nWith --join or --leave, add a project to the series or remove it. Books in a\nseries share the characters, settings and glossary under\n<projects-dir>/.series/<series>/, which each book sees as series/. With\n--search, search every book's index at once.": "シリーズの本を表示し、矛盾がないか確認します。\n\n--join または --leave でプロジェクトをシリーズに加えたり外したりします。シリーズの本は\n<projects-dir>/.series/<series>/ の下の登場人物、舞台設定、用語集を共有し、\n各本からは series/ として見えます。--search ですべての本のインデックスを一度に検索します。",
+	"Replace straight quotes, double hyphens and dots with typographic punctuation": "ストレートクォート、二重ハイフン、ドットを組版用の約物に置き換える",
+	"Normalize the punctuation of the chapters: curly quotes, em dashes and\nellipses, with the rules of the manuscript's language. French uses guillemets\nand non-breaking spaces, Korean drops stray spaces before punctuation, and\nJapanese uses corner brackets and full-width marks. Code, comments and links\nare left alone.\n\nThe language defaults to the project's language setting.": "原稿の言語の規則に従って章の約物（カーリークォート、ダッシュ、三点リーダー）を\n整えます。フランス語はギュメとノーブレークスペース、韓国語は約物の前の余計な空白の\n削除、日本語はかぎ括弧と全角記号を使います。コード、コメント、リンクは\nそのままです。\n\n言語の既定値はプロジェクトの言語設定です。",
+
+	// Flags
+	"Projects root directory (overrides $DREAMTELLER_PROJECTS_DIR and config)":                      "プロジェクトのルートディレクトリ（$DREAMTELLER_PROJECTS_DIR と設定より優先）",
+	"Disable all network calls; AI features are unavailable":                                        "すべてのネットワーク通信を無効にする（AI 機能は使えません）",
+	"Language of help, messages and prompts: en, ko or ja (overrides the language config)":          "ヘルプ、メッセージ、確認の言語: en、ko、ja（language 設定より優先）",
+	"Persist a new projects root directory":                                                         "新しいプロジェクトのルートディレクトリを保存",
+	"Persist the language of help, messages and prompts: en, ko or ja":                              "ヘルプ、メッセージ、確認の言語を保存: en、ko、ja",
+	"Path to prompt file for one-shot setup (use '-' for stdin)":                                    "一括設定用のプロンプトファイルのパス（標準入力は '-'）",
+	"Genre for quick project creation without wizard":                                               "ウィザードなしでプロジェクトをすばやく作るときのジャンル",
+	"Path to a ChatGPT or Claude export (conversations.json) to seed the project from":              "プロジェクトの元にする ChatGPT または Claude のエクスポート（conversations.json）のパス",
+	"Import only conversations whose title contains this text":                                      "タイトルにこのテキストを含む会話だけを取り込む",
+	"Delete without confirmation":                                                                   "確認せずに削除",
+	"Configure a specific provider":                                                                 "特定のプロバイダーを設定",
+	"Send a test request to a provider and report latency and capabilities":                         "プロバイダーにテストリクエストを送り、応答時間と機能を報告",
+	"Local server preset: ollama, lmstudio, llamacpp, vllm, textgen":                                "ローカルサーバーのプリセット: ollama、lmstudio、llamacpp、vllm、textgen",
+	"Operation to run: summarize, lint, rules, pacing or translate":                                 "実行する処理: summarize、lint、rules、pacing、translate",
+	"Chapters to process, e.g. 1-10 or 1-3,7 (default: all)":                                        "処理する章、例: 1-10 や 1-3,7（既定: すべて）",
+	"Target language for --op translate, e.g. ja":                                                   "--op translate の翻訳先の言語、例: ja",
+	"Discard saved progress and process every chapter again":                                        "保存した進捗を破棄し、すべての章を処理し直す",
+	"Maximum requests per minute (0 for no limit)":                                                  "1 分あたりの最大リクエスト数（0 で無制限）",
+	"Target language, e.g. ja or en":                                                                "翻訳先の言語、例: ja や en",
+	"Chapters to translate, e.g. 1-10 (default: all)":                                               "翻訳する章、例: 1-10（既定: すべて）",
+	"Discard saved progress and translate every chapter again":                                      "保存した進捗を破棄し、すべての章を翻訳し直す",
+	"Typography rules applied to the text (default: the project's language)":                        "テキストに適用する組版規則（既定: プロジェクトの言語）",
+	"Typography rules: en, fr, ko or ja (default: the project's language)":                          "組版規則: en、fr、ko、ja（既定: プロジェクトの言語）",
+	"Chapters to fix, e.g. 1-3,7 (default: all)":                                                    "修正する章、例: 1-3,7（既定: すべて）",
+	"List the chapters that would change without writing them":                                      "書き込まずに変更される章を一覧表示",
+	"Warn about characters missing from this many chapters in a row":                                "この数だけ続けて章に登場しない人物を警告",
+	"Flag characters missing from this many of the latest chapters":                                 "最近のこの数の章に登場しない人物に印を付ける",
+	"Warn about subplots untouched for this many chapters in a row":                                 "この数だけ続けて触れられていないサブプロットを警告",
+	"Also export the chart to exports/pacing.svg":                                                   "チャートを exports/pacing.svg にもエクスポート",
+	"Save a continuity snapshot instead of comparing against one":                                   "スナップショットと比較する代わりに連続性スナップショットを保存",
+	"Snapshot name (default: the current time when saving, the latest when comparing)":              "スナップショット名（既定: 保存時は現在時刻、比較時は最新のもの）",
+	"Document format: md or csv":                                                                    "文書の形式: md または csv",
+	"Export one chapter's feedback (default: the whole book)":                                       "一つの章のフィードバックだけをエクスポート（既定: 本全体）",
+	"Outline beat to draft, e.g. 2 or 2.3":                                                          "下書きするアウトラインのビート、例: 2 や 2.3",
+	"Also write a first draft of the scene's prose":                                                 "シーンの本文の初稿も書く",
+	"Print the request instead of sending it (required)":                                            "リクエストを送らずに表示（必須）",
+	"The chat message to assemble a request for":                                                    "リクエストを組み立てるチャットメッセージ",
+	"Context mode: essential, hybrid or full":                                                       "コンテキストモード: essential、hybrid、full",
+	"Add a project to the series":                                                                   "プロジェクトをシリーズに追加",
+	"Remove a project from the series":                                                              "プロジェクトをシリーズから外す",
+	"Search every book in the series":                                                               "シリーズのすべての本を検索",
+	"Rewrite files with CRLF line endings, a byte order mark or a legacy encoding as UTF-8 with LF": "CRLF の改行、BOM、古い文字コードのファイルを LF の UTF-8 に書き直す",
+	"Show under each reply why each retrieved context chunk was chosen (same as /explain)":          "返答ごとに、検索されたコンテキストのチャンクが選ばれた理由を下に表示（/explain と同じ）",
+	"Append every LLM exchange to a replay log (JSON Lines)":                                        "すべての LLM のやり取りをリプレイログ（JSON Lines）に追記",
+	"Serve canned responses from a replay log (JSON Lines) instead of a provider":                   "プロバイダーの代わりにリプレイログ（JSON Lines）の記録済み応答を使う",
+
+	// Project setup
+	"How would you like to set up your project?":   "プロジェクトの設定方法を選んでください",
+	"Wizard - Guided step-by-step setup":           "ウィザード - ステップバイステップのガイド設定",
+	"Prompt - Describe your story and auto-create": "プロンプト - ストーリーを説明して自動作成",
+	"Template - Start from a preset (coming soon)": "テンプレート - プリセットから開始（準備中）",
+	"Select your genre":                            "ジャンルを選択してください",
+	"Describe your writing style":                  "文体を説明してください",
+	"e.g., descriptive, immersive, fast-paced":     "例：描写的、没入感のある、テンポが速い",
+	"Point of View":                                "視点",
+	"Tense":                                        "時制",
+	"Content Rating":                               "コンテンツレーティング",
+	"Fantasy":                                      "ファンタジー",
+	"Science Fiction":                              "SF（サイエンスフィクション）",
+	"Mystery":                                      "ミステリー",
+	"Romance":                                      "ロマンス",
+	"Thriller":                                     "スリラー",
+	"Horror":                                       "ホラー",
+	"Historical Fiction":                           "歴史小説",
+	"Literary Fiction":                             "純文学",
+	"Other":                                        "その他",
+	"First Person":                                 "一人称",
+	"Third Person Limited":                         "三人称限定",
+	"Third Person Omniscient":                      "三人称全知",
+	"Second Person":                                "二人称",
+	"Past Tense":                                   "過去形",
+	"Present Tense":                                "現在形",
+	"No rating":                                    "レーティングなし",
+	"Young Adult":                                  "ヤングアダルト",
+	"No Graphic Violence":                          "過激な暴力描写なし",
+	"Adult":                                        "成人向け",
+	"Describe your story":                          "ストーリーを説明してください",
+	"Include details about genre, setting, characters, and plot ideas.": "ジャンル、舞台、登場人物、プロットのアイデアを詳しく書いてください。",
+	"Write a detailed description of your novel idea...":                "小説のアイデアを詳しく説明してください...",
+	"Created project '%s' at %s":                                        "プロジェクト '%s' を %s に作成しました",
+	"Created project '%s' with genre '%s' at %s":                        "ジャンル '%[2]s' のプロジェクト '%[1]s' を %[3]s に作成しました",
+	"Run 'dreamteller open %s' to start writing!":                       "'dreamteller open %s' で開始してください！",
+	"Genre: %s":                     "ジャンル: %s",
+	"Style: %s":                     "文体: %s",
+	"POV: %s, Tense: %s":            "視点: %s、時制: %s",
+	"Content Rating: %s":            "コンテンツレーティング: %s",
+	"Template mode is coming soon!": "テンプレートモードは準備中です！",
+	"Please use Wizard or Prompt mode for now.": "今はウィザードかプロンプトモードを使ってください。",
+	"Analyzing your story description...":       "ストーリーの説明を分析しています...",
+	"Creating project structure...":             "プロジェクトの構成を作成しています...",
+	"Characters: %d created":                    "登場人物: %d 件作成",
+	"Setting: created":                          "舞台設定: 作成済み",
+	"Plot hints: %d created":                    "プロットのヒント: %d 件作成",
+	"Reading %d conversation(s)...":             "会話を %d 件読み込んでいます...",
+	"The export has %d conversations:":          "エクスポートには会話が %d 件あります:",
+	"Transcripts: %d saved to %s/":              "会話記録: %[2]s/ に %[1]d 件保存",
+	"part %d of %d":                             "%[2]d 件中 %[1]d 件目",
+
+	// Messages
+	"No projects found. Create one with: dreamteller new <name>": "プロジェクトがありません。次のコマンドで作成してください: dreamteller new <name>",
+	"Projects in %s:": "%s のプロジェクト:",
+	"This will permanently delete project '%s' and all its files.": "プロジェクト '%s' とそのすべてのファイルが完全に削除されます。",
+	"Type the project name to confirm:":                            "確認のためプロジェクト名を入力してください:",
+	"Deletion cancelled.":                                          "削除を取り消しました。",
+	"Project '%s' deleted.":                                        "プロジェクト '%s' を削除しました。",
+	"Config file: %s":                                              "設定ファイル: %s",
+	"Projects directory: %s":                                       "プロジェクトディレクトリ: %s",
+	"Projects directory set to %s":                                 "プロジェクトディレクトリを %s に設定しました",
+	"Language: %s":                                                 "言語: %s",
+	"Language set to %s":                                           "言語を %s に設定しました",
+	"Configuration editor not yet implemented.":                    "設定エディターはまだ実装されていません。",
+	"Edit %s manually.":                                            "%s を直接編集してください。",
+	"Exporting '%s' to %s format...":                               "'%s' を %s 形式でエクスポートしています...",
+	"Exported '%s' to %s":                                          "'%s' を %s にエクスポートしました",
+	"Reindexing project '%s'...":                                   "プロジェクト '%s' のインデックスを再構築しています...",
+	"Reindex complete. Indexed %d chunks.":                         "インデックスの再構築が完了しました。%d 個のチャンクをインデックスしました。",
+	"Converted %d file(s) to UTF-8 with LF line endings:":          "%d 個のファイルを LF 改行の UTF-8 に変換しました:",
+	"%d file(s) are not UTF-8 with LF line endings (rewrite them with --normalize):": "%d 個のファイルが LF 改行の UTF-8 ではありません（--normalize で書き直してください）:",
+	"Configured providers:":                           "設定済みのプロバイダー:",
+	"No providers configured.":                        "設定済みのプロバイダーはありません。",
+	"Run 'dreamteller auth' to configure a provider.": "'dreamteller auth' でプロバイダーを設定してください。",
+	"Run 'dreamteller auth' to set up a provider.":    "'dreamteller auth' でプロバイダーを設定してください。",
+	"Provider '%s' removed.":                          "プロバイダー '%s' を削除しました。",
+	"(default)":                                       "（既定）",
+	"(current: %s)":                                   "（現在: %s）",
+	"(recommended)":                                   "（おすすめ）",
+	"API Key: %s":                                     "API キー: %s",
+	"Model: %s":                                       "モデル: %s",
+	"Base URL: %s":                                    "ベース URL: %s",
+	"Preset: %s":                                      "プリセット: %s",
+	"Select provider to configure":                    "設定するプロバイダーを選択してください",
+	"Local (Ollama/LM Studio)":                        "ローカル（Ollama/LM Studio）",
+	"Set as default provider?":                        "既定のプロバイダーにしますか？",
+	"Default model":                                   "既定のモデル",
+	"Get from ai.google.dev":                          "ai.google.dev で取得",
+	"Custom server":                                   "カスタムサーバー",
+	"Server":                                          "サーバー",
+	"Protocol":                                        "プロトコル",
+	"OpenAI Compatible":                               "OpenAI 互換",
+	"Anthropic Compatible":                            "Anthropic 互換",
+	"Gemini Compatible":                               "Gemini 互換",
+	"Base URL":                                        "ベース URL",
+	"Model name":                                      "モデル名",
+	"llama3, mistral, etc.":                           "llama3、mistral など",
+	"Select model":                                    "モデルを選択してください",
+	"Please enter model name manually.":               "モデル名を手動で入力してください。",
+	"Model %s not found on %s, pulling...":            "%[2]s にモデル %[1]s がないため取得しています...",
+	"✓ Pulled %s":                                     "✓ %s を取得しました",
+	"✓ %s configured successfully":                    "✓ %s を設定しました",
+	"⚠ Could not fetch models from %s: %v":            "⚠ %s からモデルの一覧を取得できませんでした: %v",
+	"⚠ No models found. Please make a model available first:":             "⚠ モデルが見つかりません。先にモデルを用意してください:",
+	"⚠ No API key configured for %s.":                                     "⚠ %s の API キーが設定されていません。",
+	"⚠ No LLM provider configured.":                                       "⚠ LLM プロバイダーが設定されていません。",
+	"Testing %s":                                                          "%s をテストしています",
+	"✓ Chat: responded in %s":                                             "✓ チャット: %s で応答",
+	"✗ Chat: %v":                                                          "✗ チャット: %v",
+	"✗ Authentication: API key rejected":                                  "✗ 認証: API キーが拒否されました",
+	"✓ Model: %s is available":                                            "✓ モデル: %s を利用できます",
+	"✗ Model: %s is not available":                                        "✗ モデル: %s は利用できません",
+	"✓ Tools: tool calls work":                                            "✓ ツール: ツール呼び出しが動作します",
+	"⚠ Tools: advertised but the model did not call the tool":             "⚠ ツール: 対応とされていますが、モデルがツールを呼び出しませんでした",
+	"⚠ Tools: request failed: %v":                                         "⚠ ツール: リクエストに失敗しました: %v",
+	"- Tools: not supported natively (suggestions use the text fallback)": "- ツール: ネイティブ非対応（提案はテキストの代替方式を使用）",
+	"Running %s with %s...":                                               "%[2]s で %[1]s を実行しています...",
+	"- Chapter %d (%s) already done":                                      "- 第 %d 章（%s）処理済み",
+	"✓ Chapter %d (%s) → %s":                                              "✓ 第 %d 章（%s）→ %s",
+	"✗ Chapter %d (%s): %v":                                               "✗ 第 %d 章（%s）: %v",
+	"⚠ formatting: %s":                                                    "⚠ 書式: %s",
+	"Done. Results are in %s":                                             "完了しました。結果は %s にあります",
+	"Interrupted. Run the same command again to resume.":                  "中断しました。同じコマンドを再実行すると再開します。",
+	"Stopped. Run the same command again to resume.":                      "停止しました。同じコマンドを再実行すると再開します。",
+	"Translation memory: %s":                                              "翻訳メモリ: %s",
+	"No misspelled glossary terms found.":                                 "用語集の誤記は見つかりませんでした。",
+	"%s:%d: %q → did you mean %q?":                                        "%s:%d: %q → %q ではありませんか？",
+	"%d possible misspelling(s).":                                         "誤記の可能性 %d 件。",
+	"Typography is already clean.":                                        "組版はすでに整っています。",
+	"Fixed typography (%s) in %d chapter(s): %s":                          "組版（%[1]s）を %[2]d 章で修正しました: %[3]s",
+	"Would fix typography (%s) in %d chapter(s): %s":                      "組版（%[1]s）を %[2]d 章で修正します: %[3]s",
+	"Exported the chart to %s":                                            "チャートを %s にエクスポートしました",
+	"Saved continuity snapshot '%s': %d fact(s), %d timeline event(s).":   "連続性スナップショット '%s' を保存しました: 事実 %d 件、タイムラインの出来事 %d 件。",
+	"No inconsistencies found.":                                           "矛盾は見つかりませんでした。",
+	"(removed since)":                                                     "（その後削除）",
+	"(changed since)":                                                     "（その後変更）",
+	"Exported %d item(s) to %s":                                           "%[1]d 件の項目を %[2]s にエクスポートしました",
+	"Planning beat %s (%s) with %s...":                                    "%[3]s でビート %[1]s（%[2]s）を計画しています...",
+	"Drafting the scene...":                                               "シーンを下書きしています...",
+	"Goal: %s\nConflict: %s\nDisaster: %s\nSequel: %s":                    "目標: %s\n葛藤: %s\n破局: %s\n余波: %s",
+	"Scene written to %s":                                                 "シーンを %s に書き出しました",
+	"The index is empty. Run 'dreamteller reindex %s' to build it.":       "インデックスが空です。'dreamteller reindex %s' で作成してください。",
+	"The index was built with other settings (%s); run 'dreamteller reindex %s' to rebuild it.": "インデックスは別の設定（%s）で作られています。'dreamteller reindex %s' で再構築してください。",
+	"%d file(s), %d chunk(s), %d tokens.":                                                       "ファイル %d 個、チャンク %d 個、%d トークン。",
+	"%d file(s) changed since indexing; run 'dreamteller reindex %s'.":                          "%d 個のファイルがインデックス後に変更されました。'dreamteller reindex %s' を実行してください。",
+	"%s is not in the project":                                                                  "%s はプロジェクトにありません",
+	"%s is not indexed. Run 'dreamteller reindex %s' to index it.":                              "%s はインデックスされていません。'dreamteller reindex %s' でインデックスしてください。",
+	"%s (%s): %d chunk(s), %d tokens, indexed as of %s":                                         "%s（%s）: チャンク %d 個、%d トークン、%s 時点でインデックス",
+	"The file changed since (%s); run 'dreamteller reindex %s'.":                                "その後ファイルが変更されました（%s）。'dreamteller reindex %s' を実行してください。",
+	"The file was removed since; run 'dreamteller reindex %s'.":                                 "その後ファイルが削除されました。'dreamteller reindex %s' を実行してください。",
+	"#%d %s %d tokens":                            "#%d %s %d トークン",
+	"%-48s %-10s %3d chunk(s) %6d tokens  %s%s":   "%-48s %-10s %3d チャンク %6d トークン  %s%s",
+	"Run 'dreamteller reindex %s' to index them.": "'dreamteller reindex %s' でインデックスしてください。",
+	"Model: %s (%s), context mode: %s":            "モデル: %s（%s）、コンテキストモード: %s",
+	"Budget: %d tokens (system %d, context %d, history %d, response %d); max output %d": "予算: %d トークン（システム %d、コンテキスト %d、履歴 %d、応答 %d）、最大出力 %d",
+	"Request: %d message(s), %d tokens; %d saved message(s) loaded":                     "リクエスト: メッセージ %d 件、%d トークン、保存済みメッセージ %d 件を読み込み",
+	"=== System prompt ===\n%s":                                 "=== システムプロンプト ===\n%s",
+	"=== Tools ===\n%s":                                         "=== ツール ===\n%s",
+	"=== Retrieved chunks (%d) ===":                             "=== 検索されたチャンク（%d）===",
+	"(none; only hybrid mode retrieves chunks for the message)": "（なし。メッセージ用のチャンクを検索するのは hybrid モードだけです）",
+	"%s %s (%s, %d tokens)\n   %s":                              "%s %s（%s、%d トークン）\n   %s",
+	"=== Messages after the system prompt ===":                  "=== システムプロンプト後のメッセージ ===",
+	"--- %s (%d tokens) ---\n%s":                                "--- %s（%d トークン）---\n%s",
+	"Series '%s': %d book(s)":                                   "シリーズ '%s': %d 冊",
+	"'%s' joined series '%s'. Shared files live in %s.":         "'%s' がシリーズ '%s' に加わりました。共有ファイルは %s にあります。",
+	"'%s' left series '%s'. Run 'dreamteller reindex %s' to drop the shared files from its index.": "'%s' がシリーズ '%s' から外れました。'dreamteller reindex %s' でインデックスから共有ファイルを除いてください。",
+	"No results.": "結果はありません。",
+	"at %s":       "%s 時点",
+	"Warning: %v": "警告: %v",
+	"Warning: failed to generate some context files: %v": "警告: 一部のコンテキストファイルを生成できませんでした: %v",
+	"Warning: failed to save the transcript of %q: %v":   "警告: %q の会話記録を保存できませんでした: %v",
+	"Warning: search index not updated: %v":              "警告: 検索インデックスを更新できませんでした: %v",
+	"%d issue(s).":                                       "問題 %d 件。",
+
+	// Errors
+	"network access is disabled (--offline)":                         "ネットワーク接続は無効です（--offline）",
+	"no LLM provider configured":                                     "LLM プロバイダーが設定されていません",
+	"%w. Run 'dreamteller auth --provider %s' first":                 "%w。先に 'dreamteller auth --provider %s' を実行してください",
+	"%s health check failed":                                         "%s のヘルスチェックに失敗しました",
+	"%s returned %d":                                                 "%s が %d を返しました",
+	"'%s' is not in series '%s'":                                     "'%s' はシリーズ '%s' に含まれていません",
+	"--from-chat requires an LLM provider: %w":                       "--from-chat には LLM プロバイダーが必要です: %w",
+	"--from-prompt requires an LLM provider: %w":                     "--from-prompt には LLM プロバイダーが必要です: %w",
+	"--message is required":                                          "--message が必要です",
+	"--op translate needs a target language (--to ja)":               "--op translate には翻訳先の言語が必要です（--to ja）",
+	"--preset only applies to the local provider":                    "--preset は local プロバイダーにだけ使えます",
+	"LLM request failed: %w":                                         "LLM リクエストに失敗しました: %w",
+	"Gemini setup failed: %w":                                        "Gemini の設定に失敗しました: %w",
+	"OpenAI setup failed: %w":                                        "OpenAI の設定に失敗しました: %w",
+	"Local setup failed: %w":                                         "ローカルの設定に失敗しました: %w",
+	"TUI error: %w":                                                  "TUI のエラー: %w",
+	"arc report failed: %w":                                          "アークのレポートに失敗しました: %w",
+	"batch requires an LLM provider: %w":                             "batch には LLM プロバイダーが必要です: %w",
+	"cannot test provider: %w":                                       "プロバイダーをテストできません: %w",
+	"choose the conversations to import with --conversation <title>": "--conversation <title> で取り込む会話を選んでください",
+	"conflicting providers: %s and --provider %s":                    "プロバイダーが食い違っています: %s と --provider %s",
+	"continuity comparison failed: %w":                               "連続性の比較に失敗しました: %w",
+	"default selection failed: %w":                                   "既定の選択に失敗しました: %w",
+	"draft failed: %w":                                               "下書きに失敗しました: %w",
+	"draft requires an LLM provider: %w":                             "draft には LLM プロバイダーが必要です: %w",
+	"dreamteller crashed; a crash report was saved to %s. Reopen the project to restore your session": "dreamteller が異常終了しました。クラッシュレポートを %s に保存しました。プロジェクトを開き直すとセッションが復元されます",
+	"dreamteller crashed and the session could not be saved: %w":                                      "dreamteller が異常終了しました。セッションを保存できませんでした: %w",
+	"dreamteller crashed. Reopen the project to restore your session":                                 "dreamteller が異常終了しました。プロジェクトを開き直すとセッションが復元されます",
+	"encoding check failed: %w":               "文字コードの確認に失敗しました: %w",
+	"error reading stdin: %w":                 "標準入力の読み込みエラー: %w",
+	"errors: %s":                              "エラー: %s",
+	"export failed: %w":                       "エクスポートに失敗しました: %w",
+	"export not yet implemented":              "エクスポートはまだ実装されていません",
+	"failed to close project: %w":             "プロジェクトを閉じられませんでした: %w",
+	"failed to create %s: %w":                 "%s を作成できませんでした: %w",
+	"failed to create project: %w":            "プロジェクトを作成できませんでした: %w",
+	"failed to delete project: %w":            "プロジェクトを削除できませんでした: %w",
+	"failed to extract the story: %w":         "ストーリーを抽出できませんでした: %w",
+	"failed to initialize LLM provider: %w":   "LLM プロバイダーを初期化できませんでした: %w",
+	"failed to initialize app: %w":            "アプリを初期化できませんでした: %w",
+	"failed to join series: %w":               "シリーズに加えられませんでした: %w",
+	"failed to leave series: %w":              "シリーズから外せませんでした: %w",
+	"failed to list projects: %w":             "プロジェクトを一覧表示できませんでした: %w",
+	"failed to load config: %w":               "設定を読み込めませんでした: %w",
+	"failed to open project: %w":              "プロジェクトを開けませんでした: %w",
+	"failed to open replay log: %w":           "リプレイログを開けませんでした: %w",
+	"failed to parse prompt: %w":              "プロンプトを解析できませんでした: %w",
+	"failed to read export: %w":               "エクスポートを読み込めませんでした: %w",
+	"failed to read file %s: %w":              "ファイル %s を読み込めませんでした: %w",
+	"failed to read prompt file: %w":          "プロンプトファイルを読み込めませんでした: %w",
+	"failed to save config: %w":               "設定を保存できませんでした: %w",
+	"failed to update language: %w":           "言語を変更できませんでした: %w",
+	"failed to update projects directory: %w": "プロジェクトディレクトリを変更できませんでした: %w",
+	"failed to use draft model %s: %w":        "下書きモデル %s を使えませんでした: %w",
+	"feedback export failed: %w":              "フィードバックのエクスポートに失敗しました: %w",
+	"glossary check failed: %w":               "用語集の確認に失敗しました: %w",
+	"invalid redaction rules: %w":             "不正な伏せ字ルール: %w",
+	"language selection failed: %w":           "言語の選択に失敗しました: %w",
+	"model input failed: %w":                  "モデルの入力に失敗しました: %w",
+	"model name is required":                  "モデル名が必要です",
+	"model selection failed: %w":              "モデルの選択に失敗しました: %w",
+	"no conversation title contains %q":       "タイトルに %q を含む会話はありません",
+	"no models available":                     "利用できるモデルがありません",
+	"pacing export failed: %w":                "ペーシングのエクスポートに失敗しました: %w",
+	"pacing report failed: %w":                "ペーシングのレポートに失敗しました: %w",
+	"please specify a project name":           "プロジェクト名を指定してください",
+	"project '%s' already exists":             "プロジェクト '%s' はすでに存在します",
+	"project '%s' not found":                  "プロジェクト '%s' が見つかりません",
+	"prompt cannot be empty":                  "プロンプトを空にはできません",
+	"prompt only assembles requests; pass --dry-run (use 'dreamteller open %s' to chat)": "prompt はリクエストを組み立てるだけです。--dry-run を付けてください（チャットは 'dreamteller open %s'）",
+	"prompt setup failed: %w":                                 "プロンプトでの設定に失敗しました: %w",
+	"provider '%s' is not configured":                         "プロバイダー '%s' は設定されていません",
+	"provider selection failed: %w":                           "プロバイダーの選択に失敗しました: %w",
+	"reindex failed: %w":                                      "インデックスの再構築に失敗しました: %w",
+	"report failed: %w":                                       "レポートに失敗しました: %w",
+	"scaffold failed: %w":                                     "骨組みの作成に失敗しました: %w",
+	"series check failed: %w":                                 "シリーズの確認に失敗しました: %w",
+	"setup mode selection failed: %w":                         "設定方法の選択に失敗しました: %w",
+	"snapshot failed: %w":                                     "スナップショットに失敗しました: %w",
+	"subplot report failed: %w":                               "サブプロットのレポートに失敗しました: %w",
+	"the export has no conversations with text":               "エクスポートにテキストのある会話がありません",
+	"typography fix failed: %w":                               "組版の修正に失敗しました: %w",
+	"unknown preset: %s (available: %s)":                      "不明なプリセット: %s（利用可能: %s）",
+	"unknown protocol: %s":                                    "不明なプロトコル: %s",
+	"unknown provider: %s (supported: openai, gemini, local)": "不明なプロバイダー: %s（対応: openai、gemini、local）",
+	"unknown setup mode: %s":                                  "不明な設定方法: %s",
+	"unsupported format: %s (use epub, pdf, or txt)":          "未対応の形式: %s（epub、pdf、txt のいずれか）",
+	"unsupported provider: %s":                                "未対応のプロバイダー: %s",
+	"wizard setup failed: %w":                                 "ウィザードでの設定に失敗しました: %w",
+	"word frequency failed: %w":                               "単語頻度の分析に失敗しました: %w",
+}
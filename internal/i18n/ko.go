@@ -0,0 +1,402 @@
+package i18n
+
+// korean is the Korean catalog.
+var korean = map[string]string{
+	// Cobra's usage template, help and completion commands
+	"Usage:":                  "사용법:",
+	"Aliases:":                "별칭:",
+	"Examples:":               "예시:",
+	"Available Commands:":     "사용 가능한 명령:",
+	"Additional Commands:":    "추가 명령:",
+	"Flags:":                  "플래그:",
+	"Global Flags:":           "전역 플래그:",
+	"Additional help topics:": "추가 도움말 주제:",
+	"Use \"%s [command] --help\" for more information about a command.": "명령에 대한 자세한 정보는 \"%s [command] --help\"를 사용하세요.",
+	"help for %s":            "%s 도움말",
+	"version for %s":         "%s 버전",
+	"Help about any command": "명령에 대한 도움말",
+	"Help provides help for any command in the application.\nSimply type dreamteller help [path to command] for full details.": "애플리케이션의 모든 명령에 대한 도움말을 보여 줍니다.\n자세한 내용은 dreamteller help [명령 경로]를 입력하세요.",
+	"Generate the autocompletion script for the specified shell":                                                               "지정한 셸의 자동 완성 스크립트 생성",
+	"Generate the autocompletion script for bash":                                                                              "bash 자동 완성 스크립트 생성",
+	"Generate the autocompletion script for zsh":                                                                               "zsh 자동 완성 스크립트 생성",
+	"Generate the autocompletion script for fish":                                                                              "fish 자동 완성 스크립트 생성",
+	"Generate the autocompletion script for powershell":                                                                        "powershell 자동 완성 스크립트 생성",
+	"disable completion descriptions":                                                                                          "자동 완성 설명 끄기",
+
+	// Cobra's and pflag's errors
+	"Error:":                                        "오류:",
+	"unknown command \"%s\" for \"%s\"":             "\"%[2]s\"에 \"%[1]s\" 명령이 없습니다",
+	"accepts %s arg(s), received %s":                "인자 %s개가 필요하지만 %s개를 받았습니다",
+	"accepts at most %s arg(s), received %s":        "인자는 최대 %s개까지 받지만 %s개를 받았습니다",
+	"accepts between %s and %s arg(s), received %s": "인자 %s~%s개가 필요하지만 %s개를 받았습니다",
+	"requires at least %s arg(s), only received %s": "인자가 %s개 이상 필요하지만 %s개만 받았습니다",
+	"required flag(s) %s not set":                   "필수 플래그 %s이(가) 지정되지 않았습니다",
+	"unknown flag: %s":                              "알 수 없는 플래그: %s",
+	"unknown shorthand flag: '%s' in %s":            "%[2]s에 알 수 없는 단축 플래그 '%[1]s'",
+	"flag needs an argument: %s":                    "플래그에 값이 필요합니다: %s",
+	"invalid argument \"%s\" for \"%s\" flag:":      "\"%[2]s\" 플래그의 값 \"%[1]s\"이(가) 잘못되었습니다:",
+	"Did you mean this?":                            "이 명령을 찾으셨나요?",
+	"unsupported language %q (use en, ko or ja)":    "지원하지 않는 언어 %q (en, ko, ja 중 하나)",
+
+	// Commands
+	"A TUI application for writing novels with AI assistance": "AI와 함께 소설을 쓰는 TUI 애플리케이션",
+	"Dreamteller is a terminal-based application that helps you write novels\nwith AI assistance. It provides context-aware suggestions based on your\ncharacters, settings, and plot points.": "Dreamteller는 AI의 도움으로 소설을 쓰는 터미널 애플리케이션입니다.\n등장인물, 배경, 플롯을 바탕으로 맥락에 맞는 제안을 제공합니다.",
+	"Create a new novel project":       "새 소설 프로젝트 만들기",
+	"List all novel projects":          "모든 소설 프로젝트 나열",
+	"Open a novel project in TUI mode": "TUI 모드로 소설 프로젝트 열기",
+	"Open a novel project in TUI mode.\n\nThe argument is either a project name inside the projects directory or a\npath to a project directory (absolute, ~/..., ./... or ../...).": "TUI 모드로 소설 프로젝트를 엽니다.\n\n인자는 프로젝트 디렉터리 안의 프로젝트 이름이나 프로젝트 디렉터리의\n경로(절대 경로, ~/..., ./..., ../...)입니다.",
+	"Delete a novel project":                      "소설 프로젝트 삭제",
+	"Edit global configuration":                   "전역 설정 편집",
+	"Export a novel to a specific format":         "소설을 지정한 형식으로 내보내기",
+	"Export a novel to epub, pdf, or txt format.": "소설을 epub, pdf, txt 형식으로 내보냅니다.",
+	"Rebuild the search index for a project":      "프로젝트의 검색 색인 다시 만들기",
+	"Configure LLM provider authentication":       "LLM 제공자 인증 설정",
+	"Configure LLM provider authentication.\n\nA provider given as an argument is the same as --provider.\n\nWith --test, send a tiny request to the given provider (or the default one)\nand report latency, model availability, and tool-call support.": "LLM 제공자 인증을 설정합니다.\n\n인자로 준 제공자는 --provider와 같습니다.\n\n--test를 주면 지정한 제공자(없으면 기본 제공자)에 작은 요청을 보내\n지연 시간, 모델 사용 가능 여부, 도구 호출 지원을 보고합니다.",
+	"List configured providers":               "설정된 제공자 나열",
+	"Remove a provider configuration":         "제공자 설정 제거",
+	"Run an LLM operation over many chapters": "여러 챕터에 LLM 작업 실행",
+	"Summarize, lint, check against the rulebook, classify scenes for pacing or\ntranslate a range of chapters, writing one result per chapter under batch/<op>/\nin the project. Progress is checkpointed after every chapter, so an interrupted run resumes where it stopped when the same command\nis run again. Chapters edited since they were processed are done again.": "챕터 범위를 요약, 검사, 규칙서 대조, 페이싱용 장면 분류 또는 번역하고\n챕터마다 결과 하나를 프로젝트의 batch/<op>/에 씁니다. 챕터마다 진행 상황을\n저장하므로, 중단된 실행은 같은 명령을 다시 실행하면 멈춘 곳부터 이어집니다.\n처리 후 수정된 챕터는 다시 처리합니다.",
+	"Translate the manuscript into another language": "원고를 다른 언어로 번역",
+	"Translate chapters into translations/<language>/, one file per chapter, leaving\nthe originals untouched. Names and invented terms are kept consistent through a\ntranslation memory at translations/<language>/memory.md, which you can edit.\nLike batch, an interrupted run resumes where it stopped.": "챕터를 translations/<language>/에 챕터마다 파일 하나로 번역하며 원문은\n그대로 둡니다. 이름과 창작 용어는 편집할 수 있는 번역 메모리\ntranslations/<language>/memory.md로 일관되게 유지합니다.\nbatch처럼 중단된 실행은 멈춘 곳부터 이어집니다.",
+	"Check chapters for misspelled glossary terms":                                                                                                                         "챕터에서 용어집 용어의 오타 확인",
+	"Compare words in every chapter against the invented terms in context/glossary and report likely misspellings.":                                                        "모든 챕터의 단어를 context/glossary의 창작 용어와 비교해 오타로 보이는 것을 보고합니다.",
+	"Report crutch words and repeated phrases in chapters":                                                                                                                 "챕터의 습관어와 반복 표현 보고",
+	"Count crutch words (built-in plus writing.crutch_words) and the most repeated phrases in every chapter, with a per-chapter heatmap.":                                  "모든 챕터의 습관어(기본 목록과 writing.crutch_words)와 가장 많이 반복된 표현을 세어 챕터별 히트맵으로 보여 줍니다.",
+	"Find unused, stale or empty story bible entries":                                                                                                                      "쓰이지 않거나 오래되었거나 빈 설정 항목 찾기",
+	"List context files no chapter references, characters missing from the latest chapters, and settings with empty descriptions.":                                         "어느 챕터도 참조하지 않는 컨텍스트 파일, 최근 챕터에 나오지 않는 등장인물, 설명이 빈 배경을 나열합니다.",
+	"Chart character arcs and long absences":                                                                                                                               "등장인물의 아크와 긴 부재를 차트로 보기",
+	"Show each character's recorded state and goal per chapter (the Arc section of their file) as a chart, and warn about characters missing from many chapters in a row.": "등장인물마다 챕터별로 기록된 상태와 목표(파일의 Arc 섹션)를 차트로 보여 주고, 여러 챕터 연속으로 나오지 않는 등장인물을 경고합니다.",
+	"Report subplot coverage and interleaving":                                                                                                                             "서브플롯의 분포와 교차 보고",
+	"Chart which chapters touch each subplot (tagged with <!-- subplot: id --> comments) and suggest where to weave back subplots left untouched for many chapters.":       "각 서브플롯(<!-- subplot: id --> 주석으로 표시)이 어느 챕터에 나오는지 차트로 보여 주고, 여러 챕터 동안 다루지 않은 서브플롯을 다시 엮을 곳을 제안합니다.",
+	"Chart scene lengths, reading time and action vs. reflection":                                                                                                          "장면 길이, 읽는 시간, 행동과 성찰의 비율을 차트로 보기",
+	"Split every chapter into scenes at scene breaks (***, ---, #) and chart their\nlengths, reading times and, once 'batch --op pacing' has classified them,\nthe share of action against reflection, flagging sagging middle chapters.\n--svg also writes the chart to exports/pacing.svg.": "모든 챕터를 장면 구분(***, ---, #)에서 장면으로 나누고 길이와 읽는 시간,\n그리고 'batch --op pacing'으로 분류한 뒤에는 행동과 성찰의 비율을 차트로 보여 주며\n늘어지는 중반 챕터를 표시합니다.\n--svg를 주면 차트를 exports/pacing.svg에도 씁니다.",
+	"Snapshot character facts and the timeline, then report what a rewrite changed": "등장인물 사실과 타임라인을 스냅샷으로 저장하고 개고로 바뀐 점 보고",
+	"Compare the character facts, remembered facts and plot timeline against a\ncontinuity snapshot, and list the chapters that still refer to facts that\nchanged since.\n\nTake a snapshot with --snapshot before a major rewrite. Without --snapshot,\ncompare against the snapshot named by --name, or the latest one.": "등장인물 사실, 기억된 사실, 플롯 타임라인을 연속성 스냅샷과 비교하고,\n그 뒤로 바뀐 사실을 아직 참조하는 챕터를 나열합니다.\n\n큰 개고 전에 --snapshot으로 스냅샷을 저장하세요. --snapshot 없이 실행하면\n--name으로 지정한 스냅샷이나 가장 최근 스냅샷과 비교합니다.",
+	"Export annotations, lint findings and reviewer feedback": "주석, 검사 결과, 리뷰어 피드백 내보내기",
+	"Gather the reviewer persona annotations, the results of 'batch --op lint' and\n'batch --op rules' and the glossary misspellings into one document under exports/, as a Markdown\nchecklist or CSV, for an offline editing pass.": "리뷰어 페르소나의 주석, 'batch --op lint'와 'batch --op rules'의 결과, 용어집 오타를\nexports/ 아래 문서 하나에 Markdown 체크리스트나 CSV로 모아 오프라인 편집에 씁니다.",
+	"Draft a scene from a beat of the outline": "개요의 비트로 장면 초안 쓰기",
+	"Plan a scene for a beat of the plot outline: its goal, conflict, disaster and\nsequel, written with the characters and places the beat mentions and the end of\nthe manuscript so far. --beat 2 names the second plot file, --beat 2.3 the third\nsection or list item in it. With --prose, a first draft of the scene follows\nthe scaffold. The result is written to a new file under scenes/; chapters are\nnever changed.": "플롯 개요의 비트에 맞춰 장면의 목표, 갈등, 재앙, 후속을 계획합니다. 비트에\n나오는 등장인물과 장소, 지금까지 원고의 끝부분을 함께 씁니다. --beat 2는 두 번째\n플롯 파일을, --beat 2.3은 그 안의 세 번째 섹션이나 목록 항목을 가리킵니다.\n--prose를 주면 골격 뒤에 장면의 초고가 이어집니다. 결과는 scenes/ 아래 새 파일에\n쓰며 챕터는 바꾸지 않습니다.",
+	"Show what the search index holds for a project or one file": "프로젝트나 파일 하나의 검색 색인 내용 보기",
+	"Inspect a project's search index":                           "프로젝트의 검색 색인 살펴보기",
+	"List every indexed file with its chunk count, tokens and the modification\ntime it was indexed at, flagging files changed or removed since. Given a file,\nshow each of its chunks with its tokens and section, to see why a passage is\nor is not retrieved.": "색인된 모든 파일을 청크 수, 토큰 수, 색인할 때의 수정 시각과 함께 나열하고,\n그 뒤로 바뀌거나 지워진 파일을 표시합니다. 파일을 주면 각 청크를 토큰 수와\n섹션과 함께 보여 주어, 어떤 구절이 검색되거나 검색되지 않는 이유를 알 수 있습니다.",
+	"Show the request a chat message would send": "채팅 메시지가 보낼 요청 보기",
+	"Assemble the request the chat would send for a message after the project's\nsaved conversation, as opening the project and sending it would, and print it\nwithout calling the provider: the system prompt, the retrieved chunks and why\neach was chosen, the history that fits the budget, and token counts. Use it to\ndebug prompts or attach to a bug report.": "프로젝트를 열어 저장된 대화 뒤에 메시지를 보낼 때처럼 채팅이 보낼 요청을 조립해\n제공자를 호출하지 않고 출력합니다. 시스템 프롬프트, 검색된 청크와 각각이 선택된 이유,\n예산에 맞는 대화 기록, 토큰 수를 보여 줍니다. 프롬프트를 디버그하거나 버그\n보고에 첨부할 때 쓰세요.",
+	"Group books into a series sharing a world bible": "세계관 설정을 공유하는 시리즈로 책 묶기",
+	"Show the books of a series and check them for inconsistencies.\n\nWith --join or --leave, add a project to the series or remove it. Books in a\nseries share the characters, settings and glossary under\n<projects-dir>/.series/<series>/, which each book sees as series/. With\n--search, search every book's index at once.": "시리즈의 책을 보여 주고 서로 모순이 없는지 확인합니다.\n\n--join이나 --leave로 프로젝트를 시리즈에 넣거나 뺍니다. 시리즈의 책은\n<projects-dir>/.series/<series>/ 아래의 등장인물, 배경, 용어집을 공유하며,\n각 책에서는 series/로 보입니다. --search로 모든 책의 색인을 한 번에 검색합니다.",
+	"Replace straight quotes, double hyphens and dots with typographic punctuation": "곧은따옴표, 이중 하이픈, 점을 조판용 문장 부호로 바꾸기",
+	"Normalize the punctuation of the chapters: curly quotes, em dashes and\nellipses, with the rules of the manuscript's language. French uses guillemets\nand non-breaking spaces, Korean drops stray spaces before punctuation, and\nJapanese uses corner brackets and full-width marks. Code, comments and links\nare left alone.\n\nThe language defaults to the project's language setting.": "원고 언어의 규칙에 따라 챕터의 문장 부호(둥근 따옴표, 줄표, 말줄임표)를\n정리합니다. 프랑스어는 기유메와 줄 바꿈 없는 공백을, 한국어는 문장 부호 앞의\n불필요한 공백을 없애고, 일본어는 낫표와 전각 부호를 씁니다. 코드, 주석, 링크는\n건드리지 않습니다.\n\n언어의 기본값은 프로젝트의 언어 설정입니다.",
+
+	// Flags
+	"Projects root directory (overrides $DREAMTELLER_PROJECTS_DIR and config)":                      "프로젝트 루트 디렉터리($DREAMTELLER_PROJECTS_DIR와 설정보다 우선)",
+	"Disable all network calls; AI features are unavailable":                                        "모든 네트워크 호출 끄기. AI 기능은 쓸 수 없습니다",
+	"Language of help, messages and prompts: en, ko or ja (overrides the language config)":          "도움말, 메시지, 질문의 언어: en, ko, ja (language 설정보다 우선)",
+	"Persist a new projects root directory":                                                         "새 프로젝트 루트 디렉터리 저장",
+	"Persist the language of help, messages and prompts: en, ko or ja":                              "도움말, 메시지, 질문의 언어 저장: en, ko, ja",
+	"Path to prompt file for one-shot setup (use '-' for stdin)":                                    "한 번에 설정할 프롬프트 파일 경로(표준 입력은 '-')",
+	"Genre for quick project creation without wizard":                                               "마법사 없이 프로젝트를 빠르게 만들 때의 장르",
+	"Path to a ChatGPT or Claude export (conversations.json) to seed the project from":              "프로젝트의 바탕이 될 ChatGPT나 Claude 내보내기 파일(conversations.json) 경로",
+	"Import only conversations whose title contains this text":                                      "제목에 이 텍스트가 들어간 대화만 가져오기",
+	"Delete without confirmation":                                                                   "확인 없이 삭제",
+	"Configure a specific provider":                                                                 "특정 제공자 설정",
+	"Send a test request to a provider and report latency and capabilities":                         "제공자에 테스트 요청을 보내 지연 시간과 기능 보고",
+	"Local server preset: ollama, lmstudio, llamacpp, vllm, textgen":                                "로컬 서버 프리셋: ollama, lmstudio, llamacpp, vllm, textgen",
+	"Operation to run: summarize, lint, rules, pacing or translate":                                 "실행할 작업: summarize, lint, rules, pacing, translate",
+	"Chapters to process, e.g. 1-10 or 1-3,7 (default: all)":                                        "처리할 챕터, 예: 1-10 또는 1-3,7 (기본값: 전체)",
+	"Target language for --op translate, e.g. ja":                                                   "--op translate의 대상 언어, 예: ja",
+	"Discard saved progress and process every chapter again":                                        "저장된 진행 상황을 버리고 모든 챕터를 다시 처리",
+	"Maximum requests per minute (0 for no limit)":                                                  "분당 최대 요청 수(0이면 제한 없음)",
+	"Target language, e.g. ja or en":                                                                "대상 언어, 예: ja 또는 en",
+	"Chapters to translate, e.g. 1-10 (default: all)":                                               "번역할 챕터, 예: 1-10 (기본값: 전체)",
+	"Discard saved progress and translate every chapter again":                                      "저장된 진행 상황을 버리고 모든 챕터를 다시 번역",
+	"Typography rules applied to the text (default: the project's language)":                        "텍스트에 적용할 조판 규칙(기본값: 프로젝트의 언어)",
+	"Typography rules: en, fr, ko or ja (default: the project's language)":                          "조판 규칙: en, fr, ko, ja (기본값: 프로젝트의 언어)",
+	"Chapters to fix, e.g. 1-3,7 (default: all)":                                                    "고칠 챕터, 예: 1-3,7 (기본값: 전체)",
+	"List the chapters that would change without writing them":                                      "쓰지 않고 바뀔 챕터만 나열",
+	"Warn about characters missing from this many chapters in a row":                                "이 수만큼 연속으로 챕터에 나오지 않는 등장인물 경고",
+	"Flag characters missing from this many of the latest chapters":                                 "최근 챕터 중 이 수만큼 나오지 않은 등장인물 표시",
+	"Warn about subplots untouched for this many chapters in a row":                                 "이 수만큼 연속으로 다루지 않은 서브플롯 경고",
+	"Also export the chart to exports/pacing.svg":                                                   "차트를 exports/pacing.svg로도 내보내기",
+	"Save a continuity snapshot instead of comparing against one":                                   "스냅샷과 비교하는 대신 연속성 스냅샷 저장",
+	"Snapshot name (default: the current time when saving, the latest when comparing)":              "스냅샷 이름(기본값: 저장할 때는 현재 시각, 비교할 때는 최신 스냅샷)",
+	"Document format: md or csv":                                                                    "문서 형식: md 또는 csv",
+	"Export one chapter's feedback (default: the whole book)":                                       "한 챕터의 피드백만 내보내기(기본값: 책 전체)",
+	"Outline beat to draft, e.g. 2 or 2.3":                                                          "초안을 쓸 개요 비트, 예: 2 또는 2.3",
+	"Also write a first draft of the scene's prose":                                                 "장면의 본문 초고도 쓰기",
+	"Print the request instead of sending it (required)":                                            "요청을 보내지 않고 출력(필수)",
+	"The chat message to assemble a request for":                                                    "요청을 조립할 채팅 메시지",
+	"Context mode: essential, hybrid or full":                                                       "컨텍스트 모드: essential, hybrid, full",
+	"Add a project to the series":                                                                   "프로젝트를 시리즈에 추가",
+	"Remove a project from the series":                                                              "프로젝트를 시리즈에서 제거",
+	"Search every book in the series":                                                               "시리즈의 모든 책 검색",
+	"Rewrite files with CRLF line endings, a byte order mark or a legacy encoding as UTF-8 with LF": "CRLF 줄 끝, 바이트 순서 표시, 옛 인코딩의 파일을 LF를 쓰는 UTF-8로 다시 쓰기",
+	"Show under each reply why each retrieved context chunk was chosen (same as /explain)":          "답변마다 아래에 검색된 컨텍스트 청크가 선택된 이유 표시(/explain과 같음)",
+	"Append every LLM exchange to a replay log (JSON Lines)":                                        "모든 LLM 주고받기를 재생 로그(JSON Lines)에 덧붙이기",
+	"Serve canned responses from a replay log (JSON Lines) instead of a provider":                   "제공자 대신 재생 로그(JSON Lines)의 저장된 응답 사용",
+
+	// Project setup
+	"How would you like to set up your project?":   "프로젝트를 어떻게 설정하시겠습니까?",
+	"Wizard - Guided step-by-step setup":           "마법사 - 단계별 안내 설정",
+	"Prompt - Describe your story and auto-create": "프롬프트 - 스토리 설명으로 자동 생성",
+	"Template - Start from a preset (coming soon)": "템플릿 - 프리셋으로 시작 (준비 중)",
+	"Select your genre":                            "장르를 선택하세요",
+	"Describe your writing style":                  "작문 스타일을 설명하세요",
+	"e.g., descriptive, immersive, fast-paced":     "예: 묘사적, 몰입감 있는, 빠른 전개",
+	"Point of View":                                "시점",
+	"Tense":                                        "시제",
+	"Content Rating":                               "콘텐츠 등급",
+	"Fantasy":                                      "판타지",
+	"Science Fiction":                              "SF (과학 소설)",
+	"Mystery":                                      "미스터리",
+	"Romance":                                      "로맨스",
+	"Thriller":                                     "스릴러",
+	"Horror":                                       "호러",
+	"Historical Fiction":                           "역사 소설",
+	"Literary Fiction":                             "순수 문학",
+	"Other":                                        "기타",
+	"First Person":                                 "1인칭",
+	"Third Person Limited":                         "3인칭 제한",
+	"Third Person Omniscient":                      "3인칭 전지",
+	"Second Person":                                "2인칭",
+	"Past Tense":                                   "과거 시제",
+	"Present Tense":                                "현재 시제",
+	"No rating":                                    "등급 없음",
+	"Young Adult":                                  "청소년 (YA)",
+	"No Graphic Violence":                          "잔혹한 폭력 묘사 없음",
+	"Adult":                                        "성인",
+	"Describe your story":                          "스토리를 설명하세요",
+	"Include details about genre, setting, characters, and plot ideas.": "장르, 배경, 등장인물, 플롯 아이디어를 자세히 적어 주세요.",
+	"Write a detailed description of your novel idea...":                "소설 아이디어를 자세히 설명하세요...",
+	"Created project '%s' at %s":                                        "'%s' 프로젝트가 %s에 생성되었습니다",
+	"Created project '%s' with genre '%s' at %s":                        "'%[2]s' 장르의 '%[1]s' 프로젝트가 %[3]s에 생성되었습니다",
+	"Run 'dreamteller open %s' to start writing!":                       "'dreamteller open %s' 명령으로 시작하세요!",
+	"Genre: %s":                     "장르: %s",
+	"Style: %s":                     "스타일: %s",
+	"POV: %s, Tense: %s":            "시점: %s, 시제: %s",
+	"Content Rating: %s":            "콘텐츠 등급: %s",
+	"Template mode is coming soon!": "템플릿 모드는 준비 중입니다!",
+	"Please use Wizard or Prompt mode for now.": "지금은 마법사나 프롬프트 모드를 사용하세요.",
+	"Analyzing your story description...":       "스토리 설명을 분석하는 중...",
+	"Creating project structure...":             "프로젝트 구조를 만드는 중...",
+	"Characters: %d created":                    "등장인물: %d개 생성",
+	"Setting: created":                          "배경: 생성됨",
+	"Plot hints: %d created":                    "플롯 힌트: %d개 생성",
+	"Reading %d conversation(s)...":             "대화 %d개를 읽는 중...",
+	"The export has %d conversations:":          "내보내기 파일에 대화가 %d개 있습니다:",
+	"Transcripts: %d saved to %s/":              "대화록: %[2]s/에 %[1]d개 저장",
+	"part %d of %d":                             "%[2]d개 중 %[1]d번째",
+
+	// Messages
+	"No projects found. Create one with: dreamteller new <name>": "프로젝트가 없습니다. 다음 명령으로 만드세요: dreamteller new <name>",
+	"Projects in %s:": "%s의 프로젝트:",
+	"This will permanently delete project '%s' and all its files.": "'%s' 프로젝트와 모든 파일이 영구히 삭제됩니다.",
+	"Type the project name to confirm:":                            "확인하려면 프로젝트 이름을 입력하세요:",
+	"Deletion cancelled.":                                          "삭제를 취소했습니다.",
+	"Project '%s' deleted.":                                        "'%s' 프로젝트를 삭제했습니다.",
+	"Config file: %s":                                              "설정 파일: %s",
+	"Projects directory: %s":                                       "프로젝트 디렉터리: %s",
+	"Projects directory set to %s":                                 "프로젝트 디렉터리 설정: %s",
+	"Language: %s":                                                 "언어: %s",
+	"Language set to %s":                                           "언어 설정: %s",
+	"Configuration editor not yet implemented.":                    "설정 편집기는 아직 구현되지 않았습니다.",
+	"Edit %s manually.":                                            "%s을(를) 직접 편집하세요.",
+	"Exporting '%s' to %s format...":                               "'%s'을(를) %s 형식으로 내보내는 중...",
+	"Exported '%s' to %s":                                          "'%s'을(를) %s(으)로 내보냈습니다",
+	"Reindexing project '%s'...":                                   "'%s' 프로젝트를 다시 색인하는 중...",
+	"Reindex complete. Indexed %d chunks.":                         "다시 색인 완료. 청크 %d개를 색인했습니다.",
+	"Converted %d file(s) to UTF-8 with LF line endings:":          "파일 %d개를 LF 줄 끝의 UTF-8로 변환했습니다:",
+	"%d file(s) are not UTF-8 with LF line endings (rewrite them with --normalize):": "파일 %d개가 LF 줄 끝의 UTF-8이 아닙니다(--normalize로 다시 쓰세요):",
+	"Configured providers:":                           "설정된 제공자:",
+	"No providers configured.":                        "설정된 제공자가 없습니다.",
+	"Run 'dreamteller auth' to configure a provider.": "'dreamteller auth'로 제공자를 설정하세요.",
+	"Run 'dreamteller auth' to set up a provider.":    "'dreamteller auth'로 제공자를 설정하세요.",
+	"Provider '%s' removed.":                          "'%s' 제공자를 제거했습니다.",
+	"(default)":                                       "(기본)",
+	"(current: %s)":                                   "(현재: %s)",
+	"(recommended)":                                   "(추천)",
+	"API Key: %s":                                     "API 키: %s",
+	"Model: %s":                                       "모델: %s",
+	"Base URL: %s":                                    "기본 URL: %s",
+	"Preset: %s":                                      "프리셋: %s",
+	"Select provider to configure":                    "설정할 제공자를 선택하세요",
+	"Local (Ollama/LM Studio)":                        "로컬 (Ollama/LM Studio)",
+	"Set as default provider?":                        "기본 제공자로 설정할까요?",
+	"Default model":                                   "기본 모델",
+	"Get from ai.google.dev":                          "ai.google.dev에서 발급",
+	"Custom server":                                   "사용자 지정 서버",
+	"Server":                                          "서버",
+	"Protocol":                                        "프로토콜",
+	"OpenAI Compatible":                               "OpenAI 호환",
+	"Anthropic Compatible":                            "Anthropic 호환",
+	"Gemini Compatible":                               "Gemini 호환",
+	"Base URL":                                        "기본 URL",
+	"Model name":                                      "모델 이름",
+	"llama3, mistral, etc.":                           "llama3, mistral 등",
+	"Select model":                                    "모델을 선택하세요",
+	"Please enter model name manually.":               "모델 이름을 직접 입력하세요.",
+	"Model %s not found on %s, pulling...":            "%[2]s에 %[1]s 모델이 없어 내려받는 중...",
+	"✓ Pulled %s":                                     "✓ %s을(를) 내려받았습니다",
+	"✓ %s configured successfully":                    "✓ %s 설정을 마쳤습니다",
+	"⚠ Could not fetch models from %s: %v":            "⚠ %s에서 모델 목록을 가져오지 못했습니다: %v",
+	"⚠ No models found. Please make a model available first:":             "⚠ 모델이 없습니다. 먼저 모델을 준비하세요:",
+	"⚠ No API key configured for %s.":                                     "⚠ %s의 API 키가 설정되지 않았습니다.",
+	"⚠ No LLM provider configured.":                                       "⚠ LLM 제공자가 설정되지 않았습니다.",
+	"Testing %s":                                                          "%s 테스트 중",
+	"✓ Chat: responded in %s":                                             "✓ 채팅: %s 만에 응답",
+	"✗ Chat: %v":                                                          "✗ 채팅: %v",
+	"✗ Authentication: API key rejected":                                  "✗ 인증: API 키가 거부되었습니다",
+	"✓ Model: %s is available":                                            "✓ 모델: %s을(를) 쓸 수 있습니다",
+	"✗ Model: %s is not available":                                        "✗ 모델: %s을(를) 쓸 수 없습니다",
+	"✓ Tools: tool calls work":                                            "✓ 도구: 도구 호출이 동작합니다",
+	"⚠ Tools: advertised but the model did not call the tool":             "⚠ 도구: 지원한다고 하지만 모델이 도구를 호출하지 않았습니다",
+	"⚠ Tools: request failed: %v":                                         "⚠ 도구: 요청 실패: %v",
+	"- Tools: not supported natively (suggestions use the text fallback)": "- 도구: 기본 지원 없음(제안은 텍스트 대체 방식 사용)",
+	"Running %s with %s...":                                               "%[2]s(으)로 %[1]s 실행 중...",
+	"- Chapter %d (%s) already done":                                      "- %d장 (%s) 이미 처리됨",
+	"✓ Chapter %d (%s) → %s":                                              "✓ %d장 (%s) → %s",
+	"✗ Chapter %d (%s): %v":                                               "✗ %d장 (%s): %v",
+	"⚠ formatting: %s":                                                    "⚠ 서식: %s",
+	"Done. Results are in %s":                                             "완료. 결과는 %s에 있습니다",
+	"Interrupted. Run the same command again to resume.":                  "중단되었습니다. 같은 명령을 다시 실행하면 이어집니다.",
+	"Stopped. Run the same command again to resume.":                      "멈췄습니다. 같은 명령을 다시 실행하면 이어집니다.",
+	"Translation memory: %s":                                              "번역 메모리: %s",
+	"No misspelled glossary terms found.":                                 "용어집 용어의 오타가 없습니다.",
+	"%s:%d: %q → did you mean %q?":                                        "%s:%d: %q → %q 아닌가요?",
+	"%d possible misspelling(s).":                                         "오타로 보이는 것 %d개.",
+	"Typography is already clean.":                                        "조판이 이미 깔끔합니다.",
+	"Fixed typography (%s) in %d chapter(s): %s":                          "조판(%[1]s)을 챕터 %[2]d개에서 고쳤습니다: %[3]s",
+	"Would fix typography (%s) in %d chapter(s): %s":                      "조판(%[1]s)을 챕터 %[2]d개에서 고칠 예정입니다: %[3]s",
+	"Exported the chart to %s":                                            "차트를 %s(으)로 내보냈습니다",
+	"Saved continuity snapshot '%s': %d fact(s), %d timeline event(s).":   "연속성 스냅샷 '%s' 저장: 사실 %d개, 타임라인 사건 %d개.",
+	"No inconsistencies found.":                                           "모순이 없습니다.",
+	"(removed since)":                                                     "(그 뒤 삭제됨)",
+	"(changed since)":                                                     "(그 뒤 변경됨)",
+	"Exported %d item(s) to %s":                                           "항목 %[1]d개를 %[2]s(으)로 내보냈습니다",
+	"Planning beat %s (%s) with %s...":                                    "%[3]s(으)로 비트 %[1]s (%[2]s) 계획 중...",
+	"Drafting the scene...":                                               "장면 초안을 쓰는 중...",
+	"Goal: %s\nConflict: %s\nDisaster: %s\nSequel: %s":                    "목표: %s\n갈등: %s\n재앙: %s\n후속: %s",
+	"Scene written to %s":                                                 "장면을 %s에 썼습니다",
+	"The index is empty. Run 'dreamteller reindex %s' to build it.":       "색인이 비었습니다. 'dreamteller reindex %s'로 만드세요.",
+	"The index was built with other settings (%s); run 'dreamteller reindex %s' to rebuild it.": "색인이 다른 설정(%s)으로 만들어졌습니다. 'dreamteller reindex %s'로 다시 만드세요.",
+	"%d file(s), %d chunk(s), %d tokens.":                                                       "파일 %d개, 청크 %d개, 토큰 %d개.",
+	"%d file(s) changed since indexing; run 'dreamteller reindex %s'.":                          "파일 %d개가 색인 뒤 바뀌었습니다. 'dreamteller reindex %s'를 실행하세요.",
+	"%s is not in the project":                                                                  "%s은(는) 프로젝트에 없습니다",
+	"%s is not indexed. Run 'dreamteller reindex %s' to index it.":                              "%s은(는) 색인되지 않았습니다. 'dreamteller reindex %s'로 색인하세요.",
+	"%s (%s): %d chunk(s), %d tokens, indexed as of %s":                                         "%s (%s): 청크 %d개, 토큰 %d개, %s 기준 색인",
+	"The file changed since (%s); run 'dreamteller reindex %s'.":                                "파일이 그 뒤 바뀌었습니다(%s). 'dreamteller reindex %s'를 실행하세요.",
+	"The file was removed since; run 'dreamteller reindex %s'.":                                 "파일이 그 뒤 삭제되었습니다. 'dreamteller reindex %s'를 실행하세요.",
+	"#%d %s %d tokens":                            "#%d %s 토큰 %d개",
+	"%-48s %-10s %3d chunk(s) %6d tokens  %s%s":   "%-48s %-10s 청크 %3d개 토큰 %6d개  %s%s",
+	"Run 'dreamteller reindex %s' to index them.": "'dreamteller reindex %s'로 색인하세요.",
+	"Model: %s (%s), context mode: %s":            "모델: %s (%s), 컨텍스트 모드: %s",
+	"Budget: %d tokens (system %d, context %d, history %d, response %d); max output %d": "예산: 토큰 %d개(시스템 %d, 컨텍스트 %d, 기록 %d, 응답 %d), 최대 출력 %d",
+	"Request: %d message(s), %d tokens; %d saved message(s) loaded":                     "요청: 메시지 %d개, 토큰 %d개, 저장된 메시지 %d개 불러옴",
+	"=== System prompt ===\n%s":                                 "=== 시스템 프롬프트 ===\n%s",
+	"=== Tools ===\n%s":                                         "=== 도구 ===\n%s",
+	"=== Retrieved chunks (%d) ===":                             "=== 검색된 청크 (%d) ===",
+	"(none; only hybrid mode retrieves chunks for the message)": "(없음. 메시지에 맞는 청크는 hybrid 모드에서만 검색합니다)",
+	"%s %s (%s, %d tokens)\n   %s":                              "%s %s (%s, 토큰 %d개)\n   %s",
+	"=== Messages after the system prompt ===":                  "=== 시스템 프롬프트 뒤의 메시지 ===",
+	"--- %s (%d tokens) ---\n%s":                                "--- %s (토큰 %d개) ---\n%s",
+	"Series '%s': %d book(s)":                                   "시리즈 '%s': 책 %d권",
+	"'%s' joined series '%s'. Shared files live in %s.":         "'%s'이(가) 시리즈 '%s'에 들어갔습니다. 공유 파일은 %s에 있습니다.",
+	"'%s' left series '%s'. Run 'dreamteller reindex %s' to drop the shared files from its index.": "'%s'이(가) 시리즈 '%s'에서 빠졌습니다. 'dreamteller reindex %s'로 색인에서 공유 파일을 빼세요.",
+	"No results.": "결과가 없습니다.",
+	"at %s":       "%s 기준",
+	"Warning: %v": "경고: %v",
+	"Warning: failed to generate some context files: %v": "경고: 일부 컨텍스트 파일을 생성하지 못했습니다: %v",
+	"Warning: failed to save the transcript of %q: %v":   "경고: %q의 대화록을 저장하지 못했습니다: %v",
+	"Warning: search index not updated: %v":              "경고: 검색 색인을 갱신하지 못했습니다: %v",
+	"%d issue(s).":                                       "문제 %d개.",
+
+	// Errors
+	"network access is disabled (--offline)":                         "네트워크 접근이 꺼져 있습니다(--offline)",
+	"no LLM provider configured":                                     "설정된 LLM 제공자가 없습니다",
+	"%w. Run 'dreamteller auth --provider %s' first":                 "%w. 먼저 'dreamteller auth --provider %s'를 실행하세요",
+	"%s health check failed":                                         "%s 상태 확인 실패",
+	"%s returned %d":                                                 "%s이(가) %d을(를) 반환했습니다",
+	"'%s' is not in series '%s'":                                     "'%s'은(는) 시리즈 '%s'에 없습니다",
+	"--from-chat requires an LLM provider: %w":                       "--from-chat에는 LLM 제공자가 필요합니다: %w",
+	"--from-prompt requires an LLM provider: %w":                     "--from-prompt에는 LLM 제공자가 필요합니다: %w",
+	"--message is required":                                          "--message가 필요합니다",
+	"--op translate needs a target language (--to ja)":               "--op translate에는 대상 언어가 필요합니다(--to ja)",
+	"--preset only applies to the local provider":                    "--preset은 local 제공자에만 쓸 수 있습니다",
+	"LLM request failed: %w":                                         "LLM 요청 실패: %w",
+	"Gemini setup failed: %w":                                        "Gemini 설정 실패: %w",
+	"OpenAI setup failed: %w":                                        "OpenAI 설정 실패: %w",
+	"Local setup failed: %w":                                         "로컬 설정 실패: %w",
+	"TUI error: %w":                                                  "TUI 오류: %w",
+	"arc report failed: %w":                                          "아크 보고 실패: %w",
+	"batch requires an LLM provider: %w":                             "batch에는 LLM 제공자가 필요합니다: %w",
+	"cannot test provider: %w":                                       "제공자를 테스트할 수 없습니다: %w",
+	"choose the conversations to import with --conversation <title>": "--conversation <title>로 가져올 대화를 고르세요",
+	"conflicting providers: %s and --provider %s":                    "제공자가 서로 다릅니다: %s 및 --provider %s",
+	"continuity comparison failed: %w":                               "연속성 비교 실패: %w",
+	"default selection failed: %w":                                   "기본값 선택 실패: %w",
+	"draft failed: %w":                                               "초안 작성 실패: %w",
+	"draft requires an LLM provider: %w":                             "draft에는 LLM 제공자가 필요합니다: %w",
+	"dreamteller crashed; a crash report was saved to %s. Reopen the project to restore your session": "dreamteller가 비정상 종료되었습니다. 충돌 보고서를 %s에 저장했습니다. 프로젝트를 다시 열면 세션이 복원됩니다",
+	"dreamteller crashed and the session could not be saved: %w":                                      "dreamteller가 비정상 종료되었습니다. 세션을 저장하지 못했습니다: %w",
+	"dreamteller crashed. Reopen the project to restore your session":                                 "dreamteller가 비정상 종료되었습니다. 프로젝트를 다시 열면 세션이 복원됩니다",
+	"encoding check failed: %w":               "인코딩 확인 실패: %w",
+	"error reading stdin: %w":                 "표준 입력을 읽는 중 오류: %w",
+	"errors: %s":                              "오류: %s",
+	"export failed: %w":                       "내보내기 실패: %w",
+	"export not yet implemented":              "내보내기는 아직 구현되지 않았습니다",
+	"failed to close project: %w":             "프로젝트를 닫지 못했습니다: %w",
+	"failed to create %s: %w":                 "%s을(를) 만들지 못했습니다: %w",
+	"failed to create project: %w":            "프로젝트를 만들지 못했습니다: %w",
+	"failed to delete project: %w":            "프로젝트를 삭제하지 못했습니다: %w",
+	"failed to extract the story: %w":         "스토리를 추출하지 못했습니다: %w",
+	"failed to initialize LLM provider: %w":   "LLM 제공자를 초기화하지 못했습니다: %w",
+	"failed to initialize app: %w":            "앱을 초기화하지 못했습니다: %w",
+	"failed to join series: %w":               "시리즈에 넣지 못했습니다: %w",
+	"failed to leave series: %w":              "시리즈에서 빼지 못했습니다: %w",
+	"failed to list projects: %w":             "프로젝트 목록을 가져오지 못했습니다: %w",
+	"failed to load config: %w":               "설정을 불러오지 못했습니다: %w",
+	"failed to open project: %w":              "프로젝트를 열지 못했습니다: %w",
+	"failed to open replay log: %w":           "재생 로그를 열지 못했습니다: %w",
+	"failed to parse prompt: %w":              "프롬프트를 해석하지 못했습니다: %w",
+	"failed to read export: %w":               "내보내기 파일을 읽지 못했습니다: %w",
+	"failed to read file %s: %w":              "파일 %s을(를) 읽지 못했습니다: %w",
+	"failed to read prompt file: %w":          "프롬프트 파일을 읽지 못했습니다: %w",
+	"failed to save config: %w":               "설정을 저장하지 못했습니다: %w",
+	"failed to update language: %w":           "언어를 바꾸지 못했습니다: %w",
+	"failed to update projects directory: %w": "프로젝트 디렉터리를 바꾸지 못했습니다: %w",
+	"failed to use draft model %s: %w":        "초안 모델 %s을(를) 쓰지 못했습니다: %w",
+	"feedback export failed: %w":              "피드백 내보내기 실패: %w",
+	"glossary check failed: %w":               "용어집 확인 실패: %w",
+	"invalid redaction rules: %w":             "잘못된 가림 규칙: %w",
+	"language selection failed: %w":           "언어 선택 실패: %w",
+	"model input failed: %w":                  "모델 입력 실패: %w",
+	"model name is required":                  "모델 이름이 필요합니다",
+	"model selection failed: %w":              "모델 선택 실패: %w",
+	"no conversation title contains %q":       "제목에 %q이(가) 들어간 대화가 없습니다",
+	"no models available":                     "쓸 수 있는 모델이 없습니다",
+	"pacing export failed: %w":                "페이싱 내보내기 실패: %w",
+	"pacing report failed: %w":                "페이싱 보고 실패: %w",
+	"please specify a project name":           "프로젝트 이름을 지정하세요",
+	"project '%s' already exists":             "'%s' 프로젝트가 이미 있습니다",
+	"project '%s' not found":                  "'%s' 프로젝트를 찾을 수 없습니다",
+	"prompt cannot be empty":                  "프롬프트가 비어 있을 수 없습니다",
+	"prompt only assembles requests; pass --dry-run (use 'dreamteller open %s' to chat)": "prompt는 요청을 조립만 합니다. --dry-run을 주세요(채팅은 'dreamteller open %s')",
+	"prompt setup failed: %w":                                 "프롬프트 설정 실패: %w",
+	"provider '%s' is not configured":                         "'%s' 제공자가 설정되지 않았습니다",
+	"provider selection failed: %w":                           "제공자 선택 실패: %w",
+	"reindex failed: %w":                                      "다시 색인 실패: %w",
+	"report failed: %w":                                       "보고 실패: %w",
+	"scaffold failed: %w":                                     "골격 작성 실패: %w",
+	"series check failed: %w":                                 "시리즈 확인 실패: %w",
+	"setup mode selection failed: %w":                         "설정 방식 선택 실패: %w",
+	"snapshot failed: %w":                                     "스냅샷 실패: %w",
+	"subplot report failed: %w":                               "서브플롯 보고 실패: %w",
+	"the export has no conversations with text":               "내보내기 파일에 텍스트가 있는 대화가 없습니다",
+	"typography fix failed: %w":                               "조판 수정 실패: %w",
+	"unknown preset: %s (available: %s)":                      "알 수 없는 프리셋: %s (사용 가능: %s)",
+	"unknown protocol: %s":                                    "알 수 없는 프로토콜: %s",
+	"unknown provider: %s (supported: openai, gemini, local)": "알 수 없는 제공자: %s (지원: openai, gemini, local)",
+	"unknown setup mode: %s":                                  "알 수 없는 설정 방식: %s",
+	"unsupported format: %s (use epub, pdf, or txt)":          "지원하지 않는 형식: %s (epub, pdf, txt 중 하나)",
+	"unsupported provider: %s":                                "지원하지 않는 제공자: %s",
+	"wizard setup failed: %w":                                 "마법사 설정 실패: %w",
+	"word frequency failed: %w":                               "단어 빈도 분석 실패: %w",
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/azyu/dreamteller/internal/llm"
@@ -14,51 +16,66 @@ import (
 // geminiModelCapabilities maps model names to their capabilities.
 var geminiModelCapabilities = map[string]llm.Capabilities{
 	"gemini-2.0-flash": {
-		SupportsTools:     true,
-		SupportsStreaming: true,
-		SupportsVision:    true,
-		MaxContextTokens:  1048576,
-		MaxOutputTokens:   8192,
-		TokenizerType:     "gemini",
-		Models:            []string{"gemini-2.0-flash"},
+		SupportsTools:            true,
+		SupportsStreaming:        true,
+		SupportsJSONMode:         true,
+		SupportsStructuredOutput: true,
+		SupportsSeed:             true,
+		SupportsVision:           true,
+		MaxContextTokens:         1048576,
+		MaxOutputTokens:          8192,
+		TokenizerType:            "gemini",
+		Models:                   []string{"gemini-2.0-flash"},
 	},
 	"gemini-2.0-flash-lite": {
-		SupportsTools:     true,
-		SupportsStreaming: true,
-		SupportsVision:    true,
-		MaxContextTokens:  1048576,
-		MaxOutputTokens:   8192,
-		TokenizerType:     "gemini",
-		Models:            []string{"gemini-2.0-flash-lite"},
+		SupportsTools:            true,
+		SupportsStreaming:        true,
+		SupportsJSONMode:         true,
+		SupportsStructuredOutput: true,
+		SupportsSeed:             true,
+		SupportsVision:           true,
+		MaxContextTokens:         1048576,
+		MaxOutputTokens:          8192,
+		TokenizerType:            "gemini",
+		Models:                   []string{"gemini-2.0-flash-lite"},
 	},
 	"gemini-2.5-pro": {
-		SupportsTools:     true,
-		SupportsStreaming: true,
-		SupportsVision:    true,
-		MaxContextTokens:  1048576,
-		MaxOutputTokens:   65536,
-		TokenizerType:     "gemini",
-		Models:            []string{"gemini-2.5-pro"},
+		SupportsTools:            true,
+		SupportsStreaming:        true,
+		SupportsJSONMode:         true,
+		SupportsStructuredOutput: true,
+		SupportsSeed:             true,
+		SupportsVision:           true,
+		MaxContextTokens:         1048576,
+		MaxOutputTokens:          65536,
+		TokenizerType:            "gemini",
+		Models:                   []string{"gemini-2.5-pro"},
 	},
 	"gemini-2.5-flash": {
-		SupportsTools:     true,
-		SupportsStreaming: true,
-		SupportsVision:    true,
-		MaxContextTokens:  1048576,
-		MaxOutputTokens:   65536,
-		TokenizerType:     "gemini",
-		Models:            []string{"gemini-2.5-flash"},
+		SupportsTools:            true,
+		SupportsStreaming:        true,
+		SupportsJSONMode:         true,
+		SupportsStructuredOutput: true,
+		SupportsSeed:             true,
+		SupportsVision:           true,
+		MaxContextTokens:         1048576,
+		MaxOutputTokens:          65536,
+		TokenizerType:            "gemini",
+		Models:                   []string{"gemini-2.5-flash"},
 	},
 }
 
 // defaultGeminiCapabilities are used when the model is not in the known list.
 var defaultGeminiCapabilities = llm.Capabilities{
-	SupportsTools:     true,
-	SupportsStreaming: true,
-	SupportsVision:    true,
-	MaxContextTokens:  128000,
-	MaxOutputTokens:   8192,
-	TokenizerType:     "gemini",
+	SupportsTools:            true,
+	SupportsStreaming:        true,
+	SupportsJSONMode:         true,
+	SupportsStructuredOutput: true,
+	SupportsSeed:             true,
+	SupportsVision:           true,
+	MaxContextTokens:         128000,
+	MaxOutputTokens:          8192,
+	TokenizerType:            "gemini",
 }
 
 // GeminiAdapter implements the Provider interface for Google's Gemini API.
@@ -132,6 +149,10 @@ func (a *GeminiAdapter) processStream(ctx context.Context, contents []*genai.Con
 
 	iter := a.client.Models.GenerateContentStream(ctx, a.model, contents, config)
 
+	// Gemini sends each function call whole, so every call gets its own
+	// index across the stream to keep the accumulator from merging them.
+	callIndex := 0
+
 	for result, err := range iter {
 		select {
 		case <-ctx.Done():
@@ -151,10 +172,12 @@ func (a *GeminiAdapter) processStream(ctx context.Context, contents []*genai.Con
 			return
 		}
 
-		chunk := a.convertStreamChunk(result)
-		chunks <- chunk
-
-		if chunk.Done {
+		done := false
+		for _, chunk := range a.convertStreamChunk(result, &callIndex) {
+			chunks <- chunk
+			done = done || chunk.Done
+		}
+		if done {
 			return
 		}
 	}
@@ -197,6 +220,10 @@ func (a *GeminiAdapter) convertMessages(messages []llm.ChatMessage) ([]*genai.Co
 	var systemInstruction *genai.Content
 	var contents []*genai.Content
 
+	// Tool results only carry the call ID, so remember which function each
+	// call ID belongs to.
+	callNames := make(map[string]string)
+
 	for _, msg := range messages {
 		switch msg.Role {
 		case llm.RoleSystem:
@@ -231,15 +258,19 @@ func (a *GeminiAdapter) convertMessages(messages []llm.ChatMessage) ([]*genai.Co
 				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
 					args = make(map[string]any)
 				}
+				callNames[tc.ID] = tc.Function.Name
 				content.Parts = append(content.Parts, &genai.Part{
 					FunctionCall: &genai.FunctionCall{
+						ID:   geminiCallID(tc.ID),
 						Name: tc.Function.Name,
 						Args: args,
 					},
 				})
 			}
 
-			contents = append(contents, content)
+			if len(content.Parts) > 0 {
+				contents = append(contents, content)
+			}
 
 		case llm.RoleTool:
 			// Gemini expects function responses in a specific format
@@ -249,16 +280,28 @@ func (a *GeminiAdapter) convertMessages(messages []llm.ChatMessage) ([]*genai.Co
 				responseMap = map[string]any{"output": msg.Content}
 			}
 
-			contents = append(contents, &genai.Content{
-				Role: "user",
-				Parts: []*genai.Part{
-					{
-						FunctionResponse: &genai.FunctionResponse{
-							Name:     msg.Name,
-							Response: responseMap,
-						},
-					},
+			name := msg.Name
+			if name == "" {
+				name = callNames[msg.ToolCallID]
+			}
+
+			part := &genai.Part{
+				FunctionResponse: &genai.FunctionResponse{
+					ID:       geminiCallID(msg.ToolCallID),
+					Name:     name,
+					Response: responseMap,
 				},
+			}
+
+			// Responses to parallel calls belong in a single user turn.
+			if n := len(contents); n > 0 && isFunctionResponseTurn(contents[n-1]) {
+				contents[n-1].Parts = append(contents[n-1].Parts, part)
+				continue
+			}
+
+			contents = append(contents, &genai.Content{
+				Role:  "user",
+				Parts: []*genai.Part{part},
 			})
 		}
 	}
@@ -266,6 +309,28 @@ func (a *GeminiAdapter) convertMessages(messages []llm.ChatMessage) ([]*genai.Co
 	return contents, systemInstruction
 }
 
+// isFunctionResponseTurn reports whether content holds only function responses.
+func isFunctionResponseTurn(content *genai.Content) bool {
+	if content.Role != "user" || len(content.Parts) == 0 {
+		return false
+	}
+	for _, part := range content.Parts {
+		if part.FunctionResponse == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// geminiCallID returns the Gemini-side ID for a tool call ID. IDs synthesized
+// by this adapter (call_<name>_<n>) were never issued by Gemini and are dropped.
+func geminiCallID(id string) string {
+	if strings.HasPrefix(id, "call_") {
+		return ""
+	}
+	return id
+}
+
 // buildConfig creates the GenerateContentConfig from our ChatRequest.
 func (a *GeminiAdapter) buildConfig(req llm.ChatRequest, systemInstruction *genai.Content) *genai.GenerateContentConfig {
 	config := &genai.GenerateContentConfig{}
@@ -282,6 +347,10 @@ func (a *GeminiAdapter) buildConfig(req llm.ChatRequest, systemInstruction *gena
 		config.Temperature = genai.Ptr(float32(req.Temperature))
 	}
 
+	if req.Seed != nil {
+		config.Seed = genai.Ptr(int32(*req.Seed))
+	}
+
 	if len(req.Stop) > 0 {
 		config.StopSequences = req.Stop
 	}
@@ -296,14 +365,23 @@ func (a *GeminiAdapter) buildConfig(req llm.ChatRequest, systemInstruction *gena
 
 	if len(req.Tools) > 0 {
 		config.Tools = a.convertTools(req.Tools)
+		config.ToolConfig = a.convertToolChoice(req.ToolChoice)
+	}
+
+	if req.JSONMode || req.ResponseSchema != nil {
+		config.ResponseMIMEType = "application/json"
+	}
+	if req.ResponseSchema != nil {
+		config.ResponseJsonSchema = req.ResponseSchema.Schema
 	}
 
 	return config
 }
 
 // convertTools converts our ToolDefinition slice to Gemini's Tool format.
+// All functions are declared on a single Tool, as the API expects.
 func (a *GeminiAdapter) convertTools(tools []llm.ToolDefinition) []*genai.Tool {
-	var geminiTools []*genai.Tool
+	var decls []*genai.FunctionDeclaration
 
 	for _, tool := range tools {
 		if tool.Type != "function" {
@@ -320,12 +398,34 @@ func (a *GeminiAdapter) convertTools(tools []llm.ToolDefinition) []*genai.Tool {
 			funcDecl.ParametersJsonSchema = tool.Function.Parameters
 		}
 
-		geminiTools = append(geminiTools, &genai.Tool{
-			FunctionDeclarations: []*genai.FunctionDeclaration{funcDecl},
-		})
+		decls = append(decls, funcDecl)
 	}
 
-	return geminiTools
+	if len(decls) == 0 {
+		return nil
+	}
+
+	return []*genai.Tool{{FunctionDeclarations: decls}}
+}
+
+// convertToolChoice maps ChatRequest.ToolChoice to Gemini's function calling mode.
+func (a *GeminiAdapter) convertToolChoice(choice string) *genai.ToolConfig {
+	cfg := &genai.FunctionCallingConfig{}
+
+	switch choice {
+	case "", "auto":
+		return nil
+	case "none":
+		cfg.Mode = genai.FunctionCallingConfigModeNone
+	case "required":
+		cfg.Mode = genai.FunctionCallingConfigModeAny
+	default:
+		// Specific tool name
+		cfg.Mode = genai.FunctionCallingConfigModeAny
+		cfg.AllowedFunctionNames = []string{choice}
+	}
+
+	return &genai.ToolConfig{FunctionCallingConfig: cfg}
 }
 
 // convertResponse converts Gemini's response to our ChatResponse format.
@@ -354,7 +454,7 @@ func (a *GeminiAdapter) convertResponse(result *genai.GenerateContentResponse) (
 			if part.FunctionCall != nil {
 				argsJSON, _ := json.Marshal(part.FunctionCall.Args)
 				toolCalls = append(toolCalls, llm.ToolCall{
-					ID:   fmt.Sprintf("call_%s_%d", part.FunctionCall.Name, len(toolCalls)),
+					ID:   toolCallID(part.FunctionCall, len(toolCalls)),
 					Type: "function",
 					Function: llm.FunctionCall{
 						Name:      part.FunctionCall.Name,
@@ -371,6 +471,11 @@ func (a *GeminiAdapter) convertResponse(result *genai.GenerateContentResponse) (
 		ToolCalls: toolCalls,
 	}
 
+	// Gemini reports STOP for function calls; match the OpenAI convention.
+	if len(toolCalls) > 0 && response.FinishReason == llm.FinishReasonStop {
+		response.FinishReason = llm.FinishReasonToolCalls
+	}
+
 	// Convert usage
 	if result.UsageMetadata != nil {
 		response.Usage = llm.TokenUsage{
@@ -384,43 +489,51 @@ func (a *GeminiAdapter) convertResponse(result *genai.GenerateContentResponse) (
 }
 
 // convertStreamChunk converts a Gemini streaming response to our StreamChunk format.
-func (a *GeminiAdapter) convertStreamChunk(result *genai.GenerateContentResponse) llm.StreamChunk {
+// Each function call becomes its own chunk; callIndex numbers calls across the stream.
+func (a *GeminiAdapter) convertStreamChunk(result *genai.GenerateContentResponse, callIndex *int) []llm.StreamChunk {
 	chunk := llm.StreamChunk{}
 
 	if result.PromptFeedback != nil && result.PromptFeedback.BlockReason != "" {
 		chunk.FinishReason = llm.FinishReasonContentFilter
 		chunk.Done = true
-		return chunk
+		return []llm.StreamChunk{chunk}
 	}
 
 	if len(result.Candidates) == 0 {
-		return chunk
+		return []llm.StreamChunk{chunk}
 	}
 
 	candidate := result.Candidates[0]
 
+	var callChunks []llm.StreamChunk
 	if candidate.Content != nil {
-		for i, part := range candidate.Content.Parts {
+		for _, part := range candidate.Content.Parts {
 			if part.Text != "" {
 				chunk.Delta += part.Text
 			}
 			if part.FunctionCall != nil {
 				argsJSON, _ := json.Marshal(part.FunctionCall.Args)
-				chunk.ToolCall = &llm.ToolCallDelta{
-					Index: i,
-					ID:    fmt.Sprintf("call_%s_%d", part.FunctionCall.Name, i),
-					Type:  "function",
-					Function: &llm.FunctionCallDelta{
-						Name:      part.FunctionCall.Name,
-						Arguments: string(argsJSON),
+				callChunks = append(callChunks, llm.StreamChunk{
+					ToolCall: &llm.ToolCallDelta{
+						Index: *callIndex,
+						ID:    toolCallID(part.FunctionCall, *callIndex),
+						Type:  "function",
+						Function: &llm.FunctionCallDelta{
+							Name:      part.FunctionCall.Name,
+							Arguments: string(argsJSON),
+						},
 					},
-				}
+				})
+				*callIndex++
 			}
 		}
 	}
 
 	if candidate.FinishReason != "" {
 		chunk.FinishReason = a.convertFinishReason(candidate.FinishReason)
+		if *callIndex > 0 && chunk.FinishReason == llm.FinishReasonStop {
+			chunk.FinishReason = llm.FinishReasonToolCalls
+		}
 		chunk.Done = true
 	}
 
@@ -432,7 +545,25 @@ func (a *GeminiAdapter) convertStreamChunk(result *genai.GenerateContentResponse
 		}
 	}
 
-	return chunk
+	// Text first, then tool calls, then the chunk carrying Done/usage.
+	var out []llm.StreamChunk
+	if chunk.Delta != "" {
+		out = append(out, llm.StreamChunk{Delta: chunk.Delta})
+		chunk.Delta = ""
+	}
+	out = append(out, callChunks...)
+	if chunk.Done || chunk.Usage != nil || len(out) == 0 {
+		out = append(out, chunk)
+	}
+	return out
+}
+
+// toolCallID returns the ID Gemini assigned to a call, or synthesizes one.
+func toolCallID(call *genai.FunctionCall, n int) string {
+	if call.ID != "" {
+		return call.ID
+	}
+	return fmt.Sprintf("call_%s_%d", call.Name, n)
 }
 
 // convertFinishReason converts Gemini's finish reason to our format.
@@ -480,5 +611,22 @@ func (a *GeminiAdapter) ModelName() string {
 	return a.model
 }
 
+// ListModels returns the models that support content generation.
+func (a *GeminiAdapter) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	for model, err := range a.client.Models.All(ctx) {
+		if err != nil {
+			return nil, a.wrapError(err)
+		}
+		if !slices.Contains(model.SupportedActions, "generateContent") {
+			continue
+		}
+		models = append(models, strings.TrimPrefix(model.Name, "models/"))
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
 // Verify GeminiAdapter implements Provider interface.
 var _ llm.Provider = (*GeminiAdapter)(nil)
+var _ llm.ModelLister = (*GeminiAdapter)(nil)
@@ -0,0 +1,136 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func TestGeminiConvertMessages(t *testing.T) {
+	a := &GeminiAdapter{model: "gemini-2.5-flash"}
+
+	t.Run("names tool results by their call and merges parallel responses", func(t *testing.T) {
+		contents, system := a.convertMessages([]llm.ChatMessage{
+			llm.NewSystemMessage("You are a novelist."),
+			llm.NewUserMessage("Who is Mira?"),
+			{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{
+				{ID: "fc-1", Type: "function", Function: llm.FunctionCall{Name: llm.ToolSearchContext, Arguments: `{"query": "Mira"}`}},
+				{ID: "call_get_character_voice_1", Type: "function", Function: llm.FunctionCall{Name: llm.ToolGetCharacterVoice, Arguments: `{"character": "Mira"}`}},
+			}},
+			{Role: llm.RoleTool, ToolCallID: "fc-1", Content: "A harbor pilot."},
+			{Role: llm.RoleTool, ToolCallID: "call_get_character_voice_1", Content: `{"voice": "clipped"}`},
+		})
+
+		require.NotNil(t, system)
+		assert.Equal(t, "You are a novelist.", system.Parts[0].Text)
+		require.Len(t, contents, 3)
+
+		calls := contents[1]
+		assert.Equal(t, "model", calls.Role)
+		require.Len(t, calls.Parts, 2)
+		assert.Equal(t, "fc-1", calls.Parts[0].FunctionCall.ID)
+		assert.Equal(t, map[string]any{"query": "Mira"}, calls.Parts[0].FunctionCall.Args)
+		assert.Empty(t, calls.Parts[1].FunctionCall.ID, "synthesized IDs are not sent to Gemini")
+
+		responses := contents[2]
+		assert.Equal(t, "user", responses.Role)
+		require.Len(t, responses.Parts, 2, "parallel responses share one turn")
+		first, second := responses.Parts[0].FunctionResponse, responses.Parts[1].FunctionResponse
+		assert.Equal(t, "fc-1", first.ID)
+		assert.Equal(t, llm.ToolSearchContext, first.Name)
+		assert.Equal(t, map[string]any{"output": "A harbor pilot."}, first.Response)
+		assert.Empty(t, second.ID)
+		assert.Equal(t, llm.ToolGetCharacterVoice, second.Name)
+		assert.Equal(t, map[string]any{"voice": "clipped"}, second.Response)
+	})
+}
+
+func TestGeminiConvertTools(t *testing.T) {
+	a := &GeminiAdapter{model: "gemini-2.5-flash"}
+
+	tools := a.convertTools(llm.ChatTools())
+	require.Len(t, tools, 1, "all functions are declared on one tool")
+	assert.Len(t, tools[0].FunctionDeclarations, len(llm.ChatTools()))
+	assert.Equal(t, llm.ChatTools()[0].Function.Name, tools[0].FunctionDeclarations[0].Name)
+
+	assert.Nil(t, a.convertTools(nil))
+}
+
+func TestGeminiConvertToolChoice(t *testing.T) {
+	a := &GeminiAdapter{model: "gemini-2.5-flash"}
+
+	assert.Nil(t, a.convertToolChoice(""))
+	assert.Nil(t, a.convertToolChoice("auto"))
+	assert.Equal(t, genai.FunctionCallingConfigModeNone, a.convertToolChoice("none").FunctionCallingConfig.Mode)
+	assert.Equal(t, genai.FunctionCallingConfigModeAny, a.convertToolChoice("required").FunctionCallingConfig.Mode)
+
+	named := a.convertToolChoice(llm.ToolSearchContext).FunctionCallingConfig
+	assert.Equal(t, genai.FunctionCallingConfigModeAny, named.Mode)
+	assert.Equal(t, []string{llm.ToolSearchContext}, named.AllowedFunctionNames)
+}
+
+func TestGeminiConvertStreamChunk(t *testing.T) {
+	a := &GeminiAdapter{model: "gemini-2.5-flash"}
+	callIndex := 0
+
+	chunks := a.convertStreamChunk(&genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{
+			{Text: "Looking her up."},
+			{FunctionCall: &genai.FunctionCall{ID: "fc-1", Name: llm.ToolSearchContext, Args: map[string]any{"query": "Mira"}}},
+		}}}},
+	}, &callIndex)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "Looking her up.", chunks[0].Delta)
+	require.NotNil(t, chunks[1].ToolCall)
+	assert.Equal(t, 0, chunks[1].ToolCall.Index)
+	assert.Equal(t, "fc-1", chunks[1].ToolCall.ID)
+	assert.JSONEq(t, `{"query": "Mira"}`, chunks[1].ToolCall.Function.Arguments)
+
+	chunks = a.convertStreamChunk(&genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content: &genai.Content{Parts: []*genai.Part{
+				{FunctionCall: &genai.FunctionCall{Name: llm.ToolGetCharacterVoice, Args: map[string]any{"character": "Mira"}}},
+			}},
+			FinishReason: genai.FinishReasonStop,
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, CandidatesTokenCount: 4, TotalTokenCount: 14},
+	}, &callIndex)
+	require.Len(t, chunks, 2)
+	require.NotNil(t, chunks[0].ToolCall)
+	assert.Equal(t, 1, chunks[0].ToolCall.Index, "calls are numbered across the stream")
+	assert.Equal(t, "call_get_character_voice_1", chunks[0].ToolCall.ID)
+
+	last := chunks[1]
+	assert.True(t, last.Done)
+	assert.Equal(t, llm.FinishReasonToolCalls, last.FinishReason, "STOP after function calls")
+	require.NotNil(t, last.Usage)
+	assert.Equal(t, 14, last.Usage.TotalTokens)
+}
+
+func TestGeminiConvertResponse(t *testing.T) {
+	a := &GeminiAdapter{model: "gemini-2.5-flash"}
+	parts := []*genai.Part{
+		{FunctionCall: &genai.FunctionCall{ID: "fc-1", Name: llm.ToolSearchContext, Args: map[string]any{"query": "Mira"}}},
+		{FunctionCall: &genai.FunctionCall{Name: llm.ToolGetCharacterVoice, Args: map[string]any{"character": "Mira"}}},
+	}
+
+	resp, err := a.convertResponse(&genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: parts}, FinishReason: genai.FinishReasonStop}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, llm.FinishReasonToolCalls, resp.FinishReason)
+
+	// Chat and Stream agree on the IDs of the same calls.
+	callIndex := 0
+	streamed := a.convertStreamChunk(&genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: parts}}},
+	}, &callIndex)
+	require.Len(t, resp.Message.ToolCalls, 2)
+	require.Len(t, streamed, 2)
+	assert.Equal(t, "fc-1", resp.Message.ToolCalls[0].ID)
+	assert.Equal(t, streamed[0].ToolCall.ID, resp.Message.ToolCalls[0].ID)
+	assert.Equal(t, streamed[1].ToolCall.ID, resp.Message.ToolCalls[1].ID)
+}
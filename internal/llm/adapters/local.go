@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -80,6 +81,7 @@ type openAIChatRequest struct {
 	Temperature float64             `json:"temperature,omitempty"`
 	Stream      bool                `json:"stream"`
 	Stop        []string            `json:"stop,omitempty"`
+	Seed        *int                `json:"seed,omitempty"`
 }
 
 // openAIChatMessage represents a message in the OpenAI format.
@@ -323,6 +325,8 @@ func (a *LocalAdapter) Capabilities() llm.Capabilities {
 	return llm.Capabilities{
 		SupportsTools:     false, // Most local models don't support tool calling
 		SupportsStreaming: true,
+		SupportsJSONMode:  false, // response_format support varies by server
+		SupportsSeed:      true,  // Servers without seed support ignore it
 		SupportsVision:    false, // Conservative default; varies by model
 		MaxContextTokens:  8192,  // Conservative default; varies by model
 		MaxOutputTokens:   2048,  // Conservative default; varies by model
@@ -364,6 +368,7 @@ func (a *LocalAdapter) buildRequest(req llm.ChatRequest, stream bool) openAIChat
 		Temperature: temperature,
 		Stream:      stream,
 		Stop:        req.Stop,
+		Seed:        req.Seed,
 	}
 }
 
@@ -403,5 +408,40 @@ func (a *LocalAdapter) BaseURL() string {
 	return a.baseURL
 }
 
+// ListModels returns the models the server lists at /v1/models.
+func (a *LocalAdapter) ListModels(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/v1/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.handleErrorResponse(resp)
+	}
+
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	models := make([]string, len(result.Data))
+	for i, model := range result.Data {
+		models[i] = model.ID
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
 // Verify LocalAdapter implements Provider interface.
 var _ llm.Provider = (*LocalAdapter)(nil)
+var _ llm.ModelLister = (*LocalAdapter)(nil)
@@ -0,0 +1,85 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/azyu/dreamteller/internal/llm"
+)
+
+// UsageRecorder receives the tokens a request used, as the provider
+// reported them.
+type UsageRecorder func(model string, usage llm.TokenUsage)
+
+// MeteringProvider wraps another provider and reports the token usage of
+// every response, e.g. to count it against a project's quota.
+type MeteringProvider struct {
+	inner  llm.Provider
+	model  string
+	record UsageRecorder
+}
+
+// NewMeteringProvider creates a provider that reports the usage of inner,
+// serving model, to record.
+func NewMeteringProvider(inner llm.Provider, model string, record UsageRecorder) *MeteringProvider {
+	return &MeteringProvider{inner: inner, model: model, record: record}
+}
+
+// Chat forwards the request and reports the response's usage.
+func (m *MeteringProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	resp, err := m.inner.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	m.report(resp.Model, resp.Usage)
+	return resp, nil
+}
+
+// Stream forwards the request and reports the usage the stream carries,
+// which providers send with its last chunks.
+func (m *MeteringProvider) Stream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	upstream, err := m.inner.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan llm.StreamChunk, 100)
+
+	go func() {
+		defer close(out)
+
+		var usage *llm.TokenUsage
+		for chunk := range upstream {
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			out <- chunk
+		}
+		if usage != nil {
+			m.report("", *usage)
+		}
+	}()
+
+	return out, nil
+}
+
+// report records usage under model, or the model the provider serves when
+// the response did not name one.
+func (m *MeteringProvider) report(model string, usage llm.TokenUsage) {
+	if usage.PromptTokens+usage.CompletionTokens == 0 {
+		return
+	}
+	if model == "" {
+		model = m.model
+	}
+	m.record(model, usage)
+}
+
+// Capabilities returns the wrapped provider's capabilities.
+func (m *MeteringProvider) Capabilities() llm.Capabilities {
+	return m.inner.Capabilities()
+}
+
+// Close closes the wrapped provider.
+func (m *MeteringProvider) Close() error {
+	return m.inner.Close()
+}
@@ -0,0 +1,506 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+)
+
+const (
+	// defaultOllamaNumCtx overrides Ollama's small built-in context window so
+	// that assembled prompts are not silently truncated.
+	defaultOllamaNumCtx = 8192
+
+	// DefaultOllamaBaseURL is where a stock Ollama install listens.
+	DefaultOllamaBaseURL = "http://localhost:11434"
+)
+
+// OllamaAdapter implements the Provider interface using Ollama's native
+// /api/chat endpoint, which exposes keep_alive and model options that the
+// OpenAI-compatible endpoint does not.
+type OllamaAdapter struct {
+	client    *http.Client
+	baseURL   string
+	model     string
+	keepAlive string
+	numCtx    int
+}
+
+// OllamaOption configures an OllamaAdapter.
+type OllamaOption func(*OllamaAdapter)
+
+// WithOllamaKeepAlive controls how long Ollama keeps the model loaded after a
+// request: a duration such as "5m" or "1h", "-1m" to keep it loaded
+// indefinitely, or a plain number of seconds such as "0" or "-1".
+func WithOllamaKeepAlive(keepAlive string) OllamaOption {
+	return func(a *OllamaAdapter) {
+		a.keepAlive = keepAlive
+	}
+}
+
+// WithOllamaNumCtx sets the context window (num_ctx) requested from Ollama.
+func WithOllamaNumCtx(numCtx int) OllamaOption {
+	return func(a *OllamaAdapter) {
+		if numCtx > 0 {
+			a.numCtx = numCtx
+		}
+	}
+}
+
+// WithOllamaTimeout sets the timeout for non-streaming requests.
+func WithOllamaTimeout(timeout time.Duration) OllamaOption {
+	return func(a *OllamaAdapter) {
+		a.client.Timeout = timeout
+	}
+}
+
+// WithOllamaHTTPClient sets a custom HTTP client.
+func WithOllamaHTTPClient(client *http.Client) OllamaOption {
+	return func(a *OllamaAdapter) {
+		a.client = client
+	}
+}
+
+// NewOllamaAdapter creates a new OllamaAdapter.
+// The baseURL should point to the Ollama server (e.g., "http://localhost:11434").
+func NewOllamaAdapter(baseURL, model string, opts ...OllamaOption) *OllamaAdapter {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+
+	adapter := &OllamaAdapter{
+		client: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		numCtx:  defaultOllamaNumCtx,
+	}
+
+	for _, opt := range opts {
+		opt(adapter)
+	}
+
+	return adapter
+}
+
+// ollamaChatRequest represents a request to /api/chat.
+type ollamaChatRequest struct {
+	Model     string              `json:"model"`
+	Messages  []ollamaChatMessage `json:"messages"`
+	Stream    bool                `json:"stream"`
+	Format    interface{}         `json:"format,omitempty"`
+	KeepAlive interface{}         `json:"keep_alive,omitempty"`
+	Options   ollamaOptions       `json:"options"`
+}
+
+// ollamaChatMessage represents a message in Ollama's format.
+type ollamaChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ollamaOptions holds the model parameters Ollama accepts per request.
+type ollamaOptions struct {
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature float64  `json:"temperature,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+}
+
+// ollamaChatResponse is both the non-streaming response and each line of a
+// streaming response.
+type ollamaChatResponse struct {
+	Model           string            `json:"model"`
+	Message         ollamaChatMessage `json:"message"`
+	Done            bool              `json:"done"`
+	DoneReason      string            `json:"done_reason"`
+	PromptEvalCount int               `json:"prompt_eval_count"`
+	EvalCount       int               `json:"eval_count"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// OllamaPullProgress reports the status of a model download.
+type OllamaPullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Percent returns the download progress in percent, or -1 when unknown.
+func (p OllamaPullProgress) Percent() int {
+	if p.Total <= 0 {
+		return -1
+	}
+	return int(p.Completed * 100 / p.Total)
+}
+
+// Chat sends a chat request and returns the complete response.
+func (a *OllamaAdapter) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	resp, err := a.post(ctx, a.client, "/api/chat", a.buildRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var ollamaResp ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if ollamaResp.Error != "" {
+		return nil, fmt.Errorf("%w: %s", llm.ErrAPIError, ollamaResp.Error)
+	}
+
+	return &llm.ChatResponse{
+		Message: llm.ChatMessage{
+			Role:    llm.RoleAssistant,
+			Content: ollamaResp.Message.Content,
+		},
+		Usage:        ollamaUsage(ollamaResp),
+		FinishReason: convertOllamaDoneReason(ollamaResp.DoneReason),
+		Model:        ollamaResp.Model,
+	}, nil
+}
+
+// Stream sends a chat request and returns a channel of streaming chunks.
+func (a *OllamaAdapter) Stream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	// Use a client without timeout for streaming - context handles cancellation
+	resp, err := a.post(ctx, &http.Client{Transport: a.client.Transport}, "/api/chat", a.buildRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan llm.StreamChunk, 100)
+
+	go a.processStream(ctx, resp.Body, chunks)
+
+	return chunks, nil
+}
+
+// processStream reads Ollama's newline-delimited JSON stream.
+func (a *OllamaAdapter) processStream(ctx context.Context, body io.ReadCloser, chunks chan<- llm.StreamChunk) {
+	defer close(chunks)
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			chunks <- llm.StreamChunk{
+				Error: ctx.Err(),
+				Done:  true,
+			}
+			return
+		default:
+		}
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var resp ollamaChatResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			continue
+		}
+
+		if resp.Error != "" {
+			chunks <- llm.StreamChunk{
+				Error: fmt.Errorf("%w: %s", llm.ErrAPIError, resp.Error),
+				Done:  true,
+			}
+			return
+		}
+
+		chunk := llm.StreamChunk{Delta: resp.Message.Content}
+		if resp.Done {
+			usage := ollamaUsage(resp)
+			chunk.Usage = &usage
+			chunk.FinishReason = convertOllamaDoneReason(resp.DoneReason)
+			chunk.Done = true
+		}
+
+		chunks <- chunk
+		if chunk.Done {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		chunks <- llm.StreamChunk{
+			Error: fmt.Errorf("failed to read stream: %w", err),
+			Done:  true,
+		}
+		return
+	}
+
+	chunks <- llm.StreamChunk{Done: true}
+}
+
+// HasModel reports whether the configured model is present on the server.
+func (a *OllamaAdapter) HasModel(ctx context.Context) (bool, error) {
+	resp, err := a.post(ctx, a.client, "/api/show", map[string]string{"model": a.model})
+	if err != nil {
+		if errors.Is(err, llm.ErrModelNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// ListModels returns the models pulled on the server.
+func (a *OllamaAdapter) ListModels(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, a.handleErrorResponse(resp)
+	}
+
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	models := make([]string, len(result.Models))
+	for i, model := range result.Models {
+		models[i] = model.Name
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// Pull downloads the configured model, calling progress for each status
+// update Ollama reports. progress may be nil.
+func (a *OllamaAdapter) Pull(ctx context.Context, progress func(OllamaPullProgress)) error {
+	resp, err := a.post(ctx, &http.Client{Transport: a.client.Transport}, "/api/pull", map[string]interface{}{
+		"model":  a.model,
+		"stream": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", a.model, err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var p OllamaPullProgress
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			continue
+		}
+		if p.Error != "" {
+			return fmt.Errorf("failed to pull %s: %s", a.model, p.Error)
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to pull %s: %w", a.model, err)
+	}
+	return nil
+}
+
+// Capabilities returns the provider's capabilities.
+func (a *OllamaAdapter) Capabilities() llm.Capabilities {
+	return llm.Capabilities{
+		SupportsTools:            false, // Tool support varies by model; use the text fallback
+		SupportsStreaming:        true,
+		SupportsJSONMode:         true,
+		SupportsStructuredOutput: true,
+		SupportsSeed:             true,
+		SupportsVision:           false, // Conservative default; varies by model
+		MaxContextTokens:         a.numCtx,
+		MaxOutputTokens:          defaultMaxTokens,
+		TokenizerType:            "", // Unknown for local models
+		Models:                   []string{a.model},
+	}
+}
+
+// Close releases resources held by the adapter.
+func (a *OllamaAdapter) Close() error {
+	// No persistent resources to clean up
+	return nil
+}
+
+// ModelName returns the name of the model being used.
+func (a *OllamaAdapter) ModelName() string {
+	return a.model
+}
+
+// BaseURL returns the base URL of the server.
+func (a *OllamaAdapter) BaseURL() string {
+	return a.baseURL
+}
+
+// buildRequest converts our ChatRequest to Ollama's format.
+func (a *OllamaAdapter) buildRequest(req llm.ChatRequest, stream bool) ollamaChatRequest {
+	messages := make([]ollamaChatMessage, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		// Ollama has no tool role without native tools; fold results into user turns.
+		role := msg.Role
+		if role == llm.RoleTool {
+			role = llm.RoleUser
+		}
+		messages = append(messages, ollamaChatMessage{
+			Role:    role,
+			Content: msg.Content,
+		})
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	temperature := req.Temperature
+	if temperature == 0 {
+		temperature = defaultTemperature
+	}
+
+	ollamaReq := ollamaChatRequest{
+		Model:     a.model,
+		Messages:  messages,
+		Stream:    stream,
+		KeepAlive: ollamaKeepAlive(a.keepAlive),
+		Options: ollamaOptions{
+			NumCtx:      a.numCtx,
+			NumPredict:  maxTokens,
+			Temperature: temperature,
+			Stop:        req.Stop,
+			Seed:        req.Seed,
+		},
+	}
+
+	switch {
+	case req.ResponseSchema != nil:
+		ollamaReq.Format = req.ResponseSchema.Schema
+	case req.JSONMode:
+		ollamaReq.Format = "json"
+	}
+
+	return ollamaReq
+}
+
+// post sends a JSON request and returns the response when it succeeded.
+func (a *OllamaAdapter) post(ctx context.Context, client *http.Client, path string, payload interface{}) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("request timed out: %w", err)
+		}
+		if errors.Is(err, context.Canceled) {
+			return nil, fmt.Errorf("request canceled: %w", err)
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, a.handleErrorResponse(resp)
+	}
+
+	return resp, nil
+}
+
+// handleErrorResponse processes error responses from the API.
+func (a *OllamaAdapter) handleErrorResponse(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	message := string(body)
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+		message = errResp.Error
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %q (run `ollama pull %s`)", llm.ErrModelNotFound, a.model, a.model)
+	case http.StatusTooManyRequests:
+		return llm.ErrRateLimited
+	default:
+		return fmt.Errorf("%w: HTTP %d - %s", llm.ErrAPIError, resp.StatusCode, message)
+	}
+}
+
+// ollamaKeepAlive converts a keep-alive setting to the form Ollama accepts:
+// a bare number is sent as a JSON number of seconds, since Ollama rejects
+// duration strings without a unit, and anything else as a duration string.
+func ollamaKeepAlive(keepAlive string) interface{} {
+	if keepAlive == "" {
+		return nil
+	}
+	if seconds, err := strconv.Atoi(keepAlive); err == nil {
+		return seconds
+	}
+	return keepAlive
+}
+
+// ollamaUsage extracts token usage from a final response.
+func ollamaUsage(resp ollamaChatResponse) llm.TokenUsage {
+	return llm.TokenUsage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	}
+}
+
+// convertOllamaDoneReason maps Ollama's done_reason to our finish reasons.
+func convertOllamaDoneReason(reason string) string {
+	switch reason {
+	case "length":
+		return llm.FinishReasonLength
+	case "", "stop", "unload":
+		return llm.FinishReasonStop
+	default:
+		return reason
+	}
+}
+
+// Verify OllamaAdapter implements Provider interface.
+var _ llm.Provider = (*OllamaAdapter)(nil)
+var _ llm.ModelLister = (*OllamaAdapter)(nil)
@@ -0,0 +1,171 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOllamaServer serves handler on the given path and fails any request
+// to another path.
+func newOllamaServer(t *testing.T, path string, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func collectChunks(t *testing.T, chunks <-chan llm.StreamChunk) []llm.StreamChunk {
+	t.Helper()
+	var collected []llm.StreamChunk
+	for chunk := range chunks {
+		collected = append(collected, chunk)
+	}
+	return collected
+}
+
+func TestOllamaStream(t *testing.T) {
+	t.Run("parses newline-delimited chunks", func(t *testing.T) {
+		var sent map[string]interface{}
+		server := newOllamaServer(t, "/api/chat", func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":"The tide"},"done":false}` + "\n\n"))
+			w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":" turned."},"done":false}` + "\n"))
+			w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":12,"eval_count":3}` + "\n"))
+		})
+
+		adapter := NewOllamaAdapter(server.URL, "llama3.2", WithOllamaKeepAlive("-1"))
+		chunks, err := adapter.Stream(context.Background(), llm.ChatRequest{
+			Messages: []llm.ChatMessage{llm.NewUserMessage("Write the storm")},
+		})
+		require.NoError(t, err)
+
+		collected := collectChunks(t, chunks)
+		require.Len(t, collected, 3)
+		assert.Equal(t, "The tide", collected[0].Delta)
+		assert.Equal(t, " turned.", collected[1].Delta)
+
+		last := collected[2]
+		assert.True(t, last.Done)
+		assert.NoError(t, last.Error)
+		assert.Equal(t, llm.FinishReasonLength, last.FinishReason)
+		require.NotNil(t, last.Usage)
+		assert.Equal(t, 15, last.Usage.TotalTokens)
+
+		assert.Equal(t, true, sent["stream"])
+		assert.Equal(t, float64(-1), sent["keep_alive"], "a bare number is sent as seconds")
+	})
+
+	t.Run("reports an error line", func(t *testing.T) {
+		server := newOllamaServer(t, "/api/chat", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"message":{"role":"assistant","content":"The"},"done":false}` + "\n"))
+			w.Write([]byte(`{"error":"model ran out of memory"}` + "\n"))
+		})
+
+		chunks, err := NewOllamaAdapter(server.URL, "llama3.2").Stream(context.Background(), llm.ChatRequest{})
+		require.NoError(t, err)
+
+		collected := collectChunks(t, chunks)
+		require.Len(t, collected, 2)
+		assert.ErrorIs(t, collected[1].Error, llm.ErrAPIError)
+		assert.Contains(t, collected[1].Error.Error(), "out of memory")
+	})
+}
+
+func TestOllamaKeepAlive(t *testing.T) {
+	assert.Nil(t, ollamaKeepAlive(""))
+	assert.Equal(t, 0, ollamaKeepAlive("0"))
+	assert.Equal(t, -1, ollamaKeepAlive("-1"))
+	assert.Equal(t, "30m", ollamaKeepAlive("30m"))
+	assert.Equal(t, "-1m", ollamaKeepAlive("-1m"))
+}
+
+func TestOllamaHasModel(t *testing.T) {
+	t.Run("present", func(t *testing.T) {
+		server := newOllamaServer(t, "/api/show", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"modelfile":"FROM llama3.2"}`))
+		})
+
+		ok, err := NewOllamaAdapter(server.URL, "llama3.2").HasModel(context.Background())
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		server := newOllamaServer(t, "/api/show", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model 'llama3.2' not found"}`))
+		})
+
+		ok, err := NewOllamaAdapter(server.URL, "llama3.2").HasModel(context.Background())
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		server := newOllamaServer(t, "/api/show", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		_, err := NewOllamaAdapter(server.URL, "llama3.2").HasModel(context.Background())
+		assert.ErrorIs(t, err, llm.ErrAPIError)
+	})
+}
+
+func TestOllamaPull(t *testing.T) {
+	t.Run("reports progress", func(t *testing.T) {
+		server := newOllamaServer(t, "/api/pull", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"status":"pulling manifest"}` + "\n"))
+			w.Write([]byte(`{"status":"pulling abc","digest":"sha256:abc","total":200,"completed":50}` + "\n"))
+			w.Write([]byte(`{"status":"success"}` + "\n"))
+		})
+
+		var updates []OllamaPullProgress
+		err := NewOllamaAdapter(server.URL, "llama3.2").Pull(context.Background(), func(p OllamaPullProgress) {
+			updates = append(updates, p)
+		})
+		require.NoError(t, err)
+		require.Len(t, updates, 3)
+		assert.Equal(t, -1, updates[0].Percent())
+		assert.Equal(t, 25, updates[1].Percent())
+		assert.Equal(t, "success", updates[2].Status)
+	})
+
+	t.Run("stops at an error line", func(t *testing.T) {
+		server := newOllamaServer(t, "/api/pull", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"status":"pulling manifest"}` + "\n"))
+			w.Write([]byte(`{"error":"pull model manifest: file does not exist"}` + "\n"))
+			w.Write([]byte(`{"status":"success"}` + "\n"))
+		})
+
+		var updates []OllamaPullProgress
+		err := NewOllamaAdapter(server.URL, "nope").Pull(context.Background(), func(p OllamaPullProgress) {
+			updates = append(updates, p)
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file does not exist")
+		assert.Len(t, updates, 1)
+	})
+
+	t.Run("missing model", func(t *testing.T) {
+		server := newOllamaServer(t, "/api/pull", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		err := NewOllamaAdapter(server.URL, "nope").Pull(context.Background(), nil)
+		assert.ErrorIs(t, err, llm.ErrModelNotFound)
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/azyu/dreamteller/internal/llm"
@@ -15,24 +17,29 @@ import (
 // modelCapabilities maps model names to their capabilities.
 var modelCapabilities = map[string]llm.Capabilities{
 	"gpt-4o": {
-		SupportsTools:     true,
-		SupportsStreaming: true,
-		SupportsVision:    true,
-		MaxContextTokens:  128000,
-		MaxOutputTokens:   16384,
-		TokenizerType:     "o200k_base",
+		SupportsTools:            true,
+		SupportsStreaming:        true,
+		SupportsJSONMode:         true,
+		SupportsStructuredOutput: true,
+		SupportsVision:           true,
+		MaxContextTokens:         128000,
+		MaxOutputTokens:          16384,
+		TokenizerType:            "o200k_base",
 	},
 	"gpt-4o-mini": {
-		SupportsTools:     true,
-		SupportsStreaming: true,
-		SupportsVision:    true,
-		MaxContextTokens:  128000,
-		MaxOutputTokens:   16384,
-		TokenizerType:     "o200k_base",
+		SupportsTools:            true,
+		SupportsStreaming:        true,
+		SupportsJSONMode:         true,
+		SupportsStructuredOutput: true,
+		SupportsVision:           true,
+		MaxContextTokens:         128000,
+		MaxOutputTokens:          16384,
+		TokenizerType:            "o200k_base",
 	},
 	"gpt-4-turbo": {
 		SupportsTools:     true,
 		SupportsStreaming: true,
+		SupportsJSONMode:  true,
 		SupportsVision:    true,
 		MaxContextTokens:  128000,
 		MaxOutputTokens:   4096,
@@ -41,6 +48,7 @@ var modelCapabilities = map[string]llm.Capabilities{
 	"gpt-4-turbo-preview": {
 		SupportsTools:     true,
 		SupportsStreaming: true,
+		SupportsJSONMode:  true,
 		SupportsVision:    false,
 		MaxContextTokens:  128000,
 		MaxOutputTokens:   4096,
@@ -65,6 +73,7 @@ var modelCapabilities = map[string]llm.Capabilities{
 	"gpt-3.5-turbo": {
 		SupportsTools:     true,
 		SupportsStreaming: true,
+		SupportsJSONMode:  true,
 		SupportsVision:    false,
 		MaxContextTokens:  16385,
 		MaxOutputTokens:   4096,
@@ -73,6 +82,7 @@ var modelCapabilities = map[string]llm.Capabilities{
 	"gpt-3.5-turbo-16k": {
 		SupportsTools:     true,
 		SupportsStreaming: true,
+		SupportsJSONMode:  true,
 		SupportsVision:    false,
 		MaxContextTokens:  16385,
 		MaxOutputTokens:   4096,
@@ -108,6 +118,7 @@ var modelCapabilities = map[string]llm.Capabilities{
 var defaultCapabilities = llm.Capabilities{
 	SupportsTools:     true,
 	SupportsStreaming: true,
+	SupportsJSONMode:  true,
 	SupportsVision:    false,
 	MaxContextTokens:  128000,
 	MaxOutputTokens:   4096,
@@ -260,6 +271,7 @@ func (a *OpenAIAdapter) Stream(ctx context.Context, req llm.ChatRequest) (<-chan
 
 	openAIReq := a.buildRequest(req)
 	openAIReq.Stream = true
+	openAIReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
 
 	stream, err := a.client.CreateChatCompletionStream(ctx, openAIReq)
 	if err != nil {
@@ -307,6 +319,14 @@ func (a *OpenAIAdapter) processStream(ctx context.Context, stream *openai.ChatCo
 		}
 
 		if len(resp.Choices) == 0 {
+			// The usage comes last, in a chunk of its own.
+			if resp.Usage != nil {
+				chunks <- llm.StreamChunk{Usage: &llm.TokenUsage{
+					PromptTokens:     resp.Usage.PromptTokens,
+					CompletionTokens: resp.Usage.CompletionTokens,
+					TotalTokens:      resp.Usage.TotalTokens,
+				}}
+			}
 			continue
 		}
 
@@ -382,11 +402,11 @@ func (a *OpenAIAdapter) processStream(ctx context.Context, stream *openai.ChatCo
 
 // Capabilities returns the provider's capabilities.
 func (a *OpenAIAdapter) Capabilities() llm.Capabilities {
-	if caps, ok := modelCapabilities[a.model]; ok {
-		caps.Models = a.availableModels()
-		return caps
+	caps, ok := modelCapabilities[a.model]
+	if !ok {
+		caps = defaultCapabilities
 	}
-	caps := defaultCapabilities
+	caps.SupportsSeed = true
 	caps.Models = a.availableModels()
 	return caps
 }
@@ -423,6 +443,8 @@ func (a *OpenAIAdapter) buildRequest(req llm.ChatRequest) openai.ChatCompletionR
 		openAIReq.Temperature = float32(req.Temperature)
 	}
 
+	openAIReq.Seed = req.Seed
+
 	// Add tools if provided and supported
 	if len(req.Tools) > 0 {
 		caps := a.Capabilities()
@@ -450,6 +472,23 @@ func (a *OpenAIAdapter) buildRequest(req llm.ChatRequest) openai.ChatCompletionR
 		}
 	}
 
+	caps := a.Capabilities()
+	switch {
+	case req.ResponseSchema != nil && caps.SupportsStructuredOutput:
+		openAIReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   req.ResponseSchema.Name,
+				Schema: req.ResponseSchema,
+				Strict: req.ResponseSchema.Strict,
+			},
+		}
+	case (req.JSONMode || req.ResponseSchema != nil) && caps.SupportsJSONMode:
+		openAIReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+
 	return openAIReq
 }
 
@@ -608,6 +647,45 @@ func (a *OpenAIAdapter) isRetryable(err error) bool {
 	return false
 }
 
+// ListModels returns the chat models available to the API key. On the
+// official API, embedding, audio and image models are left out.
+func (a *OpenAIAdapter) ListModels(ctx context.Context) ([]string, error) {
+	list, err := a.client.ListModels(ctx)
+	if err != nil {
+		return nil, a.handleError(err)
+	}
+
+	var models []string
+	for _, model := range list.Models {
+		if a.config.BaseURL == "" && !isOpenAIChatModel(model.ID) {
+			continue
+		}
+		models = append(models, model.ID)
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// isOpenAIChatModel reports whether an OpenAI model ID names a chat model.
+func isOpenAIChatModel(id string) bool {
+	chat := false
+	for _, prefix := range []string{"gpt-", "chatgpt-", "o1", "o3", "o4"} {
+		if strings.HasPrefix(id, prefix) {
+			chat = true
+			break
+		}
+	}
+	if !chat {
+		return false
+	}
+	for _, kind := range []string{"audio", "realtime", "transcribe", "tts", "image", "search"} {
+		if strings.Contains(id, kind) {
+			return false
+		}
+	}
+	return true
+}
+
 // availableModels returns the list of available OpenAI models.
 func (a *OpenAIAdapter) availableModels() []string {
 	return []string{
@@ -627,3 +705,4 @@ func (a *OpenAIAdapter) availableModels() []string {
 
 // Verify OpenAIAdapter implements Provider interface.
 var _ llm.Provider = (*OpenAIAdapter)(nil)
+var _ llm.ModelLister = (*OpenAIAdapter)(nil)
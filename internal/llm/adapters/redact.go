@@ -0,0 +1,117 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/azyu/dreamteller/internal/llm"
+)
+
+// RedactingProvider wraps another provider, hiding sensitive strings from
+// it: requests are sent with aliases in their place, and the aliases in
+// replies are swapped back before they are returned.
+type RedactingProvider struct {
+	inner    llm.Provider
+	redactor *llm.Redactor
+}
+
+// NewRedactingProvider creates a provider that redacts exchanges with inner.
+func NewRedactingProvider(inner llm.Provider, redactor *llm.Redactor) *RedactingProvider {
+	return &RedactingProvider{inner: inner, redactor: redactor}
+}
+
+// Chat sends the redacted request and restores the response.
+func (r *RedactingProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	resp, err := r.inner.Chat(ctx, r.redactor.RedactRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	restored := *resp
+	restored.Message.Content = r.redactor.Restore(resp.Message.Content, false)
+	if len(resp.Message.ToolCalls) > 0 {
+		restored.Message.ToolCalls = make([]llm.ToolCall, len(resp.Message.ToolCalls))
+		for i, tc := range resp.Message.ToolCalls {
+			tc.Function.Arguments = r.redactor.Restore(tc.Function.Arguments, true)
+			restored.Message.ToolCalls[i] = tc
+		}
+	}
+	return &restored, nil
+}
+
+// Stream sends the redacted request and restores the response as it
+// arrives. Text that may be the start of an alias is held back until the
+// next chunk shows whether it is.
+func (r *RedactingProvider) Stream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	upstream, err := r.inner.Stream(ctx, r.redactor.RedactRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan llm.StreamChunk, 100)
+
+	go func() {
+		defer close(out)
+
+		text := r.redactor.Restorer(false)
+		args := map[int]*llm.StreamRestorer{}
+		var order []int
+
+		// flushArgs sends the tool call arguments held back, except those
+		// of the call at skip.
+		flushArgs := func(skip int) {
+			for _, idx := range order {
+				if rest := args[idx].Flush(); rest != "" && idx != skip {
+					out <- llm.StreamChunk{ToolCall: &llm.ToolCallDelta{Index: idx, Function: &llm.FunctionCallDelta{Arguments: rest}}}
+				}
+			}
+		}
+
+		for chunk := range upstream {
+			last := chunk.Done || chunk.Error != nil
+			chunk.Delta = text.Write(chunk.Delta)
+			if last {
+				chunk.Delta += text.Flush()
+			}
+
+			skip := -1
+			if tc := chunk.ToolCall; tc != nil && tc.Function != nil && tc.Function.Arguments != "" {
+				restorer, ok := args[tc.Index]
+				if !ok {
+					restorer = r.redactor.Restorer(true)
+					args[tc.Index] = restorer
+					order = append(order, tc.Index)
+				}
+				function := *tc.Function
+				function.Arguments = restorer.Write(function.Arguments)
+				if last {
+					function.Arguments += restorer.Flush()
+					skip = tc.Index
+				}
+				delta := *tc
+				delta.Function = &function
+				chunk.ToolCall = &delta
+			}
+			if last {
+				flushArgs(skip)
+			}
+			out <- chunk
+		}
+
+		if rest := text.Flush(); rest != "" {
+			out <- llm.StreamChunk{Delta: rest}
+		}
+		flushArgs(-1)
+	}()
+
+	return out, nil
+}
+
+// Capabilities returns the wrapped provider's capabilities.
+func (r *RedactingProvider) Capabilities() llm.Capabilities {
+	return r.inner.Capabilities()
+}
+
+// Close closes the wrapped provider.
+func (r *RedactingProvider) Close() error {
+	return r.inner.Close()
+}
@@ -0,0 +1,146 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider streams fixed chunks. ReplayProvider sends each tool
+// call's arguments in one piece, so it cannot split an alias inside them.
+type scriptedProvider struct {
+	ReplayProvider
+	chunks []llm.StreamChunk
+}
+
+func (p *scriptedProvider) Stream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	out := make(chan llm.StreamChunk, len(p.chunks))
+	for _, chunk := range p.chunks {
+		out <- chunk
+	}
+	close(out)
+	return out, nil
+}
+
+func newTestRedactor(t *testing.T) *llm.Redactor {
+	t.Helper()
+	redactor, err := llm.NewRedactor([]types.RedactionRule{
+		{Text: "Kim Minsu", Alias: "Park Jihoon"},
+		{Text: `Lee "Ace" Jun`},
+	})
+	require.NoError(t, err)
+	return redactor
+}
+
+// redactedStream streams a request that mentions both sensitive strings,
+// so each has its alias, through a RedactingProvider around inner.
+func redactedStream(t *testing.T, inner llm.Provider, redactor *llm.Redactor) []llm.StreamChunk {
+	t.Helper()
+	chunks, err := NewRedactingProvider(inner, redactor).Stream(context.Background(), llm.ChatRequest{
+		Messages: []llm.ChatMessage{llm.NewUserMessage(`Write Kim Minsu meeting Lee "Ace" Jun`)},
+	})
+	require.NoError(t, err)
+	return collectChunks(t, chunks)
+}
+
+// streamedText joins the deltas of chunks.
+func streamedText(chunks []llm.StreamChunk) string {
+	var sb strings.Builder
+	for _, chunk := range chunks {
+		sb.WriteString(chunk.Delta)
+	}
+	return sb.String()
+}
+
+// streamedArguments joins the tool call arguments of chunks by call index.
+func streamedArguments(chunks []llm.StreamChunk) map[int]string {
+	args := map[int]string{}
+	for _, chunk := range chunks {
+		if chunk.ToolCall != nil && chunk.ToolCall.Function != nil {
+			args[chunk.ToolCall.Index] += chunk.ToolCall.Function.Arguments
+		}
+	}
+	return args
+}
+
+func TestRedactingProviderStream(t *testing.T) {
+	t.Run("restores an alias split across deltas", func(t *testing.T) {
+		inner := NewReplayProvider([]ReplayEntry{{
+			Response: ReplayResponse{Content: "Park Jihoon waved at REDACTED-1."},
+		}}, WithReplayChunkSize(3))
+
+		chunks := redactedStream(t, inner, newTestRedactor(t))
+		assert.Equal(t, `Kim Minsu waved at Lee "Ace" Jun.`, streamedText(chunks))
+		for _, chunk := range chunks {
+			assert.NotContains(t, chunk.Delta, "Park", "no piece of an alias is shown")
+		}
+		assert.True(t, chunks[len(chunks)-1].Done)
+	})
+
+	t.Run("restores an alias split across tool call arguments", func(t *testing.T) {
+		inner := &scriptedProvider{chunks: []llm.StreamChunk{
+			{ToolCall: &llm.ToolCallDelta{Index: 0, ID: "call_1", Function: &llm.FunctionCallDelta{Name: llm.ToolSearchContext, Arguments: `{"query": "Park Ji`}}},
+			{ToolCall: &llm.ToolCallDelta{Index: 1, ID: "call_2", Function: &llm.FunctionCallDelta{Name: llm.ToolSearchContext, Arguments: `{"query": "Park`}}},
+			{ToolCall: &llm.ToolCallDelta{Index: 0, Function: &llm.FunctionCallDelta{Arguments: `hoon"}`}}},
+			{ToolCall: &llm.ToolCallDelta{Index: 1, Function: &llm.FunctionCallDelta{Arguments: ` Jihoon"}`}}},
+			{Done: true, FinishReason: llm.FinishReasonToolCalls},
+		}}
+
+		chunks := redactedStream(t, inner, newTestRedactor(t))
+		assert.Equal(t, `{"query": "`, chunks[0].ToolCall.Function.Arguments, "the start of the alias is held back")
+		assert.Equal(t, map[int]string{
+			0: `{"query": "Kim Minsu"}`,
+			1: `{"query": "Kim Minsu"}`,
+		}, streamedArguments(chunks))
+	})
+
+	t.Run("flushes what is held back when the stream ends", func(t *testing.T) {
+		inner := NewReplayProvider([]ReplayEntry{{
+			Response: ReplayResponse{Content: "Ask Park"},
+		}})
+
+		chunks := redactedStream(t, inner, newTestRedactor(t))
+		assert.Equal(t, "Ask Park", streamedText(chunks))
+		last := chunks[len(chunks)-1]
+		assert.True(t, last.Done)
+		assert.Equal(t, "Park", last.Delta, "a partial alias is released with Done")
+	})
+
+	t.Run("flushes tool call arguments held back at Done once", func(t *testing.T) {
+		inner := &scriptedProvider{chunks: []llm.StreamChunk{
+			{ToolCall: &llm.ToolCallDelta{Index: 0, ID: "call_1", Function: &llm.FunctionCallDelta{Name: llm.ToolSearchContext, Arguments: `{"query": "Park`}}},
+			{ToolCall: &llm.ToolCallDelta{Index: 1, ID: "call_2", Function: &llm.FunctionCallDelta{Name: llm.ToolSearchContext, Arguments: `{"query": "Park`}}},
+			{ToolCall: &llm.ToolCallDelta{Index: 0, Function: &llm.FunctionCallDelta{Arguments: ` Ji`}}, Done: true},
+		}}
+
+		chunks := redactedStream(t, inner, newTestRedactor(t))
+		assert.Equal(t, map[int]string{
+			0: `{"query": "Park Ji`,
+			1: `{"query": "Park`,
+		}, streamedArguments(chunks))
+	})
+
+	t.Run("escapes restored strings in tool call arguments", func(t *testing.T) {
+		inner := NewReplayProvider([]ReplayEntry{{
+			Response: ReplayResponse{
+				Content:   "REDACTED-1 laughed.",
+				ToolCalls: []ReplayToolCall{{Name: llm.ToolSearchContext, Arguments: `{"query": "REDACTED-1"}`}},
+			},
+		}})
+
+		chunks := redactedStream(t, inner, newTestRedactor(t))
+		assert.Equal(t, `Lee "Ace" Jun laughed.`, streamedText(chunks))
+
+		args := streamedArguments(chunks)[0]
+		assert.Equal(t, `{"query": "Lee \"Ace\" Jun"}`, args)
+		var parsed map[string]string
+		require.NoError(t, json.Unmarshal([]byte(args), &parsed))
+		assert.Equal(t, `Lee "Ace" Jun`, parsed["query"])
+	})
+}
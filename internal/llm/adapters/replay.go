@@ -0,0 +1,482 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/azyu/dreamteller/internal/llm"
+)
+
+// ErrReplayExhausted is returned when a ReplayProvider has no responses left.
+var ErrReplayExhausted = errors.New("replay log exhausted")
+
+// ReplayEntry is one recorded exchange in a replay log.
+// Replay logs are stored as JSON Lines, one entry per line.
+type ReplayEntry struct {
+	// Match optionally restricts this entry to requests whose last user
+	// message contains the given substring. Empty entries match in order.
+	Match string `json:"match,omitempty"`
+
+	// Messages is the request conversation, recorded for reference only.
+	Messages []ReplayMessage `json:"messages,omitempty"`
+
+	// Response is the canned response served for this entry.
+	Response ReplayResponse `json:"response"`
+}
+
+// ReplayMessage is a serializable form of llm.ChatMessage.
+type ReplayMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ReplayResponse is a serializable form of a model response.
+type ReplayResponse struct {
+	Content      string           `json:"content,omitempty"`
+	ToolCalls    []ReplayToolCall `json:"tool_calls,omitempty"`
+	FinishReason string           `json:"finish_reason,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// ReplayToolCall is a serializable form of llm.ToolCall.
+type ReplayToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ReplayProvider implements llm.Provider by serving canned responses.
+// It makes no network calls, which makes it suitable for tests and demos.
+type ReplayProvider struct {
+	mu      sync.Mutex
+	entries []ReplayEntry
+	used    []bool
+	next    int
+	loop    bool
+	caps    llm.Capabilities
+	chunk   int
+}
+
+// ReplayOption configures a ReplayProvider.
+type ReplayOption func(*ReplayProvider)
+
+// WithReplayLoop restarts from the first entry once all entries are used.
+func WithReplayLoop() ReplayOption {
+	return func(p *ReplayProvider) {
+		p.loop = true
+	}
+}
+
+// WithReplayCapabilities overrides the capabilities reported by the provider.
+func WithReplayCapabilities(caps llm.Capabilities) ReplayOption {
+	return func(p *ReplayProvider) {
+		p.caps = caps
+	}
+}
+
+// WithReplayChunkSize sets how many runes each streamed delta carries.
+func WithReplayChunkSize(runes int) ReplayOption {
+	return func(p *ReplayProvider) {
+		if runes > 0 {
+			p.chunk = runes
+		}
+	}
+}
+
+// NewReplayProvider creates a provider that serves the given entries.
+func NewReplayProvider(entries []ReplayEntry, opts ...ReplayOption) *ReplayProvider {
+	p := &ReplayProvider{
+		entries: entries,
+		used:    make([]bool, len(entries)),
+		chunk:   16,
+		caps: llm.Capabilities{
+			SupportsTools:     true,
+			SupportsStreaming: true,
+			MaxContextTokens:  defaultCapabilities.MaxContextTokens,
+			MaxOutputTokens:   defaultCapabilities.MaxOutputTokens,
+			Models:            []string{"replay"},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// NewReplayProviderFromText creates a provider that replies with each text in order.
+func NewReplayProviderFromText(responses ...string) *ReplayProvider {
+	entries := make([]ReplayEntry, len(responses))
+	for i, text := range responses {
+		entries[i] = ReplayEntry{Response: ReplayResponse{Content: text}}
+	}
+	return NewReplayProvider(entries)
+}
+
+// LoadReplayFile creates a provider from a JSON Lines replay log.
+func LoadReplayFile(path string, opts ...ReplayOption) (*ReplayProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay log: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := ReadReplayEntries(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReplayProvider(entries, opts...), nil
+}
+
+// ReadReplayEntries parses replay entries from JSON Lines input.
+// Blank lines are ignored.
+func ReadReplayEntries(r io.Reader) ([]ReplayEntry, error) {
+	var entries []ReplayEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var entry ReplayEntry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("invalid replay entry on line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Chat returns the next matching canned response.
+func (p *ReplayProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resp, err := p.take(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%w: %s", llm.ErrAPIError, resp.Error)
+	}
+
+	return &llm.ChatResponse{
+		Message: llm.ChatMessage{
+			Role:      llm.RoleAssistant,
+			Content:   resp.Content,
+			ToolCalls: resp.toolCalls(),
+		},
+		FinishReason: resp.finishReason(),
+		Model:        "replay",
+	}, nil
+}
+
+// Stream replays the next matching response as a sequence of chunks.
+func (p *ReplayProvider) Stream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	resp, err := p.take(req)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan llm.StreamChunk, 100)
+
+	go func() {
+		defer close(chunks)
+
+		send := func(chunk llm.StreamChunk) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case chunks <- chunk:
+				return true
+			}
+		}
+
+		if resp.Error != "" {
+			send(llm.StreamChunk{Error: fmt.Errorf("%w: %s", llm.ErrAPIError, resp.Error), Done: true})
+			return
+		}
+
+		runes := []rune(resp.Content)
+		for i := 0; i < len(runes); i += p.chunk {
+			end := i + p.chunk
+			if end > len(runes) {
+				end = len(runes)
+			}
+			if !send(llm.StreamChunk{Delta: string(runes[i:end])}) {
+				return
+			}
+		}
+
+		for i, tc := range resp.toolCalls() {
+			delta := &llm.ToolCallDelta{
+				Index: i,
+				ID:    tc.ID,
+				Type:  tc.Type,
+				Function: &llm.FunctionCallDelta{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			}
+			if !send(llm.StreamChunk{ToolCall: delta}) {
+				return
+			}
+		}
+
+		send(llm.StreamChunk{Done: true, FinishReason: resp.finishReason()})
+	}()
+
+	return chunks, nil
+}
+
+// Capabilities returns the configured capabilities.
+func (p *ReplayProvider) Capabilities() llm.Capabilities {
+	return p.caps
+}
+
+// Close releases resources held by the provider.
+func (p *ReplayProvider) Close() error {
+	return nil
+}
+
+// Remaining returns the number of entries that have not been served yet.
+func (p *ReplayProvider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	count := 0
+	for _, used := range p.used {
+		if !used {
+			count++
+		}
+	}
+	return count
+}
+
+// take selects the response for a request. Entries with a Match that
+// appears in the last user message win; otherwise entries are served in order.
+func (p *ReplayProvider) take(req llm.ChatRequest) (ReplayResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.entries) == 0 {
+		return ReplayResponse{}, ErrReplayExhausted
+	}
+
+	lastUser := ""
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == llm.RoleUser {
+			lastUser = req.Messages[i].Content
+			break
+		}
+	}
+
+	for i, entry := range p.entries {
+		if !p.used[i] && entry.Match != "" && strings.Contains(lastUser, entry.Match) {
+			p.used[i] = true
+			return entry.Response, nil
+		}
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		for ; p.next < len(p.entries); p.next++ {
+			if !p.used[p.next] && p.entries[p.next].Match == "" {
+				p.used[p.next] = true
+				resp := p.entries[p.next].Response
+				p.next++
+				return resp, nil
+			}
+		}
+
+		if !p.loop {
+			break
+		}
+		p.next = 0
+		p.used = make([]bool, len(p.entries))
+	}
+
+	return ReplayResponse{}, ErrReplayExhausted
+}
+
+func (r ReplayResponse) toolCalls() []llm.ToolCall {
+	if len(r.ToolCalls) == 0 {
+		return nil
+	}
+
+	calls := make([]llm.ToolCall, len(r.ToolCalls))
+	for i, tc := range r.ToolCalls {
+		id := tc.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%s_%d", tc.Name, i)
+		}
+		calls[i] = llm.ToolCall{
+			ID:   id,
+			Type: "function",
+			Function: llm.FunctionCall{
+				Name:      tc.Name,
+				Arguments: tc.Arguments,
+			},
+		}
+	}
+	return calls
+}
+
+func (r ReplayResponse) finishReason() string {
+	if r.FinishReason != "" {
+		return r.FinishReason
+	}
+	if len(r.ToolCalls) > 0 {
+		return llm.FinishReasonToolCalls
+	}
+	return llm.FinishReasonStop
+}
+
+// RecordingProvider wraps another provider and appends every exchange to a
+// replay log, which can later be served by a ReplayProvider.
+type RecordingProvider struct {
+	inner llm.Provider
+	mu    sync.Mutex
+	w     io.Writer
+}
+
+// NewRecordingProvider creates a provider that records exchanges with inner to w.
+func NewRecordingProvider(inner llm.Provider, w io.Writer) *RecordingProvider {
+	return &RecordingProvider{inner: inner, w: w}
+}
+
+// Chat forwards the request and records the response.
+func (r *RecordingProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	resp, err := r.inner.Chat(ctx, req)
+	if err != nil {
+		r.record(req, ReplayResponse{Error: err.Error()})
+		return nil, err
+	}
+
+	recorded := ReplayResponse{
+		Content:      resp.Message.Content,
+		FinishReason: resp.FinishReason,
+	}
+	for _, tc := range resp.Message.ToolCalls {
+		recorded.ToolCalls = append(recorded.ToolCalls, ReplayToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	r.record(req, recorded)
+
+	return resp, nil
+}
+
+// Stream forwards the request and records the assembled response once the
+// stream completes.
+func (r *RecordingProvider) Stream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	upstream, err := r.inner.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan llm.StreamChunk, 100)
+
+	go func() {
+		defer close(out)
+
+		var content strings.Builder
+		var finishReason, errText string
+		calls := map[int]*ReplayToolCall{}
+		var order []int
+
+		for chunk := range upstream {
+			content.WriteString(chunk.Delta)
+			if chunk.ToolCall != nil {
+				tc, ok := calls[chunk.ToolCall.Index]
+				if !ok {
+					tc = &ReplayToolCall{}
+					calls[chunk.ToolCall.Index] = tc
+					order = append(order, chunk.ToolCall.Index)
+				}
+				if chunk.ToolCall.ID != "" {
+					tc.ID = chunk.ToolCall.ID
+				}
+				if chunk.ToolCall.Function != nil {
+					tc.Name += chunk.ToolCall.Function.Name
+					tc.Arguments += chunk.ToolCall.Function.Arguments
+				}
+			}
+			if chunk.FinishReason != "" {
+				finishReason = chunk.FinishReason
+			}
+			if chunk.Error != nil {
+				errText = chunk.Error.Error()
+			}
+			out <- chunk
+		}
+
+		recorded := ReplayResponse{
+			Content:      content.String(),
+			FinishReason: finishReason,
+			Error:        errText,
+		}
+		for _, idx := range order {
+			recorded.ToolCalls = append(recorded.ToolCalls, *calls[idx])
+		}
+		r.record(req, recorded)
+	}()
+
+	return out, nil
+}
+
+// Capabilities returns the wrapped provider's capabilities.
+func (r *RecordingProvider) Capabilities() llm.Capabilities {
+	return r.inner.Capabilities()
+}
+
+// Close closes the wrapped provider.
+func (r *RecordingProvider) Close() error {
+	return r.inner.Close()
+}
+
+// record appends one entry to the replay log. Write errors are ignored so
+// that recording never interferes with the session.
+func (r *RecordingProvider) record(req llm.ChatRequest, resp ReplayResponse) {
+	entry := ReplayEntry{Response: resp}
+	for _, msg := range req.Messages {
+		entry.Messages = append(entry.Messages, ReplayMessage{Role: msg.Role, Content: msg.Content})
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.w.Write(append(data, '\n'))
+}
+
+// Verify ReplayProvider and RecordingProvider implement Provider interface.
+var (
+	_ llm.Provider = (*ReplayProvider)(nil)
+	_ llm.Provider = (*RecordingProvider)(nil)
+)
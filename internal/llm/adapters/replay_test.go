@@ -0,0 +1,175 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ask sends a chat request with one user message and returns the reply.
+func ask(t *testing.T, p llm.Provider, prompt string) (string, error) {
+	t.Helper()
+	resp, err := p.Chat(context.Background(), llm.ChatRequest{
+		Messages: []llm.ChatMessage{llm.NewUserMessage(prompt)},
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
+func TestReplayProviderTake(t *testing.T) {
+	t.Run("matched entries win, the rest are served in order", func(t *testing.T) {
+		p := NewReplayProvider([]ReplayEntry{
+			{Response: ReplayResponse{Content: "first"}},
+			{Match: "storm", Response: ReplayResponse{Content: "storm reply"}},
+			{Response: ReplayResponse{Content: "second"}},
+		})
+
+		reply, err := ask(t, p, "Write the storm")
+		require.NoError(t, err)
+		assert.Equal(t, "storm reply", reply)
+
+		reply, err = ask(t, p, "Write the storm again")
+		require.NoError(t, err)
+		assert.Equal(t, "first", reply, "a matched entry is served once")
+
+		reply, err = ask(t, p, "Next")
+		require.NoError(t, err)
+		assert.Equal(t, "second", reply)
+		assert.Equal(t, 0, p.Remaining())
+
+		_, err = ask(t, p, "More")
+		assert.ErrorIs(t, err, ErrReplayExhausted)
+	})
+
+	t.Run("matches against the last user message", func(t *testing.T) {
+		p := NewReplayProvider([]ReplayEntry{
+			{Match: "harbor", Response: ReplayResponse{Content: "harbor reply"}},
+			{Response: ReplayResponse{Content: "in order"}},
+		})
+
+		resp, err := p.Chat(context.Background(), llm.ChatRequest{Messages: []llm.ChatMessage{
+			llm.NewUserMessage("Describe the harbor"),
+			llm.NewAssistantMessage("The harbor was quiet."),
+			llm.NewUserMessage("Go on"),
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "in order", resp.Message.Content)
+	})
+
+	t.Run("loops once the log runs out", func(t *testing.T) {
+		p := NewReplayProvider([]ReplayEntry{
+			{Response: ReplayResponse{Content: "one"}},
+			{Response: ReplayResponse{Content: "two"}},
+		}, WithReplayLoop())
+
+		var replies []string
+		for i := 0; i < 5; i++ {
+			reply, err := ask(t, p, "again")
+			require.NoError(t, err)
+			replies = append(replies, reply)
+		}
+		assert.Equal(t, []string{"one", "two", "one", "two", "one"}, replies)
+	})
+
+	t.Run("an empty log is exhausted even when looping", func(t *testing.T) {
+		p := NewReplayProvider(nil, WithReplayLoop())
+		_, err := ask(t, p, "hello")
+		assert.ErrorIs(t, err, ErrReplayExhausted)
+
+		_, err = p.Stream(context.Background(), llm.ChatRequest{})
+		assert.ErrorIs(t, err, ErrReplayExhausted)
+	})
+
+	t.Run("recorded errors are returned as API errors", func(t *testing.T) {
+		p := NewReplayProvider([]ReplayEntry{{Response: ReplayResponse{Error: "overloaded"}}})
+		_, err := ask(t, p, "hello")
+		assert.ErrorIs(t, err, llm.ErrAPIError)
+	})
+}
+
+func TestRecordingProvider(t *testing.T) {
+	t.Run("rebuilds streamed deltas into the recorded response", func(t *testing.T) {
+		inner := &scriptedProvider{chunks: []llm.StreamChunk{
+			{Delta: "Looking "},
+			{Delta: "her up."},
+			{ToolCall: &llm.ToolCallDelta{Index: 0, ID: "call_1", Function: &llm.FunctionCallDelta{Name: "search_", Arguments: `{"query":`}}},
+			{ToolCall: &llm.ToolCallDelta{Index: 1, ID: "call_2", Function: &llm.FunctionCallDelta{Name: llm.ToolGetCharacterVoice, Arguments: `{"character": "Mira"}`}}},
+			{ToolCall: &llm.ToolCallDelta{Index: 0, Function: &llm.FunctionCallDelta{Name: "context", Arguments: ` "Mira"}`}}},
+			{Done: true, FinishReason: llm.FinishReasonToolCalls},
+		}}
+		var log bytes.Buffer
+		recorder := NewRecordingProvider(inner, &log)
+
+		chunks, err := recorder.Stream(context.Background(), llm.ChatRequest{
+			Messages: []llm.ChatMessage{llm.NewUserMessage("Who is Mira?")},
+		})
+		require.NoError(t, err)
+		assert.Len(t, collectChunks(t, chunks), 6, "chunks pass through unchanged")
+
+		entries, err := ReadReplayEntries(&log)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, []ReplayMessage{{Role: llm.RoleUser, Content: "Who is Mira?"}}, entries[0].Messages)
+		assert.Equal(t, ReplayResponse{
+			Content: "Looking her up.",
+			ToolCalls: []ReplayToolCall{
+				{ID: "call_1", Name: llm.ToolSearchContext, Arguments: `{"query": "Mira"}`},
+				{ID: "call_2", Name: llm.ToolGetCharacterVoice, Arguments: `{"character": "Mira"}`},
+			},
+			FinishReason: llm.FinishReasonToolCalls,
+		}, entries[0].Response)
+	})
+
+	t.Run("records a stream error", func(t *testing.T) {
+		var log bytes.Buffer
+		recorder := NewRecordingProvider(NewReplayProvider([]ReplayEntry{{Response: ReplayResponse{Error: "overloaded"}}}), &log)
+
+		chunks, err := recorder.Stream(context.Background(), llm.ChatRequest{})
+		require.NoError(t, err)
+		collectChunks(t, chunks)
+
+		entries, err := ReadReplayEntries(&log)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Response.Error, "overloaded")
+	})
+
+	t.Run("replays what it recorded", func(t *testing.T) {
+		original := NewReplayProvider([]ReplayEntry{
+			{Response: ReplayResponse{Content: "The tide turned."}},
+			{Response: ReplayResponse{ToolCalls: []ReplayToolCall{{ID: "call_1", Name: llm.ToolSearchContext, Arguments: `{"query": "tide"}`}}}},
+		}, WithReplayChunkSize(4))
+		var log bytes.Buffer
+		recorder := NewRecordingProvider(original, &log)
+
+		reply, err := ask(t, recorder, "Write the tide")
+		require.NoError(t, err)
+		chunks, err := recorder.Stream(context.Background(), llm.ChatRequest{
+			Messages: []llm.ChatMessage{llm.NewUserMessage("Look up the tide")},
+		})
+		require.NoError(t, err)
+		streamed := collectChunks(t, chunks)
+
+		entries, err := ReadReplayEntries(&log)
+		require.NoError(t, err)
+		replay := NewReplayProvider(entries)
+
+		replayed, err := ask(t, replay, "Write the tide")
+		require.NoError(t, err)
+		assert.Equal(t, reply, replayed)
+
+		resp, err := replay.Chat(context.Background(), llm.ChatRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Message.ToolCalls, 1)
+		assert.Equal(t, streamedArguments(streamed)[0], resp.Message.ToolCalls[0].Function.Arguments)
+		assert.Equal(t, "call_1", resp.Message.ToolCalls[0].ID)
+		assert.Equal(t, llm.FinishReasonToolCalls, resp.FinishReason)
+		assert.Equal(t, 0, replay.Remaining())
+	})
+}
@@ -3,7 +3,10 @@ package llm
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/azyu/dreamteller/pkg/types"
 )
@@ -14,8 +17,17 @@ type ContextManager struct {
 	budget    types.BudgetConfig
 	maxTokens int
 	tokenizer TokenCounter
+
+	// counts memoizes the tokenizer by text, so history truncated again on
+	// every request is only tokenized where it changed.
+	countsMu sync.Mutex
+	counts   map[string]int
 }
 
+// maxCachedCounts bounds the memoized counts; the memo starts over once
+// full.
+const maxCachedCounts = 4096
+
 // TokenCounter interface for counting tokens.
 type TokenCounter interface {
 	Count(text string) int
@@ -53,30 +65,113 @@ func (cm *ContextManager) CalculateBudget() ContextBudget {
 
 // ContextChunk represents a piece of context to inject.
 type ContextChunk struct {
+	// ID is the chunk's index ID. Chunks with an ID are tagged in the prompt
+	// so the model can cite them.
+	ID         int64
 	Content    string
 	SourceType string
 	SourcePath string
 	Score      float64
 	Tokens     int
+
+	// Section is the heading path the chunk sits under in its file, such
+	// as "Hana > Backstory".
+	Section string
 }
 
-// SelectChunks selects chunks that fit within the context budget.
+// DefaultMaxChunksPerSource is the per-file chunk cap used when the project
+// does not set one.
+const DefaultMaxChunksPerSource = 2
+
+// nearDuplicateThreshold is the share of a chunk's word shingles found in an
+// already selected chunk above which the chunk is dropped as a near-duplicate.
+const nearDuplicateThreshold = 0.8
+
+// SelectChunks selects chunks that fit within the context budget, in ranked
+// order. Near-duplicates of selected chunks are skipped, and each source file
+// contributes at most MaxChunksPerSource chunks until every source has had a
+// turn; remaining slots are then backfilled from capped sources.
 func (cm *ContextManager) SelectChunks(chunks []ContextChunk, budget int) []ContextChunk {
-	var selected []ContextChunk
+	perSource := cm.config.MaxChunksPerSource
+	if perSource <= 0 {
+		perSource = DefaultMaxChunksPerSource
+	}
+
+	taken := make([]bool, len(chunks))
+	shingles := make([]map[string]bool, len(chunks))
+	var selected []int
+	bySource := make(map[string]int)
 	usedTokens := 0
 
-	for _, chunk := range chunks {
-		if usedTokens+chunk.Tokens > budget {
-			continue
+	take := func(i int, capSources bool) {
+		chunk := chunks[i]
+		if taken[i] || len(selected) >= cm.config.MaxChunks || usedTokens+chunk.Tokens > budget {
+			return
 		}
-		if len(selected) >= cm.config.MaxChunks {
-			break
+		if capSources && bySource[chunk.SourcePath] >= perSource {
+			return
 		}
-		selected = append(selected, chunk)
+		if shingles[i] == nil {
+			shingles[i] = wordShingles(chunk.Content)
+		}
+		for _, j := range selected {
+			if shingleContainment(shingles[i], shingles[j]) >= nearDuplicateThreshold {
+				return
+			}
+		}
+		taken[i] = true
+		selected = append(selected, i)
+		bySource[chunk.SourcePath]++
 		usedTokens += chunk.Tokens
 	}
 
-	return selected
+	for i := range chunks {
+		take(i, true)
+	}
+	for i := range chunks {
+		take(i, false)
+	}
+
+	var result []ContextChunk
+	for i, chunk := range chunks {
+		if taken[i] {
+			result = append(result, chunk)
+		}
+	}
+	return result
+}
+
+// wordShingles returns the set of lowercase word trigrams in text. Text of
+// fewer than three words is a single shingle.
+func wordShingles(text string) map[string]bool {
+	words := strings.Fields(strings.ToLower(text))
+	set := make(map[string]bool)
+	if len(words) < 3 {
+		set[strings.Join(words, " ")] = true
+		return set
+	}
+	for i := 0; i+3 <= len(words); i++ {
+		set[strings.Join(words[i:i+3], " ")] = true
+	}
+	return set
+}
+
+// shingleContainment is the share of the smaller set found in the larger,
+// so a chunk quoted inside a longer one still counts as a duplicate.
+func shingleContainment(a, b map[string]bool) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) == 0 {
+		return 0
+	}
+	shared := 0
+	for s := range a {
+		if b[s] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a))
 }
 
 // BuildContextPrompt builds the context section of the system prompt.
@@ -88,18 +183,27 @@ func (cm *ContextManager) BuildContextPrompt(chunks []ContextChunk) string {
 	var sb strings.Builder
 	sb.WriteString("\n\n## Relevant Context\n\n")
 
+	for _, chunk := range chunks {
+		if chunk.ID > 0 {
+			sb.WriteString(CitationInstruction + "\n\n")
+			break
+		}
+	}
+
 	// Group by source type
 	byType := make(map[string][]ContextChunk)
 	for _, chunk := range chunks {
 		byType[chunk.SourceType] = append(byType[chunk.SourceType], chunk)
 	}
 
-	// Order: characters, settings, plot, chapters
-	order := []string{"character", "setting", "plot", "chapter"}
+	// Order: characters, settings, plot, glossary, research, chapters
+	order := []string{"character", "setting", "plot", "glossary", "research", "chapter"}
 	typeNames := map[string]string{
 		"character": "Characters",
 		"setting":   "Settings",
 		"plot":      "Plot",
+		"glossary":  "Glossary",
+		"research":  "Research",
 		"chapter":   "Previous Chapters",
 	}
 
@@ -111,7 +215,15 @@ func (cm *ContextManager) BuildContextPrompt(chunks []ContextChunk) string {
 
 		sb.WriteString(fmt.Sprintf("### %s\n\n", typeNames[sourceType]))
 		for _, chunk := range typeChunks {
-			sb.WriteString(chunk.Content)
+			switch {
+			case chunk.ID > 0 && chunk.Section != "":
+				sb.WriteString(fmt.Sprintf("%s %s § %s\n", CitationTag(chunk.ID), chunk.SourcePath, chunk.Section))
+			case chunk.ID > 0:
+				sb.WriteString(fmt.Sprintf("%s %s\n", CitationTag(chunk.ID), chunk.SourcePath))
+			case chunk.Section != "":
+				sb.WriteString(fmt.Sprintf("§ %s\n", chunk.Section))
+			}
+			sb.WriteString(QuoteContext(chunk.Content))
 			sb.WriteString("\n\n")
 		}
 	}
@@ -119,6 +231,42 @@ func (cm *ContextManager) BuildContextPrompt(chunks []ContextChunk) string {
 	return sb.String()
 }
 
+// CitationInstruction asks the model to cite tagged context chunks.
+const CitationInstruction = "Each source below starts with a tag like [c12]. When your answer relies on a source, cite it by writing its tag after the statement."
+
+// citationPattern matches citation tags in model output.
+var citationPattern = regexp.MustCompile(`\[c(\d+)\]`)
+
+// CitationTag returns the tag used to cite the chunk with the given ID.
+func CitationTag(id int64) string {
+	return fmt.Sprintf("[c%d]", id)
+}
+
+// ExtractCitations returns the chunk IDs cited in text, in order of first
+// appearance.
+func ExtractCitations(text string) []int64 {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, match := range citationPattern.FindAllStringSubmatch(text, -1) {
+		id, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// strippedCitationPattern matches citation tags with the space before them.
+var strippedCitationPattern = regexp.MustCompile(`[ \t]*\[c\d+\]`)
+
+// StripCitations removes citation tags from text, e.g. before it is used as
+// manuscript prose.
+func StripCitations(text string) string {
+	return strippedCitationPattern.ReplaceAllString(text, "")
+}
+
 // TruncateHistory truncates conversation history to fit within budget.
 func (cm *ContextManager) TruncateHistory(messages []ChatMessage, budget int) []ChatMessage {
 	if len(messages) == 0 {
@@ -143,13 +291,13 @@ func (cm *ContextManager) TruncateHistory(messages []ChatMessage, budget int) []
 	// Calculate tokens for history (most recent first)
 	usedTokens := 0
 	if systemMsg != nil {
-		usedTokens = cm.tokenizer.Count(systemMsg.Content)
+		usedTokens = cm.countTokens(systemMsg.Content)
 	}
 
 	// Keep most recent messages that fit
 	var kept []ChatMessage
 	for i := len(history) - 1; i >= 0; i-- {
-		msgTokens := cm.tokenizer.Count(history[i].Content)
+		msgTokens := cm.countTokens(history[i].Content)
 		if usedTokens+msgTokens > budget {
 			break
 		}
@@ -165,6 +313,25 @@ func (cm *ContextManager) TruncateHistory(messages []ChatMessage, budget int) []
 	return kept
 }
 
+// countTokens counts the tokens in text, remembering the count.
+func (cm *ContextManager) countTokens(text string) int {
+	cm.countsMu.Lock()
+	n, ok := cm.counts[text]
+	cm.countsMu.Unlock()
+	if ok {
+		return n
+	}
+
+	n = cm.tokenizer.Count(text)
+	cm.countsMu.Lock()
+	if cm.counts == nil || len(cm.counts) >= maxCachedCounts {
+		cm.counts = make(map[string]int)
+	}
+	cm.counts[text] = n
+	cm.countsMu.Unlock()
+	return n
+}
+
 // SummarizeHistory creates a summary of old messages to preserve context.
 func (cm *ContextManager) SummarizeHistory(messages []ChatMessage, maxMessages int) (summary string, remaining []ChatMessage) {
 	if len(messages) <= maxMessages {
@@ -229,12 +396,37 @@ func (b *SystemPromptBuilder) AddProjectInfo(name, genre string) *SystemPromptBu
 
 // AddWritingStyle adds writing style guidelines.
 func (b *SystemPromptBuilder) AddWritingStyle(style types.WritingConfig) *SystemPromptBuilder {
+	b.parts = append(b.parts, WritingGuidelines(style))
+	return b
+}
+
+// WritingGuidelines formats the project's writing preferences, including its
+// content rating when one is set.
+func WritingGuidelines(style types.WritingConfig) string {
 	guidelines := fmt.Sprintf(`Writing Guidelines:
 - Style: %s
 - Point of View: %s
 - Tense: %s`, style.Style, style.POV, style.Tense)
-	b.parts = append(b.parts, guidelines)
-	return b
+	if rating := ContentRatingGuideline(style.Rating); rating != "" {
+		guidelines += "\n- Content Rating: " + rating
+	}
+	return guidelines
+}
+
+// ContentRatingGuideline describes what a content rating allows.
+func ContentRatingGuideline(rating string) string {
+	switch rating {
+	case "":
+		return ""
+	case types.RatingYA:
+		return "Young adult (YA). Keep violence, romance, and language suitable for readers aged 13-17: no explicit sexual content and no gratuitous gore."
+	case types.RatingAdult:
+		return "Adult. Mature themes, strong language, and graphic scenes are allowed when the story calls for them."
+	case types.RatingNoGraphicViolence:
+		return "No graphic violence. Violence may happen, but describe it without gore or lingering physical detail; imply or cut away instead."
+	default:
+		return rating
+	}
 }
 
 // AddContext adds context information.
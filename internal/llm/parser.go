@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/azyu/dreamteller/pkg/types"
 )
@@ -51,39 +52,159 @@ func NewPromptParserWithModel(provider Provider, model string) *PromptParser {
 	}
 }
 
+// maxRepairAttempts is how many times a malformed setup response is sent
+// back to the model for correction.
+const maxRepairAttempts = 2
+
 // ParseSetupPrompt parses a free-form prompt and extracts project setup information.
 // It uses the AI to analyze the prompt and extract genre, characters, setting,
 // plot hints, and writing style preferences.
+//
+// The strategy follows the provider's capabilities: native structured output
+// when available, then a forced tool call, then JSON in plain text. Responses
+// are validated against the setup schema and invalid ones are sent back for
+// repair up to maxRepairAttempts times.
 func (p *PromptParser) ParseSetupPrompt(ctx context.Context, prompt string) (*types.ParsePromptResult, error) {
 	if prompt == "" {
 		return nil, ErrEmptyPrompt
 	}
 
+	caps := p.provider.Capabilities()
+	useTools := !caps.SupportsStructuredOutput && caps.SupportsTools
+
 	systemPrompt := buildExtractionSystemPrompt()
+	if !useTools {
+		systemPrompt = buildStructuredSystemPrompt()
+	}
 
 	messages := []ChatMessage{
 		NewSystemMessage(systemPrompt),
 		NewUserMessage(prompt),
 	}
 
-	tools := []ToolDefinition{
-		extractProjectSetupTool(),
+	var lastErr error
+	for attempt := 0; attempt <= maxRepairAttempts; attempt++ {
+		req := ChatRequest{
+			Messages:    messages,
+			Temperature: 0.3,
+			MaxTokens:   2000,
+		}
+		if useTools {
+			req.Tools = []ToolDefinition{extractProjectSetupTool()}
+			req.ToolChoice = "required"
+		} else {
+			req.ResponseSchema = setupResponseSchema()
+		}
+
+		resp, err := p.provider.Chat(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("provider error: %w", err)
+		}
+
+		var arguments string
+		if useTools {
+			arguments, err = toolCallArguments(resp)
+		} else {
+			arguments = extractJSONObject(resp.Message.Content)
+		}
+		if err == nil {
+			err = ValidateJSON(setupResponseSchema().Schema, arguments)
+		}
+
+		var result *types.ParsePromptResult
+		if err == nil {
+			result, err = parseExtractedData(arguments)
+		}
+		if err == nil {
+			return result, nil
+		}
+
+		// Wrong tool or no tool at all is not something a repair prompt fixes
+		// reliably; surface it so callers can fall back.
+		if errors.Is(err, ErrNoToolCall) || errors.Is(err, ErrWrongTool) {
+			return nil, err
+		}
+
+		lastErr = err
+		messages = append(messages, repairMessages(resp, err)...)
+	}
+
+	return nil, fmt.Errorf("setup response still invalid after %d repair attempts: %w", maxRepairAttempts, lastErr)
+}
+
+// setupResponseSchema returns the structured-output schema for project setup.
+func setupResponseSchema() *ResponseSchema {
+	return &ResponseSchema{
+		Name:   ToolExtractProjectSetup,
+		Schema: extractProjectSetupTool().Function.Parameters,
 	}
+}
 
-	req := ChatRequest{
-		Messages:    messages,
-		Tools:       tools,
-		ToolChoice:  "required",
-		Temperature: 0.3,
-		MaxTokens:   2000,
+// buildStructuredSystemPrompt adapts the extraction prompt for JSON replies.
+func buildStructuredSystemPrompt() string {
+	prompt := strings.Replace(buildExtractionSystemPrompt(),
+		"You MUST use the extract_project_setup tool to provide your response in a structured format.",
+		"Respond with a single JSON object only, with no prose or markdown.", 1)
+
+	if schema, err := json.Marshal(extractProjectSetupTool().Function.Parameters); err == nil {
+		prompt += "\n\nThe JSON object must match this schema:\n" + string(schema)
 	}
+	return prompt
+}
 
-	resp, err := p.provider.Chat(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("provider error: %w", err)
+// toolCallArguments returns the arguments of the extract_project_setup call.
+func toolCallArguments(resp *ChatResponse) (string, error) {
+	if !resp.Message.HasToolCalls() {
+		return "", ErrNoToolCall
 	}
 
-	return parseToolCallResponse(resp)
+	toolCall := resp.Message.ToolCalls[0]
+	if toolCall.Function.Name != ToolExtractProjectSetup {
+		return "", fmt.Errorf("%w: expected %s, got %s",
+			ErrWrongTool, ToolExtractProjectSetup, toolCall.Function.Name)
+	}
+
+	return toolCall.Function.Arguments, nil
+}
+
+// repairMessages builds the follow-up turn asking the model to fix its output.
+func repairMessages(resp *ChatResponse, cause error) []ChatMessage {
+	instruction := fmt.Sprintf("Your previous response was invalid: %v. Return a corrected, complete response that matches the schema.", cause)
+
+	if resp.Message.HasToolCalls() {
+		call := resp.Message.ToolCalls[0]
+		return []ChatMessage{
+			resp.Message,
+			{Role: RoleTool, Content: instruction, ToolCallID: call.ID, Name: call.Function.Name},
+		}
+	}
+
+	return []ChatMessage{
+		NewAssistantMessage(resp.Message.Content),
+		NewUserMessage(instruction),
+	}
+}
+
+// extractJSONObject pulls a JSON object out of text that may be wrapped in
+// markdown fences or surrounded by prose.
+func extractJSONObject(content string) string {
+	content = strings.TrimSpace(content)
+
+	if idx := strings.Index(content, "```"); idx != -1 {
+		inner := content[idx+3:]
+		inner = strings.TrimPrefix(inner, "json")
+		if end := strings.Index(inner, "```"); end != -1 {
+			content = inner[:end]
+		}
+	}
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start != -1 && end > start {
+		content = content[start : end+1]
+	}
+
+	return strings.TrimSpace(content)
 }
 
 // buildExtractionSystemPrompt creates the system prompt for extraction.
@@ -178,21 +299,6 @@ func extractProjectSetupTool() ToolDefinition {
 	}
 }
 
-// parseToolCallResponse extracts the ParsePromptResult from the AI response.
-func parseToolCallResponse(resp *ChatResponse) (*types.ParsePromptResult, error) {
-	if !resp.Message.HasToolCalls() {
-		return nil, ErrNoToolCall
-	}
-
-	toolCall := resp.Message.ToolCalls[0]
-	if toolCall.Function.Name != ToolExtractProjectSetup {
-		return nil, fmt.Errorf("%w: expected %s, got %s",
-			ErrWrongTool, ToolExtractProjectSetup, toolCall.Function.Name)
-	}
-
-	return parseExtractedData(toolCall.Function.Arguments)
-}
-
 // rawExtractedData matches the JSON structure from the AI tool call.
 type rawExtractedData struct {
 	Genre   string `json:"genre"`
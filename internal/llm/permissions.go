@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// Tool permission profiles.
+const (
+	ToolProfileAll        = "all"
+	ToolProfileReadOnly   = "read-only"
+	ToolProfileSearchOnly = "search-only"
+	ToolProfileNone       = "none"
+)
+
+// ToolPolicy decides which chat tools a project lets the AI call. A nil
+// ToolPolicy allows every tool.
+type ToolPolicy struct {
+	allowed map[string]bool
+}
+
+// NewToolPolicy checks a project's tool permissions. It returns nil when
+// they allow every tool.
+func NewToolPolicy(perms types.ToolPermissions) (*ToolPolicy, error) {
+	known := make(map[string]bool)
+	for _, tool := range ChatTools() {
+		known[tool.Function.Name] = true
+	}
+	for _, name := range append(append([]string{}, perms.Allow...), perms.Deny...) {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown tool %q in tool permissions", name)
+		}
+	}
+
+	allowed := make(map[string]bool)
+	for name := range known {
+		switch strings.ToLower(perms.Profile) {
+		case "", ToolProfileAll:
+			allowed[name] = true
+		case ToolProfileReadOnly:
+			allowed[name] = !WritesProject(name)
+		case ToolProfileSearchOnly:
+			allowed[name] = name == ToolSearchContext || name == ToolExpandSearchResult
+		case ToolProfileNone:
+			allowed[name] = false
+		default:
+			return nil, fmt.Errorf("unknown tool profile %q: use %s, %s, %s or %s", perms.Profile, ToolProfileAll, ToolProfileReadOnly, ToolProfileSearchOnly, ToolProfileNone)
+		}
+	}
+	if len(perms.Allow) > 0 {
+		listed := make(map[string]bool)
+		for _, name := range perms.Allow {
+			listed[name] = true
+		}
+		for name := range allowed {
+			allowed[name] = allowed[name] && listed[name]
+		}
+	}
+	for _, name := range perms.Deny {
+		allowed[name] = false
+	}
+
+	for _, ok := range allowed {
+		if !ok {
+			return &ToolPolicy{allowed: allowed}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Allows reports whether the AI may call the named tool.
+func (p *ToolPolicy) Allows(name string) bool {
+	return p == nil || p.allowed[name]
+}
+
+// Filter returns the tools the policy allows.
+func (p *ToolPolicy) Filter(tools []ToolDefinition) []ToolDefinition {
+	if p == nil {
+		return tools
+	}
+	var allowed []ToolDefinition
+	for _, tool := range tools {
+		if p.Allows(tool.Function.Name) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
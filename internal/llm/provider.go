@@ -68,6 +68,13 @@ type Provider interface {
 	Close() error
 }
 
+// ModelLister is implemented by providers that can list the models they
+// serve, e.g. for switching models mid-session.
+type ModelLister interface {
+	// ListModels returns the IDs of the available chat models, sorted.
+	ListModels(ctx context.Context) ([]string, error)
+}
+
 // ChatRequest represents a request to the chat API.
 type ChatRequest struct {
 	// Messages is the conversation history to send.
@@ -91,6 +98,19 @@ type ChatRequest struct {
 
 	// Stop sequences that will stop generation.
 	Stop []string
+
+	// JSONMode asks the model to respond with a single JSON object.
+	// Ignored by providers whose Capabilities report no JSON mode support.
+	JSONMode bool
+
+	// ResponseSchema constrains the response to a JSON Schema.
+	// Only honored when Capabilities.SupportsStructuredOutput is true.
+	ResponseSchema *ResponseSchema
+
+	// Seed makes sampling repeatable: the same request with the same seed
+	// asks for the same response. Nil lets the provider choose.
+	// Only honored when Capabilities.SupportsSeed is true.
+	Seed *int
 }
 
 // ChatMessage represents a single message in a conversation.
@@ -247,6 +267,18 @@ type Capabilities struct {
 	// SupportsVision indicates if the provider supports image inputs.
 	SupportsVision bool
 
+	// SupportsJSONMode indicates if the provider can be forced to return
+	// a single JSON object (see ChatRequest.JSONMode).
+	SupportsJSONMode bool
+
+	// SupportsStructuredOutput indicates if the provider can constrain the
+	// response to a JSON Schema (see ChatRequest.ResponseSchema).
+	SupportsStructuredOutput bool
+
+	// SupportsSeed indicates if the provider accepts a sampling seed
+	// (see ChatRequest.Seed).
+	SupportsSeed bool
+
 	// MaxContextTokens is the maximum context window size.
 	MaxContextTokens int
 
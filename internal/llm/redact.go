@@ -0,0 +1,253 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// defaultRedactionAlias names the aliases of rules that do not set one.
+const defaultRedactionAlias = "REDACTED"
+
+// Redactor swaps sensitive strings for aliases in text sent to a provider
+// and swaps them back in what the provider returns. An alias given to a
+// string stays the same for the life of the Redactor, so a conversation
+// sent again in a later request reads the same.
+type Redactor struct {
+	rules []redactionRule
+
+	mu        sync.Mutex
+	aliases   map[string]string // original -> alias
+	originals map[string]string // alias -> original
+	counts    map[string]int    // numbered aliases handed out per base
+}
+
+type redactionRule struct {
+	text    string
+	pattern *regexp.Regexp
+	alias   string
+	fixed   bool
+}
+
+// NewRedactor compiles redaction rules. It returns nil when there are no
+// rules.
+func NewRedactor(rules []types.RedactionRule) (*Redactor, error) {
+	r := &Redactor{
+		aliases:   map[string]string{},
+		originals: map[string]string{},
+		counts:    map[string]int{},
+	}
+	for i, rule := range rules {
+		compiled := redactionRule{text: rule.Text, alias: rule.Alias}
+		switch {
+		case rule.Text != "" && rule.Pattern != "":
+			return nil, fmt.Errorf("redaction rule %d sets both text and pattern", i+1)
+		case rule.Pattern != "":
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("redaction rule %d: invalid pattern: %w", i+1, err)
+			}
+			compiled.pattern = re
+		case rule.Text == "":
+			return nil, fmt.Errorf("redaction rule %d sets neither text nor pattern", i+1)
+		case rule.Alias != "":
+			if other, ok := r.originals[rule.Alias]; ok {
+				return nil, fmt.Errorf("redaction alias %q is used for both %q and %q", rule.Alias, other, rule.Text)
+			}
+			compiled.fixed = true
+			r.aliases[rule.Text] = rule.Alias
+			r.originals[rule.Alias] = rule.Text
+		}
+		if compiled.alias == "" {
+			compiled.alias = defaultRedactionAlias
+		}
+		r.rules = append(r.rules, compiled)
+	}
+	if len(r.rules) == 0 {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// Redact replaces every sensitive string in text with its alias. Where
+// rules overlap, the earliest and then the longest match wins.
+func (r *Redactor) Redact(text string) string {
+	type span struct {
+		start, end int
+		rule       int
+	}
+	var spans []span
+	for i, rule := range r.rules {
+		if rule.pattern != nil {
+			for _, loc := range rule.pattern.FindAllStringIndex(text, -1) {
+				if loc[1] > loc[0] {
+					spans = append(spans, span{loc[0], loc[1], i})
+				}
+			}
+			continue
+		}
+		for at := 0; ; {
+			n := strings.Index(text[at:], rule.text)
+			if n < 0 {
+				break
+			}
+			spans = append(spans, span{at + n, at + n + len(rule.text), i})
+			at += n + len(rule.text)
+		}
+	}
+	if len(spans) == 0 {
+		return text
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sb strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			continue
+		}
+		sb.WriteString(text[last:s.start])
+		sb.WriteString(r.aliasLocked(text[s.start:s.end], r.rules[s.rule]))
+		last = s.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// aliasLocked returns the alias of original, handing out the next
+// numbered alias of rule the first time original is seen.
+func (r *Redactor) aliasLocked(original string, rule redactionRule) string {
+	if alias, ok := r.aliases[original]; ok {
+		return alias
+	}
+	for {
+		r.counts[rule.alias]++
+		alias := fmt.Sprintf("%s-%d", rule.alias, r.counts[rule.alias])
+		if _, taken := r.originals[alias]; !taken {
+			r.aliases[original] = alias
+			r.originals[alias] = original
+			return alias
+		}
+	}
+}
+
+// RedactRequest returns a copy of req with its messages redacted,
+// including the arguments of earlier tool calls.
+func (r *Redactor) RedactRequest(req ChatRequest) ChatRequest {
+	messages := make([]ChatMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		msg.Content = r.Redact(msg.Content)
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]ToolCall, len(msg.ToolCalls))
+			for j, call := range msg.ToolCalls {
+				call.Function.Arguments = r.Redact(call.Function.Arguments)
+				calls[j] = call
+			}
+			msg.ToolCalls = calls
+		}
+		messages[i] = msg
+	}
+	req.Messages = messages
+	return req
+}
+
+// Restorer returns a StreamRestorer for text written by the provider in
+// reply to what has been redacted so far. In JSON, such as tool call
+// arguments, the original strings are escaped.
+func (r *Redactor) Restorer(inJSON bool) *StreamRestorer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	originals := make(map[string]string, len(r.originals))
+	for alias, original := range r.originals {
+		if inJSON {
+			quoted, _ := json.Marshal(original)
+			original = string(quoted[1 : len(quoted)-1])
+		}
+		originals[alias] = original
+	}
+	return &StreamRestorer{originals: originals}
+}
+
+// Restore swaps the aliases in text back for the original strings.
+func (r *Redactor) Restore(text string, inJSON bool) string {
+	restorer := r.Restorer(inJSON)
+	return restorer.Write(text) + restorer.Flush()
+}
+
+// StreamRestorer swaps aliases back in text that arrives in pieces. Text
+// that may be the start of an alias is held back until the next piece
+// shows whether it is.
+type StreamRestorer struct {
+	originals map[string]string
+	pending   string
+}
+
+// Write adds a piece of text and returns what can be shown of it so far.
+func (s *StreamRestorer) Write(text string) string {
+	s.pending += text
+	out, rest := s.restore(s.pending, false)
+	s.pending = rest
+	return out
+}
+
+// Flush returns the text held back at the end of the stream.
+func (s *StreamRestorer) Flush() string {
+	out, _ := s.restore(s.pending, true)
+	s.pending = ""
+	return out
+}
+
+// restore restores text up to any trailing part that could still grow into
+// an alias, which it returns separately unless final.
+func (s *StreamRestorer) restore(text string, final bool) (string, string) {
+	var sb strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		if !final && s.couldGrow(rest) {
+			return sb.String(), rest
+		}
+		if alias := s.longestAlias(rest); alias != "" {
+			sb.WriteString(s.originals[alias])
+			i += len(alias)
+			continue
+		}
+		sb.WriteByte(text[i])
+		i++
+	}
+	return sb.String(), ""
+}
+
+// couldGrow reports whether text is the start of an alias longer than it.
+func (s *StreamRestorer) couldGrow(text string) bool {
+	for alias := range s.originals {
+		if len(text) < len(alias) && strings.HasPrefix(alias, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// longestAlias returns the longest alias text starts with.
+func (s *StreamRestorer) longestAlias(text string) string {
+	var longest string
+	for alias := range s.originals {
+		if len(alias) > len(longest) && strings.HasPrefix(text, alias) {
+			longest = alias
+		}
+	}
+	return longest
+}
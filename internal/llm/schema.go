@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSchemaViolation is returned when a JSON value does not match its schema.
+var ErrSchemaViolation = errors.New("response does not match schema")
+
+// ResponseSchema requests provider-native structured output.
+type ResponseSchema struct {
+	// Name identifies the schema (required by OpenAI).
+	Name string
+
+	// Schema is a JSON Schema object describing the expected response.
+	Schema map[string]interface{}
+
+	// Strict enables strict schema adherence where supported.
+	Strict bool
+}
+
+// MarshalJSON lets ResponseSchema be passed where a json.Marshaler is expected.
+func (s *ResponseSchema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Schema)
+}
+
+// ValidateJSON checks that raw is valid JSON matching the schema.
+// It supports the subset of JSON Schema used by the predefined tools:
+// type, properties, required, items and enum.
+func ValidateJSON(schema map[string]interface{}, raw string) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return fmt.Errorf("%w: invalid JSON: %v", ErrSchemaViolation, err)
+	}
+	return validateValue(schema, value, "$")
+}
+
+// validateValue recursively validates value against schema at path.
+func validateValue(schema map[string]interface{}, value interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+
+	if typ, ok := schema["type"].(string); ok {
+		if !matchesType(typ, value) {
+			return fmt.Errorf("%w: %s must be %s", ErrSchemaViolation, path, typ)
+		}
+	}
+
+	if enum := toStringSlice(schema["enum"]); len(enum) > 0 {
+		s, _ := value.(string)
+		found := false
+		for _, e := range enum {
+			if e == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s must be one of %s", ErrSchemaViolation, path, strings.Join(enum, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range toStringSlice(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%w: %s.%s is required", ErrSchemaViolation, path, name)
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		for name, propSchema := range props {
+			child, ok := v[name]
+			if !ok || child == nil {
+				continue
+			}
+			ps, _ := propSchema.(map[string]interface{})
+			if err := validateValue(ps, child, path+"."+name); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range v {
+			if err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesType reports whether value has the JSON Schema type typ.
+func matchesType(typ string, value interface{}) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+// toStringSlice converts []string or []interface{} schema values to []string.
+func toStringSlice(v interface{}) []string {
+	switch s := v.(type) {
+	case []string:
+		return s
+	case []interface{}:
+		out := make([]string, 0, len(s))
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// textToolBlockPattern matches fenced ```tool or ```json blocks in model output.
+var textToolBlockPattern = regexp.MustCompile("(?s)```(?:tool|json)?[ \t]*\n(.*?)\n?```")
+
+// textToolPayload is the JSON shape models are asked to emit when native
+// tool calling is unavailable.
+type textToolPayload struct {
+	Name      string          `json:"name"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// TextToolInstructions describes the given tools in prose so that models
+// without native function calling can request them as JSON in their reply.
+// Returns an empty string when there are no tools.
+func TextToolInstructions(tools []ToolDefinition) string {
+	if len(tools) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Tools\n")
+	sb.WriteString("You can call one tool by replying with a fenced block in exactly this format:\n")
+	sb.WriteString("```tool\n{\"name\": \"<tool name>\", \"arguments\": { ... }}\n```\n")
+	sb.WriteString("Only call a tool when it clearly helps; otherwise reply normally.\n\n")
+	sb.WriteString("Available tools:\n")
+
+	for _, tool := range tools {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", tool.Function.Name, tool.Function.Description))
+		if tool.Function.Parameters != nil {
+			if schema, err := json.Marshal(tool.Function.Parameters); err == nil {
+				sb.WriteString(fmt.Sprintf("  arguments schema: %s\n", schema))
+			}
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// ExtractTextToolCalls finds tool calls written as JSON in plain model output.
+// Only calls naming one of the given tools are accepted. It returns the calls
+// found and the content with those blocks removed.
+func ExtractTextToolCalls(content string, tools []ToolDefinition) ([]ToolCall, string) {
+	known := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		known[tool.Function.Name] = true
+	}
+
+	var calls []ToolCall
+	remaining := textToolBlockPattern.ReplaceAllStringFunc(content, func(block string) string {
+		inner := textToolBlockPattern.FindStringSubmatch(block)[1]
+		call, ok := parseTextToolPayload(inner, known, len(calls))
+		if !ok {
+			return block
+		}
+		calls = append(calls, call)
+		return ""
+	})
+
+	// Some models emit the JSON object bare, without a fence.
+	if len(calls) == 0 {
+		trimmed := strings.TrimSpace(content)
+		if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+			if call, ok := parseTextToolPayload(trimmed, known, 0); ok {
+				return []ToolCall{call}, ""
+			}
+		}
+	}
+
+	return calls, strings.TrimSpace(remaining)
+}
+
+// parseTextToolPayload decodes a single JSON tool request.
+func parseTextToolPayload(raw string, known map[string]bool, index int) (ToolCall, bool) {
+	var payload textToolPayload
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &payload); err != nil {
+		return ToolCall{}, false
+	}
+
+	name := payload.Name
+	if name == "" {
+		name = payload.Tool
+	}
+	if !known[name] {
+		return ToolCall{}, false
+	}
+
+	args := string(payload.Arguments)
+	if args == "" || args == "null" {
+		args = "{}"
+	}
+
+	// Arguments may arrive as a JSON-encoded string rather than an object.
+	var encoded string
+	if err := json.Unmarshal(payload.Arguments, &encoded); err == nil {
+		args = encoded
+	}
+
+	return ToolCall{
+		ID:   fmt.Sprintf("text_call_%s_%d", name, index),
+		Type: "function",
+		Function: FunctionCall{
+			Name:      name,
+			Arguments: args,
+		},
+	}, true
+}
@@ -14,9 +14,50 @@ const (
 	ToolAskUserClarification     = "ask_user_clarification"
 	ToolUpdateContext            = "update_context"
 	ToolSearchContext            = "search_context"
+	ToolExpandSearchResult       = "expand_search_result"
 	ToolExtractProjectSetup      = "extract_project_setup"
+	ToolRememberFact             = "remember_fact"
+	ToolGetCharacterVoice        = "get_character_voice"
+	ToolUpdateChapter            = "update_chapter"
+	ToolGetTravelTime            = "get_travel_time"
+	ToolMoveItem                 = "move_item"
 )
 
+// ChatTools returns the tools offered during chat: the predefined tools
+// minus extract_project_setup, which only the setup wizard uses.
+func ChatTools() []ToolDefinition {
+	var tools []ToolDefinition
+	for _, tool := range PredefinedTools() {
+		if tool.Function.Name != ToolExtractProjectSetup {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// SearchTools returns the chat tools that only search the project:
+// search_context and expand_search_result.
+func SearchTools() []ToolDefinition {
+	var tools []ToolDefinition
+	for _, tool := range ChatTools() {
+		if SearchesProject(tool.Function.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// SearchesProject reports whether a tool only searches the project.
+func SearchesProject(name string) bool {
+	return name == ToolSearchContext || name == ToolExpandSearchResult
+}
+
+// WritesProject reports whether applying a tool call changes the project's
+// files or memories.
+func WritesProject(name string) bool {
+	return name == ToolUpdateContext || name == ToolRememberFact || name == ToolUpdateChapter || name == ToolMoveItem
+}
+
 // PredefinedTools returns the tool definitions for novel writing.
 func PredefinedTools() []ToolDefinition {
 	return []ToolDefinition{
@@ -24,7 +65,7 @@ func PredefinedTools() []ToolDefinition {
 			Type: "function",
 			Function: FunctionDefinition{
 				Name:        ToolSuggestPlotDevelopment,
-				Description: "Suggest plot development ideas based on current story context. Use this when the user needs ideas for advancing the story.",
+				Description: "Suggest ideas for advancing the plot from the current story.",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -40,7 +81,7 @@ func PredefinedTools() []ToolDefinition {
 									},
 									"description": map[string]interface{}{
 										"type":        "string",
-										"description": "Detailed description of the plot development",
+										"description": "Details",
 									},
 									"impact": map[string]interface{}{
 										"type":        "string",
@@ -59,7 +100,7 @@ func PredefinedTools() []ToolDefinition {
 			Type: "function",
 			Function: FunctionDefinition{
 				Name:        ToolSuggestCharacterAction,
-				Description: "Suggest character actions or behaviors based on their personality and the current situation.",
+				Description: "Suggest actions that fit a character's personality and the situation.",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -75,15 +116,15 @@ func PredefinedTools() []ToolDefinition {
 								"properties": map[string]interface{}{
 									"action": map[string]interface{}{
 										"type":        "string",
-										"description": "The action the character could take",
+										"description": "The action",
 									},
 									"motivation": map[string]interface{}{
 										"type":        "string",
-										"description": "Why this action fits the character",
+										"description": "Why it fits the character",
 									},
 									"dialogue": map[string]interface{}{
 										"type":        "string",
-										"description": "Optional dialogue for this action",
+										"description": "Optional dialogue",
 									},
 								},
 								"required": []string{"action", "motivation"},
@@ -98,7 +139,7 @@ func PredefinedTools() []ToolDefinition {
 			Type: "function",
 			Function: FunctionDefinition{
 				Name:        ToolAskUserClarification,
-				Description: "Ask the user for clarification when something is unclear or when a decision is needed.",
+				Description: "Ask the user when something is unclear or a decision is needed.",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -115,7 +156,7 @@ func PredefinedTools() []ToolDefinition {
 						},
 						"context": map[string]interface{}{
 							"type":        "string",
-							"description": "Additional context for why this question is being asked",
+							"description": "Why you are asking",
 						},
 					},
 					"required": []string{"question"},
@@ -126,34 +167,37 @@ func PredefinedTools() []ToolDefinition {
 			Type: "function",
 			Function: FunctionDefinition{
 				Name:        ToolUpdateContext,
-				Description: "Suggest updates to context files (characters, settings, plot). Changes must be approved by the user.",
+				Description: "Suggest updates to context files. rename gives a file a clearer name; archive moves one that no longer applies out of the story bible. The user approves changes.",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
 						"file_type": map[string]interface{}{
 							"type":        "string",
-							"enum":        []string{"character", "setting", "plot"},
-							"description": "Type of context file to update",
+							"enum":        []string{"character", "setting", "plot", "glossary"},
+							"description": "Glossary files list terms as \"- Term: definition\" lines",
 						},
 						"file_name": map[string]interface{}{
 							"type":        "string",
-							"description": "Name of the file (without path or extension)",
+							"description": "File name without extension",
 						},
 						"operation": map[string]interface{}{
 							"type":        "string",
-							"enum":        []string{"create", "update", "append"},
-							"description": "Type of operation",
+							"enum":        []string{"create", "update", "append", "rename", "archive"},
 						},
 						"content": map[string]interface{}{
 							"type":        "string",
-							"description": "The content to write or append",
+							"description": "Content to write or append; empty for rename and archive",
+						},
+						"new_name": map[string]interface{}{
+							"type":        "string",
+							"description": "For rename: the new file name",
 						},
 						"reason": map[string]interface{}{
 							"type":        "string",
-							"description": "Why this update is suggested",
+							"description": "Why",
 						},
 					},
-					"required": []string{"file_type", "file_name", "operation", "content", "reason"},
+					"required": []string{"file_type", "file_name", "operation", "reason"},
 				},
 			},
 		},
@@ -161,7 +205,7 @@ func PredefinedTools() []ToolDefinition {
 			Type: "function",
 			Function: FunctionDefinition{
 				Name:        ToolSearchContext,
-				Description: "Search through context files to find relevant information for the current conversation.",
+				Description: "Search context files and chapters; returns short passages that expand_search_result reads more around.",
 				Parameters: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -169,16 +213,163 @@ func PredefinedTools() []ToolDefinition {
 							"type":        "string",
 							"description": "Search query",
 						},
-						"filter_type": map[string]interface{}{
-							"type":        "string",
-							"enum":        []string{"all", "character", "setting", "plot", "chapter"},
-							"description": "Filter by content type",
+						"source_types": map[string]interface{}{
+							"type": "array",
+							"items": map[string]interface{}{
+								"type": "string",
+								"enum": []string{"character", "setting", "plot", "chapter", "glossary", "research"},
+							},
+							"description": "Content types to include",
+						},
+						"limit": map[string]interface{}{
+							"type":        "integer",
+							"description": fmt.Sprintf("Max results (default %d, max %d)", DefaultSearchLimit, MaxSearchLimit),
+						},
+						"chapter_from": map[string]interface{}{
+							"type":        "integer",
+							"description": "First chapter",
+						},
+						"chapter_to": map[string]interface{}{
+							"type":        "integer",
+							"description": "Last chapter",
 						},
 					},
 					"required": []string{"query"},
 				},
 			},
 		},
+		{
+			Type: "function",
+			Function: FunctionDefinition{
+				Name:        ToolExpandSearchResult,
+				Description: "Read a search result with the passages around it in the same file.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{
+							"type":        "integer",
+							"description": "The number in the result's tag, e.g. 12 for [c12]",
+						},
+						"radius": map[string]interface{}{
+							"type":        "integer",
+							"description": "Passages to read on each side (default 1, max 3)",
+						},
+					},
+					"required": []string{"id"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: FunctionDefinition{
+				Name:        ToolRememberFact,
+				Description: "Save a durable story fact (a decision, rule, or detail that must stay true) to project memory, only if the user confirmed it or asked you to remember it. The user approves each fact.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"fact": map[string]interface{}{
+							"type":        "string",
+							"description": "The fact to remember, as one self-contained sentence",
+						},
+						"reason": map[string]interface{}{
+							"type":        "string",
+							"description": "Why this fact should be remembered",
+						},
+					},
+					"required": []string{"fact"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: FunctionDefinition{
+				Name:        ToolGetCharacterVoice,
+				Description: "Look up a character's voice profile (diction notes, sample lines) before writing dialogue if it is not in the context.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"character": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of the character",
+						},
+					},
+					"required": []string{"character"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: FunctionDefinition{
+				Name:        ToolUpdateChapter,
+				Description: "Propose find/replace line edits to a chapter; each find must appear in it exactly once. The user approves a diff.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"chapter": map[string]interface{}{
+							"type":        "integer",
+							"description": "Chapter number",
+						},
+						"edits": map[string]interface{}{
+							"type":        "array",
+							"description": fmt.Sprintf("At most %d", MaxChapterEdits),
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"find": map[string]interface{}{
+										"type":        "string",
+										"description": "Exact passage to replace",
+									},
+									"replace": map[string]interface{}{
+										"type":        "string",
+										"description": "Replacement text; empty deletes",
+									},
+								},
+								"required": []string{"find", "replace"},
+							},
+						},
+						"reason": map[string]interface{}{
+							"type":        "string",
+							"description": "Why edit",
+						},
+					},
+					"required": []string{"chapter", "edits", "reason"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: FunctionDefinition{
+				Name:        ToolGetTravelTime,
+				Description: "Look up how long travel takes between two places in the settings.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"from": map[string]interface{}{"type": "string"},
+						"to":   map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"from", "to"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: FunctionDefinition{
+				Name:        ToolMoveItem,
+				Description: "Record where a significant object is as of a chapter: who holds it, where it is, or its status (e.g. destroyed). The user approves.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"item":    map[string]interface{}{"type": "string"},
+						"chapter": map[string]interface{}{"type": "integer"},
+						"holder":  map[string]interface{}{"type": "string"},
+						"place":   map[string]interface{}{"type": "string"},
+						"status":  map[string]interface{}{"type": "string"},
+						"note":    map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"item", "chapter"},
+				},
+			},
+		},
 		{
 			Type: "function",
 			Function: FunctionDefinition{
@@ -315,12 +506,107 @@ type ContextUpdate struct {
 	Operation string `json:"operation"`
 	Content   string `json:"content"`
 	Reason    string `json:"reason"`
+
+	// NewName is the file's new name for a rename.
+	NewName string `json:"new_name,omitempty"`
 }
 
 // SearchQuery represents a context search query.
 type SearchQuery struct {
-	Query      string `json:"query"`
-	FilterType string `json:"filter_type,omitempty"`
+	Query string `json:"query"`
+	// FilterType is the single-type filter from earlier versions of the
+	// tool, still accepted alongside SourceTypes.
+	FilterType  string   `json:"filter_type,omitempty"`
+	SourceTypes []string `json:"source_types,omitempty"`
+	Limit       int      `json:"limit,omitempty"`
+	ChapterFrom int      `json:"chapter_from,omitempty"`
+	ChapterTo   int      `json:"chapter_to,omitempty"`
+}
+
+// Search result limits for the search_context tool.
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 20
+)
+
+// Types returns the content types the query is restricted to, combining
+// FilterType and SourceTypes. An empty result means all types.
+func (q SearchQuery) Types() []string {
+	var types []string
+	seen := make(map[string]bool)
+	for _, t := range append([]string{q.FilterType}, q.SourceTypes...) {
+		if t == "" || t == "all" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	return types
+}
+
+// ResultLimit returns the requested limit clamped to the allowed range.
+func (q SearchQuery) ResultLimit() int {
+	switch {
+	case q.Limit <= 0:
+		return DefaultSearchLimit
+	case q.Limit > MaxSearchLimit:
+		return MaxSearchLimit
+	default:
+		return q.Limit
+	}
+}
+
+// ExpandQuery asks for a search result with the passages around it.
+type ExpandQuery struct {
+	ID     int64 `json:"id"`
+	Radius int   `json:"radius,omitempty"`
+}
+
+// MemoryFact represents a fact the model asks to save to project memory.
+type MemoryFact struct {
+	Fact   string `json:"fact"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ChapterEdit represents line edits the model proposes for a chapter.
+type ChapterEdit struct {
+	Chapter int               `json:"chapter"`
+	Edits   []TextReplacement `json:"edits"`
+	Reason  string            `json:"reason"`
+}
+
+// TextReplacement replaces the one occurrence of Find with Replace.
+type TextReplacement struct {
+	Find    string `json:"find"`
+	Replace string `json:"replace"`
+}
+
+// Limits on the update_chapter tool.
+const (
+	MaxChapterEdits      = 20
+	MaxChapterEditLength = 4000
+)
+
+// CharacterVoiceQuery represents a request for a character's voice profile.
+type CharacterVoiceQuery struct {
+	Character string `json:"character"`
+}
+
+// TravelQuery represents a request for the travel time between two
+// places.
+type TravelQuery struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ItemMove represents where the model says an object is as of a chapter.
+type ItemMove struct {
+	Item    string `json:"item"`
+	Chapter int    `json:"chapter"`
+	Holder  string `json:"holder,omitempty"`
+	Place   string `json:"place,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Note    string `json:"note,omitempty"`
 }
 
 // ParseToolCall parses a tool call's arguments into the appropriate struct.
@@ -361,6 +647,80 @@ func ParseToolCall(call ToolCall) (interface{}, error) {
 		if err := json.Unmarshal([]byte(call.Function.Arguments), &result); err != nil {
 			return nil, fmt.Errorf("failed to parse search query: %w", err)
 		}
+		if result.ChapterFrom < 0 || result.ChapterTo < 0 {
+			return nil, fmt.Errorf("chapter numbers must be positive")
+		}
+		if result.ChapterTo > 0 && result.ChapterFrom > result.ChapterTo {
+			return nil, fmt.Errorf("chapter_from (%d) is after chapter_to (%d)", result.ChapterFrom, result.ChapterTo)
+		}
+		return result, nil
+
+	case ToolExpandSearchResult:
+		var result ExpandQuery
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse expand query: %w", err)
+		}
+		if result.ID <= 0 {
+			return nil, fmt.Errorf("id must be the positive number from a result's tag")
+		}
+		return result, nil
+
+	case ToolRememberFact:
+		var result MemoryFact
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse memory fact: %w", err)
+		}
+		return result, nil
+
+	case ToolUpdateChapter:
+		var result ChapterEdit
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse chapter edit: %w", err)
+		}
+		if result.Chapter <= 0 {
+			return nil, fmt.Errorf("chapter numbers must be positive")
+		}
+		if len(result.Edits) == 0 || len(result.Edits) > MaxChapterEdits {
+			return nil, fmt.Errorf("a chapter edit needs between 1 and %d edits", MaxChapterEdits)
+		}
+		for i, edit := range result.Edits {
+			if edit.Find == "" {
+				return nil, fmt.Errorf("edit %d has nothing to find", i+1)
+			}
+			if len([]rune(edit.Find)) > MaxChapterEditLength || len([]rune(edit.Replace)) > MaxChapterEditLength {
+				return nil, fmt.Errorf("edit %d is longer than %d characters; split it up", i+1, MaxChapterEditLength)
+			}
+		}
+		return result, nil
+
+	case ToolGetCharacterVoice:
+		var result CharacterVoiceQuery
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse character voice query: %w", err)
+		}
+		return result, nil
+
+	case ToolGetTravelTime:
+		var result TravelQuery
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse travel query: %w", err)
+		}
+		if result.From == "" || result.To == "" {
+			return nil, fmt.Errorf("a travel query needs both from and to")
+		}
+		return result, nil
+
+	case ToolMoveItem:
+		var result ItemMove
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse item move: %w", err)
+		}
+		if result.Chapter <= 0 {
+			return nil, fmt.Errorf("chapter numbers must be positive")
+		}
+		if result.Holder == "" && result.Place == "" && result.Status == "" {
+			return nil, fmt.Errorf("an item move needs a holder, place or status")
+		}
 		return result, nil
 
 	case ToolExtractProjectSetup:
@@ -381,6 +741,17 @@ func ParseToolCall(call ToolCall) (interface{}, error) {
 	}
 }
 
+// ValidateToolArguments checks a tool call's arguments against the schema of
+// the matching predefined tool. Unknown tools are left to ParseToolCall.
+func ValidateToolArguments(call ToolCall) error {
+	for _, tool := range PredefinedTools() {
+		if tool.Function.Name == call.Function.Name {
+			return ValidateJSON(tool.Function.Parameters, call.Function.Arguments)
+		}
+	}
+	return nil
+}
+
 // ValidateContextUpdatePath validates that a context update path is allowed.
 func ValidateContextUpdatePath(fileType, fileName string) error {
 	// Whitelist of allowed file types
@@ -388,6 +759,7 @@ func ValidateContextUpdatePath(fileType, fileName string) error {
 		"character": true,
 		"setting":   true,
 		"plot":      true,
+		"glossary":  true,
 	}
 
 	if !allowedTypes[fileType] {
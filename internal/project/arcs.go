@@ -0,0 +1,332 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultArcGap is how many chapters in a row a character may be missing
+// before the arc report warns about it.
+const DefaultArcGap = 5
+
+// arcSectionNames are the headings of a character file's arc section.
+var arcSectionNames = []string{"Arc", "아크", "변화"}
+
+// ArcPoint is a character's emotional state and goal in one chapter.
+type ArcPoint struct {
+	Chapter int
+	State   string
+	Goal    string
+}
+
+// String formats the point as a line of the arc section.
+func (a ArcPoint) String() string {
+	line := fmt.Sprintf("Chapter %d: %s", a.Chapter, a.State)
+	if a.Goal != "" {
+		line += " — goal: " + a.Goal
+	}
+	return line
+}
+
+// arcLinePattern matches an arc entry such as "- Chapter 3: grieving".
+var arcLinePattern = regexp.MustCompile(`(?i)^[-*]\s*(?:chapter|ch\.?|챕터)?\s*(\d+)\s*(?:장|화)?\s*[:.)]\s*(.*)$`)
+
+// arcGoalPattern finds where the goal starts in an arc entry.
+var arcGoalPattern = regexp.MustCompile(`(?i)(goal|목표)\s*:`)
+
+// ParseArcPoints reads the entries of an arc section, one list item per
+// chapter: "- Chapter 3: grieving — goal: find Jun". Points are sorted by
+// chapter.
+func ParseArcPoints(section string) []ArcPoint {
+	var points []ArcPoint
+	for _, line := range strings.Split(section, "\n") {
+		m := arcLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		chapter, _ := strconv.Atoi(m[1])
+		point := ArcPoint{Chapter: chapter, State: m[2]}
+		if loc := arcGoalPattern.FindStringIndex(point.State); loc != nil {
+			point.Goal = strings.TrimSpace(point.State[loc[1]:])
+			point.State = point.State[:loc[0]]
+		}
+		point.State = strings.TrimRight(strings.TrimSpace(point.State), " —–-;|,")
+		points = append(points, point)
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Chapter < points[j].Chapter })
+	return points
+}
+
+// CharacterArc is a character's recorded states and the chapters they
+// appear in.
+type CharacterArc struct {
+	Name     string
+	FilePath string
+	Points   []ArcPoint
+
+	// Appearances are the chapters that mention the character or link to
+	// them, in order.
+	Appearances []int
+}
+
+// PointAt returns the point recorded for a chapter.
+func (a *CharacterArc) PointAt(chapter int) (ArcPoint, bool) {
+	for _, point := range a.Points {
+		if point.Chapter == chapter {
+			return point, true
+		}
+	}
+	return ArcPoint{}, false
+}
+
+// ArcGap is a run of chapters a character is missing from.
+type ArcGap struct {
+	Name string
+
+	// After is the chapter the character was last seen in before the run,
+	// and From and To are its first and last chapters. Open is set when
+	// the character has not come back since.
+	After, From, To int
+	Chapters        int
+	Open            bool
+}
+
+// ArcReport is every character's arc across the chapters and the long
+// disappearances among them.
+type ArcReport struct {
+	// Chapters are the chapter numbers, in order.
+	Chapters []int
+	Arcs     []CharacterArc
+	Gaps     []ArcGap
+	MinGap   int
+}
+
+// Arc cells: a recorded state, an appearance, and an absence.
+const (
+	arcCellState   = "◆"
+	arcCellAppears = "●"
+	arcCellAbsent  = "·"
+)
+
+// String renders one chart row per character with their recorded states,
+// then the disappearances.
+func (r *ArcReport) String() string {
+	if len(r.Arcs) == 0 {
+		return "No characters yet."
+	}
+
+	var sections []string
+	for i := range r.Arcs {
+		arc := &r.Arcs[i]
+		appears := make(map[int]bool, len(arc.Appearances))
+		for _, n := range arc.Appearances {
+			appears[n] = true
+		}
+
+		var row strings.Builder
+		for _, n := range r.Chapters {
+			_, recorded := arc.PointAt(n)
+			switch {
+			case recorded:
+				row.WriteString(arcCellState)
+			case appears[n]:
+				row.WriteString(arcCellAppears)
+			default:
+				row.WriteString(arcCellAbsent)
+			}
+		}
+
+		lines := []string{fmt.Sprintf("%s (%s)", arc.Name, arc.FilePath)}
+		if len(r.Chapters) > 0 {
+			lines = append(lines, "  |"+row.String()+"|")
+		}
+		for _, point := range arc.Points {
+			lines = append(lines, "  "+point.String())
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(r.Gaps) > 0 {
+		lines := []string{fmt.Sprintf("Missing for %d+ chapters:", r.MinGap)}
+		for _, gap := range r.Gaps {
+			if gap.Open {
+				lines = append(lines, fmt.Sprintf("- %s: not seen since chapter %d (%d chapters)", gap.Name, gap.After, gap.Chapters))
+			} else {
+				lines = append(lines, fmt.Sprintf("- %s: absent from chapter %d to %d (%d chapters)", gap.Name, gap.From, gap.To, gap.Chapters))
+			}
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	sections = append(sections, fmt.Sprintf("%s state recorded  %s appears  %s absent", arcCellState, arcCellAppears, arcCellAbsent))
+	return strings.Join(sections, "\n\n")
+}
+
+// ArcReport reads each character's arc section and finds the chapters they
+// appear in. minGap is how many chapters in a row a character must miss to
+// be reported; zero or less uses DefaultArcGap.
+func (p *Project) ArcReport(minGap int) (*ArcReport, error) {
+	if minGap <= 0 {
+		minGap = DefaultArcGap
+	}
+
+	graph, err := p.LinkGraph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	characters, err := p.LoadCharacters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load characters: %w", err)
+	}
+
+	report := &ArcReport{MinGap: minGap}
+	// seen maps a context file to the chapters referencing it.
+	seen := make(map[string][]int)
+	for _, ch := range chapters {
+		report.Chapters = append(report.Chapters, ch.Number)
+		paths := append(graph.Mentioned(ch.Content), graph.Links[ch.FilePath]...)
+		referenced := make(map[string]bool)
+		for _, path := range paths {
+			if !referenced[path] {
+				referenced[path] = true
+				seen[path] = append(seen[path], ch.Number)
+			}
+		}
+	}
+
+	for _, c := range characters {
+		arc := CharacterArc{
+			Name:        c.Name,
+			FilePath:    c.FilePath,
+			Points:      ParseArcPoints(p.FS.ParseMarkdownSection(c.Description, arcSectionNames...)),
+			Appearances: seen[c.FilePath],
+		}
+		report.Arcs = append(report.Arcs, arc)
+		report.Gaps = append(report.Gaps, arcGaps(arc, report.Chapters, minGap)...)
+	}
+	return report, nil
+}
+
+// arcGaps finds the runs of at least minGap chapters a character misses
+// after first appearing.
+func arcGaps(arc CharacterArc, chapters []int, minGap int) []ArcGap {
+	if len(arc.Appearances) == 0 {
+		return nil
+	}
+	appears := make(map[int]bool, len(arc.Appearances))
+	for _, n := range arc.Appearances {
+		appears[n] = true
+	}
+
+	var gaps []ArcGap
+	last := 0
+	var run []int
+	flush := func(open bool) {
+		if len(run) >= minGap {
+			gaps = append(gaps, ArcGap{Name: arc.Name, After: last, From: run[0], To: run[len(run)-1], Chapters: len(run), Open: open})
+		}
+		run = nil
+	}
+	for _, n := range chapters {
+		switch {
+		case appears[n]:
+			flush(false)
+			last = n
+		case last > 0:
+			run = append(run, n)
+		}
+	}
+	flush(true)
+	return gaps
+}
+
+// SetArcPoint records a character's state and goal for a chapter in the
+// arc section of their file, replacing an earlier entry for the chapter,
+// and returns the character's name.
+func (p *Project) SetArcPoint(name string, point ArcPoint) (string, error) {
+	if point.Chapter <= 0 {
+		return "", fmt.Errorf("invalid chapter: %d", point.Chapter)
+	}
+	point.State = strings.TrimSpace(point.State)
+	point.Goal = strings.TrimSpace(point.Goal)
+	if point.State == "" {
+		return "", fmt.Errorf("describe the character's state")
+	}
+
+	character, err := p.FindCharacter(name)
+	if err != nil {
+		return "", err
+	}
+	content, err := p.FS.ReadMarkdown(character.FilePath)
+	if err != nil {
+		return "", err
+	}
+
+	points := ParseArcPoints(p.FS.ParseMarkdownSection(content, arcSectionNames...))
+	replaced := false
+	for i := range points {
+		if points[i].Chapter == point.Chapter {
+			points[i] = point
+			replaced = true
+		}
+	}
+	if !replaced {
+		points = append(points, point)
+		sort.SliceStable(points, func(i, j int) bool { return points[i].Chapter < points[j].Chapter })
+	}
+
+	lines := make([]string, len(points))
+	for i, pt := range points {
+		lines[i] = "- " + pt.String()
+	}
+	updated := replaceMarkdownSection(content, arcSectionNames, "## Arc", strings.Join(lines, "\n"))
+	if err := p.FS.WriteMarkdown(character.FilePath, updated); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", character.FilePath, err)
+	}
+	return character.Name, nil
+}
+
+// replaceMarkdownSection replaces the body of the first section whose
+// heading is one of names, or appends the body under heading when there is
+// no such section.
+func replaceMarkdownSection(content string, names []string, heading, body string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	start, end, level := -1, len(lines), 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		hashes := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		if hashes == 0 || len(trimmed) <= hashes || trimmed[hashes] != ' ' {
+			continue
+		}
+		if start >= 0 {
+			if hashes <= level {
+				end = i
+				break
+			}
+			continue
+		}
+		title := strings.TrimSpace(trimmed[hashes:])
+		for _, name := range names {
+			if strings.EqualFold(title, name) {
+				start, level = i, hashes
+				break
+			}
+		}
+	}
+
+	if start < 0 {
+		return strings.Join(lines, "\n") + "\n\n" + heading + "\n\n" + body + "\n"
+	}
+	section := []string{lines[start], "", body}
+	if end < len(lines) {
+		section = append(section, "")
+	}
+	out := append(append(append([]string{}, lines[:start]...), section...), lines[end:]...)
+	return strings.Join(out, "\n") + "\n"
+}
@@ -0,0 +1,369 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Beat is one step of a beat sheet. Position is how far into the book, in
+// percent of the total word count, the beat usually lands.
+type Beat struct {
+	Name        string
+	Position    int
+	Description string
+}
+
+// BeatSheet is a genre structure template.
+type BeatSheet struct {
+	Key   string
+	Name  string
+	Beats []Beat
+}
+
+// BeatSheets are the templates /beats apply can add to the plot outline.
+var BeatSheets = []BeatSheet{
+	{Key: "save-the-cat", Name: "Save the Cat", Beats: []Beat{
+		{"Opening Image", 0, "A snapshot of the hero's world and problem before the story changes it."},
+		{"Set-Up", 3, "The hero's life, flaws and what is missing from it."},
+		{"Theme Stated", 5, "Someone hints at the lesson the hero must learn."},
+		{"Catalyst", 10, "The event that upends the hero's world."},
+		{"Debate", 15, "The hero hesitates: should they go?"},
+		{"Break into Two", 20, "The hero chooses to act and enters a new world."},
+		{"B Story", 22, "A new relationship that carries the theme."},
+		{"Fun and Games", 30, "The promise of the premise: the hero explores the new world."},
+		{"Midpoint", 50, "A false victory or false defeat raises the stakes."},
+		{"Bad Guys Close In", 55, "Doubt, jealousy and outside forces push back."},
+		{"All Is Lost", 75, "The lowest point; something or someone is lost."},
+		{"Dark Night of the Soul", 80, "The hero wallows, then finds the lesson."},
+		{"Break into Three", 85, "The hero finds the solution, joining A and B stories."},
+		{"Finale", 90, "The hero applies the lesson and defeats the problem."},
+		{"Final Image", 99, "The opposite of the opening image: proof of change."},
+	}},
+	{Key: "heros-journey", Name: "Hero's Journey", Beats: []Beat{
+		{"Ordinary World", 0, "The hero at home, before the adventure."},
+		{"Call to Adventure", 10, "A challenge or quest appears."},
+		{"Refusal of the Call", 15, "Fear or duty holds the hero back."},
+		{"Meeting the Mentor", 20, "Advice, training or a gift prepares the hero."},
+		{"Crossing the Threshold", 25, "The hero commits and enters the special world."},
+		{"Tests, Allies, Enemies", 35, "The hero learns the rules of the special world."},
+		{"Approach to the Inmost Cave", 45, "Preparation for the central ordeal."},
+		{"Ordeal", 50, "The hero faces death or their greatest fear."},
+		{"Reward", 60, "The hero seizes the sword: knowledge, an object or reconciliation."},
+		{"The Road Back", 75, "The hero recommits to finishing the journey."},
+		{"Resurrection", 90, "A final test where everything is at stake."},
+		{"Return with the Elixir", 98, "The hero comes home changed, bringing something to share."},
+	}},
+	{Key: "romance", Name: "Romance Arc", Beats: []Beat{
+		{"Setup", 0, "Each lead's life and the wound that keeps them from love."},
+		{"Meet Cute", 10, "The leads meet, with sparks or friction."},
+		{"No Way", 15, "Reasons they cannot be together."},
+		{"Adhesion", 20, "Circumstance forces them to stay close."},
+		{"Deepening Desire", 35, "They see past the surface and fall harder."},
+		{"Midpoint of Intimacy", 50, "A first kiss, confession or night together."},
+		{"Retreat", 65, "Old wounds and fears pull them apart."},
+		{"Breakup", 75, "The relationship seems over."},
+		{"Grand Gesture", 90, "One risks everything to show they have changed."},
+		{"Happily Ever After", 98, "The leads together, their wounds healed."},
+	}},
+}
+
+// FindBeatSheet returns the template with the given key.
+func FindBeatSheet(key string) (BeatSheet, bool) {
+	for _, sheet := range BeatSheets {
+		if strings.EqualFold(sheet.Key, key) {
+			return sheet, true
+		}
+	}
+	return BeatSheet{}, false
+}
+
+// beatFrontmatter is the frontmatter of a plot file made from a beat.
+type beatFrontmatter struct {
+	Beat     string `yaml:"beat"`
+	Position int    `yaml:"position"`
+	Done     bool   `yaml:"done"`
+}
+
+// ApplyBeatSheet adds one plot file per beat of the template, numbered so
+// the outline lists them in order, and returns the paths written. Beats
+// whose file already exists are left alone.
+func (p *Project) ApplyBeatSheet(key string) ([]string, error) {
+	sheet, ok := FindBeatSheet(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown beat sheet: %s", key)
+	}
+	if err := p.FS.EnsureDir(filepath.Join("context", "plot")); err != nil {
+		return nil, fmt.Errorf("failed to create plot directory: %w", err)
+	}
+
+	var written []string
+	for i, beat := range sheet.Beats {
+		path := filepath.Join("context", "plot", fmt.Sprintf("%02d-%s.md", i+1, FileSlug(beat.Name)))
+		if p.FS.Exists(path) {
+			continue
+		}
+		frontmatter, err := yaml.Marshal(beatFrontmatter{Beat: sheet.Key, Position: beat.Position})
+		if err != nil {
+			return written, fmt.Errorf("failed to encode %s: %w", beat.Name, err)
+		}
+		content := fmt.Sprintf("---\n%s---\n# %s\n\n%s\n", frontmatter, beat.Name, beat.Description)
+		if err := p.FS.WriteMarkdown(path, content); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// BeatStatus is a beat in the outline and whether it is written.
+type BeatStatus struct {
+	Name     string
+	FilePath string
+	Position int
+	Done     bool
+
+	// Overdue is set when the manuscript has passed the beat's position
+	// in the target word count but the beat is not done.
+	Overdue bool
+
+	// DueWords is the word count the beat usually lands at, or zero
+	// without a target.
+	DueWords int
+}
+
+// BeatProgress is the state of the beats in the outline.
+type BeatProgress struct {
+	Beats       []BeatStatus
+	Words       int
+	TargetWords int
+}
+
+// Done returns how many beats are marked done.
+func (b *BeatProgress) Done() int {
+	done := 0
+	for _, beat := range b.Beats {
+		if beat.Done {
+			done++
+		}
+	}
+	return done
+}
+
+// BeatProgress reads the beats from the plot files and compares them with
+// the word count and writing.target_words.
+func (p *Project) BeatProgress() (*BeatProgress, error) {
+	plots, err := p.LoadPlots()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plots: %w", err)
+	}
+	words, err := p.WordCount()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &BeatProgress{Words: words}
+	if p.Config != nil {
+		progress.TargetWords = p.Config.Writing.TargetWords
+	}
+	for _, plot := range plots {
+		frontmatter, _ := p.FS.ParseMarkdownFrontmatter(plot.Description)
+		var fm beatFrontmatter
+		if err := yaml.Unmarshal([]byte(frontmatter), &fm); err != nil || fm.Beat == "" {
+			continue
+		}
+
+		status := BeatStatus{Name: plot.Title, FilePath: plot.FilePath, Position: fm.Position, Done: fm.Done}
+		if progress.TargetWords > 0 {
+			status.DueWords = progress.TargetWords * fm.Position / 100
+			status.Overdue = !fm.Done && words > status.DueWords
+		}
+		progress.Beats = append(progress.Beats, status)
+	}
+	return progress, nil
+}
+
+// SetBeatDone marks the beat named name (its title or file name) done or
+// not done and returns its title.
+func (p *Project) SetBeatDone(name string, done bool) (string, error) {
+	progress, err := p.BeatProgress()
+	if err != nil {
+		return "", err
+	}
+
+	for _, beat := range progress.Beats {
+		base := strings.TrimSuffix(filepath.Base(beat.FilePath), ".md")
+		if !strings.EqualFold(beat.Name, name) && !strings.EqualFold(base, name) && !strings.EqualFold(FileSlug(beat.Name), FileSlug(name)) {
+			continue
+		}
+
+		content, err := p.FS.ReadMarkdown(beat.FilePath)
+		if err != nil {
+			return "", err
+		}
+		frontmatter, body := p.FS.ParseMarkdownFrontmatter(content)
+		var fields yaml.Node
+		if err := yaml.Unmarshal([]byte(frontmatter), &fields); err != nil {
+			return "", fmt.Errorf("invalid frontmatter in %s: %w", beat.FilePath, err)
+		}
+		setYAMLBool(&fields, "done", done)
+		updated, err := yaml.Marshal(&fields)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode frontmatter: %w", err)
+		}
+		if err := p.FS.WriteMarkdown(beat.FilePath, "---\n"+string(updated)+"---\n"+body+"\n"); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", beat.FilePath, err)
+		}
+		return beat.Name, nil
+	}
+	return "", fmt.Errorf("beat not found: %s", name)
+}
+
+// setYAMLBool sets a boolean field in a parsed YAML mapping, keeping the
+// other fields and their order.
+func setYAMLBool(doc *yaml.Node, key string, value bool) {
+	mapping := doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		mapping = doc.Content[0]
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1].SetString(fmt.Sprint(value))
+			mapping.Content[i+1].Tag = "!!bool"
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(value)})
+}
+
+// OutlineBeat is a point of the plot outline that a scene can be drafted
+// from: a whole plot file, or one point inside it.
+type OutlineBeat struct {
+	// Ref is the beat's place in the outline, "2" for the second plot
+	// file or "2.3" for the third point in it.
+	Ref      string
+	Title    string
+	Text     string
+	FilePath string
+
+	// Previous and Next are the titles of the beats around it, empty at
+	// either end.
+	Previous string
+	Next     string
+}
+
+// outlineItem matches the first line of a top-level list item.
+var outlineItem = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+(.*)$`)
+
+// outlinePoint is a section or list item of a plot file.
+type outlinePoint struct {
+	title string
+	text  string
+}
+
+// outlinePoints splits the body of a plot file into its points: its "##"
+// sections, or its top-level list items when it has no sections.
+func outlinePoints(body string) []outlinePoint {
+	lines := strings.Split(body, "\n")
+	var sections, items []outlinePoint
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "## "):
+			sections = append(sections, outlinePoint{title: strings.TrimSpace(line[3:])})
+		case len(sections) > 0:
+			sections[len(sections)-1].text += line + "\n"
+		}
+	}
+	for i := range sections {
+		sections[i].text = strings.TrimSpace(sections[i].text)
+	}
+	if len(sections) > 0 {
+		return sections
+	}
+
+	for _, line := range lines {
+		if m := outlineItem.FindStringSubmatch(line); m != nil {
+			items = append(items, outlinePoint{title: strings.TrimSpace(m[1]), text: strings.TrimSpace(m[1])})
+			continue
+		}
+		if len(items) == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			// Text after the list ends it.
+			break
+		}
+		items[len(items)-1].text += "\n" + strings.TrimSpace(line)
+	}
+	return items
+}
+
+// FindOutlineBeat returns the outline beat ref names: "N" for the Nth plot
+// file in outline order, or "N.M" for the Mth "##" section, or list item
+// when it has no sections, of that file.
+func (p *Project) FindOutlineBeat(ref string) (*OutlineBeat, error) {
+	fileRef, pointRef, hasPoint := strings.Cut(strings.TrimSpace(ref), ".")
+	file, err := strconv.Atoi(fileRef)
+	if err != nil || file < 1 {
+		return nil, fmt.Errorf("invalid beat %q (use N or N.M, such as 2.3)", ref)
+	}
+	point := 0
+	if hasPoint {
+		if point, err = strconv.Atoi(pointRef); err != nil || point < 1 {
+			return nil, fmt.Errorf("invalid beat %q (use N or N.M, such as 2.3)", ref)
+		}
+	}
+
+	plots, err := p.LoadPlots()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plots: %w", err)
+	}
+	if file > len(plots) {
+		return nil, fmt.Errorf("beat %s not found: the outline has %d plot files", ref, len(plots))
+	}
+	plot := plots[file-1]
+	_, body := p.FS.ParseMarkdownFrontmatter(plot.Description)
+	body = stripTitle(body)
+
+	beat := &OutlineBeat{Ref: strconv.Itoa(file), Title: plot.Title, Text: body, FilePath: plot.FilePath}
+	if !hasPoint {
+		if file > 1 {
+			beat.Previous = plots[file-2].Title
+		}
+		if file < len(plots) {
+			beat.Next = plots[file].Title
+		}
+		return beat, nil
+	}
+
+	points := outlinePoints(body)
+	if point > len(points) {
+		return nil, fmt.Errorf("beat %s not found: %s has %d points", ref, filepath.ToSlash(plot.FilePath), len(points))
+	}
+	beat.Ref = fmt.Sprintf("%d.%d", file, point)
+	beat.Title = points[point-1].title
+	beat.Text = points[point-1].text
+	if point > 1 {
+		beat.Previous = points[point-2].title
+	}
+	if point < len(points) {
+		beat.Next = points[point].title
+	}
+	return beat, nil
+}
+
+// stripTitle removes the "# title" line from the start of a body.
+func stripTitle(body string) string {
+	body = strings.TrimSpace(body)
+	if strings.HasPrefix(body, "# ") {
+		_, rest, _ := strings.Cut(body, "\n")
+		return strings.TrimSpace(rest)
+	}
+	return body
+}
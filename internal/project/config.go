@@ -55,3 +55,13 @@ func SaveProjectConfig(projectPath string, config *types.ProjectConfig) error {
 
 	return nil
 }
+
+// SetQueueContextUpdates turns queueing the AI's context updates for
+// review on or off.
+func (p *Project) SetQueueContextUpdates(on bool) error {
+	if p.Config == nil {
+		return fmt.Errorf("project has no config")
+	}
+	p.Config.QueueContextUpdates = on
+	return SaveProjectConfig(p.path, p.Config)
+}
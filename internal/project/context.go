@@ -0,0 +1,217 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/azyu/dreamteller/internal/storage"
+	"golang.org/x/text/unicode/norm"
+	"gopkg.in/yaml.v3"
+)
+
+// ContextCategories are the context directories edited from the TUI.
+var ContextCategories = []string{"characters", "settings", "plot"}
+
+// maxSlugRunes caps the length of a slug, keeping paths well within the
+// Windows limit.
+const maxSlugRunes = 80
+
+// FileSlug turns a name into a file name: lowercase letters and digits of
+// any script, with runs of anything else replaced by one hyphen. Names are
+// composed to NFC first, so a name typed on macOS gets the same slug as
+// elsewhere, and Windows device names such as "con" get a trailing
+// underscore.
+func FileSlug(name string) string {
+	var sb strings.Builder
+	hyphen := false
+	runes := 0
+	for _, r := range strings.ToLower(norm.NFC.String(strings.TrimSpace(name))) {
+		if runes == maxSlugRunes {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.M, r) || r == '_' {
+			sb.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && sb.Len() > 0 {
+			sb.WriteRune('-')
+			hyphen = true
+		} else {
+			continue
+		}
+		runes++
+	}
+	slug := strings.TrimSuffix(sb.String(), "-")
+	if storage.IsReservedFileName(slug) {
+		slug += "_"
+	}
+	return slug
+}
+
+// UniqueContextFileName returns a slug of name for a new file under
+// context/<category> that does not collide with an existing one, adding
+// -2, -3 and so on as needed. Names without letters or digits use
+// fallback instead.
+func (p *Project) UniqueContextFileName(category, name, fallback string) string {
+	slug := FileSlug(name)
+	if slug == "" {
+		slug = FileSlug(fallback)
+	}
+	candidate := slug
+	for i := 2; p.FS.Exists(filepath.Join("context", category, candidate+".md")); i++ {
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+	return candidate
+}
+
+// AddPlotPoint adds a plot file with the given title and body at the end
+// of the outline and returns its path. When the outline's files are
+// numbered, as /beats apply numbers them, the new file takes the next
+// number so it sorts last.
+func (p *Project) AddPlotPoint(title, body string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", fmt.Errorf("the plot point has no title")
+	}
+	if err := p.FS.EnsureDir(filepath.Join("context", "plot")); err != nil {
+		return "", fmt.Errorf("failed to create plot directory: %w", err)
+	}
+	files, err := p.FS.ListMarkdownFiles(filepath.Join("context", "plot"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list plot files: %w", err)
+	}
+
+	last := 0
+	for _, file := range files {
+		prefix, _, ok := strings.Cut(filepath.Base(file.Path), "-")
+		if n, err := strconv.Atoi(prefix); ok && err == nil && n > last {
+			last = n
+		}
+	}
+	name := title
+	if last > 0 {
+		name = fmt.Sprintf("%02d %s", last+1, title)
+	}
+
+	path := filepath.Join("context", "plot", p.UniqueContextFileName("plot", name, "plot-point")+".md")
+	content := fmt.Sprintf("# %s\n\n%s\n", title, strings.TrimSpace(body))
+	if err := p.FS.WriteMarkdown(path, content); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// ValidateContextFile checks a context file before it is saved: YAML
+// frontmatter, if present, must be closed and parse, and the body must
+// not be empty.
+func (p *Project) ValidateContextFile(content string) error {
+	body := content
+	if first, _, _ := strings.Cut(content, "\n"); strings.TrimSpace(first) == "---" {
+		var frontmatter string
+		frontmatter, body = p.FS.ParseMarkdownFrontmatter(content)
+		if body == content {
+			return fmt.Errorf("frontmatter is not closed: add a line with ---")
+		}
+		var fields map[string]interface{}
+		if err := yaml.Unmarshal([]byte(frontmatter), &fields); err != nil {
+			return fmt.Errorf("invalid frontmatter: %w", err)
+		}
+	}
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("the file is empty")
+	}
+	return nil
+}
+
+// NewContextFilePath returns the path for a new context file named after
+// the title of content. It fails if the title is missing or the file
+// already exists.
+func (p *Project) NewContextFilePath(category, content string) (string, error) {
+	_, body := p.FS.ParseMarkdownFrontmatter(content)
+	title := p.FS.ParseMarkdownTitle(body)
+	slug := FileSlug(title)
+	if slug == "" {
+		return "", fmt.Errorf("add a # Title heading to name the file")
+	}
+
+	path := filepath.Join("context", category, slug+".md")
+	if p.FS.Exists(path) {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	return path, nil
+}
+
+// DeleteContextFile removes a file under context/.
+func (p *Project) DeleteContextFile(path string) error {
+	clean := filepath.Clean(path)
+	if !strings.HasPrefix(filepath.ToSlash(clean), "context/") || !strings.HasSuffix(clean, ".md") {
+		return fmt.Errorf("not a context file: %s", path)
+	}
+	if err := p.FS.Delete(clean); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return nil
+}
+
+// CharacterSheet is a character entered through the character form.
+type CharacterSheet struct {
+	Name  string
+	Role  string
+	Age   string
+	Goals string
+	Flaw  string
+	Voice string
+}
+
+// Markdown renders the sheet as a character file. Fields always appear in
+// the same order; empty ones are left out. The Voice section is the one
+// LoadCharacters reads as the voice profile.
+func (s CharacterSheet) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# " + strings.TrimSpace(s.Name) + "\n")
+
+	var facts []string
+	if role := strings.TrimSpace(s.Role); role != "" {
+		facts = append(facts, "**Role:** "+role)
+	}
+	if age := strings.TrimSpace(s.Age); age != "" {
+		facts = append(facts, "**Age:** "+age)
+	}
+	if len(facts) > 0 {
+		sb.WriteString("\n" + strings.Join(facts, "  \n") + "\n")
+	}
+
+	for _, section := range []struct{ title, body string }{
+		{"Goals", s.Goals},
+		{"Flaw", s.Flaw},
+		{"Voice", s.Voice},
+	} {
+		if body := strings.TrimSpace(section.body); body != "" {
+			sb.WriteString(fmt.Sprintf("\n## %s\n\n%s\n", section.title, body))
+		}
+	}
+	return sb.String()
+}
+
+// CreateCharacter writes a character file for the sheet and returns its
+// path. It fails if a character file of that name already exists.
+func (p *Project) CreateCharacter(sheet CharacterSheet) (string, error) {
+	slug := FileSlug(sheet.Name)
+	if slug == "" {
+		return "", fmt.Errorf("the name needs at least one letter or digit")
+	}
+
+	path := filepath.Join("context", "characters", slug+".md")
+	if p.FS.Exists(path) {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	if err := p.FS.EnsureDir(filepath.Dir(path)); err != nil {
+		return "", fmt.Errorf("failed to create characters directory: %w", err)
+	}
+	if err := p.FS.WriteMarkdown(path, sheet.Markdown()); err != nil {
+		return "", fmt.Errorf("failed to write character: %w", err)
+	}
+	return path, nil
+}
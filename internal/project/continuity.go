@@ -0,0 +1,509 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// memorySubject is the subject of facts remembered with /remember.
+const memorySubject = "Memory"
+
+// maxFactKeyRunes is the longest text before a colon read as a fact's key;
+// longer lines are facts of their own.
+const maxFactKeyRunes = 40
+
+// ContinuityFact is one fact about a character, or a remembered fact. Key
+// is the text before the colon of a "Key: value" line, or the whole line
+// when it has none.
+type ContinuityFact struct {
+	Subject string `json:"subject"`
+	Section string `json:"section,omitempty"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Source  string `json:"source"`
+}
+
+// id identifies the fact across snapshots.
+func (f ContinuityFact) id() string {
+	return strings.ToLower(f.Subject + "\x00" + f.Section + "\x00" + f.Key)
+}
+
+// String formats the fact as "Subject / Key: value".
+func (f ContinuityFact) String() string {
+	s := f.Subject + " / " + f.Key
+	if f.Value != "" {
+		s += ": " + f.Value
+	}
+	return s
+}
+
+// TimelineEntry is one event of the plot outline.
+type TimelineEntry struct {
+	Text   string `json:"text"`
+	Source string `json:"source"`
+}
+
+// ContinuitySnapshot is the state of the character facts and the timeline
+// at one point, saved before a rewrite to compare against afterward.
+type ContinuitySnapshot struct {
+	Name      string           `json:"name"`
+	CreatedAt time.Time        `json:"created_at"`
+	Facts     []ContinuityFact `json:"facts"`
+	Timeline  []TimelineEntry  `json:"timeline"`
+}
+
+// continuityDir returns the directory holding the saved snapshots.
+func (p *Project) continuityDir() string {
+	return filepath.Join(p.path, ".dreamteller", "snapshots")
+}
+
+// CaptureContinuity reads the current character facts, remembered facts
+// and timeline.
+func (p *Project) CaptureContinuity() (*ContinuitySnapshot, error) {
+	snapshot := &ContinuitySnapshot{CreatedAt: time.Now()}
+
+	characters, err := p.LoadCharacters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load characters: %w", err)
+	}
+	for _, c := range characters {
+		snapshot.Facts = append(snapshot.Facts, parseFacts(c.Name, c.FilePath, c.Description)...)
+	}
+	if p.DB != nil {
+		memories, err := p.DB.ListMemories()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load memories: %w", err)
+		}
+		for _, mem := range memories {
+			snapshot.Facts = append(snapshot.Facts, ContinuityFact{Subject: memorySubject, Key: mem.Content, Source: "memory"})
+		}
+	}
+
+	plots, err := p.LoadPlots()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plot: %w", err)
+	}
+	for _, plot := range plots {
+		for _, line := range strings.Split(plot.Description, "\n") {
+			trimmed := strings.TrimSpace(line)
+			text := strings.TrimSpace(strings.TrimLeft(trimmed, "#-*+ "))
+			if text == "" || strings.HasPrefix(trimmed, "# ") || (!strings.HasPrefix(trimmed, "#") && !isListItem(trimmed)) {
+				continue
+			}
+			snapshot.Timeline = append(snapshot.Timeline, TimelineEntry{Text: text, Source: plot.FilePath})
+		}
+	}
+	return snapshot, nil
+}
+
+// isListItem reports whether a trimmed line is a Markdown list item.
+func isListItem(line string) bool {
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ ")
+}
+
+// parseFacts reads the facts of a character sheet: each list item or line
+// of text under its sections, except the voice profile.
+func parseFacts(subject, source, content string) []ContinuityFact {
+	skipped := make(map[string]bool)
+	for _, name := range append(append([]string{}, voiceSectionNames...), sampleLinesSectionNames...) {
+		skipped[strings.ToLower(name)] = true
+	}
+
+	var facts []ContinuityFact
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			if level := len(trimmed) - len(strings.TrimLeft(trimmed, "#")); level > 1 {
+				section = strings.TrimSpace(trimmed[level:])
+			}
+			continue
+		}
+		if skipped[strings.ToLower(section)] {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimLeft(trimmed, "-*+> "))
+		if text == "" {
+			continue
+		}
+
+		fact := ContinuityFact{Subject: subject, Section: section, Key: text, Source: source}
+		if i := strings.IndexAny(text, ":："); i > 0 {
+			key := strings.Trim(strings.TrimSpace(text[:i]), "*_")
+			if key != "" && len([]rune(key)) <= maxFactKeyRunes {
+				fact.Key = key
+				fact.Value = strings.TrimSpace(strings.TrimLeft(text[i:], ":："))
+			}
+		}
+		facts = append(facts, fact)
+	}
+	return facts
+}
+
+// SaveContinuitySnapshot captures the continuity state and saves it under
+// name, or under the current time when name is empty.
+func (p *Project) SaveContinuitySnapshot(name string) (*ContinuitySnapshot, error) {
+	if name == "" {
+		name = time.Now().Format("20060102-150405")
+	}
+	if !isValidName(name) {
+		return nil, ErrInvalidName
+	}
+	snapshot, err := p.CaptureContinuity()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Name = name
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.MkdirAll(p.continuityDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := storage.AtomicWriteFile(filepath.Join(p.continuityDir(), name+".json"), data); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// LoadContinuitySnapshot loads the snapshot saved under name, or the
+// latest one when name is empty.
+func (p *Project) LoadContinuitySnapshot(name string) (*ContinuitySnapshot, error) {
+	if name == "" {
+		snapshots, err := p.ListContinuitySnapshots()
+		if err != nil {
+			return nil, err
+		}
+		if len(snapshots) == 0 {
+			return nil, fmt.Errorf("no continuity snapshots yet")
+		}
+		return snapshots[len(snapshots)-1], nil
+	}
+	if !isValidName(name) {
+		return nil, ErrInvalidName
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.continuityDir(), name+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot ContinuitySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", name, err)
+	}
+	return &snapshot, nil
+}
+
+// ListContinuitySnapshots returns the saved snapshots, oldest first.
+func (p *Project) ListContinuitySnapshots() ([]*ContinuitySnapshot, error) {
+	entries, err := os.ReadDir(p.continuityDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []*ContinuitySnapshot
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		snapshot, err := p.LoadContinuitySnapshot(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
+
+// FactChange is a fact whose value changed since the snapshot.
+type FactChange struct {
+	Before *ContinuityFact
+	After  *ContinuityFact
+}
+
+// StaleReference is a chapter that still refers to a fact as it was before
+// it changed.
+type StaleReference struct {
+	Chapter int
+	Fact    ContinuityFact
+
+	// Term is the old wording found in the chapter.
+	Term string
+}
+
+// ContinuityReport compares the continuity state against a snapshot.
+type ContinuityReport struct {
+	Snapshot *ContinuitySnapshot
+
+	Changed []FactChange
+	Removed []ContinuityFact
+	Added   []ContinuityFact
+
+	// Timeline entries added, removed, or moved relative to the others.
+	TimelineAdded   []TimelineEntry
+	TimelineRemoved []TimelineEntry
+	TimelineMoved   []TimelineEntry
+
+	Stale []StaleReference
+}
+
+// Empty reports whether nothing changed since the snapshot.
+func (r *ContinuityReport) Empty() bool {
+	return len(r.Changed) == 0 && len(r.Removed) == 0 && len(r.Added) == 0 &&
+		len(r.TimelineAdded) == 0 && len(r.TimelineRemoved) == 0 && len(r.TimelineMoved) == 0
+}
+
+// String renders the changes, then the chapters referencing stale facts.
+func (r *ContinuityReport) String() string {
+	header := fmt.Sprintf("Continuity since snapshot %s (%s):", r.Snapshot.Name, r.Snapshot.CreatedAt.Format("2006-01-02 15:04"))
+	if r.Empty() {
+		return header + " no facts or timeline events changed."
+	}
+
+	sections := []string{header}
+	add := func(title string, lines []string) {
+		if len(lines) > 0 {
+			sections = append(sections, title+"\n"+strings.Join(lines, "\n"))
+		}
+	}
+
+	var lines []string
+	for _, c := range r.Changed {
+		lines = append(lines, fmt.Sprintf("- %s / %s: %s → %s", c.After.Subject, c.After.Key, c.Before.Value, c.After.Value))
+	}
+	add("Changed facts:", lines)
+	lines = nil
+	for _, f := range r.Removed {
+		lines = append(lines, "- "+f.String())
+	}
+	add("Removed facts:", lines)
+	lines = nil
+	for _, f := range r.Added {
+		lines = append(lines, "- "+f.String())
+	}
+	add("New facts:", lines)
+
+	lines = nil
+	for _, e := range r.TimelineRemoved {
+		lines = append(lines, "- removed: "+e.Text)
+	}
+	for _, e := range r.TimelineAdded {
+		lines = append(lines, "- added: "+e.Text)
+	}
+	for _, e := range r.TimelineMoved {
+		lines = append(lines, "- moved: "+e.Text)
+	}
+	add("Timeline:", lines)
+
+	lines = nil
+	for _, s := range r.Stale {
+		lines = append(lines, fmt.Sprintf("- Chapter %d: %q (was %s)", s.Chapter, s.Term, s.Fact.String()))
+	}
+	if len(lines) > 0 {
+		add("Chapters referencing stale facts:", lines)
+	} else if len(r.Changed) > 0 || len(r.Removed) > 0 {
+		sections = append(sections, "No chapter refers to the old facts.")
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// CompareContinuity compares the current continuity state against the
+// snapshot saved under name, or the latest one when name is empty, and
+// finds the chapters still referring to changed or removed facts.
+func (p *Project) CompareContinuity(name string) (*ContinuityReport, error) {
+	snapshot, err := p.LoadContinuitySnapshot(name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := p.CaptureContinuity()
+	if err != nil {
+		return nil, err
+	}
+	report := &ContinuityReport{Snapshot: snapshot}
+
+	now := make(map[string]*ContinuityFact, len(current.Facts))
+	for i := range current.Facts {
+		now[current.Facts[i].id()] = &current.Facts[i]
+	}
+	before := make(map[string]bool, len(snapshot.Facts))
+	for i := range snapshot.Facts {
+		old := &snapshot.Facts[i]
+		before[old.id()] = true
+		switch fact, ok := now[old.id()]; {
+		case !ok:
+			report.Removed = append(report.Removed, *old)
+		case fact.Value != old.Value:
+			report.Changed = append(report.Changed, FactChange{Before: old, After: fact})
+		}
+	}
+	for _, fact := range current.Facts {
+		if !before[fact.id()] {
+			report.Added = append(report.Added, fact)
+		}
+	}
+
+	report.TimelineAdded, report.TimelineRemoved, report.TimelineMoved = compareTimelines(snapshot.Timeline, current.Timeline)
+
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	for _, c := range report.Changed {
+		report.Stale = append(report.Stale, staleReferences(chapters, *c.Before, staleTerms(c.Before.Value, c.After.Value))...)
+	}
+	for _, f := range report.Removed {
+		old := f.Value
+		if old == "" {
+			old = f.Key
+		}
+		// A removed fact may have been reworded into a new one.
+		var reworded []string
+		for _, added := range report.Added {
+			if added.Subject == f.Subject {
+				reworded = append(reworded, added.Key, added.Value)
+			}
+		}
+		report.Stale = append(report.Stale, staleReferences(chapters, f, staleTerms(old, strings.Join(reworded, " ")))...)
+	}
+	sort.SliceStable(report.Stale, func(i, j int) bool { return report.Stale[i].Chapter < report.Stale[j].Chapter })
+	return report, nil
+}
+
+// compareTimelines finds the entries added to or removed from the timeline,
+// and those now in a different order relative to the others.
+func compareTimelines(old, now []TimelineEntry) (added, removed, moved []TimelineEntry) {
+	inOld := make(map[string]bool, len(old))
+	for _, e := range old {
+		inOld[e.Text] = true
+	}
+	inNow := make(map[string]bool, len(now))
+	for _, e := range now {
+		inNow[e.Text] = true
+	}
+
+	var oldOrder, nowOrder []TimelineEntry
+	for _, e := range old {
+		if inNow[e.Text] {
+			oldOrder = append(oldOrder, e)
+		} else {
+			removed = append(removed, e)
+		}
+	}
+	for _, e := range now {
+		if inOld[e.Text] {
+			nowOrder = append(nowOrder, e)
+		} else {
+			added = append(added, e)
+		}
+	}
+
+	// Entries off their longest common order with the snapshot moved.
+	kept := longestCommonOrder(oldOrder, nowOrder)
+	for _, e := range nowOrder {
+		if !kept[e.Text] {
+			moved = append(moved, e)
+		}
+	}
+	return added, removed, moved
+}
+
+// longestCommonOrder returns the entries of the longest subsequence the two
+// orders share.
+func longestCommonOrder(a, b []TimelineEntry) map[string]bool {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].Text == b[j].Text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	kept := make(map[string]bool)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].Text == b[j].Text:
+			kept[a[i].Text] = true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return kept
+}
+
+// staleTerms returns the words of an old value that the new value no
+// longer uses.
+func staleTerms(old, now string) []string {
+	current := make(map[string]bool)
+	for _, word := range factWords(now) {
+		current[word] = true
+	}
+	var terms []string
+	for _, word := range factWords(old) {
+		if !current[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// factWords splits a fact into lowercase words of at least two runes.
+func factWords(s string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(word)) >= 2 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// staleReferences finds the chapters that mention a fact's subject and
+// still use one of its stale terms. Remembered facts have no subject to
+// look for.
+func staleReferences(chapters []*types.Chapter, fact ContinuityFact, terms []string) []StaleReference {
+	var refs []StaleReference
+	subject := strings.ToLower(fact.Subject)
+	for _, ch := range chapters {
+		content := strings.ToLower(ch.Content)
+		if fact.Subject != memorySubject && !strings.Contains(content, subject) {
+			continue
+		}
+		for _, term := range terms {
+			if strings.Contains(content, term) {
+				refs = append(refs, StaleReference{Chapter: ch.Number, Fact: fact, Term: term})
+				break
+			}
+		}
+	}
+	return refs
+}
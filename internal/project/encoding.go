@@ -0,0 +1,50 @@
+package project
+
+import (
+	"fmt"
+
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// EncodingIssue is a project file that is not stored as UTF-8 with LF line
+// endings: it has a legacy encoding, a byte order mark or CRLF line endings.
+type EncodingIssue struct {
+	Path   string
+	Format storage.TextFormat
+}
+
+// CheckEncodings lists the project's markdown files that are not stored as
+// UTF-8 with LF line endings. They are read and indexed correctly, but
+// other tools and diffs may see them differently.
+func (p *Project) CheckEncodings() ([]EncodingIssue, error) {
+	files, err := p.FS.ListMarkdownFiles(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	var issues []EncodingIssue
+	for _, f := range files {
+		format, err := p.FS.TextFormat(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !format.Normalized() {
+			issues = append(issues, EncodingIssue{Path: f.Path, Format: format})
+		}
+	}
+	return issues, nil
+}
+
+// NormalizeEncodings rewrites the files CheckEncodings reports as UTF-8
+// without a byte order mark and with LF line endings, and returns them.
+func (p *Project) NormalizeEncodings() ([]EncodingIssue, error) {
+	issues, err := p.CheckEncodings()
+	if err != nil {
+		return nil, err
+	}
+	for i, issue := range issues {
+		if _, err := p.FS.NormalizeFile(issue.Path); err != nil {
+			return issues[:i], fmt.Errorf("failed to normalize %s: %w", issue.Path, err)
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,183 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// EventDateLayout is the format of EventConfig.Start.
+const EventDateLayout = "2006-01-02"
+
+// EventProgress is how far a writing event has come and the pace needed to
+// finish it on time.
+type EventProgress struct {
+	Event types.EventConfig
+	Start time.Time
+
+	// Written is the words added since the event began.
+	Written int
+
+	// Day is today's day of the event, from 1; past the last day once the
+	// event is over.
+	Day int
+
+	// Daily holds the words written on each day of the event up to today.
+	Daily []int
+
+	// DailyTarget is the even pace for the whole event, and RequiredPace
+	// what each remaining day, today included, needs to finish on time.
+	DailyTarget  int
+	RequiredPace int
+
+	// Expected is the words an even pace would have by the end of today.
+	Expected int
+}
+
+// Remaining returns the words still needed to reach the target.
+func (e *EventProgress) Remaining() int {
+	if e.Written >= e.Event.TargetWords {
+		return 0
+	}
+	return e.Event.TargetWords - e.Written
+}
+
+// Over reports whether the event's last day has passed.
+func (e *EventProgress) Over() bool {
+	return e.Day > e.Event.Days
+}
+
+// StartEvent begins a writing event today, counting words from the
+// manuscript's current length, and saves it in the project config.
+func (p *Project) StartEvent(name string, targetWords, days int, now time.Time) (*types.EventConfig, error) {
+	if targetWords <= 0 || days <= 0 {
+		return nil, fmt.Errorf("the target and the number of days must be positive")
+	}
+	if p.Config == nil {
+		return nil, fmt.Errorf("project has no config")
+	}
+	words, err := p.WordCount()
+	if err != nil {
+		return nil, err
+	}
+
+	event := &types.EventConfig{
+		Name:        strings.TrimSpace(name),
+		TargetWords: targetWords,
+		Start:       now.Format(EventDateLayout),
+		Days:        days,
+		StartWords:  words,
+	}
+	if event.Name == "" {
+		event.Name = "Writing event"
+	}
+	p.Config.Event = event
+	if err := SaveProjectConfig(p.path, p.Config); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// StopEvent ends the writing event.
+func (p *Project) StopEvent() error {
+	if p.Config == nil || p.Config.Event == nil {
+		return fmt.Errorf("no writing event in progress")
+	}
+	p.Config.Event = nil
+	return SaveProjectConfig(p.path, p.Config)
+}
+
+// RecordDailyWords stores today's word count for pacing and returns it.
+func (p *Project) RecordDailyWords(now time.Time) (int, error) {
+	words, err := p.WordCount()
+	if err != nil {
+		return 0, err
+	}
+	if p.DB != nil {
+		if err := p.DB.RecordDailyWords(now, words); err != nil {
+			return words, fmt.Errorf("failed to record word count: %w", err)
+		}
+	}
+	return words, nil
+}
+
+// EventProgress records today's word count and measures the writing event
+// against it. It returns nil when no event is in progress.
+func (p *Project) EventProgress(now time.Time) (*EventProgress, error) {
+	if p.Config == nil || p.Config.Event == nil {
+		return nil, nil
+	}
+	event := *p.Config.Event
+	start, err := time.ParseInLocation(EventDateLayout, event.Start, now.Location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid event start %q: %w", event.Start, err)
+	}
+
+	words, err := p.RecordDailyWords(now)
+	if err != nil {
+		return nil, err
+	}
+	var log []storage.DailyWords
+	if p.DB != nil {
+		if log, err = p.DB.ListDailyWords(start); err != nil {
+			return nil, fmt.Errorf("failed to read word counts: %w", err)
+		}
+	}
+	return eventPace(event, start, now, words, log), nil
+}
+
+// eventPace measures an event from the daily word counts recorded since it
+// started. A day without a count wrote nothing.
+func eventPace(event types.EventConfig, start, now time.Time, words int, log []storage.DailyWords) *EventProgress {
+	progress := &EventProgress{
+		Event:       event,
+		Start:       start,
+		Written:     words - event.StartWords,
+		Day:         daysBetween(start, now) + 1,
+		DailyTarget: ceilDiv(event.TargetWords, event.Days),
+	}
+
+	counted := progress.Day
+	if counted > event.Days {
+		counted = event.Days
+	}
+	if counted > 0 {
+		progress.Daily = make([]int, counted)
+	}
+	previous := event.StartWords
+	for _, d := range log {
+		i := daysBetween(start, d.Day)
+		if i >= 0 && i < len(progress.Daily) {
+			progress.Daily[i] = d.Words - previous
+		}
+		previous = d.Words
+	}
+
+	progress.Expected = event.TargetWords * counted / event.Days
+	daysLeft := event.Days - progress.Day + 1
+	switch {
+	case progress.Remaining() == 0:
+	case daysLeft <= 0:
+		progress.RequiredPace = progress.Remaining()
+	default:
+		progress.RequiredPace = ceilDiv(progress.Remaining(), daysLeft)
+	}
+	return progress
+}
+
+// daysBetween returns the number of calendar days from a to b.
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+func ceilDiv(a, b int) int {
+	if b <= 0 {
+		return a
+	}
+	return (a + b - 1) / b
+}
@@ -0,0 +1,216 @@
+package project
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportDir is where exported documents are written, relative to the
+// project root.
+const ExportDir = "exports"
+
+// lintDir holds the results of `dreamteller batch --op lint`.
+var lintDir = filepath.Join("batch", "lint")
+
+// lintLinePattern matches a lint result line:
+// - "quoted text" — problem — suggestion
+var lintLinePattern = regexp.MustCompile(`^[-*]\s*(?:"([^"]*)"|“([^”]*)”)?\s*(?:—|--|-)?\s*(.*)$`)
+
+// Feedback sources besides reviewer personas.
+const (
+	FeedbackLint     = "lint"
+	FeedbackGlossary = "glossary"
+)
+
+// FeedbackItem is one piece of feedback on a chapter: an annotation, a
+// lint finding, a broken rule or a misspelled glossary term.
+type FeedbackItem struct {
+	Chapter    int
+	Source     string
+	Category   string
+	Severity   string
+	Quote      string
+	Comment    string
+	Suggestion string
+}
+
+// Feedback gathers the feedback on a chapter, or on every chapter when
+// chapter is zero: the stored annotations, the batch lint and rules
+// results and the glossary misspellings, in chapter order.
+func (p *Project) Feedback(chapter int) ([]FeedbackItem, error) {
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	if chapter != 0 {
+		if _, err := p.FindChapter(chapter); err != nil {
+			return nil, err
+		}
+	}
+	wanted := func(n int) bool { return chapter == 0 || n == chapter }
+
+	var items []FeedbackItem
+	if p.DB != nil {
+		annotations, err := p.DB.ListAnnotations(chapter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load annotations: %w", err)
+		}
+		for _, a := range annotations {
+			items = append(items, FeedbackItem{Chapter: a.Chapter, Source: a.Source, Category: a.Category,
+				Severity: a.Severity, Quote: a.Quote, Comment: a.Comment, Suggestion: a.Suggestion})
+		}
+	}
+
+	rules, err := p.LoadRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rules: %w", err)
+	}
+
+	numbers := make(map[string]int, len(chapters))
+	for _, ch := range chapters {
+		numbers[ch.FilePath] = ch.Number
+		if !wanted(ch.Number) {
+			continue
+		}
+		lint, err := p.FS.ReadMarkdown(filepath.Join(lintDir, fmt.Sprintf("chapter-%03d.md", ch.Number)))
+		if err == nil {
+			items = append(items, parseLintResult(ch.Number, lint)...)
+		}
+		violations, err := p.FS.ReadMarkdown(filepath.Join(rulesResultDir, fmt.Sprintf("chapter-%03d.md", ch.Number)))
+		if err == nil {
+			items = append(items, parseRulesResult(ch.Number, violations, rules)...)
+		}
+	}
+
+	glossary, err := p.CheckGlossary()
+	if err != nil {
+		return nil, fmt.Errorf("glossary check failed: %w", err)
+	}
+	for _, g := range glossary {
+		n, ok := numbers[g.FilePath]
+		if !ok || !wanted(n) {
+			continue
+		}
+		items = append(items, FeedbackItem{Chapter: n, Source: FeedbackGlossary, Category: "spelling",
+			Quote: g.Word, Comment: fmt.Sprintf("line %d: likely a misspelling of %q", g.Line, g.Term), Suggestion: g.Term})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Chapter < items[j].Chapter })
+	return items, nil
+}
+
+// parseLintResult reads the issue lines of a chapter's lint result.
+func parseLintResult(chapter int, content string) []FeedbackItem {
+	var items []FeedbackItem
+	for _, line := range strings.Split(content, "\n") {
+		m := lintLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		item := FeedbackItem{Chapter: chapter, Source: FeedbackLint, Quote: m[1] + m[2]}
+		parts := strings.SplitN(m[3], " — ", 2)
+		item.Comment = strings.TrimSpace(parts[0])
+		if len(parts) > 1 {
+			item.Suggestion = strings.TrimSpace(parts[1])
+		}
+		if item.Comment == "" && item.Quote == "" {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// FormatFeedbackMarkdown renders feedback as a review document with one
+// section per chapter and a checkbox per item, for an offline editing pass.
+func FormatFeedbackMarkdown(title string, items []FeedbackItem) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\nExported %s: %d item(s).\n", title, time.Now().Format("2006-01-02 15:04"), len(items)))
+	if len(items) == 0 {
+		sb.WriteString("\nNo feedback yet.\n")
+		return sb.String()
+	}
+
+	chapter := -1
+	for _, item := range items {
+		if item.Chapter != chapter {
+			chapter = item.Chapter
+			sb.WriteString(fmt.Sprintf("\n## Chapter %d\n\n", chapter))
+		}
+		label := item.Source
+		if detail := strings.TrimSpace(item.Severity + " " + item.Category); detail != "" {
+			label += ", " + detail
+		}
+		sb.WriteString(fmt.Sprintf("- [ ] **%s**", label))
+		if item.Quote != "" {
+			sb.WriteString(fmt.Sprintf(" “%s”", item.Quote))
+		}
+		sb.WriteString(" — " + item.Comment)
+		if item.Suggestion != "" {
+			sb.WriteString("\n  - Suggestion: " + item.Suggestion)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// FormatFeedbackCSV renders feedback as CSV with a header row.
+func FormatFeedbackCSV(items []FeedbackItem) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"chapter", "source", "category", "severity", "quote", "comment", "suggestion"})
+	for _, item := range items {
+		_ = w.Write([]string{strconv.Itoa(item.Chapter), item.Source, item.Category, item.Severity, item.Quote, item.Comment, item.Suggestion})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ExportFeedback writes the feedback on a chapter, or on the whole book
+// when chapter is zero, to a Markdown or CSV document under ExportDir and
+// returns its path and how many items it holds.
+func (p *Project) ExportFeedback(chapter int, format string) (string, int, error) {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	if format == "" {
+		format = "md"
+	}
+	if format != "md" && format != "csv" {
+		return "", 0, fmt.Errorf("unsupported format: %s (use md or csv)", format)
+	}
+
+	items, err := p.Feedback(chapter)
+	if err != nil {
+		return "", 0, err
+	}
+
+	name, title := "feedback", "Feedback"
+	if p.Config != nil && p.Config.Name != "" {
+		title = "Feedback: " + p.Config.Name
+	}
+	if chapter != 0 {
+		name = fmt.Sprintf("feedback-chapter-%03d", chapter)
+		title += fmt.Sprintf(", chapter %d", chapter)
+	}
+
+	content := FormatFeedbackMarkdown(title, items)
+	if format == "csv" {
+		if content, err = FormatFeedbackCSV(items); err != nil {
+			return "", 0, err
+		}
+	}
+	path := filepath.Join(ExportDir, name+"."+format)
+	if err := p.FS.WriteMarkdown(path, content); err != nil {
+		return "", 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, len(items), nil
+}
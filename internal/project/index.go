@@ -0,0 +1,149 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/token"
+)
+
+// Indexer returns an indexer for the project's search index, chunking as
+// its config says. The token estimate keeps indexing offline; it only
+// sizes chunks.
+func (p *Project) Indexer() *search.Indexer {
+	chunkSize, overlap, chunking := 0, 0.0, ""
+	if p.Config != nil {
+		chunkSize, overlap = p.Config.Context.ChunkSize, p.Config.Context.ChunkOverlap
+		chunking = p.Config.Context.Chunking
+	}
+	return search.NewIndexer(search.NewFTSEngine(p.DB), token.NewEstimateCounter(), chunkSize, overlap,
+		search.WithChunking(chunking), search.WithExclude(p.ExcludeList()))
+}
+
+// ArchiveDir holds context files archived with update_context, kept out of
+// the search index and context.
+const ArchiveDir = "archive"
+
+// ExcludeList returns the files kept out of the search index and context:
+// the archive, the patterns in context.exclude and those in the project's
+// .dreamtellerignore, which is read anew each time so edits to it apply at
+// once.
+func (p *Project) ExcludeList() *search.ExcludeList {
+	patterns := []string{ArchiveDir + "/*"}
+	if p.Config != nil {
+		patterns = append(patterns, p.Config.Context.Exclude...)
+	}
+	if p.FS != nil {
+		if content, err := p.FS.ReadMarkdown(search.IgnoreFileName); err == nil {
+			patterns = append(patterns, search.ParseIgnoreFile(content)...)
+		}
+	}
+	return search.NewExcludeList(patterns...)
+}
+
+// SyncIndex reindexes the files changed outside dreamteller since they
+// were last indexed, such as chapters edited in another editor, and drops
+// the chunks of deleted files, so search never serves an old draft. An
+// index built with other settings is left alone and its differences
+// returned, to be rebuilt with RebuildIndex.
+func (p *Project) SyncIndex() (search.SyncResult, error) {
+	if p.DB == nil || p.FS == nil {
+		return search.SyncResult{}, nil
+	}
+	result, err := p.Indexer().Sync(p.FS, p.DB)
+	if err != nil {
+		return result, fmt.Errorf("failed to sync search index: %w", err)
+	}
+	return result, nil
+}
+
+// RebuildIndex clears the search index and builds it again from every
+// project file with the project's current settings. It returns how many
+// chunks the index holds.
+func (p *Project) RebuildIndex() (int64, error) {
+	if p.DB == nil || p.FS == nil {
+		return 0, fmt.Errorf("project has no search index")
+	}
+	if err := p.Indexer().FullReindexWithDB(p.FS, p.DB); err != nil {
+		return 0, fmt.Errorf("failed to rebuild search index: %w", err)
+	}
+	return search.NewFTSEngine(p.DB).GetChunkCount()
+}
+
+// IndexFormatChanges describes how the settings the search index was built
+// with differ from the project's current ones; see
+// search.Indexer.FormatChanges.
+func (p *Project) IndexFormatChanges() ([]string, error) {
+	if p.DB == nil {
+		return nil, nil
+	}
+	return p.Indexer().FormatChanges(p.DB)
+}
+
+// Pinned returns the research files and directories pinned to context.
+func (p *Project) Pinned() []string {
+	if p == nil || p.Config == nil {
+		return nil
+	}
+	return p.Config.Context.Pinned
+}
+
+// IsPinned reports whether path, or a directory above it, is pinned.
+func (p *Project) IsPinned(path string) bool {
+	path = filepath.ToSlash(filepath.Clean(path))
+	for _, pin := range p.Pinned() {
+		if path == pin || strings.HasPrefix(path, pin+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Pin lets a research file, or every file in a research directory, be
+// added to context automatically.
+func (p *Project) Pin(path string) error {
+	if p.Config == nil {
+		return fmt.Errorf("project has no config")
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	if !search.IsResearch(path) {
+		return fmt.Errorf("only files under %s/ can be pinned; other files are always used", search.ResearchDir)
+	}
+	if !p.FS.Exists(path) {
+		return fmt.Errorf("%s does not exist", path)
+	}
+	if slices.Contains(p.Config.Context.Pinned, path) {
+		return nil
+	}
+	p.Config.Context.Pinned = append(p.Config.Context.Pinned, path)
+	return SaveProjectConfig(p.path, p.Config)
+}
+
+// Unpin undoes Pin.
+func (p *Project) Unpin(path string) error {
+	if p.Config == nil {
+		return fmt.Errorf("project has no config")
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	i := slices.Index(p.Config.Context.Pinned, path)
+	if i < 0 {
+		return fmt.Errorf("%s is not pinned", path)
+	}
+	p.Config.Context.Pinned = slices.Delete(p.Config.Context.Pinned, i, i+1)
+	return SaveProjectConfig(p.path, p.Config)
+}
+
+// AutoContext drops the research results that are not pinned from search
+// results about to be added to context automatically.
+func (p *Project) AutoContext(results []search.FTSSearchResult) []search.FTSSearchResult {
+	kept := make([]search.FTSSearchResult, 0, len(results))
+	for _, r := range results {
+		if r.SourceType != search.SourceTypeResearch || p.IsPinned(r.SourcePath) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
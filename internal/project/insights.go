@@ -0,0 +1,12 @@
+package project
+
+import "fmt"
+
+// SetInsights turns recording usage for insights on or off.
+func (p *Project) SetInsights(on bool) error {
+	if p.Config == nil {
+		return fmt.Errorf("project has no config")
+	}
+	p.Config.Insights = on
+	return SaveProjectConfig(p.path, p.Config)
+}
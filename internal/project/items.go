@@ -0,0 +1,380 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ItemsDir is where item files live, one per significant object.
+const ItemsDir = "items"
+
+// whereaboutsSectionNames are the headings of an item file's whereabouts
+// section.
+var whereaboutsSectionNames = []string{"Whereabouts", "행방"}
+
+// Labels of the fields of a whereabouts entry.
+var (
+	itemHolderLabels = []string{"holder", "held by", "소지자"}
+	itemPlaceLabels  = []string{"place", "at", "장소"}
+	itemStatusLabels = []string{"status", "상태"}
+	itemNoteLabels   = []string{"note", "메모"}
+)
+
+// ItemDestroyed is the status of an object that no longer exists; it may
+// not appear in later chapters.
+const ItemDestroyed = "destroyed"
+
+// ItemSighting is where an object is as of a chapter: who holds it, where
+// it is, and what became of it, if anything.
+type ItemSighting struct {
+	Chapter int
+	Holder  string
+	Place   string
+	Status  string
+	Note    string
+}
+
+// String formats the sighting as a line of the whereabouts section.
+func (s ItemSighting) String() string {
+	var fields []string
+	if s.Holder != "" {
+		fields = append(fields, "holder: [["+s.Holder+"]]")
+	}
+	if s.Place != "" {
+		fields = append(fields, "place: [["+s.Place+"]]")
+	}
+	if s.Status != "" {
+		fields = append(fields, "status: "+s.Status)
+	}
+	if s.Note != "" {
+		fields = append(fields, "note: "+s.Note)
+	}
+	return fmt.Sprintf("Chapter %d: %s", s.Chapter, strings.Join(fields, "; "))
+}
+
+// Describe says where the object is in a few words.
+func (s ItemSighting) Describe() string {
+	var parts []string
+	if s.Holder != "" {
+		parts = append(parts, "held by "+s.Holder)
+	}
+	if s.Place != "" {
+		parts = append(parts, "at "+s.Place)
+	}
+	if s.Status != "" {
+		parts = append(parts, s.Status)
+	}
+	if len(parts) == 0 {
+		return "whereabouts unknown"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Destroyed reports whether the object no longer exists.
+func (s ItemSighting) Destroyed() bool {
+	return strings.EqualFold(s.Status, ItemDestroyed) || s.Status == "파괴됨"
+}
+
+// ParseItemSightings reads the entries of a whereabouts section, one list
+// item per chapter: "- Chapter 3: holder: [[Hana]]; place: [[Harbor]]".
+// Sightings are sorted by chapter.
+func ParseItemSightings(section string) []ItemSighting {
+	var sightings []ItemSighting
+	for _, line := range strings.Split(section, "\n") {
+		m := arcLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		chapter, _ := strconv.Atoi(m[1])
+		sighting := ItemSighting{Chapter: chapter}
+		for _, field := range strings.Split(m[2], ";") {
+			label, value, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			label = strings.TrimSpace(label)
+			value = strings.TrimSpace(value)
+			switch {
+			case containsFold(itemHolderLabels, label):
+				sighting.Holder = stripWikiLink(value)
+			case containsFold(itemPlaceLabels, label):
+				sighting.Place = stripWikiLink(value)
+			case containsFold(itemStatusLabels, label):
+				sighting.Status = value
+			case containsFold(itemNoteLabels, label):
+				sighting.Note = value
+			}
+		}
+		sightings = append(sightings, sighting)
+	}
+	sort.SliceStable(sightings, func(i, j int) bool { return sightings[i].Chapter < sightings[j].Chapter })
+	return sightings
+}
+
+// Item is a significant object and its recorded whereabouts.
+type Item struct {
+	Name      string
+	FilePath  string
+	Sightings []ItemSighting
+
+	// Appearances are the chapters that mention the object or link to it,
+	// in order.
+	Appearances []int
+}
+
+// At returns where the object is as of a chapter: its latest sighting at
+// or before it.
+func (i *Item) At(chapter int) (ItemSighting, bool) {
+	var found ItemSighting
+	ok := false
+	for _, s := range i.Sightings {
+		if s.Chapter > chapter {
+			break
+		}
+		found, ok = s, true
+	}
+	return found, ok
+}
+
+// LastSeen returns the last chapter the object appears in, or 0.
+func (i *Item) LastSeen() int {
+	if len(i.Appearances) == 0 {
+		return 0
+	}
+	return i.Appearances[len(i.Appearances)-1]
+}
+
+// ItemIssue is an object appearing where it cannot be.
+type ItemIssue struct {
+	Item    string
+	Chapter int
+	Message string
+}
+
+// String formats the issue for display.
+func (i ItemIssue) String() string {
+	if i.Chapter > 0 {
+		return fmt.Sprintf("%s, chapter %d: %s", i.Item, i.Chapter, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Item, i.Message)
+}
+
+// ItemReport is every object's whereabouts and the places they turn up
+// that contradict them.
+type ItemReport struct {
+	Items  []Item
+	Issues []ItemIssue
+}
+
+// String lists each object's current whereabouts and the chapter it was
+// last seen in, then the issues.
+func (r *ItemReport) String() string {
+	if len(r.Items) == 0 {
+		return "No items yet."
+	}
+
+	var sb strings.Builder
+	for _, item := range r.Items {
+		sb.WriteString(item.Name)
+		if len(item.Sightings) > 0 {
+			latest := item.Sightings[len(item.Sightings)-1]
+			fmt.Fprintf(&sb, ": %s (since chapter %d)", latest.Describe(), latest.Chapter)
+		} else {
+			sb.WriteString(": whereabouts not recorded")
+		}
+		if last := item.LastSeen(); last > 0 {
+			fmt.Fprintf(&sb, ", last seen in chapter %d", last)
+		}
+		sb.WriteString("\n")
+	}
+	if len(r.Issues) > 0 {
+		sb.WriteString("\nIssues:\n")
+		for _, issue := range r.Issues {
+			sb.WriteString("- " + issue.String() + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// LoadItems reads the item files and their whereabouts sections.
+func (p *Project) LoadItems() ([]Item, error) {
+	files, err := p.listContext(ItemsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, file := range files {
+		content, err := p.FS.ReadMarkdown(file.Path)
+		if err != nil {
+			continue
+		}
+		title := p.FS.ParseMarkdownTitle(content)
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(file.Path), ".md")
+		}
+		items = append(items, Item{
+			Name:      title,
+			FilePath:  file.Path,
+			Sightings: ParseItemSightings(p.FS.ParseMarkdownSection(content, whereaboutsSectionNames...)),
+		})
+	}
+	return items, nil
+}
+
+// FindItem returns the item whose name or file name matches name
+// (case-insensitive).
+func (p *Project) FindItem(name string) (*Item, error) {
+	items, err := p.LoadItems()
+	if err != nil {
+		return nil, err
+	}
+	name = stripWikiLink(name)
+	for i := range items {
+		base := strings.TrimSuffix(filepath.Base(items[i].FilePath), ".md")
+		if strings.EqualFold(items[i].Name, name) || strings.EqualFold(base, name) {
+			return &items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("item not found: %s", name)
+}
+
+// ItemReport finds the chapters each object appears in and checks them
+// against its whereabouts: an object held by someone who is not in the
+// chapter, or seen after it was destroyed, and holders and places that
+// are not in the story bible.
+func (p *Project) ItemReport() (*ItemReport, error) {
+	items, err := p.LoadItems()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load items: %w", err)
+	}
+	graph, err := p.LinkGraph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	world, err := p.World()
+	if err != nil {
+		return nil, err
+	}
+
+	// referenced maps a chapter to the context files it mentions or links.
+	referenced := make(map[int]map[string]bool)
+	for _, ch := range chapters {
+		paths := make(map[string]bool)
+		for _, path := range append(graph.Mentioned(ch.Content), graph.Links[ch.FilePath]...) {
+			paths[path] = true
+		}
+		referenced[ch.Number] = paths
+	}
+
+	report := &ItemReport{}
+	for _, item := range items {
+		for _, ch := range chapters {
+			if referenced[ch.Number][item.FilePath] {
+				item.Appearances = append(item.Appearances, ch.Number)
+			}
+		}
+		report.Items = append(report.Items, item)
+
+		holders := make(map[string]string)
+		for _, s := range item.Sightings {
+			if s.Holder != "" {
+				if _, ok := holders[s.Holder]; !ok {
+					character, err := p.FindCharacter(s.Holder)
+					if err != nil {
+						report.Issues = append(report.Issues, ItemIssue{Item: item.Name, Chapter: s.Chapter, Message: fmt.Sprintf("held by %s, who has no character file", s.Holder)})
+						holders[s.Holder] = ""
+					} else {
+						holders[s.Holder] = character.FilePath
+					}
+				}
+			}
+			if s.Place != "" && world.Find(s.Place) == nil {
+				report.Issues = append(report.Issues, ItemIssue{Item: item.Name, Chapter: s.Chapter, Message: fmt.Sprintf("at %s, which has no setting file", s.Place)})
+			}
+		}
+
+		for _, n := range item.Appearances {
+			s, ok := item.At(n)
+			if !ok {
+				continue
+			}
+			switch {
+			case s.Destroyed() && s.Chapter < n:
+				report.Issues = append(report.Issues, ItemIssue{Item: item.Name, Chapter: n, Message: fmt.Sprintf("appears after it was destroyed in chapter %d", s.Chapter)})
+			case s.Holder != "" && holders[s.Holder] != "" && !referenced[n][holders[s.Holder]]:
+				report.Issues = append(report.Issues, ItemIssue{Item: item.Name, Chapter: n, Message: fmt.Sprintf("appears, but %s, who has held it since chapter %d, does not", s.Holder, s.Chapter)})
+			}
+		}
+	}
+	return report, nil
+}
+
+// itemFieldSeparator matches what may not appear inside a whereabouts
+// field, since it would end the field or the entry.
+var itemFieldSeparator = regexp.MustCompile(`[;\n]`)
+
+// MoveItem records where an object is as of a chapter in the whereabouts
+// section of its file, replacing an earlier entry for the chapter. An
+// object without a file gets one. It returns the file's path.
+func (p *Project) MoveItem(name string, sighting ItemSighting) (string, error) {
+	name = stripWikiLink(name)
+	if name == "" {
+		return "", fmt.Errorf("name the item")
+	}
+	if sighting.Chapter <= 0 {
+		return "", fmt.Errorf("invalid chapter: %d", sighting.Chapter)
+	}
+	for _, field := range []*string{&sighting.Holder, &sighting.Place, &sighting.Status, &sighting.Note} {
+		*field = strings.TrimSpace(itemFieldSeparator.ReplaceAllString(stripWikiLink(*field), ","))
+	}
+	if sighting.Holder == "" && sighting.Place == "" && sighting.Status == "" {
+		return "", fmt.Errorf("say who holds the item, where it is, or what became of it")
+	}
+
+	var path, content string
+	item, err := p.FindItem(name)
+	if err == nil {
+		path = item.FilePath
+		if content, err = p.FS.ReadMarkdown(path); err != nil {
+			return "", err
+		}
+	} else {
+		if err := p.FS.EnsureDir(filepath.Join("context", ItemsDir)); err != nil {
+			return "", fmt.Errorf("failed to create items directory: %w", err)
+		}
+		path = filepath.Join("context", ItemsDir, p.UniqueContextFileName(ItemsDir, name, "item")+".md")
+		content = "# " + name + "\n"
+	}
+
+	sightings := ParseItemSightings(p.FS.ParseMarkdownSection(content, whereaboutsSectionNames...))
+	replaced := false
+	for i := range sightings {
+		if sightings[i].Chapter == sighting.Chapter {
+			sightings[i] = sighting
+			replaced = true
+		}
+	}
+	if !replaced {
+		sightings = append(sightings, sighting)
+		sort.SliceStable(sightings, func(i, j int) bool { return sightings[i].Chapter < sightings[j].Chapter })
+	}
+
+	lines := make([]string, len(sightings))
+	for i, s := range sightings {
+		lines[i] = "- " + s.String()
+	}
+	updated := replaceMarkdownSection(content, whereaboutsSectionNames, "## Whereabouts", strings.Join(lines, "\n"))
+	if err := p.FS.WriteMarkdown(path, updated); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,190 @@
+package project
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// wikiLinkPattern matches a [[Name]] link; [[Name|label]] links to Name.
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|]+)(?:\|[^\[\]]*)?\]\]`)
+
+// ParseWikiLinks returns the names linked from content, in order of first
+// appearance.
+func ParseWikiLinks(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range wikiLinkPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimSpace(match[1])
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// LinkGraph holds the [[links]] between the project's markdown files.
+// Links point at context files, named by title or file name.
+type LinkGraph struct {
+	// Links and Backlinks map a file path to the files it links to and
+	// the files linking to it.
+	Links     map[string][]string
+	Backlinks map[string][]string
+
+	// Unresolved maps a file path to the names it links to that match no
+	// context file.
+	Unresolved map[string][]string
+
+	// names maps a lowercase title or file name to its context file.
+	names map[string]string
+
+	// titles maps a context file to its title.
+	titles map[string]string
+}
+
+// LinkGraph reads the context files and chapters and resolves their links.
+func (p *Project) LinkGraph() (*LinkGraph, error) {
+	g := &LinkGraph{
+		Links:      make(map[string][]string),
+		Backlinks:  make(map[string][]string),
+		Unresolved: make(map[string][]string),
+		names:      make(map[string]string),
+		titles:     make(map[string]string),
+	}
+
+	contextFiles, err := p.FS.ListMarkdownFiles("context")
+	if err != nil {
+		return nil, err
+	}
+	if p.Config != nil && p.Config.Series != "" && p.FS.Exists(SeriesMount) {
+		shared, err := p.FS.ListMarkdownFiles(SeriesMount)
+		if err != nil {
+			return nil, err
+		}
+		contextFiles = append(contextFiles, shared...)
+	}
+	chapterFiles, err := p.FS.ListMarkdownFiles("chapters")
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string]string)
+	for _, file := range contextFiles {
+		content, err := p.FS.ReadMarkdown(file.Path)
+		if err != nil {
+			continue
+		}
+		contents[file.Path] = content
+
+		title := p.FS.ParseMarkdownTitle(content)
+		base := strings.TrimSuffix(filepath.Base(file.Path), ".md")
+		if title == "" {
+			title = base
+		}
+		g.titles[file.Path] = title
+		for _, name := range []string{title, base} {
+			if _, taken := g.names[strings.ToLower(name)]; !taken {
+				g.names[strings.ToLower(name)] = file.Path
+			}
+		}
+	}
+	for _, file := range chapterFiles {
+		if content, err := p.FS.ReadMarkdown(file.Path); err == nil {
+			contents[file.Path] = content
+		}
+	}
+
+	for path, content := range contents {
+		for _, name := range ParseWikiLinks(content) {
+			target, ok := g.Resolve(name)
+			switch {
+			case !ok:
+				g.Unresolved[path] = append(g.Unresolved[path], name)
+			case target != path:
+				g.Links[path] = append(g.Links[path], target)
+				g.Backlinks[target] = append(g.Backlinks[target], path)
+			}
+		}
+	}
+	for _, links := range g.Backlinks {
+		sort.Strings(links)
+	}
+	return g, nil
+}
+
+// Resolve returns the context file a link name points at.
+func (g *LinkGraph) Resolve(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if path, ok := g.names[name]; ok {
+		return path, true
+	}
+	path, ok := g.names[FileSlug(name)]
+	return path, ok
+}
+
+// Title returns the title of a context file, or its file name.
+func (g *LinkGraph) Title(path string) string {
+	if title, ok := g.titles[path]; ok {
+		return title
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".md")
+}
+
+// Neighbors returns the files a file links to, then those linking to it,
+// without duplicates.
+func (g *LinkGraph) Neighbors(path string) []string {
+	var neighbors []string
+	seen := map[string]bool{path: true}
+	for _, list := range [][]string{g.Links[path], g.Backlinks[path]} {
+		for _, other := range list {
+			if !seen[other] {
+				seen[other] = true
+				neighbors = append(neighbors, other)
+			}
+		}
+	}
+	return neighbors
+}
+
+// Mentioned returns the context files whose title appears in text.
+func (g *LinkGraph) Mentioned(text string) []string {
+	text = strings.ToLower(text)
+	var paths []string
+	for path, title := range g.titles {
+		if strings.Contains(text, strings.ToLower(title)) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Distances returns how many links separate each file within maxHops of
+// the seeds, following links in either direction. Seeds are at 0.
+func (g *LinkGraph) Distances(seeds []string, maxHops int) map[string]int {
+	dist := make(map[string]int)
+	frontier := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		if _, ok := dist[seed]; !ok {
+			dist[seed] = 0
+			frontier = append(frontier, seed)
+		}
+	}
+	for hop := 1; hop <= maxHops && len(frontier) > 0; hop++ {
+		var next []string
+		for _, path := range frontier {
+			for _, other := range g.Neighbors(path) {
+				if _, ok := dist[other]; !ok {
+					dist[other] = hop
+					next = append(next, other)
+				}
+			}
+		}
+		frontier = next
+	}
+	return dist
+}
@@ -0,0 +1,611 @@
+package project
+
+import (
+	"container/heap"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Headings of the sections of a setting file that place it in the world.
+var (
+	locationSectionNames = []string{"Location", "위치"}
+	travelSectionNames   = []string{"Travel", "이동"}
+)
+
+// Keys of the location section's entries.
+var (
+	locationKindKeys   = []string{"kind", "type", "종류", "유형"}
+	locationParentKeys = []string{"in", "part of", "within", "소속", "상위"}
+)
+
+// Location is a place in the world, read from a setting file. Locations
+// nest, such as a building in a city on a continent.
+type Location struct {
+	Name     string
+	Kind     string
+	FilePath string
+
+	// Parent is the location this one is in, as written; Children are
+	// the locations in it, by name.
+	Parent   string
+	Children []*Location
+
+	// Routes are the travel times the file notes to other places.
+	Routes []Route
+
+	parent *Location
+}
+
+// Route is a noted travel time from one location to another.
+type Route struct {
+	To string
+
+	// Time is the note as written, such as "2 days by road", and Duration
+	// the time it adds up to.
+	Time     string
+	Duration time.Duration
+
+	from, to *Location
+}
+
+// Path returns the location's ancestors and the location itself, outermost
+// first.
+func (l *Location) Path() []*Location {
+	var path []*Location
+	seen := make(map[*Location]bool)
+	for at := l; at != nil && !seen[at]; at = at.parent {
+		seen[at] = true
+		path = append([]*Location{at}, path...)
+	}
+	return path
+}
+
+// World is the hierarchy of the project's locations and the travel times
+// between them.
+type World struct {
+	// Locations are every location, in file order, and Roots the ones not
+	// in another location, by name.
+	Locations []*Location
+	Roots     []*Location
+
+	// Issues are contradictions in the world: unknown places, loops in
+	// the hierarchy, and travel times that do not add up.
+	Issues []string
+
+	byName map[string]*Location
+}
+
+// World reads the location and travel sections of the setting files.
+func (p *Project) World() (*World, error) {
+	settings, err := p.LoadSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	w := &World{byName: make(map[string]*Location)}
+	for _, s := range settings {
+		loc := &Location{Name: s.Name, FilePath: s.FilePath}
+		for key, value := range parseListFields(p.FS.ParseMarkdownSection(s.Description, locationSectionNames...)) {
+			switch {
+			case containsFold(locationKindKeys, key):
+				loc.Kind = value
+			case containsFold(locationParentKeys, key):
+				loc.Parent = stripWikiLink(value)
+			}
+		}
+		for _, line := range strings.Split(p.FS.ParseMarkdownSection(s.Description, travelSectionNames...), "\n") {
+			to, note, ok := splitListField(line)
+			if !ok {
+				continue
+			}
+			route := Route{To: stripWikiLink(to), Time: note}
+			duration, err := ParseTravelTime(note)
+			if err != nil {
+				w.Issues = append(w.Issues, fmt.Sprintf("%s: route to %s: %v", s.Name, route.To, err))
+				continue
+			}
+			route.Duration = duration
+			loc.Routes = append(loc.Routes, route)
+		}
+		w.Locations = append(w.Locations, loc)
+		w.byName[strings.ToLower(loc.Name)] = loc
+		if base := strings.TrimSuffix(filepath.Base(loc.FilePath), ".md"); base != "" {
+			if _, taken := w.byName[strings.ToLower(base)]; !taken {
+				w.byName[strings.ToLower(base)] = loc
+			}
+		}
+	}
+
+	w.link()
+	w.checkRoutes()
+	return w, nil
+}
+
+// link resolves parents and routes, reporting unknown places and loops.
+func (w *World) link() {
+	for _, loc := range w.Locations {
+		if loc.Parent == "" {
+			continue
+		}
+		parent := w.Find(loc.Parent)
+		switch {
+		case parent == nil:
+			w.Issues = append(w.Issues, fmt.Sprintf("%s is in %s, which is not a setting", loc.Name, loc.Parent))
+		case parent == loc:
+			w.Issues = append(w.Issues, fmt.Sprintf("%s is in itself", loc.Name))
+		default:
+			loc.parent = parent
+		}
+	}
+
+	// A loop in the hierarchy is broken where it closes, so every
+	// location still has a path to a root.
+	for _, loc := range w.Locations {
+		seen := map[*Location]bool{loc: true}
+		for at := loc; at.parent != nil; at = at.parent {
+			if seen[at.parent] {
+				w.Issues = append(w.Issues, fmt.Sprintf("%s and %s are each inside the other", at.Name, at.parent.Name))
+				at.parent = nil
+				break
+			}
+			seen[at.parent] = true
+		}
+	}
+
+	for _, loc := range w.Locations {
+		if loc.parent == nil {
+			w.Roots = append(w.Roots, loc)
+		} else {
+			loc.parent.Children = append(loc.parent.Children, loc)
+		}
+	}
+	sortLocations(w.Roots)
+	for _, loc := range w.Locations {
+		sortLocations(loc.Children)
+	}
+
+	for _, loc := range w.Locations {
+		for i := range loc.Routes {
+			route := &loc.Routes[i]
+			route.from = loc
+			route.to = w.Find(route.To)
+			if route.to == nil {
+				w.Issues = append(w.Issues, fmt.Sprintf("%s notes travel to %s, which is not a setting", loc.Name, route.To))
+			}
+		}
+	}
+}
+
+// checkRoutes reports travel times that contradict each other: a route
+// noted at both ends with different times, and a route slower than going
+// through other places.
+func (w *World) checkRoutes() {
+	type pair struct{ a, b *Location }
+	noted := make(map[pair]*Route)
+	for _, loc := range w.Locations {
+		for i := range loc.Routes {
+			route := &loc.Routes[i]
+			if route.to == nil {
+				continue
+			}
+			back, ok := noted[pair{route.to, loc}]
+			if ok && back.Duration != route.Duration {
+				w.Issues = append(w.Issues, fmt.Sprintf("%s to %s takes %s, but %s to %s takes %s",
+					back.from.Name, loc.Name, back.Time, loc.Name, route.to.Name, route.Time))
+			}
+			noted[pair{loc, route.to}] = route
+		}
+	}
+
+	for _, loc := range w.Locations {
+		for i := range loc.Routes {
+			route := &loc.Routes[i]
+			if route.to == nil || route.to == loc {
+				continue
+			}
+			if legs := w.indirectPath(loc, route.to); legs != nil && total(legs) < route.Duration {
+				w.Issues = append(w.Issues, fmt.Sprintf("%s to %s takes %s, but going by way of %s takes only %s",
+					loc.Name, route.to.Name, route.Time, stops(legs), FormatTravelTime(total(legs))))
+			}
+		}
+	}
+}
+
+// Find returns the location with the given name or file name, or nil.
+func (w *World) Find(name string) *Location {
+	return w.byName[strings.ToLower(stripWikiLink(name))]
+}
+
+// Journey is the quickest way between two locations by the noted routes.
+type Journey struct {
+	From, To *Location
+
+	// Legs are the routes taken, in order. A journey without legs is
+	// between two places in Within, which notes no time between them.
+	Legs     []Route
+	Duration time.Duration
+	Within   *Location
+}
+
+// String describes the journey for the model and the author.
+func (j *Journey) String() string {
+	if len(j.Legs) == 0 {
+		if j.From == j.To {
+			return fmt.Sprintf("%s is where the journey starts.", j.From.Name)
+		}
+		if j.Within == j.From || j.Within == j.To {
+			inner, outer := j.To, j.From
+			if j.Within == j.To {
+				inner, outer = j.From, j.To
+			}
+			return fmt.Sprintf("%s is in %s; no travel time is noted between them.", inner.Name, outer.Name)
+		}
+		return fmt.Sprintf("%s and %s are both in %s; no travel time is noted between them.", j.From.Name, j.To.Name, j.Within.Name)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s to %s: %s", j.From.Name, j.To.Name, FormatTravelTime(j.Duration))
+	if len(j.Legs) > 1 || j.Legs[0].from != j.From || j.Legs[0].to != j.To {
+		sb.WriteString(", by way of:")
+		for _, leg := range j.Legs {
+			fmt.Fprintf(&sb, "\n- %s to %s: %s", leg.from.Name, leg.to.Name, leg.Time)
+		}
+	} else {
+		fmt.Fprintf(&sb, " (%s)", j.Legs[0].Time)
+	}
+	return sb.String()
+}
+
+// Travel finds the quickest way from one location to another. Being in a
+// place means being in everything around it, so the journey may leave
+// from any place around from and arrive at any place around to, short of
+// the places around both.
+func (w *World) Travel(from, to string) (*Journey, error) {
+	start := w.Find(from)
+	if start == nil {
+		return nil, fmt.Errorf("no setting named %q", from)
+	}
+	end := w.Find(to)
+	if end == nil {
+		return nil, fmt.Errorf("no setting named %q", to)
+	}
+	if start == end {
+		return &Journey{From: start, To: end, Within: start}, nil
+	}
+
+	startPath, endPath := start.Path(), end.Path()
+	shared := 0
+	for shared < len(startPath) && shared < len(endPath) && startPath[shared] == endPath[shared] {
+		shared++
+	}
+	if shared == len(startPath) || shared == len(endPath) {
+		// One contains the other.
+		return &Journey{From: start, To: end, Within: startPath[shared-1]}, nil
+	}
+
+	if legs := w.quickest(startPath[shared:], endPath[shared:], nil); legs != nil {
+		return &Journey{From: start, To: end, Legs: legs, Duration: total(legs)}, nil
+	}
+	if shared > 0 {
+		return &Journey{From: start, To: end, Within: startPath[shared-1]}, nil
+	}
+	return nil, fmt.Errorf("no travel time is noted between %s and %s; add a route to the Travel section of one of their setting files", start.Name, end.Name)
+}
+
+// indirectPath returns the quickest legs from one location to another
+// that do not go straight there, or nil.
+func (w *World) indirectPath(from, to *Location) []Route {
+	direct := func(r Route) bool {
+		return (r.from == from && r.to == to) || (r.from == to && r.to == from)
+	}
+	return w.quickest([]*Location{from}, []*Location{to}, direct)
+}
+
+// quickest runs Dijkstra's algorithm over the routes, which go both ways,
+// from any of the sources to the first target reached, leaving out the
+// routes skip reports.
+func (w *World) quickest(sources, targets []*Location, skip func(Route) bool) []Route {
+	adjacent := make(map[*Location][]Route)
+	for _, loc := range w.Locations {
+		for i := range loc.Routes {
+			route := loc.Routes[i]
+			if route.to == nil || (skip != nil && skip(route)) {
+				continue
+			}
+			adjacent[route.from] = append(adjacent[route.from], route)
+			back := route
+			back.To, back.from, back.to = loc.Name, route.to, route.from
+			adjacent[route.to] = append(adjacent[route.to], back)
+		}
+	}
+
+	isTarget := make(map[*Location]bool, len(targets))
+	for _, t := range targets {
+		isTarget[t] = true
+	}
+	dist := make(map[*Location]time.Duration)
+	via := make(map[*Location]Route)
+	queue := &journeyQueue{}
+	for _, s := range sources {
+		dist[s] = 0
+		heap.Push(queue, journeyStop{loc: s})
+	}
+	done := make(map[*Location]bool)
+	for queue.Len() > 0 {
+		stop := heap.Pop(queue).(journeyStop)
+		if done[stop.loc] {
+			continue
+		}
+		done[stop.loc] = true
+		if isTarget[stop.loc] {
+			var legs []Route
+			for at := stop.loc; ; {
+				leg, ok := via[at]
+				if !ok {
+					return legs
+				}
+				legs = append([]Route{leg}, legs...)
+				at = leg.from
+			}
+		}
+		for _, route := range adjacent[stop.loc] {
+			d := stop.dist + route.Duration
+			if old, ok := dist[route.to]; ok && old <= d {
+				continue
+			}
+			dist[route.to] = d
+			via[route.to] = route
+			heap.Push(queue, journeyStop{loc: route.to, dist: d})
+		}
+	}
+	return nil
+}
+
+type journeyStop struct {
+	loc  *Location
+	dist time.Duration
+}
+
+// journeyQueue is a min-heap of stops by distance.
+type journeyQueue []journeyStop
+
+func (q journeyQueue) Len() int            { return len(q) }
+func (q journeyQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q journeyQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *journeyQueue) Push(x interface{}) { *q = append(*q, x.(journeyStop)) }
+func (q *journeyQueue) Pop() interface{} {
+	old := *q
+	stop := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return stop
+}
+
+func total(legs []Route) time.Duration {
+	var d time.Duration
+	for _, leg := range legs {
+		d += leg.Duration
+	}
+	return d
+}
+
+// stops names the places a journey passes through between its ends.
+func stops(legs []Route) string {
+	var names []string
+	for _, leg := range legs[:len(legs)-1] {
+		names = append(names, leg.to.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// String renders the world as a tree of locations with their kinds and
+// routes, then its issues.
+func (w *World) String() string {
+	if len(w.Locations) == 0 {
+		return "No locations yet. Add a Location section to a setting file, such as \"- Kind: city\" and \"- In: Eldoria\"."
+	}
+
+	var sb strings.Builder
+	var walk func(loc *Location, prefix, branch string)
+	walk = func(loc *Location, prefix, branch string) {
+		sb.WriteString(prefix + branch + loc.Name)
+		if loc.Kind != "" {
+			fmt.Fprintf(&sb, " (%s)", loc.Kind)
+		}
+		sb.WriteString("\n")
+
+		childPrefix := prefix
+		switch branch {
+		case "├─ ":
+			childPrefix += "│  "
+		case "└─ ":
+			childPrefix += "   "
+		}
+		for _, route := range loc.Routes {
+			rail := "   "
+			if len(loc.Children) > 0 {
+				rail = "│  "
+			}
+			fmt.Fprintf(&sb, "%s%s→ %s: %s\n", childPrefix, rail, route.To, route.Time)
+		}
+		for i, child := range loc.Children {
+			next := "├─ "
+			if i == len(loc.Children)-1 {
+				next = "└─ "
+			}
+			walk(child, childPrefix, next)
+		}
+	}
+	for _, root := range w.Roots {
+		walk(root, "", "")
+	}
+
+	if len(w.Issues) > 0 {
+		sb.WriteString("\nIssues:\n")
+		for _, issue := range w.Issues {
+			sb.WriteString("- " + issue + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// travelTimeUnits are the units of a travel time and their lengths. A
+// month is taken as 30 days.
+var travelTimeUnits = []struct {
+	pattern string
+	length  time.Duration
+}{
+	{`minutes?|mins?|분`, time.Minute},
+	{`hours?|hrs?|시간`, time.Hour},
+	{`days?|일`, 24 * time.Hour},
+	{`weeks?|wks?|주일?`, 7 * 24 * time.Hour},
+	{`months?|개월|달`, 30 * 24 * time.Hour},
+	{`m`, time.Minute},
+	{`h`, time.Hour},
+	{`d`, 24 * time.Hour},
+	{`w`, 7 * 24 * time.Hour},
+}
+
+// travelTimePattern matches one term of a travel time, such as "2 days",
+// with a group for each unit after the amount.
+var travelTimePattern = func() *regexp.Regexp {
+	var units []string
+	for _, unit := range travelTimeUnits {
+		units = append(units, "("+unit.pattern+")")
+	}
+	return regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(?:` + strings.Join(units, "|") + `)`)
+}()
+
+// ParseTravelTime reads the time in a travel note such as "2 days by road"
+// or "1 day 6 hours on foot". Terms next to each other add up; the rest of
+// the note is ignored.
+func ParseTravelTime(note string) (time.Duration, error) {
+	var d time.Duration
+	last := -1
+	for _, m := range travelTimePattern.FindAllStringSubmatchIndex(note, -1) {
+		if m[1] < len(note) && isASCIILetter(note[m[1]]) {
+			continue
+		}
+		if last >= 0 {
+			between := strings.ToLower(strings.TrimSpace(note[last:m[0]]))
+			if between != "" && between != "," && between != "and" && between != "+" {
+				break
+			}
+		}
+		amount, err := strconv.ParseFloat(note[m[2]:m[3]], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid travel time %q: %w", note, err)
+		}
+		for i, unit := range travelTimeUnits {
+			if m[4+2*i] >= 0 {
+				d += time.Duration(amount * float64(unit.length))
+				break
+			}
+		}
+		last = m[1]
+	}
+	if last < 0 {
+		return 0, fmt.Errorf("no travel time in %q; write it like \"2 days by road\"", note)
+	}
+	return d, nil
+}
+
+// FormatTravelTime renders a travel time in days, hours and minutes.
+func FormatTravelTime(d time.Duration) string {
+	if d <= 0 {
+		return "no time"
+	}
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	var parts []string
+	for _, part := range []struct {
+		n    time.Duration
+		unit string
+	}{{days, "day"}, {hours, "hour"}, {minutes, "minute"}} {
+		switch {
+		case part.n == 1:
+			parts = append(parts, "1 "+part.unit)
+		case part.n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", part.n, part.unit))
+		}
+	}
+	if len(parts) == 0 {
+		return "under a minute"
+	}
+	return strings.Join(parts, " ")
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// parseListFields reads "- Key: value" list items into a map with
+// lowercase keys.
+func parseListFields(section string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(section, "\n") {
+		if key, value, ok := splitListField(line); ok {
+			fields[strings.ToLower(key)] = value
+		}
+	}
+	return fields
+}
+
+// splitListField splits a "- Key: value" list item.
+func splitListField(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "*") {
+		return "", "", false
+	}
+	line = strings.TrimSpace(line[1:])
+	// A colon inside a [[link]] is part of the name.
+	from := 0
+	if strings.HasPrefix(line, "[[") {
+		from = strings.Index(line, "]]") + 1
+	}
+	i := strings.IndexAny(line[from:], ":：")
+	if i < 0 {
+		return "", "", false
+	}
+	i += from
+	_, width := utf8.DecodeRuneInString(line[i:])
+	key := strings.TrimSpace(line[:i])
+	value := strings.TrimSpace(line[i+width:])
+	if key == "" || value == "" {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// stripWikiLink turns "[[Name|label]]" into "Name".
+func stripWikiLink(s string) string {
+	s = strings.TrimSpace(s)
+	if m := wikiLinkPattern.FindStringSubmatch(s); m != nil && strings.TrimSpace(wikiLinkPattern.ReplaceAllString(s, "")) == "" {
+		return strings.TrimSpace(m[1])
+	}
+	return s
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortLocations(locations []*Location) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		return strings.ToLower(locations[i].Name) < strings.ToLower(locations[j].Name)
+	})
+}
@@ -0,0 +1,436 @@
+package project
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReadingWordsPerMinute is the reading speed reading times assume.
+const ReadingWordsPerMinute = 250
+
+// pacingResultDir holds the results of `dreamteller batch --op pacing`.
+var pacingResultDir = filepath.Join("batch", "pacing")
+
+// sceneBreakPattern matches a scene break on a line of its own: ***,
+// * * *, ---, ~~~, # or ⁂.
+var sceneBreakPattern = regexp.MustCompile(`^(?:(?:[*\-_~]\s*){3,}|#|⁂)$`)
+
+// sceneLinePattern matches a scene's line in a pacing result:
+// - Scene 2: reflection — Hana remembers the storm
+var sceneLinePattern = regexp.MustCompile(`(?i)^[-*]\s*scene\s+(\d+)\s*:\s*\**(action|reflection|mixed)\**\s*(?:—|--|-|:)?\s*(.*)$`)
+
+// Scene modes, as the pacing batch classifies them.
+const (
+	SceneAction     = "action"
+	SceneReflection = "reflection"
+	SceneMixed      = "mixed"
+)
+
+// sagShare is the action share below which a run of middle chapters sags.
+const sagShare = 0.3
+
+// SplitScenes splits a chapter at its scene breaks, dropping scenes
+// without prose, such as a title alone before the first break.
+func SplitScenes(content string) []string {
+	var scenes []string
+	var current []string
+	flush := func() {
+		scene := strings.TrimSpace(strings.Join(current, "\n"))
+		if sceneWords(scene) > 0 {
+			scenes = append(scenes, scene)
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if sceneBreakPattern.MatchString(strings.TrimSpace(line)) {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return scenes
+}
+
+// MarkScenes numbers a chapter's scenes with [Scene N] markers, so a model
+// can refer to them.
+func MarkScenes(content string) string {
+	scenes := SplitScenes(content)
+	for i, scene := range scenes {
+		scenes[i] = fmt.Sprintf("[Scene %d]\n\n%s", i+1, scene)
+	}
+	return strings.Join(scenes, "\n\n")
+}
+
+// sceneWords counts the words of a scene's prose, leaving out headings and
+// subplot tags.
+func sceneWords(scene string) int {
+	count := 0
+	for _, line := range strings.Split(stripSubplotTags(scene), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		count += len(strings.Fields(line))
+	}
+	return count
+}
+
+// ReadingMinutes returns how long words take to read, rounded up.
+func ReadingMinutes(words int) int {
+	return (words + ReadingWordsPerMinute - 1) / ReadingWordsPerMinute
+}
+
+// FormatReadingTime formats minutes as "45 min" or "2 h 05 min".
+func FormatReadingTime(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	return fmt.Sprintf("%d h %02d min", minutes/60, minutes%60)
+}
+
+// Scene is one scene of a chapter, with its mode once the pacing batch has
+// classified it.
+type Scene struct {
+	Number int
+	Words  int
+
+	// Mode is SceneAction, SceneReflection or SceneMixed, or empty when
+	// the scene has not been classified, and Note what the model said
+	// happens in it.
+	Mode string
+	Note string
+}
+
+// ChapterPacing is the length and pace of one chapter.
+type ChapterPacing struct {
+	Number int
+	Title  string
+	Words  int
+	Scenes []Scene
+}
+
+// Classified reports whether every scene of the chapter has a mode.
+func (c ChapterPacing) Classified() bool {
+	for _, s := range c.Scenes {
+		if s.Mode == "" {
+			return false
+		}
+	}
+	return len(c.Scenes) > 0
+}
+
+// ActionShare is the share of the chapter's words in action scenes, a mixed
+// scene counting half.
+func (c ChapterPacing) ActionShare() float64 {
+	if c.Words == 0 {
+		return 0
+	}
+	action := 0.0
+	for _, s := range c.Scenes {
+		switch s.Mode {
+		case SceneAction:
+			action += float64(s.Words)
+		case SceneMixed:
+			action += float64(s.Words) / 2
+		}
+	}
+	return action / float64(c.Words)
+}
+
+// PacingSag is a run of middle chapters that are mostly reflection.
+type PacingSag struct {
+	From, To int
+	Share    float64
+}
+
+// PacingReport is the pacing of the book, chapter by chapter.
+type PacingReport struct {
+	Chapters []ChapterPacing
+	Words    int
+	Sags     []PacingSag
+}
+
+// PacingReport splits every chapter into scenes and reads the scene modes
+// of the chapters the pacing batch has classified. A result for a different
+// number of scenes than the chapter has now is ignored.
+func (p *Project) PacingReport() (*PacingReport, error) {
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+
+	report := &PacingReport{}
+	for _, ch := range chapters {
+		c := ChapterPacing{Number: ch.Number, Title: ch.Title}
+		for i, scene := range SplitScenes(ch.Content) {
+			words := sceneWords(scene)
+			c.Scenes = append(c.Scenes, Scene{Number: i + 1, Words: words})
+			c.Words += words
+		}
+		result, err := p.FS.ReadMarkdown(filepath.Join(pacingResultDir, fmt.Sprintf("chapter-%03d.md", ch.Number)))
+		if err == nil {
+			modes := parsePacingResult(result)
+			if len(modes) == len(c.Scenes) {
+				for i := range c.Scenes {
+					c.Scenes[i].Mode, c.Scenes[i].Note = modes[i].Mode, modes[i].Note
+				}
+			}
+		}
+		report.Chapters = append(report.Chapters, c)
+		report.Words += c.Words
+	}
+	report.Sags = report.findSags()
+	return report, nil
+}
+
+// parsePacingResult reads the scene lines of a pacing result in scene
+// order. It returns nil unless the scenes are numbered 1, 2, 3 and so on.
+func parsePacingResult(content string) []Scene {
+	var scenes []Scene
+	for _, line := range strings.Split(content, "\n") {
+		m := sceneLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		if n != len(scenes)+1 {
+			return nil
+		}
+		scenes = append(scenes, Scene{Number: n, Mode: strings.ToLower(m[2]), Note: strings.TrimSpace(m[3])})
+	}
+	return scenes
+}
+
+// findSags finds the runs of at least two classified chapters in the
+// middle third of the book whose action share stays under sagShare.
+func (r *PacingReport) findSags() []PacingSag {
+	n := len(r.Chapters)
+	from, to := n/3, n-n/3
+	var sags []PacingSag
+	var run []ChapterPacing
+	flush := func() {
+		if len(run) >= 2 {
+			words, action := 0, 0.0
+			for _, c := range run {
+				words += c.Words
+				action += c.ActionShare() * float64(c.Words)
+			}
+			sags = append(sags, PacingSag{From: run[0].Number, To: run[len(run)-1].Number, Share: action / float64(words)})
+		}
+		run = nil
+	}
+	for i := from; i < to; i++ {
+		c := r.Chapters[i]
+		if c.Classified() && c.Words > 0 && c.ActionShare() < sagShare {
+			run = append(run, c)
+			continue
+		}
+		flush()
+	}
+	flush()
+	return sags
+}
+
+// Classified reports whether any chapter has been classified.
+func (r *PacingReport) Classified() bool {
+	for _, c := range r.Chapters {
+		if c.Classified() {
+			return true
+		}
+	}
+	return false
+}
+
+// pacingLevels shade a curve cell from nothing to the most.
+var pacingLevels = []rune("▁▂▃▄▅▆▇█")
+
+// pacingLevel picks the shade of value out of max.
+func pacingLevel(value, max float64) rune {
+	if max <= 0 {
+		return pacingLevels[0]
+	}
+	i := int(value / max * float64(len(pacingLevels)-1))
+	if i >= len(pacingLevels) {
+		i = len(pacingLevels) - 1
+	}
+	return pacingLevels[i]
+}
+
+// sceneMark marks a scene's mode in the chapter table.
+func sceneMark(mode string) string {
+	switch mode {
+	case SceneAction:
+		return "A"
+	case SceneReflection:
+		return "R"
+	case SceneMixed:
+		return "M"
+	}
+	return "·"
+}
+
+// String renders the length and action curves across the book, one cell
+// per chapter, then a row per chapter with its scenes, and the sags.
+func (r *PacingReport) String() string {
+	if r.Words == 0 {
+		return "No chapters yet."
+	}
+
+	longest := 0
+	for _, c := range r.Chapters {
+		if c.Words > longest {
+			longest = c.Words
+		}
+	}
+	var length, action strings.Builder
+	for _, c := range r.Chapters {
+		length.WriteRune(pacingLevel(float64(c.Words), float64(longest)))
+		if c.Classified() {
+			action.WriteRune(pacingLevel(c.ActionShare(), 1))
+		} else {
+			action.WriteString("·")
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Pacing across %d chapters (%d words, about %s to read):", len(r.Chapters), r.Words, FormatReadingTime(ReadingMinutes(r.Words))),
+		fmt.Sprintf("  Length |%s|", length.String()),
+		fmt.Sprintf("  Action |%s|", action.String()),
+		"",
+	}
+	for _, c := range r.Chapters {
+		marks := make([]string, len(c.Scenes))
+		for i, s := range c.Scenes {
+			marks[i] = sceneMark(s.Mode)
+		}
+		share := "   -"
+		if c.Classified() {
+			share = fmt.Sprintf("%3.0f%%", c.ActionShare()*100)
+		}
+		lines = append(lines, fmt.Sprintf("  %3d  %6d words  %6s  action %s  %s", c.Number, c.Words,
+			FormatReadingTime(ReadingMinutes(c.Words)), share, strings.Join(marks, " ")))
+	}
+	lines = append(lines, "", "  Scenes: A action, M mixed, R reflection, · not classified")
+	sections := []string{strings.Join(lines, "\n")}
+
+	if !r.Classified() {
+		sections = append(sections, "Run `dreamteller batch --op pacing` to classify scenes as action or reflection.")
+	}
+	if len(r.Sags) > 0 {
+		lines := []string{"Sagging middle:"}
+		for _, sag := range r.Sags {
+			lines = append(lines, fmt.Sprintf("- chapters %d-%d are mostly reflection (%.0f%% action); consider raising the stakes or cutting a quiet scene", sag.From, sag.To, sag.Share*100))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// sceneColors fill the scene bars of the SVG chart by mode.
+var sceneColors = map[string]string{
+	SceneAction:     "#d9534f",
+	SceneMixed:      "#9b6bd1",
+	SceneReflection: "#5b8def",
+	"":              "#bbbbbb",
+}
+
+// SVG renders the pacing curve as a chart: a bar per scene, as tall as the
+// scene is long and colored by its mode, under a line of each chapter's
+// action share, with the sags shaded.
+func (r *PacingReport) SVG() string {
+	const (
+		barWidth = 12
+		gap      = 2
+		height   = 240
+		top      = 30
+		left     = 40
+		bottom   = 40
+	)
+
+	longest, scenes := 0, 0
+	for _, c := range r.Chapters {
+		scenes += len(c.Scenes)
+		for _, s := range c.Scenes {
+			if s.Words > longest {
+				longest = s.Words
+			}
+		}
+	}
+	width := left + scenes*(barWidth+gap) + len(r.Chapters)*gap*3 + 20
+	if width < 320 {
+		width = 320
+	}
+	base := top + height
+
+	var bars, chapters, sags strings.Builder
+	var points []string
+	spans := make(map[int][2]int, len(r.Chapters))
+	x := left
+	for _, c := range r.Chapters {
+		start := x
+		for _, s := range c.Scenes {
+			h := 0
+			if longest > 0 {
+				h = s.Words * height / longest
+			}
+			title := fmt.Sprintf("Chapter %d, scene %d: %d words", c.Number, s.Number, s.Words)
+			if s.Mode != "" {
+				title += ", " + s.Mode
+			}
+			if s.Note != "" {
+				title += " — " + s.Note
+			}
+			fmt.Fprintf(&bars, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>%s</title></rect>`+"\n",
+				x, base-h, barWidth, h, sceneColors[s.Mode], html.EscapeString(title))
+			x += barWidth + gap
+		}
+		spans[c.Number] = [2]int{start, x - gap}
+		mid := (start + x - gap) / 2
+		fmt.Fprintf(&chapters, `<text x="%d" y="%d" font-size="10" text-anchor="middle">%d</text>`+"\n", mid, base+14, c.Number)
+		if c.Classified() {
+			points = append(points, fmt.Sprintf("%d,%d", mid, base-int(c.ActionShare()*height)))
+		}
+		x += gap * 3
+	}
+	for _, sag := range r.Sags {
+		from, to := spans[sag.From][0], spans[sag.To][1]
+		fmt.Fprintf(&sags, `<rect x="%d" y="%d" width="%d" height="%d" fill="#f0ad4e" fill-opacity="0.15"><title>Sag: chapters %d-%d, %.0f%% action</title></rect>`+"\n",
+			from-gap, top, to-from+2*gap, height, sag.From, sag.To, sag.Share*100)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif">`+"\n", width, base+bottom)
+	fmt.Fprintf(&sb, `<text x="%d" y="18" font-size="13">Pacing: %d chapters, %d words, about %s to read</text>`+"\n",
+		left, len(r.Chapters), r.Words, html.EscapeString(FormatReadingTime(ReadingMinutes(r.Words))))
+	sb.WriteString(sags.String())
+	fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#444"/>`+"\n", left-4, base, width-10, base)
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="10" text-anchor="end">100%%</text>`+"\n", left-8, top+4)
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="10" text-anchor="end">0%%</text>`+"\n", left-8, base)
+	sb.WriteString(bars.String())
+	sb.WriteString(chapters.String())
+	if len(points) > 1 {
+		fmt.Fprintf(&sb, `<polyline points="%s" fill="none" stroke="#222" stroke-width="2"/>`+"\n", strings.Join(points, " "))
+	}
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="10">Bars: scene length (red action, purple mixed, blue reflection, gray not classified). Line: action share per chapter.</text>`+"\n",
+		left, base+32)
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// ExportPacingSVG writes the pacing chart to exports/pacing.svg and returns
+// its path relative to the project root.
+func (p *Project) ExportPacingSVG() (string, error) {
+	report, err := p.PacingReport()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(ExportDir, "pacing.svg")
+	if err := p.FS.WriteMarkdown(path, report.SVG()); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/azyu/dreamteller/internal/prose"
 	"github.com/azyu/dreamteller/internal/storage"
 	"github.com/azyu/dreamteller/pkg/types"
 )
@@ -35,6 +36,12 @@ func NewManager(projectsDir string) (*Manager, error) {
 		projectsDir = filepath.Join(home, projectsDir[2:])
 	}
 
+	absDir, err := filepath.Abs(projectsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve projects directory: %w", err)
+	}
+	projectsDir = absDir
+
 	// Ensure projects directory exists
 	if err := os.MkdirAll(projectsDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create projects directory: %w", err)
@@ -52,6 +59,9 @@ type Project struct {
 	FS     *storage.FileSystem
 	DB     *storage.SQLiteDB
 	path   string
+
+	// summaries caches what the essential context lists of each file.
+	summaries summaryCache
 }
 
 // Create creates a new project.
@@ -73,6 +83,7 @@ func (m *Manager) Create(name string, config *types.ProjectConfig) (*Project, er
 		"context/characters",
 		"context/settings",
 		"context/plot",
+		"context/glossary",
 		"chapters",
 	}
 
@@ -119,6 +130,47 @@ func (m *Manager) Open(name string) (*Project, error) {
 		return nil, ErrProjectNotFound
 	}
 
+	return openAt(projectPath)
+}
+
+// OpenPath opens a project located at an arbitrary filesystem path,
+// independent of the configured projects directory.
+func (m *Manager) OpenPath(path string) (*Project, error) {
+	projectPath, err := filepath.Abs(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	// A project directory must contain .dreamteller/config.yaml
+	configPath := filepath.Join(projectPath, ".dreamteller", "config.yaml")
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, ErrProjectNotFound
+	}
+
+	return openAt(projectPath)
+}
+
+// ProjectsDir returns the root directory where projects are stored.
+func (m *Manager) ProjectsDir() string {
+	return m.projectsDir
+}
+
+// IsPathReference reports whether ref refers to a filesystem path rather
+// than a project name (absolute, home-relative, or explicitly relative).
+func IsPathReference(ref string) bool {
+	if filepath.IsAbs(ref) {
+		return true
+	}
+	for _, prefix := range []string{"~/", "./", "../", ".\\", "..\\"} {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// openAt loads a project from the given directory.
+func openAt(projectPath string) (*Project, error) {
 	// Load config
 	config, err := LoadProjectConfig(projectPath)
 	if err != nil {
@@ -133,7 +185,7 @@ func (m *Manager) Open(name string) (*Project, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	return &Project{
+	p := &Project{
 		Info: &types.Project{
 			Name:      config.Name,
 			Path:      projectPath,
@@ -145,7 +197,9 @@ func (m *Manager) Open(name string) (*Project, error) {
 		FS:     fs,
 		DB:     db,
 		path:   projectPath,
-	}, nil
+	}
+	p.mountSeries()
+	return p, nil
 }
 
 // List returns all available projects.
@@ -201,30 +255,27 @@ func (m *Manager) Delete(name string) error {
 	return os.RemoveAll(projectPath)
 }
 
-// isValidName checks if a project name is valid.
+// isValidName checks if a project name is valid: a file name that works
+// on every platform, without spaces or "..". Names in any script are
+// allowed.
 func isValidName(name string) bool {
 	if name == "" || len(name) > 100 {
 		return false
 	}
-
-	// Check for invalid characters
-	invalid := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|", "..", " "}
-	for _, char := range invalid {
-		if strings.Contains(name, char) {
-			return false
-		}
+	if strings.Contains(name, "..") || strings.Contains(name, " ") {
+		return false
 	}
+	return storage.ValidateFileName(name) == nil
+}
 
-	// Check for reserved names
-	reserved := []string{".", "..", "con", "prn", "aux", "nul"}
-	nameLower := strings.ToLower(name)
-	for _, r := range reserved {
-		if nameLower == r {
-			return false
+// expandHome expands a leading ~/ to the user's home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
 		}
 	}
-
-	return true
+	return path
 }
 
 // Path returns the project's filesystem path.
@@ -240,9 +291,10 @@ func (p *Project) Close() error {
 	return nil
 }
 
-// LoadCharacters loads all character files.
+// LoadCharacters loads all character files, including the series' shared
+// ones.
 func (p *Project) LoadCharacters() ([]*types.Character, error) {
-	files, err := p.FS.ListMarkdownFiles("context/characters")
+	files, err := p.listContext("characters")
 	if err != nil {
 		return nil, err
 	}
@@ -262,6 +314,8 @@ func (p *Project) LoadCharacters() ([]*types.Character, error) {
 		characters = append(characters, &types.Character{
 			Name:        title,
 			Description: content,
+			Voice:       p.FS.ParseMarkdownSection(content, voiceSectionNames...),
+			SampleLines: parseSampleLines(p.FS.ParseMarkdownSection(content, sampleLinesSectionNames...)),
 			FilePath:    file.Path,
 		})
 	}
@@ -269,9 +323,47 @@ func (p *Project) LoadCharacters() ([]*types.Character, error) {
 	return characters, nil
 }
 
-// LoadSettings loads all setting files.
+// Section headings that hold a character's voice profile.
+var (
+	voiceSectionNames       = []string{"Voice", "말투", "목소리"}
+	sampleLinesSectionNames = []string{"Sample Lines", "대사 예시", "예시 대사"}
+)
+
+// parseSampleLines returns the list items (or non-empty lines) of a sample
+// lines section.
+func parseSampleLines(section string) []string {
+	var samples []string
+	for _, line := range strings.Split(section, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*> ")
+		if line != "" {
+			samples = append(samples, line)
+		}
+	}
+	return samples
+}
+
+// FindCharacter returns the character whose name or file name matches name
+// (case-insensitive).
+func (p *Project) FindCharacter(name string) (*types.Character, error) {
+	characters, err := p.LoadCharacters()
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	for _, c := range characters {
+		base := strings.TrimSuffix(filepath.Base(c.FilePath), ".md")
+		if strings.EqualFold(c.Name, name) || strings.EqualFold(base, name) {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("character not found: %s", name)
+}
+
+// LoadSettings loads all setting files, including the series' shared ones.
 func (p *Project) LoadSettings() ([]*types.Setting, error) {
-	files, err := p.FS.ListMarkdownFiles("context/settings")
+	files, err := p.listContext("settings")
 	if err != nil {
 		return nil, err
 	}
@@ -298,12 +390,13 @@ func (p *Project) LoadSettings() ([]*types.Setting, error) {
 	return settings, nil
 }
 
-// LoadPlots loads all plot files.
+// LoadPlots loads all plot files that are not excluded.
 func (p *Project) LoadPlots() ([]*types.PlotPoint, error) {
 	files, err := p.FS.ListMarkdownFiles("context/plot")
 	if err != nil {
 		return nil, err
 	}
+	files = p.ExcludeList().Filter(files)
 
 	var plots []*types.PlotPoint
 	for i, file := range files {
@@ -328,6 +421,77 @@ func (p *Project) LoadPlots() ([]*types.PlotPoint, error) {
 	return plots, nil
 }
 
+// LoadGlossary loads glossary entries from context/glossary. Each list item
+// of the form "- Term: definition" (the term may be bold) is one entry.
+func (p *Project) LoadGlossary() ([]*types.GlossaryEntry, error) {
+	files, err := p.listContext("glossary")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*types.GlossaryEntry
+	for _, file := range files {
+		content, err := p.FS.ReadMarkdown(file.Path)
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, "* ") {
+				continue
+			}
+			term, definition, ok := strings.Cut(line[2:], ":")
+			term = strings.TrimSpace(strings.Trim(strings.TrimSpace(term), "*"))
+			if !ok || term == "" {
+				continue
+			}
+			entries = append(entries, &types.GlossaryEntry{
+				Term:       term,
+				Definition: strings.TrimSpace(definition),
+				FilePath:   file.Path,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// GlossaryIssue is a likely misspelling of a glossary term in a chapter.
+type GlossaryIssue struct {
+	prose.Misspelling
+	FilePath string
+}
+
+// CheckGlossary scans all chapters for likely misspellings of glossary terms.
+func (p *Project) CheckGlossary() ([]GlossaryIssue, error) {
+	entries, err := p.LoadGlossary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load glossary: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	terms := make([]string, len(entries))
+	for i, e := range entries {
+		terms[i] = e.Term
+	}
+
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+
+	var issues []GlossaryIssue
+	for _, ch := range chapters {
+		for _, m := range prose.CheckGlossary(ch.Content, terms) {
+			issues = append(issues, GlossaryIssue{Misspelling: m, FilePath: ch.FilePath})
+		}
+	}
+	return issues, nil
+}
+
 // LoadChapters loads all chapter files.
 func (p *Project) LoadChapters() ([]*types.Chapter, error) {
 	files, err := p.FS.ListMarkdownFiles("chapters")
@@ -360,14 +524,91 @@ func (p *Project) LoadChapters() ([]*types.Chapter, error) {
 	return chapters, nil
 }
 
+// FindChapter returns the chapter with the given number.
+func (p *Project) FindChapter(number int) (*types.Chapter, error) {
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	for _, ch := range chapters {
+		if ch.Number == number {
+			return ch, nil
+		}
+	}
+	return nil, fmt.Errorf("chapter %d not found", number)
+}
+
+// WordFrequency counts the built-in and configured crutch words and the
+// most repeated phrases in every chapter.
+func (p *Project) WordFrequency() (prose.FrequencyReport, error) {
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return prose.FrequencyReport{}, fmt.Errorf("failed to load chapters: %w", err)
+	}
+
+	crutch := prose.DefaultCrutchWords
+	if p.Config != nil {
+		crutch = append(append([]string(nil), crutch...), p.Config.Writing.CrutchWords...)
+	}
+	texts := make([]string, len(chapters))
+	for i, ch := range chapters {
+		texts[i] = ch.Content
+	}
+	return prose.AnalyzeFrequency(texts, crutch), nil
+}
+
+// WordCount returns the number of words across all chapters.
+func (p *Project) WordCount() (int, error) {
+	chapters, err := p.LoadChapters()
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to load chapters: %w", err)
+	}
+
+	count := 0
+	for _, ch := range chapters {
+		count += len(strings.Fields(stripSubplotTags(ch.Content)))
+	}
+	return count, nil
+}
+
 // SaveChapter saves a chapter to disk.
 func (p *Project) SaveChapter(chapter *types.Chapter) error {
 	filename := fmt.Sprintf("chapter-%03d.md", chapter.Number)
 	return p.FS.WriteMarkdown(filepath.Join("chapters", filename), chapter.Content)
 }
 
+// AppendToChapter appends content to the chapter with the given number,
+// creating the chapter file if it does not exist yet. It returns the
+// chapter's path relative to the project root.
+func (p *Project) AppendToChapter(number int, content string) (string, error) {
+	chapters, err := p.LoadChapters()
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to load chapters: %w", err)
+	}
+
+	content = strings.TrimSpace(content)
+	for _, ch := range chapters {
+		if ch.Number == number {
+			newContent := strings.TrimRight(ch.Content, "\n") + "\n\n" + content + "\n"
+			if err := p.FS.WriteMarkdown(ch.FilePath, newContent); err != nil {
+				return "", fmt.Errorf("failed to write chapter: %w", err)
+			}
+			return ch.FilePath, nil
+		}
+	}
+
+	path := filepath.Join("chapters", fmt.Sprintf("chapter-%03d.md", number))
+	if err := p.FS.WriteMarkdown(path, content+"\n"); err != nil {
+		return "", fmt.Errorf("failed to write chapter: %w", err)
+	}
+	return path, nil
+}
+
 // CreateContextFile creates a new context file.
 func (p *Project) CreateContextFile(category, filename, content string) error {
+	if err := storage.ValidateFileName(filename); err != nil {
+		return err
+	}
 	path := filepath.Join("context", category, filename)
 	if !strings.HasSuffix(path, ".md") {
 		path += ".md"
@@ -377,6 +618,9 @@ func (p *Project) CreateContextFile(category, filename, content string) error {
 
 // WriteContextContent writes or updates context content based on operation.
 func (p *Project) WriteContextContent(category, filename, content, operation string) error {
+	if err := storage.ValidateFileName(filename); err != nil {
+		return err
+	}
 	path := filepath.Join("context", category, filename)
 	if !strings.HasSuffix(path, ".md") {
 		path += ".md"
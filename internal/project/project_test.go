@@ -1,10 +1,16 @@
 package project
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/internal/token"
 	"github.com/azyu/dreamteller/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -274,6 +280,75 @@ func TestProject(t *testing.T) {
 		assert.Contains(t, foundNames["Villain"].Description, "antagonist")
 	})
 
+	t.Run("excluded files are left out of context and the index", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		charactersDir := filepath.Join(projectPath, "context", "characters")
+		require.NoError(t, os.WriteFile(filepath.Join(charactersDir, "hero.md"), []byte("# Hero\n\nA lighthouse keeper."), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(charactersDir, "cut-rival.md"), []byte("# Rival\n\nA lighthouse thief."), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(projectPath, "scrap"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(projectPath, "scrap", "notes.md"), []byte("Lighthouse ideas."), 0644))
+
+		proj.Config.Context.Exclude = []string{"cut-*.md"}
+		require.NoError(t, os.WriteFile(filepath.Join(projectPath, ".dreamtellerignore"), []byte("# not story\nscrap/\n"), 0644))
+
+		characters, err := proj.LoadCharacters()
+		require.NoError(t, err)
+		require.Len(t, characters, 1)
+		assert.Equal(t, "Hero", characters[0].Name)
+
+		_, err = proj.SyncIndex()
+		require.NoError(t, err)
+		results, err := search.NewFTSEngine(proj.DB).Search("lighthouse", 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, filepath.Join("context", "characters", "hero.md"), results[0].SourcePath)
+	})
+
+	t.Run("research reaches context only once pinned", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		require.NoError(t, os.MkdirAll(filepath.Join(projectPath, "research", "ships"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(projectPath, "research", "ships", "hulls.txt"), []byte("Clinker hulls."), 0644))
+		results := []search.FTSSearchResult{
+			{SourceType: search.SourceTypeCharacter, SourcePath: filepath.Join("context", "characters", "hero.md")},
+			{SourceType: search.SourceTypeResearch, SourcePath: filepath.Join("research", "ships", "hulls.txt")},
+		}
+		assert.Len(t, proj.AutoContext(results), 1)
+
+		require.NoError(t, proj.Pin("research/ships"))
+		assert.Len(t, proj.AutoContext(results), 2)
+		reopened, err := LoadProjectConfig(projectPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"research/ships"}, reopened.Context.Pinned)
+
+		assert.Error(t, proj.Pin("context/characters"), "only research can be pinned")
+		assert.Error(t, proj.Pin("research/missing.txt"))
+
+		require.NoError(t, proj.Unpin("research/ships"))
+		assert.Len(t, proj.AutoContext(results), 1)
+		assert.Error(t, proj.Unpin("research/ships"))
+	})
+
+	t.Run("LoadCharacters parses voice profiles", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		content := "# Mira\n\nHarbor pilot.\n\n## Voice\n\nClipped sentences, sailor slang.\n\n## Sample Lines\n\n- \"Tide's turning.\"\n- \"Hold fast.\"\n"
+		require.NoError(t, os.WriteFile(filepath.Join(projectPath, "context", "characters", "mira.md"), []byte(content), 0644))
+
+		c, err := proj.FindCharacter("mira")
+		require.NoError(t, err)
+		assert.True(t, c.HasVoice())
+		assert.Equal(t, "Clipped sentences, sailor slang.", c.Voice)
+		assert.Equal(t, []string{`"Tide's turning."`, `"Hold fast."`}, c.SampleLines)
+
+		_, err = proj.FindCharacter("Nobody")
+		assert.Error(t, err)
+	})
+
 	t.Run("LoadCharacters returns empty for no files", func(t *testing.T) {
 		proj, _ := setupProject(t)
 		defer proj.Close()
@@ -283,6 +358,48 @@ func TestProject(t *testing.T) {
 		assert.Empty(t, characters)
 	})
 
+	t.Run("LoadGlossary parses term lines", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		content := "# Glossary\n\n- **Aethermoor**: The drowned continent.\n- Vashki: Sky-whale herders.\nNot a term: ignored.\n"
+		require.NoError(t, os.WriteFile(filepath.Join(projectPath, "context", "glossary", "terms.md"), []byte(content), 0644))
+
+		entries, err := proj.LoadGlossary()
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "Aethermoor", entries[0].Term)
+		assert.Equal(t, "The drowned continent.", entries[0].Definition)
+		assert.Equal(t, "Vashki", entries[1].Term)
+	})
+
+	t.Run("LoadGlossary tolerates a missing directory", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		require.NoError(t, os.RemoveAll(filepath.Join(projectPath, "context", "glossary")))
+
+		entries, err := proj.LoadGlossary()
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("CheckGlossary flags misspellings in chapters", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		require.NoError(t, os.WriteFile(filepath.Join(projectPath, "context", "glossary", "terms.md"), []byte("- Aethermoor: The drowned continent.\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(projectPath, "chapters", "001.md"), []byte("# One\n\nThey sailed for Aethermor."), 0644))
+
+		issues, err := proj.CheckGlossary()
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, "Aethermor", issues[0].Word)
+		assert.Equal(t, "Aethermoor", issues[0].Term)
+		assert.Equal(t, 3, issues[0].Line)
+		assert.Equal(t, filepath.Join("chapters", "001.md"), issues[0].FilePath)
+	})
+
 	t.Run("LoadSettings reads markdown files", func(t *testing.T) {
 		proj, projectPath := setupProject(t)
 		defer proj.Close()
@@ -400,6 +517,110 @@ func TestProject(t *testing.T) {
 		assert.Equal(t, chapter.Content, string(data))
 	})
 
+	t.Run("AppendToChapter appends to an existing chapter", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		chapterPath := filepath.Join(projectPath, "chapters", "01-opening.md")
+		require.NoError(t, os.WriteFile(chapterPath, []byte("# Opening\n\nIt began.\n"), 0644))
+
+		path, err := proj.AppendToChapter(1, "The rain came.")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("chapters", "01-opening.md"), path)
+
+		content, err := os.ReadFile(chapterPath)
+		require.NoError(t, err)
+		assert.Equal(t, "# Opening\n\nIt began.\n\nThe rain came.\n", string(content))
+	})
+
+	t.Run("AppendToChapter creates a missing chapter", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		path, err := proj.AppendToChapter(2, "Fresh start.")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("chapters", "chapter-002.md"), path)
+
+		content, err := os.ReadFile(filepath.Join(projectPath, path))
+		require.NoError(t, err)
+		assert.Equal(t, "Fresh start.\n", string(content))
+	})
+
+	t.Run("WordCount sums words across chapters", func(t *testing.T) {
+		proj, _ := setupProject(t)
+		defer proj.Close()
+
+		count, err := proj.WordCount()
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# One\n\nIt began.\n"}))
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "The rain came down.\n"}))
+
+		count, err = proj.WordCount()
+		require.NoError(t, err)
+		assert.Equal(t, 8, count)
+	})
+
+	t.Run("ValidateContextFile checks frontmatter and body", func(t *testing.T) {
+		proj, _ := setupProject(t)
+		defer proj.Close()
+
+		assert.NoError(t, proj.ValidateContextFile("# Hana\n\nThe lead."))
+		assert.NoError(t, proj.ValidateContextFile("---\nrole: lead\n---\n# Hana\n"))
+		assert.ErrorContains(t, proj.ValidateContextFile("---\nrole: lead\n# Hana\n"), "not closed")
+		assert.ErrorContains(t, proj.ValidateContextFile("---\nrole: [lead\n---\n# Hana\n"), "invalid frontmatter")
+		assert.ErrorContains(t, proj.ValidateContextFile("---\nrole: lead\n---\n"), "empty")
+	})
+
+	t.Run("NewContextFilePath names the file after its title", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		path, err := proj.NewContextFilePath("characters", "---\nrole: lead\n---\n# Captain Mora\n")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("context", "characters", "captain-mora.md"), path)
+
+		_, err = proj.NewContextFilePath("characters", "No title here.")
+		assert.Error(t, err)
+
+		require.NoError(t, os.WriteFile(filepath.Join(projectPath, path), []byte("# Captain Mora\n"), 0644))
+		_, err = proj.NewContextFilePath("characters", "# Captain Mora\n")
+		assert.ErrorContains(t, err, "already exists")
+	})
+
+	t.Run("DeleteContextFile only removes context files", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		require.NoError(t, proj.CreateContextFile("settings", "port", "# Port\n"))
+		require.NoError(t, proj.DeleteContextFile(filepath.Join("context", "settings", "port.md")))
+		assert.NoFileExists(t, filepath.Join(projectPath, "context", "settings", "port.md"))
+
+		assert.Error(t, proj.DeleteContextFile(filepath.Join("context", "..", "project.yaml")))
+	})
+
+	t.Run("CreateCharacter writes a structured character file", func(t *testing.T) {
+		proj, projectPath := setupProject(t)
+		defer proj.Close()
+
+		sheet := CharacterSheet{Name: "Captain Mora", Role: "smuggler", Goals: "Pay off the ship.", Voice: "Clipped, nautical slang."}
+		path, err := proj.CreateCharacter(sheet)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("context", "characters", "captain-mora.md"), path)
+
+		data, err := os.ReadFile(filepath.Join(projectPath, path))
+		require.NoError(t, err)
+		assert.Equal(t, "# Captain Mora\n\n**Role:** smuggler\n\n## Goals\n\nPay off the ship.\n\n## Voice\n\nClipped, nautical slang.\n", string(data))
+
+		character, err := proj.FindCharacter("Captain Mora")
+		require.NoError(t, err)
+		assert.Equal(t, "Clipped, nautical slang.", character.Voice)
+
+		_, err = proj.CreateCharacter(sheet)
+		assert.ErrorContains(t, err, "already exists")
+	})
+
 	t.Run("CreateContextFile creates file", func(t *testing.T) {
 		proj, projectPath := setupProject(t)
 		defer proj.Close()
@@ -574,6 +795,8 @@ func TestIsValidName(t *testing.T) {
 			"a",
 			"novel-with-dashes",
 			"novel_with_underscores",
+			"나의-소설",
+			"夢の話",
 		}
 
 		for _, name := range validNames {
@@ -598,6 +821,8 @@ func TestIsValidName(t *testing.T) {
 			"name|pipe",
 			"name..dots",
 			"name with space",
+			"name\ttab",
+			"trailing-dot.",
 		}
 
 		for _, name := range invalidChars {
@@ -618,6 +843,9 @@ func TestIsValidName(t *testing.T) {
 			"AUX",
 			"nul",
 			"NUL",
+			"com1",
+			"LPT9",
+			"con.novel",
 		}
 
 		for _, name := range reserved {
@@ -695,3 +923,1092 @@ func TestProjectClose(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+// TestOpenPath tests opening projects by filesystem path.
+func TestOpenPath(t *testing.T) {
+	t.Run("opens project outside projects directory", func(t *testing.T) {
+		workspace := t.TempDir()
+		other, err := NewManager(workspace)
+		require.NoError(t, err)
+
+		config := types.DefaultProjectConfig("Elsewhere", "mystery")
+		created, err := other.Create("elsewhere", config)
+		require.NoError(t, err)
+		require.NoError(t, created.Close())
+
+		manager, err := NewManager(t.TempDir())
+		require.NoError(t, err)
+
+		proj, err := manager.OpenPath(filepath.Join(workspace, "elsewhere"))
+		require.NoError(t, err)
+		defer proj.Close()
+
+		assert.Equal(t, "Elsewhere", proj.Info.Name)
+		assert.Equal(t, filepath.Join(workspace, "elsewhere"), proj.Path())
+	})
+
+	t.Run("returns ErrProjectNotFound for non-project directory", func(t *testing.T) {
+		manager, err := NewManager(t.TempDir())
+		require.NoError(t, err)
+
+		proj, err := manager.OpenPath(t.TempDir())
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+		assert.Nil(t, proj)
+	})
+}
+
+// TestIsPathReference tests distinguishing project names from paths.
+func TestIsPathReference(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected bool
+	}{
+		{"my-novel", false},
+		{"novel_2", false},
+		{"~/novels/my-novel", true},
+		{"./my-novel", true},
+		{"../my-novel", true},
+		{filepath.Join(os.TempDir(), "my-novel"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsPathReference(tt.ref))
+		})
+	}
+}
+
+func TestFileSlug(t *testing.T) {
+	assert.Equal(t, "captain-mora", FileSlug("Captain  Mora!"))
+	assert.Equal(t, "서울-밤거리", FileSlug("서울 밤거리"))
+	assert.Equal(t, "", FileSlug("!!"))
+	assert.Equal(t, "하나", FileSlug("\u1112\u1161\u1102\u1161"), "decomposed Hangul is composed")
+	assert.Equal(t, "con_", FileSlug("Con"))
+	assert.Equal(t, "com1_", FileSlug("COM1"))
+	assert.Len(t, []rune(FileSlug(strings.Repeat("이름", 100))), maxSlugRunes)
+}
+
+func TestUniqueContextFileName(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("slugs", types.DefaultProjectConfig("Slugs", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	assert.Equal(t, "미라", proj.UniqueContextFileName("characters", "미라", "character"))
+	require.NoError(t, proj.CreateContextFile("characters", "미라", "# 미라\n"))
+	assert.Equal(t, "미라-2", proj.UniqueContextFileName("characters", "미라!", "character"))
+	require.NoError(t, proj.CreateContextFile("characters", "미라-2", "# 미라\n"))
+	assert.Equal(t, "미라-3", proj.UniqueContextFileName("characters", "미라", "character"))
+	assert.Equal(t, "character", proj.UniqueContextFileName("characters", "???", "character"))
+
+	assert.Error(t, proj.CreateContextFile("characters", "aux", "# Aux\n"))
+	assert.Error(t, proj.WriteContextContent("notes", "../escape", "x", "create"))
+}
+
+func TestParseWikiLinks(t *testing.T) {
+	links := ParseWikiLinks("[[Hana]] met [[Jun|her brother]] in [[Seoul]]. [[hana]] smiled. [[ ]]")
+	assert.Equal(t, []string{"Hana", "Jun", "Seoul"}, links)
+}
+
+func TestLinkGraph(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("linked", types.DefaultProjectConfig("Linked", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.CreateContextFile("characters", "hana", "# Hana\n\nLives in [[Seoul]] with [[Jun]]. Owes [[Nobody]]."))
+	require.NoError(t, proj.CreateContextFile("characters", "jun", "# Jun\n\nHana's brother."))
+	require.NoError(t, proj.CreateContextFile("settings", "seoul", "# Seoul\n\nRain and neon."))
+	require.NoError(t, proj.CreateContextFile("settings", "harbor", "# Harbor\n\nNear [[seoul]]."))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# One\n\n[[Hana]] wakes."}))
+
+	g, err := proj.LinkGraph()
+	require.NoError(t, err)
+
+	hana := filepath.Join("context", "characters", "hana.md")
+	jun := filepath.Join("context", "characters", "jun.md")
+	seoul := filepath.Join("context", "settings", "seoul.md")
+	harbor := filepath.Join("context", "settings", "harbor.md")
+	chapter := filepath.Join("chapters", "chapter-001.md")
+
+	assert.Equal(t, []string{seoul, jun}, g.Links[hana])
+	assert.Equal(t, []string{hana, harbor}, g.Backlinks[seoul])
+	assert.Equal(t, []string{"Nobody"}, g.Unresolved[hana])
+	assert.Equal(t, []string{chapter}, g.Backlinks[hana])
+	assert.Equal(t, "Seoul", g.Title(seoul))
+
+	assert.Equal(t, []string{seoul, jun, chapter}, g.Neighbors(hana))
+	assert.Equal(t, []string{jun}, g.Mentioned("What does Jun want?"))
+
+	dist := g.Distances([]string{jun}, 2)
+	assert.Equal(t, 0, dist[jun])
+	assert.Equal(t, 1, dist[hana])
+	assert.Equal(t, 2, dist[seoul])
+	_, ok := dist[harbor]
+	assert.False(t, ok, "harbor is three links away")
+}
+
+func TestBibleReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("bible", types.DefaultProjectConfig("Bible", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.CreateContextFile("characters", "hana", "# Hana\n\nThe lead."))
+	require.NoError(t, proj.CreateContextFile("characters", "jun", "# Jun\n\nHer brother."))
+	require.NoError(t, proj.CreateContextFile("characters", "mira", "# Mira\n\nNever shows up."))
+	require.NoError(t, proj.CreateContextFile("settings", "seoul", "# Seoul\n\nRain and neon."))
+	require.NoError(t, proj.CreateContextFile("settings", "harbor", "---\nera: modern\n---\n# Harbor\n"))
+	for i, content := range []string{
+		"# One\n\nHana meets Jun in Seoul.",
+		"# Two\n\n[[Hana]] waits at the harbor.",
+		"# Three\n\nHana alone.",
+	} {
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: i + 1, Content: content}))
+	}
+
+	t.Run("lists unreferenced, absent and empty entries", func(t *testing.T) {
+		report, err := proj.BibleReport(2)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{filepath.Join("context", "characters", "mira.md")}, report.Unreferenced)
+		require.Len(t, report.Absent, 1)
+		assert.Equal(t, AbsentCharacter{Name: "Jun", FilePath: filepath.Join("context", "characters", "jun.md"), LastChapter: 1}, report.Absent[0])
+		assert.Equal(t, []string{filepath.Join("context", "settings", "harbor.md")}, report.EmptySettings)
+		assert.Contains(t, report.String(), "Jun (context/characters/jun.md), last seen in chapter 1")
+	})
+
+	t.Run("nobody is absent while the window covers every chapter", func(t *testing.T) {
+		report, err := proj.BibleReport(0)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultRecentChapters, report.Recent)
+		assert.Empty(t, report.Absent)
+	})
+}
+
+func TestBeatSheets(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	cfg := types.DefaultProjectConfig("Beats", "fantasy")
+	cfg.Writing.TargetWords = 100
+	proj, err := manager.Create("beats", cfg)
+	require.NoError(t, err)
+	defer proj.Close()
+
+	for _, sheet := range BeatSheets {
+		for i := 1; i < len(sheet.Beats); i++ {
+			assert.LessOrEqual(t, sheet.Beats[i-1].Position, sheet.Beats[i].Position, "%s beats are in order", sheet.Name)
+		}
+	}
+
+	t.Run("ApplyBeatSheet writes numbered plot files", func(t *testing.T) {
+		written, err := proj.ApplyBeatSheet("romance")
+		require.NoError(t, err)
+		require.Len(t, written, 10)
+		assert.Equal(t, filepath.Join("context", "plot", "02-meet-cute.md"), written[1])
+
+		again, err := proj.ApplyBeatSheet("romance")
+		require.NoError(t, err)
+		assert.Empty(t, again, "existing beats are kept")
+
+		_, err = proj.ApplyBeatSheet("sonnet")
+		assert.Error(t, err)
+	})
+
+	t.Run("BeatProgress flags beats the word count has passed", func(t *testing.T) {
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: strings.Repeat("word ", 12)}))
+
+		title, err := proj.SetBeatDone("setup", true)
+		require.NoError(t, err)
+		assert.Equal(t, "Setup", title)
+
+		progress, err := proj.BeatProgress()
+		require.NoError(t, err)
+		require.Len(t, progress.Beats, 10)
+		assert.Equal(t, 1, progress.Done())
+		assert.Equal(t, 12, progress.Words)
+
+		setup, meetCute, noWay := progress.Beats[0], progress.Beats[1], progress.Beats[2]
+		assert.True(t, setup.Done)
+		assert.False(t, setup.Overdue)
+		assert.Equal(t, 10, meetCute.DueWords)
+		assert.True(t, meetCute.Overdue, "12 words is past 10% of 100")
+		assert.False(t, noWay.Overdue)
+
+		content, err := proj.FS.ReadMarkdown(setup.FilePath)
+		require.NoError(t, err)
+		assert.Contains(t, content, "beat: romance\nposition: 0\ndone: true\n---\n# Setup")
+	})
+
+	t.Run("SetBeatDone fails for unknown beats", func(t *testing.T) {
+		_, err := proj.SetBeatDone("Epilogue", true)
+		assert.Error(t, err)
+	})
+}
+
+func TestEventProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("event", types.DefaultProjectConfig("Event", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: strings.Repeat("word ", 100)}))
+	start := time.Date(2026, 11, 1, 10, 0, 0, 0, time.Local)
+
+	progress, err := proj.EventProgress(start)
+	require.NoError(t, err)
+	assert.Nil(t, progress, "no event, no progress")
+
+	event, err := proj.StartEvent("NaNoWriMo", 3000, 30, start)
+	require.NoError(t, err)
+	assert.Equal(t, types.EventConfig{Name: "NaNoWriMo", TargetWords: 3000, Start: "2026-11-01", Days: 30, StartWords: 100}, *event)
+
+	reloaded, err := LoadProjectConfig(proj.Path())
+	require.NoError(t, err)
+	require.NotNil(t, reloaded.Event, "the event is saved in the config")
+
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: strings.Repeat("word ", 250)}))
+	_, err = proj.RecordDailyWords(start.Add(8 * time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: strings.Repeat("word ", 400)}))
+
+	progress, err = proj.EventProgress(start.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.NotNil(t, progress)
+	assert.Equal(t, 300, progress.Written)
+	assert.Equal(t, 3, progress.Day)
+	assert.Equal(t, []int{150, 0, 150}, progress.Daily)
+	assert.Equal(t, 100, progress.DailyTarget)
+	assert.Equal(t, 300, progress.Expected)
+	assert.Equal(t, 2700, progress.Remaining())
+	assert.Equal(t, 97, progress.RequiredPace, "2700 words over the 28 days left")
+	assert.False(t, progress.Over())
+
+	progress, err = proj.EventProgress(start.AddDate(0, 0, 40))
+	require.NoError(t, err)
+	assert.True(t, progress.Over())
+	assert.Len(t, progress.Daily, 30)
+	assert.Equal(t, 2700, progress.RequiredPace)
+
+	require.NoError(t, proj.StopEvent())
+	assert.Nil(t, proj.Config.Event)
+	assert.Error(t, proj.StopEvent())
+}
+
+func TestSeries(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+
+	one, err := manager.Create("book-one", types.DefaultProjectConfig("Book One", "fantasy"))
+	require.NoError(t, err)
+	defer one.Close()
+	two, err := manager.Create("book-two", types.DefaultProjectConfig("Book Two", "fantasy"))
+	require.NoError(t, err)
+	defer two.Close()
+	_, err = manager.Create("standalone", types.DefaultProjectConfig("Standalone", "fantasy"))
+	require.NoError(t, err)
+
+	require.NoError(t, one.JoinSeries("saga"))
+	require.NoError(t, two.JoinSeries("saga"))
+	assert.Equal(t, "saga", two.Config.Series)
+
+	shared := filepath.Join(SeriesDir(tmpDir, "saga"), "characters", "hana.md")
+	require.NoError(t, os.WriteFile(shared, []byte("# Hana\n\nThe lead of every book. Keeper of the lighthouse."), 0644))
+	require.NoError(t, one.CreateContextFile("characters", "hana", "# Hana\n\nAn older copy."))
+	require.NoError(t, one.CreateContextFile("settings", "harbor", "# Harbor\n\nFog."))
+	require.NoError(t, two.CreateContextFile("settings", "harbor", "# Harbor\n\nSun."))
+	require.NoError(t, two.SaveChapter(&types.Chapter{Number: 1, Content: "# One\n\nHana climbs the lighthouse."}))
+
+	t.Run("books see the shared world bible", func(t *testing.T) {
+		characters, err := two.LoadCharacters()
+		require.NoError(t, err)
+		require.Len(t, characters, 1)
+		assert.Equal(t, "Hana", characters[0].Name)
+		assert.Equal(t, filepath.Join(SeriesMount, "characters", "hana.md"), characters[0].FilePath)
+
+		// A book reopened from disk mounts its series again.
+		reopened, err := manager.Open("book-two")
+		require.NoError(t, err)
+		defer reopened.Close()
+		characters, err = reopened.LoadCharacters()
+		require.NoError(t, err)
+		assert.Len(t, characters, 1)
+
+		// Shared files other books may use are not reported as unused.
+		report, err := reopened.BibleReport(0)
+		require.NoError(t, err)
+		assert.NotContains(t, report.Unreferenced, characters[0].FilePath)
+	})
+
+	t.Run("lists the books of the series", func(t *testing.T) {
+		series, err := manager.OpenSeries("saga")
+		require.NoError(t, err)
+		assert.Equal(t, []string{one.Path(), two.Path()}, series.Books)
+
+		_, err = manager.OpenSeries("missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("check finds drifted copies and duplicates", func(t *testing.T) {
+		series, err := two.Series()
+		require.NoError(t, err)
+		issues, err := series.Check()
+		require.NoError(t, err)
+		require.Len(t, issues, 2)
+
+		assert.Equal(t, "book-one", issues[0].Book)
+		assert.Equal(t, filepath.Join("context", "characters", "hana.md"), issues[0].Path)
+		assert.Contains(t, issues[0].Message, "series bible")
+		assert.Equal(t, "book-one, book-two", issues[1].Book)
+		assert.Contains(t, issues[1].Message, "Harbor is defined separately in 2 books")
+	})
+
+	t.Run("search covers every book", func(t *testing.T) {
+		for _, book := range []*Project{one, two} {
+			indexer := search.NewIndexer(search.NewFTSEngine(book.DB), token.NewEstimateCounter(), 0, 0)
+			require.NoError(t, indexer.SyncWithFileSystem(book.FS, book.DB))
+		}
+
+		series, err := manager.OpenSeries("saga")
+		require.NoError(t, err)
+		results, err := series.Search("lighthouse", 10)
+		require.NoError(t, err)
+
+		// The shared file is indexed by both books but listed once.
+		var books []string
+		for _, r := range results {
+			books = append(books, r.Book)
+		}
+		assert.ElementsMatch(t, []string{"saga", "book-two"}, books)
+	})
+
+	t.Run("leaving hides the shared files", func(t *testing.T) {
+		require.NoError(t, one.LeaveSeries())
+		characters, err := one.LoadCharacters()
+		require.NoError(t, err)
+		require.Len(t, characters, 1)
+		assert.Equal(t, filepath.Join("context", "characters", "hana.md"), characters[0].FilePath)
+		assert.Error(t, one.LeaveSeries())
+	})
+}
+
+func TestParseArcPoints(t *testing.T) {
+	points := ParseArcPoints("- Chapter 3: furious — goal: find Jun\n- Ch. 1: grieving\n- 챕터 2: 담담함; 목표: 복수\nnotes")
+	assert.Equal(t, []ArcPoint{
+		{Chapter: 1, State: "grieving"},
+		{Chapter: 2, State: "담담함", Goal: "복수"},
+		{Chapter: 3, State: "furious", Goal: "find Jun"},
+	}, points)
+}
+
+func TestArcReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("arcs", types.DefaultProjectConfig("Arcs", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.CreateContextFile("characters", "hana", "# Hana\n\nThe lead.\n\n## Voice\n\nDry."))
+	require.NoError(t, proj.CreateContextFile("characters", "jun", "# Jun\n\nHer brother."))
+	for i := 1; i <= 8; i++ {
+		content := fmt.Sprintf("# %d\n\nHana walks.", i)
+		if i == 1 || i == 7 {
+			content += " Jun follows."
+		}
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: i, Content: content}))
+	}
+
+	t.Run("records states in the arc section", func(t *testing.T) {
+		name, err := proj.SetArcPoint("hana", ArcPoint{Chapter: 3, State: "furious", Goal: "find Jun"})
+		require.NoError(t, err)
+		assert.Equal(t, "Hana", name)
+		_, err = proj.SetArcPoint("Hana", ArcPoint{Chapter: 1, State: "grieving"})
+		require.NoError(t, err)
+		_, err = proj.SetArcPoint("Hana", ArcPoint{Chapter: 3, State: "calm"})
+		require.NoError(t, err)
+
+		content, err := proj.FS.ReadMarkdown(filepath.Join("context", "characters", "hana.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Hana\n\nThe lead.\n\n## Voice\n\nDry.\n\n## Arc\n\n- Chapter 1: grieving\n- Chapter 3: calm\n", content)
+
+		_, err = proj.SetArcPoint("Mira", ArcPoint{Chapter: 1, State: "lost"})
+		assert.Error(t, err)
+		_, err = proj.SetArcPoint("Hana", ArcPoint{Chapter: 0, State: "lost"})
+		assert.Error(t, err)
+	})
+
+	t.Run("charts arcs and warns about disappearances", func(t *testing.T) {
+		report, err := proj.ArcReport(0)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, report.Chapters)
+		require.Len(t, report.Arcs, 2)
+		assert.Equal(t, []int{1, 7}, report.Arcs[1].Appearances)
+		assert.Equal(t, []ArcGap{{Name: "Jun", After: 1, From: 2, To: 6, Chapters: 5}}, report.Gaps)
+
+		out := report.String()
+		assert.Contains(t, out, "  |◆●◆●●●●●|")
+		assert.Contains(t, out, "  |●·····●·|")
+		assert.Contains(t, out, "- Jun: absent from chapter 2 to 6 (5 chapters)")
+
+		report, err = proj.ArcReport(1)
+		require.NoError(t, err)
+		assert.Contains(t, report.String(), "- Jun: not seen since chapter 7 (1 chapters)")
+	})
+}
+
+func TestSubplotReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("subplots", types.DefaultProjectConfig("Subplots", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	tags := map[int]string{
+		1: "<!-- subplot: Heist -->\nscene\n<!-- subplot: romance, heist -->",
+		2: "<!-- subplot: heist -->",
+		3: "<!-- subplot: heist -->",
+		4: "<!-- subplot: heist, revenge -->",
+		5: "",
+		6: "<!-- subplot: heist -->",
+	}
+	for i := 1; i <= 6; i++ {
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: i, Content: fmt.Sprintf("# %d\n\nWords here.\n%s", i, tags[i])}))
+	}
+
+	t.Run("parses tags", func(t *testing.T) {
+		assert.Equal(t, []string{"heist", "romance"}, ParseSubplotTags(tags[1]))
+		words, err := proj.WordCount()
+		require.NoError(t, err)
+		assert.Equal(t, 6*4+1, words)
+	})
+
+	t.Run("reports coverage and where to weave gaps back in", func(t *testing.T) {
+		report, err := proj.SubplotReport(3)
+		require.NoError(t, err)
+		require.Len(t, report.Subplots, 3)
+		assert.Equal(t, SubplotCoverage{ID: "heist", Chapters: []int{1, 2, 3, 4, 6}, Scenes: 6}, report.Subplots[0])
+		assert.Equal(t, "romance", report.Subplots[1].ID)
+
+		// Romance is untouched in 2-6; chapter 5 carries no other subplot.
+		require.Len(t, report.Gaps, 1)
+		assert.Equal(t, SubplotGap{ID: "romance", After: 1, From: 2, To: 6, Chapters: 5, Open: true, Suggest: 5}, report.Gaps[0])
+
+		out := report.String()
+		assert.Contains(t, out, "  |████·█|  83%  heist (5 chapters, 6 scenes)")
+		assert.Contains(t, out, "- romance: untouched since chapter 1 (5 chapters); weave it into the next chapter or back into chapter 5")
+	})
+
+	t.Run("tags and untags chapters", func(t *testing.T) {
+		require.NoError(t, proj.TagSubplot(5, "Romance"))
+		require.NoError(t, proj.TagSubplot(5, "romance"))
+		ch, err := proj.FindChapter(5)
+		require.NoError(t, err)
+		assert.Equal(t, "# 5\n<!-- subplot: romance -->\n\nWords here.\n", ch.Content)
+
+		require.NoError(t, proj.UntagSubplot(4, "revenge"))
+		ch, err = proj.FindChapter(4)
+		require.NoError(t, err)
+		assert.Contains(t, ch.Content, "<!-- subplot: heist -->")
+		assert.Error(t, proj.UntagSubplot(4, "revenge"))
+
+		require.NoError(t, proj.UntagSubplot(5, "romance"))
+		ch, err = proj.FindChapter(5)
+		require.NoError(t, err)
+		assert.Equal(t, "# 5\n\nWords here.\n", ch.Content)
+	})
+}
+
+func TestContinuitySnapshot(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("continuity", types.DefaultProjectConfig("Continuity", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	_, err = proj.CompareContinuity("")
+	require.Error(t, err)
+
+	require.NoError(t, proj.WriteContextContent("characters", "hana", "# Hana\n\n## Basics\n\n- Age: 27\n- Eyes: green\n- Scar on the left hand\n\n## Voice\n\nBlunt.\n", "create"))
+	require.NoError(t, proj.WriteContextContent("plot", "outline", "# Outline\n\n- Hana leaves home\n- The storm\n- Hana returns\n", "create"))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1\n\nHana, 27, looked out with green eyes."}))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "# 2\n\nThe storm had green water."}))
+
+	snapshot, err := proj.SaveContinuitySnapshot("before-rewrite")
+	require.NoError(t, err)
+	assert.Contains(t, snapshot.Facts, ContinuityFact{Subject: "Hana", Section: "Basics", Key: "Age", Value: "27", Source: "context/characters/hana.md"})
+	for _, f := range snapshot.Facts {
+		assert.NotEqual(t, "Voice", f.Section)
+	}
+	assert.Len(t, snapshot.Timeline, 3)
+
+	report, err := proj.CompareContinuity("")
+	require.NoError(t, err)
+	assert.True(t, report.Empty())
+
+	require.NoError(t, proj.WriteContextContent("characters", "hana", "# Hana\n\n## Basics\n\n- Age: 29\n- Eyes: grey\n- Limps\n", "update"))
+	require.NoError(t, proj.WriteContextContent("plot", "outline", "# Outline\n\n- The storm\n- Hana leaves home\n- Hana meets Jun\n", "update"))
+
+	report, err = proj.CompareContinuity("before-rewrite")
+	require.NoError(t, err)
+	require.Len(t, report.Changed, 2)
+	assert.Equal(t, "27", report.Changed[0].Before.Value)
+	assert.Equal(t, "29", report.Changed[0].After.Value)
+	require.Len(t, report.Removed, 1)
+	assert.Equal(t, "Scar on the left hand", report.Removed[0].Key)
+	require.Len(t, report.Added, 1)
+	assert.Equal(t, []TimelineEntry{{Text: "Hana meets Jun", Source: "context/plot/outline.md"}}, report.TimelineAdded)
+	assert.Equal(t, "Hana returns", report.TimelineRemoved[0].Text)
+	require.Len(t, report.TimelineMoved, 1)
+
+	// Chapter 2 uses "green" too but does not mention Hana.
+	require.Len(t, report.Stale, 2)
+	for _, s := range report.Stale {
+		assert.Equal(t, 1, s.Chapter)
+	}
+	assert.Equal(t, "27", report.Stale[0].Term)
+	assert.Equal(t, "green", report.Stale[1].Term)
+
+	out := report.String()
+	assert.Contains(t, out, "- Hana / Age: 27 → 29")
+	assert.Contains(t, out, "- added: Hana meets Jun")
+	assert.Contains(t, out, "- Chapter 1: \"27\" (was Hana / Age: 27)")
+}
+
+func TestReviewers(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("reviewers", types.DefaultProjectConfig("Reviewers", "cozy mystery"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	proj.Config.Reviewers = []types.ReviewerConfig{
+		{Name: "Copy-Editor", Description: "house style"},
+		{Name: "historian", Prompt: "You check the 1920s details."},
+		{Name: "empty"},
+	}
+	reviewers := proj.Reviewers()
+	require.Len(t, reviewers, 4)
+	assert.Equal(t, "house style", reviewers[0].Description)
+	assert.Equal(t, DefaultReviewers[0].Prompt, reviewers[0].Prompt, "a persona configured without a prompt keeps the built-in one")
+	assert.Contains(t, reviewers[2].Prompt, "fan of cozy mystery")
+	assert.Equal(t, "historian", reviewers[3].Name)
+
+	r, err := proj.FindReviewer("GENRE-FAN")
+	require.NoError(t, err)
+	assert.Equal(t, "genre-fan", r.Name)
+	_, err = proj.FindReviewer("empty")
+	assert.Error(t, err)
+}
+
+func TestExportFeedback(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("feedback", types.DefaultProjectConfig("Feedback", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1\n\nHana met Aldrik."}))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "# 2\n\nThe Aldrick guard, said \"hi\"."}))
+	require.NoError(t, proj.WriteContextContent("glossary", "terms", "# Terms\n\n- Aldrik: the king\n", "create"))
+	require.NoError(t, proj.FS.WriteMarkdown("batch/lint/chapter-002.md", "- \"guard, said\" — stray comma — guard said\n- Repeated \"the\"\nNo other issues.\n"))
+	_, err = proj.DB.SaveAnnotation(storage.AnnotationRecord{Chapter: 1, Source: "genre-fan", Category: "pacing", Severity: "major", Comment: "Slow start"})
+	require.NoError(t, err)
+
+	items, err := proj.Feedback(0)
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	assert.Equal(t, "genre-fan", items[0].Source)
+	assert.Equal(t, FeedbackItem{Chapter: 2, Source: FeedbackLint, Quote: "guard, said", Comment: "stray comma", Suggestion: "guard said"}, items[1])
+	assert.Equal(t, "Repeated \"the\"", items[2].Comment)
+	assert.Equal(t, FeedbackGlossary, items[3].Source)
+	assert.Equal(t, "Aldrik", items[3].Suggestion)
+
+	items, err = proj.Feedback(1)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+	_, err = proj.Feedback(9)
+	assert.Error(t, err)
+
+	path, count, err := proj.ExportFeedback(0, "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("exports", "feedback.md"), path)
+	assert.Equal(t, 4, count)
+	doc, err := proj.FS.ReadMarkdown(path)
+	require.NoError(t, err)
+	assert.Contains(t, doc, "# Feedback: Feedback\n")
+	assert.Contains(t, doc, "## Chapter 1\n\n- [ ] **genre-fan, major pacing** — Slow start\n")
+	assert.Contains(t, doc, "- [ ] **lint** “guard, said” — stray comma\n  - Suggestion: guard said\n")
+
+	path, _, err = proj.ExportFeedback(2, "csv")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("exports", "feedback-chapter-002.csv"), path)
+	doc, err = proj.FS.ReadMarkdown(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc, "chapter,source,category,severity,quote,comment,suggestion\n2,lint,,,\"guard, said\",stray comma,guard said\n"))
+
+	_, _, err = proj.ExportFeedback(0, "pdf")
+	assert.Error(t, err)
+}
+
+func TestFixTypography(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("typography", types.DefaultProjectConfig("Typography", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1\n\n\"Wait...\" she said -- too late.\n"}))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "# 2\n\n“Already clean.” <!-- subplot: heist -->\n"}))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 3, Content: "# 3\n\n\"Not this one.\"\n"}))
+
+	changed, err := proj.FixTypography(nil, "", true)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, changed)
+	ch, err := proj.FindChapter(1)
+	require.NoError(t, err)
+	assert.Contains(t, ch.Content, "\"Wait...\"", "dry run must not write")
+
+	changed, err = proj.FixTypography([]int{1, 2}, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, changed)
+	ch, err = proj.FindChapter(1)
+	require.NoError(t, err)
+	assert.Equal(t, "# 1\n\n“Wait…” she said — too late.\n", ch.Content)
+	ch, err = proj.FindChapter(3)
+	require.NoError(t, err)
+	assert.Equal(t, "# 3\n\n\"Not this one.\"\n", ch.Content)
+
+	path, err := proj.ExportText("ja")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("exports", "manuscript.txt"), path)
+	text, err := proj.FS.ReadMarkdown(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# 1\n\n“Wait…” she said — too late.\n\n\n# 2\n\n“Already clean.”\n\n\n# 3\n\n「Not this one.」\n", text)
+}
+
+func TestNormalizeEncodings(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("encodings", types.DefaultProjectConfig("Encodings", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1\n\nClean.\n"}))
+	require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), "chapters", "chapter-002.md"), []byte("\xEF\xBB\xBF# 2\r\n\r\nImported.\r\n"), 0644))
+
+	ch, err := proj.FindChapter(2)
+	require.NoError(t, err)
+	assert.Equal(t, "# 2\n\nImported.\n", ch.Content)
+
+	issues, err := proj.CheckEncodings()
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, filepath.Join("chapters", "chapter-002.md"), issues[0].Path)
+	assert.Equal(t, "UTF-8, BOM, CRLF", issues[0].Format.String())
+
+	fixed, err := proj.NormalizeEncodings()
+	require.NoError(t, err)
+	assert.Len(t, fixed, 1)
+	raw, err := os.ReadFile(filepath.Join(proj.Path(), "chapters", "chapter-002.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# 2\n\nImported.\n", string(raw))
+
+	issues, err = proj.CheckEncodings()
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestContextSummaries(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("summaries", types.DefaultProjectConfig("Summaries", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	path := filepath.Join(proj.Path(), "context", "characters", "hana.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Hana\n\nA harbor pilot."), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), "context", "plot", "act-1.md"), []byte("Opening act"), 0644))
+
+	summaries, err := proj.ContextSummaries("characters")
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, ContextSummary{Title: "Hana", FirstLine: "# Hana", FilePath: filepath.Join("context", "characters", "hana.md")}, summaries[0])
+
+	plots, err := proj.ContextSummaries("plot")
+	require.NoError(t, err)
+	require.Len(t, plots, 1)
+	assert.Equal(t, "act-1", plots[0].Title, "untitled files are named after the file")
+
+	// Same size and modification time: the cached summary is used.
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("# Mira\n\nA harbor pilot."), 0644))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	summaries, err = proj.ContextSummaries("characters")
+	require.NoError(t, err)
+	assert.Equal(t, "Hana", summaries[0].Title)
+
+	proj.ForgetContextSummaries([]string{summaries[0].FilePath})
+	summaries, err = proj.ContextSummaries("characters")
+	require.NoError(t, err)
+	assert.Equal(t, "Mira", summaries[0].Title)
+
+	// A new modification time is read again.
+	require.NoError(t, os.WriteFile(path, []byte("# Hana Seo\n\nA harbor pilot."), 0644))
+	require.NoError(t, os.Chtimes(path, info.ModTime().Add(time.Second), info.ModTime().Add(time.Second)))
+	summaries, err = proj.ContextSummaries("characters")
+	require.NoError(t, err)
+	assert.Equal(t, "Hana Seo", summaries[0].Title)
+}
+
+func TestAddPlotPoint(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager, err := NewManager(tmpDir)
+	require.NoError(t, err)
+	proj, err := manager.Create("plot", types.DefaultProjectConfig("Plot", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	path, err := proj.AddPlotPoint("The Lighthouse Goes Dark", "Mira finds the lamp smashed.")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("context", "plot", "the-lighthouse-goes-dark.md"), path)
+	content, err := proj.FS.ReadMarkdown(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# The Lighthouse Goes Dark\n\nMira finds the lamp smashed.\n", content)
+
+	path, err = proj.AddPlotPoint("The Lighthouse Goes Dark", "Again.")
+	require.NoError(t, err)
+	assert.NotEqual(t, filepath.Join("context", "plot", "the-lighthouse-goes-dark.md"), path, "existing files are kept")
+
+	// In a numbered outline the new point comes last.
+	_, err = proj.ApplyBeatSheet("romance")
+	require.NoError(t, err)
+	path, err = proj.AddPlotPoint("Storm Night", "The ferry cannot cross.")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("context", "plot", "11-storm-night.md"), path)
+
+	_, err = proj.AddPlotPoint("  ", "Nothing.")
+	assert.Error(t, err)
+}
+
+func TestFindOutlineBeat(t *testing.T) {
+	manager, err := NewManager(t.TempDir())
+	require.NoError(t, err)
+	proj, err := manager.Create("outline", types.DefaultProjectConfig("Outline", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "plot", "01-harbor.md"),
+		"# The Harbor\n\n- Hana arrives in the rain\n- She meets the ferryman\n  He knows her name.\n- The ferry leaves without her\n"))
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "plot", "02-storm.md"),
+		"---\nbeat: save-the-cat\n---\n# The Storm\n\nThe storm arc.\n\n## Warning\n\nThe radio crackles.\n\n## Landfall\n\nThe sea wall breaks.\n"))
+
+	beat, err := proj.FindOutlineBeat("1.2")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", beat.Ref)
+	assert.Equal(t, "She meets the ferryman", beat.Title)
+	assert.Equal(t, "She meets the ferryman\nHe knows her name.", beat.Text)
+	assert.Equal(t, "Hana arrives in the rain", beat.Previous)
+	assert.Equal(t, "The ferry leaves without her", beat.Next)
+
+	beat, err = proj.FindOutlineBeat("2.2")
+	require.NoError(t, err)
+	assert.Equal(t, "Landfall", beat.Title)
+	assert.Equal(t, "The sea wall breaks.", beat.Text)
+	assert.Equal(t, filepath.Join("context", "plot", "02-storm.md"), beat.FilePath)
+
+	beat, err = proj.FindOutlineBeat("2")
+	require.NoError(t, err)
+	assert.Equal(t, "The Storm", beat.Title)
+	assert.Equal(t, "The Harbor", beat.Previous)
+	assert.NotContains(t, beat.Text, "save-the-cat")
+
+	for _, ref := range []string{"3", "1.4", "0", "x.1", "1."} {
+		_, err := proj.FindOutlineBeat(ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestWorld(t *testing.T) {
+	manager, err := NewManager(t.TempDir())
+	require.NoError(t, err)
+	proj, err := manager.Create("world", types.DefaultProjectConfig("World", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	settings := map[string]string{
+		"aster.md":   "# Aster\n\n## Location\n\n- Kind: continent\n",
+		"eldoria.md": "# Eldoria\n\n## Location\n\n- Kind: city\n- In: [[Aster]]\n\n## Travel\n\n- [[Harbor]]: 2 days by road\n- Capital: 5 days by road\n",
+		"harbor.md":  "# Harbor\n\n## 위치\n\n- 종류: 도시\n- 소속: Aster\n\n## 이동\n\n- Capital: 1일 12시간 (배)\n- Eldoria: 3 days\n",
+		"capital.md": "# Capital\n\n## Location\n\n- Kind: city\n- In: Aster\n",
+		"tower.md":   "# Old Tower\n\n## Location\n\n- Kind: building\n- In: Eldoria\n\n## Travel\n\n- Nowhere: 1 hour\n- Harbor: soon\n",
+		"inn.md":     "# Inn\n\n## Location\n\n- In: Capital\n",
+	}
+	for name, content := range settings {
+		require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "settings", name), content))
+	}
+
+	world, err := proj.World()
+	require.NoError(t, err)
+	require.Len(t, world.Roots, 1)
+	assert.Equal(t, "Aster", world.Roots[0].Name)
+	tower := world.Find("[[old tower]]")
+	require.NotNil(t, tower)
+	assert.Equal(t, []string{"Aster", "Eldoria", "Old Tower"}, locationNames(tower.Path()))
+
+	tree := world.String()
+	assert.Contains(t, tree, "Aster (continent)\n├─ Capital (city)\n│  └─ Inn\n├─ Eldoria (city)\n│  │  → Harbor: 2 days by road")
+	assert.Contains(t, tree, "Old Tower (building)")
+
+	assert.ElementsMatch(t, []string{
+		"Old Tower notes travel to Nowhere, which is not a setting",
+		`Old Tower: route to Harbor: no travel time in "soon"; write it like "2 days by road"`,
+		"Eldoria to Harbor takes 2 days by road, but Harbor to Eldoria takes 3 days",
+		"Eldoria to Capital takes 5 days by road, but going by way of Harbor takes only 3 days 12 hours",
+	}, world.Issues)
+
+	// Leaving the tower means leaving Eldoria; the quickest way to the
+	// inn is by the capital, through the harbor.
+	journey, err := world.Travel("Old Tower", "inn")
+	require.NoError(t, err)
+	assert.Equal(t, 84*time.Hour, journey.Duration)
+	assert.Equal(t, "Old Tower to Inn: 3 days 12 hours, by way of:\n- Eldoria to Harbor: 2 days by road\n- Harbor to Capital: 1일 12시간 (배)", journey.String())
+
+	journey, err = world.Travel("Harbor", "Eldoria")
+	require.NoError(t, err)
+	assert.Equal(t, "Harbor to Eldoria: 2 days (2 days by road)", journey.String())
+
+	journey, err = world.Travel("Old Tower", "Aster")
+	require.NoError(t, err)
+	assert.Equal(t, "Old Tower is in Aster; no travel time is noted between them.", journey.String())
+
+	_, err = world.Travel("Old Tower", "Atlantis")
+	assert.Error(t, err)
+}
+
+func locationNames(locations []*Location) []string {
+	var names []string
+	for _, loc := range locations {
+		names = append(names, loc.Name)
+	}
+	return names
+}
+
+func TestParseTravelTime(t *testing.T) {
+	tests := map[string]time.Duration{
+		"2 days by road":        48 * time.Hour,
+		"1 day 6 hours on foot": 30 * time.Hour,
+		"half an hour, or 30m":  30 * time.Minute,
+		"3시간":                   3 * time.Hour,
+		"1.5 weeks":             252 * time.Hour,
+		"2 days, or 5 on foot":  48 * time.Hour,
+		"10 miles, 2h":          2 * time.Hour,
+	}
+	for note, want := range tests {
+		got, err := ParseTravelTime(note)
+		require.NoError(t, err, note)
+		assert.Equal(t, want, got, note)
+	}
+	_, err := ParseTravelTime("a while")
+	assert.Error(t, err)
+}
+
+func TestItemReport(t *testing.T) {
+	manager, err := NewManager(t.TempDir())
+	require.NoError(t, err)
+	proj, err := manager.Create("items", types.DefaultProjectConfig("Items", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "characters", "hana.md"), "# Hana\n"))
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "settings", "harbor.md"), "# Harbor\n"))
+	for n, content := range map[int]string{
+		1: "Hana found the Dawn Sword in the vault.",
+		2: "Jun polished the Dawn Sword alone.",
+		3: "Hana raised the Dawn Sword and it shattered.",
+		4: "The Dawn Sword gleamed again.",
+	} {
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: n, Content: fmt.Sprintf("# Chapter %d\n\n%s\n", n, content)}))
+	}
+
+	path, err := proj.MoveItem("Dawn Sword", ItemSighting{Chapter: 1, Holder: "[[Hana]]", Place: "Harbor", Note: "taken; from the vault"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("context", "items", "dawn-sword.md"), path)
+	_, err = proj.MoveItem("dawn sword", ItemSighting{Chapter: 3, Status: "destroyed"})
+	require.NoError(t, err)
+	_, err = proj.MoveItem("Dawn Sword", ItemSighting{Chapter: 1, Holder: "Hana", Place: "Atlantis"})
+	require.NoError(t, err)
+	content, err := proj.FS.ReadMarkdown(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Dawn Sword\n\n## Whereabouts\n\n- Chapter 1: holder: [[Hana]]; place: [[Atlantis]]\n- Chapter 3: status: destroyed\n", content)
+
+	_, err = proj.MoveItem("Dawn Sword", ItemSighting{Chapter: 2})
+	assert.Error(t, err)
+
+	report, err := proj.ItemReport()
+	require.NoError(t, err)
+	require.Len(t, report.Items, 1)
+	item := report.Items[0]
+	assert.Equal(t, []int{1, 2, 3, 4}, item.Appearances)
+	assert.Equal(t, 4, item.LastSeen())
+	assert.Equal(t, []string{
+		"Dawn Sword, chapter 1: at Atlantis, which has no setting file",
+		"Dawn Sword, chapter 2: appears, but Hana, who has held it since chapter 1, does not",
+		"Dawn Sword, chapter 4: appears after it was destroyed in chapter 3",
+	}, itemIssueStrings(report.Issues))
+	assert.Contains(t, report.String(), "Dawn Sword: destroyed (since chapter 3), last seen in chapter 4")
+}
+
+func itemIssueStrings(issues []ItemIssue) []string {
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+	return lines
+}
+
+func TestRulebook(t *testing.T) {
+	manager, err := NewManager(t.TempDir())
+	require.NoError(t, err)
+	proj, err := manager.Create("rules", types.DefaultProjectConfig("Rules", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "rules", "magic.md"),
+		"# Blood Magic\n\nRules of the craft.\n\n- **M1**: Every spell costs the caster blood.\n- The dead stay dead: no spell raises them.\n"))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# One\n\nMira healed him without a scratch.\n"}))
+
+	rules, err := proj.LoadRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, Rule{ID: "M1", System: "Blood Magic", Text: "Every spell costs the caster blood.", FilePath: filepath.Join("context", "rules", "magic.md")}, rules[0])
+	assert.Equal(t, "magic-2", rules[1].ID, "text before the colon that is not an ID stays in the rule")
+	assert.Equal(t, "The dead stay dead: no spell raises them.", rules[1].Text)
+
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("batch", "rules", "chapter-001.md"),
+		"- \"healed him without a scratch\" — M1 — the spell drew no blood\n- \"x\" — Z9 — not a rule\nNo issues found.\n"))
+	items, err := proj.Feedback(1)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, FeedbackRules, items[0].Source)
+	assert.Equal(t, "M1", items[0].Category)
+	assert.Equal(t, "healed him without a scratch", items[0].Quote)
+	assert.Equal(t, "breaks M1 (Blood Magic: Every spell costs the caster blood.): the spell drew no blood", items[0].Comment)
+}
+
+func TestPacingReport(t *testing.T) {
+	assert.Equal(t, []string{"# One\n\nThe ship burns.", "Hana remembers the storm."},
+		SplitScenes("# One\n\nThe ship burns.\n\n* * *\n\nHana remembers the storm.\n\n---\n"))
+	assert.Len(t, SplitScenes("# One\n\n***\n\nOnly scene."), 1, "a title alone is not a scene")
+	assert.Equal(t, 4, ReadingMinutes(1000))
+	assert.Equal(t, "2 h 05 min", FormatReadingTime(125))
+
+	manager, err := NewManager(t.TempDir())
+	require.NoError(t, err)
+	proj, err := manager.Create("pacing", types.DefaultProjectConfig("Pacing", "fantasy"))
+	require.NoError(t, err)
+	defer proj.Close()
+
+	for i := 1; i <= 6; i++ {
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: i,
+			Content: fmt.Sprintf("# %d\n\n%s\n\n***\n\n%s\n", i, strings.Repeat("run ", 30), strings.Repeat("think ", 10))}))
+	}
+	// The middle chapters turn inward; chapter 1's result misses a scene.
+	results := map[int]string{
+		1: "- Scene 1: action — a chase\n",
+		2: "- Scene 1: action — a fight\n- Scene 2: reflection\n",
+		3: "- Scene 1: reflection — memories\n- Scene 2: reflection\n",
+		4: "- Scene 1: reflection\n- Scene 2: **mixed** — a quiet talk\n",
+		5: "- Scene 1: action\n- Scene 2: action\n",
+	}
+	for n, result := range results {
+		require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("batch", "pacing", fmt.Sprintf("chapter-%03d.md", n)), result))
+	}
+
+	report, err := proj.PacingReport()
+	require.NoError(t, err)
+	require.Len(t, report.Chapters, 6)
+	assert.Equal(t, 240, report.Words)
+	assert.False(t, report.Chapters[0].Classified(), "a result for the wrong number of scenes is ignored")
+	assert.Equal(t, Scene{Number: 2, Words: 10, Mode: SceneMixed, Note: "a quiet talk"}, report.Chapters[3].Scenes[1])
+	assert.InDelta(t, 0.75, report.Chapters[1].ActionShare(), 0.001)
+	assert.Equal(t, []PacingSag{{From: 3, To: 4, Share: 0.0625}}, report.Sags)
+
+	out := report.String()
+	assert.Contains(t, out, "Pacing across 6 chapters (240 words, about 1 min to read)")
+	assert.Contains(t, out, "  Action |·▆▁▁█·|")
+	assert.Contains(t, out, "action  75%  A R")
+	assert.Contains(t, out, "- chapters 3-4 are mostly reflection (6% action)")
+
+	path, err := proj.ExportPacingSVG()
+	require.NoError(t, err)
+	svg, err := proj.FS.ReadMarkdown(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	assert.Contains(t, svg, "Chapter 4, scene 2: 10 words, mixed — a quiet talk")
+	assert.Contains(t, svg, "<polyline ")
+}
+
+func TestQuota(t *testing.T) {
+	manager, err := NewManager(t.TempDir())
+	require.NoError(t, err)
+	config := types.DefaultProjectConfig("Quota", "fantasy")
+	config.Quota = types.QuotaConfig{
+		MonthlyTokens: 10000,
+		MonthlyCost:   1,
+		Prices:        map[string]types.ModelPrice{"gpt-4o": {Input: 2.5, Output: 10}},
+	}
+	proj, err := manager.Create("quota", config)
+	require.NoError(t, err)
+	defer proj.Close()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	require.NoError(t, proj.RecordTokenUsage("gpt-4o", 4000, 1000, now))
+	require.NoError(t, proj.RecordTokenUsage("llama3", 2000, 1000, now))
+	require.NoError(t, proj.RecordTokenUsage("gpt-4o", 50000, 0, now.AddDate(0, -1, 0)))
+	require.NoError(t, proj.RecordTokenUsage("gpt-4o", 0, 0, now))
+
+	quota, err := proj.Quota(now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local), quota.Month)
+	assert.Equal(t, 8000, quota.Tokens)
+	assert.InDelta(t, 0.02, quota.Cost, 1e-9)
+	require.Len(t, quota.Models, 2)
+	assert.Equal(t, QuotaWarning, quota.Level())
+	assert.Equal(t, "8k/10k tokens, $0.02/$1.00 (80%)", quota.Summary())
+
+	require.NoError(t, proj.RecordTokenUsage("llama3", 2500, 0, now))
+	quota, err = proj.Quota(now)
+	require.NoError(t, err)
+	assert.Equal(t, QuotaExceeded, quota.Level())
+
+	proj.Config.Quota = types.QuotaConfig{}
+	quota, err = proj.Quota(now)
+	require.NoError(t, err)
+	assert.False(t, quota.Limited())
+	assert.Equal(t, QuotaOK, quota.Level())
+	assert.Equal(t, "10k tokens, $0.02", quota.Summary())
+
+	assert.Equal(t, "950", FormatTokens(950))
+	assert.Equal(t, "1.5k", FormatTokens(1500))
+	assert.Equal(t, "12k", FormatTokens(12500))
+	assert.Equal(t, "1.2M", FormatTokens(1_200_000))
+}
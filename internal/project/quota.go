@@ -0,0 +1,143 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// QuotaWarnAt is the share of a monthly limit at which the author is
+// warned that the budget is running out.
+const QuotaWarnAt = 0.8
+
+// QuotaLevel is how close a project is to its monthly budget.
+type QuotaLevel int
+
+const (
+	QuotaOK QuotaLevel = iota
+	QuotaWarning
+	QuotaExceeded
+)
+
+// QuotaStatus is what a project spent on AI this month against its
+// budget.
+type QuotaStatus struct {
+	// Month is the start of the calendar month counted.
+	Month time.Time
+
+	// Models lists the tokens and cost of each model used this month.
+	Models []storage.TokenUsage
+
+	Tokens int
+	Cost   float64
+
+	// TokenLimit and CostLimit are the monthly limits; zero is no limit.
+	TokenLimit int
+	CostLimit  float64
+}
+
+// Limited reports whether the project has a monthly budget.
+func (q *QuotaStatus) Limited() bool {
+	return q.TokenLimit > 0 || q.CostLimit > 0
+}
+
+// Used returns the share of the budget spent: the larger of the token and
+// cost shares, or 0 without a budget.
+func (q *QuotaStatus) Used() float64 {
+	var used float64
+	if q.TokenLimit > 0 {
+		used = float64(q.Tokens) / float64(q.TokenLimit)
+	}
+	if q.CostLimit > 0 {
+		used = max(used, q.Cost/q.CostLimit)
+	}
+	return used
+}
+
+// Level returns how close the project is to its budget.
+func (q *QuotaStatus) Level() QuotaLevel {
+	switch used := q.Used(); {
+	case used >= 1:
+		return QuotaExceeded
+	case used >= QuotaWarnAt:
+		return QuotaWarning
+	default:
+		return QuotaOK
+	}
+}
+
+// Summary renders the month's spending against the limits, such as
+// "82k/100k tokens, $4.10/$5.00 (82%)".
+func (q *QuotaStatus) Summary() string {
+	tokens := FormatTokens(q.Tokens)
+	if q.TokenLimit > 0 {
+		tokens += "/" + FormatTokens(q.TokenLimit)
+	}
+	parts := []string{tokens + " tokens"}
+	if q.CostLimit > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f/$%.2f", q.Cost, q.CostLimit))
+	} else if q.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f", q.Cost))
+	}
+	summary := strings.Join(parts, ", ")
+	if q.Limited() {
+		summary += fmt.Sprintf(" (%d%%)", int(q.Used()*100))
+	}
+	return summary
+}
+
+// FormatTokens renders a token count compactly, such as 950, 1.5k, 12k or
+// 1.2M.
+func FormatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1_000_000), ".0") + "M"
+	case n >= 10_000:
+		return fmt.Sprintf("%dk", n/1000)
+	case n >= 1000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1000), ".0") + "k"
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// Quota returns what the project spent this month, as of now, against its
+// budget.
+func (p *Project) Quota(now time.Time) (*QuotaStatus, error) {
+	status := &QuotaStatus{Month: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())}
+	if p.Config != nil {
+		status.TokenLimit = p.Config.Quota.MonthlyTokens
+		status.CostLimit = p.Config.Quota.MonthlyCost
+	}
+	if p.DB == nil {
+		return status, nil
+	}
+
+	models, err := p.DB.TokenUsageSince(status.Month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token usage: %w", err)
+	}
+	status.Models = models
+	for _, u := range models {
+		status.Tokens += u.Tokens()
+		status.Cost += u.Cost
+	}
+	return status, nil
+}
+
+// RecordTokenUsage records the tokens an AI request used, estimating its
+// cost from the model's price in the quota settings.
+func (p *Project) RecordTokenUsage(model string, promptTokens, completionTokens int, at time.Time) error {
+	if p.DB == nil || promptTokens+completionTokens <= 0 {
+		return nil
+	}
+	usage := storage.TokenUsage{Model: model, PromptTokens: promptTokens, CompletionTokens: completionTokens}
+	if p.Config != nil {
+		if price, ok := p.Config.Quota.Prices[model]; ok {
+			usage.Cost = (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1_000_000
+		}
+	}
+	return p.DB.RecordTokenUsage(usage, at)
+}
@@ -0,0 +1,166 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultRecentChapters is how many of the latest chapters a character must
+// appear in to count as current.
+const DefaultRecentChapters = 3
+
+// BibleReport lists story bible entries that may need pruning or updating.
+type BibleReport struct {
+	// Unreferenced lists context files no chapter mentions by title or
+	// links to.
+	Unreferenced []string
+
+	// Absent lists characters mentioned in earlier chapters but not in the
+	// last Recent chapters.
+	Absent []AbsentCharacter
+	Recent int
+
+	// EmptySettings lists setting files with nothing under their title.
+	EmptySettings []string
+}
+
+// AbsentCharacter is a character missing from the latest chapters.
+type AbsentCharacter struct {
+	Name     string
+	FilePath string
+
+	// LastChapter is the number of the last chapter mentioning them.
+	LastChapter int
+}
+
+// Empty reports whether the report found nothing.
+func (r *BibleReport) Empty() bool {
+	return len(r.Unreferenced) == 0 && len(r.Absent) == 0 && len(r.EmptySettings) == 0
+}
+
+// String renders the report as plain text, one section per finding.
+func (r *BibleReport) String() string {
+	if r.Empty() {
+		return "The story bible is up to date: every context file is used and no setting is empty."
+	}
+
+	var sections []string
+	if len(r.Unreferenced) > 0 {
+		lines := []string{fmt.Sprintf("Never referenced in a chapter (%d):", len(r.Unreferenced))}
+		for _, path := range r.Unreferenced {
+			lines = append(lines, "- "+path)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	if len(r.Absent) > 0 {
+		lines := []string{fmt.Sprintf("Not mentioned in the last %d chapters (%d):", r.Recent, len(r.Absent))}
+		for _, c := range r.Absent {
+			lines = append(lines, fmt.Sprintf("- %s (%s), last seen in chapter %d", c.Name, c.FilePath, c.LastChapter))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	if len(r.EmptySettings) > 0 {
+		lines := []string{fmt.Sprintf("Settings with no description (%d):", len(r.EmptySettings))}
+		for _, path := range r.EmptySettings {
+			lines = append(lines, "- "+path)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// BibleReport checks the context files against the chapters. A chapter
+// references a context file when it mentions the file's title or links to
+// it with [[Name]]. recent is how many of the latest chapters a character
+// must appear in; zero or less uses DefaultRecentChapters.
+func (p *Project) BibleReport(recent int) (*BibleReport, error) {
+	if recent <= 0 {
+		recent = DefaultRecentChapters
+	}
+
+	graph, err := p.LinkGraph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+
+	// lastSeen maps a context file to the last chapter referencing it.
+	lastSeen := make(map[string]int)
+	for _, ch := range chapters {
+		for _, path := range graph.Mentioned(ch.Content) {
+			lastSeen[path] = ch.Number
+		}
+		for _, path := range graph.Links[ch.FilePath] {
+			lastSeen[path] = ch.Number
+		}
+	}
+
+	report := &BibleReport{Recent: recent}
+	characters, err := p.LoadCharacters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load characters: %w", err)
+	}
+	settings, err := p.LoadSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	plots, err := p.LoadPlots()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plots: %w", err)
+	}
+
+	var paths []string
+	for _, c := range characters {
+		paths = append(paths, c.FilePath)
+	}
+	for _, s := range settings {
+		paths = append(paths, s.FilePath)
+	}
+	for _, pl := range plots {
+		paths = append(paths, pl.FilePath)
+	}
+	for _, path := range paths {
+		// The series' shared files may belong to the other books.
+		if isSeriesFile(path) {
+			continue
+		}
+		if _, ok := lastSeen[path]; !ok {
+			report.Unreferenced = append(report.Unreferenced, path)
+		}
+	}
+
+	// Characters only count as absent once there are more chapters than
+	// the recent window.
+	cutoff := len(chapters) - recent
+	for _, c := range characters {
+		if last, ok := lastSeen[c.FilePath]; ok && last <= cutoff {
+			report.Absent = append(report.Absent, AbsentCharacter{Name: c.Name, FilePath: c.FilePath, LastChapter: last})
+		}
+	}
+
+	for _, s := range settings {
+		if p.contextBody(s.Description) == "" {
+			report.EmptySettings = append(report.EmptySettings, s.FilePath)
+		}
+	}
+	return report, nil
+}
+
+// contextBody returns a context file's text without its frontmatter and
+// title heading.
+func (p *Project) contextBody(content string) string {
+	_, body := p.FS.ParseMarkdownFrontmatter(content)
+	var lines []string
+	titled := false
+	for _, line := range strings.Split(body, "\n") {
+		if !titled && strings.HasPrefix(strings.TrimSpace(line), "# ") {
+			titled = true
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
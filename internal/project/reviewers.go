@@ -0,0 +1,83 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// DefaultReviewers are the built-in reviewer personas.
+var DefaultReviewers = []types.ReviewerConfig{
+	{
+		Name:        "copy-editor",
+		Description: "grammar, punctuation, word choice, repetition and consistency of names and details",
+		Prompt:      "You are a meticulous copy editor. Review the chapter for errors in grammar, spelling and punctuation, awkward or wordy sentences, repeated words, and inconsistencies in names, spellings, timeline and details. Quote each problem exactly and suggest a correction. Do not comment on plot or taste.",
+	},
+	{
+		Name:        "sensitivity-reader",
+		Description: "stereotypes, harmful tropes and insensitive portrayals",
+		Prompt:      "You are a sensitivity reader. Review the chapter for stereotypes, harmful tropes, insensitive or inaccurate portrayals of cultures, identities, disabilities and trauma, and language that may hurt readers unintentionally. Explain why each passage may land badly and suggest a way to keep the author's intent while avoiding the harm. Do not flag dark content that the story clearly treats with care.",
+	},
+	{
+		Name:        "genre-fan",
+		Description: "what a devoted reader of the genre will love or miss",
+		Prompt:      "You are a devoted, well-read fan of {genre}. Review the chapter as a reader: where it grips you, where it drags, which genre expectations it meets or subverts, clichés that made you roll your eyes, and what would make you keep turning pages. Be honest and specific.",
+	},
+}
+
+// Reviewers returns the reviewer personas: the built-in ones, replaced by
+// configured personas of the same name, then the other configured ones.
+// Prompts have the project's genre filled in.
+func (p *Project) Reviewers() []types.ReviewerConfig {
+	var configured []types.ReviewerConfig
+	genre := "this genre"
+	if p.Config != nil {
+		configured = p.Config.Reviewers
+		if p.Config.Genre != "" {
+			genre = p.Config.Genre
+		}
+	}
+
+	var reviewers []types.ReviewerConfig
+	used := make(map[int]bool)
+	for _, r := range DefaultReviewers {
+		for i, c := range configured {
+			if strings.EqualFold(c.Name, r.Name) {
+				// A persona configured without a prompt keeps the
+				// built-in one, e.g. to change only its description.
+				if c.Prompt == "" {
+					c.Prompt = r.Prompt
+				}
+				if c.Description == "" {
+					c.Description = r.Description
+				}
+				r = c
+				used[i] = true
+				break
+			}
+		}
+		reviewers = append(reviewers, r)
+	}
+	for i, c := range configured {
+		if !used[i] && c.Name != "" && c.Prompt != "" {
+			reviewers = append(reviewers, c)
+		}
+	}
+
+	for i := range reviewers {
+		reviewers[i].Prompt = strings.ReplaceAll(reviewers[i].Prompt, "{genre}", genre)
+	}
+	return reviewers
+}
+
+// FindReviewer returns the reviewer persona with the given name
+// (case-insensitive).
+func (p *Project) FindReviewer(name string) (types.ReviewerConfig, error) {
+	for _, r := range p.Reviewers() {
+		if strings.EqualFold(r.Name, strings.TrimSpace(name)) {
+			return r, nil
+		}
+	}
+	return types.ReviewerConfig{}, fmt.Errorf("reviewer not found: %s", name)
+}
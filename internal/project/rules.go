@@ -0,0 +1,120 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RulesDir is where the rulebook lives: one file per magic or technology
+// system, one hard rule per list item.
+const RulesDir = "rules"
+
+// rulesResultDir holds the results of `dreamteller batch --op rules`.
+var rulesResultDir = filepath.Join("batch", "rules")
+
+// FeedbackRules is the feedback source of rulebook violations.
+const FeedbackRules = "rules"
+
+// ruleIDPattern matches an explicit rule ID before the colon of a rule,
+// such as "M1" in "- M1: Every spell costs blood."
+var ruleIDPattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}_.-]{0,11}$`)
+
+// Rule is a hard rule of the world's magic or technology.
+type Rule struct {
+	// ID cites the rule: the one written before its colon, or the file
+	// name and the rule's place in the file, such as "magic-2".
+	ID       string
+	System   string
+	Text     string
+	FilePath string
+}
+
+// String formats the rule with its ID and system for citation.
+func (r Rule) String() string {
+	return fmt.Sprintf("[%s] %s: %s", r.ID, r.System, r.Text)
+}
+
+// LoadRules reads the rulebook. Each list item of a file in context/rules
+// is a rule; the file's title names the system the rules belong to.
+func (p *Project) LoadRules() ([]Rule, error) {
+	files, err := p.listContext(RulesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	for _, file := range files {
+		content, err := p.FS.ReadMarkdown(file.Path)
+		if err != nil {
+			continue
+		}
+		base := strings.TrimSuffix(filepath.Base(file.Path), ".md")
+		system := p.FS.ParseMarkdownTitle(content)
+		if system == "" {
+			system = base
+		}
+
+		n := 0
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, "* ") {
+				continue
+			}
+			text := strings.TrimSpace(line[2:])
+			if text == "" {
+				continue
+			}
+			n++
+			rule := Rule{ID: base + "-" + strconv.Itoa(n), System: system, Text: text, FilePath: file.Path}
+			if id, rest, ok := strings.Cut(text, ":"); ok {
+				id = strings.Trim(strings.TrimSpace(id), "*")
+				if ruleIDPattern.MatchString(id) && strings.TrimSpace(rest) != "" {
+					rule.ID, rule.Text = id, strings.TrimSpace(rest)
+				}
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// RulebookMarkdown renders the rules for a prompt, one per line.
+func RulebookMarkdown(rules []Rule) string {
+	lines := make([]string, len(rules))
+	for i, r := range rules {
+		lines[i] = "- " + r.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseRulesResult reads the violations of a chapter's rules check, lines
+// of the form `- "quoted text" — ID — why it breaks the rule`, citing the
+// rule each one breaks.
+func parseRulesResult(chapter int, content string, rules []Rule) []FeedbackItem {
+	byID := make(map[string]Rule, len(rules))
+	for _, r := range rules {
+		byID[strings.ToLower(r.ID)] = r
+	}
+
+	var items []FeedbackItem
+	for _, item := range parseLintResult(chapter, content) {
+		item.Source = FeedbackRules
+		id := strings.Trim(item.Comment, "[]* ")
+		rule, ok := byID[strings.ToLower(id)]
+		if !ok {
+			// Not a citation, such as "No issues found."
+			continue
+		}
+		item.Category = rule.ID
+		item.Comment = fmt.Sprintf("breaks %s (%s: %s)", rule.ID, rule.System, rule.Text)
+		if item.Suggestion != "" {
+			item.Comment += ": " + item.Suggestion
+			item.Suggestion = ""
+		}
+		items = append(items, item)
+	}
+	return items
+}
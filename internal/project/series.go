@@ -0,0 +1,299 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// seriesDirName is the directory beside the projects that holds each
+// series' shared world bible.
+const seriesDirName = ".series"
+
+// SeriesMount is the directory under which a book sees its series' files.
+const SeriesMount = "series"
+
+// SeriesCategories are the context directories a series shares.
+var SeriesCategories = []string{"characters", "settings", "glossary"}
+
+// SeriesDir returns the world bible directory of a series.
+func SeriesDir(projectsDir, name string) string {
+	return filepath.Join(projectsDir, seriesDirName, name)
+}
+
+// Series is a group of books sharing a world bible.
+type Series struct {
+	Name string
+	Dir  string
+
+	// Books are the paths of the projects in the series, in name order.
+	Books []string
+}
+
+// OpenSeries returns the series name among the manager's projects.
+func (m *Manager) OpenSeries(name string) (*Series, error) {
+	return openSeries(m.projectsDir, name)
+}
+
+// Series returns the series the project belongs to, or nil.
+func (p *Project) Series() (*Series, error) {
+	if p.Config == nil || p.Config.Series == "" {
+		return nil, nil
+	}
+	return openSeries(filepath.Dir(p.path), p.Config.Series)
+}
+
+func openSeries(projectsDir, name string) (*Series, error) {
+	dir := SeriesDir(projectsDir, name)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("series not found: %s", name)
+	}
+
+	entries, err := os.ReadDir(projectsDir)
+	if err != nil {
+		return nil, err
+	}
+	series := &Series{Name: name, Dir: dir}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(projectsDir, entry.Name())
+		config, err := LoadProjectConfig(path)
+		if err != nil || config.Series != name {
+			continue
+		}
+		series.Books = append(series.Books, path)
+	}
+	return series, nil
+}
+
+// JoinSeries adds the project to a series, creating the series' world
+// bible if it is the first book, and saves the project config.
+func (p *Project) JoinSeries(name string) error {
+	if !isValidName(name) {
+		return ErrInvalidName
+	}
+	if p.Config == nil {
+		return fmt.Errorf("project has no config")
+	}
+
+	dir := SeriesDir(filepath.Dir(p.path), name)
+	for _, category := range SeriesCategories {
+		if err := os.MkdirAll(filepath.Join(dir, category), 0755); err != nil {
+			return fmt.Errorf("failed to create series directory: %w", err)
+		}
+	}
+
+	p.Config.Series = name
+	if err := SaveProjectConfig(p.path, p.Config); err != nil {
+		return err
+	}
+	p.FS.Mount(SeriesMount, dir)
+	return nil
+}
+
+// LeaveSeries removes the project from its series. The shared files stay
+// with the series.
+func (p *Project) LeaveSeries() error {
+	if p.Config == nil || p.Config.Series == "" {
+		return fmt.Errorf("project is not in a series")
+	}
+	p.Config.Series = ""
+	if err := SaveProjectConfig(p.path, p.Config); err != nil {
+		return err
+	}
+	p.FS.Unmount(SeriesMount)
+	return nil
+}
+
+// mountSeries makes the series' world bible visible to the project.
+func (p *Project) mountSeries() {
+	if p.Config != nil && p.Config.Series != "" {
+		p.FS.Mount(SeriesMount, SeriesDir(filepath.Dir(p.path), p.Config.Series))
+	}
+}
+
+// listContext lists a context category's files: the project's own, then
+// those shared by its series.
+// Directories that do not exist, such as a glossary in a project created
+// before glossaries, list nothing, and excluded files are left out.
+func (p *Project) listContext(category string) ([]storage.FileInfo, error) {
+	dirs := []string{filepath.Join("context", category)}
+	if p.Config != nil && p.Config.Series != "" {
+		dirs = append(dirs, filepath.Join(SeriesMount, category))
+	}
+
+	var files []storage.FileInfo
+	for _, dir := range dirs {
+		if !p.FS.Exists(dir) {
+			continue
+		}
+		found, err := p.FS.ListMarkdownFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return p.ExcludeList().Filter(files), nil
+}
+
+// isSeriesFile reports whether path is one of the series' shared files.
+func isSeriesFile(path string) bool {
+	return strings.HasPrefix(filepath.ToSlash(path), SeriesMount+"/")
+}
+
+// SeriesResult is a search result from one book of a series.
+type SeriesResult struct {
+	search.FTSSearchResult
+
+	// Book is the name of the book the result came from, or the series
+	// name for its shared files.
+	Book string
+}
+
+// Search searches every book's index. Shared files, indexed by each book,
+// are listed once.
+func (s *Series) Search(query string, limit int) ([]SeriesResult, error) {
+	var results []SeriesResult
+	seen := make(map[string]bool)
+	for _, path := range s.Books {
+		db, err := storage.NewSQLiteDB(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+		}
+		found, err := search.NewFTSEngine(db).Search(query, limit)
+		db.Close()
+		if err != nil {
+			return nil, fmt.Errorf("search failed in %s: %w", filepath.Base(path), err)
+		}
+
+		for _, r := range found {
+			book := filepath.Base(path)
+			if isSeriesFile(r.SourcePath) {
+				book = s.Name
+				key := r.SourcePath + "\x00" + r.Content
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			results = append(results, SeriesResult{FTSSearchResult: r, Book: book})
+		}
+	}
+
+	// bm25 scores are negative; lower is a better match.
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score < results[j].Score })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// SeriesIssue is an inconsistency between the books of a series.
+type SeriesIssue struct {
+	Book    string
+	Path    string
+	Message string
+}
+
+// Check looks for inconsistencies across the books: characters and
+// settings a book redefines instead of using the series bible, ones
+// defined separately in several books, and misspelled glossary terms.
+func (s *Series) Check() ([]SeriesIssue, error) {
+	var issues []SeriesIssue
+
+	// defined maps a lowercase name to the books defining it locally.
+	defined := make(map[string][]SeriesIssue)
+	var names []string
+	for _, path := range s.Books {
+		book := filepath.Base(path)
+		p, err := openAt(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", book, err)
+		}
+
+		entries, err := p.namedContext()
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		shared := make(map[string]string)
+		for _, e := range entries {
+			if isSeriesFile(e.path) {
+				shared[strings.ToLower(e.name)] = e.path
+			}
+		}
+		for _, e := range entries {
+			if isSeriesFile(e.path) {
+				continue
+			}
+			key := strings.ToLower(e.name)
+			if sharedPath, ok := shared[key]; ok {
+				issues = append(issues, SeriesIssue{Book: book, Path: e.path,
+					Message: fmt.Sprintf("%s is also in the series bible (%s); the book's copy may have drifted", e.name, sharedPath)})
+				continue
+			}
+			if _, ok := defined[key]; !ok {
+				names = append(names, key)
+			}
+			defined[key] = append(defined[key], SeriesIssue{Book: book, Path: e.path, Message: e.name})
+		}
+
+		glossary, err := p.CheckGlossary()
+		p.Close()
+		if err != nil {
+			return nil, fmt.Errorf("glossary check failed in %s: %w", book, err)
+		}
+		for _, g := range glossary {
+			issues = append(issues, SeriesIssue{Book: book, Path: fmt.Sprintf("%s:%d", g.FilePath, g.Line),
+				Message: fmt.Sprintf("%q → did you mean %q?", g.Word, g.Term)})
+		}
+	}
+
+	for _, key := range names {
+		copies := defined[key]
+		if len(copies) < 2 {
+			continue
+		}
+		books := make([]string, len(copies))
+		for i, c := range copies {
+			books[i] = c.Book
+		}
+		issues = append(issues, SeriesIssue{Book: strings.Join(books, ", "), Path: copies[0].Path,
+			Message: fmt.Sprintf("%s is defined separately in %d books; move it to the series bible", copies[0].Message, len(copies))})
+	}
+	return issues, nil
+}
+
+// namedEntry is a character or setting file and its title.
+type namedEntry struct {
+	name string
+	path string
+}
+
+// namedContext lists the project's characters and settings, including
+// the series' shared ones.
+func (p *Project) namedContext() ([]namedEntry, error) {
+	var entries []namedEntry
+	characters, err := p.LoadCharacters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load characters: %w", err)
+	}
+	for _, c := range characters {
+		entries = append(entries, namedEntry{name: c.Name, path: c.FilePath})
+	}
+	settings, err := p.LoadSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	for _, s := range settings {
+		entries = append(entries, namedEntry{name: s.Name, path: s.FilePath})
+	}
+	return entries, nil
+}
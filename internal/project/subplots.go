@@ -0,0 +1,286 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultSubplotGap is how many chapters in a row a subplot may go
+// untouched before the subplot report warns about it.
+const DefaultSubplotGap = 5
+
+// subplotTagPattern matches a subplot tag, an HTML comment that does not
+// show in the rendered chapter: <!-- subplot: romance, heist -->.
+var subplotTagPattern = regexp.MustCompile(`(?i)<!--\s*subplots?\s*:\s*([^>]*?)\s*-->`)
+
+// ParseSubplotTags returns the subplot IDs a chapter's tags name, in the
+// order they first appear. IDs are lowercase.
+func ParseSubplotTags(content string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, m := range subplotTagPattern.FindAllStringSubmatch(content, -1) {
+		for _, id := range strings.Split(m[1], ",") {
+			id = strings.ToLower(strings.TrimSpace(id))
+			if id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// stripSubplotTags removes subplot tags so they are not counted as prose.
+func stripSubplotTags(content string) string {
+	return subplotTagPattern.ReplaceAllString(content, "")
+}
+
+// SubplotCoverage is where one subplot appears in the manuscript.
+type SubplotCoverage struct {
+	ID string
+
+	// Chapters are the chapters that tag the subplot, in order, and
+	// Scenes the number of tags naming it.
+	Chapters []int
+	Scenes   int
+}
+
+// SubplotGap is a run of chapters a subplot goes untouched, with the
+// chapter suggested for weaving it back in.
+type SubplotGap struct {
+	ID string
+
+	// After is the last chapter to touch the subplot before the run, and
+	// From and To are the run's first and last chapters. Open is set when
+	// the subplot has not been touched since.
+	After, From, To int
+	Chapters        int
+	Open            bool
+
+	// Suggest is the chapter in the run carrying the fewest other
+	// subplots, nearest the run's middle.
+	Suggest int
+}
+
+// SubplotReport is the coverage and interleaving of the tagged subplots.
+type SubplotReport struct {
+	// Chapters are the chapter numbers, in order, and Tags the subplots
+	// each one touches.
+	Chapters []int
+	Tags     map[int][]string
+
+	Subplots []SubplotCoverage
+	Gaps     []SubplotGap
+	MinGap   int
+}
+
+// String renders an interleaving chart with one row per subplot, then the
+// long gaps with where to weave each subplot back in.
+func (r *SubplotReport) String() string {
+	if len(r.Subplots) == 0 {
+		return "No subplots tagged yet. Tag a chapter with <!-- subplot: id --> or /subplots tag <id> [chapter]."
+	}
+
+	lines := []string{fmt.Sprintf("Subplots across %d chapters:", len(r.Chapters))}
+	for _, s := range r.Subplots {
+		touched := make(map[int]bool, len(s.Chapters))
+		for _, n := range s.Chapters {
+			touched[n] = true
+		}
+		var row strings.Builder
+		for _, n := range r.Chapters {
+			if touched[n] {
+				row.WriteString("█")
+			} else {
+				row.WriteString("·")
+			}
+		}
+		percent := len(s.Chapters) * 100 / len(r.Chapters)
+		lines = append(lines, fmt.Sprintf("  |%s| %3d%%  %s (%d chapters, %d scenes)", row.String(), percent, s.ID, len(s.Chapters), s.Scenes))
+	}
+	sections := []string{strings.Join(lines, "\n")}
+
+	if len(r.Gaps) > 0 {
+		lines := []string{fmt.Sprintf("Untouched for %d+ chapters:", r.MinGap)}
+		for _, gap := range r.Gaps {
+			if gap.Open {
+				lines = append(lines, fmt.Sprintf("- %s: untouched since chapter %d (%d chapters); weave it into the next chapter or back into chapter %d", gap.ID, gap.After, gap.Chapters, gap.Suggest))
+			} else {
+				lines = append(lines, fmt.Sprintf("- %s: untouched from chapter %d to %d (%d chapters); consider weaving it into chapter %d", gap.ID, gap.From, gap.To, gap.Chapters, gap.Suggest))
+			}
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// SubplotReport reads the subplot tags in every chapter. minGap is how many
+// chapters in a row a subplot must go untouched to be reported; zero or
+// less uses DefaultSubplotGap.
+func (p *Project) SubplotReport(minGap int) (*SubplotReport, error) {
+	if minGap <= 0 {
+		minGap = DefaultSubplotGap
+	}
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+
+	report := &SubplotReport{Tags: make(map[int][]string), MinGap: minGap}
+	coverage := make(map[string]*SubplotCoverage)
+	for _, ch := range chapters {
+		report.Chapters = append(report.Chapters, ch.Number)
+		ids := ParseSubplotTags(ch.Content)
+		report.Tags[ch.Number] = ids
+		for _, id := range ids {
+			c, ok := coverage[id]
+			if !ok {
+				c = &SubplotCoverage{ID: id}
+				coverage[id] = c
+			}
+			c.Chapters = append(c.Chapters, ch.Number)
+		}
+		// Each tag naming a subplot is one scene of it.
+		for _, m := range subplotTagPattern.FindAllStringSubmatch(ch.Content, -1) {
+			for _, id := range strings.Split(m[1], ",") {
+				if c, ok := coverage[strings.ToLower(strings.TrimSpace(id))]; ok {
+					c.Scenes++
+				}
+			}
+		}
+	}
+
+	for _, c := range coverage {
+		report.Subplots = append(report.Subplots, *c)
+	}
+	// Subplots in order of their first chapter.
+	sort.Slice(report.Subplots, func(i, j int) bool {
+		a, b := report.Subplots[i], report.Subplots[j]
+		if a.Chapters[0] != b.Chapters[0] {
+			return a.Chapters[0] < b.Chapters[0]
+		}
+		return a.ID < b.ID
+	})
+	for _, s := range report.Subplots {
+		report.Gaps = append(report.Gaps, report.subplotGaps(s)...)
+	}
+	return report, nil
+}
+
+// subplotGaps finds the runs of at least MinGap chapters a subplot goes
+// untouched after it starts.
+func (r *SubplotReport) subplotGaps(s SubplotCoverage) []SubplotGap {
+	touched := make(map[int]bool, len(s.Chapters))
+	for _, n := range s.Chapters {
+		touched[n] = true
+	}
+
+	var gaps []SubplotGap
+	last := 0
+	var run []int
+	flush := func(open bool) {
+		if len(run) >= r.MinGap {
+			gaps = append(gaps, SubplotGap{ID: s.ID, After: last, From: run[0], To: run[len(run)-1],
+				Chapters: len(run), Open: open, Suggest: r.leastCrowded(run)})
+		}
+		run = nil
+	}
+	for _, n := range r.Chapters {
+		switch {
+		case touched[n]:
+			flush(false)
+			last = n
+		case last > 0:
+			run = append(run, n)
+		}
+	}
+	flush(true)
+	return gaps
+}
+
+// leastCrowded returns the chapter of run with the fewest subplots,
+// preferring the one nearest the middle.
+func (r *SubplotReport) leastCrowded(run []int) int {
+	mid := len(run) / 2
+	best := -1
+	for offset := 0; offset <= mid+1; offset++ {
+		for _, i := range []int{mid - offset, mid + offset} {
+			if i < 0 || i >= len(run) {
+				continue
+			}
+			if best < 0 || len(r.Tags[run[i]]) < len(r.Tags[run[best]]) {
+				best = i
+			}
+		}
+	}
+	return run[best]
+}
+
+// TagSubplot tags a chapter with a subplot, adding a tag under its title.
+// It does nothing when the chapter already touches the subplot.
+func (p *Project) TagSubplot(chapter int, id string) error {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" || strings.ContainsAny(id, ",>") {
+		return fmt.Errorf("invalid subplot id: %q", id)
+	}
+	ch, err := p.FindChapter(chapter)
+	if err != nil {
+		return err
+	}
+	for _, tagged := range ParseSubplotTags(ch.Content) {
+		if tagged == id {
+			return nil
+		}
+	}
+
+	tag := fmt.Sprintf("<!-- subplot: %s -->", id)
+	lines := strings.Split(ch.Content, "\n")
+	at := 0
+	if len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "# ") {
+		at = 1
+	}
+	lines = append(lines[:at], append([]string{tag}, lines[at:]...)...)
+	if err := p.FS.WriteMarkdown(ch.FilePath, strings.Join(lines, "\n")); err != nil {
+		return fmt.Errorf("failed to write chapter: %w", err)
+	}
+	return nil
+}
+
+// UntagSubplot removes a subplot from a chapter's tags, dropping tags left
+// empty.
+func (p *Project) UntagSubplot(chapter int, id string) error {
+	id = strings.ToLower(strings.TrimSpace(id))
+	ch, err := p.FindChapter(chapter)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	content := subplotTagPattern.ReplaceAllStringFunc(ch.Content, func(tag string) string {
+		var kept []string
+		for _, tagged := range strings.Split(subplotTagPattern.FindStringSubmatch(tag)[1], ",") {
+			if strings.ToLower(strings.TrimSpace(tagged)) == id {
+				found = true
+				continue
+			}
+			if t := strings.TrimSpace(tagged); t != "" {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("<!-- subplot: %s -->", strings.Join(kept, ", "))
+	})
+	if !found {
+		return fmt.Errorf("chapter %d is not tagged with %s", chapter, id)
+	}
+	// A tag alone on its line leaves an empty line behind.
+	content = strings.ReplaceAll(content, "\n\n\n", "\n\n")
+	if err := p.FS.WriteMarkdown(ch.FilePath, content); err != nil {
+		return fmt.Errorf("failed to write chapter: %w", err)
+	}
+	return nil
+}
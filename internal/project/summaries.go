@@ -0,0 +1,92 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// ContextSummary is what the essential context lists for one context file:
+// its title and its first line.
+type ContextSummary struct {
+	Title     string
+	FirstLine string
+	FilePath  string
+}
+
+// summaryCache remembers the summary of each context file along with the
+// modification time and size it was read at, so only files that changed
+// since are read again. It is safe for concurrent use, since prompts are
+// assembled off the UI goroutine.
+type summaryCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedSummary
+}
+
+// cachedSummary is a summary and the version of the file it was read from.
+type cachedSummary struct {
+	modTime time.Time
+	size    int64
+	summary ContextSummary
+}
+
+// ContextSummaries returns the summary of every file in a context category
+// (characters, settings or plot), in the order the Load functions return
+// them. Files unchanged since the last call are not read again.
+func (p *Project) ContextSummaries(category string) ([]ContextSummary, error) {
+	var files []storage.FileInfo
+	var err error
+	if category == "plot" {
+		files, err = p.FS.ListMarkdownFiles(filepath.Join("context", category))
+	} else {
+		files, err = p.listContext(category)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", category, err)
+	}
+
+	p.summaries.mu.Lock()
+	defer p.summaries.mu.Unlock()
+	if p.summaries.entries == nil {
+		p.summaries.entries = make(map[string]cachedSummary)
+	}
+
+	summaries := make([]ContextSummary, 0, len(files))
+	for _, file := range files {
+		cached, ok := p.summaries.entries[file.Path]
+		if !ok || !cached.modTime.Equal(file.ModTime) || cached.size != file.Size {
+			content, err := p.FS.ReadMarkdown(file.Path)
+			if err != nil {
+				continue
+			}
+			cached = cachedSummary{modTime: file.ModTime, size: file.Size, summary: p.summarize(file.Path, content)}
+			p.summaries.entries[file.Path] = cached
+		}
+		summaries = append(summaries, cached.summary)
+	}
+	return summaries, nil
+}
+
+// ForgetContextSummaries drops the cached summaries of the given files, for
+// changes the modification time may not show.
+func (p *Project) ForgetContextSummaries(paths []string) {
+	p.summaries.mu.Lock()
+	defer p.summaries.mu.Unlock()
+	for _, path := range paths {
+		delete(p.summaries.entries, path)
+	}
+}
+
+// summarize reads the title and first line of a context file.
+func (p *Project) summarize(path, content string) ContextSummary {
+	title := p.FS.ParseMarkdownTitle(content)
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(path), ".md")
+	}
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	return ContextSummary{Title: title, FirstLine: firstLine, FilePath: path}
+}
@@ -0,0 +1,82 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/prose"
+)
+
+// TypographyLanguage returns the language whose typography rules apply to
+// the manuscript: the project's language, or English.
+func (p *Project) TypographyLanguage() string {
+	if p.Config != nil && p.Config.Language != "" {
+		return p.Config.Language
+	}
+	return "en"
+}
+
+// FixTypography normalizes the punctuation of the given chapters, or of
+// every chapter when numbers is empty, with the typography rules of lang
+// (the project's language when empty). It returns the numbers of the
+// chapters that changed; with dryRun nothing is written.
+func (p *Project) FixTypography(numbers []int, lang string, dryRun bool) ([]int, error) {
+	if lang == "" {
+		lang = p.TypographyLanguage()
+	}
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	wanted := make(map[int]bool, len(numbers))
+	for _, n := range numbers {
+		wanted[n] = true
+	}
+
+	var changed []int
+	for _, ch := range chapters {
+		if len(wanted) > 0 && !wanted[ch.Number] {
+			continue
+		}
+		fixed := prose.NormalizeTypography(ch.Content, lang)
+		if fixed == ch.Content {
+			continue
+		}
+		changed = append(changed, ch.Number)
+		if dryRun {
+			continue
+		}
+		if err := p.FS.WriteMarkdown(ch.FilePath, fixed); err != nil {
+			return changed, fmt.Errorf("failed to write chapter %d: %w", ch.Number, err)
+		}
+	}
+	return changed, nil
+}
+
+// ExportText writes the manuscript to manuscript.txt under ExportDir,
+// with subplot tags removed and the typography of lang (the project's
+// language when empty) applied, and returns its path.
+func (p *Project) ExportText(lang string) (string, error) {
+	if lang == "" {
+		lang = p.TypographyLanguage()
+	}
+	chapters, err := p.LoadChapters()
+	if err != nil {
+		return "", fmt.Errorf("failed to load chapters: %w", err)
+	}
+	if len(chapters) == 0 {
+		return "", fmt.Errorf("no chapters to export")
+	}
+
+	parts := make([]string, 0, len(chapters))
+	for _, ch := range chapters {
+		parts = append(parts, strings.TrimSpace(prose.NormalizeTypography(stripSubplotTags(ch.Content), lang)))
+	}
+
+	path := filepath.Join(ExportDir, "manuscript.txt")
+	if err := p.FS.WriteMarkdown(path, strings.Join(parts, "\n\n\n")+"\n"); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,216 @@
+package prose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultCrutchWords are filler words and phrases that weaken prose when
+// overused.
+var DefaultCrutchWords = []string{
+	"suddenly", "just", "really", "very", "actually", "quite", "somehow",
+	"literally", "basically", "simply", "seemed", "began to", "started to",
+	"couldn't help but", "in order to", "for a moment",
+	"갑자기", "그냥", "정말", "진짜", "약간", "조금", "문득", "왠지", "살짝", "순간",
+}
+
+// Phrases must recur this often to be reported, and at most this many are.
+const (
+	phraseLength   = 3
+	minPhraseCount = 3
+	maxPhrases     = 10
+)
+
+// phraseStopWords cannot make up a repeated phrase on their own.
+var phraseStopWords = wordSet("the", "a", "an", "and", "or", "but", "of", "to", "in", "on", "at",
+	"it", "was", "is", "he", "she", "they", "i", "his", "her", "that", "with", "for", "as", "had")
+
+// WordUsage counts a word or phrase across chapters.
+type WordUsage struct {
+	Word  string
+	Total int
+
+	// PerChapter holds the count in each chapter, in chapter order.
+	PerChapter []int
+}
+
+// FrequencyReport is the overused words and phrases in a set of chapters.
+type FrequencyReport struct {
+	Words int
+
+	// Crutch lists the crutch words that occur, most frequent first.
+	Crutch []WordUsage
+
+	// Phrases lists the most repeated phrases, such as character tics.
+	Phrases []WordUsage
+}
+
+// AnalyzeFrequency counts crutch words and repeated phrases in chapters.
+func AnalyzeFrequency(chapters []string, crutch []string) FrequencyReport {
+	tokenized := make([][]string, len(chapters))
+	var report FrequencyReport
+	for i, text := range chapters {
+		tokenized[i] = splitWords(text)
+		report.Words += len(tokenized[i])
+	}
+
+	seen := make(map[string]bool)
+	for _, word := range crutch {
+		target := splitWords(word)
+		key := strings.Join(target, " ")
+		if len(target) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		usage := WordUsage{Word: key, PerChapter: make([]int, len(chapters))}
+		for i, tokens := range tokenized {
+			usage.PerChapter[i] = countSequence(tokens, target)
+			usage.Total += usage.PerChapter[i]
+		}
+		if usage.Total > 0 {
+			report.Crutch = append(report.Crutch, usage)
+		}
+	}
+	sortUsage(report.Crutch)
+
+	phrases := make(map[string]*WordUsage)
+	for i, tokens := range tokenized {
+		for j := 0; j+phraseLength <= len(tokens); j++ {
+			gram := tokens[j : j+phraseLength]
+			if allStopWords(gram) {
+				continue
+			}
+			key := strings.Join(gram, " ")
+			usage, ok := phrases[key]
+			if !ok {
+				usage = &WordUsage{Word: key, PerChapter: make([]int, len(chapters))}
+				phrases[key] = usage
+			}
+			usage.PerChapter[i]++
+			usage.Total++
+		}
+	}
+	for _, usage := range phrases {
+		if usage.Total >= minPhraseCount {
+			report.Phrases = append(report.Phrases, *usage)
+		}
+	}
+	sortUsage(report.Phrases)
+	if len(report.Phrases) > maxPhrases {
+		report.Phrases = report.Phrases[:maxPhrases]
+	}
+	return report
+}
+
+// TopOffenders returns up to n of the most frequent crutch words and
+// phrases, crutch words first.
+func (r FrequencyReport) TopOffenders(n int) []string {
+	var words []string
+	for _, list := range [][]WordUsage{r.Crutch, r.Phrases} {
+		for _, usage := range list {
+			if len(words) == n {
+				return words
+			}
+			words = append(words, usage.Word)
+		}
+	}
+	return words
+}
+
+// String renders the report as a table: each word's total, a heatmap with
+// one cell per chapter, then the word. The word comes last so wide scripts
+// do not break the columns.
+func (r FrequencyReport) String() string {
+	if len(r.Crutch) == 0 && len(r.Phrases) == 0 {
+		return fmt.Sprintf("No crutch words or repeated phrases found in %d words.", r.Words)
+	}
+
+	var sb strings.Builder
+	for _, section := range []struct {
+		title  string
+		usages []WordUsage
+	}{{"Crutch words", r.Crutch}, {"Repeated phrases", r.Phrases}} {
+		if len(section.usages) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(section.title + " (count, per chapter):\n")
+		for _, usage := range section.usages {
+			sb.WriteString(fmt.Sprintf("  %5d |%s| %s\n", usage.Total, Heatmap(usage.PerChapter), usage.Word))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// heatLevels shade a heatmap cell from unused to the busiest chapter.
+var heatLevels = []rune(" ▁▂▃▄▅▆▇█")
+
+// Heatmap renders counts as one cell per chapter, scaled to the largest.
+func Heatmap(counts []int) string {
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+
+	cells := make([]rune, len(counts))
+	for i, c := range counts {
+		level := 0
+		if c > 0 {
+			level = 1 + c*(len(heatLevels)-2)/max
+		}
+		cells[i] = heatLevels[level]
+	}
+	return string(cells)
+}
+
+// splitWords splits text into lowercase words, keeping apostrophes inside
+// words (couldn't, Hana's).
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(strings.ReplaceAll(text, "’", "'")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+}
+
+// countSequence counts the occurrences of target in tokens.
+func countSequence(tokens, target []string) int {
+	count := 0
+	for i := 0; i+len(target) <= len(tokens); i++ {
+		match := true
+		for j, word := range target {
+			if tokens[i+j] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			count++
+		}
+	}
+	return count
+}
+
+func allStopWords(words []string) bool {
+	for _, w := range words {
+		if !phraseStopWords[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortUsage orders usages by count, then alphabetically.
+func sortUsage(usages []WordUsage) {
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Total != usages[j].Total {
+			return usages[i].Total > usages[j].Total
+		}
+		return usages[i].Word < usages[j].Word
+	})
+}
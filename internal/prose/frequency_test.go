@@ -0,0 +1,50 @@
+package prose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeFrequency(t *testing.T) {
+	chapters := []string{
+		"She just smiled. He just left. Suddenly the door opened, and she tucked her hair behind her ear.",
+		"It was very quiet. She tucked her hair behind her ear again.",
+		"갑자기 비가 왔다. She tucked her hair behind her ear and began to run.",
+	}
+
+	report := AnalyzeFrequency(chapters, append(DefaultCrutchWords, "Just"))
+
+	require.NotEmpty(t, report.Crutch)
+	assert.Equal(t, WordUsage{Word: "just", Total: 2, PerChapter: []int{2, 0, 0}}, report.Crutch[0], "the busiest word comes first and duplicates are counted once")
+	words := make([]string, len(report.Crutch))
+	for i, usage := range report.Crutch {
+		words[i] = usage.Word
+	}
+	assert.ElementsMatch(t, []string{"just", "suddenly", "very", "began to", "갑자기"}, words)
+
+	require.NotEmpty(t, report.Phrases)
+	assert.Equal(t, "behind her ear", report.Phrases[0].Word)
+	assert.Equal(t, []int{1, 1, 1}, report.Phrases[0].PerChapter)
+	for _, usage := range report.Phrases {
+		assert.GreaterOrEqual(t, usage.Total, minPhraseCount)
+	}
+
+	assert.Equal(t, []string{"just", "began to"}, report.TopOffenders(2))
+	assert.Contains(t, report.String(), "|█  | just")
+}
+
+func TestAnalyzeFrequency_NothingFound(t *testing.T) {
+	report := AnalyzeFrequency([]string{"The tide came in."}, DefaultCrutchWords)
+	assert.Empty(t, report.Crutch)
+	assert.Empty(t, report.Phrases)
+	assert.Equal(t, 4, report.Words)
+	assert.Contains(t, report.String(), "No crutch words")
+}
+
+func TestHeatmap(t *testing.T) {
+	assert.Equal(t, " ▄█", Heatmap([]int{0, 4, 8}))
+	assert.Equal(t, "▁█", Heatmap([]int{1, 100}))
+	assert.Equal(t, "  ", Heatmap([]int{0, 0}))
+}
@@ -0,0 +1,151 @@
+package prose
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Misspelling is a word that nearly matches a glossary term.
+type Misspelling struct {
+	Word string
+	Term string
+	Line int
+}
+
+// Very short terms are skipped: their near matches are mostly ordinary words.
+const (
+	minTermRunes       = 4
+	minHangulTermRunes = 3
+)
+
+// CheckGlossary flags words in text that are close to, but not exactly, one
+// of the invented terms. Latin-script words are only compared when
+// capitalized, since invented names usually are; Hangul words are compared
+// with trailing particles allowed (e.g. 아스란이 for 아스란).
+func CheckGlossary(text string, terms []string) []Misspelling {
+	var candidates []string
+	known := make(map[string]bool)
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		known[strings.ToLower(term)] = true
+		runes := []rune(term)
+		minRunes := minTermRunes
+		if isHangul(runes[0]) {
+			minRunes = minHangulTermRunes
+		}
+		if len(runes) >= minRunes && !strings.ContainsRune(term, ' ') {
+			candidates = append(candidates, term)
+		}
+	}
+
+	var found []Misspelling
+	for i, line := range strings.Split(text, "\n") {
+		for _, field := range strings.Fields(line) {
+			word := strings.TrimFunc(field, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '-'
+			})
+			word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "’s")
+			if word == "" || known[strings.ToLower(word)] {
+				continue
+			}
+			if term, ok := nearestTerm(word, candidates, known); ok {
+				found = append(found, Misspelling{Word: word, Term: term, Line: i + 1})
+			}
+		}
+	}
+	return found
+}
+
+// nearestTerm returns the term word is a likely misspelling of.
+func nearestTerm(word string, terms []string, known map[string]bool) (string, bool) {
+	runes := []rune(word)
+	hangul := isHangul(runes[0])
+	if !hangul && !unicode.IsUpper(runes[0]) {
+		return "", false
+	}
+
+	for _, term := range terms {
+		termRunes := []rune(term)
+		if isHangul(termRunes[0]) != hangul {
+			continue
+		}
+
+		maxDist := 1
+		if len(termRunes) >= 8 {
+			maxDist = 2
+		}
+
+		if !hangul {
+			d := editDistance(strings.ToLower(word), strings.ToLower(term))
+			if d > 0 && d <= maxDist {
+				return term, true
+			}
+			continue
+		}
+
+		// Hangul: the word is the term, optionally followed by a particle.
+		if strings.HasPrefix(word, term) {
+			continue
+		}
+		for _, stem := range hangulStems(runes) {
+			if known[stem] {
+				break
+			}
+			if d := editDistance(stem, term); d > 0 && d <= maxDist {
+				return term, true
+			}
+		}
+	}
+	return "", false
+}
+
+// koreanParticles are suffixes stripped from Hangul words before comparison.
+var koreanParticles = []string{"에게서", "으로", "에게", "에서", "이랑", "이다", "이", "가", "은", "는", "을", "를", "의", "에", "와", "과", "도", "로", "만", "랑"}
+
+// hangulStems returns word and, for each particle it ends with, word without
+// that particle.
+func hangulStems(word []rune) []string {
+	s := string(word)
+	stems := []string{s}
+	for _, particle := range koreanParticles {
+		if stem := strings.TrimSuffix(s, particle); stem != s && stem != "" {
+			stems = append(stems, stem)
+		}
+	}
+	return stems
+}
+
+func isHangul(r rune) bool {
+	return r >= hangulBase && r <= hangulLast
+}
+
+// editDistance is the edit distance between a and b in runes, counting an
+// adjacent transposition (a common typo) as one edit.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	d := make([][]int, len(ar)+1)
+	for i := range d {
+		d[i] = make([]int, len(br)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ar)][len(br)]
+}
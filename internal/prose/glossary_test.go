@@ -0,0 +1,57 @@
+package prose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckGlossary(t *testing.T) {
+	terms := []string{"Aethermoor", "Vashki", "아스란", "Silver Reach", "Io"}
+
+	tests := []struct {
+		name string
+		text string
+		want []Misspelling
+	}{
+		{
+			name: "exact spellings pass",
+			text: "The ships of Aethermoor's fleet reached Vashki. 아스란이 웃었다. Io slept in the Silver Reach.",
+		},
+		{
+			name: "flags near misses with line numbers",
+			text: "Dawn over Aethermor.\nThe Vaskhi envoy arrived.",
+			want: []Misspelling{
+				{Word: "Aethermor", Term: "Aethermoor", Line: 1},
+				{Word: "Vaskhi", Term: "Vashki", Line: 2},
+			},
+		},
+		{
+			name: "hangul with particles",
+			text: "아슬란이 검을 들었다.",
+			want: []Misspelling{{Word: "아슬란이", Term: "아스란", Line: 1}},
+		},
+		{
+			name: "lowercase words are ordinary vocabulary",
+			text: "the vashki and vaski were in the market",
+		},
+		{
+			name: "short and multi-word terms are not spell-checked",
+			text: "Ia walked along the Silver Beach.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CheckGlossary(tt.text, terms))
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("abc", "abc"))
+	assert.Equal(t, 1, editDistance("Vashki", "Vaski"))
+	assert.Equal(t, 1, editDistance("Vashki", "Vaskhi"))
+	assert.Equal(t, 2, editDistance("Vashki", "Vaxhka"))
+	assert.Equal(t, 1, editDistance("아스란", "아슬란"))
+}
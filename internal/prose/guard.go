@@ -0,0 +1,244 @@
+// Package prose provides lightweight checks on generated prose.
+package prose
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// IssueKind identifies what a narration check flagged.
+type IssueKind string
+
+const (
+	IssuePOV   IssueKind = "pov"
+	IssueTense IssueKind = "tense"
+)
+
+// Issue describes a deviation from the project's configured POV or tense.
+type Issue struct {
+	Kind     IssueKind
+	Message  string
+	Evidence []string
+}
+
+// Thresholds that keep the checker quiet on short or ambiguous replies.
+const (
+	minNarrationWords = 40
+	minDeviations     = 3
+	maxEvidence       = 3
+)
+
+var (
+	// quotedRE matches dialogue, which may use any POV or tense.
+	quotedRE = regexp.MustCompile(`"[^"\n]*"|“[^”\n]*”|‘[^’\n]*’|「[^」\n]*」|『[^』\n]*』`)
+
+	firstPersonWords = wordSet("i", "me", "my", "mine", "myself", "we", "us", "our", "ours", "ourselves",
+		"나는", "내가", "나를", "나의", "나도", "내", "우리는", "우리가", "우리를", "우리의", "저는", "제가")
+	thirdPersonWords = wordSet("he", "she", "him", "her", "his", "hers", "himself", "herself",
+		"그는", "그가", "그를", "그의", "그녀는", "그녀가", "그녀를", "그녀의")
+
+	pastWords    = wordSet("was", "were", "had", "did", "said", "thought", "went", "looked", "felt", "knew")
+	presentWords = wordSet("is", "are", "am", "has", "does", "says", "thinks", "goes", "looks", "feels", "knows")
+)
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// CheckNarration flags narration in text that deviates from the configured
+// POV and tense. Dialogue and short framing lines (e.g. "Here's the scene:")
+// are ignored, and nothing is flagged for replies too short to judge.
+func CheckNarration(text string, writing types.WritingConfig) []Issue {
+	narration := extractNarration(text)
+	words := tokenize(narration)
+	if len(words) < minNarrationWords {
+		return nil
+	}
+
+	var issues []Issue
+	if issue := checkPOV(words, writing.POV); issue != nil {
+		issues = append(issues, *issue)
+	}
+	if issue := checkTense(narration, words, writing.Tense); issue != nil {
+		issues = append(issues, *issue)
+	}
+	return issues
+}
+
+// extractNarration drops dialogue and framing paragraphs from text.
+func extractNarration(text string) string {
+	var paragraphs []string
+	for _, p := range strings.Split(text, "\n") {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.HasSuffix(p, ":") || strings.HasPrefix(p, "#") ||
+			strings.HasPrefix(p, "- ") || strings.HasPrefix(p, "* ") || strings.HasPrefix(p, "```") {
+			continue
+		}
+		p = strings.TrimSpace(quotedRE.ReplaceAllString(p, " "))
+		if p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// tokenize splits text into lowercase words without surrounding punctuation.
+func tokenize(text string) []string {
+	fields := strings.Fields(text)
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		w := strings.ToLower(strings.TrimFunc(f, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}))
+		if w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func checkPOV(words []string, pov string) *Issue {
+	first := matches(words, firstPersonWords)
+	switch {
+	case strings.HasPrefix(pov, "third"):
+		if len(first) >= minDeviations {
+			return &Issue{
+				Kind:     IssuePOV,
+				Message:  "first-person narration in a third-person book",
+				Evidence: evidence(first),
+			}
+		}
+	case strings.HasPrefix(pov, "first"):
+		third := matches(words, thirdPersonWords)
+		if len(first) == 0 && len(third) >= minDeviations {
+			return &Issue{
+				Kind:     IssuePOV,
+				Message:  "third-person narration in a first-person book",
+				Evidence: evidence(third),
+			}
+		}
+	}
+	return nil
+}
+
+func checkTense(narration string, words []string, tense string) *Issue {
+	past := matches(words, pastWords)
+	present := matches(words, presentWords)
+
+	// Korean narration carries tense in the sentence ending.
+	koPast, koPresent := koreanSentenceTenses(narration)
+	past = append(past, koPast...)
+	present = append(present, koPresent...)
+
+	switch tense {
+	case "past":
+		if len(present) >= minDeviations && len(present) > len(past) {
+			return &Issue{
+				Kind:     IssueTense,
+				Message:  "present-tense narration in a past-tense book",
+				Evidence: evidence(present),
+			}
+		}
+	case "present":
+		if len(past) >= minDeviations && len(past) > len(present) {
+			return &Issue{
+				Kind:     IssueTense,
+				Message:  "past-tense narration in a present-tense book",
+				Evidence: evidence(past),
+			}
+		}
+	}
+	return nil
+}
+
+// Hangul syllable layout: final consonant index within a syllable block.
+const (
+	hangulBase   = 0xAC00
+	hangulLast   = 0xD7A3
+	finalCount   = 28
+	finalNieun   = 4  // ㄴ
+	finalSsangS  = 20 // ㅆ
+	sentenceEnds = ".!?…"
+)
+
+// koreanSentenceTenses classifies sentences ending in "다" as past (ㅆ before
+// 다, e.g. 걸었다) or present (ㄴ/는다, e.g. 걷는다, 간다). The returned slices
+// hold the sentence endings used as evidence.
+func koreanSentenceTenses(text string) (past, present []string) {
+	sentences := strings.FieldsFunc(text, func(r rune) bool {
+		return strings.ContainsRune(sentenceEnds, r) || r == '\n'
+	})
+	for _, s := range sentences {
+		runes := []rune(strings.TrimSpace(s))
+		n := len(runes)
+		if n < 2 || runes[n-1] != '다' {
+			continue
+		}
+		prev := runes[n-2]
+		if prev < hangulBase || prev > hangulLast {
+			continue
+		}
+
+		ending := string(runes[max(0, n-4):])
+		switch (prev - hangulBase) % finalCount {
+		case finalSsangS:
+			// 있다 describes a state, not a past event.
+			if prev != '있' {
+				past = append(past, ending)
+			}
+		case finalNieun:
+			present = append(present, ending)
+		default:
+			if prev == '는' {
+				present = append(present, ending)
+			}
+		}
+	}
+	return past, present
+}
+
+func matches(words []string, set map[string]bool) []string {
+	var found []string
+	for _, w := range words {
+		if set[w] {
+			found = append(found, w)
+		}
+	}
+	return found
+}
+
+func evidence(found []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, f := range found {
+		if !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+		if len(out) == maxEvidence {
+			break
+		}
+	}
+	return out
+}
+
+// FixInstruction builds the prompt asking the model to rewrite its reply to
+// resolve the given issues.
+func FixInstruction(issues []Issue, writing types.WritingConfig) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Rewrite your previous reply so the narration consistently uses %s point of view and %s tense.", writing.POV, writing.Tense))
+	sb.WriteString(" Problems found:")
+	for _, issue := range issues {
+		sb.WriteString(fmt.Sprintf("\n- %s (e.g. %s)", issue.Message, strings.Join(issue.Evidence, ", ")))
+	}
+	sb.WriteString("\nKeep the events, dialogue, and length the same. Reply with the revised prose only.")
+	return sb.String()
+}
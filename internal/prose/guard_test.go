@@ -0,0 +1,91 @@
+package prose
+
+import (
+	"testing"
+
+	"github.com/azyu/dreamteller/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var thirdPast = types.WritingConfig{POV: "third-person-limited", Tense: "past"}
+
+func TestCheckNarration(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		writing types.WritingConfig
+		want    []IssueKind
+	}{
+		{
+			name: "consistent third-person past",
+			text: "Mira walked to the end of the pier. She was tired, and the wind had teeth. " +
+				"\"I am not going back,\" she said. The boats knocked together in the dark water while " +
+				"she thought about her brother and the debts he had left behind, and the lamps were out.",
+			writing: thirdPast,
+		},
+		{
+			name: "first-person drift in third-person book",
+			text: "Mira walked to the end of the pier. I was tired, and the wind had teeth. " +
+				"My hands were numb. The boats knocked together in the dark water while I thought " +
+				"about my brother and the debts he had left behind, and the lamps along the harbor were out.",
+			writing: thirdPast,
+			want:    []IssueKind{IssuePOV},
+		},
+		{
+			name: "present-tense drift in past-tense book",
+			text: "Mira walks to the end of the pier. She is tired, and the wind has teeth. " +
+				"Her hands are numb. The boats knock together in the dark water while she thinks " +
+				"about her brother and the debts he left behind, and the lamps along the harbor are out.",
+			writing: thirdPast,
+			want:    []IssueKind{IssueTense},
+		},
+		{
+			name: "third-person drift in first-person book",
+			text: "Mira walked to the end of the pier. She was tired, and the wind had teeth. " +
+				"Her hands were numb. The boats knocked together in the dark water while she thought " +
+				"about her brother and the debts he had left behind, and the lamps along the harbor were out.",
+			writing: types.WritingConfig{POV: "first-person", Tense: "past"},
+			want:    []IssueKind{IssuePOV},
+		},
+		{
+			name:    "too short to judge",
+			text:    "I am here. I am tired. We are lost.",
+			writing: thirdPast,
+		},
+		{
+			name: "korean present endings in past-tense book",
+			text: "미라는 부두 끝까지 걷는다. 바람이 차갑게 분다. 배들이 어두운 물 위에서 서로 부딪친다. " +
+				"그녀는 오빠가 남긴 빚을 생각한다. 항구의 등불은 모두 꺼져 있다. 멀리서 종이 울린다. " +
+				"그녀는 젖은 밧줄을 손에 감고 천천히 숨을 고른다. 물때가 바뀌는 소리가 들린다. " +
+				"선착장 끝의 낡은 배 한 척이 바람에 흔들리며 삐걱거린다. 그녀는 아무 말도 하지 않는다.",
+			writing: thirdPast,
+			want:    []IssueKind{IssueTense},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []IssueKind
+			for _, issue := range CheckNarration(tt.text, tt.writing) {
+				kinds = append(kinds, issue.Kind)
+				assert.NotEmpty(t, issue.Evidence)
+			}
+			assert.Equal(t, tt.want, kinds)
+		})
+	}
+}
+
+func TestExtractNarration_DropsDialogueAndFraming(t *testing.T) {
+	text := "Here's the scene:\n\n\"I won't,\" she said.\n# Chapter 2\n- note"
+	assert.Equal(t, "she said.", extractNarration(text))
+}
+
+func TestFixInstruction(t *testing.T) {
+	issues := []Issue{{Kind: IssuePOV, Message: "first-person narration in a third-person book", Evidence: []string{"i", "my"}}}
+
+	instruction := FixInstruction(issues, thirdPast)
+	require.Contains(t, instruction, "third-person-limited")
+	require.Contains(t, instruction, "past tense")
+	require.Contains(t, instruction, "first-person narration in a third-person book (e.g. i, my)")
+}
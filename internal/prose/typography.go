@@ -0,0 +1,252 @@
+package prose
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Non-breaking spaces used by French punctuation: a narrow one before ; ! and
+// ?, a full one inside guillemets and before :.
+const (
+	nbsp       = "\u00a0"
+	narrowNbsp = "\u202f"
+)
+
+// typography is the punctuation of one language.
+type typography struct {
+	openDouble, closeDouble string
+	openSingle, closeSingle string
+	apostrophe              string
+	ellipsis                string
+	dash                    string
+
+	// french puts non-breaking spaces inside guillemets and before
+	// high punctuation.
+	french bool
+
+	// korean drops stray spaces before sentence punctuation and replaces
+	// the Hangul letter ㆍ misused as a middle dot.
+	korean bool
+
+	// japanese uses full-width ！ and ？ after Japanese text, followed by
+	// a full-width space when the sentence goes on.
+	japanese bool
+}
+
+var typographies = map[string]typography{
+	"en": {openDouble: "“", closeDouble: "”", openSingle: "‘", closeSingle: "’", apostrophe: "’", ellipsis: "…", dash: "—"},
+	"fr": {openDouble: "«" + nbsp, closeDouble: nbsp + "»", openSingle: "“", closeSingle: "”", apostrophe: "’", ellipsis: "…", dash: "—", french: true},
+	"ko": {openDouble: "“", closeDouble: "”", openSingle: "‘", closeSingle: "’", apostrophe: "’", ellipsis: "…", dash: "—", korean: true},
+	"ja": {openDouble: "「", closeDouble: "」", openSingle: "『", closeSingle: "』", apostrophe: "’", ellipsis: "……", dash: "――", japanese: true},
+}
+
+// TypographyLanguages are the languages with their own typography rules.
+var TypographyLanguages = []string{"en", "fr", "ko", "ja"}
+
+// NormalizeTypography replaces typewriter punctuation in Markdown prose with
+// the typographic punctuation of lang: curly quotes (guillemets in French,
+// corner brackets in Japanese), em dashes for -- and ellipses for ...,
+// plus each language's spacing rules. Languages without rules of their own
+// get the English ones. Code, HTML comments, link targets and horizontal
+// rules are left alone.
+func NormalizeTypography(text, lang string) string {
+	t, ok := typographies[strings.ToLower(lang)]
+	if !ok {
+		t = typographies["en"]
+	}
+
+	lines := strings.Split(text, "\n")
+	fence := ""
+	inComment := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if isRuleLine(trimmed) {
+			continue
+		}
+		lines[i], inComment = t.normalizeLine(line, inComment)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isRuleLine reports whether a line is a horizontal rule or a table
+// separator, whose dashes are markup.
+func isRuleLine(line string) bool {
+	if !strings.Contains(line, "--") {
+		return false
+	}
+	return strings.Trim(line, "-|: *_") == ""
+}
+
+// normalizeLine normalizes one line, given whether it starts inside an HTML
+// comment, and reports whether it ends inside one.
+func (t typography) normalizeLine(line string, inComment bool) (string, bool) {
+	runes := []rune(line)
+	var sb strings.Builder
+	var prev rune
+	emit := func(s string) {
+		sb.WriteString(s)
+		if s != "" {
+			r := []rune(s)
+			prev = r[len(r)-1]
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		if inComment {
+			end := strings.Index(string(runes[i:]), "-->")
+			if end < 0 {
+				emit(string(runes[i:]))
+				return sb.String(), true
+			}
+			n := len([]rune(string(runes[i:])[:end])) + 3
+			emit(string(runes[i : i+n]))
+			i += n - 1
+			inComment = false
+			continue
+		}
+
+		r := runes[i]
+		switch {
+		case hasRunesAt(runes, i, "<!--"):
+			inComment = true
+			emit("<!--")
+			i += 3
+		case r == '`':
+			j := i
+			for j < len(runes) && runes[j] == '`' {
+				j++
+			}
+			ticks := string(runes[i:j])
+			end := strings.Index(string(runes[j:]), ticks)
+			if end < 0 {
+				emit(ticks)
+				i = j - 1
+				continue
+			}
+			n := len([]rune(string(runes[j:])[:end]))
+			emit(string(runes[i : j+n+len(ticks)]))
+			i = j + n + len(ticks) - 1
+		case r == ']' && i+1 < len(runes) && runes[i+1] == '(':
+			end := strings.IndexRune(string(runes[i:]), ')')
+			if end < 0 {
+				emit("]")
+				continue
+			}
+			n := len([]rune(string(runes[i:])[:end])) + 1
+			emit(string(runes[i : i+n]))
+			i += n - 1
+		case r == '"':
+			if opensQuote(prev) {
+				emit(t.openDouble)
+			} else {
+				emit(t.closeDouble)
+			}
+		case r == '\'':
+			next := runeAt(runes, i+1)
+			switch {
+			case isWordRune(prev) && unicode.IsLetter(next):
+				emit(t.apostrophe)
+			case opensQuote(prev) && unicode.IsDigit(next):
+				emit(t.apostrophe) // '90s
+			case opensQuote(prev):
+				emit(t.openSingle)
+			default:
+				emit(t.closeSingle)
+			}
+		case r == '-' && runeAt(runes, i+1) == '-' && runeAt(runes, i+2) != '>':
+			i++
+			if runeAt(runes, i+1) == '-' {
+				i++
+			}
+			emit(t.dash)
+		case r == '.' && hasRunesAt(runes, i, "...") && runeAt(runes, i+3) != '.' && prev != '.':
+			emit(t.ellipsis)
+			i += 2
+		case t.french && r == ' ' && strings.ContainsRune(";:!?»", runeAt(runes, i+1)) && prev != 0:
+			if runeAt(runes, i+1) == ':' || runeAt(runes, i+1) == '»' {
+				emit(nbsp)
+			} else {
+				emit(narrowNbsp)
+			}
+		case t.french && r == ' ' && prev == '«':
+			emit(nbsp)
+		case t.korean && r == 'ㆍ':
+			emit("·")
+		case t.korean && r == ' ' && isHangul(prev) && isSentencePunct(nextNonSpace(runes, i)):
+			// A space between a word and its period is a typo in Korean.
+		case t.japanese && (r == '!' || r == '?') && isJapanese(prev):
+			emit(string(r + 0xfee0)) // full-width ！ and ？
+			if next := runeAt(runes, i+1); next != 0 && !strings.ContainsRune(" !?\"'", next) && !isClosing(next) {
+				emit("\u3000")
+			}
+		default:
+			emit(string(r))
+		}
+	}
+	return sb.String(), inComment
+}
+
+// opensQuote reports whether a quote after prev opens a quotation.
+func opensQuote(prev rune) bool {
+	return prev == 0 || unicode.IsSpace(prev) || strings.ContainsRune("([{“‘«「『—–-/*_>", prev)
+}
+
+// isWordRune reports whether r can end a word an apostrophe continues.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isSentencePunct reports whether r ends a sentence or clause.
+func isSentencePunct(r rune) bool {
+	return strings.ContainsRune(".,!?…", r)
+}
+
+// isClosing reports whether r closes a quotation or bracket.
+func isClosing(r rune) bool {
+	return strings.ContainsRune(")]}」』”’）", r)
+}
+
+// isJapanese reports whether r is kana, a CJK ideograph or Japanese
+// punctuation.
+func isJapanese(r rune) bool {
+	return unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) || strings.ContainsRune("ー。、」』", r)
+}
+
+// runeAt returns the rune at i, or zero past either end.
+func runeAt(runes []rune, i int) rune {
+	if i < 0 || i >= len(runes) {
+		return 0
+	}
+	return runes[i]
+}
+
+// nextNonSpace returns the first rune after i that is not a space.
+func nextNonSpace(runes []rune, i int) rune {
+	for j := i + 1; j < len(runes); j++ {
+		if runes[j] != ' ' {
+			return runes[j]
+		}
+	}
+	return 0
+}
+
+// hasRunesAt reports whether s appears in runes at i.
+func hasRunesAt(runes []rune, i int, s string) bool {
+	for _, r := range s {
+		if runeAt(runes, i) != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package prose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTypography(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		text string
+		want string
+	}{
+		{
+			name: "english quotes, dashes and ellipses",
+			lang: "en",
+			text: `"Don't," she said -- 'wait'... It was the '90s.`,
+			want: "“Don’t,” she said — ‘wait’… It was the ’90s.",
+		},
+		{
+			name: "unknown language uses english rules",
+			lang: "de",
+			text: `"Hi"`,
+			want: "“Hi”",
+		},
+		{
+			name: "french guillemets and non-breaking spaces",
+			lang: "fr",
+			text: `Il a dit "non" : pourquoi ? C'est fini !`,
+			want: "Il a dit « non » : pourquoi ? C’est fini !",
+		},
+		{
+			name: "korean quotes, stray spaces and middle dots",
+			lang: "ko",
+			text: `"안녕" 그가 말했다 . 서울ㆍ부산... 끝`,
+			want: "“안녕” 그가 말했다. 서울·부산… 끝",
+		},
+		{
+			name: "japanese brackets, dashes and full-width marks",
+			lang: "ja",
+			text: `"本当?"彼は言った--そして... 'はい'! 行こう`,
+			want: "「本当？」彼は言った――そして…… 『はい』！ 行こう",
+		},
+		{
+			name: "japanese space after a mark mid-sentence",
+			lang: "ja",
+			text: "えっ!まさか",
+			want: "えっ！　まさか",
+		},
+		{
+			name: "markup is left alone",
+			lang: "en",
+			text: "---\n<!-- subplot: \"heist\" -->\nSee `a--b \"x\"` and [the \"map\"](http://x.test/a--b).\n```\n\"code\" -- ...\n```\n| a | b |\n|---|---|",
+			want: "---\n<!-- subplot: \"heist\" -->\nSee `a--b \"x\"` and [the “map”](http://x.test/a--b).\n```\n\"code\" -- ...\n```\n| a | b |\n|---|---|",
+		},
+		{
+			name: "comments spanning lines",
+			lang: "en",
+			text: "<!--\n\"note\"\n--> \"text\"",
+			want: "<!--\n\"note\"\n--> “text”",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeTypography(tt.text, tt.lang))
+		})
+	}
+}
+
+func TestNormalizeTypography_Idempotent(t *testing.T) {
+	for _, lang := range TypographyLanguages {
+		once := NormalizeTypography(`"Wait..." -- she left. "Why?" 'Yes' don't`, lang)
+		assert.Equal(t, once, NormalizeTypography(once, lang), lang)
+	}
+}
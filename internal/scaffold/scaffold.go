@@ -0,0 +1,291 @@
+// Package scaffold turns a beat of the plot outline into a scene
+// scaffold, the goal, conflict, disaster and sequel of the scene, and
+// optionally a first draft of its prose.
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+)
+
+const (
+	// maxContextFiles is how many context files mentioned by the beat
+	// are quoted.
+	maxContextFiles = 8
+
+	// maxContextRunes caps each quoted context file.
+	maxContextRunes = 1500
+
+	// previousChapterRunes is how much of the end of the last chapter is
+	// quoted, so the scene picks up where the manuscript stops.
+	previousChapterRunes = 1500
+
+	// ScenesDir is where scene files are written, relative to the
+	// project root. They are drafts, so they are not indexed.
+	ScenesDir = "scenes"
+)
+
+// Scene is a scene drafted from an outline beat.
+type Scene struct {
+	Beat *project.OutlineBeat
+
+	// The scaffold, in Dwight Swain's scene and sequel terms: what the
+	// viewpoint character wants, what stands in the way, how it goes
+	// wrong, and how they react and decide what to do next.
+	Goal     string
+	Conflict string
+	Disaster string
+	Sequel   string
+
+	// Prose is the first draft of the scene, empty unless asked for.
+	Prose string
+}
+
+// Generator drafts scenes for a project.
+type Generator struct {
+	Project  *project.Project
+	Provider llm.Provider
+}
+
+// Scaffold asks the model for the scaffold of a scene for beat, given the
+// context files the beat mentions and the end of the manuscript so far.
+func (g *Generator) Scaffold(ctx context.Context, beat *project.OutlineBeat) (*Scene, error) {
+	background, err := g.background(beat)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := g.chat(ctx, scaffoldInstruction, background+"\n\n"+beatPrompt(beat), 0.5)
+	if err != nil {
+		return nil, err
+	}
+	scene, err := parseScaffold(reply)
+	if err != nil {
+		return nil, err
+	}
+	scene.Beat = beat
+	return scene, nil
+}
+
+// WriteProse asks the model for a first draft of a scaffolded scene.
+func (g *Generator) WriteProse(ctx context.Context, scene *Scene) error {
+	background, err := g.background(scene.Beat)
+	if err != nil {
+		return err
+	}
+	prompt := fmt.Sprintf("%s\n\n%s\n\n## Scene scaffold\n\n%s", background, beatPrompt(scene.Beat), scene.scaffoldMarkdown())
+	prose, err := g.chat(ctx, g.proseInstruction(), prompt, 0.8)
+	if err != nil {
+		return err
+	}
+	scene.Prose = prose
+	return nil
+}
+
+// Save writes the scene to a new file under scenes/, named after its beat,
+// and returns its path relative to the project root.
+func (g *Generator) Save(scene *Scene) (string, error) {
+	name := strings.ReplaceAll(scene.Beat.Ref, ".", "-")
+	if slug := project.FileSlug(scene.Beat.Title); slug != "" {
+		name += "-" + slug
+	}
+	path := filepath.Join(ScenesDir, name+".md")
+	for i := 2; g.Project.FS.Exists(path); i++ {
+		path = filepath.Join(ScenesDir, fmt.Sprintf("%s-%d.md", name, i))
+	}
+
+	if err := g.Project.FS.EnsureDir(ScenesDir); err != nil {
+		return "", fmt.Errorf("failed to create scenes directory: %w", err)
+	}
+	if err := g.Project.FS.WriteMarkdown(path, scene.Markdown()); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// Markdown renders the scene file: the beat it came from, the scaffold and
+// the draft, if any.
+func (s *Scene) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "---\nbeat: %q\noutline: %s\n---\n# %s\n\n", s.Beat.Ref, filepath.ToSlash(s.Beat.FilePath), s.Beat.Title)
+	sb.WriteString(s.scaffoldMarkdown())
+	if s.Prose != "" {
+		sb.WriteString("\n## Draft\n\n")
+		sb.WriteString(s.Prose)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (s *Scene) scaffoldMarkdown() string {
+	return fmt.Sprintf("## Goal\n\n%s\n\n## Conflict\n\n%s\n\n## Disaster\n\n%s\n\n## Sequel\n\n%s\n", s.Goal, s.Conflict, s.Disaster, s.Sequel)
+}
+
+// background quotes the project's style, the context files the beat
+// mentions or links to, and the end of the last chapter.
+func (g *Generator) background(beat *project.OutlineBeat) (string, error) {
+	var sb strings.Builder
+	if cfg := g.Project.Config; cfg != nil {
+		fmt.Fprintf(&sb, "Novel: %s (%s)\n", cfg.Name, cfg.Genre)
+		if w := cfg.Writing; w.Style != "" || w.POV != "" || w.Tense != "" {
+			fmt.Fprintf(&sb, "Style: %s; point of view: %s; tense: %s\n", w.Style, w.POV, w.Tense)
+		}
+	}
+
+	graph, err := g.Project.LinkGraph()
+	if err != nil {
+		return "", fmt.Errorf("failed to read context links: %w", err)
+	}
+	paths := graph.Mentioned(beat.Title + "\n" + beat.Text)
+	for _, linked := range graph.Links[beat.FilePath] {
+		if !containsPath(paths, linked) {
+			paths = append(paths, linked)
+		}
+	}
+	quoted := 0
+	for _, path := range paths {
+		if quoted == maxContextFiles {
+			break
+		}
+		if path == beat.FilePath || !strings.HasPrefix(filepath.ToSlash(path), "context/") {
+			continue
+		}
+		content, err := g.Project.FS.ReadMarkdown(path)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n<context source=%q>\n%s\n</context>\n", filepath.ToSlash(path), truncateRunes(strings.TrimSpace(content), maxContextRunes, false))
+		quoted++
+	}
+
+	chapters, err := g.Project.LoadChapters()
+	if err != nil {
+		return "", fmt.Errorf("failed to load chapters: %w", err)
+	}
+	if len(chapters) > 0 {
+		last := chapters[len(chapters)-1]
+		fmt.Fprintf(&sb, "\nThe manuscript so far ends (chapter %d, %s):\n<context source=%q>\n%s\n</context>\n",
+			last.Number, last.Title, filepath.ToSlash(last.FilePath), truncateRunes(strings.TrimSpace(last.Content), previousChapterRunes, true))
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func (g *Generator) chat(ctx context.Context, system, prompt string, temperature float64) (string, error) {
+	resp, err := g.Provider.Chat(ctx, llm.ChatRequest{
+		Messages: []llm.ChatMessage{
+			llm.NewSystemMessage(system),
+			llm.NewUserMessage(prompt),
+		},
+		Temperature: temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(resp.Message.Content)
+	if text == "" {
+		return "", fmt.Errorf("the model returned an empty reply")
+	}
+	return text, nil
+}
+
+const scaffoldInstruction = "You help a novelist plan scenes. From the outline beat, plan one scene that dramatizes it, using what the context establishes about the characters and places. Reply with exactly four Markdown sections, a few sentences each: `## Goal` (what the viewpoint character wants in this scene), `## Conflict` (what stands in the way), `## Disaster` (how the scene ends worse or differently than hoped) and `## Sequel` (the character's reaction, dilemma and decision, leading into the next beat). Write in the language of the outline and reply with the four sections only."
+
+// proseInstruction asks for the scene's prose in the project's style.
+func (g *Generator) proseInstruction() string {
+	instruction := "You are drafting a scene of a novel for its author. Write the scene's prose from the scaffold, in the language of the outline, following the established characters and style and continuing from where the manuscript stops. Show the goal, conflict and disaster in action and dialogue, and end on the sequel. Reply with the prose only, without headings or commentary."
+	if cfg := g.Project.Config; cfg != nil {
+		if rating := cfg.Writing.Rating; rating != "" {
+			instruction += fmt.Sprintf(" Keep the content within the %s rating.", rating)
+		}
+	}
+	return instruction
+}
+
+// beatPrompt describes the beat and its neighbours.
+func beatPrompt(beat *project.OutlineBeat) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Outline beat %s: %s\n", beat.Ref, beat.Title)
+	if beat.Text != "" && beat.Text != beat.Title {
+		fmt.Fprintf(&sb, "\n%s\n", beat.Text)
+	}
+	if beat.Previous != "" {
+		fmt.Fprintf(&sb, "\nPrevious beat: %s\n", beat.Previous)
+	}
+	if beat.Next != "" {
+		fmt.Fprintf(&sb, "Next beat: %s\n", beat.Next)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// scaffoldHeading matches the heading of a scaffold section.
+var scaffoldHeading = regexp.MustCompile(`(?i)^#{1,6}\s*\**\s*(goal|conflict|disaster|sequel)\b`)
+
+// parseScaffold reads the four sections of the model's scaffold.
+func parseScaffold(reply string) (*Scene, error) {
+	sections := make(map[string]*strings.Builder)
+	var current *strings.Builder
+	for _, line := range strings.Split(reply, "\n") {
+		if m := scaffoldHeading.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			current = &strings.Builder{}
+			sections[strings.ToLower(m[1])] = current
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			current = nil
+		}
+		if current != nil {
+			current.WriteString(line)
+			current.WriteString("\n")
+		}
+	}
+
+	text := func(name string) (string, error) {
+		section, ok := sections[name]
+		if !ok || strings.TrimSpace(section.String()) == "" {
+			return "", fmt.Errorf("the model's scaffold has no %s section", name)
+		}
+		return strings.TrimSpace(section.String()), nil
+	}
+	scene := &Scene{}
+	var err error
+	if scene.Goal, err = text("goal"); err != nil {
+		return nil, err
+	}
+	if scene.Conflict, err = text("conflict"); err != nil {
+		return nil, err
+	}
+	if scene.Disaster, err = text("disaster"); err != nil {
+		return nil, err
+	}
+	if scene.Sequel, err = text("sequel"); err != nil {
+		return nil, err
+	}
+	return scene, nil
+}
+
+// truncateRunes shortens s to at most n runes, keeping its start, or its
+// end when tail is set.
+func truncateRunes(s string, n int, tail bool) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if tail {
+		return "..." + string(runes[len(runes)-n:])
+	}
+	return string(runes[:n]) + "..."
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
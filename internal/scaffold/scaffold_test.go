@@ -0,0 +1,95 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider answers Chat with its replies in order and records the
+// requests.
+type scriptedProvider struct {
+	llm.Provider
+	replies  []string
+	requests []llm.ChatRequest
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.requests = append(p.requests, req)
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return &llm.ChatResponse{Message: llm.NewAssistantMessage(reply)}, nil
+}
+
+func createOutlineProject(t *testing.T) *project.Project {
+	t.Helper()
+
+	mgr, err := project.NewManager(t.TempDir())
+	require.NoError(t, err)
+	proj, err := mgr.Create("scaffold-novel", types.DefaultProjectConfig("Scaffold Novel", "fantasy"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = proj.Close() })
+
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "characters", "hana.md"), "# Hana\n\nA harbor pilot afraid of storms.\n"))
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "characters", "jun.md"), "# Jun\n\nA baker.\n"))
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "plot", "01-arrival.md"), "# Arrival\n\n- The town\n- Hana takes the night shift\n- The storm\n"))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# Chapter 1\n\nThe lighthouse went dark.\n"}))
+	return proj
+}
+
+const scaffoldReply = "## Goal\nHana wants to guide the last ship in.\n\n## Conflict\nThe radio is dead.\n\n## Disaster\nThe ship runs aground.\n\n## Sequel\nShe decides to row out.\n"
+
+func TestGenerator(t *testing.T) {
+	proj := createOutlineProject(t)
+	beat, err := proj.FindOutlineBeat("1.2")
+	require.NoError(t, err)
+
+	provider := &scriptedProvider{replies: []string{scaffoldReply, "Rain hammered the glass."}}
+	g := &Generator{Project: proj, Provider: provider}
+
+	scene, err := g.Scaffold(context.Background(), beat)
+	require.NoError(t, err)
+	assert.Equal(t, "Hana wants to guide the last ship in.", scene.Goal)
+	assert.Equal(t, "She decides to row out.", scene.Sequel)
+
+	prompt := provider.requests[0].Messages[1].Content
+	assert.Contains(t, prompt, "A harbor pilot afraid of storms.", "mentioned characters are quoted")
+	assert.NotContains(t, prompt, "A baker.")
+	assert.Contains(t, prompt, "The lighthouse went dark.", "the manuscript's end is quoted")
+	assert.Contains(t, prompt, "Outline beat 1.2: Hana takes the night shift")
+	assert.Contains(t, prompt, "Next beat: The storm")
+
+	require.NoError(t, g.WriteProse(context.Background(), scene))
+	assert.Contains(t, provider.requests[1].Messages[1].Content, "## Disaster\n\nThe ship runs aground.")
+
+	path, err := g.Save(scene)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(ScenesDir, "1-2-hana-takes-the-night-shift.md"), path)
+	content, err := os.ReadFile(filepath.Join(proj.Path(), path))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "beat: \"1.2\"\noutline: context/plot/01-arrival.md\n")
+	assert.Contains(t, string(content), "## Goal\n\nHana wants to guide the last ship in.")
+	assert.Contains(t, string(content), "## Draft\n\nRain hammered the glass.\n")
+
+	again, err := g.Save(scene)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(ScenesDir, "1-2-hana-takes-the-night-shift-2.md"), again, "existing scenes are kept")
+}
+
+func TestParseScaffold(t *testing.T) {
+	scene, err := parseScaffold("Here is the plan.\n\n### **Goal**\nEscape.\n### Conflict\nGuards.\n### Disaster\nCaught.\n### Sequel\nPlots anew.\n")
+	require.NoError(t, err)
+	assert.Equal(t, "Escape.", scene.Goal)
+	assert.Equal(t, "Plots anew.", scene.Sequel)
+
+	_, err = parseScaffold("## Goal\nEscape.\n## Conflict\nGuards.\n## Disaster\nCaught.\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sequel")
+}
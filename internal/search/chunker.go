@@ -0,0 +1,352 @@
+package search
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunking strategies, set by the project's context.chunking.
+const (
+	// ChunkingTokens cuts content every chunk size tokens, wherever that
+	// falls.
+	ChunkingTokens = "tokens"
+
+	// ChunkingSemantic cuts content at markdown headings, paragraphs and
+	// sentences, so chunks read as whole passages.
+	ChunkingSemantic = "semantic"
+)
+
+// IndexerOption configures an Indexer.
+type IndexerOption func(*Indexer)
+
+// WithChunking sets the chunking strategy. Unknown strategies cut by
+// tokens.
+func WithChunking(strategy string) IndexerOption {
+	return func(idx *Indexer) {
+		idx.chunking = strategy
+	}
+}
+
+// chunkUnit is a piece of content that is never cut: a heading, a
+// paragraph, or part of a paragraph too big for one chunk. start and end
+// are byte offsets into the content.
+type chunkUnit struct {
+	start, end int
+	tokens     int
+	heading    bool
+}
+
+// sentenceEnds are the runes that end a sentence, and sentenceClosers
+// those that may follow the end, like closing quotes.
+const (
+	sentenceEnds    = ".!?…。！？"
+	sentenceClosers = "\"'”’)]」』"
+)
+
+// splitSemantic cuts content into chunks of at most chunkSize tokens at
+// the best boundary available. Each heading starts a new chunk, and
+// paragraphs stay whole unless one alone is too big, in which case it is
+// cut between lines, then sentences, then words. A chunk that starts
+// mid-section repeats the last paragraphs or sentences of the one before,
+// up to the overlap fraction of chunkSize.
+func splitSemantic(counter TokenCounter, content string, chunkSize int, overlap float64) []string {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	if counter.Count(content) <= chunkSize {
+		return []string{content}
+	}
+
+	var units []chunkUnit
+	for _, block := range markdownBlocks(content) {
+		units = append(units, splitUnit(counter, content, block, chunkSize, 0)...)
+	}
+	overlapTokens := int(float64(chunkSize) * overlap)
+
+	var chunks []string
+	emit := func(from, to int) {
+		if text := strings.TrimSpace(content[units[from].start:units[to-1].end]); text != "" {
+			chunks = append(chunks, text)
+		}
+	}
+
+	start, tokens := 0, 0
+	onlyHeadings := true
+	for i, unit := range units {
+		if i > start && (unit.heading && !onlyHeadings || tokens+unit.tokens > chunkSize) {
+			emit(start, i)
+			next, carried := i, 0
+			if !unit.heading {
+				// Carry the end of the previous chunk over, leaving at
+				// least its first unit behind so every chunk moves on.
+				for next-1 > start && carried+units[next-1].tokens <= overlapTokens &&
+					carried+units[next-1].tokens+unit.tokens <= chunkSize {
+					next--
+					carried += units[next].tokens
+				}
+			}
+			start, tokens = next, carried
+			onlyHeadings = true
+			for _, u := range units[start:i] {
+				onlyHeadings = onlyHeadings && u.heading
+			}
+		}
+		tokens += unit.tokens
+		onlyHeadings = onlyHeadings && unit.heading
+	}
+	emit(start, len(units))
+	return chunks
+}
+
+// markdownBlocks returns the headings and paragraphs of content, as units
+// without token counts. Fenced code blocks are one paragraph, blank lines
+// and all.
+func markdownBlocks(content string) []chunkUnit {
+	var blocks []chunkUnit
+	blockStart, inFence := -1, false
+	end := func(at int) {
+		if blockStart >= 0 {
+			blocks = append(blocks, chunkUnit{start: blockStart, end: at})
+			blockStart = -1
+		}
+	}
+
+	for offset := 0; offset < len(content); {
+		lineEnd := strings.IndexByte(content[offset:], '\n') + 1
+		if lineEnd == 0 {
+			lineEnd = len(content) - offset
+		}
+		line := strings.TrimSpace(content[offset : offset+lineEnd])
+		next := offset + lineEnd
+
+		switch {
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			if blockStart < 0 {
+				blockStart = offset
+			}
+			inFence = !inFence
+		case inFence:
+		case line == "":
+			end(offset)
+		case isHeading(line):
+			end(offset)
+			blocks = append(blocks, chunkUnit{start: offset, end: next, heading: true})
+		default:
+			if blockStart < 0 {
+				blockStart = offset
+			}
+		}
+		offset = next
+	}
+	end(len(content))
+	return blocks
+}
+
+// isHeading reports whether a trimmed line is an ATX markdown heading.
+func isHeading(line string) bool {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	return level >= 1 && level <= 6 && (len(line) == level || line[level] == ' ')
+}
+
+// unitCutters cut a unit too big for a chunk at ever smaller boundaries:
+// lines, sentences, then words. Each returns the offsets, within text,
+// where the pieces after the first start.
+var unitCutters = []func(text string) []int{cutLines, cutSentences, cutWords}
+
+// splitUnit counts a unit's tokens and, when it does not fit in a chunk,
+// cuts it with the cutters from level on. A piece no cutter can shrink is
+// cut into even runs of runes.
+func splitUnit(counter TokenCounter, content string, unit chunkUnit, chunkSize, level int) []chunkUnit {
+	unit.tokens = counter.Count(content[unit.start:unit.end])
+	if unit.tokens <= chunkSize {
+		return []chunkUnit{unit}
+	}
+
+	for ; level < len(unitCutters); level++ {
+		cuts := unitCutters[level](content[unit.start:unit.end])
+		if len(cuts) == 0 {
+			continue
+		}
+		var pieces []chunkUnit
+		from := unit.start
+		for _, cut := range append(cuts, unit.end-unit.start) {
+			piece := chunkUnit{start: from, end: unit.start + cut, heading: unit.heading && from == unit.start}
+			pieces = append(pieces, splitUnit(counter, content, piece, chunkSize, level+1)...)
+			from = unit.start + cut
+		}
+		return pieces
+	}
+	return cutRunes(counter, content, unit, chunkSize)
+}
+
+// cutLines returns where each line after the first starts.
+func cutLines(text string) []int {
+	var cuts []int
+	for i := 0; i < len(text)-1; i++ {
+		if text[i] == '\n' {
+			cuts = append(cuts, i+1)
+		}
+	}
+	return cuts
+}
+
+// cutSentences returns where each sentence after the first starts: after
+// an ending mark, any closing quotes and the whitespace that follows.
+func cutSentences(text string) []int {
+	var cuts []int
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		if !strings.ContainsRune(sentenceEnds, r) {
+			continue
+		}
+		end := i
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !strings.ContainsRune(sentenceEnds, r) && !strings.ContainsRune(sentenceClosers, r) {
+				break
+			}
+			end += size
+		}
+		next := end
+		for next < len(text) {
+			r, size := utf8.DecodeRuneInString(text[next:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			next += size
+		}
+		if next > end && next < len(text) {
+			cuts = append(cuts, next)
+		}
+		i = next
+	}
+	return cuts
+}
+
+// cutWords returns where each word after the first starts.
+func cutWords(text string) []int {
+	var cuts []int
+	space := false
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space && i > 0 {
+			cuts = append(cuts, i)
+		}
+		space = false
+	}
+	return cuts
+}
+
+// cutRunes cuts a unit without any boundary, such as one long word, into
+// even runs of runes that each fit in a chunk.
+func cutRunes(counter TokenCounter, content string, unit chunkUnit, chunkSize int) []chunkUnit {
+	text := content[unit.start:unit.end]
+	parts := unit.tokens/chunkSize + 1
+	perPart := utf8.RuneCountInString(text)/parts + 1
+
+	var pieces []chunkUnit
+	from, runes := unit.start, 0
+	for i := range text {
+		if runes > 0 && runes%perPart == 0 {
+			pieces = append(pieces, chunkUnit{start: from, end: unit.start + i})
+			from = unit.start + i
+		}
+		runes++
+	}
+	pieces = append(pieces, chunkUnit{start: from, end: unit.end})
+	for i := range pieces {
+		pieces[i].tokens = counter.Count(content[pieces[i].start:pieces[i].end])
+	}
+	pieces[0].heading = unit.heading
+	return pieces
+}
+
+// sectionSeparator joins the headings of a section path.
+const sectionSeparator = " > "
+
+// chunkSections returns the heading path each chunk sits under, such as
+// "Hana > Backstory", finding the chunks in content in order. A chunk that
+// starts with headings is under them. Chunks that cannot be found, which
+// token chunking can produce, take the path of the chunk before.
+func chunkSections(content string, chunks []string) []string {
+	var headings []chunkUnit
+	for _, block := range markdownBlocks(content) {
+		if block.heading {
+			headings = append(headings, block)
+		}
+	}
+
+	sections := make([]string, len(chunks))
+	from := 0
+	for i, chunk := range chunks {
+		at := strings.Index(content[from:], chunk)
+		if at < 0 {
+			if i > 0 {
+				sections[i] = sections[i-1]
+			}
+			continue
+		}
+		at += from
+		from = at + 1
+		sections[i] = sectionAt(content, headings, at+leadingHeadings(chunk))
+	}
+	return sections
+}
+
+// leadingHeadings returns where the first line of chunk that is neither
+// blank nor a heading starts.
+func leadingHeadings(chunk string) int {
+	offset := 0
+	for offset < len(chunk) {
+		lineEnd := strings.IndexByte(chunk[offset:], '\n') + 1
+		if lineEnd == 0 {
+			lineEnd = len(chunk) - offset
+		}
+		if line := strings.TrimSpace(chunk[offset : offset+lineEnd]); line != "" && !isHeading(line) {
+			break
+		}
+		offset += lineEnd
+	}
+	return offset
+}
+
+// sectionAt returns the path of the headings in effect at offset: each
+// heading before it, minus those closed by a later heading of the same or
+// a higher level.
+func sectionAt(content string, headings []chunkUnit, offset int) string {
+	var path []string
+	var levels []int
+	for _, h := range headings {
+		if h.start >= offset {
+			break
+		}
+		line := strings.TrimSpace(content[h.start:h.end])
+		level := len(line) - len(strings.TrimLeft(line, "#"))
+		for len(levels) > 0 && levels[len(levels)-1] >= level {
+			path, levels = path[:len(path)-1], levels[:len(levels)-1]
+		}
+		title := strings.TrimSpace(strings.TrimRight(line[level:], "# "))
+		path, levels = append(path, title), append(levels, level)
+	}
+	return strings.Join(path, sectionSeparator)
+}
+
+// chunkSection reads the heading path from a chunk's metadata JSON.
+func chunkSection(metadata string) string {
+	if metadata == "" {
+		return ""
+	}
+	var meta struct {
+		Section string `json:"section"`
+	}
+	if err := json.Unmarshal([]byte(metadata), &meta); err != nil {
+		return ""
+	}
+	return meta.Section
+}
@@ -21,6 +21,8 @@ const (
 	SourceTypeSetting   = "setting"
 	SourceTypePlot      = "plot"
 	SourceTypeChapter   = "chapter"
+	SourceTypeGlossary  = "glossary"
+	SourceTypeResearch  = "research"
 )
 
 // SearchEngine defines the interface for search operations.
@@ -56,7 +58,7 @@ type SearchOptions struct {
 	Offset int
 
 	// FilterType restricts results to a specific source type.
-	// Valid values: "character", "setting", "plot", "chapter".
+	// Valid values: "character", "setting", "plot", "chapter", "glossary".
 	// Empty string matches all types.
 	FilterType string
 
@@ -88,7 +90,7 @@ type Document struct {
 	Content string
 
 	// SourceType indicates the content category.
-	// Values: "character", "setting", "plot", "chapter".
+	// Values: "character", "setting", "plot", "chapter", "glossary".
 	SourceType string
 
 	// SourcePath is the file path or URI of the source content.
@@ -157,7 +159,7 @@ func (o SearchOptions) WithMinScore(minScore float64) SearchOptions {
 // IsValidSourceType returns true if the given type is a valid source type.
 func IsValidSourceType(sourceType string) bool {
 	switch sourceType {
-	case SourceTypeCharacter, SourceTypeSetting, SourceTypePlot, SourceTypeChapter, "":
+	case SourceTypeCharacter, SourceTypeSetting, SourceTypePlot, SourceTypeChapter, SourceTypeGlossary, "":
 		return true
 	default:
 		return false
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// IgnoreFileName is the file at a project's root listing, like
+// .gitignore, paths to keep out of the search index and context.
+const IgnoreFileName = ".dreamtellerignore"
+
+// ExcludeList matches project paths against glob patterns. A pattern with
+// no slash, such as "scrap" or "*.old.md", matches a file or directory of
+// that name anywhere; one with a slash, such as "research/*", matches from
+// the project root. Everything under a matched directory is excluded, and
+// a trailing slash matches directories only.
+type ExcludeList struct {
+	patterns []string
+}
+
+// NewExcludeList creates a list from patterns, skipping blank ones.
+func NewExcludeList(patterns ...string) *ExcludeList {
+	var list ExcludeList
+	for _, p := range patterns {
+		p = strings.TrimPrefix(strings.TrimSpace(filepath.ToSlash(p)), "./")
+		if p != "" && p != "/" {
+			list.patterns = append(list.patterns, p)
+		}
+	}
+	return &list
+}
+
+// ParseIgnoreFile returns the patterns in the content of an ignore file:
+// one per line, skipping blank lines and # comments.
+func ParseIgnoreFile(content string) []string {
+	var patterns []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns
+}
+
+// Excludes reports whether path, relative to the project root, is
+// excluded. A nil list excludes nothing.
+func (l *ExcludeList) Excludes(p string) bool {
+	if l == nil || len(l.patterns) == 0 {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(p)), "/")
+	for _, pattern := range l.patterns {
+		dirOnly := strings.HasSuffix(pattern, "/")
+		pattern = strings.Trim(pattern, "/")
+		anchored := strings.Contains(pattern, "/")
+
+		// Try each leading run of segments: the file itself, unless the
+		// pattern names directories only, and every directory above it.
+		last := len(segments)
+		if dirOnly {
+			last--
+		}
+		for n := 1; n <= last; n++ {
+			if anchored {
+				if ok, _ := path.Match(pattern, strings.Join(segments[:n], "/")); ok {
+					return true
+				}
+			} else if ok, _ := path.Match(pattern, segments[n-1]); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Filter returns the files that are not excluded.
+func (l *ExcludeList) Filter(files []storage.FileInfo) []storage.FileInfo {
+	if l == nil || len(l.patterns) == 0 {
+		return files
+	}
+	kept := files[:0]
+	for _, f := range files {
+		if !l.Excludes(f.Path) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// WithExclude keeps the files an exclude list matches out of the index.
+func WithExclude(list *ExcludeList) IndexerOption {
+	return func(idx *Indexer) {
+		idx.exclude = list
+	}
+}
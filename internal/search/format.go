@@ -0,0 +1,83 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// IndexFormatVersion is bumped whenever the way files are chunked or
+// chunks are stored changes, so indexes built the old way get rebuilt.
+// Version 2 gives files under research/ their own source type.
+const IndexFormatVersion = 2
+
+// Format returns the settings the indexer builds the index with.
+func (idx *Indexer) Format() storage.IndexFormat {
+	chunking := ChunkingTokens
+	if idx.chunking == ChunkingSemantic {
+		chunking = ChunkingSemantic
+	}
+	tokenizer := ""
+	if named, ok := idx.counter.(interface{ Encoding() string }); ok {
+		tokenizer = named.Encoding()
+	}
+	return storage.IndexFormat{
+		Version:      IndexFormatVersion,
+		ChunkSize:    idx.chunkSize,
+		ChunkOverlap: idx.chunkOverlap,
+		Chunking:     chunking,
+		Tokenizer:    tokenizer,
+	}
+}
+
+// FormatChanges compares the settings the index was built with to the
+// indexer's and describes each difference, such as "chunk size 500 → 800".
+// It returns nil when they match or the index is empty. An index that
+// predates recorded settings is reported as one difference.
+func (idx *Indexer) FormatChanges(db *storage.SQLiteDB) ([]string, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required to check the index format")
+	}
+
+	built, err := db.GetIndexFormat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index format: %w", err)
+	}
+	if built == nil {
+		count, err := idx.engine.GetChunkCount()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, nil
+		}
+		return []string{"no settings recorded"}, nil
+	}
+
+	want := idx.Format()
+	var changes []string
+	if built.Version != want.Version {
+		changes = append(changes, fmt.Sprintf("index format v%d → v%d", built.Version, want.Version))
+	}
+	if built.ChunkSize != want.ChunkSize {
+		changes = append(changes, fmt.Sprintf("chunk size %d → %d", built.ChunkSize, want.ChunkSize))
+	}
+	if built.ChunkOverlap != want.ChunkOverlap {
+		changes = append(changes, fmt.Sprintf("chunk overlap %g → %g", built.ChunkOverlap, want.ChunkOverlap))
+	}
+	if built.Chunking != want.Chunking {
+		changes = append(changes, fmt.Sprintf("chunking %s → %s", built.Chunking, want.Chunking))
+	}
+	if built.Tokenizer != want.Tokenizer {
+		changes = append(changes, fmt.Sprintf("tokenizer %s → %s", formatName(built.Tokenizer), formatName(want.Tokenizer)))
+	}
+	return changes, nil
+}
+
+// formatName shows an unrecorded setting as "unknown".
+func formatName(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
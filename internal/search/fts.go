@@ -4,6 +4,8 @@ package search
 import (
 	"database/sql"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +20,22 @@ type FTSSearchResult struct {
 	SourcePath string
 	TokenCount int
 	Score      float64
+
+	// Section is the heading path the chunk sits under in its file, such
+	// as "Hana > Backstory", when the file has headings.
+	Section string
+
+	// Fuzzy marks a result found by FuzzySearch, which matches words
+	// close to the query's rather than the words themselves.
+	Fuzzy bool
+}
+
+// Location returns the chunk's file, followed by its section when known.
+func (r FTSSearchResult) Location() string {
+	if r.Section == "" {
+		return r.SourcePath
+	}
+	return r.SourcePath + " § " + r.Section
 }
 
 // FTSEngine implements a search engine using SQLite FTS5.
@@ -57,6 +75,7 @@ func (e *FTSEngine) Search(query string, limit int) ([]FTSSearchResult, error) {
 			chunks_fts.source_type,
 			chunks_fts.source_path,
 			chunks_meta.token_count,
+			COALESCE(chunks_meta.metadata, ''),
 			bm25(chunks_fts) as score
 		FROM chunks_fts
 		JOIN chunks_meta ON chunks_fts.rowid = chunks_meta.rowid
@@ -74,16 +93,19 @@ func (e *FTSEngine) Search(query string, limit int) ([]FTSSearchResult, error) {
 	var results []FTSSearchResult
 	for rows.Next() {
 		var r FTSSearchResult
+		var metadata string
 		if err := rows.Scan(
 			&r.ID,
 			&r.Content,
 			&r.SourceType,
 			&r.SourcePath,
 			&r.TokenCount,
+			&metadata,
 			&r.Score,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan search result: %w", err)
 		}
+		r.Section = chunkSection(metadata)
 		results = append(results, r)
 	}
 
@@ -118,6 +140,7 @@ func (e *FTSEngine) SearchWithFilter(query string, sourceType string, limit int)
 			chunks_fts.source_type,
 			chunks_fts.source_path,
 			chunks_meta.token_count,
+			COALESCE(chunks_meta.metadata, ''),
 			bm25(chunks_fts) as score
 		FROM chunks_fts
 		JOIN chunks_meta ON chunks_fts.rowid = chunks_meta.rowid
@@ -136,16 +159,19 @@ func (e *FTSEngine) SearchWithFilter(query string, sourceType string, limit int)
 	var results []FTSSearchResult
 	for rows.Next() {
 		var r FTSSearchResult
+		var metadata string
 		if err := rows.Scan(
 			&r.ID,
 			&r.Content,
 			&r.SourceType,
 			&r.SourcePath,
 			&r.TokenCount,
+			&metadata,
 			&r.Score,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan search result: %w", err)
 		}
+		r.Section = chunkSection(metadata)
 		results = append(results, r)
 	}
 
@@ -156,6 +182,138 @@ func (e *FTSEngine) SearchWithFilter(query string, sourceType string, limit int)
 	return results, nil
 }
 
+// SearchFilter narrows a search. The zero value matches everything.
+type SearchFilter struct {
+	// SourceTypes restricts results to these source types.
+	SourceTypes []string
+
+	// ChapterFrom and ChapterTo bound chapter results by the number in their
+	// file name, inclusive. Zero leaves that end open. Results from other
+	// source types are not affected.
+	ChapterFrom int
+	ChapterTo   int
+}
+
+// hasChapterRange reports whether the filter bounds chapter numbers.
+func (f SearchFilter) hasChapterRange() bool {
+	return f.ChapterFrom > 0 || f.ChapterTo > 0
+}
+
+// includes reports whether a result passes the chapter range.
+func (f SearchFilter) includes(r FTSSearchResult) bool {
+	if r.SourceType != SourceTypeChapter || !f.hasChapterRange() {
+		return true
+	}
+	n, ok := ChapterNumber(r.SourcePath)
+	if !ok {
+		return false
+	}
+	return n >= f.ChapterFrom && (f.ChapterTo == 0 || n <= f.ChapterTo)
+}
+
+// SearchFiltered performs a full-text search restricted by filter.
+func (e *FTSEngine) SearchFiltered(query string, filter SearchFilter, limit int) ([]FTSSearchResult, error) {
+	if query == "" {
+		return nil, nil
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	sanitizedQuery := sanitizeFTS5Query(query)
+	if sanitizedQuery == "" {
+		return nil, nil
+	}
+
+	where := "chunks_fts MATCH ?"
+	args := []interface{}{sanitizedQuery}
+	if len(filter.SourceTypes) > 0 {
+		where += " AND chunks_fts.source_type IN (?" + strings.Repeat(", ?", len(filter.SourceTypes)-1) + ")"
+		for _, t := range filter.SourceTypes {
+			args = append(args, t)
+		}
+	}
+
+	// The chapter number lives in the file name, so a chapter range is
+	// applied after the query and the limit with it.
+	limitClause := ""
+	if !filter.hasChapterRange() {
+		limitClause = " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := e.db.DB().Query(`
+		SELECT
+			chunks_fts.rowid,
+			chunks_fts.content,
+			chunks_fts.source_type,
+			chunks_fts.source_path,
+			chunks_meta.token_count,
+			COALESCE(chunks_meta.metadata, ''),
+			bm25(chunks_fts) as score
+		FROM chunks_fts
+		JOIN chunks_meta ON chunks_fts.rowid = chunks_meta.rowid
+		WHERE `+where+`
+		ORDER BY score`+limitClause,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []FTSSearchResult
+	for rows.Next() {
+		var r FTSSearchResult
+		var metadata string
+		if err := rows.Scan(
+			&r.ID,
+			&r.Content,
+			&r.SourceType,
+			&r.SourcePath,
+			&r.TokenCount,
+			&metadata,
+			&r.Score,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		r.Section = chunkSection(metadata)
+		if !filter.includes(r) {
+			continue
+		}
+		results = append(results, r)
+		if len(results) == limit {
+			break
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// ChapterNumber extracts the chapter number from a chapter file path, using
+// the first run of digits in the file name (e.g. "chapter-003.md" is 3).
+func ChapterNumber(path string) (int, bool) {
+	base := filepath.Base(path)
+	start := strings.IndexAny(base, "0123456789")
+	if start < 0 {
+		return 0, false
+	}
+	end := start
+	for end < len(base) && base[end] >= '0' && base[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(base[start:end])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
 // SearchWithHighlight performs a search and returns results with highlighted snippets.
 // The highlightStart and highlightEnd strings wrap matched terms in the snippet.
 func (e *FTSEngine) SearchWithHighlight(query string, limit int, highlightStart, highlightEnd string) ([]HighlightedResult, error) {
@@ -187,6 +345,7 @@ func (e *FTSEngine) SearchWithHighlight(query string, limit int, highlightStart,
 			chunks_fts.source_type,
 			chunks_fts.source_path,
 			chunks_meta.token_count,
+			COALESCE(chunks_meta.metadata, ''),
 			bm25(chunks_fts) as score
 		FROM chunks_fts
 		JOIN chunks_meta ON chunks_fts.rowid = chunks_meta.rowid
@@ -206,6 +365,7 @@ func (e *FTSEngine) SearchWithHighlight(query string, limit int, highlightStart,
 	var results []HighlightedResult
 	for rows.Next() {
 		var r HighlightedResult
+		var metadata string
 		if err := rows.Scan(
 			&r.ID,
 			&r.Content,
@@ -213,10 +373,12 @@ func (e *FTSEngine) SearchWithHighlight(query string, limit int, highlightStart,
 			&r.SourceType,
 			&r.SourcePath,
 			&r.TokenCount,
+			&metadata,
 			&r.Score,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan search result: %w", err)
 		}
+		r.Section = chunkSection(metadata)
 		results = append(results, r)
 	}
 
@@ -445,13 +607,15 @@ type IndexableChunk struct {
 // GetChunkByID retrieves a chunk by its rowid.
 func (e *FTSEngine) GetChunkByID(id int64) (*FTSSearchResult, error) {
 	var r FTSSearchResult
+	var metadata string
 	err := e.db.DB().QueryRow(`
 		SELECT
 			chunks_fts.rowid,
 			chunks_fts.content,
 			chunks_fts.source_type,
 			chunks_fts.source_path,
-			chunks_meta.token_count
+			chunks_meta.token_count,
+			COALESCE(chunks_meta.metadata, '')
 		FROM chunks_fts
 		JOIN chunks_meta ON chunks_fts.rowid = chunks_meta.rowid
 		WHERE chunks_fts.rowid = ?`,
@@ -462,6 +626,7 @@ func (e *FTSEngine) GetChunkByID(id int64) (*FTSSearchResult, error) {
 		&r.SourceType,
 		&r.SourcePath,
 		&r.TokenCount,
+		&metadata,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -469,9 +634,80 @@ func (e *FTSEngine) GetChunkByID(id int64) (*FTSSearchResult, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chunk by ID: %w", err)
 	}
+	r.Section = chunkSection(metadata)
 	return &r, nil
 }
 
+// Limits on how many neighboring chunks ExpandChunk reads on each side.
+const (
+	DefaultExpandRadius = 1
+	MaxExpandRadius     = 3
+)
+
+// ExpandChunk returns the chunk with the given ID together with up to
+// radius chunks before and after it from the same file, in file order, for
+// reading a search hit in fuller context. The radius is clamped to
+// MaxExpandRadius; zero or less means DefaultExpandRadius. It returns nil
+// if the chunk does not exist.
+func (e *FTSEngine) ExpandChunk(id int64, radius int) ([]FTSSearchResult, error) {
+	switch {
+	case radius <= 0:
+		radius = DefaultExpandRadius
+	case radius > MaxExpandRadius:
+		radius = MaxExpandRadius
+	}
+
+	chunk, err := e.GetChunkByID(id)
+	if err != nil || chunk == nil {
+		return nil, err
+	}
+
+	// A file's chunks are indexed in order, so rowids follow their order.
+	rows, err := e.db.DB().Query(`
+		SELECT
+			chunks_fts.rowid,
+			chunks_fts.content,
+			chunks_fts.source_type,
+			chunks_fts.source_path,
+			chunks_meta.token_count,
+			COALESCE(chunks_meta.metadata, '')
+		FROM chunks_meta
+		JOIN chunks_fts ON chunks_fts.rowid = chunks_meta.rowid
+		WHERE chunks_meta.source_path = ?
+		ORDER BY chunks_meta.rowid`,
+		chunk.SourcePath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunks of %s: %w", chunk.SourcePath, err)
+	}
+	defer rows.Close()
+
+	var chunks []FTSSearchResult
+	at := -1
+	for rows.Next() {
+		var r FTSSearchResult
+		var metadata string
+		if err := rows.Scan(&r.ID, &r.Content, &r.SourceType, &r.SourcePath, &r.TokenCount, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		r.Section = chunkSection(metadata)
+		if r.ID == id {
+			at = len(chunks)
+		}
+		chunks = append(chunks, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunks: %w", err)
+	}
+	if at < 0 {
+		return []FTSSearchResult{*chunk}, nil
+	}
+
+	from := max(at-radius, 0)
+	to := min(at+radius+1, len(chunks))
+	return chunks[from:to], nil
+}
+
 // sanitizeFTS5Query prepares a query string for FTS5 MATCH.
 // It escapes special characters and handles common query patterns.
 func sanitizeFTS5Query(query string) string {
@@ -0,0 +1,214 @@
+package search
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// fuzzyThreshold is the similarity, from 0 to 1, a word needs to count as
+// a fuzzy match for a query term.
+const fuzzyThreshold = 0.7
+
+// SearchWithFuzzyFallback runs SearchFiltered and, when it finds nothing,
+// retries with FuzzySearch so a typo or an inflected form still finds
+// something. Fuzzy results have Fuzzy set.
+func (e *FTSEngine) SearchWithFuzzyFallback(query string, filter SearchFilter, limit int) ([]FTSSearchResult, error) {
+	results, err := e.SearchFiltered(query, filter, limit)
+	if err != nil || len(results) > 0 {
+		return results, err
+	}
+	return e.FuzzySearch(query, filter, limit)
+}
+
+// FuzzySearch finds chunks in which every query term is close to some word
+// of the chunk, its file name or its section, by trigram similarity or edit
+// distance. It reads every chunk, so it is meant as a fallback when the
+// index finds nothing. Results have Fuzzy set and a Score from 0 to 1,
+// higher being closer, and are ordered best first.
+func (e *FTSEngine) FuzzySearch(query string, filter SearchFilter, limit int) ([]FTSSearchResult, error) {
+	terms := fuzzyWords(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	where := ""
+	var args []interface{}
+	if len(filter.SourceTypes) > 0 {
+		where = " WHERE chunks_meta.source_type IN (?" + strings.Repeat(", ?", len(filter.SourceTypes)-1) + ")"
+		for _, t := range filter.SourceTypes {
+			args = append(args, t)
+		}
+	}
+
+	rows, err := e.db.DB().Query(`
+		SELECT
+			chunks_fts.rowid,
+			chunks_fts.content,
+			chunks_fts.source_type,
+			chunks_fts.source_path,
+			chunks_meta.token_count,
+			COALESCE(chunks_meta.metadata, '')
+		FROM chunks_meta
+		JOIN chunks_fts ON chunks_fts.rowid = chunks_meta.rowid`+where+`
+		ORDER BY chunks_meta.rowid`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("fuzzy search query failed: %w", err)
+	}
+	defer rows.Close()
+
+	// Chunks share most of their vocabulary, so each word is compared
+	// with each term once.
+	similarities := make(map[string][]float64)
+	var results []FTSSearchResult
+	for rows.Next() {
+		var r FTSSearchResult
+		var metadata string
+		if err := rows.Scan(&r.ID, &r.Content, &r.SourceType, &r.SourcePath, &r.TokenCount, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		r.Section = chunkSection(metadata)
+		if !filter.includes(r) {
+			continue
+		}
+
+		title := strings.TrimSuffix(filepath.Base(r.SourcePath), filepath.Ext(r.SourcePath))
+		best := make([]float64, len(terms))
+		for _, word := range fuzzyWords(title + " " + r.Section + " " + r.Content) {
+			sims, ok := similarities[word]
+			if !ok {
+				sims = make([]float64, len(terms))
+				for i, term := range terms {
+					sims[i] = wordSimilarity(term, word)
+				}
+				similarities[word] = sims
+			}
+			for i, sim := range sims {
+				best[i] = max(best[i], sim)
+			}
+		}
+
+		var total float64
+		matched := true
+		for _, sim := range best {
+			if sim < fuzzyThreshold {
+				matched = false
+				break
+			}
+			total += sim
+		}
+		if !matched {
+			continue
+		}
+		r.Score = total / float64(len(terms))
+		r.Fuzzy = true
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// fuzzyWords splits text into lowercase words of letters and digits,
+// dropping single characters, which match too much to be useful.
+func fuzzyWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) > 1 {
+			kept = append(kept, w)
+		}
+	}
+	return kept
+}
+
+// prefixSimilarity is the score of a word that starts with the query term,
+// such as an inflected form or, in Korean, a name followed by a particle.
+const prefixSimilarity = 0.8
+
+// wordSimilarity scores how alike a query term and a word are from 0 to 1:
+// the better of their trigram overlap, which forgives inserted and missing
+// letters in long words, and their edit distance, which forgives a typo in
+// short ones. A word that starts with the term scores at least
+// prefixSimilarity.
+func wordSimilarity(term, word string) float64 {
+	if term == word {
+		return 1
+	}
+	if strings.HasPrefix(word, term) {
+		return prefixSimilarity
+	}
+	ra, rb := []rune(term), []rune(word)
+	longer := max(len(ra), len(rb))
+	// Words this different in length are too far apart to compare.
+	if float64(min(len(ra), len(rb)))/float64(longer) < fuzzyThreshold-0.2 {
+		return 0
+	}
+	edit := 1 - float64(levenshtein(ra, rb))/float64(longer)
+	return max(edit, trigramSimilarity(ra, rb))
+}
+
+// trigramSimilarity returns the Dice coefficient of the words' trigrams,
+// padded so the first and last letters count too.
+func trigramSimilarity(a, b []rune) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ta)+len(tb))
+}
+
+// trigrams returns the set of three-rune sequences in " word ".
+func trigrams(word []rune) map[string]bool {
+	padded := append(append([]rune{' '}, word...), ' ')
+	set := make(map[string]bool, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		set[string(padded[i:i+3])] = true
+	}
+	return set
+}
+
+// levenshtein returns the number of single-rune insertions, deletions and
+// substitutions that turn a into b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/azyu/dreamteller/internal/storage"
@@ -24,6 +26,8 @@ type Indexer struct {
 	counter      TokenCounter
 	chunkSize    int
 	chunkOverlap float64
+	chunking     string
+	exclude      *ExcludeList
 }
 
 // DefaultChunkSize is the default number of tokens per chunk.
@@ -32,8 +36,9 @@ const DefaultChunkSize = 800
 // DefaultChunkOverlap is the default overlap fraction between chunks.
 const DefaultChunkOverlap = 0.15
 
-// NewIndexer creates a new indexer with the specified configuration.
-func NewIndexer(engine *FTSEngine, counter TokenCounter, chunkSize int, overlap float64) *Indexer {
+// NewIndexer creates a new indexer with the specified configuration. It
+// chunks by tokens unless an option says otherwise.
+func NewIndexer(engine *FTSEngine, counter TokenCounter, chunkSize int, overlap float64, opts ...IndexerOption) *Indexer {
 	if chunkSize <= 0 {
 		chunkSize = DefaultChunkSize
 	}
@@ -41,12 +46,17 @@ func NewIndexer(engine *FTSEngine, counter TokenCounter, chunkSize int, overlap
 		overlap = DefaultChunkOverlap
 	}
 
-	return &Indexer{
+	idx := &Indexer{
 		engine:       engine,
 		counter:      counter,
 		chunkSize:    chunkSize,
 		chunkOverlap: overlap,
+		chunking:     ChunkingTokens,
 	}
+	for _, opt := range opts {
+		opt(idx)
+	}
+	return idx
 }
 
 // IndexFile indexes a single file by reading its content, splitting into chunks,
@@ -75,6 +85,7 @@ func (idx *Indexer) IndexFileWithContent(path, sourceType, content string, mtime
 	}
 
 	// Index each chunk
+	sections := chunkSections(content, chunks)
 	for i, chunk := range chunks {
 		chunkID := generateChunkID(path, i)
 		tokenCount := idx.counter.Count(chunk)
@@ -84,6 +95,9 @@ func (idx *Indexer) IndexFileWithContent(path, sourceType, content string, mtime
 			"total_chunks": len(chunks),
 			"chunk_id":     chunkID,
 		}
+		if sections[i] != "" {
+			metadata["section"] = sections[i]
+		}
 		metadataJSON, err := json.Marshal(metadata)
 		if err != nil {
 			return fmt.Errorf("failed to marshal metadata for chunk %d: %w", i, err)
@@ -116,6 +130,7 @@ func (idx *Indexer) indexDirectoryWithFS(fs *storage.FileSystem, dir, sourceType
 	if err != nil {
 		return fmt.Errorf("failed to list markdown files in %s: %w", dir, err)
 	}
+	files = idx.exclude.Filter(files)
 
 	var indexErrors []error
 	for _, file := range files {
@@ -132,38 +147,111 @@ func (idx *Indexer) indexDirectoryWithFS(fs *storage.FileSystem, dir, sourceType
 }
 
 func (idx *Indexer) indexFileWithFS(fs *storage.FileSystem, path, sourceType string) error {
+	content, mtime, err := readIndexable(fs, path)
+	if err != nil {
+		return err
+	}
+	return idx.IndexFileWithContent(path, sourceType, content, mtime)
+}
+
+// readIndexable reads the text of a file to index along with its
+// modification time.
+func readIndexable(fs *storage.FileSystem, path string) (string, time.Time, error) {
 	if fs == nil {
-		return fmt.Errorf("filesystem is required for file indexing")
+		return "", time.Time{}, fmt.Errorf("filesystem is required for file indexing")
 	}
 
-	content, err := fs.ReadMarkdown(path)
+	content, err := readText(fs, path)
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", path, err)
+		return "", time.Time{}, err
 	}
 
 	fileInfo, err := fs.GetFileInfo(path)
 	if err != nil {
-		return fmt.Errorf("failed to get file info for %s: %w", path, err)
+		return "", time.Time{}, fmt.Errorf("failed to get file info for %s: %w", path, err)
 	}
 
-	return idx.IndexFileWithContent(path, sourceType, content, fileInfo.ModTime)
+	return content, fileInfo.ModTime, nil
+}
+
+// indexTracked indexes content read from path and records its mtime and
+// hash in the file tracking.
+func (idx *Indexer) indexTracked(db *storage.SQLiteDB, path, content string, mtime time.Time) error {
+	if err := idx.IndexFileWithContent(path, determineSourceType(path), content, mtime); err != nil {
+		return fmt.Errorf("failed to reindex %s: %w", path, err)
+	}
+	if err := db.TrackFile(path, mtime, contentHash(content)); err != nil {
+		return fmt.Errorf("failed to update tracking for %s: %w", path, err)
+	}
+	return nil
+}
+
+// contentHash returns the hex SHA-256 of content, as kept in the file
+// tracking.
+func contentHash(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// SyncResult lists the files a sync brought up to date.
+type SyncResult struct {
+	// Reindexed are files that were new or changed, and Removed files
+	// whose chunks were dropped because they no longer exist or are
+	// excluded.
+	Reindexed []string
+	Removed   []string
+
+	// Skipped are research files whose text could not be extracted, such
+	// as PDFs when pdftotext is not installed.
+	Skipped []string
+
+	// FormatChanges is set instead when the index was built with other
+	// settings than the indexer's; see Indexer.FormatChanges. Syncing
+	// would mix chunks of both, so the index is left for a full rebuild.
+	FormatChanges []string
+}
+
+// Changed reports whether the sync touched the index at all.
+func (r SyncResult) Changed() bool {
+	return len(r.Reindexed) > 0 || len(r.Removed) > 0
 }
 
 // SyncWithFileSystem performs mtime-based incremental sync.
 // It compares file mtimes with indexed mtimes, reindexes changed files,
 // and deletes chunks for removed files.
 func (idx *Indexer) SyncWithFileSystem(fs *storage.FileSystem, db *storage.SQLiteDB) error {
+	_, err := idx.Sync(fs, db)
+	return err
+}
+
+// Sync brings the index up to date with the project files and reports
+// what it changed. A file whose mtime differs from the one tracked is
+// read, and reindexed only if its content hash differs too, so touching
+// or checking out a file does not rechunk it. Chunks of files that are
+// gone or excluded are deleted. An index built with other settings is not
+// synced.
+func (idx *Indexer) Sync(fs *storage.FileSystem, db *storage.SQLiteDB) (SyncResult, error) {
+	var result SyncResult
 	if fs == nil {
-		return fmt.Errorf("filesystem is required for sync")
+		return result, fmt.Errorf("filesystem is required for sync")
 	}
 	if db == nil {
-		return fmt.Errorf("database is required for sync")
+		return result, fmt.Errorf("database is required for sync")
+	}
+
+	changes, err := idx.FormatChanges(db)
+	if err != nil {
+		return result, err
+	}
+	if len(changes) > 0 {
+		result.FormatChanges = changes
+		return result, nil
 	}
 
 	// Get all currently tracked files from database
 	trackedFiles, err := db.GetAllTrackedFiles()
 	if err != nil {
-		return fmt.Errorf("failed to get tracked files: %w", err)
+		return result, fmt.Errorf("failed to get tracked files: %w", err)
 	}
 
 	// Build a map of tracked files for quick lookup
@@ -172,10 +260,10 @@ func (idx *Indexer) SyncWithFileSystem(fs *storage.FileSystem, db *storage.SQLit
 		trackedMap[tf.Path] = tf
 	}
 
-	// Get all current markdown files from filesystem
-	currentFiles, err := fs.ListMarkdownFiles(".")
+	// Get all current files to index from filesystem
+	currentFiles, err := idx.indexableFiles(fs)
 	if err != nil {
-		return fmt.Errorf("failed to list markdown files: %w", err)
+		return result, err
 	}
 
 	// Build a set of current file paths
@@ -187,36 +275,80 @@ func (idx *Indexer) SyncWithFileSystem(fs *storage.FileSystem, db *storage.SQLit
 	// Process current files
 	for _, file := range currentFiles {
 		tracked, exists := trackedMap[file.Path]
+		if exists && file.ModTime.Unix() == tracked.MTime.Unix() {
+			continue
+		}
 
-		needsReindex := !exists || file.ModTime.After(tracked.MTime)
-
-		if needsReindex {
-			sourceType := determineSourceType(file.Path)
-
-			if err := idx.indexFileWithFS(fs, file.Path, sourceType); err != nil {
-				return fmt.Errorf("failed to reindex %s: %w", file.Path, err)
+		content, mtime, err := readIndexable(fs, file.Path)
+		if err != nil && IsResearch(file.Path) {
+			result.Skipped = append(result.Skipped, file.Path)
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		hash := contentHash(content)
+		if exists && tracked.Hash == hash {
+			if err := db.TrackFile(file.Path, mtime, hash); err != nil {
+				return result, fmt.Errorf("failed to update tracking for %s: %w", file.Path, err)
 			}
+			continue
+		}
 
-			if err := db.UpdateFileTracking(file.Path, file.ModTime); err != nil {
-				return fmt.Errorf("failed to update tracking for %s: %w", file.Path, err)
-			}
+		if err := idx.indexTracked(db, file.Path, content, mtime); err != nil {
+			return result, err
 		}
+		result.Reindexed = append(result.Reindexed, file.Path)
 	}
 
-	// Delete chunks for removed files
+	// Delete chunks for removed and excluded files
 	for path := range trackedMap {
 		if _, exists := currentPaths[path]; !exists {
 			if err := idx.engine.DeleteBySource(path); err != nil {
-				return fmt.Errorf("failed to delete chunks for removed file %s: %w", path, err)
+				return result, fmt.Errorf("failed to delete chunks for removed file %s: %w", path, err)
 			}
 
 			if err := db.DeleteFileTracking(path); err != nil {
-				return fmt.Errorf("failed to delete tracking for %s: %w", path, err)
+				return result, fmt.Errorf("failed to delete tracking for %s: %w", path, err)
 			}
+			result.Removed = append(result.Removed, path)
 		}
 	}
+	slices.Sort(result.Removed)
 
-	return nil
+	// An index synced from empty is built with the indexer's settings.
+	if err := db.SetIndexFormat(idx.Format()); err != nil {
+		return result, fmt.Errorf("failed to record index format: %w", err)
+	}
+	return result, nil
+}
+
+// SyncFile brings one file's chunks up to date: it reindexes the file, or
+// drops its chunks when the file no longer exists or is excluded, and
+// updates its tracking so the next incremental sync skips it.
+func (idx *Indexer) SyncFile(fs *storage.FileSystem, db *storage.SQLiteDB, path string) error {
+	if fs == nil {
+		return fmt.Errorf("filesystem is required for sync")
+	}
+	if db == nil {
+		return fmt.Errorf("database is required for sync")
+	}
+
+	if !fs.Exists(path) || idx.exclude.Excludes(path) {
+		if err := idx.engine.DeleteBySource(path); err != nil {
+			return fmt.Errorf("failed to delete chunks for removed file %s: %w", path, err)
+		}
+		if err := db.DeleteFileTracking(path); err != nil {
+			return fmt.Errorf("failed to delete tracking for %s: %w", path, err)
+		}
+		return nil
+	}
+
+	content, mtime, err := readIndexable(fs, path)
+	if err != nil {
+		return fmt.Errorf("failed to reindex %s: %w", path, err)
+	}
+	return idx.indexTracked(db, path, content, mtime)
 }
 
 // FullReindex clears the entire index and rebuilds it from scratch.
@@ -260,35 +392,60 @@ func (idx *Indexer) FullReindexWithDB(fs *storage.FileSystem, db *storage.SQLite
 		}
 	}
 
-	// Get all markdown files
-	files, err := fs.ListMarkdownFiles(".")
+	// Get all files to index
+	files, err := idx.indexableFiles(fs)
 	if err != nil {
-		return fmt.Errorf("failed to list markdown files: %w", err)
+		return err
 	}
 
-	// Index each file
+	// Index each file. Research files whose text cannot be extracted are
+	// left out; the next sync reports them.
 	for _, file := range files {
-		sourceType := determineSourceType(file.Path)
-
-		if err := idx.indexFileWithFS(fs, file.Path, sourceType); err != nil {
+		content, mtime, err := readIndexable(fs, file.Path)
+		if err != nil && IsResearch(file.Path) {
+			continue
+		}
+		if err != nil {
 			return fmt.Errorf("failed to index %s: %w", file.Path, err)
 		}
-
-		if err := db.UpdateFileTracking(file.Path, file.ModTime); err != nil {
-			return fmt.Errorf("failed to update tracking for %s: %w", file.Path, err)
+		if err := idx.indexTracked(db, file.Path, content, mtime); err != nil {
+			return err
 		}
 	}
 
+	if err := db.SetIndexFormat(idx.Format()); err != nil {
+		return fmt.Errorf("failed to record index format: %w", err)
+	}
 	return nil
 }
 
+// indexableFiles lists the files the index covers: markdown files across
+// the project and research material, minus generated and excluded files.
+func (idx *Indexer) indexableFiles(fs *storage.FileSystem) ([]storage.FileInfo, error) {
+	files, err := fs.ListMarkdownFiles(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list markdown files: %w", err)
+	}
+	research, err := fs.ListFiles(ResearchDir, researchExts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list research files: %w", err)
+	}
+	files = append(files, research...)
+	return idx.exclude.Filter(skipGeneratedFiles(files)), nil
+}
+
 // chunkContent splits content into overlapping chunks using the token counter.
 func (idx *Indexer) chunkContent(content string) []string {
 	if content == "" {
 		return nil
 	}
 
-	chunks := idx.counter.Split(content, idx.chunkSize, idx.chunkOverlap)
+	var chunks []string
+	if idx.chunking == ChunkingSemantic {
+		chunks = splitSemantic(idx.counter, content, idx.chunkSize, idx.chunkOverlap)
+	} else {
+		chunks = idx.counter.Split(content, idx.chunkSize, idx.chunkOverlap)
+	}
 	if len(chunks) == 0 {
 		return nil
 	}
@@ -303,8 +460,29 @@ func generateChunkID(path string, index int) string {
 	return hex.EncodeToString(hash[:8])
 }
 
+// generatedDirs hold files dreamteller writes itself, such as drafts,
+// batch results, translations, scene scaffolds and exports, which are not
+// story context.
+var generatedDirs = []string{".dreamteller", "batch", "translations", "scenes", "exports"}
+
+// skipGeneratedFiles drops files under generatedDirs.
+func skipGeneratedFiles(files []storage.FileInfo) []storage.FileInfo {
+	kept := files[:0]
+	for _, f := range files {
+		top := strings.Split(filepath.ToSlash(f.Path), "/")[0]
+		if !slices.Contains(generatedDirs, top) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
 // determineSourceType infers the source type from the file path.
 func determineSourceType(path string) string {
+	if IsResearch(path) {
+		return SourceTypeResearch
+	}
+
 	dir := filepath.Dir(path)
 	base := filepath.Base(dir)
 
@@ -313,8 +491,10 @@ func determineSourceType(path string) string {
 		return SourceTypeCharacter
 	case "world", "settings":
 		return SourceTypeSetting
-	case "plots":
+	case "plot", "plots":
 		return SourceTypePlot
+	case "glossary":
+		return SourceTypeGlossary
 	case "chapters":
 		return SourceTypeChapter
 	default:
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"fmt"
+	"time"
+)
+
+// IndexedChunk is a chunk as stored in the index.
+type IndexedChunk struct {
+	ID         int64
+	Content    string
+	SourceType string
+	SourcePath string
+	TokenCount int
+	Section    string
+
+	// MTime is the modification time of the file when it was indexed.
+	MTime time.Time
+}
+
+// IndexedSource summarizes the chunks indexed for one file.
+type IndexedSource struct {
+	SourcePath string
+	SourceType string
+	Chunks     int
+	Tokens     int
+
+	// MTime is the modification time of the file when it was indexed.
+	MTime time.Time
+}
+
+// GetChunksBySource returns every chunk indexed for a file, in the order
+// they appear in it.
+func (e *FTSEngine) GetChunksBySource(sourcePath string) ([]IndexedChunk, error) {
+	rows, err := e.db.DB().Query(`
+		SELECT
+			chunks_fts.rowid,
+			chunks_fts.content,
+			chunks_fts.source_type,
+			chunks_fts.source_path,
+			chunks_meta.token_count,
+			chunks_meta.mtime,
+			COALESCE(chunks_meta.metadata, '')
+		FROM chunks_fts
+		JOIN chunks_meta ON chunks_fts.rowid = chunks_meta.rowid
+		WHERE chunks_meta.source_path = ?
+		ORDER BY chunks_fts.rowid`,
+		sourcePath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunks for %s: %w", sourcePath, err)
+	}
+	defer rows.Close()
+
+	var chunks []IndexedChunk
+	for rows.Next() {
+		var c IndexedChunk
+		var mtime int64
+		var metadata string
+		if err := rows.Scan(&c.ID, &c.Content, &c.SourceType, &c.SourcePath, &c.TokenCount, &mtime, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		c.MTime = time.Unix(mtime, 0)
+		c.Section = chunkSection(metadata)
+		chunks = append(chunks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunks: %w", err)
+	}
+	return chunks, nil
+}
+
+// ListSources returns a summary of every indexed file, sorted by path.
+func (e *FTSEngine) ListSources() ([]IndexedSource, error) {
+	rows, err := e.db.DB().Query(`
+		SELECT source_path, source_type, COUNT(*), SUM(token_count), MAX(mtime)
+		FROM chunks_meta
+		GROUP BY source_path, source_type
+		ORDER BY source_path`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []IndexedSource
+	for rows.Next() {
+		var s IndexedSource
+		var mtime int64
+		if err := rows.Scan(&s.SourcePath, &s.SourceType, &s.Chunks, &s.Tokens, &mtime); err != nil {
+			return nil, fmt.Errorf("failed to scan indexed file: %w", err)
+		}
+		s.MTime = time.Unix(mtime, 0)
+		sources = append(sources, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating indexed files: %w", err)
+	}
+	return sources, nil
+}
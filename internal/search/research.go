@@ -0,0 +1,232 @@
+package search
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// ResearchDir holds research material: articles, notes and books kept for
+// reference. It is searched like any other file but only reaches the
+// model's context automatically once pinned.
+const ResearchDir = "research"
+
+// researchExts are the files indexed under ResearchDir besides markdown.
+var researchExts = []string{".txt", ".pdf", ".epub"}
+
+// pdfToText is the poppler tool that extracts a PDF's text.
+var pdfToText = "pdftotext"
+
+// ErrNoPDFTool is returned for a PDF when pdftotext is not installed.
+var ErrNoPDFTool = errors.New("pdftotext is not installed")
+
+// IsResearch reports whether path is under ResearchDir.
+func IsResearch(p string) bool {
+	top, _, _ := strings.Cut(filepath.ToSlash(filepath.Clean(p)), "/")
+	return top == ResearchDir
+}
+
+// readText reads the text to index from a file: markdown and text files as
+// they are, PDF and EPUB files by extracting their text.
+func readText(fs *storage.FileSystem, p string) (string, error) {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".pdf":
+		return extractPDF(fs.AbsPath(p))
+	case ".epub":
+		data, err := fs.ReadFile(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s: %w", p, err)
+		}
+		return extractEPUB(data)
+	default:
+		content, err := fs.ReadMarkdown(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s: %w", p, err)
+		}
+		return content, nil
+	}
+}
+
+// extractPDF returns the text of a PDF, page by page, using pdftotext.
+func extractPDF(file string) (string, error) {
+	tool, err := exec.LookPath(pdfToText)
+	if err != nil {
+		return "", ErrNoPDFTool
+	}
+	out, err := exec.Command(tool, "-enc", "UTF-8", file, "-").Output()
+	if err != nil {
+		return "", fmt.Errorf("pdftotext failed on %s: %w", filepath.Base(file), err)
+	}
+	// Pages are separated by form feeds; make them paragraph breaks.
+	return strings.TrimSpace(strings.ReplaceAll(string(out), "\f", "\n\n")), nil
+}
+
+// extractEPUB returns the text of an EPUB's documents in reading order,
+// one paragraph per block and headings as markdown headings, so semantic
+// chunking cuts it at its chapters.
+func extractEPUB(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not an EPUB: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	docs, err := epubSpine(files)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, name := range docs {
+		f, ok := files[name]
+		if !ok {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open %s in EPUB: %w", name, err)
+		}
+		text, err := xhtmlText(rc)
+		rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s in EPUB: %w", name, err)
+		}
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// epubSpine returns the zip names of an EPUB's content documents in
+// reading order, from the package document its container points to.
+func epubSpine(files map[string]*zip.File) ([]string, error) {
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := readZipXML(files["META-INF/container.xml"], &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil, fmt.Errorf("EPUB has no package document")
+	}
+	opf := container.Rootfiles[0].FullPath
+
+	var pkg struct {
+		Items []struct {
+			ID   string `xml:"id,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := readZipXML(files[opf], &pkg); err != nil {
+		return nil, fmt.Errorf("failed to read EPUB package document: %w", err)
+	}
+
+	hrefs := make(map[string]string, len(pkg.Items))
+	for _, item := range pkg.Items {
+		hrefs[item.ID] = item.Href
+	}
+	var docs []string
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		docs = append(docs, path.Join(path.Dir(opf), href))
+	}
+	return docs, nil
+}
+
+// readZipXML decodes an XML file in a zip archive into v.
+func readZipXML(f *zip.File, v any) error {
+	if f == nil {
+		return fmt.Errorf("missing file")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// textBlocks are the (X)HTML elements that start and end a paragraph.
+var textBlocks = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "blockquote": true, "section": true,
+	"tr": true, "pre": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// xhtmlText returns the text of an (X)HTML document: one paragraph per
+// block, with whitespace collapsed, and headings prefixed with #. Scripts,
+// styles and the head are skipped.
+func xhtmlText(r io.Reader) (string, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	var paragraphs []string
+	var current strings.Builder
+	flush := func() {
+		text := strings.Join(strings.Fields(current.String()), " ")
+		if strings.Trim(text, "# ") != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		current.Reset()
+	}
+
+	skipping := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch name := strings.ToLower(t.Name.Local); {
+			case name == "head" || name == "script" || name == "style":
+				skipping++
+			case textBlocks[name]:
+				flush()
+				if len(name) == 2 && name[0] == 'h' {
+					current.WriteString(strings.Repeat("#", int(name[1]-'0')) + " ")
+				}
+			}
+		case xml.EndElement:
+			switch name := strings.ToLower(t.Name.Local); {
+			case name == "head" || name == "script" || name == "style":
+				if skipping > 0 {
+					skipping--
+				}
+			case textBlocks[name]:
+				flush()
+			}
+		case xml.CharData:
+			if skipping == 0 {
+				current.Write(t)
+			}
+		}
+	}
+	flush()
+	return strings.Join(paragraphs, "\n\n"), nil
+}
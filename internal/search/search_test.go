@@ -1,9 +1,13 @@
 package search
 
 import (
+	"archive/zip"
+	"bytes"
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -187,6 +191,72 @@ func TestFTSEngine_SearchWithFilter(t *testing.T) {
 	assert.Equal(t, SourceTypePlot, results[0].SourceType)
 }
 
+func TestFTSEngine_SearchFiltered(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	engine := NewFTSEngine(db)
+
+	now := time.Now()
+	require.NoError(t, engine.Index("The storm broke over the harbor", SourceTypeChapter, "chapters/chapter-001.md", 6, now, "{}"))
+	require.NoError(t, engine.Index("A storm of arrows fell", SourceTypeChapter, "chapters/chapter-002.md", 5, now, "{}"))
+	require.NoError(t, engine.Index("The storm season lasts a month", SourceTypeChapter, "chapters/chapter-010.md", 6, now, "{}"))
+	require.NoError(t, engine.Index("Storm magic is forbidden", SourceTypePlot, "context/plot/rules.md", 4, now, "{}"))
+	require.NoError(t, engine.Index("Mira fears the storm", SourceTypeCharacter, "context/characters/mira.md", 4, now, "{}"))
+
+	paths := func(results []FTSSearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.SourcePath)
+		}
+		return out
+	}
+
+	t.Run("multiple source types", func(t *testing.T) {
+		results, err := engine.SearchFiltered("storm", SearchFilter{SourceTypes: []string{SourceTypePlot, SourceTypeCharacter}}, 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"context/plot/rules.md", "context/characters/mira.md"}, paths(results))
+	})
+
+	t.Run("chapter range", func(t *testing.T) {
+		results, err := engine.SearchFiltered("storm", SearchFilter{SourceTypes: []string{SourceTypeChapter}, ChapterFrom: 2, ChapterTo: 10}, 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"chapters/chapter-002.md", "chapters/chapter-010.md"}, paths(results))
+
+		results, err = engine.SearchFiltered("storm", SearchFilter{ChapterTo: 1}, 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"chapters/chapter-001.md", "context/plot/rules.md", "context/characters/mira.md"}, paths(results))
+	})
+
+	t.Run("limit applies after the chapter range", func(t *testing.T) {
+		results, err := engine.SearchFiltered("storm", SearchFilter{SourceTypes: []string{SourceTypeChapter}, ChapterFrom: 2}, 1)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.NotEqual(t, "chapters/chapter-001.md", results[0].SourcePath)
+	})
+}
+
+func TestChapterNumber(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+		ok   bool
+	}{
+		{"chapters/chapter-003.md", 3, true},
+		{"chapters/12-the-flood.md", 12, true},
+		{"chapters/v2/chapter7.md", 7, true},
+		{"chapters/prologue.md", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			n, ok := ChapterNumber(tt.path)
+			assert.Equal(t, tt.want, n)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
 func TestFTSEngine_DeleteBySource(t *testing.T) {
 	db, cleanup := testDB(t)
 	defer cleanup()
@@ -530,6 +600,16 @@ func TestDetermineSourceType(t *testing.T) {
 			path:     "readme.md",
 			expected: "document",
 		},
+		{
+			name:     "plot directory",
+			path:     "/project/context/plot/main.md",
+			expected: SourceTypePlot,
+		},
+		{
+			name:     "glossary directory",
+			path:     "/project/context/glossary/terms.md",
+			expected: SourceTypeGlossary,
+		},
 		{
 			name:     "deeply nested plots",
 			path:     "/a/b/c/plots/story.md",
@@ -713,6 +793,166 @@ func TestIndexer_IndexFileWithContent_EmptyContent(t *testing.T) {
 	assert.Equal(t, int64(0), count)
 }
 
+func TestIndexer_SyncFile(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	engine := NewFTSEngine(db)
+	counter := &mockTokenCounter{
+		countFunc: func(text string) int { return len(text) / 4 },
+		splitFunc: func(text string, chunkSize int, overlap float64) []string { return []string{text} },
+	}
+	indexer := NewIndexer(engine, counter, 800, 0.15)
+
+	root := t.TempDir()
+	fs := storage.NewFileSystem(root)
+	path := filepath.Join("context", "characters", "hero.md")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "context", "characters"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte("The hero is brave."), 0644))
+
+	require.NoError(t, indexer.SyncFile(fs, db, path))
+	results, err := engine.Search("brave", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, SourceTypeCharacter, results[0].SourceType)
+
+	tracked, err := db.GetFileTracking(path)
+	require.NoError(t, err)
+	require.NotNil(t, tracked)
+
+	require.NoError(t, os.Remove(filepath.Join(root, path)))
+	require.NoError(t, indexer.SyncFile(fs, db, path))
+	count, err := engine.GetChunkCount()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	tracked, err = db.GetFileTracking(path)
+	require.NoError(t, err)
+	assert.Nil(t, tracked)
+}
+
+func TestIndexer_Sync(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	engine := NewFTSEngine(db)
+	counter := &mockTokenCounter{
+		countFunc: func(text string) int { return len(text) / 4 },
+		splitFunc: func(text string, chunkSize int, overlap float64) []string { return []string{text} },
+	}
+	indexer := NewIndexer(engine, counter, 800, 0.15)
+
+	root := t.TempDir()
+	fs := storage.NewFileSystem(root)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "chapters"), 0755))
+	one := filepath.Join("chapters", "one.md")
+	two := filepath.Join("chapters", "two.md")
+	require.NoError(t, os.WriteFile(filepath.Join(root, one), []byte("The storm broke."), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, two), []byte("The harbor slept."), 0644))
+
+	result, err := indexer.Sync(fs, db)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{one, two}, result.Reindexed)
+
+	t.Run("skips unchanged files", func(t *testing.T) {
+		result, err := indexer.Sync(fs, db)
+		require.NoError(t, err)
+		assert.False(t, result.Changed())
+	})
+
+	t.Run("touched files only update tracking", func(t *testing.T) {
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(root, one), later, later))
+
+		result, err := indexer.Sync(fs, db)
+		require.NoError(t, err)
+		assert.False(t, result.Changed())
+
+		tracked, err := db.GetFileTracking(one)
+		require.NoError(t, err)
+		assert.Equal(t, later.Unix(), tracked.MTime.Unix())
+	})
+
+	t.Run("reindexes edited and drops removed files", func(t *testing.T) {
+		// An older mtime counts too, as when a draft is restored from a
+		// backup.
+		earlier := time.Now().Add(-24 * time.Hour)
+		require.NoError(t, os.WriteFile(filepath.Join(root, one), []byte("The storm passed."), 0644))
+		require.NoError(t, os.Chtimes(filepath.Join(root, one), earlier, earlier))
+		require.NoError(t, os.Remove(filepath.Join(root, two)))
+
+		result, err := indexer.Sync(fs, db)
+		require.NoError(t, err)
+		assert.Equal(t, []string{one}, result.Reindexed)
+		assert.Equal(t, []string{two}, result.Removed)
+
+		results, err := engine.Search("broke", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		results, err = engine.Search("passed", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+		results, err = engine.Search("harbor", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestIndexer_FormatChanges(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	engine := NewFTSEngine(db)
+	counter := &mockTokenCounter{
+		countFunc: func(text string) int { return len(text) / 4 },
+		splitFunc: func(text string, chunkSize int, overlap float64) []string { return []string{text} },
+	}
+	root := t.TempDir()
+	fs := storage.NewFileSystem(root)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "chapters"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "chapters", "one.md"), []byte("The storm broke."), 0644))
+
+	indexer := NewIndexer(engine, counter, 500, 0.1)
+	changes, err := indexer.FormatChanges(db)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "an empty index matches anything")
+
+	_, err = indexer.Sync(fs, db)
+	require.NoError(t, err)
+	changes, err = indexer.FormatChanges(db)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	rechunked := NewIndexer(engine, counter, 800, 0.1, WithChunking(ChunkingSemantic))
+	changes, err = rechunked.FormatChanges(db)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chunk size 500 → 800", "chunking tokens → semantic"}, changes)
+
+	t.Run("sync leaves a mismatched index for a rebuild", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, "chapters", "two.md"), []byte("The harbor slept."), 0644))
+		result, err := rechunked.Sync(fs, db)
+		require.NoError(t, err)
+		assert.Equal(t, changes, result.FormatChanges)
+		assert.False(t, result.Changed())
+
+		require.NoError(t, rechunked.FullReindexWithDB(fs, db))
+		changes, err := rechunked.FormatChanges(db)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+		results, err := engine.Search("harbor", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("an index without recorded settings needs a rebuild", func(t *testing.T) {
+		_, err := db.DB().Exec("DELETE FROM index_format")
+		require.NoError(t, err)
+		changes, err := rechunked.FormatChanges(db)
+		require.NoError(t, err)
+		assert.Len(t, changes, 1)
+	})
+}
+
 func TestIndexer_DefaultValues(t *testing.T) {
 	db, cleanup := testDB(t)
 	defer cleanup()
@@ -994,6 +1234,7 @@ func TestIsValidSourceType(t *testing.T) {
 		{SourceTypeSetting, true},
 		{SourceTypePlot, true},
 		{SourceTypeChapter, true},
+		{SourceTypeGlossary, true},
 		{"", true}, // empty is valid (matches all)
 		{"unknown", false},
 		{"CHAPTER", false}, // case sensitive
@@ -1123,6 +1364,114 @@ func TestFTSEngine_GetChunkByID_NotFound(t *testing.T) {
 	assert.Nil(t, chunk)
 }
 
+func TestFTSEngine_ExpandChunk(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	engine := NewFTSEngine(db)
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, engine.Index(fmt.Sprintf("Part %d of the voyage", i), SourceTypeChapter, "chapters/chapter-001.md", 5, now, "{}"))
+		require.NoError(t, engine.Index(fmt.Sprintf("Other file part %d", i), SourceTypeChapter, "chapters/chapter-002.md", 5, now, "{}"))
+	}
+
+	results, err := engine.Search("voyage", 10)
+	require.NoError(t, err)
+	var third int64
+	for _, r := range results {
+		if r.Content == "Part 3 of the voyage" {
+			third = r.ID
+		}
+	}
+	require.NotZero(t, third)
+
+	contents := func(chunks []FTSSearchResult) []string {
+		var out []string
+		for _, c := range chunks {
+			out = append(out, c.Content)
+		}
+		return out
+	}
+
+	t.Run("default radius reads one chunk each side", func(t *testing.T) {
+		chunks, err := engine.ExpandChunk(third, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Part 2 of the voyage", "Part 3 of the voyage", "Part 4 of the voyage"}, contents(chunks))
+	})
+
+	t.Run("stops at the ends of the file", func(t *testing.T) {
+		chunks, err := engine.ExpandChunk(third, 10)
+		require.NoError(t, err)
+		assert.Len(t, chunks, 5)
+		assert.Equal(t, "Part 1 of the voyage", chunks[0].Content)
+		assert.Equal(t, "Part 5 of the voyage", chunks[4].Content)
+	})
+
+	t.Run("unknown chunk", func(t *testing.T) {
+		chunks, err := engine.ExpandChunk(99999, 1)
+		require.NoError(t, err)
+		assert.Nil(t, chunks)
+	})
+}
+
+func TestFTSEngine_FuzzySearch(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	engine := NewFTSEngine(db)
+	now := time.Now()
+	require.NoError(t, engine.Index("The lighthouse keeper kept a logbook of every storm.", SourceTypeChapter, "chapters/chapter-001.md", 10, now, "{}"))
+	require.NoError(t, engine.Index("하나는 바다를 두려워한다.", SourceTypeCharacter, "context/characters/hana.md", 10, now, "{}"))
+	require.NoError(t, engine.Index("Grain prices doubled after the drought.", SourceTypeSetting, "context/settings/harbor-town.md", 10, now, "{}"))
+
+	t.Run("typos", func(t *testing.T) {
+		results, err := engine.FuzzySearch("lighthose keper", SearchFilter{}, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "chapters/chapter-001.md", results[0].SourcePath)
+		assert.True(t, results[0].Fuzzy)
+		assert.Greater(t, results[0].Score, 0.7)
+	})
+
+	t.Run("word endings", func(t *testing.T) {
+		results, err := engine.FuzzySearch("하나", SearchFilter{}, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "context/characters/hana.md", results[0].SourcePath)
+	})
+
+	t.Run("file names", func(t *testing.T) {
+		results, err := engine.FuzzySearch("harbour", SearchFilter{}, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "context/settings/harbor-town.md", results[0].SourcePath)
+	})
+
+	t.Run("every term must match", func(t *testing.T) {
+		results, err := engine.FuzzySearch("lighthose dragon", SearchFilter{}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		results, err := engine.FuzzySearch("lighthose", SearchFilter{SourceTypes: []string{SourceTypeCharacter}}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("fallback only when nothing matches exactly", func(t *testing.T) {
+		results, err := engine.SearchWithFuzzyFallback("logbook", SearchFilter{}, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Fuzzy)
+
+		results, err = engine.SearchWithFuzzyFallback("logbok", SearchFilter{}, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, results[0].Fuzzy)
+	})
+}
+
 // ============================================================================
 // TestFTSEngine_SearchWithHighlight
 // ============================================================================
@@ -1245,3 +1594,304 @@ func testDBRaw(t *testing.T) (*sql.DB, func()) {
 
 	return db, cleanup
 }
+
+func TestSkipGeneratedFiles(t *testing.T) {
+	files := []storage.FileInfo{
+		{Path: "chapters/chapter-001.md"},
+		{Path: ".dreamteller/draft.md"},
+		{Path: "translations/ja/chapter-001.md"},
+		{Path: "batch/summarize/chapter-001.md"},
+		{Path: "scenes/2-3-landfall.md"},
+		{Path: "context/characters/hana.md"},
+	}
+
+	kept := skipGeneratedFiles(files)
+	require.Len(t, kept, 2)
+	assert.Equal(t, "chapters/chapter-001.md", kept[0].Path)
+	assert.Equal(t, "context/characters/hana.md", kept[1].Path)
+}
+
+func TestSplitSemantic(t *testing.T) {
+	// One token per word keeps the sizes readable.
+	words := &mockTokenCounter{countFunc: func(text string) int { return len(strings.Fields(text)) }}
+
+	t.Run("keeps content that fits whole", func(t *testing.T) {
+		content := "# Hana\n\nA quiet girl.\n"
+		assert.Equal(t, []string{content}, splitSemantic(words, content, 10, 0.15))
+		assert.Nil(t, splitSemantic(words, "  \n", 10, 0.15))
+	})
+
+	t.Run("starts a chunk at each heading", func(t *testing.T) {
+		content := "# Hana\n## Backstory\n\nBorn in a harbor town.\n\n## Goals\n\nShe wants to leave it.\n"
+		assert.Equal(t, []string{
+			"# Hana\n## Backstory\n\nBorn in a harbor town.",
+			"## Goals\n\nShe wants to leave it.",
+		}, splitSemantic(words, content, 10, 0.5))
+	})
+
+	t.Run("cuts long paragraphs between sentences with overlap", func(t *testing.T) {
+		content := "One two three. Four five six. Seven eight nine. Ten eleven twelve."
+		assert.Equal(t, []string{
+			"One two three. Four five six.",
+			"Four five six. Seven eight nine.",
+			"Seven eight nine. Ten eleven twelve.",
+		}, splitSemantic(words, content, 7, 0.5))
+	})
+
+	t.Run("cuts a sentence too long for a chunk between words", func(t *testing.T) {
+		chunks := splitSemantic(words, strings.Repeat("word ", 10), 4, 0)
+		assert.Equal(t, []string{"word word word word", "word word word word", "word word"}, chunks)
+	})
+
+	t.Run("keeps fenced code together", func(t *testing.T) {
+		blocks := markdownBlocks("Intro.\n\n```\n# not a heading\n\ncode\n```\n\nOutro.\n")
+		require.Len(t, blocks, 3)
+		assert.False(t, blocks[1].heading)
+	})
+
+	t.Run("is used by indexers configured for it", func(t *testing.T) {
+		content := "# Hana\n\nBorn in a harbor town.\n\n# Minho\n\nA fisherman.\n"
+		indexer := NewIndexer(nil, words, 8, 0, WithChunking(ChunkingSemantic))
+		assert.Equal(t, []string{"# Hana\n\nBorn in a harbor town.", "# Minho\n\nA fisherman."}, indexer.chunkContent(content))
+	})
+}
+
+func TestCutSentences(t *testing.T) {
+	text := `그가 말했다. "가자!" 하나는 웃었다…… 끝.`
+	var sentences []string
+	from := 0
+	for _, cut := range append(cutSentences(text), len(text)) {
+		sentences = append(sentences, text[from:cut])
+		from = cut
+	}
+	assert.Equal(t, []string{"그가 말했다. ", `"가자!" `, "하나는 웃었다…… ", "끝."}, sentences)
+}
+
+func TestChunkSections(t *testing.T) {
+	content := "# Hana\n\nA quiet girl.\n\n## Backstory\n\nBorn in a harbor town.\n\n### Childhood\n\nShe fished.\n\n## Goals ##\n\nShe wants to leave.\n"
+	chunks := []string{
+		"A quiet girl.",
+		"## Backstory\n\nBorn in a harbor town.",
+		"She fished.",
+		"She wants to leave.",
+		"not in the content",
+	}
+	assert.Equal(t, []string{
+		"Hana",
+		"Hana > Backstory",
+		"Hana > Backstory > Childhood",
+		"Hana > Goals",
+		"Hana > Goals",
+	}, chunkSections(content, chunks))
+
+	t.Run("is stored with the chunk and returned by searches", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+		engine := NewFTSEngine(db)
+		words := &mockTokenCounter{countFunc: func(text string) int { return len(strings.Fields(text)) }}
+		indexer := NewIndexer(engine, words, 12, 0, WithChunking(ChunkingSemantic))
+		require.NoError(t, indexer.IndexFileWithContent("context/characters/hana.md", SourceTypeCharacter, content, time.Now()))
+
+		results, err := engine.Search("harbor", 5)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Hana > Backstory", results[0].Section)
+		assert.Equal(t, "context/characters/hana.md § Hana > Backstory", results[0].Location())
+
+		chunk, err := engine.GetChunkByID(results[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Hana > Backstory", chunk.Section)
+	})
+}
+
+func TestFTSEngine_GetChunksBySource(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+	engine := NewFTSEngine(db)
+	words := &mockTokenCounter{countFunc: func(text string) int { return len(strings.Fields(text)) }}
+	indexer := NewIndexer(engine, words, 8, 0, WithChunking(ChunkingSemantic))
+
+	mtime := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, indexer.IndexFileWithContent("context/characters/hana.md", SourceTypeCharacter,
+		"# Hana\n\nA quiet girl from the harbor.\n\n## Goals\n\nShe wants to leave the town.\n", mtime))
+	require.NoError(t, indexer.IndexFileWithContent("chapters/chapter-001.md", SourceTypeChapter, "The storm broke.", mtime))
+
+	chunks, err := engine.GetChunksBySource("context/characters/hana.md")
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "# Hana\n\nA quiet girl from the harbor.", chunks[0].Content)
+	assert.Equal(t, "Hana > Goals", chunks[1].Section)
+	assert.Equal(t, 8, chunks[1].TokenCount)
+	assert.True(t, mtime.Equal(chunks[1].MTime))
+
+	none, err := engine.GetChunksBySource("context/characters/minho.md")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	sources, err := engine.ListSources()
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "chapters/chapter-001.md", sources[0].SourcePath)
+	assert.Equal(t, IndexedSource{
+		SourcePath: "context/characters/hana.md",
+		SourceType: SourceTypeCharacter,
+		Chunks:     2,
+		Tokens:     16,
+		MTime:      sources[1].MTime,
+	}, sources[1])
+	assert.True(t, mtime.Equal(sources[1].MTime))
+}
+
+func TestExcludeList(t *testing.T) {
+	list := NewExcludeList(append([]string{"scrap", "*.old.md", "research/*", "drafts/"},
+		ParseIgnoreFile("# notes\n\ncontext/characters/minor-*.md\r\n")...)...)
+
+	for path, excluded := range map[string]bool{
+		"scrap/idea.md":                     true,
+		"chapters/scrap/idea.md":            true,
+		"chapters/scrapbook.md":             false,
+		"chapters/chapter-001.old.md":       true,
+		"research/ships.md":                 true,
+		"research/deep/ships.md":            true,
+		"notes/research/ships.md":           false,
+		"drafts/chapter-002.md":             true,
+		"drafts":                            false,
+		"context/characters/minor-guard.md": true,
+		"context/characters/hana.md":        false,
+	} {
+		assert.Equal(t, excluded, list.Excludes(filepath.FromSlash(path)), path)
+	}
+
+	var none *ExcludeList
+	assert.False(t, none.Excludes("scrap/idea.md"))
+}
+
+func TestIndexer_SyncExcludes(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	engine := NewFTSEngine(db)
+	counter := &mockTokenCounter{
+		countFunc: func(text string) int { return len(text) / 4 },
+		splitFunc: func(text string, chunkSize int, overlap float64) []string { return []string{text} },
+	}
+	root := t.TempDir()
+	fs := storage.NewFileSystem(root)
+	for _, dir := range []string{"chapters", "scrap"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	keep := filepath.Join("chapters", "one.md")
+	scrap := filepath.Join("scrap", "idea.md")
+	require.NoError(t, os.WriteFile(filepath.Join(root, keep), []byte("The storm broke."), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, scrap), []byte("A discarded storm."), 0644))
+
+	_, err := NewIndexer(engine, counter, 800, 0.15).Sync(fs, db)
+	require.NoError(t, err)
+
+	indexer := NewIndexer(engine, counter, 800, 0.15, WithExclude(NewExcludeList("scrap/")))
+	result, err := indexer.Sync(fs, db)
+	require.NoError(t, err)
+	assert.Equal(t, []string{scrap}, result.Removed)
+
+	results, err := engine.Search("storm", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, keep, results[0].SourcePath)
+
+	require.NoError(t, indexer.SyncFile(fs, db, scrap))
+	count, err := engine.GetChunkCount()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+// writeEPUB writes a minimal EPUB whose spine lists chapters in order.
+func writeEPUB(t *testing.T, file string, chapters map[string]string, spine ...string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name, content string) {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	add("mimetype", "application/epub+zip")
+	add("META-INF/container.xml", `<?xml version="1.0"?>
+<container xmlns="urn:oasis:names:tc:opendocument:xmlns:container" version="1.0">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`)
+	var items, refs strings.Builder
+	for i, name := range spine {
+		fmt.Fprintf(&items, `<item id="c%d" href="%s" media-type="application/xhtml+xml"/>`, i, name)
+		fmt.Fprintf(&refs, `<itemref idref="c%d"/>`, i)
+	}
+	add("OEBPS/content.opf", `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <manifest>`+items.String()+`</manifest>
+  <spine>`+refs.String()+`</spine>
+</package>`)
+	for name, content := range chapters {
+		add("OEBPS/"+name, content)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(file, buf.Bytes(), 0644))
+}
+
+func TestExtractEPUB(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ships.epub")
+	writeEPUB(t, file, map[string]string{
+		"one.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Skip me</title><style>p { }</style></head>
+<body><h1>Hulls</h1><p>Clinker   hulls overlap
+their planks.</p><p>Carvel hulls&nbsp;do not.</p></body></html>`,
+		"two.xhtml": `<html><body><h2>Sails</h2><p>A lateen sail<br/>is triangular.</p></body></html>`,
+	}, "two.xhtml", "one.xhtml")
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	text, err := extractEPUB(data)
+	require.NoError(t, err)
+	assert.Equal(t, "## Sails\n\nA lateen sail\n\nis triangular.\n\n# Hulls\n\nClinker hulls overlap their planks.\n\nCarvel hulls do not.", text)
+
+	_, err = extractEPUB([]byte("not a zip"))
+	assert.Error(t, err)
+}
+
+func TestIndexer_SyncResearch(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	engine := NewFTSEngine(db)
+	counter := &mockTokenCounter{
+		countFunc: func(text string) int { return len(text) / 4 },
+		splitFunc: func(text string, chunkSize int, overlap float64) []string { return []string{text} },
+	}
+	indexer := NewIndexer(engine, counter, 800, 0.15)
+
+	root := t.TempDir()
+	fs := storage.NewFileSystem(root)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "research", "books"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "research", "tides.txt"), []byte("Spring tides follow the full moon."), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "research", "scan.pdf"), []byte("%PDF-1.4"), 0644))
+	writeEPUB(t, filepath.Join(root, "research", "books", "ships.epub"), map[string]string{
+		"one.xhtml": `<html><body><p>Clinker hulls overlap their planks.</p></body></html>`,
+	}, "one.xhtml")
+
+	defer func(tool string) { pdfToText = tool }(pdfToText)
+	pdfToText = "dreamteller-no-such-pdftotext"
+
+	result, err := indexer.Sync(fs, db)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{filepath.Join("research", "tides.txt"), filepath.Join("research", "books", "ships.epub")}, result.Reindexed)
+	assert.Equal(t, []string{filepath.Join("research", "scan.pdf")}, result.Skipped)
+
+	for query, path := range map[string]string{
+		"tides":   filepath.Join("research", "tides.txt"),
+		"clinker": filepath.Join("research", "books", "ships.epub"),
+	} {
+		results, err := engine.Search(query, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1, query)
+		assert.Equal(t, path, results[0].SourcePath)
+		assert.Equal(t, SourceTypeResearch, results[0].SourceType)
+	}
+}
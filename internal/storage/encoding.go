@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// TextFormat describes how a text file was stored before it was decoded.
+type TextFormat struct {
+	// Encoding is the character encoding, e.g. "UTF-8" or "EUC-KR".
+	Encoding string
+
+	// BOM is set when the file starts with a byte order mark.
+	BOM bool
+
+	// CRLF is set when the file has Windows or classic Mac line endings.
+	CRLF bool
+}
+
+// Normalized reports whether the file is already UTF-8 without a byte order
+// mark and with LF line endings.
+func (f TextFormat) Normalized() bool {
+	return f.Encoding == "UTF-8" && !f.BOM && !f.CRLF
+}
+
+// String describes the format, e.g. "EUC-KR, CRLF".
+func (f TextFormat) String() string {
+	parts := []string{f.Encoding}
+	if f.BOM {
+		parts = append(parts, "BOM")
+	}
+	if f.CRLF {
+		parts = append(parts, "CRLF")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// legacyEncodings are tried, in order, on files that are not valid UTF-8.
+// Each is accepted only when most of the non-ASCII text it decodes to fits
+// its language, since a file in one of them often decodes without error in
+// another, as rare syllables or half-width kana.
+var legacyEncodings = []struct {
+	name string
+	enc  encoding.Encoding
+	fits func(rune) bool
+}{
+	{"EUC-KR", korean.EUCKR, isCommonHangul},
+	{"Shift_JIS", japanese.ShiftJIS, isJapaneseText},
+	{"EUC-JP", japanese.EUCJP, isJapaneseText},
+}
+
+// isCommonHangul reports whether r is one of the 2,350 common syllables of
+// KS X 1001, rather than one only CP949 adds.
+func isCommonHangul(r rune) bool {
+	if !unicode.Is(unicode.Hangul, r) {
+		return false
+	}
+	b, err := korean.EUCKR.NewEncoder().Bytes([]byte(string(r)))
+	return err == nil && len(b) == 2 && b[0] >= 0xA1 && b[1] >= 0xA1
+}
+
+// isJapaneseText reports whether r is kana, a kanji or Japanese punctuation.
+// Half-width katakana, rare in prose, does not count.
+func isJapaneseText(r rune) bool {
+	return unicode.In(r, unicode.Hiragana, unicode.Han) ||
+		(unicode.Is(unicode.Katakana, r) && r < 0xFF00) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF01 && r <= 0xFF5E)
+}
+
+// DecodeText detects the encoding of a text file and returns its content as
+// UTF-8 with the byte order mark removed and LF line endings, along with the
+// format it was stored in. Files that are not valid UTF-8 are decoded as
+// UTF-16 (with a byte order mark), EUC-KR (including CP949), Shift_JIS or
+// EUC-JP, falling back to Windows-1252.
+func DecodeText(data []byte) (string, TextFormat) {
+	format := TextFormat{Encoding: "UTF-8"}
+	var text string
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		format.BOM = true
+		text = string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		format.BOM = true
+		format.Encoding = "UTF-16LE"
+		if data[0] == 0xFE {
+			format.Encoding = "UTF-16BE"
+		}
+		decoded, err := xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM).NewDecoder().Bytes(data)
+		if err != nil {
+			decoded = data
+		}
+		text = string(decoded)
+	case utf8.Valid(data):
+		text = string(data)
+	default:
+		text, format.Encoding = decodeLegacy(data)
+	}
+
+	if strings.ContainsRune(text, '\r') {
+		format.CRLF = true
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+		text = strings.ReplaceAll(text, "\r", "\n")
+	}
+	return text, format
+}
+
+// decodeLegacy decodes text in the first legacy encoding that fits it.
+func decodeLegacy(data []byte) (string, string) {
+	for _, candidate := range legacyEncodings {
+		decoded, err := candidate.enc.NewDecoder().Bytes(data)
+		if err != nil || bytes.ContainsRune(decoded, utf8.RuneError) {
+			continue
+		}
+		inScript, nonASCII := 0, 0
+		for _, r := range string(decoded) {
+			if r < utf8.RuneSelf {
+				continue
+			}
+			nonASCII++
+			if candidate.fits(r) {
+				inScript++
+			}
+		}
+		if inScript*2 > nonASCII {
+			return string(decoded), candidate.name
+		}
+	}
+	decoded, _ := charmap.Windows1252.NewDecoder().Bytes(data)
+	return string(decoded), "Windows-1252"
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxFileNameBytes is the longest file name most file systems accept.
+const maxFileNameBytes = 255
+
+// windowsReservedNames are the device names Windows refuses as file names,
+// with or without an extension.
+var windowsReservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true, "conin$": true, "conout$": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// IsReservedFileName reports whether name is a Windows device name such as
+// CON or com1.md, which cannot be used as a file name there.
+func IsReservedFileName(name string) bool {
+	stem, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(name)), ".")
+	return windowsReservedNames[stem]
+}
+
+// ValidateFileName checks that name can be used as a single file or
+// directory name on Windows, macOS and Linux: it must not be empty, . or
+// .., contain path separators, characters Windows forbids or control
+// characters, end in a dot or space, be a reserved device name or be
+// longer than file systems allow. Letters of any script are allowed.
+func ValidateFileName(name string) error {
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("invalid file name: %q", name)
+	}
+	for _, r := range name {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || unicode.IsControl(r) {
+			return fmt.Errorf("invalid character in file name %q: %q", name, r)
+		}
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return fmt.Errorf("file name %q must not end with a dot or space", name)
+	}
+	if IsReservedFileName(name) {
+		return fmt.Errorf("file name %q is reserved on Windows", name)
+	}
+	if len(name) > maxFileNameBytes {
+		return fmt.Errorf("file name is too long: %d bytes (at most %d)", len(name), maxFileNameBytes)
+	}
+	return nil
+}
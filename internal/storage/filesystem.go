@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,6 +26,10 @@ type FileInfo struct {
 type FileSystem struct {
 	basePath string
 	md       goldmark.Markdown
+
+	// mounts maps a top-level directory name to a directory outside
+	// basePath whose files appear under that name.
+	mounts map[string]string
 }
 
 // NewFileSystem creates a new file system handler.
@@ -34,26 +40,133 @@ func NewFileSystem(basePath string) *FileSystem {
 	}
 }
 
-// ReadMarkdown reads and parses a markdown file.
+// Mount makes the files under dir appear under the top-level directory
+// name, as if they were part of the project.
+func (fs *FileSystem) Mount(name, dir string) {
+	if fs.mounts == nil {
+		fs.mounts = make(map[string]string)
+	}
+	fs.mounts[name] = dir
+}
+
+// Unmount removes a directory added with Mount.
+func (fs *FileSystem) Unmount(name string) {
+	delete(fs.mounts, name)
+}
+
+// resolve returns the full path of a project-relative path, following
+// mounts.
+func (fs *FileSystem) resolve(relativePath string) string {
+	top, rest, _ := strings.Cut(filepath.ToSlash(filepath.Clean(relativePath)), "/")
+	if dir, ok := fs.mounts[top]; ok {
+		return filepath.Join(dir, filepath.FromSlash(rest))
+	}
+	return filepath.Join(fs.basePath, relativePath)
+}
+
+// ReadMarkdown reads a markdown file as UTF-8 with LF line endings,
+// whatever encoding and line endings it was saved with.
 func (fs *FileSystem) ReadMarkdown(relativePath string) (string, error) {
-	fullPath := filepath.Join(fs.basePath, relativePath)
+	fullPath := fs.resolve(relativePath)
 	data, err := os.ReadFile(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read markdown file: %w", err)
 	}
-	return string(data), nil
+	text, _ := DecodeText(data)
+	return text, nil
+}
+
+// TextFormat reports the encoding and line endings a file is stored with.
+func (fs *FileSystem) TextFormat(relativePath string) (TextFormat, error) {
+	data, err := os.ReadFile(fs.resolve(relativePath))
+	if err != nil {
+		return TextFormat{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	_, format := DecodeText(data)
+	return format, nil
+}
+
+// NormalizeFile rewrites a file as UTF-8 without a byte order mark and with
+// LF line endings, unless it already is, and returns the format it had.
+func (fs *FileSystem) NormalizeFile(relativePath string) (TextFormat, error) {
+	fullPath := fs.resolve(relativePath)
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return TextFormat{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	text, format := DecodeText(data)
+	if format.Normalized() {
+		return format, nil
+	}
+	if err := AtomicWriteFile(fullPath, []byte(text)); err != nil {
+		return format, fmt.Errorf("failed to rewrite file: %w", err)
+	}
+	return format, nil
 }
 
 // WriteMarkdown writes content to a markdown file atomically.
 func (fs *FileSystem) WriteMarkdown(relativePath, content string) error {
-	fullPath := filepath.Join(fs.basePath, relativePath)
+	fullPath := fs.resolve(relativePath)
 	return AtomicWriteFile(fullPath, []byte(content))
 }
 
-// ListMarkdownFiles lists all markdown files in a directory.
+// ListMarkdownFiles lists all markdown files in a directory. Listing the
+// project root includes mounted directories.
 func (fs *FileSystem) ListMarkdownFiles(relativePath string) ([]FileInfo, error) {
-	dirPath := filepath.Join(fs.basePath, relativePath)
+	top, _, _ := strings.Cut(filepath.ToSlash(filepath.Clean(relativePath)), "/")
+	if dir, ok := fs.mounts[top]; ok {
+		return listFiles(fs.resolve(relativePath), dir, top, markdownExts)
+	}
+
+	files, err := listFiles(filepath.Join(fs.basePath, relativePath), fs.basePath, "", markdownExts)
+	if err != nil || top != "." {
+		return files, err
+	}
+
+	names := make([]string, 0, len(fs.mounts))
+	for name := range fs.mounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := os.Stat(fs.mounts[name]); err != nil {
+			continue
+		}
+		mounted, err := listFiles(fs.mounts[name], fs.mounts[name], name, markdownExts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, mounted...)
+	}
+	return files, nil
+}
+
+// ListFiles lists the files in a directory with one of the given
+// extensions, such as ".txt", matched case-insensitively.
+func (fs *FileSystem) ListFiles(relativePath string, exts ...string) ([]FileInfo, error) {
+	top, _, _ := strings.Cut(filepath.ToSlash(filepath.Clean(relativePath)), "/")
+	if dir, ok := fs.mounts[top]; ok {
+		return listFiles(fs.resolve(relativePath), dir, top, exts)
+	}
+	return listFiles(filepath.Join(fs.basePath, relativePath), fs.basePath, "", exts)
+}
+
+// ReadFile reads a file's raw bytes.
+func (fs *FileSystem) ReadFile(relativePath string) ([]byte, error) {
+	return os.ReadFile(fs.resolve(relativePath))
+}
+
+// AbsPath returns the full path of a project-relative path.
+func (fs *FileSystem) AbsPath(relativePath string) string {
+	return fs.resolve(relativePath)
+}
 
+// markdownExts are the extensions of markdown files.
+var markdownExts = []string{".md"}
+
+// listFiles walks dirPath for files with one of exts, naming each by its
+// path relative to base, under prefix.
+func listFiles(dirPath, base, prefix string, exts []string) ([]FileInfo, error) {
 	var files []FileInfo
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -64,10 +177,10 @@ func (fs *FileSystem) ListMarkdownFiles(relativePath string) ([]FileInfo, error)
 			return nil
 		}
 
-		if strings.HasSuffix(strings.ToLower(path), ".md") {
-			relPath, _ := filepath.Rel(fs.basePath, path)
+		if slices.Contains(exts, strings.ToLower(filepath.Ext(path))) {
+			relPath, _ := filepath.Rel(base, path)
 			files = append(files, FileInfo{
-				Path:    relPath,
+				Path:    filepath.Join(prefix, relPath),
 				ModTime: info.ModTime(),
 				Size:    info.Size(),
 			})
@@ -80,7 +193,7 @@ func (fs *FileSystem) ListMarkdownFiles(relativePath string) ([]FileInfo, error)
 		if os.IsNotExist(err) {
 			return []FileInfo{}, nil
 		}
-		return nil, fmt.Errorf("failed to list markdown files: %w", err)
+		return nil, fmt.Errorf("failed to list files: %w", err)
 	}
 
 	return files, nil
@@ -88,7 +201,7 @@ func (fs *FileSystem) ListMarkdownFiles(relativePath string) ([]FileInfo, error)
 
 // GetFileInfo returns file metadata.
 func (fs *FileSystem) GetFileInfo(relativePath string) (*FileInfo, error) {
-	fullPath := filepath.Join(fs.basePath, relativePath)
+	fullPath := fs.resolve(relativePath)
 	info, err := os.Stat(fullPath)
 	if err != nil {
 		return nil, err
@@ -103,20 +216,20 @@ func (fs *FileSystem) GetFileInfo(relativePath string) (*FileInfo, error) {
 
 // EnsureDir ensures a directory exists.
 func (fs *FileSystem) EnsureDir(relativePath string) error {
-	fullPath := filepath.Join(fs.basePath, relativePath)
+	fullPath := fs.resolve(relativePath)
 	return os.MkdirAll(fullPath, 0755)
 }
 
 // Exists checks if a file or directory exists.
 func (fs *FileSystem) Exists(relativePath string) bool {
-	fullPath := filepath.Join(fs.basePath, relativePath)
+	fullPath := fs.resolve(relativePath)
 	_, err := os.Stat(fullPath)
 	return err == nil
 }
 
 // Delete removes a file.
 func (fs *FileSystem) Delete(relativePath string) error {
-	fullPath := filepath.Join(fs.basePath, relativePath)
+	fullPath := fs.resolve(relativePath)
 	return os.Remove(fullPath)
 }
 
@@ -162,6 +275,42 @@ func (fs *FileSystem) ParseMarkdownFrontmatter(content string) (string, string)
 	return frontmatter, strings.TrimSpace(body)
 }
 
+// ParseMarkdownSection returns the body of the first heading whose text
+// matches one of names (case-insensitive), up to the next heading of the same
+// or higher level. It returns "" when no such heading exists.
+func (fs *FileSystem) ParseMarkdownSection(content string, names ...string) string {
+	lines := strings.Split(content, "\n")
+
+	level := 0
+	var body []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		hashes := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		isHeading := hashes > 0 && len(trimmed) > hashes && trimmed[hashes] == ' '
+
+		if level > 0 {
+			if isHeading && hashes <= level {
+				break
+			}
+			body = append(body, line)
+			continue
+		}
+
+		if !isHeading {
+			continue
+		}
+		heading := strings.TrimSpace(trimmed[hashes:])
+		for _, name := range names {
+			if strings.EqualFold(heading, name) {
+				level = hashes
+				break
+			}
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(body, "\n"))
+}
+
 // BasePath returns the base path of the filesystem.
 func (fs *FileSystem) BasePath() string {
 	return fs.basePath
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -68,7 +70,8 @@ func (s *SQLiteDB) initialize() error {
 	CREATE TABLE IF NOT EXISTS file_tracking (
 		path TEXT PRIMARY KEY,
 		mtime INTEGER NOT NULL,
-		indexed_at INTEGER NOT NULL
+		indexed_at INTEGER NOT NULL,
+		hash TEXT NOT NULL DEFAULT ''
 	);
 
 	-- Conversation history
@@ -76,7 +79,142 @@ func (s *SQLiteDB) initialize() error {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		role TEXT NOT NULL,
 		content TEXT NOT NULL,
-		timestamp INTEGER NOT NULL
+		timestamp INTEGER NOT NULL,
+		interrupted INTEGER NOT NULL DEFAULT 0,
+		model TEXT NOT NULL DEFAULT '',
+		draft INTEGER NOT NULL DEFAULT 0,
+		thread TEXT NOT NULL DEFAULT 'main'
+	);
+
+	-- The persona answering in each conversation thread, when not the default
+	CREATE TABLE IF NOT EXISTS thread_persona (
+		thread TEXT PRIMARY KEY,
+		persona TEXT NOT NULL
+	);
+
+	-- Durable facts saved by the user or the model
+	CREATE TABLE IF NOT EXISTS memories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		content TEXT NOT NULL,
+		source TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	-- Writing sprints and the words written during each
+	CREATE TABLE IF NOT EXISTS sprints (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at INTEGER NOT NULL,
+		planned_seconds INTEGER NOT NULL,
+		actual_seconds INTEGER NOT NULL,
+		words_before INTEGER NOT NULL,
+		words_after INTEGER NOT NULL,
+		ai_locked INTEGER NOT NULL DEFAULT 0
+	);
+
+	-- Prompts sent from the composer, for recall
+	CREATE TABLE IF NOT EXISTS prompt_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		prompt TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	-- The manuscript's word count at the end of each day, for pacing
+	CREATE TABLE IF NOT EXISTS daily_words (
+		day TEXT PRIMARY KEY,
+		words INTEGER NOT NULL
+	);
+
+	-- Feedback on chapters, such as reviewer persona critiques
+	CREATE TABLE IF NOT EXISTS annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chapter INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		category TEXT NOT NULL DEFAULT '',
+		severity TEXT NOT NULL DEFAULT '',
+		quote TEXT NOT NULL DEFAULT '',
+		comment TEXT NOT NULL,
+		suggestion TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+
+	-- Requests sent to the model, with the reply, so they can be replayed
+	CREATE TABLE IF NOT EXISTS generations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider TEXT NOT NULL,
+		model TEXT NOT NULL,
+		seed INTEGER,
+		chunk_ids TEXT NOT NULL DEFAULT '',
+		request TEXT NOT NULL,
+		reply TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+
+	-- A/B comparisons of two models or settings, and which reply was kept
+	CREATE TABLE IF NOT EXISTS comparisons (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		prompt TEXT NOT NULL,
+		variant_a TEXT NOT NULL,
+		variant_b TEXT NOT NULL,
+		reply_a TEXT NOT NULL,
+		reply_b TEXT NOT NULL,
+		choice INTEGER NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	-- Chat sessions, recorded only when usage insights are on
+	CREATE TABLE IF NOT EXISTS sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at INTEGER NOT NULL,
+		ended_at INTEGER,
+		words_before INTEGER NOT NULL,
+		words_after INTEGER
+	);
+
+	-- Counted uses of tools, commands and suggestions, for usage insights
+	CREATE TABLE IF NOT EXISTS usage_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		name TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	-- Plot and character suggestions and what the author did with each
+	CREATE TABLE IF NOT EXISTS suggestion_feedback (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		title TEXT NOT NULL,
+		content TEXT NOT NULL,
+		outcome TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+
+	-- Tokens each AI request used, with its estimated cost, for the quota
+	CREATE TABLE IF NOT EXISTS token_usage (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model TEXT NOT NULL,
+		prompt_tokens INTEGER NOT NULL,
+		completion_tokens INTEGER NOT NULL,
+		cost REAL NOT NULL DEFAULT 0,
+		created_at INTEGER NOT NULL
+	);
+
+	-- The author's preferences, summarized by the model from that feedback
+	CREATE TABLE IF NOT EXISTS preference_digest (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		digest TEXT NOT NULL,
+		feedback_id INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- How the search index was chunked, to notice when that changes
+	CREATE TABLE IF NOT EXISTS index_format (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		version INTEGER NOT NULL,
+		chunk_size INTEGER NOT NULL,
+		chunk_overlap REAL NOT NULL,
+		chunking TEXT NOT NULL,
+		tokenizer TEXT NOT NULL
 	);
 
 	-- Schema version for migrations
@@ -87,8 +225,88 @@ func (s *SQLiteDB) initialize() error {
 	INSERT OR IGNORE INTO schema_version (version) VALUES (1);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	return s.migrate()
+}
+
+// migrate adds columns introduced after a database was first created.
+func (s *SQLiteDB) migrate() error {
+	hasInterrupted, err := s.hasColumn("conversation", "interrupted")
+	if err != nil {
+		return err
+	}
+	if !hasInterrupted {
+		if _, err := s.db.Exec("ALTER TABLE conversation ADD COLUMN interrupted INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return fmt.Errorf("failed to add conversation.interrupted: %w", err)
+		}
+	}
+
+	hasModel, err := s.hasColumn("conversation", "model")
+	if err != nil {
+		return err
+	}
+	if !hasModel {
+		if _, err := s.db.Exec("ALTER TABLE conversation ADD COLUMN model TEXT NOT NULL DEFAULT ''"); err != nil {
+			return fmt.Errorf("failed to add conversation.model: %w", err)
+		}
+	}
+
+	hasDraft, err := s.hasColumn("conversation", "draft")
+	if err != nil {
+		return err
+	}
+	if !hasDraft {
+		if _, err := s.db.Exec("ALTER TABLE conversation ADD COLUMN draft INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return fmt.Errorf("failed to add conversation.draft: %w", err)
+		}
+	}
+
+	hasThread, err := s.hasColumn("conversation", "thread")
+	if err != nil {
+		return err
+	}
+	if !hasThread {
+		if _, err := s.db.Exec("ALTER TABLE conversation ADD COLUMN thread TEXT NOT NULL DEFAULT 'main'"); err != nil {
+			return fmt.Errorf("failed to add conversation.thread: %w", err)
+		}
+	}
+	if _, err := s.db.Exec("CREATE INDEX IF NOT EXISTS idx_conversation_thread ON conversation(thread, id)"); err != nil {
+		return fmt.Errorf("failed to index conversation.thread: %w", err)
+	}
+
+	hasHash, err := s.hasColumn("file_tracking", "hash")
+	if err != nil {
+		return err
+	}
+	if !hasHash {
+		if _, err := s.db.Exec("ALTER TABLE file_tracking ADD COLUMN hash TEXT NOT NULL DEFAULT ''"); err != nil {
+			return fmt.Errorf("failed to add file_tracking.hash: %w", err)
+		}
+	}
+	return nil
+}
+
+// hasColumn reports whether table has the named column.
+func (s *SQLiteDB) hasColumn(table, column string) (bool, error) {
+	rows, err := s.db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
 }
 
 // InsertChunk inserts a chunk into both FTS and metadata tables.
@@ -209,12 +427,19 @@ func (s *SQLiteDB) DeleteChunksBySource(sourcePath string) error {
 	return tx.Commit()
 }
 
-// UpdateFileTracking updates the tracking information for a file.
+// UpdateFileTracking updates the tracking information for a file, without
+// a content hash.
 func (s *SQLiteDB) UpdateFileTracking(path string, mtime time.Time) error {
+	return s.TrackFile(path, mtime, "")
+}
+
+// TrackFile records that a file was indexed at mtime with content of the
+// given hash, so a later sync can tell whether it changed.
+func (s *SQLiteDB) TrackFile(path string, mtime time.Time, hash string) error {
 	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO file_tracking (path, mtime, indexed_at)
-		VALUES (?, ?, ?)
-	`, path, mtime.Unix(), time.Now().Unix())
+		INSERT OR REPLACE INTO file_tracking (path, mtime, indexed_at, hash)
+		VALUES (?, ?, ?, ?)
+	`, path, mtime.Unix(), time.Now().Unix(), hash)
 	return err
 }
 
@@ -224,9 +449,9 @@ func (s *SQLiteDB) GetFileTracking(path string) (*FileTrackingInfo, error) {
 	var mtimeUnix, indexedAtUnix int64
 
 	err := s.db.QueryRow(
-		"SELECT path, mtime, indexed_at FROM file_tracking WHERE path = ?",
+		"SELECT path, mtime, indexed_at, hash FROM file_tracking WHERE path = ?",
 		path,
-	).Scan(&info.Path, &mtimeUnix, &indexedAtUnix)
+	).Scan(&info.Path, &mtimeUnix, &indexedAtUnix, &info.Hash)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -245,6 +470,9 @@ type FileTrackingInfo struct {
 	Path      string
 	MTime     time.Time
 	IndexedAt time.Time
+
+	// Hash is the SHA-256 of the content indexed, or empty when unknown.
+	Hash string
 }
 
 // DeleteFileTracking removes tracking for a file.
@@ -255,7 +483,7 @@ func (s *SQLiteDB) DeleteFileTracking(path string) error {
 
 // GetAllTrackedFiles returns all tracked files.
 func (s *SQLiteDB) GetAllTrackedFiles() ([]FileTrackingInfo, error) {
-	rows, err := s.db.Query("SELECT path, mtime, indexed_at FROM file_tracking")
+	rows, err := s.db.Query("SELECT path, mtime, indexed_at, hash FROM file_tracking")
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +493,7 @@ func (s *SQLiteDB) GetAllTrackedFiles() ([]FileTrackingInfo, error) {
 	for rows.Next() {
 		var info FileTrackingInfo
 		var mtimeUnix, indexedAtUnix int64
-		if err := rows.Scan(&info.Path, &mtimeUnix, &indexedAtUnix); err != nil {
+		if err := rows.Scan(&info.Path, &mtimeUnix, &indexedAtUnix, &info.Hash); err != nil {
 			return nil, err
 		}
 		info.MTime = time.Unix(mtimeUnix, 0)
@@ -276,23 +504,163 @@ func (s *SQLiteDB) GetAllTrackedFiles() ([]FileTrackingInfo, error) {
 	return files, rows.Err()
 }
 
-// SaveConversationMessage saves a message to conversation history.
-func (s *SQLiteDB) SaveConversationMessage(role, content string) error {
+// IndexFormat records the settings the search index was built with.
+type IndexFormat struct {
+	Version      int
+	ChunkSize    int
+	ChunkOverlap float64
+	Chunking     string
+	Tokenizer    string
+}
+
+// GetIndexFormat returns the settings the search index was built with, or
+// nil if none were recorded.
+func (s *SQLiteDB) GetIndexFormat() (*IndexFormat, error) {
+	var f IndexFormat
+	err := s.db.QueryRow(
+		"SELECT version, chunk_size, chunk_overlap, chunking, tokenizer FROM index_format WHERE id = 1",
+	).Scan(&f.Version, &f.ChunkSize, &f.ChunkOverlap, &f.Chunking, &f.Tokenizer)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SetIndexFormat records the settings the search index was built with.
+func (s *SQLiteDB) SetIndexFormat(f IndexFormat) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO index_format (id, version, chunk_size, chunk_overlap, chunking, tokenizer)
+		VALUES (1, ?, ?, ?, ?, ?)
+	`, f.Version, f.ChunkSize, f.ChunkOverlap, f.Chunking, f.Tokenizer)
+	return err
+}
+
+// MainThread is the conversation thread of the project's first chat tab,
+// which holds the history saved before chats had threads.
+const MainThread = "main"
+
+// SaveConversationMessage saves a message to a thread's conversation
+// history.
+func (s *SQLiteDB) SaveConversationMessage(thread, role, content string) error {
+	_, err := s.db.Exec(
+		"INSERT INTO conversation (role, content, timestamp, thread) VALUES (?, ?, ?, ?)",
+		role, content, time.Now().Unix(), thread,
+	)
+	return err
+}
+
+// SaveInterruptedMessage saves a partial message whose generation was
+// cancelled or timed out.
+func (s *SQLiteDB) SaveInterruptedMessage(thread, role, content string) error {
+	_, err := s.db.Exec(
+		"INSERT INTO conversation (role, content, timestamp, interrupted, thread) VALUES (?, ?, ?, 1, ?)",
+		role, content, time.Now().Unix(), thread,
+	)
+	return err
+}
+
+// SaveGeneratedMessage saves an assistant message with the model that
+// generated it. Interrupted marks a partial reply.
+func (s *SQLiteDB) SaveGeneratedMessage(thread, content, model string, interrupted bool) error {
+	_, err := s.db.Exec(
+		"INSERT INTO conversation (role, content, timestamp, interrupted, model, thread) VALUES ('assistant', ?, ?, ?, ?, ?)",
+		content, time.Now().Unix(), interrupted, model, thread,
+	)
+	return err
+}
+
+// UpdateLastConversationMessage replaces the content and interrupted flag of
+// a thread's most recent message, e.g. after an interrupted reply is
+// continued.
+func (s *SQLiteDB) UpdateLastConversationMessage(thread, content string, interrupted bool) error {
 	_, err := s.db.Exec(
-		"INSERT INTO conversation (role, content, timestamp) VALUES (?, ?, ?)",
-		role, content, time.Now().Unix(),
+		"UPDATE conversation SET content = ?, interrupted = ?, timestamp = ? WHERE id = (SELECT MAX(id) FROM conversation WHERE thread = ?)",
+		content, interrupted, time.Now().Unix(), thread,
 	)
 	return err
 }
 
-// GetConversationHistory returns the conversation history.
-func (s *SQLiteDB) GetConversationHistory(limit int) ([]ConversationRecord, error) {
+// SetLastConversationDraft marks or unmarks a thread's most recent message
+// as a first draft superseded by a refined reply.
+func (s *SQLiteDB) SetLastConversationDraft(thread string, draft bool) error {
+	_, err := s.db.Exec(
+		"UPDATE conversation SET draft = ? WHERE id = (SELECT MAX(id) FROM conversation WHERE thread = ?)",
+		draft, thread,
+	)
+	return err
+}
+
+// ConversationThreads lists the threads with saved messages, in the order
+// they were started.
+func (s *SQLiteDB) ConversationThreads() ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT thread
+		FROM conversation
+		GROUP BY thread
+		ORDER BY MIN(id)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var threads []string
+	for rows.Next() {
+		var thread string
+		if err := rows.Scan(&thread); err != nil {
+			return nil, err
+		}
+		threads = append(threads, thread)
+	}
+	return threads, rows.Err()
+}
+
+// SetThreadPersona records the persona answering in a thread; an empty
+// persona restores the default.
+func (s *SQLiteDB) SetThreadPersona(thread, persona string) error {
+	if persona == "" {
+		_, err := s.db.Exec("DELETE FROM thread_persona WHERE thread = ?", thread)
+		return err
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO thread_persona (thread, persona) VALUES (?, ?)
+		ON CONFLICT(thread) DO UPDATE SET persona = excluded.persona
+	`, thread, persona)
+	return err
+}
+
+// ThreadPersonas returns the persona of each thread that has one set.
+func (s *SQLiteDB) ThreadPersonas() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT thread, persona FROM thread_persona")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	personas := make(map[string]string)
+	for rows.Next() {
+		var thread, persona string
+		if err := rows.Scan(&thread, &persona); err != nil {
+			return nil, err
+		}
+		personas[thread] = persona
+	}
+	return personas, rows.Err()
+}
+
+// GetConversationHistory returns the most recent messages of a thread's
+// conversation history.
+func (s *SQLiteDB) GetConversationHistory(thread string, limit int) ([]ConversationRecord, error) {
 	rows, err := s.db.Query(`
-		SELECT id, role, content, timestamp
+		SELECT id, role, content, timestamp, interrupted, model, draft
 		FROM conversation
+		WHERE thread = ?
 		ORDER BY id DESC
 		LIMIT ?
-	`, limit)
+	`, thread, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -302,7 +670,7 @@ func (s *SQLiteDB) GetConversationHistory(limit int) ([]ConversationRecord, erro
 	for rows.Next() {
 		var msg ConversationRecord
 		var timestampUnix int64
-		if err := rows.Scan(&msg.ID, &msg.Role, &msg.Content, &timestampUnix); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.Role, &msg.Content, &timestampUnix, &msg.Interrupted, &msg.Model, &msg.Draft); err != nil {
 			return nil, err
 		}
 		msg.Timestamp = time.Unix(timestampUnix, 0)
@@ -323,11 +691,759 @@ type ConversationRecord struct {
 	Role      string
 	Content   string
 	Timestamp time.Time
+
+	// Interrupted marks a partial reply whose generation was cancelled.
+	Interrupted bool
+
+	// Model is the model that generated an assistant message, if recorded.
+	Model string
+
+	// Draft marks a first draft that a refined reply after it replaces.
+	Draft bool
+}
+
+// ClearConversation clears a thread's conversation history.
+func (s *SQLiteDB) ClearConversation(thread string) error {
+	_, err := s.db.Exec("DELETE FROM conversation WHERE thread = ?", thread)
+	return err
+}
+
+// MemoryRecord represents a remembered fact.
+type MemoryRecord struct {
+	ID        int64
+	Content   string
+	Source    string // "user" or "model"
+	CreatedAt time.Time
+}
+
+// SaveMemory stores a fact in project memory and returns its ID.
+func (s *SQLiteDB) SaveMemory(content, source string) (int64, error) {
+	result, err := s.db.Exec(
+		"INSERT INTO memories (content, source, created_at) VALUES (?, ?, ?)",
+		content, source, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ListMemories returns all remembered facts, oldest first.
+func (s *SQLiteDB) ListMemories() ([]MemoryRecord, error) {
+	rows, err := s.db.Query("SELECT id, content, source, created_at FROM memories ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memories []MemoryRecord
+	for rows.Next() {
+		var m MemoryRecord
+		var createdUnix int64
+		if err := rows.Scan(&m.ID, &m.Content, &m.Source, &createdUnix); err != nil {
+			return nil, err
+		}
+		m.CreatedAt = time.Unix(createdUnix, 0)
+		memories = append(memories, m)
+	}
+
+	return memories, rows.Err()
 }
 
-// ClearConversation clears the conversation history.
-func (s *SQLiteDB) ClearConversation() error {
-	_, err := s.db.Exec("DELETE FROM conversation")
+// DeleteMemory removes a remembered fact.
+func (s *SQLiteDB) DeleteMemory(id int64) error {
+	result, err := s.db.Exec("DELETE FROM memories WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("memory %d not found", id)
+	}
+	return nil
+}
+
+// SprintRecord is a finished writing sprint.
+type SprintRecord struct {
+	ID        int64
+	StartedAt time.Time
+	Planned   time.Duration
+	Actual    time.Duration
+
+	// WordsBefore and WordsAfter are the manuscript's word counts when the
+	// sprint started and ended.
+	WordsBefore int
+	WordsAfter  int
+
+	// AILocked records that AI requests were disabled during the sprint.
+	AILocked bool
+}
+
+// WordsWritten returns the sprint's word count delta.
+func (r SprintRecord) WordsWritten() int {
+	return r.WordsAfter - r.WordsBefore
+}
+
+// SaveSprint records a finished writing sprint.
+func (s *SQLiteDB) SaveSprint(r SprintRecord) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO sprints (started_at, planned_seconds, actual_seconds, words_before, words_after, ai_locked)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		r.StartedAt.Unix(), int64(r.Planned.Seconds()), int64(r.Actual.Seconds()), r.WordsBefore, r.WordsAfter, r.AILocked,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ListSprints returns the most recent sprints, newest first.
+func (s *SQLiteDB) ListSprints(limit int) ([]SprintRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT id, started_at, planned_seconds, actual_seconds, words_before, words_after, ai_locked
+		FROM sprints
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sprints []SprintRecord
+	for rows.Next() {
+		var r SprintRecord
+		var startedUnix, planned, actual int64
+		if err := rows.Scan(&r.ID, &startedUnix, &planned, &actual, &r.WordsBefore, &r.WordsAfter, &r.AILocked); err != nil {
+			return nil, err
+		}
+		r.StartedAt = time.Unix(startedUnix, 0)
+		r.Planned = time.Duration(planned) * time.Second
+		r.Actual = time.Duration(actual) * time.Second
+		sprints = append(sprints, r)
+	}
+
+	return sprints, rows.Err()
+}
+
+// dayLayout formats the day column of daily_words.
+const dayLayout = "2006-01-02"
+
+// DailyWords is the manuscript's word count at the end of a day.
+type DailyWords struct {
+	Day   time.Time
+	Words int
+}
+
+// RecordDailyWords stores the word count for the day of t, replacing the
+// count recorded earlier that day.
+func (s *SQLiteDB) RecordDailyWords(t time.Time, words int) error {
+	_, err := s.db.Exec(
+		`INSERT INTO daily_words (day, words) VALUES (?, ?)
+		ON CONFLICT(day) DO UPDATE SET words = excluded.words`,
+		t.Format(dayLayout), words,
+	)
+	return err
+}
+
+// ListDailyWords returns the word counts recorded from the day of since
+// onward, oldest first. Days are in the local time zone.
+func (s *SQLiteDB) ListDailyWords(since time.Time) ([]DailyWords, error) {
+	rows, err := s.db.Query(`
+		SELECT day, words
+		FROM daily_words
+		WHERE day >= ?
+		ORDER BY day
+	`, since.Format(dayLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []DailyWords
+	for rows.Next() {
+		var day string
+		var d DailyWords
+		if err := rows.Scan(&day, &d.Words); err != nil {
+			return nil, err
+		}
+		if d.Day, err = time.ParseInLocation(dayLayout, day, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid day %q: %w", day, err)
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
+
+// AnnotationRecord is a piece of feedback on a chapter.
+type AnnotationRecord struct {
+	ID      int64
+	Chapter int
+
+	// Source names who gave the feedback, e.g. a reviewer persona.
+	Source   string
+	Category string
+	Severity string
+
+	// Quote is the passage the feedback is about; empty for feedback on
+	// the whole chapter.
+	Quote      string
+	Comment    string
+	Suggestion string
+	CreatedAt  time.Time
+}
+
+// SaveAnnotation stores a piece of feedback and returns its ID.
+func (s *SQLiteDB) SaveAnnotation(a AnnotationRecord) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO annotations (chapter, source, category, severity, quote, comment, suggestion, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.Chapter, a.Source, a.Category, a.Severity, a.Quote, a.Comment, a.Suggestion, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ListAnnotations returns the feedback on a chapter, or on every chapter
+// when chapter is zero, in chapter order and then oldest first.
+func (s *SQLiteDB) ListAnnotations(chapter int) ([]AnnotationRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT id, chapter, source, category, severity, quote, comment, suggestion, created_at
+		FROM annotations
+		WHERE ? = 0 OR chapter = ?
+		ORDER BY chapter, id
+	`, chapter, chapter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var annotations []AnnotationRecord
+	for rows.Next() {
+		var a AnnotationRecord
+		var createdUnix int64
+		if err := rows.Scan(&a.ID, &a.Chapter, &a.Source, &a.Category, &a.Severity, &a.Quote, &a.Comment, &a.Suggestion, &createdUnix); err != nil {
+			return nil, err
+		}
+		a.CreatedAt = time.Unix(createdUnix, 0)
+		annotations = append(annotations, a)
+	}
+
+	return annotations, rows.Err()
+}
+
+// DeleteAnnotations removes the feedback a source gave on a chapter.
+func (s *SQLiteDB) DeleteAnnotations(chapter int, source string) error {
+	_, err := s.db.Exec("DELETE FROM annotations WHERE chapter = ? AND source = ?", chapter, source)
+	return err
+}
+
+// AddPromptHistory records a prompt sent from the composer. Sending a
+// prompt again moves it to the end instead of storing a duplicate.
+func (s *SQLiteDB) AddPromptHistory(prompt string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM prompt_history WHERE prompt = ?", prompt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO prompt_history (prompt, created_at) VALUES (?, ?)",
+		prompt, time.Now().Unix(),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListPromptHistory returns the most recent prompts, newest first.
+func (s *SQLiteDB) ListPromptHistory(limit int) ([]string, error) {
+	rows, err := s.db.Query("SELECT prompt FROM prompt_history ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prompts []string
+	for rows.Next() {
+		var prompt string
+		if err := rows.Scan(&prompt); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+	}
+
+	return prompts, rows.Err()
+}
+
+// maxGenerations caps how many generation manifests are kept; requests
+// carry the whole context, so older ones are dropped.
+const maxGenerations = 100
+
+// GenerationRecord is the manifest of a request sent to the model: enough
+// to send it again exactly.
+type GenerationRecord struct {
+	ID       int64
+	Provider string
+	Model    string
+
+	// Seed is the sampling seed sent, or nil if the provider takes none.
+	Seed *int64
+
+	// ChunkIDs are the search index chunks retrieved into the context.
+	ChunkIDs []int64
+
+	// Request is the request as sent, encoded by the caller.
+	Request string
+
+	// Reply is the text generated, empty until the reply is finished.
+	Reply     string
+	CreatedAt time.Time
+}
+
+// SaveGeneration records a request manifest and returns its ID, dropping
+// the oldest manifests beyond the most recent maxGenerations.
+func (s *SQLiteDB) SaveGeneration(r GenerationRecord) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, len(r.ChunkIDs))
+	for i, id := range r.ChunkIDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	result, err := tx.Exec(
+		`INSERT INTO generations (provider, model, seed, chunk_ids, request, reply, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.Provider, r.Model, r.Seed, strings.Join(ids, ","), r.Request, r.Reply, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM generations WHERE id <= ?", id-maxGenerations); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// SetGenerationReply records the reply to a saved request.
+func (s *SQLiteDB) SetGenerationReply(id int64, reply string) error {
+	_, err := s.db.Exec("UPDATE generations SET reply = ? WHERE id = ?", reply, id)
+	return err
+}
+
+// GetGeneration returns the manifest with the given ID, or nil if there is
+// none.
+func (s *SQLiteDB) GetGeneration(id int64) (*GenerationRecord, error) {
+	rows, err := s.db.Query(generationQuery+" WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	records, err := scanGenerations(rows)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return &records[0], nil
+}
+
+// ListGenerations returns the most recent manifests, newest first.
+func (s *SQLiteDB) ListGenerations(limit int) ([]GenerationRecord, error) {
+	rows, err := s.db.Query(generationQuery+" ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanGenerations(rows)
+}
+
+const generationQuery = `
+	SELECT id, provider, model, seed, chunk_ids, request, reply, created_at
+	FROM generations`
+
+// scanGenerations reads and closes rows selected with generationQuery.
+func scanGenerations(rows *sql.Rows) ([]GenerationRecord, error) {
+	defer rows.Close()
+
+	var records []GenerationRecord
+	for rows.Next() {
+		var r GenerationRecord
+		var seed sql.NullInt64
+		var chunkIDs string
+		var createdUnix int64
+		if err := rows.Scan(&r.ID, &r.Provider, &r.Model, &seed, &chunkIDs, &r.Request, &r.Reply, &createdUnix); err != nil {
+			return nil, err
+		}
+		if seed.Valid {
+			r.Seed = &seed.Int64
+		}
+		for _, field := range strings.Split(chunkIDs, ",") {
+			if id, err := strconv.ParseInt(field, 10, 64); err == nil {
+				r.ChunkIDs = append(r.ChunkIDs, id)
+			}
+		}
+		r.CreatedAt = time.Unix(createdUnix, 0)
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
+
+// ComparisonRecord is an A/B comparison: one prompt answered by two
+// variants, and which reply the writer kept.
+type ComparisonRecord struct {
+	ID     int64
+	Prompt string
+
+	// Variants describe the two sides, e.g. a model and a temperature.
+	Variants [2]string
+	Replies  [2]string
+
+	// Choice is the index of the reply kept, or -1 if neither was.
+	Choice    int
+	CreatedAt time.Time
+}
+
+// SaveComparison records an A/B comparison and returns its ID.
+func (s *SQLiteDB) SaveComparison(c ComparisonRecord) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO comparisons (prompt, variant_a, variant_b, reply_a, reply_b, choice, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.Prompt, c.Variants[0], c.Variants[1], c.Replies[0], c.Replies[1], c.Choice, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ListComparisons returns the most recent A/B comparisons, newest first.
+func (s *SQLiteDB) ListComparisons(limit int) ([]ComparisonRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT id, prompt, variant_a, variant_b, reply_a, reply_b, choice, created_at
+		FROM comparisons
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comparisons []ComparisonRecord
+	for rows.Next() {
+		var c ComparisonRecord
+		var createdUnix int64
+		if err := rows.Scan(&c.ID, &c.Prompt, &c.Variants[0], &c.Variants[1], &c.Replies[0], &c.Replies[1], &c.Choice, &createdUnix); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = time.Unix(createdUnix, 0)
+		comparisons = append(comparisons, c)
+	}
+
+	return comparisons, rows.Err()
+}
+
+// SessionRecord is one chat session. A session that did not end cleanly
+// has no end.
+type SessionRecord struct {
+	ID        int64
+	StartedAt time.Time
+	EndedAt   *time.Time
+
+	// WordsBefore and WordsAfter are the manuscript's word counts when the
+	// session started and ended. WordsAfter is nil until it ends.
+	WordsBefore int
+	WordsAfter  *int
+}
+
+// StartSession records the start of a chat session.
+func (s *SQLiteDB) StartSession(at time.Time, words int) (int64, error) {
+	result, err := s.db.Exec(`INSERT INTO sessions (started_at, words_before) VALUES (?, ?)`, at.Unix(), words)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// EndSession records the end of the session with the given ID.
+func (s *SQLiteDB) EndSession(id int64, at time.Time, words int) error {
+	_, err := s.db.Exec(`UPDATE sessions SET ended_at = ?, words_after = ? WHERE id = ?`, at.Unix(), words, id)
+	return err
+}
+
+// ListSessions returns the sessions started since the given time, oldest
+// first.
+func (s *SQLiteDB) ListSessions(since time.Time) ([]SessionRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT id, started_at, ended_at, words_before, words_after
+		FROM sessions
+		WHERE started_at >= ?
+		ORDER BY id
+	`, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []SessionRecord
+	for rows.Next() {
+		var r SessionRecord
+		var startedUnix int64
+		var endedUnix, wordsAfter sql.NullInt64
+		if err := rows.Scan(&r.ID, &startedUnix, &endedUnix, &r.WordsBefore, &wordsAfter); err != nil {
+			return nil, err
+		}
+		r.StartedAt = time.Unix(startedUnix, 0)
+		if endedUnix.Valid {
+			ended := time.Unix(endedUnix.Int64, 0)
+			r.EndedAt = &ended
+		}
+		if wordsAfter.Valid {
+			words := int(wordsAfter.Int64)
+			r.WordsAfter = &words
+		}
+		sessions = append(sessions, r)
+	}
+
+	return sessions, rows.Err()
+}
+
+// UsageCount is how many times something was used.
+type UsageCount struct {
+	Kind  string
+	Name  string
+	Count int
+}
+
+// RecordUsage counts one use of the named tool, command or suggestion
+// outcome, grouped by kind.
+func (s *SQLiteDB) RecordUsage(kind, name string, at time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO usage_events (kind, name, created_at) VALUES (?, ?, ?)`, kind, name, at.Unix())
+	return err
+}
+
+// CountUsage totals the uses recorded since the given time, most used
+// first.
+func (s *SQLiteDB) CountUsage(since time.Time) ([]UsageCount, error) {
+	rows, err := s.db.Query(`
+		SELECT kind, name, COUNT(*) AS uses
+		FROM usage_events
+		WHERE created_at >= ?
+		GROUP BY kind, name
+		ORDER BY uses DESC, name
+	`, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []UsageCount
+	for rows.Next() {
+		var c UsageCount
+		if err := rows.Scan(&c.Kind, &c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// ClearUsage deletes the recorded sessions and usage.
+func (s *SQLiteDB) ClearUsage() error {
+	if _, err := s.db.Exec("DELETE FROM sessions"); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("DELETE FROM usage_events")
+	return err
+}
+
+// TokenUsage is the tokens used by a model, with their estimated cost.
+type TokenUsage struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// Tokens returns the prompt and completion tokens together.
+func (u TokenUsage) Tokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// RecordTokenUsage records the tokens one AI request used.
+func (s *SQLiteDB) RecordTokenUsage(u TokenUsage, at time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT INTO token_usage (model, prompt_tokens, completion_tokens, cost, created_at) VALUES (?, ?, ?, ?, ?)`,
+		u.Model, u.PromptTokens, u.CompletionTokens, u.Cost, at.Unix(),
+	)
+	return err
+}
+
+// TokenUsageSince totals the tokens used per model since the given time,
+// the most used model first.
+func (s *SQLiteDB) TokenUsageSince(since time.Time) ([]TokenUsage, error) {
+	rows, err := s.db.Query(`
+		SELECT model, SUM(prompt_tokens), SUM(completion_tokens), SUM(cost)
+		FROM token_usage
+		WHERE created_at >= ?
+		GROUP BY model
+		ORDER BY SUM(prompt_tokens) + SUM(completion_tokens) DESC, model
+	`, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []TokenUsage
+	for rows.Next() {
+		var u TokenUsage
+		if err := rows.Scan(&u.Model, &u.PromptTokens, &u.CompletionTokens, &u.Cost); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// SuggestionFeedback records what the author did with a suggestion.
+type SuggestionFeedback struct {
+	ID int64
+
+	// Kind is the suggestion's type, such as "plot".
+	Kind    string
+	Title   string
+	Content string
+
+	// Outcome is "accepted", "rejected" or "modified".
+	Outcome string
+
+	// Note is what the author asked to change in a modified suggestion.
+	Note string
+
+	CreatedAt time.Time
+}
+
+// SaveSuggestionFeedback records the outcome of a suggestion.
+func (s *SQLiteDB) SaveSuggestionFeedback(f SuggestionFeedback) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO suggestion_feedback (kind, title, content, outcome, note, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		f.Kind, f.Title, f.Content, f.Outcome, f.Note, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// SetSuggestionFeedbackNote records what the author asked to change in a
+// modified suggestion.
+func (s *SQLiteDB) SetSuggestionFeedbackNote(id int64, note string) error {
+	_, err := s.db.Exec(`UPDATE suggestion_feedback SET note = ? WHERE id = ?`, note, id)
+	return err
+}
+
+// ListSuggestionFeedback returns up to limit outcomes recorded after the
+// one with the given ID, oldest first.
+func (s *SQLiteDB) ListSuggestionFeedback(afterID int64, limit int) ([]SuggestionFeedback, error) {
+	rows, err := s.db.Query(`
+		SELECT id, kind, title, content, outcome, note, created_at
+		FROM suggestion_feedback
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feedback []SuggestionFeedback
+	for rows.Next() {
+		var f SuggestionFeedback
+		var createdUnix int64
+		if err := rows.Scan(&f.ID, &f.Kind, &f.Title, &f.Content, &f.Outcome, &f.Note, &createdUnix); err != nil {
+			return nil, err
+		}
+		f.CreatedAt = time.Unix(createdUnix, 0)
+		feedback = append(feedback, f)
+	}
+
+	return feedback, rows.Err()
+}
+
+// CountSuggestionFeedback counts the recorded outcomes by outcome.
+func (s *SQLiteDB) CountSuggestionFeedback() (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT outcome, COUNT(*) FROM suggestion_feedback GROUP BY outcome`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var outcome string
+		var n int
+		if err := rows.Scan(&outcome, &n); err != nil {
+			return nil, err
+		}
+		counts[outcome] = n
+	}
+
+	return counts, rows.Err()
+}
+
+// PreferenceDigest is the model's summary of the author's preferences.
+type PreferenceDigest struct {
+	Text string
+
+	// FeedbackID is the last suggestion outcome the digest covers.
+	FeedbackID int64
+
+	UpdatedAt time.Time
+}
+
+// GetPreferenceDigest returns the preference digest, or nil if there is
+// none yet.
+func (s *SQLiteDB) GetPreferenceDigest() (*PreferenceDigest, error) {
+	var d PreferenceDigest
+	var updatedUnix int64
+	err := s.db.QueryRow(`SELECT digest, feedback_id, updated_at FROM preference_digest WHERE id = 1`).Scan(&d.Text, &d.FeedbackID, &updatedUnix)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.UpdatedAt = time.Unix(updatedUnix, 0)
+	return &d, nil
+}
+
+// SavePreferenceDigest replaces the preference digest.
+func (s *SQLiteDB) SavePreferenceDigest(d PreferenceDigest) error {
+	_, err := s.db.Exec(`
+		INSERT INTO preference_digest (id, digest, feedback_id, updated_at) VALUES (1, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET digest = excluded.digest, feedback_id = excluded.feedback_id, updated_at = excluded.updated_at
+	`, d.Text, d.FeedbackID, time.Now().Unix())
+	return err
+}
+
+// ClearSuggestionFeedback deletes the recorded outcomes and the digest.
+func (s *SQLiteDB) ClearSuggestionFeedback() error {
+	if _, err := s.db.Exec("DELETE FROM suggestion_feedback"); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("DELETE FROM preference_digest")
 	return err
 }
 
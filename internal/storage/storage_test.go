@@ -5,11 +5,16 @@ package storage
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	xunicode "golang.org/x/text/encoding/unicode"
 )
 
 // setupTestDB creates a temporary SQLite database for testing.
@@ -350,6 +355,54 @@ title: Just Frontmatter
 		}
 	})
 
+	t.Run("ParseMarkdownSection extracts a named section", func(t *testing.T) {
+		fs := NewFileSystem(t.TempDir())
+
+		content := `# Mira
+
+Harbor pilot.
+
+## Voice
+
+Clipped sentences.
+
+### Habits
+
+Never swears.
+
+## Sample Lines
+
+- "Tide's turning."`
+
+		tests := []struct {
+			name     string
+			names    []string
+			expected string
+		}{
+			{
+				name:     "includes nested headings",
+				names:    []string{"voice"},
+				expected: "Clipped sentences.\n\n### Habits\n\nNever swears.",
+			},
+			{
+				name:     "matches any alias",
+				names:    []string{"대사 예시", "Sample Lines"},
+				expected: `- "Tide's turning."`,
+			},
+			{
+				name:     "missing section",
+				names:    []string{"Backstory"},
+				expected: "",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.expected, fs.ParseMarkdownSection(content, tt.names...))
+			})
+		}
+	})
+
 	t.Run("GetFileInfo returns correct metadata", func(t *testing.T) {
 		tempDir := t.TempDir()
 		fs := NewFileSystem(tempDir)
@@ -407,6 +460,40 @@ title: Just Frontmatter
 
 		assert.Equal(t, tempDir, fs.BasePath())
 	})
+
+	t.Run("Mount shows an outside directory under a name", func(t *testing.T) {
+		tempDir := t.TempDir()
+		shared := t.TempDir()
+		fs := NewFileSystem(tempDir)
+		require.NoError(t, fs.WriteMarkdown("context/own.md", "own"))
+		require.NoError(t, os.MkdirAll(filepath.Join(shared, "characters"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(shared, "characters", "hana.md"), []byte("# Hana"), 0644))
+
+		fs.Mount("series", shared)
+		content, err := fs.ReadMarkdown("series/characters/hana.md")
+		require.NoError(t, err)
+		assert.Equal(t, "# Hana", content)
+
+		files, err := fs.ListMarkdownFiles("series/characters")
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.Equal(t, filepath.Join("series", "characters", "hana.md"), files[0].Path)
+
+		// Listing the project root includes mounted files.
+		files, err = fs.ListMarkdownFiles(".")
+		require.NoError(t, err)
+		var paths []string
+		for _, f := range files {
+			paths = append(paths, f.Path)
+		}
+		assert.Equal(t, []string{filepath.Join("context", "own.md"), filepath.Join("series", "characters", "hana.md")}, paths)
+
+		require.NoError(t, fs.WriteMarkdown("series/characters/jun.md", "# Jun"))
+		assert.FileExists(t, filepath.Join(shared, "characters", "jun.md"))
+
+		fs.Unmount("series")
+		assert.False(t, fs.Exists("series/characters/hana.md"))
+	})
 }
 
 // =============================================================================
@@ -550,6 +637,24 @@ func TestSQLiteDB_FileTracking(t *testing.T) {
 		assert.Equal(t, newMtime.Unix(), info.MTime.Unix())
 	})
 
+	t.Run("TrackFile records the content hash", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		path := "hashed.md"
+		require.NoError(t, db.TrackFile(path, time.Now(), "abc123"))
+
+		info, err := db.GetFileTracking(path)
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", info.Hash)
+
+		// Tracking without a hash clears it, so the next sync rereads the file.
+		require.NoError(t, db.UpdateFileTracking(path, time.Now()))
+		info, err = db.GetFileTracking(path)
+		require.NoError(t, err)
+		assert.Empty(t, info.Hash)
+	})
+
 	t.Run("DeleteFileTracking removes tracking", func(t *testing.T) {
 		db, cleanup := setupTestDB(t)
 		defer cleanup()
@@ -605,17 +710,17 @@ func TestSQLiteDB_Conversation(t *testing.T) {
 		defer cleanup()
 
 		// Save messages
-		err := db.SaveConversationMessage("user", "Hello, how are you?")
+		err := db.SaveConversationMessage(MainThread, "user", "Hello, how are you?")
 		require.NoError(t, err)
 
-		err = db.SaveConversationMessage("assistant", "I'm doing well, thank you!")
+		err = db.SaveConversationMessage(MainThread, "assistant", "I'm doing well, thank you!")
 		require.NoError(t, err)
 
-		err = db.SaveConversationMessage("user", "Great to hear!")
+		err = db.SaveConversationMessage(MainThread, "user", "Great to hear!")
 		require.NoError(t, err)
 
 		// Get history
-		history, err := db.GetConversationHistory(10)
+		history, err := db.GetConversationHistory(MainThread, 10)
 		require.NoError(t, err)
 		require.Len(t, history, 3)
 
@@ -635,11 +740,11 @@ func TestSQLiteDB_Conversation(t *testing.T) {
 		defer cleanup()
 
 		for i := 0; i < 10; i++ {
-			err := db.SaveConversationMessage("user", "Message")
+			err := db.SaveConversationMessage(MainThread, "user", "Message")
 			require.NoError(t, err)
 		}
 
-		history, err := db.GetConversationHistory(5)
+		history, err := db.GetConversationHistory(MainThread, 5)
 		require.NoError(t, err)
 		assert.Len(t, history, 5)
 	})
@@ -649,11 +754,11 @@ func TestSQLiteDB_Conversation(t *testing.T) {
 		defer cleanup()
 
 		for i := 1; i <= 10; i++ {
-			err := db.SaveConversationMessage("user", "Message "+string(rune('0'+i)))
+			err := db.SaveConversationMessage(MainThread, "user", "Message "+string(rune('0'+i)))
 			require.NoError(t, err)
 		}
 
-		history, err := db.GetConversationHistory(3)
+		history, err := db.GetConversationHistory(MainThread, 3)
 		require.NoError(t, err)
 		require.Len(t, history, 3)
 
@@ -663,11 +768,71 @@ func TestSQLiteDB_Conversation(t *testing.T) {
 		// Note: Message 10 would be ":' (rune 58) - let's fix this edge case
 	})
 
+	t.Run("interrupted message can be completed in place", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		require.NoError(t, db.SaveConversationMessage(MainThread, "user", "Write the storm scene"))
+		require.NoError(t, db.SaveInterruptedMessage(MainThread, "assistant", "The wind rose"))
+
+		history, err := db.GetConversationHistory(MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.False(t, history[0].Interrupted)
+		assert.True(t, history[1].Interrupted)
+
+		require.NoError(t, db.UpdateLastConversationMessage(MainThread, "The wind rose and the harbor flooded.", false))
+
+		history, err = db.GetConversationHistory(MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, "The wind rose and the harbor flooded.", history[1].Content)
+		assert.False(t, history[1].Interrupted)
+	})
+
+	t.Run("generated messages record their model", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		require.NoError(t, db.SaveConversationMessage(MainThread, "user", "Write the storm scene"))
+		require.NoError(t, db.SaveGeneratedMessage(MainThread, "The wind rose", "gpt-4o", false))
+		require.NoError(t, db.SaveGeneratedMessage(MainThread, "The wind climbed", "claude-sonnet", true))
+
+		history, err := db.GetConversationHistory(MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Empty(t, history[0].Model)
+		assert.Equal(t, "assistant", history[1].Role)
+		assert.Equal(t, "gpt-4o", history[1].Model)
+		assert.Equal(t, "claude-sonnet", history[2].Model)
+		assert.True(t, history[2].Interrupted)
+	})
+
+	t.Run("last message can be marked as a draft", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		require.NoError(t, db.SaveConversationMessage(MainThread, "user", "Write the storm scene"))
+		require.NoError(t, db.SaveGeneratedMessage(MainThread, "The wind rose", "llama3", false))
+		require.NoError(t, db.SetLastConversationDraft(MainThread, true))
+
+		history, err := db.GetConversationHistory(MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.False(t, history[0].Draft)
+		assert.True(t, history[1].Draft)
+
+		require.NoError(t, db.SetLastConversationDraft(MainThread, false))
+		history, err = db.GetConversationHistory(MainThread, 10)
+		require.NoError(t, err)
+		assert.False(t, history[1].Draft)
+	})
+
 	t.Run("GetConversationHistory returns empty slice when no messages", func(t *testing.T) {
 		db, cleanup := setupTestDB(t)
 		defer cleanup()
 
-		history, err := db.GetConversationHistory(10)
+		history, err := db.GetConversationHistory(MainThread, 10)
 		require.NoError(t, err)
 		assert.Empty(t, history)
 	})
@@ -677,17 +842,17 @@ func TestSQLiteDB_Conversation(t *testing.T) {
 		defer cleanup()
 
 		// Add messages
-		err := db.SaveConversationMessage("user", "Message 1")
+		err := db.SaveConversationMessage(MainThread, "user", "Message 1")
 		require.NoError(t, err)
-		err = db.SaveConversationMessage("assistant", "Message 2")
+		err = db.SaveConversationMessage(MainThread, "assistant", "Message 2")
 		require.NoError(t, err)
 
 		// Clear
-		err = db.ClearConversation()
+		err = db.ClearConversation(MainThread)
 		require.NoError(t, err)
 
 		// Verify empty
-		history, err := db.GetConversationHistory(10)
+		history, err := db.GetConversationHistory(MainThread, 10)
 		require.NoError(t, err)
 		assert.Empty(t, history)
 	})
@@ -698,18 +863,325 @@ func TestSQLiteDB_Conversation(t *testing.T) {
 
 		beforeSave := time.Now().Add(-time.Second)
 
-		err := db.SaveConversationMessage("user", "Test message")
+		err := db.SaveConversationMessage(MainThread, "user", "Test message")
 		require.NoError(t, err)
 
 		afterSave := time.Now().Add(time.Second)
 
-		history, err := db.GetConversationHistory(1)
+		history, err := db.GetConversationHistory(MainThread, 1)
 		require.NoError(t, err)
 		require.Len(t, history, 1)
 
 		assert.True(t, history[0].Timestamp.After(beforeSave))
 		assert.True(t, history[0].Timestamp.Before(afterSave))
 	})
+	t.Run("threads keep separate histories", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		require.NoError(t, db.SaveConversationMessage("worldbuilding", "user", "How does the tide magic work?"))
+		require.NoError(t, db.SaveConversationMessage(MainThread, "user", "Draft chapter 3"))
+		require.NoError(t, db.SaveGeneratedMessage(MainThread, "The ferry left at dawn.", "gpt-4o", true))
+		require.NoError(t, db.SaveGeneratedMessage("worldbuilding", "It follows the moon.", "gpt-4o", false))
+
+		// Updates touch the thread's own last message.
+		require.NoError(t, db.UpdateLastConversationMessage(MainThread, "The ferry left at dawn, late.", false))
+		require.NoError(t, db.SetLastConversationDraft(MainThread, true))
+
+		threads, err := db.ConversationThreads()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"worldbuilding", MainThread}, threads)
+
+		main, err := db.GetConversationHistory(MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, main, 2)
+		assert.Equal(t, "The ferry left at dawn, late.", main[1].Content)
+		assert.True(t, main[1].Draft)
+
+		world, err := db.GetConversationHistory("worldbuilding", 10)
+		require.NoError(t, err)
+		require.Len(t, world, 2)
+		assert.Equal(t, "It follows the moon.", world[1].Content)
+		assert.False(t, world[1].Draft)
+
+		require.NoError(t, db.ClearConversation("worldbuilding"))
+		threads, err = db.ConversationThreads()
+		require.NoError(t, err)
+		assert.Equal(t, []string{MainThread}, threads)
+	})
+
+	t.Run("thread personas", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		personas, err := db.ThreadPersonas()
+		require.NoError(t, err)
+		assert.Empty(t, personas)
+
+		require.NoError(t, db.SetThreadPersona("research", "librarian"))
+		require.NoError(t, db.SetThreadPersona("research", "librarian"))
+		require.NoError(t, db.SetThreadPersona(MainThread, "librarian"))
+		require.NoError(t, db.SetThreadPersona(MainThread, ""))
+
+		personas, err = db.ThreadPersonas()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"research": "librarian"}, personas)
+	})
+}
+
+func TestSQLiteDB_Sprints(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	started := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+	_, err := db.SaveSprint(SprintRecord{StartedAt: started, Planned: 25 * time.Minute, Actual: 25 * time.Minute, WordsBefore: 1000, WordsAfter: 1420, AILocked: true})
+	require.NoError(t, err)
+	_, err = db.SaveSprint(SprintRecord{StartedAt: started.Add(time.Minute), Planned: 10 * time.Minute, Actual: 4 * time.Minute, WordsBefore: 1420, WordsAfter: 1500})
+	require.NoError(t, err)
+
+	sprints, err := db.ListSprints(10)
+	require.NoError(t, err)
+	require.Len(t, sprints, 2)
+
+	assert.Equal(t, 4*time.Minute, sprints[0].Actual)
+	assert.Equal(t, 80, sprints[0].WordsWritten())
+	assert.False(t, sprints[0].AILocked)
+
+	assert.Equal(t, started, sprints[1].StartedAt)
+	assert.Equal(t, 25*time.Minute, sprints[1].Planned)
+	assert.Equal(t, 420, sprints[1].WordsWritten())
+	assert.True(t, sprints[1].AILocked)
+
+	sprints, err = db.ListSprints(1)
+	require.NoError(t, err)
+	assert.Len(t, sprints, 1)
+}
+
+func TestSQLiteDB_DailyWords(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	day1 := time.Date(2026, 11, 1, 9, 0, 0, 0, time.Local)
+	require.NoError(t, db.RecordDailyWords(day1, 1200))
+	require.NoError(t, db.RecordDailyWords(day1.Add(12*time.Hour), 2900))
+	require.NoError(t, db.RecordDailyWords(day1.AddDate(0, 0, 2), 4000))
+
+	days, err := db.ListDailyWords(day1)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, DailyWords{Day: time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local), Words: 2900}, days[0], "a later count replaces the day's earlier one")
+	assert.Equal(t, 4000, days[1].Words)
+
+	days, err = db.ListDailyWords(day1.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Len(t, days, 1)
+}
+
+func TestSQLiteDB_PromptHistory(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, prompt := range []string{"first", "second", "first", "third"} {
+		require.NoError(t, db.AddPromptHistory(prompt))
+	}
+
+	prompts, err := db.ListPromptHistory(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"third", "first", "second"}, prompts, "a resent prompt moves to the front")
+
+	prompts, err = db.ListPromptHistory(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"third", "first"}, prompts)
+}
+
+func TestSQLiteDB_Annotations(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := db.SaveAnnotation(AnnotationRecord{Chapter: 2, Source: "copy-editor", Severity: "minor", Quote: "teh", Comment: "Typo", Suggestion: "the"})
+	require.NoError(t, err)
+	_, err = db.SaveAnnotation(AnnotationRecord{Chapter: 1, Source: "genre-fan", Comment: "Slow opening"})
+	require.NoError(t, err)
+	_, err = db.SaveAnnotation(AnnotationRecord{Chapter: 2, Source: "genre-fan", Comment: "Great twist"})
+	require.NoError(t, err)
+
+	annotations, err := db.ListAnnotations(0)
+	require.NoError(t, err)
+	require.Len(t, annotations, 3)
+	assert.Equal(t, "Slow opening", annotations[0].Comment, "annotations are in chapter order")
+	assert.Equal(t, "the", annotations[1].Suggestion)
+
+	require.NoError(t, db.DeleteAnnotations(2, "copy-editor"))
+	annotations, err = db.ListAnnotations(2)
+	require.NoError(t, err)
+	require.Len(t, annotations, 1)
+	assert.Equal(t, "Great twist", annotations[0].Comment)
+}
+
+func TestSQLiteDB_Generations(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	seed := int64(42)
+	first, err := db.SaveGeneration(GenerationRecord{Provider: "openai", Model: "gpt-4o", Seed: &seed, ChunkIDs: []int64{3, 7}, Request: `{"Messages":[]}`})
+	require.NoError(t, err)
+	second, err := db.SaveGeneration(GenerationRecord{Provider: "local", Model: "llama3", Request: "{}"})
+	require.NoError(t, err)
+	require.NoError(t, db.SetGenerationReply(first, "The storm broke."))
+
+	record, err := db.GetGeneration(first)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "gpt-4o", record.Model)
+	require.NotNil(t, record.Seed)
+	assert.Equal(t, int64(42), *record.Seed)
+	assert.Equal(t, []int64{3, 7}, record.ChunkIDs)
+	assert.Equal(t, `{"Messages":[]}`, record.Request)
+	assert.Equal(t, "The storm broke.", record.Reply)
+
+	records, err := db.ListGenerations(10)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, second, records[0].ID, "newest first")
+	assert.Nil(t, records[0].Seed)
+	assert.Empty(t, records[0].ChunkIDs)
+
+	record, err = db.GetGeneration(99)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	t.Run("keeps only the most recent manifests", func(t *testing.T) {
+		for i := 0; i < maxGenerations; i++ {
+			_, err := db.SaveGeneration(GenerationRecord{Provider: "openai", Model: "gpt-4o", Request: "{}"})
+			require.NoError(t, err)
+		}
+		records, err := db.ListGenerations(2 * maxGenerations)
+		require.NoError(t, err)
+		assert.Len(t, records, maxGenerations)
+
+		record, err := db.GetGeneration(first)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+}
+
+func TestSQLiteDB_Comparisons(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := db.SaveComparison(ComparisonRecord{Prompt: "the storm", Variants: [2]string{"gpt-4o", "gemini-2.5-flash"}, Replies: [2]string{"Rain.", "Thunder."}, Choice: 1})
+	require.NoError(t, err)
+	_, err = db.SaveComparison(ComparisonRecord{Prompt: "the harbor", Variants: [2]string{"gpt-4o", "gpt-4o at temperature 1.2"}, Replies: [2]string{"Boats.", "Gulls."}, Choice: -1})
+	require.NoError(t, err)
+
+	comparisons, err := db.ListComparisons(10)
+	require.NoError(t, err)
+	require.Len(t, comparisons, 2)
+	assert.Equal(t, "the harbor", comparisons[0].Prompt, "newest first")
+	assert.Equal(t, -1, comparisons[0].Choice)
+	assert.Equal(t, [2]string{"gpt-4o", "gemini-2.5-flash"}, comparisons[1].Variants)
+	assert.Equal(t, "Thunder.", comparisons[1].Replies[comparisons[1].Choice])
+}
+
+func TestSQLiteDB_Usage(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	start := time.Now().Add(-time.Hour)
+	id, err := db.StartSession(start, 1000)
+	require.NoError(t, err)
+	require.NoError(t, db.EndSession(id, start.Add(30*time.Minute), 1400))
+	_, err = db.StartSession(start.Add(45*time.Minute), 1400)
+	require.NoError(t, err)
+
+	sessions, err := db.ListSessions(start.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.NotNil(t, sessions[0].WordsAfter)
+	assert.Equal(t, 1400, *sessions[0].WordsAfter)
+	assert.Nil(t, sessions[1].EndedAt, "a session that has not ended")
+
+	for _, name := range []string{"search_context", "update_context", "search_context"} {
+		require.NoError(t, db.RecordUsage("tool", name, time.Now()))
+	}
+	require.NoError(t, db.RecordUsage("tool", "search_context", time.Now().AddDate(0, -2, 0)))
+
+	counts, err := db.CountUsage(time.Now().AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, []UsageCount{{Kind: "tool", Name: "search_context", Count: 2}, {Kind: "tool", Name: "update_context", Count: 1}}, counts)
+
+	require.NoError(t, db.ClearUsage())
+	sessions, err = db.ListSessions(time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	counts, err = db.CountUsage(time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
+
+func TestSQLiteDB_TokenUsage(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	require.NoError(t, db.RecordTokenUsage(TokenUsage{Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 200, Cost: 0.0045}, now))
+	require.NoError(t, db.RecordTokenUsage(TokenUsage{Model: "llama3", PromptTokens: 5000, CompletionTokens: 800}, now))
+	require.NoError(t, db.RecordTokenUsage(TokenUsage{Model: "gpt-4o", PromptTokens: 400, CompletionTokens: 100, Cost: 0.002}, now))
+	require.NoError(t, db.RecordTokenUsage(TokenUsage{Model: "gpt-4o", PromptTokens: 9000, CompletionTokens: 900}, now.AddDate(0, -2, 0)))
+
+	usage, err := db.TokenUsageSince(now.AddDate(0, 0, -7))
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	assert.Equal(t, "llama3", usage[0].Model)
+	assert.Equal(t, 5800, usage[0].Tokens())
+	assert.Equal(t, "gpt-4o", usage[1].Model)
+	assert.Equal(t, 1400, usage[1].PromptTokens)
+	assert.Equal(t, 300, usage[1].CompletionTokens)
+	assert.InDelta(t, 0.0065, usage[1].Cost, 1e-9)
+}
+
+func TestSQLiteDB_SuggestionFeedback(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first, err := db.SaveSuggestionFeedback(SuggestionFeedback{Kind: "plot", Title: "Mira betrays Jun", Content: "In chapter 9 Mira sells Jun out.", Outcome: "rejected"})
+	require.NoError(t, err)
+	second, err := db.SaveSuggestionFeedback(SuggestionFeedback{Kind: "plot", Title: "A storm strands them", Content: "The ferry stops running.", Outcome: "modified"})
+	require.NoError(t, err)
+	require.NoError(t, db.SetSuggestionFeedbackNote(second, "make it fog instead"))
+
+	feedback, err := db.ListSuggestionFeedback(0, 10)
+	require.NoError(t, err)
+	require.Len(t, feedback, 2)
+	assert.Equal(t, "Mira betrays Jun", feedback[0].Title, "oldest first")
+	assert.Equal(t, "make it fog instead", feedback[1].Note)
+
+	feedback, err = db.ListSuggestionFeedback(first, 10)
+	require.NoError(t, err)
+	require.Len(t, feedback, 1)
+
+	counts, err := db.CountSuggestionFeedback()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"rejected": 1, "modified": 1}, counts)
+
+	digest, err := db.GetPreferenceDigest()
+	require.NoError(t, err)
+	assert.Nil(t, digest)
+	require.NoError(t, db.SavePreferenceDigest(PreferenceDigest{Text: "- Dislikes betrayals", FeedbackID: first}))
+	require.NoError(t, db.SavePreferenceDigest(PreferenceDigest{Text: "- Dislikes betrayals\n- Likes fog", FeedbackID: second}))
+	digest, err = db.GetPreferenceDigest()
+	require.NoError(t, err)
+	require.NotNil(t, digest)
+	assert.Equal(t, second, digest.FeedbackID)
+	assert.Contains(t, digest.Text, "Likes fog")
+
+	require.NoError(t, db.ClearSuggestionFeedback())
+	digest, err = db.GetPreferenceDigest()
+	require.NoError(t, err)
+	assert.Nil(t, digest)
+	feedback, err = db.ListSuggestionFeedback(0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, feedback)
 }
 
 func TestSQLiteDB_Close(t *testing.T) {
@@ -720,7 +1192,7 @@ func TestSQLiteDB_Close(t *testing.T) {
 		require.NoError(t, err)
 
 		// Attempting to use the database after close should fail
-		_, err = db.GetConversationHistory(10)
+		_, err = db.GetConversationHistory(MainThread, 10)
 		assert.Error(t, err)
 	})
 }
@@ -784,3 +1256,91 @@ func TestAtomicCopyFile(t *testing.T) {
 		assert.Equal(t, []byte("content"), dstContent)
 	})
 }
+
+func TestDecodeText(t *testing.T) {
+	encode := func(enc encoding.Encoding, s string) []byte {
+		data, err := enc.NewEncoder().Bytes([]byte(s))
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		want   string
+		format TextFormat
+	}{
+		{"utf-8", []byte("# 1장\n\n하나"), "# 1장\n\n하나", TextFormat{Encoding: "UTF-8"}},
+		{"bom and crlf", []byte("\xEF\xBB\xBF# One\r\n\r\nText\r"), "# One\n\nText\n", TextFormat{Encoding: "UTF-8", BOM: true, CRLF: true}},
+		{"utf-16", encode(xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM), "# 서울\r\n"), "# 서울\n", TextFormat{Encoding: "UTF-16BE", BOM: true, CRLF: true}},
+		{"euc-kr", encode(korean.EUCKR, "# 1장\n\n하나는 서울에 갔다."), "# 1장\n\n하나는 서울에 갔다.", TextFormat{Encoding: "EUC-KR"}},
+		{"shift_jis", encode(japanese.ShiftJIS, "# 第一章\n\nこんにちは、東京。"), "# 第一章\n\nこんにちは、東京。", TextFormat{Encoding: "Shift_JIS"}},
+		{"windows-1252", []byte("caf\xe9 \x93quoted\x94"), "café “quoted”", TextFormat{Encoding: "Windows-1252"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, format := DecodeText(tt.data)
+			assert.Equal(t, tt.want, text)
+			assert.Equal(t, tt.format, format)
+			assert.Equal(t, tt.format == TextFormat{Encoding: "UTF-8"}, format.Normalized())
+		})
+	}
+}
+
+func TestFileSystem_NormalizeFile(t *testing.T) {
+	tempDir := t.TempDir()
+	fs := NewFileSystem(tempDir)
+	data, err := korean.EUCKR.NewEncoder().Bytes([]byte("# 하나\r\n\r\n안녕\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "hana.md"), data, 0644))
+
+	content, err := fs.ReadMarkdown("hana.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# 하나\n\n안녕\n", content)
+
+	format, err := fs.TextFormat("hana.md")
+	require.NoError(t, err)
+	assert.Equal(t, "EUC-KR, CRLF", format.String())
+
+	format, err = fs.NormalizeFile("hana.md")
+	require.NoError(t, err)
+	assert.False(t, format.Normalized())
+	raw, err := os.ReadFile(filepath.Join(tempDir, "hana.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# 하나\n\n안녕\n", string(raw))
+
+	format, err = fs.NormalizeFile("hana.md")
+	require.NoError(t, err)
+	assert.True(t, format.Normalized())
+}
+
+func TestValidateFileName(t *testing.T) {
+	for _, name := range []string{"hana.md", "하나.md", "夢の話", "chapter-001.md", "a b.md"} {
+		assert.NoError(t, ValidateFileName(name), name)
+	}
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`, "a:b", "what?", "tab\there", "dot.", "space ", "CON", "nul.md", "com3.txt", "Lpt1", strings.Repeat("가", 100)} {
+		assert.Error(t, ValidateFileName(name), name)
+	}
+	assert.True(t, IsReservedFileName("Aux.md"))
+	assert.False(t, IsReservedFileName("auxiliary.md"))
+}
+
+func TestSQLiteDB_IndexFormat(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	format, err := db.GetIndexFormat()
+	require.NoError(t, err)
+	assert.Nil(t, format)
+
+	want := IndexFormat{Version: 1, ChunkSize: 800, ChunkOverlap: 0.15, Chunking: "semantic", Tokenizer: "estimate"}
+	require.NoError(t, db.SetIndexFormat(want))
+	want.ChunkSize = 500
+	require.NoError(t, db.SetIndexFormat(want))
+
+	format, err = db.GetIndexFormat()
+	require.NoError(t, err)
+	require.NotNil(t, format)
+	assert.Equal(t, want, *format)
+}
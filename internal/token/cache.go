@@ -0,0 +1,59 @@
+package token
+
+import "sync"
+
+// maxCachedCounts bounds a CachedCounter; once full it starts over, which
+// drops counts for messages long since edited or cleared.
+const maxCachedCounts = 4096
+
+// TextCounter counts the tokens in a text.
+type TextCounter interface {
+	Count(text string) int
+}
+
+// CachedCounter remembers the counts of another counter by text. Chat
+// history is counted again for every request, so only messages that are
+// new, or were edited and are therefore a different text, get tokenized.
+// It is safe for concurrent use.
+type CachedCounter struct {
+	counter TextCounter
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewCachedCounter creates a counter that memoizes counter. A counter that
+// already memoizes is returned as is.
+func NewCachedCounter(counter TextCounter) *CachedCounter {
+	if cached, ok := counter.(*CachedCounter); ok {
+		return cached
+	}
+	return &CachedCounter{counter: counter}
+}
+
+// Count returns the number of tokens in text, counting it only the first
+// time it is seen.
+func (c *CachedCounter) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	c.mu.Lock()
+	n, ok := c.counts[text]
+	c.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	// Counted unlocked: tokenizing is the slow part, and counting the
+	// same text twice at once only does the work twice.
+	n = c.counter.Count(text)
+
+	c.mu.Lock()
+	if c.counts == nil || len(c.counts) >= maxCachedCounts {
+		c.counts = make(map[string]int)
+	}
+	c.counts[text] = n
+	c.mu.Unlock()
+	return n
+}
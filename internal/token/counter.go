@@ -12,6 +12,10 @@ import (
 type Counter struct {
 	encoder  *tiktoken.Tiktoken
 	encoding string
+
+	// messages memoizes message counts for CountMessages, which sees the
+	// same history on every call.
+	messages *CachedCounter
 }
 
 // Default encoding for fallback.
@@ -49,10 +53,12 @@ func NewCounter(encoding string) (*Counter, error) {
 		encoding = defaultEncoding
 	}
 
-	return &Counter{
+	counter := &Counter{
 		encoder:  encoder,
 		encoding: encoding,
-	}, nil
+	}
+	counter.messages = NewCachedCounter(counter)
+	return counter, nil
 }
 
 // Encoding returns the current encoding name.
@@ -72,16 +78,21 @@ func (c *Counter) Count(text string) int {
 // CountMessages counts the total tokens in a slice of chat messages,
 // including per-message overhead for role and formatting.
 // This follows OpenAI's token counting convention for chat messages.
+// Message contents are counted once per counter and remembered.
 func (c *Counter) CountMessages(messages []ChatMessage) int {
 	if len(messages) == 0 {
 		return 0
 	}
+	var contents TextCounter = c
+	if c.messages != nil {
+		contents = c.messages
+	}
 
 	total := 0
 	for _, msg := range messages {
 		// Each message has overhead for role and formatting
 		total += messageOverhead
-		total += c.Count(msg.Content)
+		total += contents.Count(msg.Content)
 
 		// Add tokens for role name if present
 		if msg.Name != "" {
@@ -195,6 +206,71 @@ func EstimateTokens(text string) int {
 	return (runeCount + 3) / 4
 }
 
+// EstimateEncoding names the EstimateTokens heuristic where an encoding
+// name is expected.
+const EstimateEncoding = "estimate"
+
+// EstimateCounter counts and splits text using the EstimateTokens heuristic.
+// It needs no encoder data, so it works without network access.
+type EstimateCounter struct{}
+
+// NewEstimateCounter creates a heuristic token counter.
+func NewEstimateCounter() *EstimateCounter {
+	return &EstimateCounter{}
+}
+
+// Encoding returns EstimateEncoding.
+func (c *EstimateCounter) Encoding() string {
+	return EstimateEncoding
+}
+
+// Count returns the estimated number of tokens in the given text.
+func (c *EstimateCounter) Count(text string) int {
+	return EstimateTokens(text)
+}
+
+// Split divides text into chunks of approximately chunkSize tokens,
+// using the same overlap semantics as Counter.Split.
+func (c *EstimateCounter) Split(text string, chunkSize int, overlap float64) []string {
+	if text == "" || chunkSize <= 0 {
+		return nil
+	}
+
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap >= 1 {
+		overlap = 0.9
+	}
+
+	runes := []rune(text)
+	runesPerChunk := chunkSize * 4
+	if len(runes) <= runesPerChunk {
+		return []string{text}
+	}
+
+	step := runesPerChunk - int(float64(runesPerChunk)*overlap)
+	if step <= 0 {
+		step = 1
+	}
+
+	var chunks []string
+	for i := 0; i < len(runes); i += step {
+		end := i + runesPerChunk
+		if end > len(runes) {
+			end = len(runes)
+		}
+
+		chunks = append(chunks, string(runes[i:end]))
+
+		if end >= len(runes) {
+			break
+		}
+	}
+
+	return chunks
+}
+
 // SplitByWords splits text into chunks trying to respect word boundaries.
 // Each chunk will be at most maxTokens. Returns chunks with approximate token counts.
 func (c *Counter) SplitByWords(text string, maxTokens int) []ChunkWithCount {
@@ -455,6 +455,34 @@ func TestEstimateTokens(t *testing.T) {
 	}
 }
 
+// TestEstimateCounter tests the network-free heuristic counter.
+func TestEstimateCounter(t *testing.T) {
+	counter := NewEstimateCounter()
+
+	t.Run("Count matches EstimateTokens", func(t *testing.T) {
+		text := "The dragon slept beneath the mountain."
+		assert.Equal(t, EstimateTokens(text), counter.Count(text))
+	})
+
+	t.Run("Split returns nil for empty text", func(t *testing.T) {
+		assert.Nil(t, counter.Split("", 10, 0.1))
+	})
+
+	t.Run("Split keeps short text whole", func(t *testing.T) {
+		chunks := counter.Split("short text", 10, 0.1)
+		assert.Equal(t, []string{"short text"}, chunks)
+	})
+
+	t.Run("Split produces overlapping chunks within limit", func(t *testing.T) {
+		text := strings.Repeat("가나다라마바사아자차", 20)
+		chunks := counter.Split(text, 10, 0.2)
+		require.Greater(t, len(chunks), 1)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, counter.Count(chunk), 10)
+		}
+	})
+}
+
 // TestCounter_TruncateToFit tests truncation from beginning or end.
 func TestCounter_TruncateToFit(t *testing.T) {
 	counter, err := NewCounter("cl100k_base")
@@ -1415,3 +1443,31 @@ func TestModelContextLimits(t *testing.T) {
 		})
 	}
 }
+
+// countingCounter counts how many texts it tokenized.
+type countingCounter struct {
+	calls int
+}
+
+func (c *countingCounter) Count(text string) int {
+	c.calls++
+	return len(strings.Fields(text))
+}
+
+// TestCachedCounter tests that texts are tokenized once until they change.
+func TestCachedCounter(t *testing.T) {
+	inner := &countingCounter{}
+	counter := NewCachedCounter(inner)
+	assert.Same(t, counter, NewCachedCounter(counter))
+
+	assert.Equal(t, 3, counter.Count("the storm broke"))
+	assert.Equal(t, 3, counter.Count("the storm broke"))
+	assert.Equal(t, 1, inner.calls)
+
+	// An edited message is a new text.
+	assert.Equal(t, 4, counter.Count("the storm broke early"))
+	assert.Equal(t, 2, inner.calls)
+
+	assert.Equal(t, 0, counter.Count(""))
+	assert.Equal(t, 2, inner.calls)
+}
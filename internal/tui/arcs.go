@@ -0,0 +1,228 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// arcExtractTimeout bounds the request that reads arcs from a chapter.
+const arcExtractTimeout = 2 * time.Minute
+
+// arcExtractPrompt asks the model for each character's state and goal in a
+// chapter as JSON.
+const arcExtractPrompt = `You track character arcs in a novel. Read the chapter and, for each listed character who appears in it, describe their emotional state and their goal at the end of the chapter in a few words each, in the chapter's language.
+
+Reply with JSON only: {"characters": [{"name": "...", "state": "...", "goal": "..."}]}. Use the names exactly as listed and leave out characters who do not appear.`
+
+// extractedArc is one character's state in the model's reply.
+type extractedArc struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Goal  string `json:"goal"`
+}
+
+// arcsExtractedMsg carries the arcs the model read from a chapter.
+type arcsExtractedMsg struct {
+	chapter int
+	arcs    []extractedArc
+	err     error
+}
+
+// handleArcsCommand handles /arcs [N | set <character> <chapter> <state> |
+// extract [chapter]].
+func (m *Model) handleArcsCommand(args []string) tea.Cmd {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return nil
+	}
+	if len(args) == 0 {
+		m.showArcReport(0)
+		return nil
+	}
+	if n, err := strconv.Atoi(args[0]); err == nil {
+		m.showArcReport(n)
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "set":
+		name, point, err := parseArcArgs(args[1:])
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		title, err := m.project.SetArcPoint(name, point)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		m.statusText = fmt.Sprintf("Arc updated: %s, chapter %d", title, point.Chapter)
+		m.showArcReport(0)
+	case "extract":
+		if m.offline {
+			m.showOfflineNotice()
+			return nil
+		}
+		if m.aiLocked() {
+			return nil
+		}
+		return m.extractArcs(args[1:])
+	default:
+		m.err = fmt.Errorf("usage: /arcs [N | set <character> <chapter> <state> [goal: <goal>] | extract [chapter]]")
+	}
+	return nil
+}
+
+// parseArcArgs splits "<character> <chapter> <state> [goal: <goal>]"; the
+// first number ends the character's name.
+func parseArcArgs(args []string) (string, project.ArcPoint, error) {
+	usage := fmt.Errorf("usage: /arcs set <character> <chapter> <state> [goal: <goal>]")
+	for i, arg := range args {
+		chapter, err := strconv.Atoi(arg)
+		if err != nil {
+			continue
+		}
+		if i == 0 || i == len(args)-1 {
+			return "", project.ArcPoint{}, usage
+		}
+		points := project.ParseArcPoints(fmt.Sprintf("- %d: %s", chapter, strings.Join(args[i+1:], " ")))
+		if len(points) == 0 {
+			return "", project.ArcPoint{}, usage
+		}
+		return strings.Join(args[:i], " "), points[0], nil
+	}
+	return "", project.ArcPoint{}, usage
+}
+
+// showArcReport shows every character's arc chart and who has been missing
+// for minGap chapters or more.
+func (m *Model) showArcReport(minGap int) {
+	report, err := m.project.ArcReport(minGap)
+	if err != nil {
+		m.err = err
+		return
+	}
+	content := report.String()
+	if len(report.Arcs) > 0 && len(report.Chapters) > 0 {
+		content += "\n\nRecord a state with /arcs set <character> <chapter> <state>, or let the AI read a chapter with /arcs extract [chapter]."
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	m.updateViewport()
+}
+
+// extractArcs asks the model for each character's state in a chapter, the
+// latest one by default.
+func (m *Model) extractArcs(args []string) tea.Cmd {
+	provider, model := m.activeProvider()
+	if provider == nil {
+		m.err = fmt.Errorf("no AI provider configured")
+		return nil
+	}
+	chapters, err := m.project.LoadChapters()
+	if err != nil {
+		m.err = fmt.Errorf("failed to load chapters: %w", err)
+		return nil
+	}
+	if len(chapters) == 0 {
+		m.err = fmt.Errorf("no chapters yet")
+		return nil
+	}
+	chapter := chapters[len(chapters)-1]
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			m.err = fmt.Errorf("invalid chapter: %s", args[0])
+			return nil
+		}
+		if chapter, err = m.project.FindChapter(n); err != nil {
+			m.err = err
+			return nil
+		}
+	}
+	characters, err := m.project.LoadCharacters()
+	if err != nil {
+		m.err = fmt.Errorf("failed to load characters: %w", err)
+		return nil
+	}
+	if len(characters) == 0 {
+		m.err = fmt.Errorf("no characters yet")
+		return nil
+	}
+
+	m.statusText = fmt.Sprintf("Reading character arcs in chapter %d with %s...", chapter.Number, model)
+	req := arcExtractRequest(chapter, characters)
+	number := chapter.Number
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), arcExtractTimeout)
+		defer cancel()
+		resp, err := provider.Chat(ctx, req)
+		if err != nil {
+			return arcsExtractedMsg{chapter: number, err: err}
+		}
+		arcs, err := parseExtractedArcs(resp.Message.Content)
+		return arcsExtractedMsg{chapter: number, arcs: arcs, err: err}
+	}
+}
+
+// arcExtractRequest builds the request that reads arcs from a chapter.
+func arcExtractRequest(chapter *types.Chapter, characters []*types.Character) llm.ChatRequest {
+	names := make([]string, len(characters))
+	for i, c := range characters {
+		names[i] = "- " + c.Name
+	}
+	user := fmt.Sprintf("Characters:\n%s\n\nChapter %d:\n\n%s", strings.Join(names, "\n"), chapter.Number, chapter.Content)
+	return llm.ChatRequest{
+		Messages:    []llm.ChatMessage{llm.NewSystemMessage(arcExtractPrompt), llm.NewUserMessage(user)},
+		Temperature: 0.2,
+		JSONMode:    true,
+	}
+}
+
+// parseExtractedArcs reads the model's JSON reply, which may be wrapped in
+// a code fence or prose.
+func parseExtractedArcs(content string) ([]extractedArc, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the model did not reply with JSON")
+	}
+	var reply struct {
+		Characters []extractedArc `json:"characters"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &reply); err != nil {
+		return nil, fmt.Errorf("invalid arc reply: %w", err)
+	}
+	return reply.Characters, nil
+}
+
+// handleArcsExtracted records the states the model read and shows the
+// arcs.
+func (m *Model) handleArcsExtracted(msg arcsExtractedMsg) {
+	m.statusText = ""
+	if msg.err != nil {
+		m.err = fmt.Errorf("arc extraction failed: %w", msg.err)
+		return
+	}
+
+	recorded := 0
+	for _, arc := range msg.arcs {
+		if strings.TrimSpace(arc.State) == "" {
+			continue
+		}
+		if _, err := m.project.SetArcPoint(arc.Name, project.ArcPoint{Chapter: msg.chapter, State: arc.State, Goal: arc.Goal}); err != nil {
+			continue
+		}
+		recorded++
+	}
+	m.statusText = fmt.Sprintf("Recorded %d arc state(s) from chapter %d", recorded, msg.chapter)
+	m.showArcReport(0)
+}
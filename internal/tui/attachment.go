@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/internal/token"
+)
+
+// Attachment is text queued to be sent with the next message.
+type Attachment struct {
+	Name    string
+	Content string
+}
+
+// Tokens estimates the attachment's size in tokens.
+func (a Attachment) Tokens() int {
+	return token.EstimateTokens(a.Content)
+}
+
+// attachmentPattern matches an attachment block in a sent message.
+var attachmentPattern = regexp.MustCompile(`(?s)\[Attached: ([^\]\n]+)\]\n(.*?)\n\[End of attachment\]`)
+
+// formatAttachment renders an attachment block appended to a message.
+func formatAttachment(a Attachment) string {
+	return fmt.Sprintf("[Attached: %s]\n%s\n[End of attachment]", a.Name, strings.TrimRight(a.Content, "\n"))
+}
+
+// withAttachments appends the attachment blocks to a message.
+func withAttachments(input string, attachments []Attachment) string {
+	if len(attachments) == 0 {
+		return input
+	}
+	parts := []string{input}
+	for _, a := range attachments {
+		parts = append(parts, formatAttachment(a))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// stripAttachments removes attachment blocks from a message, leaving what
+// the user typed.
+func stripAttachments(content string) string {
+	return strings.TrimSpace(attachmentPattern.ReplaceAllString(content, ""))
+}
+
+// collapseAttachments replaces attachment blocks with a one-line reference
+// for display.
+func collapseAttachments(content string) string {
+	return attachmentPattern.ReplaceAllStringFunc(content, func(block string) string {
+		match := attachmentPattern.FindStringSubmatch(block)
+		return fmt.Sprintf("📎 %s (~%d tokens)", match[1], token.EstimateTokens(match[2]))
+	})
+}
+
+// attachmentTokens estimates the tokens of all queued attachments.
+func (m *Model) attachmentTokens() int {
+	total := 0
+	for _, a := range m.attachments {
+		total += a.Tokens()
+	}
+	return total
+}
+
+// queueAttachment adds an attachment to the next message and reports it.
+func (m *Model) queueAttachment(a Attachment) {
+	if strings.TrimSpace(a.Content) == "" {
+		m.err = fmt.Errorf("nothing to attach: %s is empty", a.Name)
+		return
+	}
+	m.attachments = append(m.attachments, a)
+	m.messages = append(m.messages, Message{
+		Role:    "system",
+		Content: fmt.Sprintf("Attached %s (~%d tokens). It will be sent with your next message.", a.Name, a.Tokens()),
+	})
+	m.updateViewport()
+}
+
+// attachFile queues a project file. Paths are relative to the project root
+// and may not point outside it.
+func (m *Model) attachFile(path string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	root := m.project.Path()
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(root, path)
+	}
+	rel, err := filepath.Rel(root, filepath.Clean(full))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		m.err = fmt.Errorf("can only attach files inside the project: %s", path)
+		return
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, rel))
+	if err != nil {
+		m.err = fmt.Errorf("failed to read %s: %w", path, err)
+		return
+	}
+
+	text, _ := storage.DecodeText(content)
+	m.queueAttachment(Attachment{Name: filepath.ToSlash(rel), Content: text})
+}
+
+// readClipboard reads the system clipboard. It is a variable so tests can
+// replace it.
+var readClipboard = clipboard.ReadAll
+
+// attachClipboard queues the clipboard text.
+func (m *Model) attachClipboard() {
+	text, err := readClipboard()
+	if err != nil {
+		m.err = fmt.Errorf("failed to read the clipboard: %w", err)
+		return
+	}
+	m.queueAttachment(Attachment{Name: "clipboard", Content: text})
+}
+
+// showAttachments lists the attachments queued for the next message.
+func (m *Model) showAttachments() {
+	var sb strings.Builder
+	if len(m.attachments) == 0 {
+		sb.WriteString("No attachments. Use /attach <path> or /paste to add one.")
+	} else {
+		sb.WriteString(fmt.Sprintf("Attachments for the next message (~%d tokens):\n", m.attachmentTokens()))
+		for _, a := range m.attachments {
+			sb.WriteString(fmt.Sprintf("\n📎 %s (~%d tokens)", a.Name, a.Tokens()))
+		}
+		sb.WriteString("\n\nUse /attach clear to remove them.")
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// clearAttachments drops the queued attachments.
+func (m *Model) clearAttachments() {
+	m.attachments = nil
+	m.messages = append(m.messages, Message{Role: "system", Content: "Attachments cleared."})
+	m.updateViewport()
+}
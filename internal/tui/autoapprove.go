@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/token"
+	"github.com/azyu/dreamteller/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// autoApplied is the last set of context updates applied without asking,
+// kept so /undo can put the files back.
+type autoApplied struct {
+	title string
+	files []*pendingFile
+}
+
+// autoApproves reports whether every update matches one of rules. Creating
+// or replacing a character file is never approved automatically.
+func autoApproves(rules []types.AutoApproveRule, updates []llm.ContextUpdate) bool {
+	if len(rules) == 0 || len(updates) == 0 {
+		return false
+	}
+	for _, update := range updates {
+		if update.FileType == "character" && update.Operation != "append" {
+			return false
+		}
+		matched := false
+		for _, rule := range rules {
+			if ruleMatches(rule, update) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleMatches reports whether rule covers update.
+func ruleMatches(rule types.AutoApproveRule, update llm.ContextUpdate) bool {
+	if rule.FileType != "" && !strings.EqualFold(rule.FileType, update.FileType) {
+		return false
+	}
+	if rule.Operation != "" && !strings.EqualFold(rule.Operation, update.Operation) {
+		return false
+	}
+	return rule.MaxTokens <= 0 || token.EstimateTokens(update.Content) <= rule.MaxTokens
+}
+
+// pendingContextUpdates returns the updates of a context update suggestion.
+func pendingContextUpdates(s *SuggestionResult) []llm.ContextUpdate {
+	switch data := s.ParsedData.(type) {
+	case llm.ContextUpdate:
+		return []llm.ContextUpdate{data}
+	case []llm.ContextUpdate:
+		return data
+	}
+	return nil
+}
+
+// autoApplyContextUpdates applies a context update suggestion the project's
+// auto_approve rules cover, without showing it for approval. It reports
+// false, having changed nothing, if the suggestion needs the author: the
+// rules do not cover it, or the files changed on disk since it was made.
+func (m *Model) autoApplyContextUpdates(s *SuggestionResult) (tea.Cmd, bool) {
+	if s.Type != SuggestionTypeContextUpdate || m.project == nil || m.project.Config == nil {
+		return nil, false
+	}
+	updates := pendingContextUpdates(s)
+	if !autoApproves(m.project.Config.AutoApprove, updates) {
+		return nil, false
+	}
+
+	// Keep what the files held before, for /undo.
+	var files []*pendingFile
+	seen := make(map[string]bool)
+	for _, update := range updates {
+		for _, path := range []string{contextUpdatePath(update), m.suggestionHandler.destinationPath(update)} {
+			if path == "" || seen[path] {
+				continue
+			}
+			seen[path] = true
+			state, original, err := m.suggestionHandler.readContextFile(path)
+			if err != nil {
+				return nil, false
+			}
+			files = append(files, &pendingFile{path: path, existed: state.exists, original: original})
+		}
+	}
+
+	if err := m.applyContextUpdates(updates, s.snapshots); err != nil {
+		if _, ok := errContextConflict(err); ok {
+			return nil, false
+		}
+		m.err = err
+		m.endToolReply()
+		return nil, true
+	}
+	for _, f := range files {
+		if state, _, err := m.suggestionHandler.readContextFile(f.path); err == nil {
+			f.content = state.content
+			f.deleted = !state.exists
+		}
+	}
+	m.lastAutoApplied = &autoApplied{title: s.Title, files: files}
+	m.recordUsage(usageSuggestion, "accepted")
+
+	// applyContextUpdates reported what it wrote; say it was not asked.
+	last := &m.messages[len(m.messages)-1]
+	last.Content += " (approved automatically; /undo reverts it)"
+
+	toast, toastCmd := showToast("Applied automatically: "+s.Title+" — /undo reverts it", ToastSuccess, 5*time.Second)
+	m.toast = toast
+	m.endToolReply()
+	return toastCmd, true
+}
+
+// endToolReply ends a reply whose suggestion was handled without showing
+// it, and returns to the chat.
+func (m *Model) endToolReply() {
+	m.streaming = false
+	m.inputMode = true
+	m.textarea.Focus()
+	m.updateViewport()
+}
+
+// undoAutoApplied reverts the last context updates applied without asking,
+// unless the files have been changed since.
+func (m *Model) undoAutoApplied() {
+	undo := m.lastAutoApplied
+	if undo == nil {
+		m.err = fmt.Errorf("nothing to undo: no context update was applied automatically")
+		return
+	}
+	for _, f := range undo.files {
+		state, _, err := m.suggestionHandler.readContextFile(f.path)
+		if err != nil {
+			m.err = err
+			return
+		}
+		if state.exists == f.deleted || state.content != f.content {
+			m.err = fmt.Errorf("%s has changed since it was updated; not undoing", filepath.ToSlash(f.path))
+			return
+		}
+	}
+	if err := m.suggestionHandler.rollback(undo.files); err != nil {
+		m.err = fmt.Errorf("undo failed: %w", err)
+		return
+	}
+	for _, f := range undo.files {
+		if err := m.reindexContextFile(f.path); err != nil {
+			m.statusText = fmt.Sprintf("Undone, but reindexing %s failed: %v", filepath.ToSlash(f.path), err)
+		}
+	}
+	m.lastAutoApplied = nil
+	m.messages = append(m.messages, Message{Role: "system", Content: "Undone: " + undo.title})
+	m.updateViewport()
+}
@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/project"
+)
+
+// beatSheetKeys lists the beat sheet templates for usage messages.
+func beatSheetKeys() string {
+	keys := make([]string, len(project.BeatSheets))
+	for i, sheet := range project.BeatSheets {
+		keys[i] = sheet.Key
+	}
+	return strings.Join(keys, "|")
+}
+
+// handleBeatsCommand handles /beats [apply <template> | done <beat> |
+// undo <beat>].
+func (m *Model) handleBeatsCommand(args []string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	if len(args) == 0 {
+		m.showBeatProgress()
+		return
+	}
+
+	name := strings.Join(args[1:], " ")
+	switch strings.ToLower(args[0]) {
+	case "apply":
+		sheet, ok := project.FindBeatSheet(name)
+		if !ok {
+			m.err = fmt.Errorf("usage: /beats apply <%s>", beatSheetKeys())
+			return
+		}
+		written, err := m.project.ApplyBeatSheet(sheet.Key)
+		for _, path := range written {
+			if err := m.reindexContextFile(path); err != nil {
+				m.err = fmt.Errorf("indexing failed: %w", err)
+			}
+		}
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf(
+			"Added %d %s beats to context/plot/. Edit them with /context and mark each one with /beats done <beat> once it is written.",
+			len(written), sheet.Name)})
+		m.updateViewport()
+	case "done", "undo":
+		if name == "" {
+			m.err = fmt.Errorf("usage: /beats %s <beat>", strings.ToLower(args[0]))
+			return
+		}
+		done := strings.ToLower(args[0]) == "done"
+		title, err := m.project.SetBeatDone(name, done)
+		if err != nil {
+			m.err = err
+			return
+		}
+		if done {
+			m.statusText = "Beat done: " + title
+		} else {
+			m.statusText = "Beat reopened: " + title
+		}
+		m.showBeatProgress()
+	default:
+		m.err = fmt.Errorf("usage: /beats [apply <template> | done <beat> | undo <beat>]")
+	}
+}
+
+// showBeatProgress lists the outline's beats, which are done, and which the
+// word count says should have happened by now.
+func (m *Model) showBeatProgress() {
+	progress, err := m.project.BeatProgress()
+	if err != nil {
+		m.err = fmt.Errorf("failed to read beats: %w", err)
+		return
+	}
+
+	var sb strings.Builder
+	if len(progress.Beats) == 0 {
+		sb.WriteString(fmt.Sprintf("No beats in the outline yet. Add a template with /beats apply <%s>.", beatSheetKeys()))
+	} else {
+		sb.WriteString(fmt.Sprintf("Beats: %d/%d done", progress.Done(), len(progress.Beats)))
+		if progress.TargetWords > 0 {
+			sb.WriteString(fmt.Sprintf(" • %d/%d words (%d%%)", progress.Words, progress.TargetWords, progress.Words*100/progress.TargetWords))
+		}
+		sb.WriteString("\n")
+
+		overdue := 0
+		for _, beat := range progress.Beats {
+			mark := "○"
+			switch {
+			case beat.Done:
+				mark = "✓"
+			case beat.Overdue:
+				mark = "⚠"
+				overdue++
+			}
+			line := fmt.Sprintf("\n%s %3d%%  %s", mark, beat.Position, beat.Name)
+			if beat.Overdue {
+				line += fmt.Sprintf(" (due by %d words)", beat.DueWords)
+			}
+			sb.WriteString(line)
+		}
+
+		switch {
+		case progress.TargetWords == 0:
+			sb.WriteString("\n\nSet writing.target_words in .dreamteller/config.yaml to see which beats are due.")
+		case overdue > 0:
+			sb.WriteString(fmt.Sprintf("\n\n%d beat(s) should have happened by now. Mark written beats with /beats done <beat>.", overdue))
+		}
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
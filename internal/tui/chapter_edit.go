@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+)
+
+// SuggestionTypeChapterEdit is a line edit to a chapter the model
+// proposed, shown as a diff that always needs approval.
+const SuggestionTypeChapterEdit SuggestionType = "chapter_edit"
+
+// chapterReplacement is one edit located in a chapter.
+type chapterReplacement struct {
+	start, end int
+	replace    string
+}
+
+// applyChapterReplacements makes edits to content. Each passage to find
+// must appear in content exactly once, and no two may overlap, so an edit
+// can never land somewhere the model did not mean.
+func applyChapterReplacements(content string, edits []llm.TextReplacement) (string, error) {
+	located, err := locateChapterReplacements(content, edits)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, r := range located {
+		sb.WriteString(content[last:r.start])
+		sb.WriteString(r.replace)
+		last = r.end
+	}
+	sb.WriteString(content[last:])
+	return sb.String(), nil
+}
+
+// locateChapterReplacements finds where each edit applies in content, in
+// the order the passages appear.
+func locateChapterReplacements(content string, edits []llm.TextReplacement) ([]chapterReplacement, error) {
+	located := make([]chapterReplacement, 0, len(edits))
+	for i, edit := range edits {
+		switch n := strings.Count(content, edit.Find); n {
+		case 0:
+			return nil, fmt.Errorf("edit %d: passage not found in the chapter", i+1)
+		case 1:
+		default:
+			return nil, fmt.Errorf("edit %d: passage appears %d times in the chapter; quote more of the surrounding text", i+1, n)
+		}
+		start := strings.Index(content, edit.Find)
+		located = append(located, chapterReplacement{start: start, end: start + len(edit.Find), replace: edit.Replace})
+	}
+
+	sort.Slice(located, func(i, j int) bool { return located[i].start < located[j].start })
+	for i := 1; i < len(located); i++ {
+		if located[i].start < located[i-1].end {
+			return nil, fmt.Errorf("edits overlap; combine them into one edit")
+		}
+	}
+	return located, nil
+}
+
+// handleChapterEdit checks that a proposed chapter edit applies cleanly
+// and shows it as a diff.
+func (h *SuggestionHandler) handleChapterEdit(call llm.ToolCall, edit llm.ChapterEdit) (*SuggestionResult, error) {
+	if h.project == nil {
+		return nil, fmt.Errorf("no project loaded")
+	}
+	chapter, err := h.project.FindChapter(edit.Chapter)
+	if err != nil {
+		return nil, err
+	}
+	located, err := locateChapterReplacements(chapter.Content, edit.Edits)
+	if err != nil {
+		return nil, fmt.Errorf("chapter %d: %w", edit.Chapter, err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(styles.Subtitle.Render(fmt.Sprintf("Chapter %d: %s", chapter.Number, chapter.Title)))
+	sb.WriteString("\n")
+	sb.WriteString(styles.MutedText.Render(filepath.ToSlash(chapter.FilePath)))
+	sb.WriteString("\n")
+	for _, r := range located {
+		line := strings.Count(chapter.Content[:r.start], "\n") + 1
+		sb.WriteString("\n")
+		sb.WriteString(styles.MutedText.Render(fmt.Sprintf("Line %d", line)))
+		sb.WriteString("\n")
+		sb.WriteString(formatContentPreview(chapter.Content[r.start:r.end], "-"))
+		sb.WriteString(formatContentPreview(r.replace, "+"))
+	}
+	if edit.Reason != "" {
+		sb.WriteString("\n")
+		sb.WriteString(styles.MutedText.Render(fmt.Sprintf("Reason: %s", edit.Reason)))
+		sb.WriteString("\n")
+	}
+
+	return &SuggestionResult{
+		Type:             SuggestionTypeChapterEdit,
+		Title:            fmt.Sprintf("Edit Chapter %d?", chapter.Number),
+		Content:          sb.String(),
+		RequiresApproval: true,
+		ToolCallID:       call.ID,
+		ToolCall:         call,
+		ParsedData:       edit,
+	}, nil
+}
+
+// applyChapterEdit makes an approved chapter edit to the chapter as it is
+// now, so text written since the edit was proposed is kept, and refuses
+// if the edit no longer applies cleanly.
+func (m *Model) applyChapterEdit(edit llm.ChapterEdit) error {
+	if m.project == nil {
+		return fmt.Errorf("no project loaded")
+	}
+	chapter, err := m.project.FindChapter(edit.Chapter)
+	if err != nil {
+		return err
+	}
+	updated, err := applyChapterReplacements(chapter.Content, edit.Edits)
+	if err != nil {
+		return fmt.Errorf("chapter %d changed since the edit was proposed: %w", edit.Chapter, err)
+	}
+
+	before, err := m.project.WordCount()
+	if err != nil {
+		return err
+	}
+	if err := writeContextFile(filepath.Join(m.project.Path(), chapter.FilePath), []byte(updated)); err != nil {
+		return fmt.Errorf("failed to write chapter %d: %w", edit.Chapter, err)
+	}
+	if err := m.reindexContextFile(chapter.FilePath); err != nil {
+		m.statusText = fmt.Sprintf("Saved, but reindexing chapter %d failed: %v", edit.Chapter, err)
+	}
+	words, err := m.project.RecordDailyWords(time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.messages = append(m.messages, Message{
+		Role:    "system",
+		Content: fmt.Sprintf("Chapter %d edited (%d changes): the manuscript has %d words (%+d).", edit.Chapter, len(edit.Edits), words, words-before),
+	})
+	return nil
+}
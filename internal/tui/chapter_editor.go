@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chapterEditedMsg is sent when the external editor opened on a chapter
+// exits.
+type chapterEditedMsg struct {
+	number int
+	path   string
+
+	// original is the chapter before editing and words the manuscript's
+	// word count then.
+	original string
+	words    int
+
+	err error
+}
+
+// editChapter handles /edit-chapter [number]: it suspends the TUI and opens
+// the chapter, the active one by default, in the external editor.
+func (m *Model) editChapter(args []string) tea.Cmd {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return nil
+	}
+	number := m.activeChapterNumber()
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			m.err = fmt.Errorf("usage: /edit-chapter [number]")
+			return nil
+		}
+		number = n
+	}
+
+	chapter, err := m.project.FindChapter(number)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	words, err := m.project.WordCount()
+	if err != nil {
+		m.err = fmt.Errorf("failed to count words: %w", err)
+		return nil
+	}
+
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], filepath.Join(m.project.Path(), chapter.FilePath))...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return chapterEditedMsg{number: number, path: chapter.FilePath, original: chapter.Content, words: words, err: err}
+	})
+}
+
+// handleChapterEdited re-reads a chapter after the external editor exits,
+// reindexes it and reports the new word count.
+func (m *Model) handleChapterEdited(msg chapterEditedMsg) {
+	if msg.err != nil {
+		m.err = fmt.Errorf("editor exited with an error: %w", msg.err)
+	}
+
+	content, err := m.project.FS.ReadMarkdown(msg.path)
+	if err != nil {
+		m.err = fmt.Errorf("failed to read chapter %d: %w", msg.number, err)
+		return
+	}
+	if content == msg.original {
+		m.statusText = fmt.Sprintf("Chapter %d unchanged", msg.number)
+		return
+	}
+
+	if err := m.reindexContextFile(msg.path); err != nil {
+		m.err = fmt.Errorf("chapter %d saved, but reindexing failed: %w", msg.number, err)
+	}
+	words, err := m.project.RecordDailyWords(time.Now())
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.messages = append(m.messages, Message{
+		Role:    "system",
+		Content: fmt.Sprintf("Chapter %d updated: the manuscript has %d words (%+d).", msg.number, words, words-msg.words),
+	})
+	m.updateViewport()
+}
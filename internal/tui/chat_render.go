@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/prose"
+)
+
+// renderedMessage is a message as last rendered in the chat view, with
+// what its rendering depended on.
+type renderedMessage struct {
+	key   renderKey
+	text  string
+	lines int
+}
+
+// renderKey is everything a message's rendering depends on.
+type renderKey struct {
+	msg             Message
+	selected        bool
+	sandbox         bool
+	expandCitations bool
+	explainRanking  bool
+}
+
+// renderMessageCached returns message i rendered, from the cache when
+// neither the message nor the view state it depends on has changed. Long
+// sessions then only render the message being streamed into.
+func (m *Model) renderMessageCached(i int) renderedMessage {
+	msg := m.messages[i]
+	key := renderKey{
+		msg:             keyMessage(msg),
+		selected:        m.selectMode && i == m.selectedMessage,
+		sandbox:         m.sandbox != nil,
+		expandCitations: m.expandCitations,
+		explainRanking:  m.explainRanking,
+	}
+	if i < len(m.renderedMessages) && reflect.DeepEqual(m.renderedMessages[i].key, key) {
+		return m.renderedMessages[i]
+	}
+
+	text := m.renderMessage(msg, key.selected)
+	rendered := renderedMessage{key: key, text: text, lines: strings.Count(text, "\n")}
+	if i < len(m.renderedMessages) {
+		m.renderedMessages[i] = rendered
+	} else {
+		m.renderedMessages = append(m.renderedMessages, rendered)
+	}
+	return rendered
+}
+
+// keyMessage copies a message for a render key. The copy has its own
+// slices, since messages' slices are edited in place, with empty ones nil
+// so they compare equal.
+func keyMessage(msg Message) Message {
+	msg.Issues = append([]prose.Issue(nil), msg.Issues...)
+	msg.Citations = append([]Citation(nil), msg.Citations...)
+	msg.Retrieval = append([]RankedChunk(nil), msg.Retrieval...)
+	return msg
+}
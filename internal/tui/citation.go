@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+)
+
+// maxCitationSnippetRunes caps the snippet shown for an expanded citation.
+const maxCitationSnippetRunes = 240
+
+// Citation is a context chunk cited in an assistant reply.
+type Citation struct {
+	ID         int64
+	SourceType string
+	SourcePath string
+	Snippet    string
+}
+
+// resolveCitations looks up the chunks cited in msg. Tags that no longer
+// match an indexed chunk (e.g. after a reindex) are ignored.
+func (m *Model) resolveCitations(msg *Message) {
+	msg.Citations = nil
+	if m.searchEngine == nil || msg.Role != "assistant" {
+		return
+	}
+
+	for _, id := range llm.ExtractCitations(msg.Content) {
+		chunk, err := m.searchEngine.GetChunkByID(id)
+		if err != nil || chunk == nil {
+			continue
+		}
+		msg.Citations = append(msg.Citations, Citation{
+			ID:         id,
+			SourceType: chunk.SourceType,
+			SourcePath: chunk.SourcePath,
+			Snippet:    citationSnippet(chunk.Content),
+		})
+	}
+}
+
+// citationSnippet flattens content to one line of at most
+// maxCitationSnippetRunes runes.
+func citationSnippet(content string) string {
+	snippet := strings.Join(strings.Fields(content), " ")
+	if runes := []rune(snippet); len(runes) > maxCitationSnippetRunes {
+		snippet = string(runes[:maxCitationSnippetRunes-3]) + "..."
+	}
+	return snippet
+}
+
+// toggleCitations expands or collapses citation snippets in the chat.
+func (m *Model) toggleCitations() {
+	m.expandCitations = !m.expandCitations
+	m.updateViewport()
+}
+
+// renderCitations renders the sources cited by an assistant reply: a
+// compact list, or each source with its snippet when expanded.
+func (m *Model) renderCitations(citations []Citation) string {
+	if len(citations) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if !m.expandCitations {
+		refs := make([]string, len(citations))
+		for i, c := range citations {
+			refs[i] = fmt.Sprintf("%s %s", llm.CitationTag(c.ID), c.SourcePath)
+		}
+		sb.WriteString(styles.MutedText.Render("Sources: " + strings.Join(refs, " · ") + " — Ctrl+O or /sources to expand"))
+		return sb.String()
+	}
+
+	sb.WriteString(styles.MutedText.Render("Sources:"))
+	for _, c := range citations {
+		sb.WriteString("\n")
+		sb.WriteString(styles.InfoText.Render(fmt.Sprintf("%s %s (%s)", llm.CitationTag(c.ID), c.SourcePath, c.SourceType)))
+		sb.WriteString("\n")
+		sb.WriteString(styles.MutedText.Render("   " + c.Snippet))
+	}
+	return sb.String()
+}
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// clarificationAnswer is the user turn that answers the model's question
+// and lets it pick up the reply it stopped to ask about.
+const clarificationAnswer = "You asked: %s\nMy answer: %s\n\nContinue where you left off."
+
+// answerClarification sends the option chosen with key back to the model
+// as the next user turn. It reports false if key does not pick one of the
+// pending question's options.
+func (m *Model) answerClarification(key string) (tea.Model, tea.Cmd, bool) {
+	question, ok := m.pendingSuggestion.ParsedData.(llm.ClarificationQuestion)
+	if !ok {
+		return m, nil, false
+	}
+	n, err := strconv.Atoi(key)
+	if err != nil || n < 1 || n > len(question.Options) {
+		return m, nil, false
+	}
+	answer := fmt.Sprintf(clarificationAnswer, question.Question, question.Options[n-1])
+
+	m.pendingSuggestion = nil
+	m.view = ViewChat
+	m.inputMode = true
+	m.textarea.Focus()
+	if m.offline || m.aiLocked() {
+		// Keep the answer in the composer to send later.
+		if m.offline {
+			m.showOfflineNotice()
+		}
+		m.textarea.SetValue(answer)
+		m.resizeComposer()
+		m.updateViewport()
+		return m, nil, true
+	}
+	model, cmd := m.submitPrompt(answer)
+	return model, cmd, true
+}
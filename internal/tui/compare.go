@@ -0,0 +1,405 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// abUsage is the usage of /ab.
+const abUsage = "usage: /ab <@model|temperature> <@model|temperature> <prompt>"
+
+// abTallyLimit caps how many recorded comparisons /ab tallies.
+const abTallyLimit = 200
+
+// abVariant is one side of an A/B comparison: a model, or the session's
+// model at another temperature.
+type abVariant struct {
+	Provider string
+	Model    string
+
+	// Temperature replaces the request's temperature when above zero.
+	Temperature float64
+}
+
+// Label describes the variant for the comparison view and its record.
+func (v abVariant) Label() string {
+	if v.Temperature > 0 {
+		return fmt.Sprintf("%s at temperature %.1f", v.Model, v.Temperature)
+	}
+	return v.Model
+}
+
+// abComparison is a prompt answered by two variants, shown side by side
+// until one reply is kept.
+type abComparison struct {
+	prompt   string
+	variants [2]abVariant
+	replies  [2]string
+	errs     [2]error
+
+	// selected is the side the picker is on.
+	selected int
+}
+
+// abDoneMsg carries the replies of both sides of a comparison.
+type abDoneMsg struct {
+	comparison *abComparison
+}
+
+// parseABVariant reads a variant: @model, or a temperature for the
+// session's model.
+func (m *Model) parseABVariant(arg string) (abVariant, error) {
+	if ref, ok := strings.CutPrefix(arg, "@"); ok && ref != "" {
+		choice := m.resolveModelOverride(ref)
+		return abVariant{Provider: choice.Provider, Model: choice.Model}, nil
+	}
+	temperature, err := strconv.ParseFloat(arg, 64)
+	if err != nil || temperature <= 0 || temperature > 2 {
+		return abVariant{}, fmt.Errorf("invalid variant %q: want @model or a temperature between 0 and 2", arg)
+	}
+	return abVariant{Provider: m.providerName, Model: m.modelName, Temperature: temperature}, nil
+}
+
+// handleABCommand handles /ab: it sends a prompt to two variants at once
+// and shows the replies side by side. Without arguments it tallies which
+// variants were kept in past comparisons.
+func (m *Model) handleABCommand(input string) tea.Cmd {
+	fields := strings.Fields(input)
+	if len(fields) == 1 {
+		m.showABTally()
+		return nil
+	}
+	if len(fields) < 4 {
+		m.err = fmt.Errorf(abUsage)
+		return nil
+	}
+
+	// The prompt keeps its own spacing and line breaks.
+	prompt := input
+	for _, field := range fields[:3] {
+		prompt = strings.TrimSpace(prompt)
+		prompt = strings.TrimPrefix(prompt, field)
+	}
+	prompt = strings.TrimSpace(prompt)
+
+	var variants [2]abVariant
+	for i, arg := range fields[1:3] {
+		v, err := m.parseABVariant(arg)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		variants[i] = v
+	}
+	if variants[0] == variants[1] {
+		m.err = fmt.Errorf("both variants are %s; compare two models or two temperatures", variants[0].Label())
+		return nil
+	}
+	if m.offline {
+		m.showOfflineNotice()
+		return nil
+	}
+	if m.aiLocked() {
+		return nil
+	}
+	if m.comparing {
+		m.err = fmt.Errorf("a comparison is already running")
+		return nil
+	}
+
+	var providers [2]llm.Provider
+	var opened []llm.Provider
+	for i, v := range variants {
+		if v.Provider == m.providerName && v.Model == m.modelName {
+			providers[i] = m.provider
+			continue
+		}
+		if m.providerFactory == nil {
+			m.err = fmt.Errorf("model overrides are not available in this session")
+			break
+		}
+		provider, err := m.providerFactory(v.Provider, v.Model)
+		if err != nil {
+			m.err = fmt.Errorf("failed to use %s/%s: %w", v.Provider, v.Model, err)
+			break
+		}
+		providers[i] = provider
+		opened = append(opened, provider)
+	}
+	if providers[0] == nil || providers[1] == nil {
+		for _, p := range opened {
+			_ = p.Close()
+		}
+		if m.err == nil {
+			m.err = fmt.Errorf("no LLM provider configured")
+		}
+		return nil
+	}
+
+	m.messages = append(m.messages, Message{Role: "user", Content: prompt})
+	m.saveMessage("user", prompt)
+	m.comparing = true
+	m.statusText = fmt.Sprintf("Comparing %s and %s...", variants[0].Label(), variants[1].Label())
+	m.updateViewport()
+
+	comparison := &abComparison{prompt: prompt, variants: variants}
+	messages := make([]Message, len(m.messages))
+	copy(messages, m.messages)
+	messages = withHistorySummary(messages, m.historySummary)
+	proj, mode, engine, timeout := m.project, m.contextMode, m.searchEngine, m.streamConfig.Timeout
+
+	return func() tea.Msg {
+		defer func() {
+			for _, p := range opened {
+				_ = p.Close()
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for i := range variants {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				comparison.replies[i], comparison.errs[i] = generateABReply(proj, providers[i], variants[i], mode, engine, messages, timeout)
+			}(i)
+		}
+		wg.Wait()
+		return abDoneMsg{comparison: comparison}
+	}
+}
+
+// generateABReply answers the chat as it stands with one variant. Tools are
+// left out, since only one side's reply is kept.
+func generateABReply(proj *project.Project, provider llm.Provider, variant abVariant, mode ContextMode, engine *search.FTSEngine, messages []Message, timeout time.Duration) (string, error) {
+	assembled, err := assembleChatRequest(proj, provider, variant.Model, mode, engine, messages)
+	if err != nil {
+		return "", err
+	}
+	req := assembled.Request
+	req.Tools = nil
+	req.ToolChoice = ""
+	if variant.Temperature > 0 {
+		req.Temperature = variant.Temperature
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := provider.Chat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	// Providers without native tools may still answer with a text tool
+	// call, which is dropped.
+	_, reply := llm.ExtractTextToolCalls(resp.Message.Content, llm.ChatTools())
+	reply = strings.TrimSpace(reply)
+	if reply == "" {
+		return "", fmt.Errorf("the model returned no text")
+	}
+	return reply, nil
+}
+
+// handleABDone opens the comparison view once both sides have replied.
+func (m *Model) handleABDone(msg abDoneMsg) {
+	m.comparing = false
+	m.statusText = ""
+	c := msg.comparison
+	if c.errs[0] != nil && c.errs[1] != nil {
+		m.err = fmt.Errorf("comparison failed: %w", c.errs[0])
+		return
+	}
+	if c.errs[0] != nil {
+		c.selected = 1
+	}
+	m.comparison = c
+	m.view = ViewCompare
+	m.inputMode = false
+	m.updateViewport()
+	m.viewport.GotoTop()
+}
+
+// handleCompareKey handles the comparison view's picker.
+func (m *Model) handleCompareKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.comparison
+	switch msg.String() {
+	case "left", "h", "1":
+		if c.errs[0] == nil {
+			c.selected = 0
+		}
+	case "right", "l", "2":
+		if c.errs[1] == nil {
+			c.selected = 1
+		}
+	case "tab":
+		if c.errs[1-c.selected] == nil {
+			c.selected = 1 - c.selected
+		}
+	case "enter":
+		m.finishComparison(c.selected)
+		return m, nil
+	case "esc", "ctrl+c":
+		m.finishComparison(-1)
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	offset := m.viewport.YOffset
+	m.updateViewport()
+	m.viewport.SetYOffset(offset)
+	return m, nil
+}
+
+// finishComparison keeps the reply on side choice, or neither when choice
+// is -1, records the choice and returns to the chat.
+func (m *Model) finishComparison(choice int) {
+	c := m.comparison
+	m.comparison = nil
+	m.view = ViewChat
+	m.inputMode = true
+	m.textarea.Focus()
+
+	if m.project != nil && m.project.DB != nil && !m.offRecord() {
+		_, _ = m.project.DB.SaveComparison(storage.ComparisonRecord{
+			Prompt:   c.prompt,
+			Variants: [2]string{c.variants[0].Label(), c.variants[1].Label()},
+			Replies:  c.replies,
+			Choice:   choice,
+		})
+	}
+
+	if choice < 0 {
+		m.messages = append(m.messages, Message{Role: "system", Content: "Comparison discarded; neither reply was kept."})
+		m.updateViewport()
+		return
+	}
+
+	variant := c.variants[choice]
+	reply := Message{Role: "assistant", Content: c.replies[choice]}
+	if variant.Provider != m.providerName || variant.Model != m.modelName {
+		reply.Model = variant.Model
+	}
+	m.messages = append(m.messages, reply)
+	if m.project != nil && m.project.DB != nil && !m.offRecord() {
+		_ = m.project.DB.SaveGeneratedMessage(m.thread, reply.Content, variant.Model, false)
+	}
+	m.checkLastReply()
+	m.resolveCitations(&m.messages[len(m.messages)-1])
+	m.statusText = fmt.Sprintf("Kept the reply from %s", variant.Label())
+	m.updateViewport()
+}
+
+// renderComparison renders the two replies side by side.
+func (m *Model) renderComparison() string {
+	c := m.comparison
+	if c == nil {
+		return styles.MutedText.Render("No comparison.")
+	}
+
+	width := max((m.width-3)/2, 20)
+	var columns [2]string
+	for i, v := range c.variants {
+		header := fmt.Sprintf("%c · %s", 'A'+rune(i), v.Label())
+		if i == c.selected {
+			header = styles.SelectedItem.Render("▶ " + header)
+		} else {
+			header = styles.Subtitle.Render("  " + header)
+		}
+		body := c.replies[i]
+		if c.errs[i] != nil {
+			body = styles.ErrorText.Render("Failed: " + c.errs[i].Error())
+		}
+		columns[i] = lipgloss.NewStyle().Width(width).Render(header + "\n\n" + body)
+	}
+	divider := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderLeft(true).
+		BorderForeground(styles.Muted).
+		PaddingLeft(1)
+
+	var sb strings.Builder
+	sb.WriteString(styles.Title.Render("A/B: " + truncateContent(c.prompt, 60)))
+	sb.WriteString("\n\n")
+	sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, columns[0], " ", divider.Render(columns[1])))
+	sb.WriteString("\n\n")
+	sb.WriteString(fmt.Sprintf("  [%s] Choose  ", styles.HelpKey.Render("←/→")))
+	sb.WriteString(fmt.Sprintf("[%s] Keep  ", styles.HelpKey.Render("Enter")))
+	sb.WriteString(fmt.Sprintf("[%s] Discard both", styles.HelpKey.Render("Esc")))
+	return sb.String()
+}
+
+// showABTally shows how often each variant was kept in recorded
+// comparisons.
+func (m *Model) showABTally() {
+	if m.project == nil || m.project.DB == nil {
+		m.err = fmt.Errorf("no project database")
+		return
+	}
+	comparisons, err := m.project.DB.ListComparisons(abTallyLimit)
+	if err != nil {
+		m.err = fmt.Errorf("failed to load comparisons: %w", err)
+		return
+	}
+	if len(comparisons) == 0 {
+		m.messages = append(m.messages, Message{Role: "system", Content: "No comparisons yet. Compare two models with /ab @gpt-4o @gemini-2.5-flash <prompt>, or two temperatures of this model with /ab 0.7 1.2 <prompt>."})
+		m.updateViewport()
+		return
+	}
+
+	type tally struct {
+		label       string
+		kept, shown int
+	}
+	byLabel := make(map[string]*tally)
+	discarded := 0
+	for _, c := range comparisons {
+		for i, label := range c.Variants {
+			t, ok := byLabel[label]
+			if !ok {
+				t = &tally{label: label}
+				byLabel[label] = t
+			}
+			t.shown++
+			if c.Choice == i {
+				t.kept++
+			}
+		}
+		if c.Choice < 0 {
+			discarded++
+		}
+	}
+	tallies := make([]*tally, 0, len(byLabel))
+	for _, t := range byLabel {
+		tallies = append(tallies, t)
+	}
+	sort.Slice(tallies, func(i, j int) bool {
+		if tallies[i].kept != tallies[j].kept {
+			return tallies[i].kept > tallies[j].kept
+		}
+		return tallies[i].label < tallies[j].label
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Kept replies in the last %d comparisons:", len(comparisons)))
+	for _, t := range tallies {
+		sb.WriteString(fmt.Sprintf("\n  %s: kept %d of %d", t.label, t.kept, t.shown))
+	}
+	if discarded > 0 {
+		sb.WriteString(fmt.Sprintf("\n  Neither kept: %d", discarded))
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// composerCharLimit caps the length of a composed message.
+	composerCharLimit = 20000
+
+	// maxComposerHeight caps how many rows the composer grows to before it
+	// scrolls.
+	maxComposerHeight = 8
+
+	// multilineComposerHeight is the composer height in multi-line mode.
+	multilineComposerHeight = 3
+
+	// chromeHeight is the number of rows around the chat viewport (header,
+	// status, rules, a one-line composer and the status line).
+	chromeHeight = 8
+)
+
+// editorFinishedMsg is sent when the external editor exits.
+type editorFinishedMsg struct {
+	path string
+	err  error
+}
+
+// toggleMultiline switches between sending on Enter and inserting a newline
+// on Enter.
+func (m *Model) toggleMultiline() {
+	m.multiline = !m.multiline
+	if m.multiline {
+		m.statusText = "Multi-line mode: Enter adds a line, Alt+Enter or Ctrl+S sends"
+	} else {
+		m.statusText = "Single-line mode: Enter sends, Alt+Enter adds a line"
+	}
+	m.resizeComposer()
+}
+
+// handleComposerEnter handles Enter and its variants in the composer.
+// In single-line mode Enter sends and Alt+Enter or Ctrl+J (which many
+// terminals send for Shift+Enter) add a line; multi-line mode swaps them.
+func (m *Model) handleComposerEnter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	newline := msg.Type == tea.KeyCtrlJ || (msg.Type == tea.KeyEnter && msg.Alt)
+	if m.multiline {
+		newline = msg.Type == tea.KeyEnter && !msg.Alt
+	}
+
+	if newline {
+		m.textarea.InsertString("\n")
+		m.resizeComposer()
+		return m, nil
+	}
+	return m.handleSubmit()
+}
+
+// resizeComposer grows the composer with its content, up to
+// maxComposerHeight rows, and gives the rest of the screen to the chat.
+func (m *Model) resizeComposer() {
+	height := m.textarea.LineCount()
+	if m.multiline && height < multilineComposerHeight {
+		height = multilineComposerHeight
+	}
+	if height > maxComposerHeight {
+		height = maxComposerHeight
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	if height != m.textarea.Height() {
+		m.textarea.SetHeight(height)
+	}
+	if m.ready && m.height > 0 {
+		m.viewport.Height = m.height - chromeHeight - (height - 1)
+	}
+}
+
+// editorCommand returns the user's editor command from $VISUAL or $EDITOR,
+// falling back to vi.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// openEditor opens the composer content in the external editor. The
+// edited text replaces the composer content when the editor exits.
+func (m *Model) openEditor() (tea.Model, tea.Cmd) {
+	f, err := os.CreateTemp("", "dreamteller-*.md")
+	if err != nil {
+		m.err = fmt.Errorf("failed to create temp file: %w", err)
+		return m, nil
+	}
+	path := f.Name()
+	_, err = f.WriteString(m.textarea.Value())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		m.err = fmt.Errorf("failed to write temp file: %w", err)
+		return m, nil
+	}
+
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{path: path, err: err}
+	})
+}
+
+// handleEditorFinished loads the edited text back into the composer.
+func (m *Model) handleEditorFinished(msg editorFinishedMsg) {
+	defer os.Remove(msg.path)
+
+	if msg.err != nil {
+		m.err = fmt.Errorf("editor exited with an error: %w", msg.err)
+		return
+	}
+
+	content, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.err = fmt.Errorf("failed to read edited message: %w", err)
+		return
+	}
+
+	text, _ := storage.DecodeText(content)
+	m.textarea.SetValue(strings.TrimRight(text, "\n"))
+	m.textarea.Focus()
+	m.resizeComposer()
+}
@@ -0,0 +1,480 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// contextViewHint lists the keys available in the context view.
+const contextViewHint = "↑/↓ select • 1-9 follow link • Enter edit • n new • d delete • Esc back"
+
+// contextEditorHint lists the keys available in the context editor.
+const contextEditorHint = "Ctrl+S save • Esc cancel"
+
+// contextTemplates seed a new context file in each category.
+var contextTemplates = map[string]string{
+	"characters": "# Name\n\n## Voice\n\n## Sample Lines\n\n",
+	"settings":   "# Place\n\n",
+	"plot":       "# Plot Point\n\n",
+}
+
+// contextSections titles the categories in the context view.
+var contextSections = map[string]string{
+	"characters": "Characters",
+	"settings":   "Settings",
+	"plot":       "Plot Points",
+}
+
+// contextEntry is a context file listed in the context view.
+type contextEntry struct {
+	category string
+	name     string
+	path     string
+}
+
+// contextEdit is a context file open in the embedded editor.
+type contextEdit struct {
+	category string
+
+	// path is the file being edited, or "" for a new file named after its
+	// title when saved.
+	path     string
+	editor   textarea.Model
+	original string
+
+	// discardPending is set after Esc on unsaved changes; a second Esc
+	// discards them.
+	discardPending bool
+}
+
+// contextEntries lists the character, setting and plot files.
+func (m *Model) contextEntries() []contextEntry {
+	if m.project == nil {
+		return nil
+	}
+
+	var entries []contextEntry
+	characters, _ := m.project.LoadCharacters()
+	for _, c := range characters {
+		entries = append(entries, contextEntry{category: "characters", name: c.Name, path: c.FilePath})
+	}
+	settings, _ := m.project.LoadSettings()
+	for _, s := range settings {
+		entries = append(entries, contextEntry{category: "settings", name: s.Name, path: s.FilePath})
+	}
+	plots, _ := m.project.LoadPlots()
+	for _, p := range plots {
+		entries = append(entries, contextEntry{category: "plot", name: p.Title, path: p.FilePath})
+	}
+	return entries
+}
+
+// selectedContextEntry returns the entry under the cursor.
+func (m *Model) selectedContextEntry() (contextEntry, bool) {
+	entries := m.contextEntries()
+	if m.contextIndex < 0 || m.contextIndex >= len(entries) {
+		return contextEntry{}, false
+	}
+	return entries[m.contextIndex], true
+}
+
+// findContextEntry returns the entry whose title or file name matches name
+// (case-insensitive).
+func (m *Model) findContextEntry(name string) (contextEntry, bool) {
+	for _, e := range m.contextEntries() {
+		base := strings.TrimSuffix(filepath.Base(e.path), ".md")
+		if strings.EqualFold(e.name, name) || strings.EqualFold(base, name) {
+			return e, true
+		}
+	}
+	return contextEntry{}, false
+}
+
+// contextLinks returns the entries the entry links to, then those linking
+// to it, as numbered in the context view.
+func (m *Model) contextLinks(entry contextEntry) (links, backlinks []contextEntry) {
+	graph := linkGraph(m.project)
+	if graph == nil {
+		return nil, nil
+	}
+
+	entries := make(map[string]contextEntry)
+	for _, e := range m.contextEntries() {
+		entries[e.path] = e
+	}
+	outgoing := make(map[string]bool)
+	for _, path := range graph.Links[entry.path] {
+		outgoing[path] = true
+	}
+	for _, path := range graph.Neighbors(entry.path) {
+		e, ok := entries[path]
+		if !ok {
+			continue
+		}
+		if outgoing[path] {
+			links = append(links, e)
+		} else {
+			backlinks = append(backlinks, e)
+		}
+	}
+	return links, backlinks
+}
+
+// followContextLink selects the n-th (1-based) file linked with the
+// selected one.
+func (m *Model) followContextLink(n int) {
+	entry, ok := m.selectedContextEntry()
+	if !ok {
+		return
+	}
+	links, backlinks := m.contextLinks(entry)
+	linked := append(links, backlinks...)
+	if n < 1 || n > len(linked) {
+		m.statusText = fmt.Sprintf("%s has no link %d", entry.name, n)
+		return
+	}
+
+	for i, e := range m.contextEntries() {
+		if e.path == linked[n-1].path {
+			m.contextIndex = i
+			break
+		}
+	}
+	m.updateViewport()
+}
+
+// handleContextCommand handles /context [new <category> | edit <name> |
+// delete <name>].
+func (m *Model) handleContextCommand(args []string) {
+	if len(args) == 0 {
+		m.view = ViewContext
+		m.updateViewport()
+		return
+	}
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	name := strings.Join(args[1:], " ")
+	switch strings.ToLower(args[0]) {
+	case "new":
+		category := strings.ToLower(name)
+		if _, ok := contextTemplates[category]; !ok {
+			m.err = fmt.Errorf("usage: /context new <%s>", strings.Join(project.ContextCategories, "|"))
+			return
+		}
+		m.view = ViewContext
+		m.openContextEditor(category, "", contextTemplates[category])
+	case "edit":
+		entry, ok := m.findContextEntry(name)
+		if !ok {
+			m.err = fmt.Errorf("context file not found: %s", name)
+			return
+		}
+		m.view = ViewContext
+		m.editContextEntry(entry)
+	case "delete":
+		entry, ok := m.findContextEntry(name)
+		if !ok {
+			m.err = fmt.Errorf("context file not found: %s", name)
+			return
+		}
+		m.deleteContextEntry(entry)
+	default:
+		m.err = fmt.Errorf("usage: /context [new <category> | edit <name> | delete <name>]")
+	}
+}
+
+// showBibleReport lists unused, stale and empty context files inline
+// (usage: /report [recent chapters]).
+func (m *Model) showBibleReport(args []string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	recent := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			m.err = fmt.Errorf("usage: /report [recent chapters]")
+			return
+		}
+		recent = n
+	}
+
+	report, err := m.project.BibleReport(recent)
+	if err != nil {
+		m.err = fmt.Errorf("report failed: %w", err)
+		return
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: report.String()})
+	m.updateViewport()
+}
+
+// handleContextKey handles keys in the context view while the composer is
+// empty. It reports whether it used the key.
+func (m *Model) handleContextKey(msg tea.KeyMsg) bool {
+	if m.contextDeletePending {
+		m.contextDeletePending = false
+		if msg.Type == tea.KeyRunes && strings.ToLower(string(msg.Runes)) == "y" {
+			if entry, ok := m.selectedContextEntry(); ok {
+				m.deleteContextEntry(entry)
+			}
+		} else {
+			m.statusText = "Delete cancelled"
+		}
+		return true
+	}
+	if m.streaming || strings.TrimSpace(m.textarea.Value()) != "" {
+		return false
+	}
+
+	key := msg.String()
+	switch {
+	case msg.Type == tea.KeyUp || key == "k":
+		if m.contextIndex > 0 {
+			m.contextIndex--
+			m.updateViewport()
+		}
+	case msg.Type == tea.KeyDown || key == "j":
+		if m.contextIndex < len(m.contextEntries())-1 {
+			m.contextIndex++
+			m.updateViewport()
+		}
+	case msg.Type == tea.KeyEnter || key == "e":
+		if entry, ok := m.selectedContextEntry(); ok {
+			m.editContextEntry(entry)
+		}
+	case len(key) == 1 && key >= "1" && key <= "9":
+		m.followContextLink(int(key[0] - '0'))
+	case key == "n":
+		category := "characters"
+		if entry, ok := m.selectedContextEntry(); ok {
+			category = entry.category
+		}
+		m.openContextEditor(category, "", contextTemplates[category])
+	case key == "d":
+		if entry, ok := m.selectedContextEntry(); ok {
+			m.contextDeletePending = true
+			m.statusText = fmt.Sprintf("Delete %s? Press y to confirm", entry.path)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// editContextEntry opens an existing context file in the editor.
+func (m *Model) editContextEntry(entry contextEntry) {
+	content, err := m.project.FS.ReadMarkdown(entry.path)
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.openContextEditor(entry.category, entry.path, content)
+}
+
+// openContextEditor opens the embedded editor on content. An empty path
+// creates a new file in category when saved.
+func (m *Model) openContextEditor(category, path, content string) {
+	editor := textarea.New()
+	editor.ShowLineNumbers = false
+	editor.CharLimit = 0
+	editor.Prompt = ""
+	editor.FocusedStyle.CursorLine = styles.InputText
+	editor.SetValue(content)
+	editor.Focus()
+
+	m.contextEdit = &contextEdit{category: category, path: path, editor: editor, original: content}
+	m.inputMode = false
+	m.textarea.Blur()
+}
+
+// closeContextEditor returns to the context view.
+func (m *Model) closeContextEditor() {
+	m.contextEdit = nil
+	m.inputMode = true
+	m.textarea.Focus()
+	m.updateViewport()
+}
+
+// handleContextEditorKey handles keyboard input in the context editor.
+func (m *Model) handleContextEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	edit := m.contextEdit
+	switch msg.Type {
+	case tea.KeyCtrlS:
+		return m, m.saveContextEdit()
+
+	case tea.KeyEsc, tea.KeyCtrlC:
+		if edit.editor.Value() != edit.original && !edit.discardPending {
+			edit.discardPending = true
+			m.statusText = "Unsaved changes: Esc again to discard, Ctrl+S to save"
+			return m, nil
+		}
+		m.closeContextEditor()
+		return m, nil
+	}
+
+	edit.discardPending = false
+	var cmd tea.Cmd
+	edit.editor, cmd = edit.editor.Update(msg)
+	return m, cmd
+}
+
+// saveContextEdit validates and writes the edited file, then reindexes it
+// so search and context assembly see the change at once.
+func (m *Model) saveContextEdit() tea.Cmd {
+	edit := m.contextEdit
+	content := strings.TrimRight(edit.editor.Value(), "\n") + "\n"
+	if err := m.project.ValidateContextFile(content); err != nil {
+		m.err = err
+		return nil
+	}
+
+	path := edit.path
+	if path == "" {
+		var err error
+		if path, err = m.project.NewContextFilePath(edit.category, content); err != nil {
+			m.err = err
+			return nil
+		}
+	}
+	if err := m.project.FS.EnsureDir(filepath.Dir(path)); err != nil {
+		m.err = fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		return nil
+	}
+	if err := m.project.FS.WriteMarkdown(path, content); err != nil {
+		m.err = fmt.Errorf("failed to save %s: %w", path, err)
+		return nil
+	}
+
+	m.closeContextEditor()
+	if err := m.reindexContextFile(path); err != nil {
+		m.err = fmt.Errorf("saved, but reindexing failed: %w", err)
+	}
+	return m.showActionToast("Saved " + path)
+}
+
+// deleteContextEntry deletes a context file and drops it from the index.
+func (m *Model) deleteContextEntry(entry contextEntry) {
+	if err := m.project.DeleteContextFile(entry.path); err != nil {
+		m.err = err
+		return
+	}
+	if err := m.reindexContextFile(entry.path); err != nil {
+		m.err = fmt.Errorf("deleted, but reindexing failed: %w", err)
+	}
+	if m.contextIndex > 0 && m.contextIndex >= len(m.contextEntries()) {
+		m.contextIndex--
+	}
+	m.statusText = "Deleted " + entry.path
+	m.updateViewport()
+}
+
+// reindexContextFile updates the search index for one file.
+func (m *Model) reindexContextFile(path string) error {
+	if m.searchEngine == nil || m.project.DB == nil {
+		return nil
+	}
+	return m.project.Indexer().SyncFile(m.project.FS, m.project.DB, path)
+}
+
+// renderContextEditor renders the embedded editor in place of the chat.
+func (m *Model) renderContextEditor() string {
+	edit := m.contextEdit
+	title := "New " + strings.TrimSuffix(contextSections[edit.category], "s")
+	if edit.path != "" {
+		title = "Editing " + edit.path
+	}
+
+	height := m.viewport.Height - 2
+	if height < 3 {
+		height = 3
+	}
+	edit.editor.SetWidth(m.width)
+	edit.editor.SetHeight(height)
+
+	var sb strings.Builder
+	sb.WriteString(styles.Title.Render(title))
+	sb.WriteString("\n")
+	sb.WriteString(edit.editor.View())
+	sb.WriteString("\n")
+	sb.WriteString(styles.HelpDesc.Render(contextEditorHint))
+	return sb.String()
+}
+
+// renderContext renders the context view: the context files by category,
+// with the selected one highlighted and the files it is linked with.
+func (m *Model) renderContext() string {
+	var sb strings.Builder
+	sb.WriteString(styles.Title.Render("Context Files"))
+	sb.WriteString("\n\n")
+
+	if m.project == nil {
+		sb.WriteString(styles.ErrorText.Render("No project loaded"))
+		return sb.String()
+	}
+
+	entries := m.contextEntries()
+	for i, category := range project.ContextCategories {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(styles.Subtitle.Render(contextSections[category] + ":"))
+		sb.WriteString("\n")
+
+		listed := false
+		for j, e := range entries {
+			if e.category != category {
+				continue
+			}
+			listed = true
+			if j == m.contextIndex {
+				sb.WriteString(styles.SelectedItem.Render("> " + e.name))
+			} else {
+				sb.WriteString(styles.ListItem.Render("  - " + e.name))
+			}
+			sb.WriteString("\n")
+		}
+		if !listed {
+			sb.WriteString(styles.MutedText.Render(fmt.Sprintf("  No %s defined\n", strings.ToLower(contextSections[category]))))
+		}
+	}
+
+	if entry, ok := m.selectedContextEntry(); ok {
+		links, backlinks := m.contextLinks(entry)
+		n := 0
+		for _, group := range []struct {
+			label   string
+			entries []contextEntry
+		}{{"Links", links}, {"Linked from", backlinks}} {
+			if len(group.entries) == 0 {
+				continue
+			}
+			names := make([]string, len(group.entries))
+			for i, e := range group.entries {
+				n++
+				names[i] = fmt.Sprintf("[%d] %s", n, e.name)
+			}
+			sb.WriteString("\n")
+			sb.WriteString(styles.Subtitle.Render(group.label+":") + " " + strings.Join(names, "  "))
+		}
+		if n > 0 {
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(styles.MutedText.Render(contextViewHint))
+
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// handleContinuityCommand handles /continuity [snapshot [name] | compare
+// [name]]. Without arguments it lists the snapshots.
+func (m *Model) handleContinuityCommand(args []string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	if len(args) == 0 {
+		m.showContinuitySnapshots()
+		return
+	}
+	if len(args) > 2 {
+		m.err = fmt.Errorf("usage: /continuity [snapshot [name] | compare [name]]")
+		return
+	}
+
+	name := ""
+	if len(args) > 1 {
+		name = args[1]
+	}
+	switch strings.ToLower(args[0]) {
+	case "snapshot":
+		snapshot, err := m.project.SaveContinuitySnapshot(name)
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.statusText = fmt.Sprintf("Continuity snapshot %s saved: %d fact(s), %d timeline event(s)", snapshot.Name, len(snapshot.Facts), len(snapshot.Timeline))
+	case "compare":
+		report, err := m.project.CompareContinuity(name)
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.messages = append(m.messages, Message{Role: "system", Content: report.String()})
+		m.updateViewport()
+	default:
+		m.err = fmt.Errorf("usage: /continuity [snapshot [name] | compare [name]]")
+	}
+}
+
+// showContinuitySnapshots lists the saved continuity snapshots.
+func (m *Model) showContinuitySnapshots() {
+	snapshots, err := m.project.ListContinuitySnapshots()
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	var sb strings.Builder
+	if len(snapshots) == 0 {
+		sb.WriteString("No continuity snapshots yet. Take one with /continuity snapshot [name] before a major rewrite.")
+	} else {
+		sb.WriteString("Continuity snapshots:\n")
+		for _, s := range snapshots {
+			sb.WriteString(fmt.Sprintf("\n%s  %s (%d facts, %d timeline events)", s.CreatedAt.Format("2006-01-02 15:04"), s.Name, len(s.Facts), len(s.Timeline)))
+		}
+		sb.WriteString("\n\nCompare the story against one with /continuity compare [name].")
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
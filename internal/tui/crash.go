@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// crashSessionFile holds the session saved when the TUI crashes, until it
+// is restored or discarded on the next open.
+const crashSessionFile = "crash-session.json"
+
+// crashSession is the state saved when the TUI crashes.
+type crashSession struct {
+	SavedAt     time.Time    `json:"saved_at"`
+	Composer    string       `json:"composer,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Messages    []Message    `json:"messages"`
+}
+
+// Update handles messages. A panic is recorded as a crash and ends the
+// program cleanly so the terminal is restored.
+func (m *Model) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.recordCrash(r)
+			model, cmd = m, tea.Quit
+		}
+	}()
+	return m.update(msg)
+}
+
+// View renders the TUI. A panic is recorded as a crash and passed on to
+// bubbletea, which restores the terminal.
+func (m *Model) View() (view string) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.recordCrash(r)
+			panic(r)
+		}
+	}()
+	return m.render()
+}
+
+// CrashReport returns the path of the crash report written when the
+// session crashed, or "" if it did not.
+func (m *Model) CrashReport() string {
+	return m.crashReport
+}
+
+// crashDir returns where crash files are written: the project's
+// .dreamteller directory, or the temp directory without a project.
+func (m *Model) crashDir() string {
+	if m.project == nil {
+		return os.TempDir()
+	}
+	return filepath.Join(m.project.Path(), ".dreamteller")
+}
+
+// recordCrash writes a crash report and saves the session so it can be
+// restored on the next open. Only the first crash is recorded.
+func (m *Model) recordCrash(r any) {
+	if m.crashReport != "" {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("dreamteller crashed at %s\n\n", time.Now().Format(time.RFC3339)))
+	sb.WriteString(fmt.Sprintf("panic: %v\n\n", r))
+	sb.WriteString(fmt.Sprintf("model: %s (%s)\nmessages: %d\nstreaming: %t\n\n", m.modelName, m.providerName, len(m.messages), m.streaming))
+	sb.Write(debug.Stack())
+
+	path := filepath.Join(m.crashDir(), fmt.Sprintf("crash-%s.log", time.Now().Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		path = "(could not be written: " + err.Error() + ")"
+	}
+	m.crashReport = path
+
+	_ = m.SaveCrashSession()
+}
+
+// SaveCrashSession saves the conversation and unsent composer text so the
+// next open offers to restore them. It does nothing without a project.
+func (m *Model) SaveCrashSession() error {
+	if m.project == nil {
+		return nil
+	}
+	session := crashSession{
+		SavedAt:     time.Now(),
+		Composer:    m.textarea.Value(),
+		Attachments: m.attachments,
+		Messages:    m.messages,
+	}
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	return os.WriteFile(filepath.Join(m.crashDir(), crashSessionFile), data, 0644)
+}
+
+// loadCrashSession loads the session saved by a crash, or returns nil if
+// there is none.
+func (m *Model) loadCrashSession() (*crashSession, error) {
+	if m.project == nil {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(m.crashDir(), crashSessionFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crashed session: %w", err)
+	}
+
+	var session crashSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to parse crashed session: %w", err)
+	}
+	return &session, nil
+}
+
+// offerCrashRestore tells the user when the last session crashed and
+// reports whether it did.
+func (m *Model) offerCrashRestore() bool {
+	session, err := m.loadCrashSession()
+	if err != nil || session == nil {
+		return false
+	}
+	m.messages = append(m.messages, Message{
+		Role: "system",
+		Content: fmt.Sprintf("The last session ended unexpectedly at %s. Type /restore to bring back its conversation and unsent text, or /restore discard to drop it.",
+			session.SavedAt.Format("2006-01-02 15:04")),
+	})
+	return true
+}
+
+// restoreCrashSession restores or discards the session saved by a crash.
+func (m *Model) restoreCrashSession(discard bool) {
+	session, err := m.loadCrashSession()
+	if err != nil {
+		m.err = err
+		return
+	}
+	if session == nil {
+		m.err = fmt.Errorf("there is no crashed session to restore")
+		return
+	}
+	if err := os.Remove(filepath.Join(m.crashDir(), crashSessionFile)); err != nil {
+		m.err = fmt.Errorf("failed to remove crashed session: %w", err)
+		return
+	}
+
+	if discard {
+		m.statusText = "Discarded the crashed session"
+		return
+	}
+
+	m.messages = append(session.Messages, Message{
+		Role:    "system",
+		Content: fmt.Sprintf("Restored %d messages from the crashed session.", len(session.Messages)),
+	})
+	m.attachments = session.Attachments
+	m.textarea.SetValue(session.Composer)
+	m.resizeComposer()
+	m.updateViewport()
+}
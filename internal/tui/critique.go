@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// critiquePrompt asks the model to review its own draft before revising it.
+const critiquePrompt = "Before this reply is final, critique the draft above. Check it against the request, the writing style, point of view and tense set for this project, the plot outline and the story context. List its concrete problems as short bullet points: continuity errors, departures from the outline, style, point-of-view or tense slips, and weak or repetitive prose. Quote the passages you mean. Do not rewrite the draft."
+
+// critiqueRevisePrompt asks for the final reply once the critique is in.
+const critiqueRevisePrompt = "Revise the draft to address the critique. Fix every valid point, keep what already works, and still follow the original request. Reply with the revised text only."
+
+// toggleSelfCritique handles /critique.
+func (m *Model) toggleSelfCritique() {
+	m.selfCritique = !m.selfCritique
+	if m.selfCritique {
+		m.statusText = "Self-critique on: each reply is critiqued and revised before it is final"
+	} else {
+		m.statusText = "Self-critique off"
+	}
+}
+
+// critiqueDraft asks the model that wrote the reply just finished to
+// critique it. The draft stays visible above the critique and the revision
+// but is no longer sent to the model.
+func (m *Model) critiqueDraft() tea.Cmd {
+	last := &m.messages[len(m.messages)-1]
+	last.Draft = true
+	m.markLastDraft(true)
+
+	m.critiquing = true
+	m.newReply = true
+	m.stream = nil
+	m.streamedContent = false
+	m.statusText = "Critiquing the draft..."
+
+	return tea.Batch(m.spinner.Tick, m.startStreamWithFollowUp([]llm.ChatMessage{
+		llm.NewAssistantMessage(last.Content),
+		llm.NewUserMessage(critiquePrompt),
+	}))
+}
+
+// reviseAfterCritique starts the revision once the critique has been
+// written. The critique is folded; selecting it unfolds it. The revision
+// is a refine stage, so it ends the way refining a draft does.
+func (m *Model) reviseAfterCritique() tea.Cmd {
+	critique := &m.messages[len(m.messages)-1]
+	critique.Folded = true
+	draft := m.messages[len(m.messages)-2]
+
+	m.critiquing = false
+	m.refining = true
+	m.newReply = true
+	m.stream = nil
+	m.streamedContent = false
+	m.statusText = "Revising the draft..."
+	m.updateViewport()
+
+	return tea.Batch(m.spinner.Tick, m.startStreamWithFollowUp([]llm.ChatMessage{
+		llm.NewAssistantMessage(draft.Content),
+		llm.NewUserMessage(critiquePrompt),
+		llm.NewAssistantMessage(critique.Content),
+		llm.NewUserMessage(critiqueRevisePrompt + m.avoidWordsInstruction()),
+	}))
+}
+
+// abandonCritique keeps the draft as the reply when the critique stage is
+// interrupted or writes nothing.
+func (m *Model) abandonCritique() {
+	m.critiquing = false
+	m.restoreDraftReply()
+}
+
+// restoreDraftReply makes the draft marked for a refine or critique stage
+// the reply again, skipping any critique written after it.
+func (m *Model) restoreDraftReply() {
+	i := len(m.messages) - 1
+	if i > 0 && m.messages[i].Critique {
+		i--
+	}
+	if i < 0 {
+		return
+	}
+	if draft := &m.messages[i]; draft.Role == "assistant" && draft.Draft {
+		draft.Draft = false
+		m.markLastDraft(false)
+	}
+}
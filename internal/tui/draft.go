@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// draftFile holds the unsent composer text between sessions.
+	draftFile = "draft.md"
+
+	// draftAutosaveInterval is how often the composer text is saved.
+	draftAutosaveInterval = 5 * time.Second
+)
+
+// draftTickMsg triggers a draft autosave.
+type draftTickMsg struct{}
+
+// draftTick schedules the next draft autosave.
+func draftTick() tea.Cmd {
+	return tea.Tick(draftAutosaveInterval, func(time.Time) tea.Msg {
+		return draftTickMsg{}
+	})
+}
+
+// draftPath returns the draft file path, or "" without a project.
+func (m *Model) draftPath() string {
+	if m.project == nil {
+		return ""
+	}
+	return filepath.Join(m.project.Path(), ".dreamteller", draftFile)
+}
+
+// saveDraft persists the composer text when it changed since the last
+// save. An empty composer removes the draft.
+func (m *Model) saveDraft() {
+	path := m.draftPath()
+	value := m.textarea.Value()
+	if path == "" || value == m.savedDraft {
+		return
+	}
+
+	var err error
+	if strings.TrimSpace(value) == "" {
+		err = os.Remove(path)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	} else {
+		err = os.WriteFile(path, []byte(value), 0644)
+	}
+	if err != nil {
+		m.err = fmt.Errorf("failed to save draft: %w", err)
+		return
+	}
+	m.savedDraft = value
+}
+
+// loadDraft reads the draft saved by the last session.
+func (m *Model) loadDraft() string {
+	path := m.draftPath()
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// restoreDraft loads the draft saved by the last session into the composer
+// and returns a command showing a notice, or nil if there is none.
+func (m *Model) restoreDraft() tea.Cmd {
+	m.savedDraft = m.loadDraft()
+	if strings.TrimSpace(m.savedDraft) == "" {
+		return nil
+	}
+
+	m.textarea.SetValue(m.savedDraft)
+	m.resizeComposer()
+
+	toast, cmd := showToast("Restored your unsent draft", ToastInfo, 4*time.Second)
+	m.toast = toast
+	return cmd
+}
+
+// quit saves the draft, logs a running sprint and exits.
+func (m *Model) quit() tea.Cmd {
+	m.saveDraft()
+	if m.sprint != nil {
+		m.finishSprint()
+	}
+	m.recordDailyWords()
+	m.endSession()
+	return tea.Quit
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/internal/token"
+)
+
+// ParseContextMode returns the context mode named name: essential, hybrid
+// or full, in any case.
+func ParseContextMode(name string) (ContextMode, error) {
+	for _, mode := range []ContextMode{ContextEssential, ContextHybrid, ContextFull} {
+		if strings.EqualFold(name, mode.String()) {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown context mode %q (want essential, hybrid or full)", name)
+}
+
+// DryRun is the request the chat would send for a message, assembled
+// without calling the provider.
+type DryRun struct {
+	Request llm.ChatRequest
+
+	// SystemPrompt is the system prompt, including any text tool catalog.
+	SystemPrompt string
+
+	// Retrieval lists the chunks injected as retrieval context and why.
+	Retrieval []RankedChunk
+
+	// Budget is the token budget the request was assembled against.
+	Budget token.BudgetAllocation
+
+	// Tokens counts the tokens of each message in Request.
+	Tokens []int
+
+	// SavedMessages is how many saved messages were loaded before the
+	// history was truncated to fit the request.
+	SavedMessages int
+}
+
+// AssembleDryRun assembles the request the chat would send for message,
+// after the project's saved conversation, the way opening the project and
+// sending it in mode would. Only the provider's capabilities are used.
+func AssembleDryRun(proj *project.Project, provider llm.Provider, modelName string, mode ContextMode, searchEngine *search.FTSEngine, message string) (*DryRun, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("message is empty")
+	}
+
+	history, err := savedHistory(proj, storage.MainThread, provider, modelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation history: %w", err)
+	}
+	messages := append(history, Message{Role: llm.RoleUser, Content: message})
+
+	assembled, err := assembleChatRequest(proj, provider, modelName, mode, searchEngine, messages)
+	if err != nil {
+		return nil, err
+	}
+	env, err := newAssemblyEnv(proj, provider, modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]int, len(assembled.Request.Messages))
+	for i, msg := range assembled.Request.Messages {
+		tokens[i] = env.tokenizer.Count(msg.Content)
+	}
+
+	return &DryRun{
+		Request:       assembled.Request,
+		SystemPrompt:  assembled.SystemPrompt,
+		Retrieval:     assembled.Retrieval,
+		Budget:        assembled.Budget,
+		Tokens:        tokens,
+		SavedMessages: len(history),
+	}, nil
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+)
+
+// formatExpandedChunks renders a search hit with the chunks around it, each
+// under its citation tag so the passages can be cited.
+func formatExpandedChunks(id int64, chunks []search.FTSSearchResult) string {
+	if len(chunks) == 0 {
+		return fmt.Sprintf("No indexed passage has the tag %s; it may have changed since the search. Search again.", llm.CitationTag(id))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s in %s (%s) with the passages around it:", llm.CitationTag(id), chunks[0].SourcePath, chunks[0].SourceType))
+	for _, c := range chunks {
+		sb.WriteString("\n\n" + llm.CitationTag(c.ID))
+		if c.Section != "" {
+			sb.WriteString(" § " + c.Section)
+		}
+		sb.WriteString("\n" + llm.QuoteContext(strings.TrimSpace(c.Content)))
+	}
+	return sb.String()
+}
+
+// handleExpandSearchResult reads a search result with its neighboring
+// chunks. Like a search, the result is answered back to the model.
+func (h *SuggestionHandler) handleExpandSearchResult(call llm.ToolCall, query llm.ExpandQuery) (*SuggestionResult, error) {
+	if h.searchEngine == nil {
+		return nil, fmt.Errorf("search engine not initialized")
+	}
+
+	chunks, err := h.searchEngine.ExpandChunk(query.ID, query.Radius)
+	if err != nil {
+		return nil, fmt.Errorf("expand failed: %w", err)
+	}
+	answer := formatExpandedChunks(query.ID, chunks)
+
+	return &SuggestionResult{
+		Type:             SuggestionTypeSearch,
+		Title:            fmt.Sprintf("Reading around %s", llm.CitationTag(query.ID)),
+		Content:          styles.MutedText.Render(answer),
+		RequiresApproval: false,
+		ToolCallID:       call.ID,
+		ToolCall:         call,
+		ParsedData:       answer,
+	}, nil
+}
+
+// expandSearchResult handles /expand: it shows a result of the last
+// /search, by its number, or a cited chunk, by its tag, with the chunks
+// before and after it in the same file.
+func (m *Model) expandSearchResult(arg string) {
+	if m.searchEngine == nil {
+		m.err = fmt.Errorf("search index not available")
+		return
+	}
+
+	var id int64
+	if ids := llm.ExtractCitations(arg); len(ids) > 0 {
+		id = ids[0]
+	} else if tag, ok := strings.CutPrefix(arg, "c"); ok {
+		id, _ = strconv.ParseInt(tag, 10, 64)
+	} else if n, err := strconv.Atoi(arg); err == nil && n >= 1 && n <= len(m.lastSearch) {
+		id = m.lastSearch[n-1].ID
+	}
+	if id <= 0 {
+		if len(m.lastSearch) == 0 {
+			m.err = fmt.Errorf("usage: /expand <result number> after /search, or /expand c<id> for a cited passage")
+		} else {
+			m.err = fmt.Errorf("usage: /expand <1-%d> or /expand c<id>", len(m.lastSearch))
+		}
+		return
+	}
+
+	chunks, err := m.searchEngine.ExpandChunk(id, search.DefaultExpandRadius)
+	if err != nil {
+		m.err = fmt.Errorf("expand failed: %w", err)
+		return
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: formatExpandedChunks(id, chunks)})
+	m.updateViewport()
+}
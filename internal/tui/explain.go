@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+)
+
+// RankedChunk records why a retrieved chunk was put in a request's context:
+// its search score and what adjusted it, for explain mode.
+type RankedChunk struct {
+	ID         int64
+	SourceType string
+	SourcePath string
+	Section    string
+	Tokens     int
+
+	// BM25 is the full-text score before boosts. Lower is better.
+	BM25 float64
+
+	// LinkDistance is how many [[links]] separate the chunk's file from the
+	// files the request is about, or -1 if it was not boosted for links.
+	LinkDistance int
+
+	// Score is the final score the chunks were ranked by.
+	Score float64
+
+	// Pinned marks research that reached the context because it is pinned.
+	Pinned bool
+
+	// Rank is the chunk's position, from 1, among Candidates ranked chunks.
+	Rank       int
+	Candidates int
+}
+
+// SetExplainRanking turns explain mode on or off: replies then show the
+// chunks retrieved for them and why each was chosen.
+func (m *Model) SetExplainRanking(on bool) {
+	m.explainRanking = on
+}
+
+// toggleExplainRanking handles /explain.
+func (m *Model) toggleExplainRanking() {
+	m.explainRanking = !m.explainRanking
+	m.updateViewport()
+	if m.explainRanking {
+		m.statusText = "Explain mode on: replies show why each context chunk was retrieved"
+	} else {
+		m.statusText = "Explain mode off"
+	}
+}
+
+// renderRankingExplanation renders the chunks retrieved for a reply, in
+// the order they were selected, with the scores behind each.
+func renderRankingExplanation(chunks []RankedChunk) string {
+	if len(chunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(styles.MutedText.Render(fmt.Sprintf("Retrieved context (%d of %d candidates; bm25, lower ranks higher):", len(chunks), chunks[0].Candidates)))
+	for _, c := range chunks {
+		location := c.SourcePath
+		if c.Section != "" {
+			location += " § " + c.Section
+		}
+		sb.WriteString("\n")
+		sb.WriteString(styles.InfoText.Render(fmt.Sprintf("%s %s (%s, %d tokens)", llm.CitationTag(c.ID), location, c.SourceType, c.Tokens)))
+		sb.WriteString("\n")
+		sb.WriteString(styles.MutedText.Render("   " + c.Explain()))
+	}
+	return sb.String()
+}
+
+// Explain describes how the chunk's score was reached.
+func (c RankedChunk) Explain() string {
+	parts := []string{fmt.Sprintf("rank %d", c.Rank), fmt.Sprintf("bm25 %.2f", c.BM25)}
+	if c.LinkDistance >= 0 {
+		var links string
+		switch c.LinkDistance {
+		case 0:
+			links = "the file the request is about"
+		case 1:
+			links = "1 link away"
+		default:
+			links = fmt.Sprintf("%d links away", c.LinkDistance)
+		}
+		parts = append(parts, fmt.Sprintf("×%.2f link boost (%s) = %.2f", linkProximityBoosts[c.LinkDistance], links, c.Score))
+	}
+	if c.Pinned {
+		parts = append(parts, "pinned research")
+	}
+	return strings.Join(parts, " · ")
+}
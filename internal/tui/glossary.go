@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// maxGlossaryTokens caps how much of the system prompt glossary entries may use.
+const maxGlossaryTokens = 300
+
+// mentionedTerms returns the glossary entries whose term appears in text.
+func mentionedTerms(entries []*types.GlossaryEntry, text string) []*types.GlossaryEntry {
+	lower := strings.ToLower(text)
+
+	var mentioned []*types.GlossaryEntry
+	for _, e := range entries {
+		if strings.Contains(lower, strings.ToLower(e.Term)) {
+			mentioned = append(mentioned, e)
+		}
+	}
+	return mentioned
+}
+
+// formatGlossaryEntry renders one entry as a list item.
+func formatGlossaryEntry(e *types.GlossaryEntry) string {
+	if e.Definition == "" {
+		return "- " + e.Term
+	}
+	return fmt.Sprintf("- %s: %s", e.Term, e.Definition)
+}
+
+// buildGlossarySection renders the glossary entries mentioned in userInput
+// for the system prompt, in file order, within maxTokens.
+func buildGlossarySection(proj *project.Project, userInput string, tokenizer llm.TokenCounter, maxTokens int) string {
+	if proj == nil || userInput == "" {
+		return ""
+	}
+
+	entries, err := proj.LoadGlossary()
+	if err != nil {
+		return ""
+	}
+
+	header := "## Glossary (use these exact spellings)"
+	used := tokenizer.Count(header)
+	var lines []string
+	for _, e := range mentionedTerms(entries, userInput) {
+		line := formatGlossaryEntry(e)
+		t := tokenizer.Count(line)
+		if used+t > maxTokens {
+			continue
+		}
+		lines = append(lines, line)
+		used += t
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+// showGlossary lists glossary entries inline.
+func (m *Model) showGlossary() {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	entries, err := m.project.LoadGlossary()
+	if err != nil {
+		m.err = fmt.Errorf("failed to load glossary: %w", err)
+		return
+	}
+
+	var sb strings.Builder
+	if len(entries) == 0 {
+		sb.WriteString("No glossary terms yet. Add \"- Term: definition\" lines to a file in context/glossary/.")
+	} else {
+		sb.WriteString("Glossary:\n")
+		for _, e := range entries {
+			sb.WriteString("\n" + formatGlossaryEntry(e))
+		}
+		sb.WriteString("\n\nUse /glossary check to find misspelled terms in chapters.")
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// checkGlossary reports likely misspellings of glossary terms in chapters.
+func (m *Model) checkGlossary() {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	issues, err := m.project.CheckGlossary()
+	if err != nil {
+		m.err = fmt.Errorf("glossary check failed: %w", err)
+		return
+	}
+
+	var sb strings.Builder
+	if len(issues) == 0 {
+		sb.WriteString("No misspelled glossary terms found in chapters.")
+	} else {
+		sb.WriteString(fmt.Sprintf("Possible misspellings (%d):\n", len(issues)))
+		for _, issue := range issues {
+			sb.WriteString(fmt.Sprintf("\n%s:%d: %q → did you mean %q?", issue.FilePath, issue.Line, issue.Word, issue.Term))
+		}
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// showWordFrequency lists crutch words and repeated phrases in chapters
+// with a heatmap per chapter.
+func (m *Model) showWordFrequency() {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	report, err := m.project.WordFrequency()
+	if err != nil {
+		m.err = fmt.Errorf("word frequency failed: %w", err)
+		return
+	}
+
+	content := report.String()
+	if m.project.Config != nil && !m.project.Config.Writing.AvoidCrutchWords && len(report.Crutch)+len(report.Phrases) > 0 {
+		content += "\n\nSet writing.avoid_crutch_words: true to have /polish and the refine model avoid the top offenders."
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	m.updateViewport()
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/prose"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// checkLastReply runs the POV/tense guard on the last assistant reply and
+// records any deviations on it.
+func (m *Model) checkLastReply() {
+	if m.project == nil || m.project.Config == nil || len(m.messages) == 0 {
+		return
+	}
+	last := &m.messages[len(m.messages)-1]
+	if last.Role != "assistant" {
+		return
+	}
+	last.Issues = prose.CheckNarration(last.Content, m.project.Config.Writing)
+}
+
+// lastReplyIssues returns the guard issues on the last message, if any.
+func (m *Model) lastReplyIssues() []prose.Issue {
+	if len(m.messages) == 0 {
+		return nil
+	}
+	last := m.messages[len(m.messages)-1]
+	if last.Role != "assistant" {
+		return nil
+	}
+	return last.Issues
+}
+
+// fixProse regenerates the last reply with instructions to resolve the
+// flagged POV/tense issues.
+func (m *Model) fixProse() (tea.Model, tea.Cmd) {
+	issues := m.lastReplyIssues()
+	if len(issues) == 0 {
+		m.err = fmt.Errorf("nothing to fix: the last reply has no POV or tense issues")
+		return m, nil
+	}
+	return m.reviseReply(prose.FixInstruction(issues, m.project.Config.Writing), "Rewriting to fix POV/tense...")
+}
+
+// reviseReply replaces the last assistant reply with a rewrite that follows
+// instruction. The original is restored if the rewrite produces nothing.
+func (m *Model) reviseReply(instruction, status string) (tea.Model, tea.Cmd) {
+	if len(m.messages) == 0 || m.messages[len(m.messages)-1].Role != "assistant" {
+		m.err = fmt.Errorf("nothing to revise: the last message is not an AI reply")
+		return m, nil
+	}
+	if m.provider == nil {
+		m.err = fmt.Errorf("no LLM provider configured")
+		return m, nil
+	}
+
+	last := len(m.messages) - 1
+	m.revisedFrom = m.messages[last].Content
+	m.messages = m.messages[:last]
+	m.revising = true
+	m.streaming = true
+	m.inputMode = false
+	m.statusText = status
+	m.updateViewport()
+
+	return m, tea.Batch(m.spinner.Tick, m.startStreamWithFollowUp([]llm.ChatMessage{
+		llm.NewAssistantMessage(m.revisedFrom),
+		llm.NewUserMessage(instruction),
+	}))
+}
+
+// restoreRevisedReply puts the original reply back when a revision ends
+// without producing any text.
+func (m *Model) restoreRevisedReply() {
+	if !m.revising {
+		return
+	}
+	m.revising = false
+	if len(m.messages) > 0 && m.messages[len(m.messages)-1].Role == "assistant" {
+		return
+	}
+	m.messages = append(m.messages, Message{Role: "assistant", Content: m.revisedFrom})
+}
@@ -0,0 +1,271 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// promptHistoryLimit caps how many past prompts are loaded for recall.
+	promptHistoryLimit = 500
+
+	// historyPaletteRows caps the matches listed in the history palette.
+	historyPaletteRows = 15
+
+	// historyPreviewRunes caps the preview of a prompt in the palette.
+	historyPreviewRunes = 100
+)
+
+// historyPaletteHint lists the keys available in the history palette.
+const historyPaletteHint = "Type to search • ↑/↓ Navigate • Enter Use • Esc Cancel"
+
+// loadPromptHistory loads the project's sent prompts, oldest first.
+func (m *Model) loadPromptHistory() {
+	if m.project == nil || m.project.DB == nil {
+		return
+	}
+	prompts, err := m.project.DB.ListPromptHistory(promptHistoryLimit)
+	if err != nil {
+		return
+	}
+	m.promptHistory = make([]string, len(prompts))
+	for i, prompt := range prompts {
+		m.promptHistory[len(prompts)-1-i] = prompt
+	}
+}
+
+// recordPrompt adds a sent prompt or command to the history and ends any
+// recall in progress. A repeated prompt moves to the end.
+func (m *Model) recordPrompt(input string) {
+	m.historyPos = 0
+	m.historyStash = ""
+
+	for i, prompt := range m.promptHistory {
+		if prompt == input {
+			m.promptHistory = append(m.promptHistory[:i], m.promptHistory[i+1:]...)
+			break
+		}
+	}
+	m.promptHistory = append(m.promptHistory, input)
+
+	if m.project != nil && m.project.DB != nil {
+		if err := m.project.DB.AddPromptHistory(input); err != nil {
+			m.err = fmt.Errorf("failed to save prompt history: %w", err)
+		}
+	}
+}
+
+// recallPrompt steps through the history into the composer: delta 1 moves
+// to an older prompt and -1 to a newer one. Stepping past the newest
+// prompt brings back the text that was being composed.
+func (m *Model) recallPrompt(delta int) {
+	pos := m.historyPos + delta
+	if pos < 0 || pos > len(m.promptHistory) {
+		return
+	}
+	if m.historyPos == 0 {
+		m.historyStash = m.textarea.Value()
+	}
+	m.historyPos = pos
+
+	if pos == 0 {
+		m.textarea.SetValue(m.historyStash)
+	} else {
+		m.textarea.SetValue(m.promptHistory[len(m.promptHistory)-pos])
+	}
+	m.resizeComposer()
+}
+
+// handleHistoryKey recalls prompts with Ctrl+P/Ctrl+N, with Up/Down when
+// the composer is empty or already showing a recalled prompt, and opens
+// the history palette with Ctrl+R. It reports whether it used the key.
+func (m *Model) handleHistoryKey(msg tea.KeyMsg) bool {
+	if !m.inputMode || m.streaming || m.view != ViewChat || m.modelSelectMode || m.projectSelectMode {
+		return false
+	}
+
+	switch msg.Type {
+	case tea.KeyCtrlP:
+		m.recallPrompt(1)
+	case tea.KeyCtrlN:
+		m.recallPrompt(-1)
+	case tea.KeyUp:
+		browsing := m.historyPos > 0 || strings.TrimSpace(m.textarea.Value()) == ""
+		if !browsing || m.textarea.Line() > 0 || len(m.promptHistory) == 0 {
+			return false
+		}
+		m.recallPrompt(1)
+	case tea.KeyDown:
+		if m.historyPos == 0 || m.textarea.Line() < m.textarea.LineCount()-1 {
+			return false
+		}
+		m.recallPrompt(-1)
+	case tea.KeyCtrlR:
+		m.openHistoryPalette("")
+	default:
+		return false
+	}
+	return true
+}
+
+// historyMatch is a prompt listed in the history palette.
+type historyMatch struct {
+	prompt string
+	score  int
+}
+
+// fuzzyScore matches query against text as a case-insensitive subsequence.
+// Consecutive matches and matches at the start of a word score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	queryRunes := []rune(strings.ToLower(query))
+	if len(queryRunes) == 0 {
+		return 0, true
+	}
+
+	score, qi, last := 0, 0, -2
+	prev := ' '
+	for i, r := range []rune(strings.ToLower(text)) {
+		if qi < len(queryRunes) && r == queryRunes[qi] {
+			score++
+			if i == last+1 {
+				score += 3
+			}
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+				score += 2
+			}
+			last = i
+			qi++
+		}
+		prev = r
+	}
+	return score, qi == len(queryRunes)
+}
+
+// historyMatches returns the prompts matching the palette query, best
+// match first and newest first among equals.
+func (m *Model) historyMatches() []historyMatch {
+	var matches []historyMatch
+	for i := len(m.promptHistory) - 1; i >= 0; i-- {
+		prompt := m.promptHistory[i]
+		if score, ok := fuzzyScore(m.historyQuery, prompt); ok {
+			matches = append(matches, historyMatch{prompt: prompt, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	if len(matches) > historyPaletteRows {
+		matches = matches[:historyPaletteRows]
+	}
+	return matches
+}
+
+// openHistoryPalette shows the searchable prompt history.
+func (m *Model) openHistoryPalette(query string) {
+	if len(m.promptHistory) == 0 {
+		m.err = fmt.Errorf("no prompts sent yet")
+		return
+	}
+	m.historyPaletteMode = true
+	m.historyQuery = query
+	m.historyPaletteIndex = 0
+	m.inputMode = false
+	m.textarea.Blur()
+	m.updateViewport()
+}
+
+// closeHistoryPalette returns to the composer.
+func (m *Model) closeHistoryPalette() {
+	m.historyPaletteMode = false
+	m.inputMode = true
+	m.textarea.Focus()
+	m.updateViewport()
+}
+
+// handleHistoryPaletteKey handles keyboard input in the history palette.
+func (m *Model) handleHistoryPaletteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.closeHistoryPalette()
+
+	case tea.KeyEnter:
+		if matches := m.historyMatches(); m.historyPaletteIndex < len(matches) {
+			m.historyPos = 0
+			m.textarea.SetValue(matches[m.historyPaletteIndex].prompt)
+			m.resizeComposer()
+		}
+		m.closeHistoryPalette()
+
+	case tea.KeyUp, tea.KeyCtrlP:
+		if m.historyPaletteIndex > 0 {
+			m.historyPaletteIndex--
+			m.updateViewport()
+		}
+
+	case tea.KeyDown, tea.KeyCtrlN:
+		if m.historyPaletteIndex < len(m.historyMatches())-1 {
+			m.historyPaletteIndex++
+			m.updateViewport()
+		}
+
+	case tea.KeyBackspace:
+		if runes := []rune(m.historyQuery); len(runes) > 0 {
+			m.historyQuery = string(runes[:len(runes)-1])
+			m.historyPaletteIndex = 0
+			m.updateViewport()
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.historyQuery += string(msg.Runes)
+		m.historyPaletteIndex = 0
+		m.updateViewport()
+	}
+	return m, nil
+}
+
+// renderHistoryPalette renders the prompt history palette.
+func (m *Model) renderHistoryPalette() string {
+	var sb strings.Builder
+	sb.WriteString(styles.Title.Render("Prompt History"))
+	sb.WriteString("\n\n")
+	sb.WriteString(styles.HelpKey.Render("Search: ") + m.historyQuery + "▏")
+	sb.WriteString("\n\n")
+
+	matches := m.historyMatches()
+	if len(matches) == 0 {
+		sb.WriteString(styles.MutedText.Render("No matching prompts"))
+		sb.WriteString("\n")
+	}
+	for i, match := range matches {
+		prefix := "  "
+		style := styles.MutedText
+		if i == m.historyPaletteIndex {
+			prefix = "> "
+			style = styles.SelectedItem
+		}
+		sb.WriteString(style.Render(prefix + historyPreview(match.prompt)))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(styles.HelpDesc.Render(historyPaletteHint))
+	return sb.String()
+}
+
+// historyPreview renders a prompt on one line for the palette.
+func historyPreview(prompt string) string {
+	lines := strings.Split(strings.TrimSpace(prompt), "\n")
+	preview := lines[0]
+	if runes := []rune(preview); len(runes) > historyPreviewRunes {
+		preview = string(runes[:historyPreviewRunes-3]) + "..."
+	}
+	if len(lines) > 1 {
+		preview += fmt.Sprintf(" (+%d lines)", len(lines)-1)
+	}
+	return preview
+}
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historySummaryTimeout bounds the request that updates the history summary.
+const historySummaryTimeout = 2 * time.Minute
+
+// historySummaryHeading introduces summarized history in a request.
+const historySummaryHeading = "이전 대화 요약:\n"
+
+// historySummaryPrompt asks the model to fold older messages into the
+// running summary.
+const historySummaryPrompt = `You keep a running summary of a conversation between a novelist and their writing assistant, so the assistant remembers it once the early messages are gone. Update the summary with the new messages: keep story facts, names, decisions, requests and open threads, and drop chit-chat. Write it in the conversation's language, as plain prose or short bullets, under 300 words. Reply with the summary only.`
+
+// historySummary is a model-written summary of the start of the chat,
+// standing in for those messages once history outgrows its budget.
+type historySummary struct {
+	// covered is how many messages it summarizes and last the content of
+	// the last of them, to notice when the chat was cleared or edited.
+	covered int
+	last    string
+
+	text string
+}
+
+// historySummarizedMsg carries an updated history summary; a nil summary
+// means history still fits its budget.
+type historySummarizedMsg struct {
+	summary *historySummary
+	err     error
+}
+
+// validFor reports whether the summary still covers the start of messages.
+func (s *historySummary) validFor(messages []Message) bool {
+	return s != nil && s.covered > 0 && s.covered <= len(messages) &&
+		messages[s.covered-1].Content == s.last
+}
+
+// withHistorySummary replaces the messages a summary covers with the
+// summary.
+func withHistorySummary(messages []Message, summary *historySummary) []Message {
+	if !summary.validFor(messages) {
+		return messages
+	}
+	out := make([]Message, 0, len(messages)-summary.covered+1)
+	out = append(out, Message{Role: llm.RoleAssistant, Content: historySummaryHeading + summary.text})
+	return append(out, messages[summary.covered:]...)
+}
+
+// historySummaryEnabled reports whether the project keeps a running
+// history summary.
+func (m *Model) historySummaryEnabled() bool {
+	return m.project != nil && m.project.Config != nil && m.project.Config.Context.HistorySummary
+}
+
+// summarizeHistory updates the running history summary in the background
+// once the chat outgrows the history budget, folding in every message but
+// the most recent ones. It does nothing while an update is running.
+func (m *Model) summarizeHistory() tea.Cmd {
+	if !m.historySummaryEnabled() || m.offline || m.summarizingHistory {
+		return nil
+	}
+	provider, modelName := m.activeProvider()
+	if provider == nil {
+		return nil
+	}
+
+	previous := m.historySummary
+	if !previous.validFor(m.messages) {
+		previous = nil
+	}
+	start := 0
+	if previous != nil {
+		start = previous.covered
+	}
+	end := len(m.messages) - defaultRecentMessagesToKeep
+	if end <= start {
+		return nil
+	}
+
+	proj := m.project
+	messages := withHistorySummary(m.messages, previous)
+	folded := make([]Message, end-start)
+	copy(folded, m.messages[start:end])
+	last := m.messages[end-1].Content
+	m.summarizingHistory = true
+
+	return func() tea.Msg {
+		env, err := newAssemblyEnv(proj, provider, modelName)
+		if err != nil {
+			return historySummarizedMsg{err: err}
+		}
+		history := convertTUIMessagesToLLM(messages)
+		if !needsHistoryCompression(env.tokenizer, history, "", env.budget.History) {
+			return historySummarizedMsg{}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), historySummaryTimeout)
+		defer cancel()
+		resp, err := provider.Chat(ctx, historySummaryRequest(previous, folded))
+		if err != nil {
+			return historySummarizedMsg{err: err}
+		}
+		text := strings.TrimSpace(resp.Message.Content)
+		if text == "" {
+			return historySummarizedMsg{err: fmt.Errorf("the model returned an empty summary")}
+		}
+		return historySummarizedMsg{summary: &historySummary{covered: end, last: last, text: text}}
+	}
+}
+
+// historySummaryRequest builds the request that folds messages into the
+// previous summary.
+func historySummaryRequest(previous *historySummary, messages []Message) llm.ChatRequest {
+	var sb strings.Builder
+	if previous != nil {
+		sb.WriteString("Summary so far:\n")
+		sb.WriteString(previous.text)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("New messages:\n")
+	for _, msg := range convertTUIMessagesToLLM(messages) {
+		if msg.Role == llm.RoleSystem {
+			// Notices the TUI showed, not part of the conversation.
+			continue
+		}
+		fmt.Fprintf(&sb, "\n[%s]\n%s\n", msg.Role, msg.Content)
+	}
+	return llm.ChatRequest{
+		Messages:    []llm.ChatMessage{llm.NewSystemMessage(historySummaryPrompt), llm.NewUserMessage(sb.String())},
+		Temperature: 0.2,
+	}
+}
+
+// handleHistorySummarized keeps an updated summary if the messages it
+// covers are still in the chat.
+func (m *Model) handleHistorySummarized(msg historySummarizedMsg) {
+	m.summarizingHistory = false
+	if msg.err != nil {
+		// Assembly falls back to the short digest; nothing is lost.
+		m.statusText = fmt.Sprintf("History summary not updated: %v", msg.err)
+		return
+	}
+	if msg.summary == nil || !msg.summary.validFor(m.messages) {
+		return
+	}
+	if m.historySummary.validFor(m.messages) && m.historySummary.covered >= msg.summary.covered {
+		return
+	}
+	m.historySummary = msg.summary
+}
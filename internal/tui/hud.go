@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/token"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// composerHistoryBudget returns the history budget a message is checked
+// against when sent, or 0 without a provider. The conservative safety margin
+// is used since the estimate does not know the provider's tokenizer.
+func (m *Model) composerHistoryBudget() int {
+	if m.provider == nil {
+		return 0
+	}
+	maxForBudget := budgetTokens(m.provider.Capabilities(), defaultUnknownTokenizerSafetyMargin)
+	return token.NewBudgetManagerWithConfig(m.modelName, maxForBudget, budgetRatios(m.project)).GetBudget().History
+}
+
+// renderComposerTokens renders the token estimate of the message being
+// typed plus any queued attachments, in a warning color when it alone
+// exceeds the history budget. It is empty while there is nothing to send.
+func (m *Model) renderComposerTokens() string {
+	text := strings.TrimSpace(m.textarea.Value())
+	if strings.HasPrefix(text, "/") || (text == "" && len(m.attachments) == 0) {
+		return ""
+	}
+
+	tokens := token.EstimateTokens(text) + m.attachmentTokens()
+	label := fmt.Sprintf("~%d tokens", tokens)
+	if len(m.attachments) > 0 {
+		label = fmt.Sprintf("📎 %d · %s", len(m.attachments), label)
+	}
+	if budget := m.composerHistoryBudget(); budget > 0 && tokens > budget {
+		return styles.TokenWarning.Render(fmt.Sprintf("%s (over the %d history budget)", label, budget))
+	}
+	return styles.TokenCounter.Render(label)
+}
+
+// renderComposerRule renders the rule under the composer with the token
+// estimate right-aligned in it.
+func (m *Model) renderComposerRule() string {
+	hud := m.renderComposerTokens()
+	if hud == "" {
+		return styles.MutedText.Render(strings.Repeat("─", m.width))
+	}
+
+	rule := m.width - lipgloss.Width(hud) - 1
+	if rule < 0 {
+		rule = 0
+	}
+	return styles.MutedText.Render(strings.Repeat("─", rule)) + " " + hud
+}
@@ -0,0 +1,193 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/storage"
+)
+
+// insightsDays is how many days /insights covers by default.
+const insightsDays = 30
+
+// insightsTop caps the tools and commands listed in the report.
+const insightsTop = 5
+
+// insightsPrivacyNote says where insights are kept.
+const insightsPrivacyNote = "Insights are kept only in this project's local database; nothing is sent anywhere."
+
+// Usage kinds counted for insights.
+const (
+	usageTool       = "tool"
+	usageCommand    = "command"
+	usageSuggestion = "suggestion"
+)
+
+// insightsOn reports whether usage is being recorded for insights.
+func (m *Model) insightsOn() bool {
+	return m.project != nil && m.project.Config != nil && m.project.Config.Insights && m.project.DB != nil
+}
+
+// startSession records the start of the session when insights are on.
+func (m *Model) startSession() {
+	if !m.insightsOn() || m.session != 0 {
+		return
+	}
+	words, err := m.project.WordCount()
+	if err != nil {
+		return
+	}
+	if id, err := m.project.DB.StartSession(time.Now(), words); err == nil {
+		m.session = id
+	}
+}
+
+// endSession records the end of the session started by startSession.
+func (m *Model) endSession() {
+	if m.session == 0 || m.project == nil || m.project.DB == nil {
+		return
+	}
+	if words, err := m.project.WordCount(); err == nil {
+		_ = m.project.DB.EndSession(m.session, time.Now(), words)
+	}
+	m.session = 0
+}
+
+// recordUsage counts a use of a tool, command or suggestion outcome when
+// insights are on. Only the name is recorded, never what was written.
+func (m *Model) recordUsage(kind, name string) {
+	if m.insightsOn() {
+		_ = m.project.DB.RecordUsage(kind, name, time.Now())
+	}
+}
+
+// handleInsightsCommand handles /insights [on|off|clear|<days>].
+func (m *Model) handleInsightsCommand(args []string) {
+	if m.project == nil || m.project.Config == nil || m.project.DB == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	arg := ""
+	if len(args) > 0 {
+		arg = strings.ToLower(args[0])
+	}
+	switch arg {
+	case "on", "off":
+		on := arg == "on"
+		if err := m.project.SetInsights(on); err != nil {
+			m.err = fmt.Errorf("failed to save config: %w", err)
+			return
+		}
+		if on {
+			m.startSession()
+			m.statusText = "Insights on: usage is recorded in this project's local database only"
+		} else {
+			m.endSession()
+			m.statusText = "Insights off: usage is no longer recorded"
+		}
+		return
+	case "clear":
+		if err := m.project.DB.ClearUsage(); err != nil {
+			m.err = fmt.Errorf("failed to clear insights: %w", err)
+			return
+		}
+		m.session = 0
+		m.startSession()
+		m.statusText = "Recorded usage cleared"
+		return
+	}
+
+	days := insightsDays
+	if arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			m.err = fmt.Errorf("usage: /insights [on|off|clear|<days>]")
+			return
+		}
+		days = n
+	}
+	m.showInsights(days)
+}
+
+// showInsights reports the usage recorded in the last days days.
+func (m *Model) showInsights(days int) {
+	var content string
+	if !m.project.Config.Insights {
+		content = "Insights are off. /insights on records sessions, words written, accepted and rejected suggestions, and which tools and commands are used, so /insights can summarize them. " + insightsPrivacyNote
+	} else {
+		since := time.Now().AddDate(0, 0, -days)
+		sessions, err := m.project.DB.ListSessions(since)
+		if err != nil {
+			m.err = fmt.Errorf("failed to load sessions: %w", err)
+			return
+		}
+		counts, err := m.project.DB.CountUsage(since)
+		if err != nil {
+			m.err = fmt.Errorf("failed to load usage: %w", err)
+			return
+		}
+		content = renderInsights(days, sessions, counts)
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	m.updateViewport()
+}
+
+// renderInsights summarizes recorded sessions and usage.
+func renderInsights(days int, sessions []storage.SessionRecord, counts []storage.UsageCount) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Insights for the last %d days. %s\n", days, insightsPrivacyNote))
+
+	var ended, words int
+	var length time.Duration
+	for _, s := range sessions {
+		if s.EndedAt == nil || s.WordsAfter == nil {
+			continue
+		}
+		ended++
+		words += *s.WordsAfter - s.WordsBefore
+		length += s.EndedAt.Sub(s.StartedAt)
+	}
+	sb.WriteString(fmt.Sprintf("\nSessions: %d", len(sessions)))
+	if ended > 0 {
+		sb.WriteString(fmt.Sprintf(", %s long on average", (length / time.Duration(ended)).Round(time.Minute)))
+		sb.WriteString(fmt.Sprintf("\nWords per session: %d on average, %d in all", words/ended, words))
+	}
+
+	var accepted, rejected int
+	var tools, commands []string
+	for _, c := range counts {
+		switch c.Kind {
+		case usageSuggestion:
+			if c.Name == "accepted" {
+				accepted = c.Count
+			} else {
+				rejected = c.Count
+			}
+		case usageTool:
+			if len(tools) < insightsTop {
+				tools = append(tools, fmt.Sprintf("%s ×%d", c.Name, c.Count))
+			}
+		case usageCommand:
+			if len(commands) < insightsTop {
+				commands = append(commands, fmt.Sprintf("%s ×%d", c.Name, c.Count))
+			}
+		}
+	}
+	if total := accepted + rejected; total > 0 {
+		sb.WriteString(fmt.Sprintf("\nSuggestions: %d accepted, %d rejected (%d%% accepted)", accepted, rejected, accepted*100/total))
+	} else {
+		sb.WriteString("\nSuggestions: none yet")
+	}
+	if len(tools) > 0 {
+		sb.WriteString("\nMost-used tools: " + strings.Join(tools, ", "))
+	}
+	if len(commands) > 0 {
+		sb.WriteString("\nMost-used commands: " + strings.Join(commands, ", "))
+	}
+
+	sb.WriteString("\n\n/insights <days> covers another period, /insights clear deletes what was recorded and /insights off stops recording.")
+	return sb.String()
+}
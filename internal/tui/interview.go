@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// Recent scenes given to an interviewed character: the last chapters they
+// appear in, each cut to its closing runes.
+const (
+	interviewScenes     = 3
+	interviewSceneRunes = 1500
+)
+
+// interviewPrompt sets up the interview; the character's sheet and recent
+// scenes follow it.
+const interviewPrompt = `You are %s, a character in a novel, being interviewed by the author to discover your voice and backstory. Stay in character: answer in the first person, in your own voice and in the language of the questions, as you are now in the story. Draw on your character sheet and the scenes below, and where they are silent, invent details that fit them. Do not step out of character, narrate, or mention being an AI or a character.`
+
+// interview is a conversation with one character, kept apart from the main
+// thread.
+type interview struct {
+	character string
+
+	// prompt replaces the system prompt while the interview lasts.
+	prompt string
+
+	// main is the main thread, restored when the interview ends.
+	main []Message
+}
+
+// handleInterviewCommand handles /interview [<character> | save | end].
+func (m *Model) handleInterviewCommand(arg string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	switch strings.ToLower(arg) {
+	case "":
+		if m.interview == nil {
+			m.err = fmt.Errorf("usage: /interview <character>")
+			return
+		}
+		m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf(
+			"Interviewing %s. /interview save keeps the transcript as a note, /interview end returns to the main thread.", m.interview.character)})
+		m.updateViewport()
+	case "save":
+		m.saveInterview()
+	case "end", "exit", "leave":
+		m.endInterview()
+	default:
+		m.startInterview(arg)
+	}
+}
+
+// startInterview sets the main thread aside and starts interviewing a
+// character.
+func (m *Model) startInterview(name string) {
+	switch {
+	case m.streaming:
+		m.err = fmt.Errorf("wait for the reply to finish before starting an interview")
+		return
+	case m.interview != nil:
+		m.err = fmt.Errorf("already interviewing %s; /interview end first", m.interview.character)
+		return
+	case m.sandbox != nil:
+		m.err = fmt.Errorf("end the sandbox before starting an interview")
+		return
+	}
+
+	character, err := m.project.FindCharacter(name)
+	if err != nil {
+		m.err = err
+		return
+	}
+	scenes, err := m.recentScenes(character)
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	m.interview = &interview{
+		character: character.Name,
+		prompt:    buildInterviewPrompt(character, scenes),
+		main:      m.messages,
+	}
+	m.messages = []Message{{Role: "system", Content: fmt.Sprintf("Interviewing %s (%d recent scene(s)). Ask them anything: they answer in their own voice. Nothing is saved to the conversation history.\n\n"+
+		"Keep the transcript as a note with /interview save, and return to the main thread with /interview end.", character.Name, len(scenes))}}
+	m.statusText = "Interviewing " + character.Name
+	m.updateViewport()
+}
+
+// recentScenes returns the last chapters mentioning or linking to a
+// character, oldest first.
+func (m *Model) recentScenes(c *types.Character) ([]*types.Chapter, error) {
+	graph, err := m.project.LinkGraph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+	chapters, err := m.project.LoadChapters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+
+	var scenes []*types.Chapter
+	for _, ch := range chapters {
+		for _, path := range append(graph.Mentioned(ch.Content), graph.Links[ch.FilePath]...) {
+			if path == c.FilePath {
+				scenes = append(scenes, ch)
+				break
+			}
+		}
+	}
+	if len(scenes) > interviewScenes {
+		scenes = scenes[len(scenes)-interviewScenes:]
+	}
+	return scenes, nil
+}
+
+// buildInterviewPrompt renders the system prompt of an interview.
+func buildInterviewPrompt(c *types.Character, scenes []*types.Chapter) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(interviewPrompt, c.Name))
+	sb.WriteString("\n\n## Character Sheet\n\n")
+	sb.WriteString(strings.TrimSpace(c.Description))
+	if c.HasVoice() {
+		sb.WriteString("\n\n## Voice\n\n")
+		sb.WriteString(formatVoiceProfile(c))
+	}
+	if len(scenes) > 0 {
+		sb.WriteString("\n\n## Recent Scenes")
+		for _, ch := range scenes {
+			content := []rune(strings.TrimSpace(ch.Content))
+			if len(content) > interviewSceneRunes {
+				content = append([]rune("…"), content[len(content)-interviewSceneRunes:]...)
+			}
+			sb.WriteString(fmt.Sprintf("\n\n### Chapter %d\n\n%s", ch.Number, string(content)))
+		}
+	}
+	return sb.String()
+}
+
+// interviewRequest puts the character in place of the writing assistant:
+// the interview prompt replaces the system prompt and no tools are offered.
+func interviewRequest(req *llm.ChatRequest, prompt string) {
+	if len(req.Messages) > 0 && req.Messages[0].Role == llm.RoleSystem {
+		req.Messages[0].Content = prompt
+	} else {
+		req.Messages = append([]llm.ChatMessage{llm.NewSystemMessage(prompt)}, req.Messages...)
+	}
+	req.Tools = nil
+}
+
+// interviewTranscript renders the questions and answers so far.
+func (m *Model) interviewTranscript() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Interview with %s\n\n%s\n", m.interview.character, time.Now().Format("2006-01-02")))
+	for _, msg := range m.messages {
+		switch msg.Role {
+		case "user":
+			sb.WriteString("\n**Q:** " + strings.TrimSpace(msg.Content) + "\n")
+		case "assistant":
+			sb.WriteString(fmt.Sprintf("\n**%s:** %s\n", m.interview.character, strings.TrimSpace(msg.Content)))
+		}
+	}
+	return sb.String()
+}
+
+// saveInterview saves the transcript as a new file under context/notes.
+func (m *Model) saveInterview() {
+	if m.interview == nil {
+		m.err = fmt.Errorf("not in an interview; start one with /interview <character>")
+		return
+	}
+	answered := false
+	for _, msg := range m.messages {
+		if msg.Role == "assistant" {
+			answered = true
+			break
+		}
+	}
+	if !answered {
+		m.err = fmt.Errorf("nothing to save yet; ask %s something first", m.interview.character)
+		return
+	}
+
+	filename := "interview-" + time.Now().Format("20060102-150405")
+	if err := m.project.WriteContextContent("notes", filename, m.interviewTranscript(), "create"); err != nil {
+		m.err = fmt.Errorf("failed to save transcript: %w", err)
+		return
+	}
+	m.statusText = fmt.Sprintf("Transcript saved as context/notes/%s.md", filename)
+}
+
+// endInterview discards the interview and restores the main thread.
+func (m *Model) endInterview() {
+	if m.interview == nil {
+		m.err = fmt.Errorf("not in an interview")
+		return
+	}
+	if m.streaming {
+		m.err = fmt.Errorf("wait for the reply to finish or press Esc before ending the interview")
+		return
+	}
+
+	name := m.interview.character
+	m.messages = m.interview.main
+	m.interview = nil
+	m.statusText = "Interview with " + name + " ended"
+	m.updateViewport()
+}
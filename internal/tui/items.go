@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+)
+
+// SuggestionTypeItem is a change to an object's whereabouts the model
+// proposed, which always needs approval.
+const SuggestionTypeItem SuggestionType = "item"
+
+// handleMoveItem shows where an object is now and where the model says it
+// is as of a chapter.
+func (h *SuggestionHandler) handleMoveItem(call llm.ToolCall, move llm.ItemMove) (*SuggestionResult, error) {
+	if h.project == nil {
+		return nil, fmt.Errorf("no project loaded")
+	}
+	if strings.TrimSpace(move.Item) == "" {
+		return nil, fmt.Errorf("the item move names no item")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(styles.Subtitle.Render(move.Item))
+	sb.WriteString("\n")
+	if item, err := h.project.FindItem(move.Item); err == nil {
+		sb.WriteString(styles.MutedText.Render(filepath.ToSlash(item.FilePath)))
+		sb.WriteString("\n")
+		if before, ok := item.At(move.Chapter); ok {
+			sb.WriteString(formatContentPreview(fmt.Sprintf("Chapter %d: %s", before.Chapter, before.Describe()), "-"))
+		}
+	} else {
+		sb.WriteString(styles.MutedText.Render("New item"))
+		sb.WriteString("\n")
+	}
+	after := itemSighting(move)
+	sb.WriteString(formatContentPreview(fmt.Sprintf("Chapter %d: %s", after.Chapter, after.Describe()), "+"))
+	if move.Note != "" {
+		sb.WriteString("\n")
+		sb.WriteString(styles.MutedText.Render(fmt.Sprintf("Note: %s", move.Note)))
+		sb.WriteString("\n")
+	}
+
+	return &SuggestionResult{
+		Type:             SuggestionTypeItem,
+		Title:            fmt.Sprintf("Update %s?", move.Item),
+		Content:          sb.String(),
+		RequiresApproval: true,
+		ToolCallID:       call.ID,
+		ToolCall:         call,
+		ParsedData:       move,
+	}, nil
+}
+
+func itemSighting(move llm.ItemMove) project.ItemSighting {
+	return project.ItemSighting{Chapter: move.Chapter, Holder: move.Holder, Place: move.Place, Status: move.Status, Note: move.Note}
+}
+
+// applyItemMove records an approved item move and reports any place the
+// object now turns up that contradicts it.
+func (m *Model) applyItemMove(move llm.ItemMove) error {
+	if m.project == nil {
+		return fmt.Errorf("no project loaded")
+	}
+	path, err := m.project.MoveItem(move.Item, itemSighting(move))
+	if err != nil {
+		return err
+	}
+	if err := m.reindexContextFile(path); err != nil {
+		m.statusText = fmt.Sprintf("Saved, but reindexing %s failed: %v", filepath.ToSlash(path), err)
+	}
+
+	content := fmt.Sprintf("%s, chapter %d: %s.", move.Item, move.Chapter, itemSighting(move).Describe())
+	if report, err := m.project.ItemReport(); err == nil {
+		for _, issue := range report.Issues {
+			if strings.EqualFold(issue.Item, move.Item) {
+				content += "\n- " + issue.String()
+			}
+		}
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	return nil
+}
+
+// handleItemsCommand handles /items, which lists objects and their
+// whereabouts with the issues found, and /items move <item> <chapter>
+// <holder>, which records who holds an object.
+func (m *Model) handleItemsCommand(args []string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	if len(args) > 0 && strings.EqualFold(args[0], "move") {
+		if len(args) < 4 {
+			m.err = fmt.Errorf("usage: /items move <item> <chapter> <holder>")
+			return
+		}
+		chapter, err := strconv.Atoi(args[2])
+		if err != nil || chapter <= 0 {
+			m.err = fmt.Errorf("invalid chapter: %s", args[2])
+			return
+		}
+		if err := m.applyItemMove(llm.ItemMove{Item: args[1], Chapter: chapter, Holder: strings.Join(args[3:], " ")}); err != nil {
+			m.err = err
+			return
+		}
+		m.updateViewport()
+		return
+	}
+
+	report, err := m.project.ItemReport()
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: report.String()})
+	m.updateViewport()
+}
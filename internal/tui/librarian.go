@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// personaLibrarian is the persona saved for a tab answered by the research
+// librarian; tabs without one get the writing assistant.
+const personaLibrarian = "librarian"
+
+// librarianTemperature keeps the librarian's answers close to the sources.
+const librarianTemperature = 0.2
+
+// librarianPrompt takes the place of the writing assistant's role while a
+// tab's persona is the librarian.
+const librarianPrompt = `You are the research librarian of the author's manuscript. You answer factual questions about the story as written, such as when a character learned something, where a scene takes place, or what was said, and nothing else.
+
+- Search the manuscript with the search tools before answering, and read around a result when you need more of it.
+- Base every statement on a source and cite it by its tag, such as [c12], right after the statement.
+- If the sources do not settle the question, say so and say what you found instead; never guess or invent.
+- Never write, continue, rewrite or suggest prose, dialogue or plot, even when asked. Answer briefly, in the language of the question, and tell the author that /librarian off brings the writing assistant back.`
+
+// handleLibrarianCommand handles /librarian [on|off], which sets the
+// persona of the tab shown.
+func (m *Model) handleLibrarianCommand(args []string) {
+	on := !m.librarian
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "on":
+			on = true
+		case "off":
+			on = false
+		default:
+			m.err = fmt.Errorf("usage: /librarian [on|off]")
+			return
+		}
+	}
+	if m.streaming {
+		m.err = fmt.Errorf("wait for the reply to finish before switching personas")
+		return
+	}
+	if m.interview != nil {
+		m.err = fmt.Errorf("end the interview before switching personas")
+		return
+	}
+	if on == m.librarian {
+		m.statusText = fmt.Sprintf("Tab %s already uses the %s", m.thread, personaName(on))
+		return
+	}
+
+	m.librarian = on
+	m.tabs[m.currentTab()].librarian = on
+	if m.project != nil && m.project.DB != nil {
+		persona := ""
+		if on {
+			persona = personaLibrarian
+		}
+		if err := m.project.DB.SetThreadPersona(m.thread, persona); err != nil {
+			m.err = fmt.Errorf("failed to save the tab's persona: %w", err)
+		}
+	}
+
+	note := "The writing assistant answers in this tab again."
+	if on {
+		note = "The research librarian answers in this tab: it searches the manuscript and answers with cited sources, without writing prose. /librarian off brings the writing assistant back."
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: note})
+	m.statusText = fmt.Sprintf("Tab %s: %s", m.thread, personaName(on))
+	m.updateViewport()
+}
+
+// personaName names the persona for messages.
+func personaName(librarian bool) string {
+	if librarian {
+		return "research librarian"
+	}
+	return "writing assistant"
+}
+
+// librarianRequest turns a chat request into a question for the librarian:
+// its role replaces the writing assistant's and only the search tools are
+// offered. The story context and retrieved sources stay.
+func librarianRequest(req *llm.ChatRequest) {
+	if len(req.Messages) > 0 && req.Messages[0].Role == llm.RoleSystem {
+		system := &req.Messages[0].Content
+		if role := llm.DefaultNovelWritingPrompt(); strings.Contains(*system, role) {
+			*system = strings.Replace(*system, role, librarianPrompt, 1)
+		} else {
+			*system = librarianPrompt + "\n\n" + *system
+		}
+	} else {
+		req.Messages = append([]llm.ChatMessage{llm.NewSystemMessage(librarianPrompt)}, req.Messages...)
+	}
+
+	var tools []llm.ToolDefinition
+	for _, tool := range req.Tools {
+		if llm.SearchesProject(tool.Function.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	req.Tools = tools
+	req.Temperature = librarianTemperature
+}
+
+// refuseLibrarianTool ends a librarian turn whose tool call does more
+// than search the manuscript.
+func (m *Model) refuseLibrarianTool(call llm.ToolCall) (tea.Model, tea.Cmd) {
+	m.streaming = false
+	m.inputMode = true
+	m.textarea.Focus()
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf(
+		"The research librarian only searches the manuscript: ignored the model's %s request.", call.Function.Name)})
+	m.updateViewport()
+	return m, nil
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+)
+
+// maxMemoryTokens caps how much of the system prompt remembered facts may use.
+// Newer facts win when the budget is exceeded.
+const maxMemoryTokens = 400
+
+// rememberFact saves a user-provided fact to project memory.
+func (m *Model) rememberFact(fact string) {
+	if err := m.suggestionHandler.SaveMemory(fact, "user"); err != nil {
+		m.err = fmt.Errorf("failed to remember: %w", err)
+		return
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf("Remembered: %s", fact)})
+	m.updateViewport()
+}
+
+// showMemories lists remembered facts inline.
+func (m *Model) showMemories() {
+	if m.project == nil || m.project.DB == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	memories, err := m.project.DB.ListMemories()
+	if err != nil {
+		m.err = fmt.Errorf("failed to load memories: %w", err)
+		return
+	}
+
+	var sb strings.Builder
+	if len(memories) == 0 {
+		sb.WriteString("No memories yet. Use /remember <fact> to add one.")
+	} else {
+		sb.WriteString("Memories:\n")
+		for _, mem := range memories {
+			sb.WriteString(fmt.Sprintf("\n[%d] %s (%s)", mem.ID, mem.Content, mem.Source))
+		}
+		sb.WriteString("\n\nUse /memories delete <id> to remove one.")
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// deleteMemory removes a remembered fact by ID.
+func (m *Model) deleteMemory(arg string) {
+	if m.project == nil || m.project.DB == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		m.err = fmt.Errorf("usage: /memories delete <id>")
+		return
+	}
+	if err := m.project.DB.DeleteMemory(id); err != nil {
+		m.err = fmt.Errorf("failed to delete memory: %w", err)
+		return
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf("Forgot memory %d.", id)})
+	m.updateViewport()
+}
+
+// buildMemorySection renders remembered facts for the system prompt, keeping
+// the newest facts that fit in maxTokens.
+func buildMemorySection(proj *project.Project, tokenizer llm.TokenCounter, maxTokens int) string {
+	if proj == nil || proj.DB == nil {
+		return ""
+	}
+
+	memories, err := proj.DB.ListMemories()
+	if err != nil || len(memories) == 0 {
+		return ""
+	}
+
+	header := "## Remembered Facts (always true)"
+	used := tokenizer.Count(header)
+	var lines []string
+	for i := len(memories) - 1; i >= 0; i-- {
+		line := "- " + memories[i].Content
+		t := tokenizer.Count(line)
+		if used+t > maxTokens {
+			break
+		}
+		lines = append([]string{line}, lines...)
+		used += t
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return header + "\n" + strings.Join(lines, "\n")
+}
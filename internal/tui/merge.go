@@ -0,0 +1,329 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SuggestionTypeMerge is a context update whose files changed on disk
+// after it was suggested, shown as a three-way merge.
+const SuggestionTypeMerge SuggestionType = "merge"
+
+// Conflict markers around the lines both sides changed.
+const (
+	conflictDisk      = "<<<<<<< on disk"
+	conflictSeparator = "======="
+	conflictSuggested = ">>>>>>> suggested"
+)
+
+// contextConflict is a context file that changed on disk while an update
+// to it was pending.
+type contextConflict struct {
+	path               string
+	fileType, fileName string
+
+	// base is the file when the update was suggested, disk the file now,
+	// and suggested the update applied to base.
+	base, disk contextSnapshot
+	suggested  string
+
+	// merged combines the changes on disk and the suggested ones, with
+	// conflict markers where they overlap; conflicts counts those.
+	merged    string
+	conflicts int
+}
+
+// contextConflictError reports context updates that were not applied
+// because their files changed on disk since they were suggested.
+type contextConflictError struct {
+	updates   []llm.ContextUpdate
+	snapshots map[string]contextSnapshot
+	conflicts []contextConflict
+}
+
+func (e *contextConflictError) Error() string {
+	paths := make([]string, len(e.conflicts))
+	for i, c := range e.conflicts {
+		paths[i] = filepath.ToSlash(c.path)
+	}
+	return fmt.Sprintf("changed on disk since the suggestion was made: %s", strings.Join(paths, ", "))
+}
+
+// mergeLines merges two edits of base line by line, the way diff3 does:
+// lines only one side changed take that side's version, and lines both
+// changed differently are wrapped in conflict markers. It returns the
+// merged text and how many conflicting regions it holds.
+func mergeLines(base, disk, suggested string) (string, int) {
+	b, d, s := splitLines(base), splitLines(disk), splitLines(suggested)
+	toDisk, toSuggested := matchLines(b, d), matchLines(b, s)
+
+	var out []string
+	conflicts := 0
+	resolve := func(baseChunk, diskChunk, suggestedChunk []string) {
+		switch {
+		case equalLines(diskChunk, baseChunk):
+			out = append(out, suggestedChunk...)
+		case equalLines(suggestedChunk, baseChunk), equalLines(diskChunk, suggestedChunk):
+			out = append(out, diskChunk...)
+		default:
+			conflicts++
+			out = append(out, conflictDisk+"\n")
+			out = appendLines(out, diskChunk)
+			out = append(out, conflictSeparator+"\n")
+			out = appendLines(out, suggestedChunk)
+			out = append(out, conflictSuggested+"\n")
+		}
+	}
+
+	i, j, k := 0, 0, 0
+	for bi := range b {
+		dj, inDisk := toDisk[bi]
+		sk, inSuggested := toSuggested[bi]
+		if !inDisk || !inSuggested {
+			continue
+		}
+		// Both sides kept this base line: everything before it is one
+		// chunk to resolve.
+		resolve(b[i:bi], d[j:dj], s[k:sk])
+		out = append(out, b[bi])
+		i, j, k = bi+1, dj+1, sk+1
+	}
+	resolve(b[i:], d[j:], s[k:])
+	return strings.Join(out, ""), conflicts
+}
+
+// splitLines splits text into lines that keep their newlines, so joining
+// them restores the text exactly.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// appendLines appends a chunk of lines, ending its last line with a
+// newline so a conflict marker can follow.
+func appendLines(out, lines []string) []string {
+	out = append(out, lines...)
+	if n := len(out); len(lines) > 0 && !strings.HasSuffix(out[n-1], "\n") {
+		out[n-1] += "\n"
+	}
+	return out
+}
+
+// matchLines maps the lines of a that stay unchanged in b, by index, along
+// a longest common subsequence.
+func matchLines(a, b []string) map[int]int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	matches := make(map[int]int)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			matches[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// equalLines reports whether two chunks hold the same lines.
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// showMerge replaces a pending context update whose files changed on disk
+// with a three-way merge of each of them.
+func (m *Model) showMerge(conflict *contextConflictError) {
+	var sb strings.Builder
+	sb.WriteString(styles.InfoText.Render("These files changed on disk after the suggestion was made, so nothing was saved yet."))
+	sb.WriteString("\n")
+	for _, c := range conflict.conflicts {
+		sb.WriteString("\n")
+		sb.WriteString(styles.Subtitle.Render(filepath.ToSlash(c.path)))
+		sb.WriteString("\n")
+		if c.conflicts == 0 {
+			sb.WriteString(styles.SuccessText.Render("The changes on disk and the suggested ones merge cleanly."))
+		} else {
+			sb.WriteString(styles.ErrorText.Render(fmt.Sprintf("%d conflict(s): both changed the same lines.", c.conflicts)))
+		}
+		sb.WriteString("\n\n")
+		for _, line := range splitLines(c.merged) {
+			line = strings.TrimSuffix(line, "\n")
+			switch line {
+			case conflictDisk, conflictSeparator, conflictSuggested:
+				sb.WriteString(styles.ErrorText.Render("  " + line))
+			default:
+				sb.WriteString("  " + line)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	m.pendingSuggestion = &SuggestionResult{
+		Type:    SuggestionTypeMerge,
+		Title:   "Merge: " + conflict.Error(),
+		Content: sb.String(),
+		Actions: []SuggestionAction{
+			{Label: "Apply the merged result", Key: "a"},
+			{Label: "Edit the merged result", Key: "e"},
+			{Label: "Overwrite with the suggestion", Key: "o", Handler: func() error {
+				return m.applyContextUpdates(conflict.resolve(func(c contextConflict) string { return c.suggested }, ""))
+			}},
+			{Label: "Keep the files on disk", Key: "r"},
+		},
+		ParsedData: conflict,
+	}
+	m.view = ViewSuggestion
+	m.inputMode = false
+	m.updateViewport()
+}
+
+// acceptMerge applies a merge without conflicts.
+func (m *Model) acceptMerge(conflict *contextConflictError) (tea.Model, tea.Cmd) {
+	for _, c := range conflict.conflicts {
+		if c.conflicts > 0 {
+			m.err = fmt.Errorf("%s has %d conflict(s): press e to resolve them, o to overwrite or r to keep the file on disk", filepath.ToSlash(c.path), c.conflicts)
+			return m, nil
+		}
+	}
+	if err := m.applyContextUpdates(conflict.resolve(func(c contextConflict) string { return c.merged }, "")); err != nil {
+		m.err = err
+	}
+	return m.returnToChat()
+}
+
+// editMerge applies the rest of the pending updates and opens the first
+// conflicting file's merged result in the context editor to resolve it.
+func (m *Model) editMerge(conflict *contextConflictError) (tea.Model, tea.Cmd) {
+	edited := conflict.conflicts[0]
+	for _, c := range conflict.conflicts {
+		if c.conflicts > 0 {
+			edited = c
+			break
+		}
+	}
+
+	updates, snapshots := conflict.resolve(func(c contextConflict) string { return c.merged }, edited.path)
+	var skipped []string
+	kept := updates[:0]
+	for _, update := range updates {
+		path := contextUpdatePath(update)
+		if c := conflict.find(path); c != nil && c.conflicts > 0 {
+			skipped = append(skipped, filepath.ToSlash(path))
+			continue
+		}
+		kept = append(kept, update)
+	}
+	if len(kept) > 0 {
+		if err := m.applyContextUpdates(kept, snapshots); err != nil {
+			m.err = err
+			return m, nil
+		}
+	}
+
+	m.pendingSuggestion = nil
+	m.view = ViewContext
+	m.openContextEditor(pluralizeFileType(edited.fileType), edited.path, edited.merged)
+	// Leaving the editor without saving keeps the file on disk, so ask first.
+	m.contextEdit.original = edited.disk.content
+	m.statusText = "Resolve the conflict markers, then Ctrl+S to save"
+	if len(skipped) > 0 {
+		m.statusText += "; not saved, also conflicting: " + strings.Join(skipped, ", ")
+	}
+	return m, nil
+}
+
+// find returns the conflict for a path, or nil.
+func (e *contextConflictError) find(path string) *contextConflict {
+	for i := range e.conflicts {
+		if e.conflicts[i].path == path {
+			return &e.conflicts[i]
+		}
+	}
+	return nil
+}
+
+// resolve turns the pending updates into ones that can be applied now:
+// updates to files without conflicts stay as they were, and each
+// conflicting file gets one update writing the content pick chooses,
+// checked against the file as the merge saw it. The file at skip is left
+// out.
+func (e *contextConflictError) resolve(pick func(contextConflict) string, skip string) ([]llm.ContextUpdate, map[string]contextSnapshot) {
+	var updates []llm.ContextUpdate
+	snapshots := make(map[string]contextSnapshot)
+	for _, update := range e.updates {
+		path := contextUpdatePath(update)
+		if path == skip || e.find(path) != nil {
+			continue
+		}
+		updates = append(updates, update)
+		if snapshot, ok := e.snapshots[path]; ok {
+			snapshots[path] = snapshot
+		}
+	}
+	for _, c := range e.conflicts {
+		if c.path == skip {
+			continue
+		}
+		operation := "update"
+		if !c.disk.exists {
+			operation = "create"
+		}
+		updates = append(updates, llm.ContextUpdate{FileType: c.fileType, FileName: c.fileName, Operation: operation, Content: pick(c)})
+		snapshots[c.path] = c.disk
+	}
+	return updates, snapshots
+}
+
+// mergeConflict returns the conflict a merge suggestion resolves.
+func (s *SuggestionResult) mergeConflict() (*contextConflictError, bool) {
+	if s == nil || s.Type != SuggestionTypeMerge {
+		return nil, false
+	}
+	conflict, ok := s.ParsedData.(*contextConflictError)
+	return conflict, ok
+}
+
+// errContextConflict reports whether err is a conflict with changes on
+// disk, and returns it.
+func errContextConflict(err error) (*contextConflictError, bool) {
+	var conflict *contextConflictError
+	ok := errors.As(err, &conflict)
+	return conflict, ok
+}
@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listModelsTimeout bounds each provider's list-models request.
+const listModelsTimeout = 10 * time.Second
+
+// ProviderFactory builds a provider for a configured provider name and
+// model. An empty model selects the provider's default model.
+type ProviderFactory func(providerName, model string) (llm.Provider, error)
+
+// modelChoice is a provider and model offered by /models.
+type modelChoice struct {
+	Provider string
+	Model    string
+}
+
+type modelsListMsg struct {
+	models []modelChoice
+	err    error
+}
+
+// SetProviderSwitching lets /models list and switch to the models of the
+// given configured providers, built with factory.
+func (m *Model) SetProviderSwitching(providers []string, factory ProviderFactory) {
+	m.providerNames = providers
+	m.providerFactory = factory
+}
+
+// CloseProvider closes the provider the session ended on, which /models
+// may have switched from the one it started with.
+func (m *Model) CloseProvider() error {
+	if m.provider == nil {
+		return nil
+	}
+	return m.provider.Close()
+}
+
+func (m *Model) showModelSelection(providerFilter string) (tea.Model, tea.Cmd) {
+	m.statusText = "Fetching models..."
+	m.textarea.Reset()
+
+	return m, m.fetchModelsCmd(providerFilter)
+}
+
+// modelProviders returns the providers /models lists: the current one
+// first, then the other configured providers. A filter picks one.
+func (m *Model) modelProviders(filter string) ([]string, error) {
+	providers := []string{m.providerName}
+	if m.providerFactory != nil {
+		for _, name := range m.providerNames {
+			if name != m.providerName {
+				providers = append(providers, name)
+			}
+		}
+	}
+	if filter == "" {
+		return providers, nil
+	}
+
+	for _, name := range providers {
+		if strings.EqualFold(name, filter) {
+			return []string{name}, nil
+		}
+	}
+	return nil, fmt.Errorf("provider %q is not configured (available: %s)", filter, strings.Join(providers, ", "))
+}
+
+// fetchModelsCmd lists the models of each provider through its list-models
+// API. Providers that fail are reported but do not hide the others.
+func (m *Model) fetchModelsCmd(providerFilter string) tea.Cmd {
+	providers, err := m.modelProviders(providerFilter)
+	if err != nil {
+		return func() tea.Msg { return modelsListMsg{err: err} }
+	}
+	current, currentName, factory := m.provider, m.providerName, m.providerFactory
+
+	return func() tea.Msg {
+		var choices []modelChoice
+		var errs []error
+		for _, name := range providers {
+			var provider llm.Provider
+			if name == currentName {
+				provider = current
+			}
+			if provider == nil && factory != nil {
+				p, err := factory(name, "")
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+					continue
+				}
+				defer p.Close()
+				provider = p
+			}
+
+			lister, ok := provider.(llm.ModelLister)
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: listing models is not supported", name))
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), listModelsTimeout)
+			models, err := lister.ListModels(ctx)
+			cancel()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			for _, model := range models {
+				choices = append(choices, modelChoice{Provider: name, Model: model})
+			}
+		}
+
+		return modelsListMsg{models: choices, err: errors.Join(errs...)}
+	}
+}
+
+// handleModelsList opens the model picker, reporting providers that could
+// not be listed.
+func (m *Model) handleModelsList(msg modelsListMsg) {
+	m.err = msg.err
+	m.statusText = ""
+	if len(msg.models) == 0 {
+		if m.err == nil {
+			m.err = fmt.Errorf("no models available")
+		}
+		return
+	}
+
+	m.availableModels = msg.models
+	m.modelSelectIndex = 0
+	for i, choice := range msg.models {
+		if choice.Provider == m.providerName && choice.Model == m.modelName {
+			m.modelSelectIndex = i
+			break
+		}
+	}
+	m.modelSelectMode = true
+	m.inputMode = false
+	m.statusText = "Select a model (↑/↓ to navigate, Enter to select, Esc to cancel)"
+	m.updateViewport()
+}
+
+// switchModel switches to the chosen provider and model. The provider is
+// rebuilt so its capabilities, and with them the token budgets, match the
+// new model's context window.
+func (m *Model) switchModel(choice modelChoice) {
+	if m.providerFactory == nil {
+		if choice.Provider != m.providerName {
+			m.err = fmt.Errorf("switching providers is not available in this session")
+			return
+		}
+		m.modelName = choice.Model
+		m.statusText = fmt.Sprintf("Switched to %s", m.modelName)
+		return
+	}
+
+	provider, err := m.providerFactory(choice.Provider, choice.Model)
+	if err != nil {
+		m.err = fmt.Errorf("failed to switch to %s/%s: %w", choice.Provider, choice.Model, err)
+		return
+	}
+	if m.provider != nil {
+		_ = m.provider.Close()
+	}
+	m.provider = provider
+	m.providerName = choice.Provider
+	m.modelName = choice.Model
+
+	status := fmt.Sprintf("Switched to %s/%s", m.providerName, m.modelName)
+	if limit := provider.Capabilities().MaxContextTokens; limit > 0 {
+		status += fmt.Sprintf(" (%d-token context)", limit)
+	}
+	m.statusText = status
+}
+
+func (m *Model) renderModelSelect() string {
+	var sb strings.Builder
+	sb.WriteString(styles.Title.Render("Select Model"))
+	sb.WriteString("\n\n")
+
+	if len(m.availableModels) == 0 {
+		sb.WriteString(styles.MutedText.Render("No models available"))
+		return sb.String()
+	}
+
+	lastProvider := ""
+	for i, choice := range m.availableModels {
+		if choice.Provider != lastProvider {
+			if lastProvider != "" {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(styles.HelpKey.Render(choice.Provider))
+			sb.WriteString("\n")
+			lastProvider = choice.Provider
+		}
+
+		prefix := "  "
+		style := styles.MutedText
+		if i == m.modelSelectIndex {
+			prefix = "> "
+			style = styles.SelectedItem
+		}
+		if choice.Provider == m.providerName && choice.Model == m.modelName {
+			sb.WriteString(style.Render(fmt.Sprintf("%s%s (current)\n", prefix, choice.Model)))
+		} else {
+			sb.WriteString(style.Render(fmt.Sprintf("%s%s\n", prefix, choice.Model)))
+		}
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(styles.HelpDesc.Render("↑/↓ Navigate • Enter Select • Esc Cancel"))
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// characterForm is the /newchar form and the sheet its fields fill in.
+type characterForm struct {
+	form  *huh.Form
+	sheet *project.CharacterSheet
+}
+
+// openCharacterForm shows the character sheet form in place of the chat.
+func (m *Model) openCharacterForm() tea.Cmd {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return nil
+	}
+
+	sheet := &project.CharacterSheet{}
+	keymap := huh.NewDefaultKeyMap()
+	keymap.Quit = key.NewBinding(key.WithKeys("esc", "ctrl+c"), key.WithHelp("esc", "cancel"))
+
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewInput().Title("Name").Value(&sheet.Name).Validate(m.validateCharacterName),
+		huh.NewInput().Title("Role").Placeholder("protagonist, mentor, rival...").Value(&sheet.Role),
+		huh.NewInput().Title("Age").Value(&sheet.Age),
+		huh.NewText().Title("Goals").Description("What they want, and why").Lines(3).Value(&sheet.Goals),
+		huh.NewText().Title("Flaw").Description("What stands in their way from within").Lines(3).Value(&sheet.Flaw),
+		huh.NewText().Title("Voice").Description("How they talk: rhythm, vocabulary, verbal tics").Lines(3).Value(&sheet.Voice),
+	)).WithKeyMap(keymap).WithWidth(m.width).WithShowHelp(true)
+
+	m.charForm = &characterForm{form: form, sheet: sheet}
+	m.inputMode = false
+	m.textarea.Blur()
+	return form.Init()
+}
+
+// validateCharacterName requires a name that no character file uses yet.
+func (m *Model) validateCharacterName(name string) error {
+	if project.FileSlug(name) == "" {
+		return fmt.Errorf("enter a name")
+	}
+	if c, err := m.project.FindCharacter(name); err == nil {
+		return fmt.Errorf("%s already exists in %s", c.Name, c.FilePath)
+	}
+	return nil
+}
+
+// updateCharacterForm passes a message to the form and saves the character
+// once the form is submitted.
+func (m *Model) updateCharacterForm(msg tea.Msg) tea.Cmd {
+	model, cmd := m.charForm.form.Update(msg)
+	if form, ok := model.(*huh.Form); ok {
+		m.charForm.form = form
+	}
+
+	switch m.charForm.form.State {
+	case huh.StateCompleted:
+		return m.saveCharacterForm()
+	case huh.StateAborted:
+		m.closeCharacterForm()
+		m.statusText = "Character not created"
+		return nil
+	}
+	return cmd
+}
+
+// saveCharacterForm writes the character file and indexes it so the next
+// request can use it as context.
+func (m *Model) saveCharacterForm() tea.Cmd {
+	sheet := *m.charForm.sheet
+	m.closeCharacterForm()
+
+	path, err := m.project.CreateCharacter(sheet)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if err := m.reindexContextFile(path); err != nil {
+		m.err = fmt.Errorf("saved, but indexing failed: %w", err)
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf("Created %s in %s.", strings.TrimSpace(sheet.Name), path)})
+	m.updateViewport()
+	return m.showActionToast("Created " + path)
+}
+
+// closeCharacterForm returns to the composer.
+func (m *Model) closeCharacterForm() {
+	m.charForm = nil
+	m.inputMode = true
+	m.textarea.Focus()
+	m.updateViewport()
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+)
+
+// modelOverridePattern matches a prompt prefixed with @model (or
+// @provider/model) followed by the prompt itself.
+var modelOverridePattern = regexp.MustCompile(`^@(\S+)\s+(\S[\s\S]*)$`)
+
+// parseModelOverride splits "@model prompt" into the model reference and
+// the prompt. Input without the prefix is returned unchanged.
+func parseModelOverride(input string) (ref, prompt string) {
+	match := modelOverridePattern.FindStringSubmatch(input)
+	if match == nil {
+		return "", input
+	}
+	return match[1], match[2]
+}
+
+// guessModelProvider returns the provider serving a well-known model name,
+// or "" if the name does not identify one.
+func guessModelProvider(model string) string {
+	name := strings.ToLower(model)
+	for _, prefix := range []string{"gpt-", "chatgpt-", "o1", "o3", "o4"} {
+		if strings.HasPrefix(name, prefix) {
+			return "openai"
+		}
+	}
+	if strings.HasPrefix(name, "gemini") {
+		return "gemini"
+	}
+	return ""
+}
+
+// ResolveModelRef resolves a model reference to a provider and model.
+// "provider/model" names one of the configured providers explicitly; a bare
+// model goes to the configured provider that serves it, or else current.
+func ResolveModelRef(ref, current string, configured []string) (provider, model string) {
+	isConfigured := func(name string) bool {
+		if strings.EqualFold(name, current) {
+			return true
+		}
+		for _, c := range configured {
+			if strings.EqualFold(name, c) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if provider, model, ok := strings.Cut(ref, "/"); ok && isConfigured(provider) {
+		return strings.ToLower(provider), model
+	}
+	if provider := guessModelProvider(ref); provider != "" && isConfigured(provider) {
+		return provider, ref
+	}
+	return current, ref
+}
+
+// resolveModelOverride resolves an @ reference against the session's
+// providers.
+func (m *Model) resolveModelOverride(ref string) modelChoice {
+	provider, model := ResolveModelRef(ref, m.providerName, m.providerNames)
+	return modelChoice{Provider: provider, Model: model}
+}
+
+// beginTurn sets the provider and model for the next request. A nil choice,
+// or one naming the session's own model, uses the session default; any
+// other choice builds a provider used for this turn only.
+func (m *Model) beginTurn(choice *modelChoice) error {
+	if m.turnProvider != nil {
+		_ = m.turnProvider.Close()
+	}
+	m.turnProvider = nil
+	m.turnModel = ""
+	m.turnProviderName = ""
+
+	if choice == nil || (choice.Provider == m.providerName && choice.Model == m.modelName) {
+		return nil
+	}
+	if m.providerFactory == nil {
+		return fmt.Errorf("model overrides are not available in this session")
+	}
+
+	provider, err := m.providerFactory(choice.Provider, choice.Model)
+	if err != nil {
+		return fmt.Errorf("failed to use %s/%s: %w", choice.Provider, choice.Model, err)
+	}
+	m.turnProvider = provider
+	m.turnModel = choice.Model
+	m.turnProviderName = choice.Provider
+	return nil
+}
+
+// activeProvider returns the provider and model serving the current turn:
+// the @model override if there is one, or else the session default.
+func (m *Model) activeProvider() (llm.Provider, string) {
+	if m.turnProvider != nil {
+		return m.turnProvider, m.turnModel
+	}
+	return m.provider, m.modelName
+}
+
+// activeProviderName names the provider serving the current turn.
+func (m *Model) activeProviderName() string {
+	if m.turnProvider != nil {
+		return m.turnProviderName
+	}
+	return m.providerName
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/azyu/dreamteller/internal/project"
+)
+
+// handlePacingCommand shows the pacing curve across the book, or with
+// "svg" exports it as a chart.
+func (m *Model) handlePacingCommand(args []string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	if len(args) > 0 {
+		if args[0] != "svg" {
+			m.err = fmt.Errorf("usage: /pacing [svg]")
+			return
+		}
+		path, err := m.project.ExportPacingSVG()
+		if err != nil {
+			m.err = fmt.Errorf("pacing export failed: %w", err)
+			return
+		}
+		m.statusText = fmt.Sprintf("Exported the pacing chart to %s", filepath.ToSlash(path))
+		return
+	}
+
+	report, err := m.project.PacingReport()
+	if err != nil {
+		m.err = fmt.Errorf("pacing report failed: %w", err)
+		return
+	}
+	content := report.String()
+	if report.Words > 0 {
+		content += fmt.Sprintf("\n\nReading times assume %d words a minute. /pacing svg exports the chart.", project.ReadingWordsPerMinute)
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	m.updateViewport()
+}
@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// queueContextUpdate adds a context update suggestion to the review queue
+// instead of showing it, when the project queues them. It reports whether
+// the suggestion was queued.
+func (m *Model) queueContextUpdate(s *SuggestionResult) (tea.Cmd, bool) {
+	if s.Type != SuggestionTypeContextUpdate || m.project == nil || m.project.Config == nil || !m.project.Config.QueueContextUpdates {
+		return nil, false
+	}
+	m.reviewQueue = append(m.reviewQueue, s)
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf("Queued for review: %s (%d pending; /pending to review)", s.Title, len(m.reviewQueue))})
+	m.endToolReply()
+
+	toast, cmd := showToast(fmt.Sprintf("%d context update(s) pending — /pending to review", len(m.reviewQueue)), ToastInfo, 3*time.Second)
+	m.toast = toast
+	return cmd, true
+}
+
+// handlePendingCommand handles /pending [approve [<n>] | reject [<n>] |
+// on | off].
+func (m *Model) handlePendingCommand(args []string) tea.Cmd {
+	if m.project == nil || m.project.Config == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return nil
+	}
+	if len(args) == 0 {
+		m.reviewPending()
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on", "off":
+		on := strings.EqualFold(args[0], "on")
+		if err := m.project.SetQueueContextUpdates(on); err != nil {
+			m.err = fmt.Errorf("failed to save config: %w", err)
+			return nil
+		}
+		if on {
+			m.statusText = "Context updates are queued for /pending instead of asked about one by one"
+		} else {
+			m.statusText = "Context updates are asked about as they come"
+		}
+	case "approve", "reject":
+		approve := strings.EqualFold(args[0], "approve")
+		if len(args) == 1 {
+			if !approve {
+				m.rejectPending()
+				return nil
+			}
+			m.reviewPending()
+			if m.pendingSuggestion == nil {
+				return nil
+			}
+			_, cmd := m.acceptSuggestion()
+			return cmd
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || n > len(m.reviewQueue) {
+			m.err = fmt.Errorf("no pending update %s; /pending shows them", args[1])
+			return nil
+		}
+		if approve {
+			m.approvePending(n - 1)
+		} else {
+			s := m.reviewQueue[n-1]
+			m.reviewQueue = append(m.reviewQueue[:n-1], m.reviewQueue[n:]...)
+			m.recordUsage(usageSuggestion, "rejected")
+			m.messages = append(m.messages, Message{Role: "system", Content: "Rejected: " + s.Title})
+			m.updateViewport()
+		}
+	default:
+		m.err = fmt.Errorf("usage: /pending [approve [<n>] | reject [<n>] | on | off]")
+	}
+	return nil
+}
+
+// reviewPending shows every queued context update, with its diff, to be
+// approved or rejected together.
+func (m *Model) reviewPending() {
+	if len(m.reviewQueue) == 0 {
+		hint := "/pending on queues the AI's context updates here instead of asking about each one."
+		if m.project.Config.QueueContextUpdates {
+			hint = "Context updates the AI suggests are queued here."
+		}
+		m.statusText = "No pending context updates. " + hint
+		return
+	}
+
+	queued := m.reviewQueue
+	m.reviewQueue = nil
+
+	var updates []llm.ContextUpdate
+	snapshots := make(map[string]contextSnapshot)
+	var sb strings.Builder
+	sb.WriteString(styles.MutedText.Render("Approving applies them all together: if one fails, none is kept. /pending approve <n> or /pending reject <n> handles one at a time."))
+	for i, s := range queued {
+		updates = append(updates, pendingContextUpdates(s)...)
+		for path, snapshot := range s.snapshots {
+			if _, ok := snapshots[path]; !ok {
+				snapshots[path] = snapshot
+			}
+		}
+		sb.WriteString("\n\n")
+		sb.WriteString(styles.Subtitle.Render(fmt.Sprintf("%d.", i+1)))
+		sb.WriteString(" ")
+		sb.WriteString(s.Content)
+	}
+
+	m.pendingSuggestion = &SuggestionResult{
+		Type:    SuggestionTypeContextUpdate,
+		Title:   fmt.Sprintf("Pending Context Updates: %d", len(queued)),
+		Content: sb.String(),
+		Actions: []SuggestionAction{
+			{Label: "Approve all", Key: "a"},
+			{Label: "Reject all", Key: "r"},
+			{Label: "Keep them queued", Key: "k"},
+		},
+		RequiresApproval: true,
+		ParsedData:       updates,
+		snapshots:        snapshots,
+		queued:           queued,
+	}
+	m.view = ViewSuggestion
+	m.inputMode = false
+	m.updateViewport()
+}
+
+// keepPending closes a /pending review and puts its updates back in the
+// queue.
+func (m *Model) keepPending() (tea.Model, tea.Cmd) {
+	m.reviewQueue = append(m.pendingSuggestion.queued, m.reviewQueue...)
+	m.statusText = fmt.Sprintf("%d context update(s) still pending", len(m.reviewQueue))
+	return m.returnToChat()
+}
+
+// rejectPending drops every queued context update.
+func (m *Model) rejectPending() {
+	if len(m.reviewQueue) == 0 {
+		m.statusText = "No pending context updates"
+		return
+	}
+	for range m.reviewQueue {
+		m.recordUsage(usageSuggestion, "rejected")
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf("Rejected %d pending context update(s)", len(m.reviewQueue))})
+	m.reviewQueue = nil
+	m.updateViewport()
+}
+
+// approvePending applies the queued context update at i on its own.
+func (m *Model) approvePending(i int) {
+	s := m.reviewQueue[i]
+	m.reviewQueue = append(m.reviewQueue[:i], m.reviewQueue[i+1:]...)
+	updates := pendingContextUpdates(s)
+	if err := m.applyContextUpdates(updates, s.snapshots); err != nil {
+		if conflict, ok := errContextConflict(err); ok {
+			m.showMerge(conflict)
+			return
+		}
+		m.reviewQueue = append(m.reviewQueue[:i], append([]*SuggestionResult{s}, m.reviewQueue[i:]...)...)
+		m.err = err
+		return
+	}
+	m.recordUsage(usageSuggestion, "accepted")
+
+	// Updates still queued for the files just written were made before
+	// this one; compare them with the files as they are now, so this
+	// change does not read as a change on disk.
+	for _, update := range updates {
+		path := contextUpdatePath(update)
+		for _, rest := range m.reviewQueue {
+			if _, ok := rest.snapshots[path]; ok {
+				rest.snapshots[path] = m.suggestionHandler.snapshotContextFile(path)
+			}
+		}
+	}
+	m.updateViewport()
+}
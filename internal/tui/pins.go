@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// handlePinCommand handles /pin and /unpin: with a path it pins or unpins
+// research material for automatic context, without one it lists the pins.
+func (m *Model) handlePinCommand(pin bool, arg string) {
+	if m.project == nil || m.project.Config == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	if arg == "" {
+		m.showPins()
+		return
+	}
+
+	if pin {
+		if err := m.project.Pin(arg); err != nil {
+			m.err = err
+			return
+		}
+		m.statusText = "Pinned " + arg + "; its passages can now be added to context"
+		return
+	}
+	if err := m.project.Unpin(arg); err != nil {
+		m.err = err
+		return
+	}
+	m.statusText = "Unpinned " + arg
+}
+
+// showPins lists the pinned research material.
+func (m *Model) showPins() {
+	var sb strings.Builder
+	pinned := m.project.Pinned()
+	if len(pinned) == 0 {
+		sb.WriteString("Nothing is pinned. Research is searched with /search but only added to context once pinned with /pin research/<file>.")
+	} else {
+		sb.WriteString("Pinned research:\n")
+		for _, p := range pinned {
+			sb.WriteString("\n- " + p)
+		}
+		sb.WriteString("\n\nUse /unpin <path> to remove one.")
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
@@ -0,0 +1,163 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/batch"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pitchExcerptRunes caps the opening of a chapter that has no summary.
+const pitchExcerptRunes = 600
+
+// pitchPrompts are the instructions for each kind of pitch material.
+var pitchPrompts = map[string]string{
+	"blurb":    "Write a back-cover blurb for this novel in 150-200 words: open with a hook, introduce the protagonist and what they want, raise the central conflict and the stakes, and end on a question or turn that makes readers open the book. Do not reveal the ending. Write in the manuscript's language.",
+	"synopsis": "Write a one-page synopsis of this novel in 500-700 words, in the present tense and third person: the protagonist and their situation, the inciting incident, the major turning points in order, the climax and the ending, which must be revealed. Follow the main characters' arcs, name only the characters who matter, and put each name in capitals the first time it appears. Write in the manuscript's language.",
+	"query":    "Write a query letter to a literary agent for this novel: a one-sentence hook; a 200-300 word pitch in the style of a blurb that sets up the protagonist, the conflict and the stakes without revealing the ending; a paragraph giving the title, genre and word count, with [comparable titles] left as a placeholder; a short [author bio] placeholder; and a courteous closing. Write in the manuscript's language.",
+}
+
+// pitchKinds are the pitch materials in the order usage lists them.
+var pitchKinds = []string{"blurb", "synopsis", "query"}
+
+// genreConventions are the notes on what each genre's pitches lead with,
+// keyed by words that may appear in the project's genre.
+var genreConventions = []struct {
+	keywords []string
+	note     string
+}{
+	{[]string{"romance", "로맨스"}, "Romance pitches introduce both leads, what draws them together and what keeps them apart, and promise the emotional payoff."},
+	{[]string{"mystery", "추리", "미스터리"}, "Mystery pitches lead with the crime or puzzle, the sleuth's personal stake and the obstacles to the truth."},
+	{[]string{"thriller", "스릴러"}, "Thriller pitches lead with the threat, the ticking clock and what the protagonist stands to lose."},
+	{[]string{"fantasy", "판타지"}, "Fantasy pitches ground the world in one vivid, specific detail and keep invented terms to a minimum."},
+	{[]string{"sf", "science fiction", "sci-fi", "과학"}, "Science fiction pitches state the speculative premise early and show what it costs the characters."},
+	{[]string{"horror", "호러", "공포"}, "Horror pitches build dread and end on the threat, not its resolution."},
+	{[]string{"literary", "문학"}, "Literary pitches lead with the character's inner conflict and the book's central question."},
+}
+
+// handlePitchCommand handles /pitch <blurb | synopsis | query> [notes]. The
+// request goes into the chat, where the result can be refined by replying.
+func (m *Model) handlePitchCommand(args []string) (tea.Model, tea.Cmd) {
+	m.textarea.Reset()
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return m, nil
+	}
+	usage := fmt.Errorf("usage: /pitch <%s> [notes]", strings.Join(pitchKinds, " | "))
+	if len(args) == 0 {
+		m.err = usage
+		return m, nil
+	}
+	kind := strings.ToLower(args[0])
+	if kind == "query-letter" {
+		kind = "query"
+	}
+	prompt, ok := pitchPrompts[kind]
+	if !ok {
+		m.err = usage
+		return m, nil
+	}
+
+	material, err := m.pitchMaterial()
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if note := m.genreConvention(); note != "" {
+		prompt += " " + note
+	}
+	if notes := strings.Join(args[1:], " "); notes != "" {
+		prompt += " Also: " + notes
+	}
+
+	if m.offline {
+		m.showOfflineNotice()
+		return m, nil
+	}
+	if m.aiLocked() {
+		return m, nil
+	}
+	if err := m.beginTurn(nil); err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.statusText = "Drafting the " + kind + "; reply to refine it"
+	return m.submitPrompt(withAttachments(prompt, []Attachment{{Name: "Outline and chapter summaries", Content: material}}))
+}
+
+// genreConvention returns the pitch conventions of the project's genre.
+func (m *Model) genreConvention() string {
+	if m.project.Config == nil {
+		return ""
+	}
+	genre := strings.ToLower(m.project.Config.Genre)
+	if genre == "" {
+		return ""
+	}
+	var notes []string
+	for _, c := range genreConventions {
+		for _, keyword := range c.keywords {
+			if strings.Contains(genre, keyword) {
+				notes = append(notes, c.note)
+				break
+			}
+		}
+	}
+	return strings.Join(notes, " ")
+}
+
+// pitchMaterial gathers what the pitch is written from: the title, genre
+// and word count, the plot outline, and a summary of each chapter. Chapters
+// not summarized with `dreamteller batch --op summarize` contribute their
+// opening instead.
+func (m *Model) pitchMaterial() (string, error) {
+	chapters, err := m.project.LoadChapters()
+	if err != nil {
+		return "", fmt.Errorf("failed to load chapters: %w", err)
+	}
+	plots, err := m.project.LoadPlots()
+	if err != nil {
+		return "", fmt.Errorf("failed to load plot: %w", err)
+	}
+	if len(chapters) == 0 && len(plots) == 0 {
+		return "", fmt.Errorf("nothing to pitch yet: write an outline in context/plot or some chapters first")
+	}
+
+	var sb strings.Builder
+	if config := m.project.Config; config != nil {
+		sb.WriteString("Title: " + config.Name + "\n")
+		if config.Genre != "" {
+			sb.WriteString("Genre: " + config.Genre + "\n")
+		}
+	}
+	if words, err := m.project.WordCount(); err == nil {
+		sb.WriteString(fmt.Sprintf("Word count so far: %d\n", words))
+	}
+
+	if len(plots) > 0 {
+		sb.WriteString("\n## Outline\n")
+		for _, plot := range plots {
+			sb.WriteString("\n" + strings.TrimSpace(plot.Description) + "\n")
+		}
+	}
+
+	if len(chapters) > 0 {
+		sb.WriteString("\n## Chapters\n")
+		summaries := batch.Job{Op: batch.OpSummarize}.OutputDir()
+		for _, ch := range chapters {
+			path := filepath.Join(summaries, fmt.Sprintf("chapter-%03d.md", ch.Number))
+			if summary, err := m.project.FS.ReadMarkdown(path); err == nil && strings.TrimSpace(summary) != "" {
+				sb.WriteString(fmt.Sprintf("\n### Chapter %d (summary)\n\n%s\n", ch.Number, strings.TrimSpace(summary)))
+				continue
+			}
+			opening := []rune(strings.TrimSpace(ch.Content))
+			if len(opening) > pitchExcerptRunes {
+				opening = append(opening[:pitchExcerptRunes], '…')
+			}
+			sb.WriteString(fmt.Sprintf("\n### Chapter %d (opening)\n\n%s\n", ch.Number, string(opening)))
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// plotSceneRequest asks the model to draft the scene for a plot
+// development the author added to the outline.
+const plotSceneRequest = "Draft the scene where this happens: %s. %s"
+
+// applyPlotSuggestion adds the plot suggestion chosen with key to the
+// outline and readies a request to draft its scene in the composer. It
+// reports false if key does not pick one of the pending suggestions.
+func (m *Model) applyPlotSuggestion(key string) (tea.Model, tea.Cmd, bool) {
+	suggestions, ok := m.pendingSuggestion.ParsedData.([]llm.PlotSuggestion)
+	if !ok || m.project == nil {
+		return m, nil, false
+	}
+	n, err := strconv.Atoi(key)
+	if err != nil || n < 1 || n > len(suggestions) {
+		return m, nil, false
+	}
+	s := suggestions[n-1]
+
+	m.recordUsage(usageSuggestion, "accepted")
+	m.noteSuggestionOutcome(outcomeAccepted)
+
+	body := s.Description
+	if s.Impact != "" {
+		body += "\n\nImpact: " + s.Impact
+	}
+	path, err := m.project.AddPlotPoint(s.Title, body)
+	if err != nil {
+		m.err = err
+		model, cmd := m.returnToChat()
+		return model, cmd, true
+	}
+	if err := m.reindexContextFile(path); err != nil {
+		m.err = fmt.Errorf("indexing failed: %w", err)
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf("Added to the outline: %s (%s)", s.Title, path)})
+
+	// Leave a draft the author is writing alone.
+	if strings.TrimSpace(m.textarea.Value()) == "" {
+		m.textarea.SetValue(fmt.Sprintf(plotSceneRequest, s.Title, s.Description))
+		m.resizeComposer()
+		m.statusText = "Press Enter to draft the scene, or edit the request first"
+	}
+	model, cmd := m.returnToChat()
+	return model, cmd, true
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// polishPrompt asks the editing model for a line edit of the passage it
+// was just shown.
+const polishPrompt = "Line-edit your last passage: tighten wordy sentences, fix grammar and punctuation, vary sentence rhythm, sharpen word choice and remove repetition. Keep every event, line of dialogue, the point of view and the tense unchanged. Reply with the edited passage only."
+
+// maxAvoidWords caps how many overused words editing prompts name.
+const maxAvoidWords = 8
+
+// avoidWordsInstruction names the project's most overused crutch words and
+// phrases for editing prompts when writing.avoid_crutch_words is set.
+func (m *Model) avoidWordsInstruction() string {
+	if m.project == nil || m.project.Config == nil || !m.project.Config.Writing.AvoidCrutchWords {
+		return ""
+	}
+	report, err := m.project.WordFrequency()
+	if err != nil {
+		return ""
+	}
+	words := report.TopOffenders(maxAvoidWords)
+	if len(words) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" The manuscript overuses these words and phrases; avoid them: %s.", strings.Join(words, ", "))
+}
+
+// polishModel returns the editing model configured as llm.polish_model, or
+// nil to use the session's model.
+func (m *Model) polishModel() *modelChoice {
+	if m.project == nil || m.project.Config == nil {
+		return nil
+	}
+	ref := strings.TrimSpace(m.project.Config.LLM.PolishModel)
+	if ref == "" {
+		return nil
+	}
+	choice := m.resolveModelOverride(ref)
+	return &choice
+}
+
+// polishLastPassage sends the last generated passage to the editing model
+// for a line edit. The draft is kept and the edit is added after it, so
+// each text stays attributed to the model that wrote it.
+func (m *Model) polishLastPassage() (tea.Model, tea.Cmd) {
+	if len(m.messages) == 0 || m.messages[len(m.messages)-1].Role != "assistant" {
+		m.err = fmt.Errorf("nothing to polish: the last message is not an AI reply")
+		return m, nil
+	}
+	if err := m.beginTurn(m.polishModel()); err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	_, model := m.activeProvider()
+	m.statusText = fmt.Sprintf("Polishing with %s...", model)
+	return m.submitPrompt(polishPrompt + m.avoidWordsInstruction())
+}
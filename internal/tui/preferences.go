@@ -0,0 +1,245 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Suggestion outcomes recorded for the preference digest.
+const (
+	outcomeAccepted = "accepted"
+	outcomeRejected = "rejected"
+	outcomeModified = "modified"
+)
+
+// preferenceRefreshEvery is how many new suggestion outcomes update the
+// preference digest.
+const preferenceRefreshEvery = 5
+
+// preferenceFeedbackLimit caps the outcomes folded into one update.
+const preferenceFeedbackLimit = 50
+
+// preferenceFeedbackRunes caps how much of a suggestion is recorded.
+const preferenceFeedbackRunes = 400
+
+// preferenceDigestTimeout bounds the request that updates the digest.
+const preferenceDigestTimeout = 2 * time.Minute
+
+// preferenceDigestPrompt asks the model to fold suggestion outcomes into
+// the digest of the author's preferences.
+const preferenceDigestPrompt = `You keep a short digest of a novelist's tastes, learned from the plot and character suggestions their writing assistant made and what the author did with each: accepted it, rejected it, or asked for changes. Update the digest with the new outcomes. Write short statements the assistant can act on, such as "The author dislikes sudden betrayals" or "The author prefers quiet, character-driven turns to action set pieces". State only what the outcomes support, and keep earlier points unless new outcomes contradict them. Write in the language of the suggestions, as at most 8 bullets. Reply with the digest only.`
+
+// preferenceDigestHeading introduces the digest in the system prompt.
+const preferenceDigestHeading = "## Author Preferences\n\nLearned from the plot and character suggestions the author accepted, rejected or asked to change. Let them shape your suggestions.\n\n"
+
+// preferencesUpdatedMsg carries an updated preference digest.
+type preferencesUpdatedMsg struct {
+	digest *storage.PreferenceDigest
+	err    error
+}
+
+// tracksPreferences reports whether the outcome of a suggestion of type t
+// says something about the author's taste in story.
+func tracksPreferences(t SuggestionType) bool {
+	switch t {
+	case SuggestionTypePlot, SuggestionTypeCharacterAction, SuggestionTypeContextUpdate:
+		return true
+	}
+	return false
+}
+
+// noteSuggestionOutcome records what the author did with the pending
+// suggestion. For a modified suggestion, the author's next message is kept
+// as what they asked to change.
+func (m *Model) noteSuggestionOutcome(outcome string) {
+	s := m.pendingSuggestion
+	if s == nil || !tracksPreferences(s.Type) || m.project == nil || m.project.DB == nil || m.offRecord() {
+		return
+	}
+	content := s.Content
+	if runes := []rune(content); len(runes) > preferenceFeedbackRunes {
+		content = string(runes[:preferenceFeedbackRunes]) + "..."
+	}
+	id, err := m.project.DB.SaveSuggestionFeedback(storage.SuggestionFeedback{
+		Kind:    string(s.Type),
+		Title:   s.Title,
+		Content: content,
+		Outcome: outcome,
+	})
+	if err == nil && outcome == outcomeModified {
+		m.modifiedSuggestion = id
+	}
+}
+
+// noteModification keeps input as what the author asked to change in the
+// suggestion they chose to modify.
+func (m *Model) noteModification(input string) {
+	if m.modifiedSuggestion == 0 {
+		return
+	}
+	if m.project != nil && m.project.DB != nil {
+		if runes := []rune(input); len(runes) > preferenceFeedbackRunes {
+			input = string(runes[:preferenceFeedbackRunes]) + "..."
+		}
+		_ = m.project.DB.SetSuggestionFeedbackNote(m.modifiedSuggestion, input)
+	}
+	m.modifiedSuggestion = 0
+}
+
+// refreshPreferences updates the preference digest in the background once
+// enough suggestion outcomes have been recorded since it was last updated,
+// or whenever there are new ones if force is set.
+func (m *Model) refreshPreferences(force bool) tea.Cmd {
+	if m.offline || m.refreshingPreferences || m.project == nil || m.project.DB == nil {
+		return nil
+	}
+	provider, _ := m.activeProvider()
+	if provider == nil {
+		return nil
+	}
+
+	previous, err := m.project.DB.GetPreferenceDigest()
+	if err != nil {
+		return nil
+	}
+	var after int64
+	if previous != nil {
+		after = previous.FeedbackID
+	}
+	feedback, err := m.project.DB.ListSuggestionFeedback(after, preferenceFeedbackLimit)
+	if err != nil || len(feedback) == 0 || (!force && len(feedback) < preferenceRefreshEvery) {
+		return nil
+	}
+
+	m.refreshingPreferences = true
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), preferenceDigestTimeout)
+		defer cancel()
+		resp, err := provider.Chat(ctx, preferenceDigestRequest(previous, feedback))
+		if err != nil {
+			return preferencesUpdatedMsg{err: err}
+		}
+		text := strings.TrimSpace(resp.Message.Content)
+		if text == "" {
+			return preferencesUpdatedMsg{err: fmt.Errorf("the model returned an empty digest")}
+		}
+		return preferencesUpdatedMsg{digest: &storage.PreferenceDigest{Text: text, FeedbackID: feedback[len(feedback)-1].ID}}
+	}
+}
+
+// preferenceDigestRequest builds the request that folds suggestion
+// outcomes into the previous digest.
+func preferenceDigestRequest(previous *storage.PreferenceDigest, feedback []storage.SuggestionFeedback) llm.ChatRequest {
+	var sb strings.Builder
+	if previous != nil {
+		sb.WriteString("Digest so far:\n")
+		sb.WriteString(previous.Text)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("New outcomes:\n")
+	for _, f := range feedback {
+		fmt.Fprintf(&sb, "\n[%s, %s] %s\n%s\n", f.Kind, f.Outcome, f.Title, f.Content)
+		if f.Note != "" {
+			fmt.Fprintf(&sb, "The author asked instead: %s\n", f.Note)
+		}
+	}
+	return llm.ChatRequest{
+		Messages:    []llm.ChatMessage{llm.NewSystemMessage(preferenceDigestPrompt), llm.NewUserMessage(sb.String())},
+		Temperature: 0.2,
+	}
+}
+
+// handlePreferencesUpdated saves an updated digest.
+func (m *Model) handlePreferencesUpdated(msg preferencesUpdatedMsg) {
+	m.refreshingPreferences = false
+	if msg.err != nil {
+		m.statusText = fmt.Sprintf("Preference digest not updated: %v", msg.err)
+		return
+	}
+	if err := m.project.DB.SavePreferenceDigest(*msg.digest); err != nil {
+		m.statusText = fmt.Sprintf("Preference digest not saved: %v", err)
+		return
+	}
+	m.statusText = "Preference digest updated from your suggestion choices"
+}
+
+// handlePreferencesCommand handles /preferences [refresh|clear].
+func (m *Model) handlePreferencesCommand(args []string) tea.Cmd {
+	if m.project == nil || m.project.DB == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return nil
+	}
+
+	switch {
+	case len(args) == 0:
+		m.showPreferences()
+	case strings.EqualFold(args[0], "refresh"):
+		if m.refreshingPreferences {
+			m.statusText = "The preference digest is already being updated"
+			return nil
+		}
+		cmd := m.refreshPreferences(true)
+		if cmd == nil {
+			m.statusText = "No new suggestion choices to learn from"
+			return nil
+		}
+		m.statusText = "Updating the preference digest..."
+		return cmd
+	case strings.EqualFold(args[0], "clear"):
+		if err := m.project.DB.ClearSuggestionFeedback(); err != nil {
+			m.err = fmt.Errorf("failed to clear preferences: %w", err)
+			return nil
+		}
+		m.modifiedSuggestion = 0
+		m.statusText = "Suggestion choices and the preference digest cleared"
+	default:
+		m.err = fmt.Errorf("usage: /preferences [refresh|clear]")
+	}
+	return nil
+}
+
+// showPreferences shows the preference digest and the outcomes behind it.
+func (m *Model) showPreferences() {
+	counts, err := m.project.DB.CountSuggestionFeedback()
+	if err != nil {
+		m.err = fmt.Errorf("failed to load suggestion choices: %w", err)
+		return
+	}
+	digest, err := m.project.DB.GetPreferenceDigest()
+	if err != nil {
+		m.err = fmt.Errorf("failed to load the preference digest: %w", err)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Suggestion choices: %d accepted, %d rejected, %d modified.", counts[outcomeAccepted], counts[outcomeRejected], counts[outcomeModified]))
+	if digest == nil {
+		sb.WriteString(fmt.Sprintf("\n\nNo preference digest yet. It is written after %d plot or character suggestions are accepted, rejected or modified, and then sent with each request.", preferenceRefreshEvery))
+	} else {
+		sb.WriteString(fmt.Sprintf("\n\nPreference digest (updated %s), sent with each request:\n\n%s", digest.UpdatedAt.Format("2006-01-02 15:04"), digest.Text))
+	}
+	sb.WriteString("\n\n/preferences refresh updates it now; /preferences clear forgets the choices and the digest.")
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// buildPreferenceSection returns the preference digest for the system
+// prompt, or "" if there is none.
+func buildPreferenceSection(proj *project.Project) string {
+	if proj == nil || proj.DB == nil {
+		return ""
+	}
+	digest, err := proj.DB.GetPreferenceDigest()
+	if err != nil || digest == nil {
+		return ""
+	}
+	return preferenceDigestHeading + digest.Text
+}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	"github.com/azyu/dreamteller/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ProjectLister lists the projects /projects can switch to.
+type ProjectLister func() ([]*types.Project, error)
+
+// SetProjectSwitching lets /projects list the projects and switch to one.
+// Switching ends the program; NextProject names the project to open next.
+func (m *Model) SetProjectSwitching(list ProjectLister) {
+	m.projectLister = list
+}
+
+// NextProject returns the path of the project chosen with /projects, or ""
+// when the session ended without switching.
+func (m *Model) NextProject() string {
+	return m.nextProject
+}
+
+// handleProjectsCommand handles /projects [name]: without a name it opens
+// the project picker, with one it switches to that project.
+func (m *Model) handleProjectsCommand(args []string) (tea.Model, tea.Cmd) {
+	m.textarea.Reset()
+	if m.projectLister == nil {
+		m.err = fmt.Errorf("switching projects is not available in this session")
+		return m, nil
+	}
+	projects, err := m.projectLister()
+	if err != nil {
+		m.err = fmt.Errorf("failed to list projects: %w", err)
+		return m, nil
+	}
+	if len(projects) == 0 {
+		m.err = fmt.Errorf("no projects found")
+		return m, nil
+	}
+
+	if name := strings.Join(args, " "); name != "" {
+		for _, p := range projects {
+			if strings.EqualFold(p.Name, name) || strings.EqualFold(filepath.Base(p.Path), name) {
+				return m, m.switchProject(p)
+			}
+		}
+		m.err = fmt.Errorf("project %q not found", name)
+		return m, nil
+	}
+
+	m.availableProjects = projects
+	m.projectSelectIndex = 0
+	for i, p := range projects {
+		if m.isCurrentProject(p) {
+			m.projectSelectIndex = i
+			break
+		}
+	}
+	m.projectSelectMode = true
+	m.inputMode = false
+	m.statusText = "Select a project (↑/↓ to navigate, Enter to open, Esc to cancel)"
+	m.updateViewport()
+	return m, nil
+}
+
+// isCurrentProject reports whether p is the open project.
+func (m *Model) isCurrentProject(p *types.Project) bool {
+	if m.project == nil {
+		return false
+	}
+	current, err1 := filepath.Abs(m.project.Path())
+	other, err2 := filepath.Abs(p.Path)
+	return err1 == nil && err2 == nil && current == other
+}
+
+// switchProject ends the session the way /quit does, saving the draft and
+// closing the sprint and session, so the project can be closed and p
+// opened in its place.
+func (m *Model) switchProject(p *types.Project) tea.Cmd {
+	if m.isCurrentProject(p) {
+		m.statusText = fmt.Sprintf("Already in %s", p.Name)
+		return nil
+	}
+	path, err := filepath.Abs(p.Path)
+	if err != nil {
+		m.err = fmt.Errorf("failed to resolve %s: %w", p.Name, err)
+		return nil
+	}
+	m.nextProject = path
+	m.statusText = fmt.Sprintf("Opening %s...", p.Name)
+	return m.quit()
+}
+
+func (m *Model) handleProjectSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.closeProjectSelect()
+		m.statusText = ""
+		return m, nil
+
+	case tea.KeyEnter:
+		m.closeProjectSelect()
+		if m.projectSelectIndex < len(m.availableProjects) {
+			return m, m.switchProject(m.availableProjects[m.projectSelectIndex])
+		}
+		return m, nil
+
+	case tea.KeyUp:
+		if m.projectSelectIndex > 0 {
+			m.projectSelectIndex--
+			m.updateViewport()
+		}
+		return m, nil
+
+	case tea.KeyDown:
+		if m.projectSelectIndex < len(m.availableProjects)-1 {
+			m.projectSelectIndex++
+			m.updateViewport()
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m *Model) closeProjectSelect() {
+	m.projectSelectMode = false
+	m.inputMode = true
+	m.textarea.Focus()
+	m.updateViewport()
+}
+
+func (m *Model) renderProjectSelect() string {
+	var sb strings.Builder
+	sb.WriteString(styles.Title.Render("Switch Project"))
+	sb.WriteString("\n\n")
+
+	for i, p := range m.availableProjects {
+		prefix := "  "
+		style := styles.MutedText
+		if i == m.projectSelectIndex {
+			prefix = "> "
+			style = styles.SelectedItem
+		}
+		line := prefix + p.Name
+		if p.Genre != "" {
+			line += fmt.Sprintf(" (%s)", p.Genre)
+		}
+		if m.isCurrentProject(p) {
+			line += " (current)"
+		}
+		if !p.UpdatedAt.IsZero() {
+			line += "  " + p.UpdatedAt.Format("2006-01-02")
+		}
+		sb.WriteString(style.Render(line + "\n"))
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(styles.HelpDesc.Render("↑/↓ Navigate • Enter Open • Esc Cancel"))
+	return sb.String()
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refreshQuota reloads what the project spent this month, for the status
+// bar. Once per session, it warns when the spending reaches
+// project.QuotaWarnAt of the monthly budget.
+func (m *Model) refreshQuota() tea.Cmd {
+	if m.project == nil {
+		return nil
+	}
+	quota, err := m.project.Quota(time.Now())
+	if err != nil {
+		return nil
+	}
+	m.quota = quota
+	if quota.Level() == project.QuotaOK || m.quotaWarned {
+		return nil
+	}
+
+	m.quotaWarned = true
+	text := fmt.Sprintf("%d%% of this month's AI budget used: %s", int(quota.Used()*100), quota.Summary())
+	toast, cmd := showToast(text, ToastWarning, 8*time.Second)
+	m.toast = toast
+	return cmd
+}
+
+// quotaBlocked reports whether input is held back because the project
+// spent its monthly budget. The author confirms by sending it again, which
+// lets the rest of the session's messages through. The returned command
+// clears the budget warning, if refreshing the quota showed one.
+func (m *Model) quotaBlocked(input string) (bool, tea.Cmd) {
+	if m.quotaConfirmed {
+		return false, nil
+	}
+	cmd := m.refreshQuota()
+	if m.quota == nil || m.quota.Level() != project.QuotaExceeded {
+		return false, cmd
+	}
+	if m.quotaPending == input {
+		m.quotaConfirmed = true
+		m.quotaPending = ""
+		return false, cmd
+	}
+
+	// Keep the draft in the composer so it can be sent again.
+	m.quotaPending = input
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf(
+		"This project has spent its AI budget for %s: %s.\n\nPress Enter again to send anyway; the rest of this session will not ask again. The budget is set under quota in .dreamteller/config.yaml.",
+		m.quota.Month.Format("January"), m.quota.Summary())})
+	m.updateViewport()
+	return true, cmd
+}
+
+// quotaStatus renders the month's spending for the status bar, or "" when
+// the project has no budget.
+func (m *Model) quotaStatus() string {
+	if m.quota == nil || !m.quota.Limited() {
+		return ""
+	}
+	status := fmt.Sprintf("💰 %d%%", int(m.quota.Used()*100))
+	if m.quota.Level() == project.QuotaOK {
+		return styles.StatusBar.Render(status)
+	}
+	return styles.TokenWarning.Render(status)
+}
+
+// renderQuota renders the month's AI usage per model for the stats view.
+func renderQuota(q *project.QuotaStatus) string {
+	var sb strings.Builder
+	sb.WriteString(styles.Subtitle.Render("AI Usage"))
+	sb.WriteString(styles.MutedText.Render("  " + q.Month.Format("January 2006")))
+	sb.WriteString("\n")
+
+	switch {
+	case q.Limited():
+		percent := int(q.Used() * 100)
+		line := fmt.Sprintf("%s %s", progressBar(percent, eventProgressWidth), q.Summary())
+		switch q.Level() {
+		case project.QuotaExceeded:
+			line = styles.ErrorText.Render(line + " • over budget")
+		case project.QuotaWarning:
+			line = styles.TokenWarning.Render(line)
+		}
+		sb.WriteString(line)
+	default:
+		sb.WriteString(q.Summary() + styles.MutedText.Render(" • no monthly budget set"))
+	}
+
+	for _, u := range q.Models {
+		line := fmt.Sprintf("\n  %-24s %8s in  %8s out", u.Model, project.FormatTokens(u.PromptTokens), project.FormatTokens(u.CompletionTokens))
+		if u.Cost > 0 {
+			line += fmt.Sprintf("  $%.2f", u.Cost)
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refinePrompt asks the stronger model to revise the first draft it is
+// shown into the final reply.
+const refinePrompt = "The reply above is a first draft written by a smaller model. Revise it into the final reply: fix continuity errors and anything that contradicts the story context, strengthen the prose and dialogue, and follow the request and the project's style, point of view and tense. Reply with the revised text only."
+
+// refineModel returns the model configured as llm.refine_model to revise
+// each first draft, or nil when the draft-then-refine pipeline is off.
+func (m *Model) refineModel() *modelChoice {
+	if m.project == nil || m.project.Config == nil {
+		return nil
+	}
+	ref := strings.TrimSpace(m.project.Config.LLM.RefineModel)
+	if ref == "" {
+		return nil
+	}
+	choice := m.resolveModelOverride(ref)
+	return &choice
+}
+
+// refineDraft hands the reply just written to the refine model, which
+// revises it in the same turn. The draft stays visible above the revision
+// but is no longer sent to the model.
+func (m *Model) refineDraft(choice modelChoice) tea.Cmd {
+	_, draftModel := m.activeProvider()
+	if err := m.beginTurn(&choice); err != nil {
+		m.err = fmt.Errorf("draft kept as the reply: %w", err)
+		return nil
+	}
+
+	last := &m.messages[len(m.messages)-1]
+	last.Draft = true
+	if last.Model == "" {
+		last.Model = draftModel
+	}
+	m.markLastDraft(true)
+
+	m.refining = true
+	m.newReply = true
+	m.stream = nil
+	m.streamedContent = false
+	m.statusText = fmt.Sprintf("Refining the draft with %s...", m.turnModel)
+
+	return tea.Batch(m.spinner.Tick, m.startStreamWithFollowUp([]llm.ChatMessage{
+		llm.NewAssistantMessage(last.Content),
+		llm.NewUserMessage(refinePrompt + m.avoidWordsInstruction()),
+	}))
+}
+
+// abandonRefinement keeps the draft as the reply when the refine stage
+// ends without producing any text.
+func (m *Model) abandonRefinement() {
+	if !m.refining || !m.newReply || len(m.messages) == 0 {
+		return
+	}
+	m.restoreDraftReply()
+}
+
+// markLastDraft marks or unmarks the last saved message as a draft.
+func (m *Model) markLastDraft(draft bool) {
+	if m.project == nil || m.project.DB == nil || m.offRecord() {
+		return
+	}
+	_ = m.project.DB.SetLastConversationDraft(m.thread, draft)
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/search"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// indexRebuiltMsg reports a finished /reindex.
+type indexRebuiltMsg struct {
+	chunks int64
+	err    error
+}
+
+// SetIndexSync reports the files the search index caught up on when the
+// project was opened, or that it must be rebuilt.
+func (m *Model) SetIndexSync(result search.SyncResult) {
+	if result.Changed() || len(result.FormatChanges) > 0 || len(result.Skipped) > 0 {
+		m.statusText = indexSyncStatus(result)
+	}
+}
+
+// indexSyncStatus describes an index sync for the status line.
+func indexSyncStatus(result search.SyncResult) string {
+	if len(result.FormatChanges) > 0 {
+		return fmt.Sprintf("Search index was built with other settings (%s); /reindex rebuilds it",
+			strings.Join(result.FormatChanges, ", "))
+	}
+	var parts []string
+	if n := len(result.Reindexed); n > 0 {
+		parts = append(parts, fmt.Sprintf("reindexed %d file(s) changed", n))
+	}
+	if n := len(result.Removed); n > 0 {
+		parts = append(parts, fmt.Sprintf("dropped %d removed or excluded file(s)", n))
+	}
+	status := "Search index: " + strings.Join(parts, ", ") + " since the last session"
+	if len(parts) == 0 {
+		status = "Search index"
+	}
+	if n := len(result.Skipped); n > 0 {
+		status += fmt.Sprintf("; could not read %d research file(s), such as %s (PDFs need pdftotext)", n, result.Skipped[0])
+	}
+	return status
+}
+
+// rebuildIndex rebuilds the search index in the background.
+func (m *Model) rebuildIndex() tea.Cmd {
+	if m.searchEngine == nil || m.project == nil || m.project.DB == nil {
+		m.statusText = "No search index to rebuild"
+		return nil
+	}
+	if m.reindexing {
+		return nil
+	}
+	m.reindexing = true
+	m.statusText = "Reindexing..."
+
+	proj := m.project
+	return func() tea.Msg {
+		chunks, err := proj.RebuildIndex()
+		return indexRebuiltMsg{chunks: chunks, err: err}
+	}
+}
+
+// handleIndexRebuilt reports a finished /reindex.
+func (m *Model) handleIndexRebuilt(msg indexRebuiltMsg) {
+	m.reindexing = false
+	if msg.err != nil {
+		m.statusText = ""
+		m.err = msg.err
+		return
+	}
+	m.statusText = fmt.Sprintf("Reindex complete. Indexed %d chunks.", msg.chunks)
+}
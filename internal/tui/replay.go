@@ -0,0 +1,217 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// replayListLimit caps how many recorded requests /replay lists.
+const replayListLimit = 10
+
+// withSeed gives a request a random sampling seed when the provider takes
+// one, so that replaying it with the same seed can reproduce the reply.
+func withSeed(req *llm.ChatRequest, provider llm.Provider) {
+	if !provider.Capabilities().SupportsSeed {
+		req.Seed = nil
+		return
+	}
+	seed := int(rand.Int31())
+	req.Seed = &seed
+}
+
+// recordGeneration saves the manifest of a request about to be sent: the
+// provider, model, seed and retrieved chunks with the request itself.
+// Replays and off-record chats are not recorded.
+func (m *Model) recordGeneration(msg requestAssembledMsg) {
+	m.turnGeneration = 0
+	m.generationReply = ""
+	if m.project == nil || m.project.DB == nil || m.offRecord() || m.replayOf != 0 {
+		return
+	}
+
+	request, err := json.Marshal(msg.request)
+	if err != nil {
+		return
+	}
+	_, model := m.activeProvider()
+	record := storage.GenerationRecord{
+		Provider: m.activeProviderName(),
+		Model:    model,
+		Request:  string(request),
+	}
+	if msg.request.Seed != nil {
+		seed := int64(*msg.request.Seed)
+		record.Seed = &seed
+	}
+	for _, chunk := range msg.retrieval {
+		record.ChunkIDs = append(record.ChunkIDs, chunk.ID)
+	}
+
+	if id, err := m.project.DB.SaveGeneration(record); err == nil {
+		m.turnGeneration = id
+	}
+}
+
+// finishGeneration records the text generated for the turn's request.
+func (m *Model) finishGeneration() {
+	if m.turnGeneration != 0 && m.project != nil && m.project.DB != nil {
+		_ = m.project.DB.SetGenerationReply(m.turnGeneration, m.generationReply)
+	}
+	m.turnGeneration = 0
+	m.generationReply = ""
+}
+
+// replayGeneration handles /replay. Without arguments it lists the recent
+// requests; with an ID it sends that request again exactly as recorded,
+// seed included, to the model that answered it or to the one named with
+// @model. The reply is shown next to the original but not saved or sent to
+// the model later.
+func (m *Model) replayGeneration(args []string) (tea.Model, tea.Cmd) {
+	if m.project == nil || m.project.DB == nil {
+		m.err = fmt.Errorf("no project database")
+		return m, nil
+	}
+	if len(args) == 0 {
+		m.listGenerations()
+		return m, nil
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil || id <= 0 || len(args) > 2 {
+		m.err = fmt.Errorf("usage: /replay [<id> [@model]]")
+		return m, nil
+	}
+	record, err := m.project.DB.GetGeneration(id)
+	if err != nil {
+		m.err = fmt.Errorf("failed to load request #%d: %w", id, err)
+		return m, nil
+	}
+	if record == nil {
+		m.err = fmt.Errorf("no recorded request #%d; /replay lists them", id)
+		return m, nil
+	}
+	var req llm.ChatRequest
+	if err := json.Unmarshal([]byte(record.Request), &req); err != nil {
+		m.err = fmt.Errorf("failed to read request #%d: %w", id, err)
+		return m, nil
+	}
+
+	choice := modelChoice{Provider: record.Provider, Model: record.Model}
+	if len(args) == 2 {
+		ref, ok := strings.CutPrefix(args[1], "@")
+		if !ok || ref == "" {
+			m.err = fmt.Errorf("usage: /replay [<id> [@model]]")
+			return m, nil
+		}
+		choice = m.resolveModelOverride(ref)
+	}
+	if err := m.beginTurn(&choice); err != nil {
+		m.err = err
+		return m, nil
+	}
+	provider, model := m.activeProvider()
+	if provider == nil {
+		m.err = fmt.Errorf("no LLM provider configured")
+		return m, nil
+	}
+
+	note := fmt.Sprintf("Replaying request #%d on %s", id, model)
+	if req.Seed != nil && !provider.Capabilities().SupportsSeed {
+		req.Seed = nil
+		note += " (the provider takes no seed, so the reply may differ)"
+	} else if req.Seed != nil {
+		note += fmt.Sprintf(" with seed %d", *req.Seed)
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: note + "..."})
+	m.updateViewport()
+
+	if m.streamController != nil {
+		m.streamController.Cancel()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.streamConfig.Timeout)
+	m.streamController = &StreamController{ctx: ctx, cancel: cancel, config: m.streamConfig}
+	m.streamedContent = false
+	m.newReply = true
+	m.replayOf = id
+	m.streaming = true
+	m.inputMode = false
+
+	msg := requestAssembledMsg{ctx: ctx, provider: provider, request: req}
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg { return msg })
+}
+
+// listGenerations shows the most recent recorded requests for /replay.
+func (m *Model) listGenerations() {
+	records, err := m.project.DB.ListGenerations(replayListLimit)
+	if err != nil {
+		m.err = fmt.Errorf("failed to list requests: %w", err)
+		return
+	}
+	if len(records) == 0 {
+		m.messages = append(m.messages, Message{Role: "system", Content: "No requests recorded yet."})
+		m.updateViewport()
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Recent requests:")
+	for _, r := range records {
+		seed := "no seed"
+		if r.Seed != nil {
+			seed = fmt.Sprintf("seed %d", *r.Seed)
+		}
+		sb.WriteString(fmt.Sprintf("\n#%d  %s  %s/%s, %s, %d context chunks", r.ID, r.CreatedAt.Format("01-02 15:04"), r.Provider, r.Model, seed, len(r.ChunkIDs)))
+		if r.Reply != "" {
+			sb.WriteString("\n    " + truncateContent(r.Reply, 100))
+		}
+	}
+	sb.WriteString("\n\nUse /replay <id> to send a request again, or /replay <id> @model to try another model.")
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// finishReplay ends a replayed stream. Tool calls are listed rather than
+// run, and the reply is compared with the one recorded for the request.
+func (m *Model) finishReplay() (tea.Model, tea.Cmd) {
+	id := m.replayOf
+	m.replayOf = 0
+	m.stream = nil
+	m.streamedContent = false
+	m.newReply = false
+
+	var reply string
+	if last := len(m.messages) - 1; last >= 0 && m.messages[last].Replay == id {
+		reply = m.messages[last].Content
+	}
+
+	var sb strings.Builder
+	for _, call := range m.toolCallAccumulator.GetCompletedCalls() {
+		sb.WriteString(fmt.Sprintf("The replay called %s %s; replayed tool calls are not run.\n", call.Function.Name, call.Function.Arguments))
+	}
+	m.toolCallAccumulator.Reset()
+
+	record, err := m.project.DB.GetGeneration(id)
+	switch {
+	case err != nil || record == nil:
+		sb.WriteString(fmt.Sprintf("Replay of #%d done.", id))
+	case record.Reply == "":
+		sb.WriteString(fmt.Sprintf("Replay of #%d done. No reply was recorded for the original request.", id))
+	case record.Reply == reply:
+		sb.WriteString(fmt.Sprintf("Replay of #%d matches the original reply exactly.", id))
+	default:
+		sb.WriteString(fmt.Sprintf("Replay of #%d differs from the original reply by %s/%s:\n\n%s", id, record.Provider, record.Model, record.Reply))
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+	return m, func() tea.Msg { return StreamDoneMsg{} }
+}
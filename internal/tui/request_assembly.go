@@ -6,12 +6,14 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/azyu/dreamteller/internal/llm"
 	"github.com/azyu/dreamteller/internal/project"
 	"github.com/azyu/dreamteller/internal/search"
 	"github.com/azyu/dreamteller/internal/token"
 	"github.com/azyu/dreamteller/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 const (
@@ -24,11 +26,62 @@ const (
 	defaultKnownTokenizerSafetyMargin   = 0.07
 )
 
+// tokenCounters holds a memoizing counter per encoding, shared by every
+// request, so the chat history is tokenized once rather than per request.
+var tokenCounters = struct {
+	sync.Mutex
+	byEncoding map[string]*token.CachedCounter
+}{byEncoding: make(map[string]*token.CachedCounter)}
+
+// sharedTokenCounter returns the memoizing counter for an encoding.
+func sharedTokenCounter(encoding string) (*token.CachedCounter, error) {
+	tokenCounters.Lock()
+	defer tokenCounters.Unlock()
+	if counter, ok := tokenCounters.byEncoding[encoding]; ok {
+		return counter, nil
+	}
+	counter, err := token.NewCounter(encoding)
+	if err != nil {
+		return nil, err
+	}
+	cached := token.NewCachedCounter(counter)
+	tokenCounters.byEncoding[encoding] = cached
+	return cached, nil
+}
+
+// projectToolPolicy returns the tools a project lets the AI call.
+func projectToolPolicy(proj *project.Project) (*llm.ToolPolicy, error) {
+	if proj == nil || proj.Config == nil {
+		return nil, nil
+	}
+	policy, err := llm.NewToolPolicy(proj.Config.Tools)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tools setting in config.yaml: %w", err)
+	}
+	return policy, nil
+}
+
+// refuseDisallowedTool ends a turn whose tool call the project's tool
+// permissions do not allow. The tool was not offered, so the model should
+// not have called it.
+func (m *Model) refuseDisallowedTool(call llm.ToolCall) (tea.Model, tea.Cmd) {
+	m.streaming = false
+	m.inputMode = true
+	m.textarea.Focus()
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf(
+		"The project's tool permissions do not allow %s: ignored the model's request.", call.Function.Name)})
+	m.updateViewport()
+	return m, nil
+}
+
 var errUserMessageTooLarge = errors.New("user message too large to fit within history budget")
 
 type assembledRequest struct {
 	Request llm.ChatRequest
 
+	// Retrieval lists the chunks injected as retrieval context and why.
+	Retrieval []RankedChunk
+
 	// Debug fields used by tests.
 	SystemPrompt string
 	Budget       token.BudgetAllocation
@@ -48,10 +101,6 @@ func newAssemblyEnv(proj *project.Project, provider llm.Provider, modelName stri
 	}
 
 	caps := provider.Capabilities()
-	maxContext := caps.MaxContextTokens
-	if maxContext <= 0 {
-		maxContext = token.DefaultContextLimit
-	}
 
 	encoding := caps.TokenizerType
 	safetyMargin := defaultKnownTokenizerSafetyMargin
@@ -60,26 +109,21 @@ func newAssemblyEnv(proj *project.Project, provider llm.Provider, modelName stri
 		safetyMargin = defaultUnknownTokenizerSafetyMargin
 	}
 
-	counter, err := token.NewCounter(encoding)
+	counter, err := sharedTokenCounter(encoding)
 	if err != nil {
 		counter = nil
 		safetyMargin = defaultUnknownTokenizerSafetyMargin
 	}
 
-	maxForBudget := int(float64(maxContext) * (1.0 - safetyMargin))
-	if maxForBudget <= 0 {
-		maxForBudget = maxContext
-	}
+	maxForBudget := budgetTokens(caps, safetyMargin)
+	ratios := budgetRatios(proj)
 
-	ratios := token.DefaultBudgetRatios
 	contextCfg := types.ContextConfig{MaxChunks: 10}
 	if proj != nil && proj.Config != nil {
-		if token.ValidateRatios(proj.Config.Budget) {
-			ratios = proj.Config.Budget
-		}
 		if proj.Config.Context.MaxChunks > 0 {
 			contextCfg.MaxChunks = proj.Config.Context.MaxChunks
 		}
+		contextCfg.MaxChunksPerSource = proj.Config.Context.MaxChunksPerSource
 	}
 
 	bm := token.NewBudgetManagerWithConfig(modelName, maxForBudget, ratios)
@@ -102,6 +146,30 @@ func newAssemblyEnv(proj *project.Project, provider llm.Provider, modelName stri
 	}, nil
 }
 
+// budgetTokens returns the part of the provider's context window that is
+// budgeted, after the tokenizer safety margin.
+func budgetTokens(caps llm.Capabilities, safetyMargin float64) int {
+	maxContext := caps.MaxContextTokens
+	if maxContext <= 0 {
+		maxContext = token.DefaultContextLimit
+	}
+
+	maxForBudget := int(float64(maxContext) * (1.0 - safetyMargin))
+	if maxForBudget <= 0 {
+		maxForBudget = maxContext
+	}
+	return maxForBudget
+}
+
+// budgetRatios returns the project's budget ratios, or the defaults when the
+// project has none or they are invalid.
+func budgetRatios(proj *project.Project) types.BudgetConfig {
+	if proj != nil && proj.Config != nil && token.ValidateRatios(proj.Config.Budget) {
+		return proj.Config.Budget
+	}
+	return token.DefaultBudgetRatios
+}
+
 func assembleChatRequest(
 	proj *project.Project,
 	provider llm.Provider,
@@ -119,15 +187,45 @@ func assembleChatRequest(
 	if userMsg == nil {
 		return assembledRequest{}, fmt.Errorf("no user message to send")
 	}
+	policy, err := projectToolPolicy(proj)
+	if err != nil {
+		return assembledRequest{}, err
+	}
+	tools := policy.Filter(llm.ChatTools())
+
+	// Providers without native function calling get the tool catalog as
+	// prose and reply with JSON-in-text, parsed by llm.ExtractTextToolCalls.
+	// The catalog is carved out of the system prompt budget.
+	var textTools string
+	systemBudget := env.budget.SystemPrompt
+	if !env.caps.SupportsTools && len(tools) > 0 {
+		textTools = llm.TextToolInstructions(tools)
+		if systemBudget > 0 {
+			systemBudget -= env.tokenizer.Count(textTools)
+			if systemBudget <= 0 {
+				// Not enough room for both; keep the story context.
+				textTools = ""
+				systemBudget = env.budget.SystemPrompt
+			}
+		}
+	}
 
 	// System prompt: role + canonical facts (Korean) + project info/style + mode context.
-	systemPrompt := buildBudgetedSystemPrompt(proj, contextMode, env.tokenizer, env.budget.SystemPrompt)
+	systemPrompt := buildBudgetedSystemPrompt(proj, contextMode, userMsg.Content, env.tokenizer, systemBudget)
+	if textTools != "" {
+		systemPrompt += "\n\n" + textTools
+	}
 
 	chatMessages := []llm.ChatMessage{llm.NewSystemMessage(systemPrompt)}
 
 	// Hybrid: retrieval injection goes into middle as a NON-system message.
+	// Attached text is left out of the query so it does not drown out what
+	// the user asked.
+	var ranked []RankedChunk
 	if contextMode == ContextHybrid {
-		if retrieval := buildBudgetedRetrievalMessage(searchEngine, env.cm, env.tokenizer, env.budget.Context, userMsg.Content); retrieval != nil {
+		var retrieval *llm.ChatMessage
+		retrieval, ranked = buildBudgetedRetrievalMessage(proj, searchEngine, linkGraph(proj), env.cm, env.tokenizer, env.budget.Context, stripAttachments(userMsg.Content))
+		if retrieval != nil {
 			chatMessages = append(chatMessages, *retrieval)
 		}
 	}
@@ -139,7 +237,7 @@ func assembleChatRequest(
 		summary, remaining := env.cm.SummarizeHistory(historyMsgs, defaultRecentMessagesToKeep)
 		summary = strings.TrimSpace(summary)
 		if summary != "" {
-			summaryContent := "이전 대화 요약:\n" + summary
+			summaryContent := historySummaryHeading + summary
 			chatMessages = append(chatMessages, llm.NewAssistantMessage(summaryContent))
 		}
 		historyMsgs = remaining
@@ -161,13 +259,19 @@ func assembleChatRequest(
 		maxOut = 1024
 	}
 
+	req := llm.ChatRequest{
+		Messages:    chatMessages,
+		MaxTokens:   maxOut,
+		Temperature: 0.7,
+	}
+
+	if env.caps.SupportsTools && len(tools) > 0 {
+		req.Tools = tools
+	}
+
 	return assembledRequest{
-		Request: llm.ChatRequest{
-			Messages:    chatMessages,
-			MaxTokens:   maxOut,
-			Temperature: 0.7,
-			Tools:       llm.PredefinedTools(),
-		},
+		Request:      req,
+		Retrieval:    ranked,
 		SystemPrompt: systemPrompt,
 		Budget:       env.budget,
 	}, nil
@@ -195,7 +299,7 @@ func splitCurrentUserMessage(messages []Message) (user *Message, history []Messa
 	return &m, append([]Message{}, messages[:len(messages)-1]...)
 }
 
-func buildBudgetedSystemPrompt(proj *project.Project, mode ContextMode, tokenizer llm.TokenCounter, systemBudget int) string {
+func buildBudgetedSystemPrompt(proj *project.Project, mode ContextMode, userInput string, tokenizer llm.TokenCounter, systemBudget int) string {
 	// NOTE: We intentionally put canonical facts BEFORE the general role prompt.
 	// The default role prompt is long, and for small budgets it can crowd out
 	// the facts. Putting facts first ensures they survive truncation.
@@ -203,14 +307,54 @@ func buildBudgetedSystemPrompt(proj *project.Project, mode ContextMode, tokenize
 	if facts := buildCanonicalFactsKorean(proj); facts != "" {
 		parts = append(parts, facts)
 	}
+
+	// Remembered facts get a small fixed share so they are never crowded out.
+	memoryBudget := maxMemoryTokens
+	if systemBudget > 0 && systemBudget/4 < memoryBudget {
+		memoryBudget = systemBudget / 4
+	}
+	if memories := buildMemorySection(proj, tokenizer, memoryBudget); memories != "" {
+		parts = append(parts, memories)
+	}
+
+	// The rulebook's hard rules, the ones the user's input touches first.
+	ruleBudget := maxRuleTokens
+	if systemBudget > 0 && systemBudget/8 < ruleBudget {
+		ruleBudget = systemBudget / 8
+	}
+	if rules := buildRuleSection(proj, userInput, tokenizer, ruleBudget); rules != "" {
+		parts = append(parts, rules)
+	}
+
+	// Voices of characters the user mentions keep generated dialogue in character.
+	voiceBudget := maxVoiceTokens
+	if systemBudget > 0 && systemBudget/4 < voiceBudget {
+		voiceBudget = systemBudget / 4
+	}
+	if voices := buildVoiceSection(proj, userInput, tokenizer, voiceBudget); voices != "" {
+		parts = append(parts, voices)
+	}
+
+	// Glossary entries for terms the user mentions keep invented words spelled consistently.
+	glossaryBudget := maxGlossaryTokens
+	if systemBudget > 0 && systemBudget/8 < glossaryBudget {
+		glossaryBudget = systemBudget / 8
+	}
+	if glossary := buildGlossarySection(proj, userInput, tokenizer, glossaryBudget); glossary != "" {
+		parts = append(parts, glossary)
+	}
+
+	// What the author's suggestion choices say about their taste.
+	if preferences := buildPreferenceSection(proj); preferences != "" {
+		parts = append(parts, preferences)
+	}
+
 	parts = append(parts, llm.DefaultNovelWritingPrompt())
+	parts = append(parts, llm.ContextDataInstruction)
 
 	if proj != nil && proj.Info != nil {
 		parts = append(parts, fmt.Sprintf("You are helping write a %s novel titled \"%s\".", proj.Config.Genre, proj.Info.Name))
-		parts = append(parts, fmt.Sprintf(`Writing Guidelines:
-- Style: %s
-- Point of View: %s
-- Tense: %s`, proj.Config.Writing.Style, proj.Config.Writing.POV, proj.Config.Writing.Tense))
+		parts = append(parts, llm.WritingGuidelines(proj.Config.Writing))
 	}
 
 	// Mode-specific static context remains in system prompt.
@@ -235,35 +379,56 @@ func buildBudgetedSystemPrompt(proj *project.Project, mode ContextMode, tokenize
 	return truncateToTokens(tokenizer, prompt, systemBudget, false)
 }
 
+// buildBudgetedRetrievalMessage searches the index for the user's input and
+// returns the chunks that fit the context budget as a message, with a
+// record of why each was chosen.
 func buildBudgetedRetrievalMessage(
+	proj *project.Project,
 	searchEngine *search.FTSEngine,
+	graph *project.LinkGraph,
 	cm *llm.ContextManager,
 	tokenizer llm.TokenCounter,
 	contextBudget int,
 	userInput string,
-) *llm.ChatMessage {
+) (*llm.ChatMessage, []RankedChunk) {
 	if searchEngine == nil || userInput == "" || contextBudget <= 0 {
-		return nil
+		return nil, nil
 	}
 
 	results, err := searchEngine.Search(userInput, defaultSearchCandidateLimit)
-	if err != nil || len(results) == 0 {
-		return nil
+	if err != nil {
+		return nil, nil
+	}
+	results = proj.AutoContext(results)
+	if len(results) == 0 {
+		return nil, nil
 	}
 
+	bm25 := make(map[int64]float64, len(results))
+	for _, r := range results {
+		bm25[r.ID] = r.Score
+	}
+	distances := boostLinkedResults(results, graph, userInput)
+
 	// Search returns results ordered by score (bm25), lower is better.
 	sort.SliceStable(results, func(i, j int) bool {
 		return results[i].Score < results[j].Score
 	})
+	ranks := make(map[int64]int, len(results))
+	for i, r := range results {
+		ranks[r.ID] = i + 1
+	}
 
 	chunks := make([]llm.ContextChunk, 0, len(results))
 	for _, r := range results {
 		chunks = append(chunks, llm.ContextChunk{
+			ID:         r.ID,
 			Content:    r.Content,
 			SourceType: r.SourceType,
 			SourcePath: r.SourcePath,
 			Score:      r.Score,
 			Tokens:     r.TokenCount,
+			Section:    r.Section,
 		})
 	}
 
@@ -275,19 +440,88 @@ func buildBudgetedRetrievalMessage(
 
 	selected := cm.SelectChunks(chunks, usableBudget)
 	if len(selected) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	ctx := cm.BuildContextPrompt(selected)
 	ctx = strings.TrimSpace(ctx)
 	if ctx == "" {
-		return nil
+		return nil, nil
+	}
+
+	ranked := make([]RankedChunk, len(selected))
+	for i, c := range selected {
+		distance, boosted := distances[c.SourcePath]
+		if !boosted {
+			distance = -1
+		}
+		ranked[i] = RankedChunk{
+			ID:           c.ID,
+			SourceType:   c.SourceType,
+			SourcePath:   c.SourcePath,
+			Section:      c.Section,
+			Tokens:       c.Tokens,
+			BM25:         bm25[c.ID],
+			LinkDistance: distance,
+			Score:        c.Score,
+			Pinned:       c.SourceType == search.SourceTypeResearch && proj.IsPinned(c.SourcePath),
+			Rank:         ranks[c.ID],
+			Candidates:   len(results),
+		}
 	}
 
 	content := "참고 컨텍스트(검색 결과):\n" + ctx
 	content = truncateToTokens(tokenizer, content, contextBudget, false)
 	m := llm.NewAssistantMessage(content)
-	return &m
+	return &m, ranked
+}
+
+// linkProximityBoosts scale the bm25 score of results by how many [[links]]
+// separate their file from the files the request is about.
+var linkProximityBoosts = []float64{1.5, 1.25, 1.1}
+
+// linkGraph returns the project's link graph, or nil if it cannot be read.
+func linkGraph(proj *project.Project) *project.LinkGraph {
+	if proj == nil {
+		return nil
+	}
+	graph, err := proj.LinkGraph()
+	if err != nil {
+		return nil
+	}
+	return graph
+}
+
+// boostLinkedResults ranks results from files close in the link graph to
+// the context files named in the query higher. Without a named file, the
+// best match stands in for it. It returns the link distance of each file it
+// boosted.
+func boostLinkedResults(results []search.FTSSearchResult, graph *project.LinkGraph, query string) map[string]int {
+	if graph == nil || len(results) == 0 {
+		return nil
+	}
+
+	seeds := graph.Mentioned(query)
+	if len(seeds) == 0 {
+		best := 0
+		for i, r := range results {
+			if r.Score < results[best].Score {
+				best = i
+			}
+		}
+		seeds = []string{results[best].SourcePath}
+	}
+
+	dist := graph.Distances(seeds, len(linkProximityBoosts)-1)
+	boosted := make(map[string]int)
+	for i, r := range results {
+		// bm25 scores are negative; scaling one up makes it rank higher.
+		if d, ok := dist[r.SourcePath]; ok && r.Score < 0 {
+			results[i].Score *= linkProximityBoosts[d]
+			boosted[r.SourcePath] = d
+		}
+	}
+	return boosted
 }
 
 func needsHistoryCompression(tokenizer llm.TokenCounter, history []llm.ChatMessage, currentUser string, historyBudget int) bool {
@@ -358,6 +592,11 @@ func convertTUIMessagesToLLM(msgs []Message) []llm.ChatMessage {
 	}
 	out := make([]llm.ChatMessage, 0, len(msgs))
 	for _, m := range msgs {
+		if m.Draft || m.Replay != 0 || m.Critique {
+			// Superseded by the refined reply that follows it, a replay
+			// shown for comparison, or a self-critique of a draft.
+			continue
+		}
 		switch m.Role {
 		case llm.RoleUser:
 			out = append(out, llm.NewUserMessage(m.Content))
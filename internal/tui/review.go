@@ -0,0 +1,298 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reviewTimeout bounds a reviewer persona's critique of a chapter.
+const reviewTimeout = 3 * time.Minute
+
+// reviewFormat asks the persona for its critique as JSON.
+const reviewFormat = `
+
+Reply with JSON only: {"summary": "your overall impression in two or three sentences", "notes": [{"quote": "the exact passage, or empty for the whole chapter", "category": "a short label, e.g. grammar or pacing", "severity": "minor, moderate or major", "comment": "what is wrong or what works", "suggestion": "how to fix it, or empty"}]}. Write in the chapter's language.`
+
+// reviewNote is one note of a persona's critique.
+type reviewNote struct {
+	Quote      string `json:"quote"`
+	Category   string `json:"category"`
+	Severity   string `json:"severity"`
+	Comment    string `json:"comment"`
+	Suggestion string `json:"suggestion"`
+}
+
+// reviewDoneMsg carries a persona's critique of a chapter.
+type reviewDoneMsg struct {
+	reviewer string
+	chapter  int
+	summary  string
+	notes    []reviewNote
+	err      error
+}
+
+// handleReviewCommand handles /review [<persona> [chapter] | notes
+// [chapter] | export [chapter] [md|csv]]. Without arguments it lists the
+// personas.
+func (m *Model) handleReviewCommand(args []string) tea.Cmd {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return nil
+	}
+	if len(args) == 0 {
+		m.showReviewers()
+		return nil
+	}
+	if strings.ToLower(args[0]) == "export" {
+		m.exportFeedback(args[1:])
+		return nil
+	}
+	if len(args) > 2 {
+		m.err = fmt.Errorf("usage: /review [<persona> [chapter] | notes [chapter] | export [chapter] [md|csv]]")
+		return nil
+	}
+
+	if strings.ToLower(args[0]) == "notes" {
+		chapter := 0
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				m.err = fmt.Errorf("invalid chapter: %s", args[1])
+				return nil
+			}
+			chapter = n
+		}
+		m.showAnnotations(chapter)
+		return nil
+	}
+
+	reviewer, err := m.project.FindReviewer(args[0])
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	chapter, err := m.chapterOrLatest(args[1:])
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if m.offline {
+		m.showOfflineNotice()
+		return nil
+	}
+	if m.aiLocked() {
+		return nil
+	}
+	return m.reviewChapter(reviewer, chapter)
+}
+
+// showReviewers lists the reviewer personas.
+func (m *Model) showReviewers() {
+	var sb strings.Builder
+	sb.WriteString("Reviewer personas:\n")
+	for _, r := range m.project.Reviewers() {
+		sb.WriteString("\n" + r.Name)
+		if r.Description != "" {
+			sb.WriteString(" — " + r.Description)
+		}
+	}
+	sb.WriteString("\n\nAsk one to critique a chapter with /review <persona> [chapter], list the feedback with /review notes [chapter], and export it with lint findings for an editing pass with /review export [chapter] [md|csv]. Add personas under reviewers: in .dreamteller/config.yaml.")
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// reviewChapter asks a reviewer persona to critique a chapter.
+func (m *Model) reviewChapter(reviewer types.ReviewerConfig, number int) tea.Cmd {
+	provider, model := m.activeProvider()
+	if provider == nil {
+		m.err = fmt.Errorf("no AI provider configured")
+		return nil
+	}
+	chapter, err := m.project.FindChapter(number)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+
+	m.statusText = fmt.Sprintf("%s is reviewing chapter %d with %s...", reviewer.Name, number, model)
+	req := llm.ChatRequest{
+		Messages: []llm.ChatMessage{
+			llm.NewSystemMessage(reviewer.Prompt + reviewFormat),
+			llm.NewUserMessage(fmt.Sprintf("Chapter %d:\n\n%s", chapter.Number, chapter.Content)),
+		},
+		Temperature: 0.3,
+		JSONMode:    true,
+	}
+	name := reviewer.Name
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), reviewTimeout)
+		defer cancel()
+		resp, err := provider.Chat(ctx, req)
+		if err != nil {
+			return reviewDoneMsg{reviewer: name, chapter: number, err: err}
+		}
+		summary, notes, err := parseReview(resp.Message.Content)
+		return reviewDoneMsg{reviewer: name, chapter: number, summary: summary, notes: notes, err: err}
+	}
+}
+
+// parseReview reads a persona's JSON critique, which may be wrapped in a
+// code fence or prose.
+func parseReview(content string) (string, []reviewNote, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return "", nil, fmt.Errorf("the model did not reply with JSON")
+	}
+	var reply struct {
+		Summary string       `json:"summary"`
+		Notes   []reviewNote `json:"notes"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &reply); err != nil {
+		return "", nil, fmt.Errorf("invalid review reply: %w", err)
+	}
+	return strings.TrimSpace(reply.Summary), reply.Notes, nil
+}
+
+// handleReviewDone stores a critique as annotations on the chapter,
+// replacing the persona's earlier ones, and shows it.
+func (m *Model) handleReviewDone(msg reviewDoneMsg) {
+	m.statusText = ""
+	if msg.err != nil {
+		m.err = fmt.Errorf("review failed: %w", msg.err)
+		return
+	}
+	if m.project == nil || m.project.DB == nil {
+		return
+	}
+
+	if err := m.project.DB.DeleteAnnotations(msg.chapter, msg.reviewer); err != nil {
+		m.err = fmt.Errorf("failed to replace earlier feedback: %w", err)
+		return
+	}
+	records := make([]storage.AnnotationRecord, 0, len(msg.notes)+1)
+	if msg.summary != "" {
+		records = append(records, storage.AnnotationRecord{Category: "summary", Comment: msg.summary})
+	}
+	notes := 0
+	for _, note := range msg.notes {
+		if strings.TrimSpace(note.Comment) == "" {
+			continue
+		}
+		notes++
+		records = append(records, storage.AnnotationRecord{
+			Category:   strings.TrimSpace(note.Category),
+			Severity:   strings.ToLower(strings.TrimSpace(note.Severity)),
+			Quote:      strings.TrimSpace(note.Quote),
+			Comment:    strings.TrimSpace(note.Comment),
+			Suggestion: strings.TrimSpace(note.Suggestion),
+		})
+	}
+	for _, r := range records {
+		r.Chapter = msg.chapter
+		r.Source = msg.reviewer
+		if _, err := m.project.DB.SaveAnnotation(r); err != nil {
+			m.err = fmt.Errorf("failed to save feedback: %w", err)
+			return
+		}
+	}
+
+	m.statusText = fmt.Sprintf("%s left %d note(s) on chapter %d", msg.reviewer, notes, msg.chapter)
+	annotations, err := m.project.DB.ListAnnotations(msg.chapter)
+	if err != nil {
+		m.err = err
+		return
+	}
+	var own []storage.AnnotationRecord
+	for _, a := range annotations {
+		if a.Source == msg.reviewer {
+			own = append(own, a)
+		}
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: formatAnnotations(own)})
+	m.updateViewport()
+}
+
+// showAnnotations lists the feedback on a chapter, or on every chapter
+// when chapter is zero.
+func (m *Model) showAnnotations(chapter int) {
+	if m.project.DB == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	annotations, err := m.project.DB.ListAnnotations(chapter)
+	if err != nil {
+		m.err = fmt.Errorf("failed to load feedback: %w", err)
+		return
+	}
+	content := formatAnnotations(annotations)
+	if len(annotations) == 0 {
+		content = "No feedback yet. Ask a reviewer persona with /review <persona> [chapter]."
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	m.updateViewport()
+}
+
+// exportFeedback writes the annotations, lint findings and glossary
+// misspellings of a chapter, or of the whole book, to one document.
+func (m *Model) exportFeedback(args []string) {
+	chapter, format := 0, "md"
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil {
+			chapter = n
+			continue
+		}
+		format = arg
+	}
+	path, count, err := m.project.ExportFeedback(chapter, format)
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.statusText = fmt.Sprintf("Exported %d feedback item(s) to %s", count, filepath.ToSlash(path))
+}
+
+// formatAnnotations renders feedback grouped by chapter and source, with
+// each source's summary first.
+func formatAnnotations(annotations []storage.AnnotationRecord) string {
+	var sections []string
+	for i := 0; i < len(annotations); {
+		chapter, source := annotations[i].Chapter, annotations[i].Source
+		lines := []string{fmt.Sprintf("Chapter %d — %s:", chapter, source)}
+		for ; i < len(annotations) && annotations[i].Chapter == chapter && annotations[i].Source == source; i++ {
+			a := annotations[i]
+			if a.Category == "summary" && a.Quote == "" {
+				lines = append(lines, a.Comment)
+				continue
+			}
+			label := a.Category
+			if a.Severity != "" {
+				label = strings.TrimSpace(a.Severity + " " + label)
+			}
+			line := "- "
+			if label != "" {
+				line += "[" + label + "] "
+			}
+			if a.Quote != "" {
+				line += fmt.Sprintf("%q: ", truncateString(a.Quote, 80))
+			}
+			line += a.Comment
+			if a.Suggestion != "" {
+				line += " → " + a.Suggestion
+			}
+			lines = append(lines, line)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+)
+
+// maxRuleTokens caps how much of the system prompt the rulebook may use.
+const maxRuleTokens = 400
+
+// ruleRelevance counts the words of a rule and its system's name that
+// appear in text. Short words are skipped, except in Hangul, where two
+// syllables already make a word.
+func ruleRelevance(rule project.Rule, text string) int {
+	text = strings.ToLower(text)
+	score := 0
+	if strings.Contains(text, strings.ToLower(rule.System)) {
+		score += 2
+	}
+	seen := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(rule.Text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune(word)
+		if seen[word] || len(runes) < 4 && !(len(runes) >= 2 && unicode.Is(unicode.Hangul, runes[0])) {
+			continue
+		}
+		seen[word] = true
+		if strings.Contains(text, word) {
+			score++
+		}
+	}
+	return score
+}
+
+// buildRuleSection renders the rulebook for the system prompt within
+// maxTokens. Every rule is a hard constraint, so all of them go in when
+// they fit; the ones userInput touches come first so they survive a small
+// budget.
+func buildRuleSection(proj *project.Project, userInput string, tokenizer llm.TokenCounter, maxTokens int) string {
+	if proj == nil {
+		return ""
+	}
+	rules, err := proj.LoadRules()
+	if err != nil || len(rules) == 0 {
+		return ""
+	}
+
+	scores := make([]int, len(rules))
+	order := make([]int, len(rules))
+	for i, rule := range rules {
+		scores[i] = ruleRelevance(rule, userInput)
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	header := "## World Rules (hard constraints: never break them; cite the ID if asked to)"
+	used := tokenizer.Count(header)
+	var lines []string
+	for _, i := range order {
+		line := "- " + rules[i].String()
+		t := tokenizer.Count(line)
+		if used+t > maxTokens {
+			continue
+		}
+		lines = append(lines, line)
+		used += t
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+// showRules lists the rulebook inline.
+func (m *Model) showRules() {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	rules, err := m.project.LoadRules()
+	if err != nil {
+		m.err = fmt.Errorf("failed to load rules: %w", err)
+		return
+	}
+
+	var content string
+	if len(rules) == 0 {
+		content = fmt.Sprintf("No rules yet. Add hard rules of the world's magic or technology as list items (\"- M1: Every spell costs blood.\") to a file in context/%s/.", project.RulesDir)
+	} else {
+		content = project.RulebookMarkdown(rules) + "\n\nCheck chapters against them with `dreamteller batch <name> --op rules`; violations are exported with /review export."
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	m.updateViewport()
+}
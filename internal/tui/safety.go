@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// safetyText holds the localized strings of a safety block explanation.
+type safetyText struct {
+	Blocked     string
+	Empty       string
+	Explanation string
+	Rating      string // formatted with the project's rating
+	NoRating    string
+	Retry       string
+	Soften      string
+	Rephrase    string
+}
+
+var safetyTexts = map[string]safetyText{
+	"en": {
+		Blocked:     "Reply blocked by the provider's safety filter",
+		Empty:       "No reply received (the content may have been blocked)",
+		Explanation: "The provider declined to generate this content. Project settings cannot override provider policies.",
+		Rating:      "Project content rating: %s.",
+		NoRating:    "No content rating is set. Add `rating:` under `writing:` in .dreamteller/config.yaml to tell the AI your limits.",
+		Retry:       "/retry — send the same request again",
+		Soften:      "/retry soften — ask for a version that implies the sensitive parts instead of depicting them",
+		Rephrase:    "Or rephrase your message and send it again.",
+	},
+	"ko": {
+		Blocked:     "응답이 프로바이더의 안전 필터에 의해 차단되었습니다",
+		Empty:       "응답을 받지 못했습니다 (콘텐츠가 차단되었을 수 있음)",
+		Explanation: "프로바이더가 이 내용의 생성을 거부했습니다. 프로젝트 설정으로 프로바이더 정책을 우회할 수는 없습니다.",
+		Rating:      "프로젝트 콘텐츠 등급: %s.",
+		NoRating:    "콘텐츠 등급이 설정되지 않았습니다. .dreamteller/config.yaml의 `writing:` 아래에 `rating:`을 추가해 AI에 허용 범위를 알려주세요.",
+		Retry:       "/retry — 같은 요청을 다시 보내기",
+		Soften:      "/retry soften — 민감한 부분을 직접 묘사하지 않고 암시하는 버전으로 요청하기",
+		Rephrase:    "또는 메시지를 다르게 표현해 다시 보내세요.",
+	},
+	"ja": {
+		Blocked:     "プロバイダーの安全フィルターにより応答がブロックされました",
+		Empty:       "応答がありませんでした（内容がブロックされた可能性があります）",
+		Explanation: "プロバイダーがこの内容の生成を拒否しました。プロジェクト設定でプロバイダーのポリシーを上書きすることはできません。",
+		Rating:      "プロジェクトのコンテンツレーティング: %s。",
+		NoRating:    "コンテンツレーティングが設定されていません。.dreamteller/config.yaml の `writing:` に `rating:` を追加して、AI に許容範囲を伝えてください。",
+		Retry:       "/retry — 同じリクエストを再送する",
+		Soften:      "/retry soften — 際どい部分を直接描写せず暗示するバージョンを依頼する",
+		Rephrase:    "または、メッセージを言い換えて再送してください。",
+	},
+}
+
+// softenPrompt asks the model to retry a blocked request within the rating.
+const softenPrompt = "Your previous attempt at this request was blocked by a safety filter. Write it again within the project's content rating, implying violent or explicit details instead of depicting them."
+
+// language returns the project's TUI language, defaulting to English.
+func (m *Model) language() string {
+	if m.project != nil && m.project.Config != nil {
+		if _, ok := safetyTexts[m.project.Config.Language]; ok {
+			return m.project.Config.Language
+		}
+	}
+	return "en"
+}
+
+// showSafetyNotice explains a blocked or empty reply in the project's
+// language and lists the ways to retry.
+func (m *Model) showSafetyNotice(blocked bool) tea.Cmd {
+	text := safetyTexts[m.language()]
+
+	title := text.Empty
+	if blocked {
+		title = text.Blocked
+	}
+
+	var sb strings.Builder
+	sb.WriteString("⚠ " + title + "\n")
+	sb.WriteString(text.Explanation + "\n")
+	if m.project != nil && m.project.Config != nil && m.project.Config.Writing.Rating != "" {
+		sb.WriteString(fmt.Sprintf(text.Rating, m.project.Config.Writing.Rating) + "\n")
+	} else {
+		sb.WriteString(text.NoRating + "\n")
+	}
+	sb.WriteString("\n  " + text.Retry)
+	sb.WriteString("\n  " + text.Soften)
+	sb.WriteString("\n  " + text.Rephrase)
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+
+	toast, cmd := showToast(title, ToastWarning, 5*time.Second)
+	m.toast = toast
+	return cmd
+}
+
+// retryLastRequest resends the last user message, optionally asking the
+// model to soften the content.
+func (m *Model) retryLastRequest(soften bool) (tea.Model, tea.Cmd) {
+	if m.provider == nil {
+		m.err = fmt.Errorf("no LLM provider configured")
+		return m, nil
+	}
+	if len(m.messages) == 0 {
+		m.err = fmt.Errorf("nothing to retry")
+		return m, nil
+	}
+	if m.messages[len(m.messages)-1].Role == "assistant" {
+		m.err = fmt.Errorf("the last request already has a reply; send a new message instead")
+		return m, nil
+	}
+
+	hasUser := false
+	for _, msg := range m.messages {
+		if msg.Role == "user" {
+			hasUser = true
+			break
+		}
+	}
+	if !hasUser {
+		m.err = fmt.Errorf("nothing to retry")
+		return m, nil
+	}
+
+	var followUp []llm.ChatMessage
+	if soften {
+		followUp = append(followUp, llm.NewUserMessage(softenPrompt))
+	}
+
+	m.toolRepairAttempts = 0
+	m.toolLookups = 0
+	m.autoContinues = 0
+	m.streaming = true
+	m.inputMode = false
+	m.statusText = "Retrying..."
+	m.updateViewport()
+
+	return m, tea.Batch(m.spinner.Tick, m.startStreamWithFollowUp(followUp))
+}
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sandboxPrompt is added to the system prompt of sandbox requests.
+const sandboxPrompt = "\n\nThis conversation is a what-if sandbox for exploring alternate plot ideas. Nothing said here is canon and the story files are read-only: explore freely, and do not update context files or remember facts."
+
+// sandbox is a what-if conversation kept apart from the main thread.
+type sandbox struct {
+	// main is the main thread, restored when the sandbox ends.
+	main []Message
+
+	// promoted are the replies to carry back into the main thread.
+	promoted []Message
+}
+
+// handleSandboxCommand handles /sandbox [idea | promote | end]. An idea
+// starts the sandbox, if needed, and sends it as the first prompt.
+func (m *Model) handleSandboxCommand(arg string) (tea.Model, tea.Cmd) {
+	m.textarea.Reset()
+	switch strings.ToLower(arg) {
+	case "end", "exit", "leave":
+		m.leaveSandbox()
+		return m, nil
+	case "promote":
+		if m.sandbox == nil {
+			m.err = fmt.Errorf("not in a sandbox; start one with /sandbox")
+			return m, nil
+		}
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if m.messages[i].Role == "assistant" {
+				m.promote(i)
+				return m, nil
+			}
+		}
+		m.err = fmt.Errorf("no AI reply to promote yet")
+		return m, nil
+	}
+
+	if m.sandbox == nil {
+		if !m.enterSandbox() {
+			return m, nil
+		}
+	} else if arg == "" {
+		m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf(
+			"In the sandbox (%d repl(ies) promoted). /sandbox promote keeps the last reply, /sandbox end returns to the main thread.", len(m.sandbox.promoted))})
+		m.updateViewport()
+	}
+	if arg == "" {
+		return m, nil
+	}
+
+	if m.offline {
+		m.showOfflineNotice()
+		return m, nil
+	}
+	if m.aiLocked() {
+		return m, nil
+	}
+	if err := m.beginTurn(nil); err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m.submitPrompt(arg)
+}
+
+// enterSandbox sets the main thread aside and starts an empty sandbox. It
+// reports whether the sandbox started.
+func (m *Model) enterSandbox() bool {
+	if m.streaming {
+		m.err = fmt.Errorf("wait for the reply to finish before starting a sandbox")
+		return false
+	}
+	if m.interview != nil {
+		m.err = fmt.Errorf("end the interview with %s before starting a sandbox", m.interview.character)
+		return false
+	}
+	m.sandbox = &sandbox{main: m.messages}
+	m.messages = []Message{{Role: "system", Content: "What-if sandbox: explore alternate plot ideas here. Nothing is saved to the conversation history and the story files stay read-only.\n\n" +
+		"Promote a reply to the main thread with /sandbox promote (or p while selecting), save one as a note with n while selecting, and return with /sandbox end."}}
+	m.statusText = "Sandbox started"
+	m.updateViewport()
+	return true
+}
+
+// leaveSandbox discards the sandbox and restores the main thread with the
+// promoted replies added to it.
+func (m *Model) leaveSandbox() {
+	if m.sandbox == nil {
+		m.err = fmt.Errorf("not in a sandbox")
+		return
+	}
+	if m.streaming {
+		m.err = fmt.Errorf("wait for the reply to finish or press Esc before leaving the sandbox")
+		return
+	}
+
+	promoted := m.sandbox.promoted
+	m.messages = m.sandbox.main
+	m.sandbox = nil
+	if len(promoted) > 0 {
+		m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf("Promoted from the sandbox (%d):", len(promoted))})
+		for _, msg := range promoted {
+			m.messages = append(m.messages, msg)
+			m.saveMessage(msg.Role, msg.Content)
+		}
+	}
+	m.statusText = fmt.Sprintf("Left the sandbox (%d promoted)", len(promoted))
+	m.updateViewport()
+}
+
+// promoteSelected promotes the selected reply.
+func (m *Model) promoteSelected() tea.Cmd {
+	if _, err := m.selectedReply(); err != nil {
+		m.err = err
+		return nil
+	}
+	m.promote(m.selectedMessage)
+	return m.showActionToast("Promoted to the main thread")
+}
+
+// promote marks the i-th sandbox message to be carried back into the main
+// thread.
+func (m *Model) promote(i int) {
+	msg := m.messages[i]
+	msg.Folded = false
+	m.sandbox.promoted = append(m.sandbox.promoted, msg)
+	m.statusText = fmt.Sprintf("Reply promoted (%d so far); it joins the main thread at /sandbox end", len(m.sandbox.promoted))
+}
+
+// sandboxRequest keeps a sandbox request from writing to the project: it
+// tells the model so and offers only the read-only tools.
+func sandboxRequest(req *llm.ChatRequest) {
+	if len(req.Messages) > 0 && req.Messages[0].Role == llm.RoleSystem {
+		req.Messages[0].Content += sandboxPrompt
+	}
+	var tools []llm.ToolDefinition
+	for _, tool := range req.Tools {
+		if !llm.WritesProject(tool.Function.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	req.Tools = tools
+}
+
+// refuseOffRecordWrite ends a sandbox or interview turn whose tool call
+// would write to the project.
+func (m *Model) refuseOffRecordWrite(call llm.ToolCall) (tea.Model, tea.Cmd) {
+	m.streaming = false
+	m.inputMode = true
+	m.textarea.Focus()
+	m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf(
+		"This conversation is off the record: ignored the model's %s request.", call.Function.Name)})
+	m.updateViewport()
+	return m, nil
+}
@@ -0,0 +1,278 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/azyu/dreamteller/internal/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chatSelectionHint lists the keys available while selecting a message.
+const chatSelectionHint = "↑/↓ select • Enter fold • c copy • a append to chapter • n save as note • q quote • d delete • Esc done"
+
+// sandboxSelectionHint adds promoting a reply in the sandbox.
+const sandboxSelectionHint = "↑/↓ select • Enter fold • c copy • p promote to main thread • n save as note • q quote • d delete • Esc done"
+
+// selectionHint returns the keys available while selecting a message.
+func (m *Model) selectionHint() string {
+	if m.sandbox != nil {
+		return sandboxSelectionHint
+	}
+	return chatSelectionHint
+}
+
+// foldedPreviewRunes caps the preview shown for a folded message.
+const foldedPreviewRunes = 120
+
+// writeClipboard writes the system clipboard. It is a variable so tests can
+// replace it.
+var writeClipboard = clipboard.WriteAll
+
+// selectableMessage reports whether a message can be selected. System
+// notices are skipped.
+func selectableMessage(msg Message) bool {
+	return msg.Role == "user" || msg.Role == "assistant"
+}
+
+// enterSelectMode starts selecting messages, beginning with the newest one.
+func (m *Model) enterSelectMode() {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if selectableMessage(m.messages[i]) {
+			m.selectMode = true
+			m.selectedMessage = i
+			m.inputMode = false
+			m.textarea.Blur()
+			m.updateViewport()
+			return
+		}
+	}
+	m.err = fmt.Errorf("no messages to select")
+}
+
+// exitSelectMode returns to the composer.
+func (m *Model) exitSelectMode() {
+	m.selectMode = false
+	m.inputMode = true
+	m.textarea.Focus()
+	m.updateViewport()
+}
+
+// moveSelection moves the selection to the next selectable message in the
+// given direction, staying put at either end.
+func (m *Model) moveSelection(delta int) {
+	for i := m.selectedMessage + delta; i >= 0 && i < len(m.messages); i += delta {
+		if selectableMessage(m.messages[i]) {
+			m.selectedMessage = i
+			m.updateViewport()
+			return
+		}
+	}
+}
+
+// handleSelectKey handles keyboard input while selecting messages.
+func (m *Model) handleSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.exitSelectMode()
+	case tea.KeyUp:
+		m.moveSelection(-1)
+	case tea.KeyDown:
+		m.moveSelection(1)
+	case tea.KeyEnter:
+		m.toggleFold()
+	case tea.KeyRunes:
+		switch string(msg.Runes) {
+		case "k":
+			m.moveSelection(-1)
+		case "j":
+			m.moveSelection(1)
+		case "c":
+			return m, m.copySelectedMessage()
+		case "a":
+			if m.sandbox != nil {
+				m.err = fmt.Errorf("the sandbox leaves the manuscript alone; promote the reply with p first")
+				return m, nil
+			}
+			return m, m.appendSelectedToChapter()
+		case "n":
+			return m, m.saveSelectedAsNote()
+		case "p":
+			if m.sandbox != nil {
+				return m, m.promoteSelected()
+			}
+		case "q":
+			m.quoteSelectedMessage()
+		case "d":
+			m.deleteSelectedMessage()
+		}
+	}
+	return m, nil
+}
+
+// toggleFold folds or unfolds the selected message.
+func (m *Model) toggleFold() {
+	m.messages[m.selectedMessage].Folded = !m.messages[m.selectedMessage].Folded
+	m.updateViewport()
+}
+
+// foldedPreview renders a folded message as its first line and a count of
+// the hidden lines.
+func foldedPreview(content string) string {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	preview := lines[0]
+	if runes := []rune(preview); len(runes) > foldedPreviewRunes {
+		preview = string(runes[:foldedPreviewRunes-3]) + "..."
+	}
+	return fmt.Sprintf("%s ▸ (%d more lines folded)", preview, len(lines)-1)
+}
+
+// quoteSelectedMessage quotes the selected message into the composer and
+// returns to it.
+func (m *Model) quoteSelectedMessage() {
+	lines := strings.Split(m.selectedText(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	quote := strings.Join(lines, "\n") + "\n\n"
+
+	m.exitSelectMode()
+	if existing := m.textarea.Value(); strings.TrimSpace(existing) != "" {
+		quote = strings.TrimRight(existing, "\n") + "\n\n" + quote
+	}
+	m.textarea.SetValue(quote)
+	m.resizeComposer()
+}
+
+// deleteSelectedMessage removes the selected message from the working
+// history so it is no longer sent to the model. The saved session is left
+// as is.
+func (m *Model) deleteSelectedMessage() {
+	i := m.selectedMessage
+	m.messages = append(m.messages[:i], m.messages[i+1:]...)
+
+	// Select the message that took its place, or else the one before it.
+	for j := i; j < len(m.messages); j++ {
+		if selectableMessage(m.messages[j]) {
+			m.selectedMessage = j
+			m.updateViewport()
+			return
+		}
+	}
+	for j := i - 1; j >= 0; j-- {
+		if selectableMessage(m.messages[j]) {
+			m.selectedMessage = j
+			m.updateViewport()
+			return
+		}
+	}
+	m.exitSelectMode()
+}
+
+// selectedText returns the selected message's text without attachment
+// blocks or citation tags.
+func (m *Model) selectedText() string {
+	if m.selectedMessage < 0 || m.selectedMessage >= len(m.messages) {
+		return ""
+	}
+	msg := m.messages[m.selectedMessage]
+	return strings.TrimSpace(llm.StripCitations(stripAttachments(msg.Content)))
+}
+
+// selectedReply returns the selected assistant reply's text, or an error
+// when a user message is selected.
+func (m *Model) selectedReply() (string, error) {
+	if m.messages[m.selectedMessage].Role != "assistant" {
+		return "", fmt.Errorf("select an AI reply to use it in the manuscript")
+	}
+	text := m.selectedText()
+	if text == "" {
+		return "", fmt.Errorf("the selected reply is empty")
+	}
+	return text, nil
+}
+
+// copySelectedMessage copies the selected message to the clipboard.
+func (m *Model) copySelectedMessage() tea.Cmd {
+	if err := writeClipboard(m.selectedText()); err != nil {
+		m.err = fmt.Errorf("failed to copy to the clipboard: %w", err)
+		return nil
+	}
+	return m.showActionToast("Copied to the clipboard")
+}
+
+// activeChapterNumber returns the chapter replies are appended to: the one
+// picked with /chapter, or else the latest chapter.
+func (m *Model) activeChapterNumber() int {
+	if m.activeChapter > 0 {
+		return m.activeChapter
+	}
+	chapters, _ := m.project.LoadChapters()
+	if len(chapters) == 0 {
+		return 1
+	}
+	return chapters[len(chapters)-1].Number
+}
+
+// appendSelectedToChapter appends the selected reply to the active chapter.
+func (m *Model) appendSelectedToChapter() tea.Cmd {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return nil
+	}
+	text, err := m.selectedReply()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+
+	path, err := m.project.AppendToChapter(m.activeChapterNumber(), text)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.recordDailyWords()
+	return m.showActionToast(fmt.Sprintf("Appended to %s", filepath.ToSlash(path)))
+}
+
+// saveSelectedAsNote saves the selected reply as a new file under
+// context/notes.
+func (m *Model) saveSelectedAsNote() tea.Cmd {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return nil
+	}
+	text, err := m.selectedReply()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+
+	filename := "note-" + time.Now().Format("20060102-150405.000")
+	if err := m.project.WriteContextContent("notes", filename, text+"\n", "create"); err != nil {
+		m.err = fmt.Errorf("failed to save note: %w", err)
+		return nil
+	}
+	return m.showActionToast(fmt.Sprintf("Saved as context/notes/%s.md", filename))
+}
+
+// showActionToast confirms a message action.
+func (m *Model) showActionToast(text string) tea.Cmd {
+	toast, cmd := showToast(text, ToastSuccess, 3*time.Second)
+	m.toast = toast
+	return cmd
+}
+
+// setActiveChapter picks the chapter replies are appended to.
+func (m *Model) setActiveChapter(arg string) {
+	var number int
+	if _, err := fmt.Sscanf(arg, "%d", &number); err != nil || number < 1 {
+		m.err = fmt.Errorf("usage: /chapter <number>")
+		return
+	}
+	m.activeChapter = number
+	m.statusText = fmt.Sprintf("Active chapter: %d", number)
+}
@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/project"
+)
+
+// seriesSearchLimit caps the results /series search shows.
+const seriesSearchLimit = 10
+
+// handleSeriesCommand handles /series [join <name> | leave | search <query> |
+// check].
+func (m *Model) handleSeriesCommand(args []string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	if len(args) == 0 {
+		m.showSeries()
+		return
+	}
+
+	rest := strings.Join(args[1:], " ")
+	switch strings.ToLower(args[0]) {
+	case "join":
+		if rest == "" {
+			m.err = fmt.Errorf("usage: /series join <name>")
+			return
+		}
+		if m.project.Config.Series != "" {
+			m.err = fmt.Errorf("already in series %s; /series leave first", m.project.Config.Series)
+			return
+		}
+		if err := m.project.JoinSeries(rest); err != nil {
+			m.err = err
+			return
+		}
+		m.reindexSeriesFiles()
+		m.statusText = "Joined series " + rest
+		m.showSeries()
+	case "leave":
+		// List the shared files while they are still visible so their
+		// chunks can be dropped from the index.
+		shared, _ := m.project.FS.ListMarkdownFiles(project.SeriesMount)
+		name := m.project.Config.Series
+		if err := m.project.LeaveSeries(); err != nil {
+			m.err = err
+			return
+		}
+		for _, file := range shared {
+			if err := m.reindexContextFile(file.Path); err != nil {
+				m.err = fmt.Errorf("indexing failed: %w", err)
+			}
+		}
+		m.statusText = "Left series " + name
+	case "search":
+		if rest == "" {
+			m.err = fmt.Errorf("usage: /series search <query>")
+			return
+		}
+		m.searchSeries(rest)
+	case "check":
+		m.checkSeries()
+	default:
+		m.err = fmt.Errorf("usage: /series [join <name> | leave | search <query> | check]")
+	}
+}
+
+// reindexSeriesFiles indexes the series' shared files in this book.
+func (m *Model) reindexSeriesFiles() {
+	if !m.project.FS.Exists(project.SeriesMount) {
+		return
+	}
+	files, err := m.project.FS.ListMarkdownFiles(project.SeriesMount)
+	if err != nil {
+		m.err = fmt.Errorf("failed to list series files: %w", err)
+		return
+	}
+	for _, file := range files {
+		if err := m.reindexContextFile(file.Path); err != nil {
+			m.err = fmt.Errorf("indexing failed: %w", err)
+		}
+	}
+}
+
+// openSeries returns the project's series, or sets an error when it is not
+// in one.
+func (m *Model) openSeries() *project.Series {
+	series, err := m.project.Series()
+	switch {
+	case err != nil:
+		m.err = err
+	case series == nil:
+		m.err = fmt.Errorf("not in a series; use /series join <name>")
+	}
+	return series
+}
+
+// showSeries lists the books of the project's series.
+func (m *Model) showSeries() {
+	series := m.openSeries()
+	if series == nil {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Series %s: %d book(s)\n", series.Name, len(series.Books)))
+	for _, book := range series.Books {
+		mark := " "
+		if book == m.project.Path() {
+			mark = "*"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %s", mark, filepath.Base(book)))
+	}
+	sb.WriteString(fmt.Sprintf("\n\nShared characters, settings and glossary live in %s and appear here under series/.", series.Dir))
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// searchSeries searches every book of the series.
+func (m *Model) searchSeries(query string) {
+	series := m.openSeries()
+	if series == nil {
+		return
+	}
+	results, err := series.Search(query, seriesSearchLimit)
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	var sb strings.Builder
+	if len(results) == 0 {
+		sb.WriteString(fmt.Sprintf("No results for %q in series %s.", query, series.Name))
+	} else {
+		sb.WriteString(fmt.Sprintf("Results for %q in series %s:", query, series.Name))
+		for _, r := range results {
+			sb.WriteString(fmt.Sprintf("\n\n[%s] %s\n%s", r.Book, r.Location(), truncateString(strings.Join(strings.Fields(r.Content), " "), 160)))
+		}
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// checkSeries reports inconsistencies between the books of the series.
+func (m *Model) checkSeries() {
+	series := m.openSeries()
+	if series == nil {
+		return
+	}
+	issues, err := series.Check()
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	var sb strings.Builder
+	if len(issues) == 0 {
+		sb.WriteString(fmt.Sprintf("No inconsistencies across the %d book(s) of series %s.", len(series.Books), series.Name))
+	} else {
+		sb.WriteString(fmt.Sprintf("Series %s: %d issue(s)", series.Name, len(issues)))
+		for _, issue := range issues {
+			sb.WriteString(fmt.Sprintf("\n\n[%s] %s\n%s", issue.Book, issue.Path, issue.Message))
+		}
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sprintHistoryLimit is how many past sprints /sprint lists.
+const sprintHistoryLimit = 5
+
+// sprint is a timed writing session started with /sprint.
+type sprint struct {
+	id           int
+	start        time.Time
+	duration     time.Duration
+	wordsAtStart int
+
+	// lockAI disables AI requests until the sprint ends.
+	lockAI bool
+}
+
+// remaining returns the time left in the sprint, never negative.
+func (s *sprint) remaining() time.Duration {
+	left := s.duration - time.Since(s.start)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// sprintTickMsg updates the sprint timer. It carries the sprint's id so a
+// tick scheduled for a stopped sprint does not drive the next one.
+type sprintTickMsg struct {
+	id int
+}
+
+// sprintTick schedules the next timer update.
+func sprintTick(id int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return sprintTickMsg{id: id}
+	})
+}
+
+// parseSprintDuration parses a sprint length such as "25m" or "1h"; a
+// bare number is minutes.
+func parseSprintDuration(s string) (time.Duration, error) {
+	if minutes, err := strconv.Atoi(s); err == nil {
+		s = fmt.Sprintf("%dm", minutes)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute {
+		return 0, fmt.Errorf("invalid sprint length %q: use e.g. 25m", s)
+	}
+	return d, nil
+}
+
+// formatTimer formats a duration as mm:ss.
+func formatTimer(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// handleSprintCommand handles /sprint [<length> [lock] | stop].
+func (m *Model) handleSprintCommand(args []string) tea.Cmd {
+	if len(args) == 0 {
+		m.showSprints()
+		return nil
+	}
+	if strings.ToLower(args[0]) == "stop" {
+		if m.sprint == nil {
+			m.err = fmt.Errorf("no sprint is running")
+			return nil
+		}
+		return m.finishSprint()
+	}
+
+	if m.sprint != nil {
+		m.err = fmt.Errorf("a sprint is already running; /sprint stop ends it")
+		return nil
+	}
+	if m.project == nil {
+		m.err = fmt.Errorf("sprints need an open project")
+		return nil
+	}
+	duration, err := parseSprintDuration(args[0])
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	lockAI := len(args) > 1 && strings.ToLower(args[1]) == "lock"
+
+	words, err := m.project.WordCount()
+	if err != nil {
+		m.err = fmt.Errorf("failed to count words: %w", err)
+		return nil
+	}
+
+	m.sprints++
+	m.sprint = &sprint{id: m.sprints, start: time.Now(), duration: duration, wordsAtStart: words, lockAI: lockAI}
+
+	notice := fmt.Sprintf("Sprint started: %s. Write in your chapter files; the words you add are counted when it ends.", formatTimer(duration))
+	if lockAI {
+		notice += " AI requests are off until then."
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: notice})
+	m.updateViewport()
+	return sprintTick(m.sprint.id)
+}
+
+// handleSprintTick finishes the sprint when its time is up and otherwise
+// schedules the next tick.
+func (m *Model) handleSprintTick(msg sprintTickMsg) tea.Cmd {
+	if m.sprint == nil || m.sprint.id != msg.id {
+		return nil
+	}
+	if m.sprint.remaining() == 0 {
+		return m.finishSprint()
+	}
+	return sprintTick(msg.id)
+}
+
+// finishSprint ends the running sprint, logs it to the project's stats and
+// reports the words written.
+func (m *Model) finishSprint() tea.Cmd {
+	s := m.sprint
+	m.sprint = nil
+
+	words, err := m.project.WordCount()
+	if err != nil {
+		m.err = fmt.Errorf("failed to count words: %w", err)
+		return nil
+	}
+	elapsed := time.Since(s.start)
+	if elapsed > s.duration {
+		elapsed = s.duration
+	}
+	record := storage.SprintRecord{
+		StartedAt:   s.start,
+		Planned:     s.duration,
+		Actual:      elapsed.Round(time.Second),
+		WordsBefore: s.wordsAtStart,
+		WordsAfter:  words,
+		AILocked:    s.lockAI,
+	}
+	if m.project.DB != nil {
+		if _, err := m.project.DB.SaveSprint(record); err != nil {
+			m.err = fmt.Errorf("failed to log sprint: %w", err)
+		}
+		_ = m.project.DB.RecordDailyWords(time.Now(), words)
+	}
+
+	summary := fmt.Sprintf("Sprint finished after %s: %+d words (%d → %d).",
+		formatTimer(record.Actual), record.WordsWritten(), record.WordsBefore, record.WordsAfter)
+	m.messages = append(m.messages, Message{Role: "system", Content: summary})
+	m.updateViewport()
+
+	toast, cmd := showToast(fmt.Sprintf("Sprint over: %+d words", record.WordsWritten()), ToastSuccess, 5*time.Second)
+	m.toast = toast
+	return cmd
+}
+
+// showSprints shows the running sprint or, without one, the recent ones.
+func (m *Model) showSprints() {
+	if m.sprint != nil {
+		m.messages = append(m.messages, Message{Role: "system", Content: fmt.Sprintf(
+			"Sprint running: %s left. /sprint stop ends it early.", formatTimer(m.sprint.remaining()))})
+		m.updateViewport()
+		return
+	}
+	if m.project == nil || m.project.DB == nil {
+		m.err = fmt.Errorf("usage: /sprint <length> [lock]")
+		return
+	}
+
+	sprints, err := m.project.DB.ListSprints(sprintHistoryLimit)
+	if err != nil {
+		m.err = fmt.Errorf("failed to load sprints: %w", err)
+		return
+	}
+
+	var sb strings.Builder
+	if len(sprints) == 0 {
+		sb.WriteString("No sprints yet. Start one with /sprint 25m (add \"lock\" to turn AI requests off).")
+	} else {
+		sb.WriteString("Recent sprints:\n")
+		for _, s := range sprints {
+			line := fmt.Sprintf("\n%s  %s/%s  %+d words", s.StartedAt.Format("2006-01-02 15:04"),
+				formatTimer(s.Actual), formatTimer(s.Planned), s.WordsWritten())
+			if s.AILocked {
+				line += "  🔒"
+			}
+			sb.WriteString(line)
+		}
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
+// aiLocked reports whether a sprint has AI requests turned off, and says
+// so in the status line.
+func (m *Model) aiLocked() bool {
+	if m.sprint == nil || !m.sprint.lockAI {
+		return false
+	}
+	m.err = fmt.Errorf("AI requests are off during the sprint (%s left); /sprint stop ends it early", formatTimer(m.sprint.remaining()))
+	return true
+}
+
+// sprintStatus renders the sprint timer for the status bar, or "".
+func (m *Model) sprintStatus() string {
+	if m.sprint == nil {
+		return ""
+	}
+	status := "⏱ " + formatTimer(m.sprint.remaining())
+	if m.sprint.lockAI {
+		status += " 🔒"
+	}
+	return status
+}
@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+)
+
+// statsSprintLimit caps how many sprints the stats view totals.
+const statsSprintLimit = 1000
+
+// eventProgressWidth is the width of the event progress bar.
+const eventProgressWidth = 30
+
+// statsSnapshot holds what the stats view shows, gathered when it opens so
+// rendering does not touch the disk.
+type statsSnapshot struct {
+	words       int
+	chapters    int
+	sprints     int
+	sprintWords int
+	event       *project.EventProgress
+	quota       *project.QuotaStatus
+}
+
+// recordDailyWords logs today's word count for event pacing.
+func (m *Model) recordDailyWords() {
+	if m.project == nil {
+		return
+	}
+	_, _ = m.project.RecordDailyWords(time.Now())
+}
+
+// openStats gathers the project's numbers and shows the stats view.
+func (m *Model) openStats() {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+
+	snapshot := &statsSnapshot{}
+	chapters, err := m.project.LoadChapters()
+	if err != nil {
+		m.err = fmt.Errorf("failed to load chapters: %w", err)
+		return
+	}
+	snapshot.chapters = len(chapters)
+	if snapshot.words, err = m.project.RecordDailyWords(time.Now()); err != nil {
+		m.err = err
+	}
+	if m.project.DB != nil {
+		sprints, _ := m.project.DB.ListSprints(statsSprintLimit)
+		snapshot.sprints = len(sprints)
+		for _, s := range sprints {
+			snapshot.sprintWords += s.WordsWritten()
+		}
+	}
+	if snapshot.event, err = m.project.EventProgress(time.Now()); err != nil {
+		m.err = err
+	}
+	if snapshot.quota, err = m.project.Quota(time.Now()); err != nil {
+		m.err = err
+	}
+
+	m.stats = snapshot
+	m.view = ViewStats
+	m.updateViewport()
+}
+
+// handleEventCommand handles /event [start <target> <days> [name] | stop].
+func (m *Model) handleEventCommand(args []string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	if len(args) == 0 {
+		m.openStats()
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "start":
+		if len(args) < 3 {
+			m.err = fmt.Errorf("usage: /event start <target words> <days> [name]")
+			return
+		}
+		target, err := parseWordTarget(args[1])
+		if err != nil {
+			m.err = err
+			return
+		}
+		days, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(args[2]), "d"))
+		if err != nil {
+			m.err = fmt.Errorf("invalid number of days: %s", args[2])
+			return
+		}
+		event, err := m.project.StartEvent(strings.Join(args[3:], " "), target, days, time.Now())
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.statusText = fmt.Sprintf("%s started: %d words in %d days", event.Name, event.TargetWords, event.Days)
+		m.openStats()
+	case "stop":
+		if err := m.project.StopEvent(); err != nil {
+			m.err = err
+			return
+		}
+		m.statusText = "Writing event ended"
+		if m.view == ViewStats {
+			m.openStats()
+		}
+	default:
+		m.err = fmt.Errorf("usage: /event [start <target words> <days> [name] | stop]")
+	}
+}
+
+// parseWordTarget parses a word count such as 50000 or 50k.
+func parseWordTarget(s string) (int, error) {
+	s = strings.ToLower(strings.ReplaceAll(s, ",", ""))
+	multiplier := 1
+	if strings.HasSuffix(s, "k") {
+		multiplier = 1000
+		s = strings.TrimSuffix(s, "k")
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid word target: %s", s)
+	}
+	return int(n * float64(multiplier)), nil
+}
+
+// renderStats renders the stats view: manuscript totals, sprints, the
+// writing event with its calendar and the month's AI usage.
+func (m *Model) renderStats() string {
+	var sb strings.Builder
+	sb.WriteString(styles.Title.Render("Writing Stats"))
+	sb.WriteString("\n\n")
+
+	s := m.stats
+	if m.project == nil || s == nil {
+		sb.WriteString(styles.ErrorText.Render("No project loaded"))
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("Manuscript: %d words, %d chapters\n", s.words, s.chapters))
+	sb.WriteString(fmt.Sprintf("Sprints:    %d (%+d words)\n", s.sprints, s.sprintWords))
+
+	sb.WriteString("\n")
+	if s.event == nil {
+		sb.WriteString(styles.MutedText.Render("No writing event. Start one with /event start 50k 30 NaNoWriMo."))
+	} else {
+		sb.WriteString(renderEvent(s.event))
+	}
+
+	if s.quota != nil {
+		sb.WriteString("\n\n")
+		sb.WriteString(renderQuota(s.quota))
+	}
+
+	sb.WriteString("\n\n")
+	sb.WriteString(styles.MutedText.Render("Press /back or Esc to return to chat."))
+	return sb.String()
+}
+
+// renderEvent renders an event's progress, pace and calendar.
+func renderEvent(e *project.EventProgress) string {
+	var sb strings.Builder
+	sb.WriteString(styles.Subtitle.Render(e.Event.Name))
+	end := e.Start.AddDate(0, 0, e.Event.Days-1)
+	if e.Over() {
+		sb.WriteString(styles.MutedText.Render(fmt.Sprintf("  ended %s", end.Format("Jan 2"))))
+	} else {
+		sb.WriteString(styles.MutedText.Render(fmt.Sprintf("  day %d of %d, until %s", e.Day, e.Event.Days, end.Format("Jan 2"))))
+	}
+	sb.WriteString("\n")
+
+	percent := 0
+	if e.Event.TargetWords > 0 {
+		percent = e.Written * 100 / e.Event.TargetWords
+	}
+	sb.WriteString(fmt.Sprintf("%s %d/%d words (%d%%)\n", progressBar(percent, eventProgressWidth), e.Written, e.Event.TargetWords, percent))
+
+	today := 0
+	if len(e.Daily) > 0 && !e.Over() {
+		today = e.Daily[len(e.Daily)-1]
+	}
+	switch {
+	case e.Remaining() == 0:
+		sb.WriteString(styles.SuccessText.Render("Target reached!"))
+	case e.Over():
+		sb.WriteString(styles.ErrorText.Render(fmt.Sprintf("Finished %d words short.", e.Remaining())))
+	default:
+		sb.WriteString(fmt.Sprintf("Today: %d words • pace %d/day • needed %d/day to finish on time", today, e.DailyTarget, e.RequiredPace))
+		if diff := e.Written - e.Expected; diff >= 0 {
+			sb.WriteString("\n" + styles.SuccessText.Render(fmt.Sprintf("%d words ahead of pace", diff)))
+		} else {
+			sb.WriteString("\n" + styles.TokenWarning.Render(fmt.Sprintf("%d words behind pace", -diff)))
+		}
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(renderEventCalendar(e))
+	return sb.String()
+}
+
+// progressBar renders percent (capped at 100) as a bar width cells wide.
+func progressBar(percent, width int) string {
+	if percent > 100 {
+		percent = 100
+	}
+	filled := percent * width / 100
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
+
+// calendarCells shade a day by its words against the daily target.
+var calendarCells = []string{"··", "░░", "▒▒", "▓▓", "██"}
+
+// calendarCell returns the cell for a day's words.
+func calendarCell(words, target int) string {
+	switch {
+	case words <= 0:
+		return calendarCells[0]
+	case target <= 0 || words >= target*3/2:
+		return calendarCells[4]
+	case words >= target:
+		return calendarCells[3]
+	case words*2 >= target:
+		return calendarCells[2]
+	default:
+		return calendarCells[1]
+	}
+}
+
+// renderEventCalendar renders the event's days as a week-by-week heatmap.
+// Days still to come are blank.
+func renderEventCalendar(e *project.EventProgress) string {
+	var sb strings.Builder
+	sb.WriteString(styles.MutedText.Render("Mo Tu We Th Fr Sa Su") + "\n")
+
+	// Pad the first week back to Monday.
+	offset := (int(e.Start.Weekday()) + 6) % 7
+	cells := make([]string, 0, offset+e.Event.Days)
+	for i := 0; i < offset; i++ {
+		cells = append(cells, "  ")
+	}
+	for day := 0; day < e.Event.Days; day++ {
+		if day < len(e.Daily) {
+			cells = append(cells, calendarCell(e.Daily[day], e.DailyTarget))
+		} else {
+			cells = append(cells, "  ")
+		}
+	}
+
+	for i := 0; i < len(cells); i += 7 {
+		end := i + 7
+		if end > len(cells) {
+			end = len(cells)
+		}
+		sb.WriteString(strings.TrimRight(strings.Join(cells[i:end], " "), " ") + "\n")
+	}
+	sb.WriteString(styles.MutedText.Render(fmt.Sprintf("· none  ░ some  ▒ half  ▓ daily pace (%d)  █ 150%%", e.DailyTarget)))
+	return sb.String()
+}
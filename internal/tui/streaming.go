@@ -5,6 +5,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/azyu/dreamteller/internal/llm"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -18,6 +19,11 @@ type StreamConfig struct {
 	RetryDelay time.Duration
 }
 
+// chunkCoalesceWindow is how long text deltas that follow one another are
+// gathered into a single update, so fast providers do not re-render the
+// viewport for every token.
+const chunkCoalesceWindow = 40 * time.Millisecond
+
 // DefaultStreamConfig returns sensible defaults.
 func DefaultStreamConfig() StreamConfig {
 	return StreamConfig{
@@ -178,3 +184,113 @@ func (rs *RetryableStream) Attempt() int {
 func (rs *RetryableStream) Reset() {
 	rs.attempt = 0
 }
+
+// chatAsStream performs a non-streaming Chat call and delivers the result
+// as a stream of chunks: the text, each tool call, then a final done chunk.
+func chatAsStream(ctx context.Context, provider llm.Provider, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	resp, err := provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan llm.StreamChunk, len(resp.Message.ToolCalls)+2)
+	if resp.Message.Content != "" {
+		chunks <- llm.StreamChunk{Delta: resp.Message.Content}
+	}
+	for i, tc := range resp.Message.ToolCalls {
+		chunks <- llm.StreamChunk{ToolCall: &llm.ToolCallDelta{
+			Index: i,
+			ID:    tc.ID,
+			Type:  tc.Type,
+			Function: &llm.FunctionCallDelta{
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			},
+		}}
+	}
+	usage := resp.Usage
+	chunks <- llm.StreamChunk{Done: true, FinishReason: resp.FinishReason, Usage: &usage}
+	close(chunks)
+
+	return chunks, nil
+}
+
+// chunkStream reads a provider stream for the UI, merging text deltas that
+// arrive within the coalescing window into one message. Reads happen one
+// at a time, each in the command that waits for the next chunk.
+type chunkStream struct {
+	ch     <-chan llm.StreamChunk
+	window time.Duration
+
+	// held is a chunk read while coalescing that could not be merged,
+	// such as a tool call; the next read returns it.
+	held   *llm.StreamChunk
+	closed bool
+}
+
+// newChunkStream wraps a provider stream.
+func newChunkStream(ch <-chan llm.StreamChunk) *chunkStream {
+	return &chunkStream{ch: ch, window: chunkCoalesceWindow}
+}
+
+// next waits for the next chunk and returns it as a message, with the text
+// of any deltas that follow within the window appended.
+func (s *chunkStream) next() tea.Msg {
+	chunk, ok := s.read()
+	if !ok {
+		return StreamChunkMsg{Done: true}
+	}
+	if chunk.Error != nil {
+		return StreamErrorMsg{Err: chunk.Error}
+	}
+	msg := StreamChunkMsg{
+		Content:      chunk.Delta,
+		ToolCall:     chunk.ToolCall,
+		Done:         chunk.Done,
+		FinishReason: chunk.FinishReason,
+	}
+	if chunk.ToolCall != nil || chunk.Done || s.window <= 0 {
+		return msg
+	}
+
+	timer := time.NewTimer(s.window)
+	defer timer.Stop()
+	for {
+		select {
+		case chunk, ok := <-s.ch:
+			if !ok {
+				s.closed = true
+				return msg
+			}
+			if chunk.Error != nil || chunk.ToolCall != nil {
+				s.held = &chunk
+				return msg
+			}
+			msg.Content += chunk.Delta
+			if chunk.Done {
+				msg.Done, msg.FinishReason = true, chunk.FinishReason
+				return msg
+			}
+		case <-timer.C:
+			return msg
+		}
+	}
+}
+
+// read returns the held chunk or waits for the next one. It reports false
+// once the stream is closed.
+func (s *chunkStream) read() (llm.StreamChunk, bool) {
+	if s.held != nil {
+		chunk := *s.held
+		s.held = nil
+		return chunk, true
+	}
+	if s.closed {
+		return llm.StreamChunk{}, false
+	}
+	chunk, ok := <-s.ch
+	if !ok {
+		s.closed = true
+	}
+	return chunk, ok
+}
@@ -2,10 +2,23 @@ package tui
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/llm/adapters"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -580,3 +593,1409 @@ func TestRetryableStream_NegativeRetries(t *testing.T) {
 	// Should not retry with negative attempts
 	assert.False(t, rs.ShouldRetry(errors.New("error")))
 }
+
+func TestStreamingFlow_ReplayProvider(t *testing.T) {
+	t.Run("streams canned text into chat", func(t *testing.T) {
+		provider := adapters.NewReplayProviderFromText("The storm broke over the harbor.")
+		m := New(nil, provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "describe the weather")
+		m = driveStream(t, m, m.startStream("describe the weather"))
+
+		assertStreaming(t, m, false)
+		assertLastMessage(t, m, "assistant", "The storm broke over the harbor.")
+	})
+
+	t.Run("replays tool calls as suggestions", func(t *testing.T) {
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{{
+			Response: adapters.ReplayResponse{
+				ToolCalls: []adapters.ReplayToolCall{{
+					Name:      llm.ToolAskUserClarification,
+					Arguments: `{"question":"Whose POV?","options":["Mira","Jun"]}`,
+				}},
+			},
+		}})
+		m := New(nil, provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "continue the scene")
+		m = driveStream(t, m, m.startStream("continue the scene"))
+
+		require.NotNil(t, m.pendingSuggestion)
+		assert.Equal(t, SuggestionTypeClarification, m.pendingSuggestion.Type)
+	})
+}
+
+func TestChunkStream(t *testing.T) {
+	t.Run("merges deltas that arrive together", func(t *testing.T) {
+		ch := make(chan llm.StreamChunk, 4)
+		ch <- llm.StreamChunk{Delta: "The "}
+		ch <- llm.StreamChunk{Delta: "storm "}
+		ch <- llm.StreamChunk{Delta: "broke."}
+		ch <- llm.StreamChunk{Done: true, FinishReason: llm.FinishReasonLength}
+		close(ch)
+
+		stream := newChunkStream(ch)
+		assert.Equal(t, StreamChunkMsg{Content: "The storm broke.", Done: true, FinishReason: llm.FinishReasonLength}, stream.next())
+		assert.Equal(t, StreamChunkMsg{Done: true}, stream.next())
+	})
+
+	t.Run("returns after the window", func(t *testing.T) {
+		ch := make(chan llm.StreamChunk, 1)
+		ch <- llm.StreamChunk{Delta: "The wind rose"}
+
+		stream := newChunkStream(ch)
+		stream.window = time.Millisecond
+		assert.Equal(t, StreamChunkMsg{Content: "The wind rose"}, stream.next())
+	})
+
+	t.Run("tool calls and errors are not merged", func(t *testing.T) {
+		call := &llm.ToolCallDelta{Index: 0, ID: "1"}
+		ch := make(chan llm.StreamChunk, 3)
+		ch <- llm.StreamChunk{Delta: "Let me check."}
+		ch <- llm.StreamChunk{ToolCall: call}
+		ch <- llm.StreamChunk{Error: errors.New("connection reset")}
+		close(ch)
+
+		stream := newChunkStream(ch)
+		assert.Equal(t, StreamChunkMsg{Content: "Let me check."}, stream.next())
+		assert.Equal(t, StreamChunkMsg{ToolCall: call}, stream.next())
+		assert.Equal(t, StreamErrorMsg{Err: errors.New("connection reset")}, stream.next())
+		assert.Equal(t, StreamChunkMsg{Done: true}, stream.next())
+	})
+}
+
+func TestStreamingFlow_Assembly(t *testing.T) {
+	t.Run("shows the assembly phase until the request is sent", func(t *testing.T) {
+		provider := adapters.NewReplayProviderFromText("The storm broke over the harbor.")
+		m := New(nil, provider, nil, "replay", "replay", "")
+		m.ready = true
+		m.width = testConfig.Width
+
+		addMessage(m, "user", "describe the weather")
+		m.streaming = true
+		cmd := m.startStream("describe the weather")
+		assert.True(t, m.assembling)
+		assert.Contains(t, m.View(), "assembling context")
+
+		msg, ok := cmd().(requestAssembledMsg)
+		require.True(t, ok)
+		model, next := m.Update(msg)
+		m = model.(*Model)
+		assert.False(t, m.assembling)
+		require.NotNil(t, next)
+		assert.IsType(t, StreamReadyMsg{}, next())
+	})
+
+	t.Run("interrupting drops the assembled request", func(t *testing.T) {
+		provider := adapters.NewReplayProviderFromText("The storm broke over the harbor.")
+		m := New(nil, provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "describe the weather")
+		m.streaming = true
+		cmd := m.startStream("describe the weather")
+		m = sendKeyMsg(m, tea.KeyEsc)
+		assertStreaming(t, m, false)
+		assert.False(t, m.assembling)
+
+		if msg := cmd(); msg != nil {
+			_, next := m.Update(msg)
+			assert.Nil(t, next, "nothing is sent")
+		}
+		assert.Equal(t, 1, provider.Remaining())
+	})
+}
+
+func TestStreamingFlow_GracefulDegradation(t *testing.T) {
+	noTools := llm.Capabilities{SupportsStreaming: true, MaxContextTokens: 8192, MaxOutputTokens: 1024}
+
+	t.Run("parses JSON-in-text tool call when tools unsupported", func(t *testing.T) {
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{{
+			Response: adapters.ReplayResponse{
+				Content: "Let me check.\n```tool\n{\"name\": \"ask_user_clarification\", \"arguments\": {\"question\": \"Whose POV?\"}}\n```",
+			},
+		}}, adapters.WithReplayCapabilities(noTools))
+		m := New(nil, provider, nil, "local", "local", "")
+		m.ready = true
+
+		addMessage(m, "user", "continue")
+		m = driveStream(t, m, m.startStream("continue"))
+
+		require.NotNil(t, m.pendingSuggestion)
+		assert.Equal(t, SuggestionTypeClarification, m.pendingSuggestion.Type)
+		assertLastMessage(t, m, "assistant", "Let me check.")
+		assert.NotContains(t, m.messages[len(m.messages)-1].Content, "```tool")
+	})
+
+	t.Run("uses Chat when streaming unsupported", func(t *testing.T) {
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{{
+			Response: adapters.ReplayResponse{Content: "One-shot reply."},
+		}}, adapters.WithReplayCapabilities(llm.Capabilities{SupportsTools: true, MaxContextTokens: 8192}))
+		m := New(nil, provider, nil, "o1", "openai", "")
+		m.ready = true
+
+		addMessage(m, "user", "hello")
+		m = driveStream(t, m, m.startStream("hello"))
+
+		assertLastMessage(t, m, "assistant", "One-shot reply.")
+	})
+}
+
+func TestToolArgumentRepair(t *testing.T) {
+	badCall := adapters.ReplayToolCall{Name: llm.ToolAskUserClarification, Arguments: `{"question": `}
+	goodCall := adapters.ReplayToolCall{Name: llm.ToolAskUserClarification, Arguments: `{"question": "Whose POV?"}`}
+
+	t.Run("retries with tool error and shows corrected suggestion", func(t *testing.T) {
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{ToolCalls: []adapters.ReplayToolCall{badCall}}},
+			{Response: adapters.ReplayResponse{ToolCalls: []adapters.ReplayToolCall{goodCall}}},
+		})
+		m := New(nil, provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "continue")
+		m = driveStream(t, m, m.startStream("continue"))
+
+		assertNoError(t, m)
+		assert.Equal(t, 0, provider.Remaining())
+		require.NotNil(t, m.pendingSuggestion)
+		assert.Equal(t, SuggestionTypeClarification, m.pendingSuggestion.Type)
+		assert.Equal(t, 0, m.toolRepairAttempts)
+	})
+
+	t.Run("repairs the context update that failed", func(t *testing.T) {
+		goodUpdate := adapters.ReplayToolCall{ID: "call_1", Name: llm.ToolUpdateContext,
+			Arguments: `{"file_type": "character", "file_name": "mira", "operation": "create", "content": "# Mira", "reason": "new character"}`}
+		badUpdate := adapters.ReplayToolCall{ID: "call_2", Name: llm.ToolUpdateContext, Arguments: `{"file_type": "setting", `}
+		var requests []llm.ChatRequest
+		provider := &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{ToolCalls: []adapters.ReplayToolCall{goodUpdate, badUpdate}}},
+			{Response: adapters.ReplayResponse{Content: "Let me try that again."}},
+		}), requests: &requests}
+		m := New(createTempProjectWithContext(t), provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "Add Mira and her harbor")
+		m = driveStream(t, m, m.startStream("Add Mira and her harbor"))
+
+		require.Len(t, requests, 2)
+		sent := requests[1].Messages
+		require.GreaterOrEqual(t, len(sent), 2)
+		repaired := sent[len(sent)-2]
+		require.Len(t, repaired.ToolCalls, 1)
+		assert.Equal(t, badUpdate.ID, repaired.ToolCalls[0].ID)
+		assert.Equal(t, badUpdate.ID, sent[len(sent)-1].ToolCallID)
+	})
+
+	t.Run("gives up with a notice after max attempts", func(t *testing.T) {
+		m := New(nil, adapters.NewReplayProviderFromText(), nil, "replay", "replay", "")
+		m.ready = true
+		m.toolRepairAttempts = maxToolRepairAttempts
+		m.toolCallAccumulator.AddCall(mockToolCall(badCall.Name, badCall.Arguments))
+
+		model, cmd := m.processToolCalls()
+		m = model.(*Model)
+
+		assert.NotNil(t, cmd)
+		assertNoError(t, m)
+		assert.True(t, m.toast.Visible)
+		assert.Nil(t, m.pendingSuggestion)
+		assert.True(t, m.inputMode)
+	})
+
+	t.Run("shows other errors without asking for a repair", func(t *testing.T) {
+		var requests []llm.ChatRequest
+		provider := &recordingProvider{Provider: adapters.NewReplayProviderFromText("unused"), requests: &requests}
+		m := New(nil, provider, nil, "replay", "replay", "")
+		m.ready = true
+		m.toolCallAccumulator.AddCall(mockToolCall(llm.ToolSearchContext, `{"query": "Mira"}`))
+
+		model, cmd := m.processToolCalls()
+		m = model.(*Model)
+
+		assert.Nil(t, cmd)
+		assert.Empty(t, requests)
+		require.Error(t, m.err)
+		assert.Contains(t, m.err.Error(), "search engine not initialized")
+		assert.Equal(t, 0, m.toolRepairAttempts)
+		assert.False(t, m.streaming)
+		assert.True(t, m.inputMode)
+	})
+}
+
+func TestToolRepairMessages(t *testing.T) {
+	call := mockToolCall(llm.ToolSearchContext, `{"query": 1}`)
+	cause := errors.New("bad query")
+
+	t.Run("native tools use tool role", func(t *testing.T) {
+		msgs := toolRepairMessages(call, cause, true)
+		require.Len(t, msgs, 2)
+		assert.Equal(t, llm.RoleAssistant, msgs[0].Role)
+		assert.Len(t, msgs[0].ToolCalls, 1)
+		assert.Equal(t, llm.RoleTool, msgs[1].Role)
+		assert.Equal(t, call.ID, msgs[1].ToolCallID)
+		assert.Contains(t, msgs[1].Content, "bad query")
+	})
+
+	t.Run("text tools use user role", func(t *testing.T) {
+		msgs := toolRepairMessages(call, cause, false)
+		require.Len(t, msgs, 2)
+		assert.Contains(t, msgs[0].Content, "```tool")
+		assert.Equal(t, llm.RoleUser, msgs[1].Role)
+	})
+}
+
+// recordingProvider records the requests streamed through the wrapped provider.
+type recordingProvider struct {
+	llm.Provider
+	requests *[]llm.ChatRequest
+}
+
+func (p *recordingProvider) Stream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	*p.requests = append(*p.requests, req)
+	return p.Provider.Stream(ctx, req)
+}
+
+func TestCharacterVoiceLookup(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), "context", "characters", "mira.md"), []byte(
+		"# Mira\n\n## Voice\n\nClipped sentences, sailor slang.\n",
+	), 0644))
+
+	lookup := adapters.ReplayToolCall{Name: llm.ToolGetCharacterVoice, Arguments: `{"character": "Mira"}`}
+
+	t.Run("answers the model and shows its reply", func(t *testing.T) {
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{ToolCalls: []adapters.ReplayToolCall{lookup}}},
+			{Response: adapters.ReplayResponse{Content: "\"Tide's turning,\" Mira said."}},
+		})
+		m := New(proj, provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "Write Mira's first line")
+		m = driveStream(t, m, m.startStream("Write Mira's first line"))
+
+		assertNoError(t, m)
+		assert.Equal(t, 0, provider.Remaining())
+		assert.Nil(t, m.pendingSuggestion)
+		assert.Equal(t, 1, m.toolLookups)
+		assertLastMessage(t, m, "assistant", "\"Tide's turning,\" Mira said.")
+	})
+
+	t.Run("shows the profile once lookups are exhausted", func(t *testing.T) {
+		m := New(proj, adapters.NewReplayProviderFromText(), nil, "replay", "replay", "")
+		m.ready = true
+		m.toolLookups = maxToolLookups
+		m.toolCallAccumulator.AddCall(mockToolCall(lookup.Name, lookup.Arguments))
+
+		_, cmd := m.processToolCalls()
+		require.NotNil(t, cmd)
+		msg, ok := cmd().(SuggestionMsg)
+		require.True(t, ok)
+		assert.Equal(t, SuggestionTypeVoice, msg.Suggestion.Type)
+		assert.Contains(t, msg.Suggestion.ParsedData, "Clipped sentences")
+	})
+}
+
+func TestABComparison(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	session := adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{Content: "The tide turned."}},
+	})
+	var built []string
+	factory := func(name, model string) (llm.Provider, error) {
+		built = append(built, name+"/"+model)
+		return adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{Content: "The gulls fell silent."}},
+		}), nil
+	}
+
+	m := New(proj, session, nil, "gpt-4o", "openai", "")
+	m.ready = true
+	m.width = testConfig.Width
+	m.SetProviderSwitching([]string{"gemini", "openai"}, factory)
+
+	m = sendRunesMsg(m, "/ab @gpt-4o @gemini-2.5-flash  continue the harbor scene")
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(*Model)
+	require.NotNil(t, cmd)
+	assert.True(t, m.comparing)
+	model, _ = m.Update(cmd())
+	m = model.(*Model)
+
+	assertNoError(t, m)
+	assert.Equal(t, []string{"gemini/gemini-2.5-flash"}, built)
+	require.Equal(t, ViewCompare, m.view)
+	view := m.renderComparison()
+	assert.Contains(t, view, "A · gpt-4o")
+	assert.Contains(t, view, "B · gemini-2.5-flash")
+	assert.Contains(t, view, "The tide turned.")
+	assert.Contains(t, view, "The gulls fell silent.")
+
+	m = sendKeyMsg(m, tea.KeyRight)
+	m = sendKeyMsg(m, tea.KeyEnter)
+	assert.Equal(t, ViewChat, m.view)
+	last := m.messages[len(m.messages)-1]
+	assert.Equal(t, "The gulls fell silent.", last.Content)
+	assert.Equal(t, "gemini-2.5-flash", last.Model)
+	assert.Equal(t, "continue the harbor scene", m.messages[len(m.messages)-2].Content)
+
+	history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2, "the prompt and the kept reply are saved")
+	comparisons, err := proj.DB.ListComparisons(10)
+	require.NoError(t, err)
+	require.Len(t, comparisons, 1)
+	assert.Equal(t, 1, comparisons[0].Choice)
+	assert.Equal(t, [2]string{"gpt-4o", "gemini-2.5-flash"}, comparisons[0].Variants)
+
+	t.Run("tallies the choices", func(t *testing.T) {
+		m, _ = typeAndSubmit(m, "/ab")
+		tally := m.messages[len(m.messages)-1].Content
+		assert.Contains(t, tally, "gemini-2.5-flash: kept 1 of 1")
+		assert.Contains(t, tally, "gpt-4o: kept 0 of 1")
+	})
+
+	t.Run("compares temperatures of the session model", func(t *testing.T) {
+		v, err := m.parseABVariant("1.2")
+		require.NoError(t, err)
+		assert.Equal(t, "gpt-4o at temperature 1.2", v.Label())
+		_, err = m.parseABVariant("3")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects identical variants", func(t *testing.T) {
+		m, _ = typeAndSubmit(m, "/ab 0.7 0.7 hello")
+		require.Error(t, m.err)
+		assert.Contains(t, m.err.Error(), "both variants")
+	})
+}
+
+func TestSearchContextLookup(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	engine := search.NewFTSEngine(proj.DB)
+	require.NoError(t, engine.Index("The lighthouse keeper vanished in the storm", search.SourceTypeChapter, "chapters/chapter-002.md", 8, time.Now(), "{}"))
+	require.NoError(t, engine.Index("The storm season begins", search.SourceTypeChapter, "chapters/chapter-009.md", 5, time.Now(), "{}"))
+
+	var requests []llm.ChatRequest
+	provider := &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{ToolCalls: []adapters.ReplayToolCall{{
+			Name:      llm.ToolSearchContext,
+			Arguments: `{"query": "storm", "source_types": ["chapter"], "chapter_to": 3, "limit": 5}`,
+		}}}},
+		{Response: adapters.ReplayResponse{Content: "The keeper vanished in chapter 2."}},
+	}), requests: &requests}
+
+	m := New(proj, provider, engine, "replay", "replay", "")
+	m.ready = true
+
+	addMessage(m, "user", "When did the keeper vanish?")
+	m = driveStream(t, m, m.startStream("When did the keeper vanish?"))
+
+	assertNoError(t, m)
+	assert.Nil(t, m.pendingSuggestion)
+	assertLastMessage(t, m, "assistant", "The keeper vanished in chapter 2.")
+
+	require.Len(t, requests, 2)
+	followUp := requests[1].Messages
+	answer := followUp[len(followUp)-1].Content
+	assert.Contains(t, answer, "chapters/chapter-002.md")
+	assert.NotContains(t, answer, "chapter-009")
+}
+
+func TestExpandSearchResultLookup(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	engine := search.NewFTSEngine(proj.DB)
+	for _, part := range []string{"The bell rang at dusk.", "The keeper climbed the stairs.", "The lamp was already out."} {
+		require.NoError(t, engine.Index(part, search.SourceTypeChapter, "chapters/chapter-002.md", 5, time.Now(), "{}"))
+	}
+	results, err := engine.Search("keeper", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	var requests []llm.ChatRequest
+	provider := &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{ToolCalls: []adapters.ReplayToolCall{{
+			Name:      llm.ToolExpandSearchResult,
+			Arguments: fmt.Sprintf(`{"id": %d}`, results[0].ID),
+		}}}},
+		{Response: adapters.ReplayResponse{Content: "The lamp was out when the keeper arrived."}},
+	}), requests: &requests}
+
+	m := New(proj, provider, engine, "replay", "replay", "")
+	m.ready = true
+
+	addMessage(m, "user", "Was the lamp lit?")
+	m = driveStream(t, m, m.startStream("Was the lamp lit?"))
+
+	assertNoError(t, m)
+	require.Len(t, requests, 2)
+	followUp := requests[1].Messages
+	answer := followUp[len(followUp)-1].Content
+	assert.Contains(t, answer, "The bell rang at dusk.")
+	assert.Contains(t, answer, "The lamp was already out.")
+
+	m, _ = typeAndSubmit(m, "/expand c"+strconv.FormatInt(results[0].ID, 10))
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "The lamp was already out.")
+}
+
+func TestExplainRanking(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	engine := search.NewFTSEngine(proj.DB)
+	require.NoError(t, engine.Index("The lighthouse keeper vanished in the storm.", search.SourceTypeChapter, "chapters/chapter-002.md", 8, time.Now(), "{}"))
+	results, err := engine.Search("keeper", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	provider := adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{Content: "The keeper vanished."}},
+	})
+	m := New(proj, provider, engine, "replay", "replay", "")
+	m.ready = true
+	m.contextMode = ContextHybrid
+
+	addMessage(m, "user", "keeper storm")
+	m = driveStream(t, m, m.startStream("keeper storm"))
+
+	assertNoError(t, m)
+	last := m.messages[len(m.messages)-1]
+	require.Len(t, last.Retrieval, 1)
+	assert.Equal(t, results[0].ID, last.Retrieval[0].ID)
+	assert.Equal(t, 1, last.Retrieval[0].Rank)
+	assert.NotContains(t, m.renderChat(), "bm25")
+
+	m, _ = typeAndSubmit(m, "/explain")
+	chat := m.renderChat()
+	assert.Contains(t, chat, "Retrieved context")
+	assert.Contains(t, chat, llm.CitationTag(results[0].ID)+" chapters/chapter-002.md")
+	assert.Contains(t, chat, "bm25")
+}
+
+func TestReplayGeneration(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	engine := search.NewFTSEngine(proj.DB)
+	require.NoError(t, engine.Index("The lighthouse keeper vanished in the storm.", search.SourceTypeChapter, "chapters/chapter-002.md", 8, time.Now(), "{}"))
+
+	provider := adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{Content: "The keeper vanished."}},
+		{Response: adapters.ReplayResponse{Content: "The keeper returned."}},
+		{Response: adapters.ReplayResponse{Content: "The keeper vanished."}},
+	}, adapters.WithReplayCapabilities(llm.Capabilities{SupportsStreaming: true, SupportsSeed: true, MaxContextTokens: 8192, MaxOutputTokens: 1024}))
+	m := New(proj, provider, engine, "replay", "replay", "")
+	m.ready = true
+	m.contextMode = ContextHybrid
+
+	addMessage(m, "user", "keeper storm")
+	m = driveStream(t, m, m.startStream("keeper storm"))
+	assertNoError(t, m)
+
+	records, err := proj.DB.ListGenerations(10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, "replay", record.Provider)
+	assert.Equal(t, "The keeper vanished.", record.Reply)
+	assert.NotNil(t, record.Seed, "providers that take a seed get one")
+	assert.Len(t, record.ChunkIDs, 1)
+
+	var req llm.ChatRequest
+	require.NoError(t, json.Unmarshal([]byte(record.Request), &req))
+	assert.Equal(t, llm.RoleUser, req.Messages[len(req.Messages)-1].Role)
+
+	t.Run("lists recorded requests", func(t *testing.T) {
+		m, _ = typeAndSubmit(m, "/replay")
+		assert.Contains(t, m.messages[len(m.messages)-1].Content, fmt.Sprintf("#%d", record.ID))
+	})
+
+	t.Run("compares a replay with the original", func(t *testing.T) {
+		messages := len(m.messages)
+		m = sendRunesMsg(m, fmt.Sprintf("/replay %d", record.ID))
+		model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = driveStream(t, model.(*Model), cmd)
+		assertNoError(t, m)
+
+		reply := m.messages[len(m.messages)-2]
+		assert.Equal(t, record.ID, reply.Replay)
+		assert.Equal(t, "The keeper returned.", reply.Content)
+		assert.Contains(t, m.messages[len(m.messages)-1].Content, "differs from the original reply")
+		assert.Contains(t, m.renderChat(), fmt.Sprintf("AI (replay of #%d)", record.ID))
+		assert.Len(t, m.messages, messages+3, "a note, the replay and the comparison")
+
+		for _, msg := range convertTUIMessagesToLLM(m.messages) {
+			assert.NotEqual(t, "The keeper returned.", msg.Content, "replays are not sent to the model")
+		}
+		history, err := proj.DB.GetConversationHistory(storage.MainThread, 100)
+		require.NoError(t, err)
+		for _, saved := range history {
+			assert.NotEqual(t, "The keeper returned.", saved.Content, "replays are not saved")
+		}
+		records, err := proj.DB.ListGenerations(10)
+		require.NoError(t, err)
+		assert.Len(t, records, 1, "replays are not recorded")
+
+		m = sendRunesMsg(m, fmt.Sprintf("/replay %d", record.ID))
+		model, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = driveStream(t, model.(*Model), cmd)
+		assert.Contains(t, m.messages[len(m.messages)-1].Content, "matches the original reply exactly")
+	})
+
+	t.Run("unknown request", func(t *testing.T) {
+		m, _ = typeAndSubmit(m, "/replay 999")
+		require.Error(t, m.err)
+		assert.Contains(t, m.err.Error(), "no recorded request #999")
+	})
+}
+
+func TestCitations(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	engine := search.NewFTSEngine(proj.DB)
+	require.NoError(t, engine.Index("Mira fears storms and never sails at night.", search.SourceTypeCharacter, "context/characters/mira.md", 10, time.Now(), "{}"))
+	results, err := engine.Search("storms", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	tag := llm.CitationTag(results[0].ID)
+
+	provider := adapters.NewReplayProvider([]adapters.ReplayEntry{{
+		Response: adapters.ReplayResponse{Content: "Mira stays ashore " + tag + ". The lamps go out [c9999]."},
+	}})
+	m := New(proj, provider, engine, "replay", "replay", "")
+	m.ready = true
+
+	addMessage(m, "user", "Does Mira sail in storms?")
+	m = driveStream(t, m, m.startStream("Does Mira sail in storms?"))
+
+	assertNoError(t, m)
+	last := m.messages[len(m.messages)-1]
+	require.Len(t, last.Citations, 1, "unknown tags are ignored")
+	assert.Equal(t, "context/characters/mira.md", last.Citations[0].SourcePath)
+
+	chat := m.renderChat()
+	assert.Contains(t, chat, "Sources: "+tag+" context/characters/mira.md")
+	assert.NotContains(t, chat, "never sails at night")
+
+	m, _ = typeAndSubmit(m, "/sources")
+	assert.Contains(t, m.renderChat(), "never sails at night")
+
+	m = sendKeyMsg(m, tea.KeyCtrlO)
+	assert.NotContains(t, m.renderChat(), "never sails at night")
+}
+
+func TestInterruptAndContinue(t *testing.T) {
+	t.Run("cancel keeps partial output marked interrupted", func(t *testing.T) {
+		m := newTestModel(t)
+		addMessage(m, "user", "Write the storm scene")
+		m.streaming = true
+
+		model, _ := m.Update(StreamChunkMsg{Content: "The wind rose"})
+		m = model.(*Model)
+		m = sendKeyMsg(m, tea.KeyCtrlC)
+
+		assertStreaming(t, m, false)
+		assertLastMessage(t, m, "assistant", "The wind rose")
+		assert.True(t, m.messages[len(m.messages)-1].Interrupted)
+		assert.Contains(t, m.renderChat(), "/continue")
+	})
+
+	t.Run("continue stitches onto the interrupted reply", func(t *testing.T) {
+		var requests []llm.ChatRequest
+		provider := &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{{
+			Response: adapters.ReplayResponse{Content: " and the harbor flooded."},
+		}}), requests: &requests}
+		m := New(nil, provider, nil, "replay", "replay", "")
+		m.ready = true
+		m.messages = []Message{
+			{Role: "user", Content: "Write the storm scene"},
+			{Role: "assistant", Content: "The wind rose", Interrupted: true},
+		}
+
+		setTextareaValue(m, "/continue")
+		model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = driveStream(t, model.(*Model), cmd)
+
+		assertMessageCount(t, m, 2)
+		assertLastMessage(t, m, "assistant", "The wind rose and the harbor flooded.")
+		assert.False(t, m.messages[1].Interrupted)
+
+		// The partial reply is sent back for the model to pick up from.
+		require.Len(t, requests, 1)
+		sent := requests[0].Messages
+		require.GreaterOrEqual(t, len(sent), 2)
+		assert.Equal(t, llm.NewAssistantMessage("The wind rose"), sent[len(sent)-2])
+		assert.Equal(t, llm.NewUserMessage(continuePrompt), sent[len(sent)-1])
+	})
+
+	t.Run("continue requires an AI reply", func(t *testing.T) {
+		m := newTestModel(t)
+		addMessage(m, "user", "Hello")
+
+		m.continueReply()
+
+		assertError(t, m)
+		assertStreaming(t, m, false)
+	})
+
+	t.Run("stream timeout is configurable", func(t *testing.T) {
+		m := newTestModel(t)
+		m.SetStreamTimeout(5 * time.Minute)
+		assert.Equal(t, 5*time.Minute, m.streamConfig.Timeout)
+
+		m.SetStreamTimeout(0)
+		assert.Equal(t, 5*time.Minute, m.streamConfig.Timeout)
+	})
+}
+
+func TestLengthLimitContinuation(t *testing.T) {
+	entries := []adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{Content: "The wind rose", FinishReason: llm.FinishReasonLength}},
+		{Response: adapters.ReplayResponse{Content: " and the harbor flooded."}},
+	}
+
+	t.Run("offers continue by default", func(t *testing.T) {
+		m := New(nil, adapters.NewReplayProvider(entries), nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "Write the storm scene")
+		m = driveStream(t, m, m.startStream("Write the storm scene"))
+
+		assertStreaming(t, m, false)
+		assertLastMessage(t, m, "assistant", "The wind rose")
+		assert.True(t, m.messages[len(m.messages)-1].Interrupted)
+		assert.Contains(t, m.toast.Message, "/continue")
+	})
+
+	t.Run("continues automatically when enabled", func(t *testing.T) {
+		m := New(nil, adapters.NewReplayProvider(entries), nil, "replay", "replay", "")
+		m.ready = true
+		m.SetAutoContinue(1)
+
+		addMessage(m, "user", "Write the storm scene")
+		m = driveStream(t, m, m.startStream("Write the storm scene"))
+
+		assertStreaming(t, m, false)
+		assertMessageCount(t, m, 2)
+		assertLastMessage(t, m, "assistant", "The wind rose and the harbor flooded.")
+		assert.False(t, m.messages[1].Interrupted)
+	})
+}
+
+func TestProseGuard(t *testing.T) {
+	drift := "Mira walked to the end of the pier. I was tired, and the wind had teeth. " +
+		"My hands were numb. The boats knocked together in the dark water while I thought " +
+		"about my brother and the debts he had left behind, and the lamps along the harbor were out."
+	fixed := "Mira walked to the end of the pier. She was tired, and the wind had teeth. " +
+		"Her hands were numb. The boats knocked together in the dark water while she thought " +
+		"about her brother and the debts he had left behind, and the lamps along the harbor were out."
+
+	t.Run("flags drift and rewrites on Ctrl+F", func(t *testing.T) {
+		proj := createTempProjectWithContext(t)
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{Content: drift}},
+			{Response: adapters.ReplayResponse{Content: fixed}},
+		})
+		m := New(proj, provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "Write the pier scene")
+		m.saveMessage("user", "Write the pier scene")
+		m = driveStream(t, m, m.startStream("Write the pier scene"))
+
+		issues := m.lastReplyIssues()
+		require.Len(t, issues, 1)
+		assert.Contains(t, m.renderChat(), "Ctrl+F or /fix")
+
+		model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+		m = driveStream(t, model.(*Model), cmd)
+
+		assertNoError(t, m)
+		assertMessageCount(t, m, 2)
+		assertLastMessage(t, m, "assistant", fixed)
+		assert.Empty(t, m.lastReplyIssues())
+
+		history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, fixed, history[1].Content)
+	})
+
+	t.Run("restores the original when the rewrite is empty", func(t *testing.T) {
+		proj := createTempProjectWithContext(t)
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{Content: drift}},
+			{Response: adapters.ReplayResponse{}},
+		})
+		m := New(proj, provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "Write the pier scene")
+		m = driveStream(t, m, m.startStream("Write the pier scene"))
+
+		model, cmd := m.handleCommand("/fix")
+		m = driveStream(t, model.(*Model), cmd)
+
+		require.Len(t, m.messages, 3)
+		assert.Equal(t, drift, m.messages[1].Content)
+		assert.Equal(t, "system", m.messages[2].Role)
+		assert.False(t, m.revising)
+	})
+
+	t.Run("fix without issues reports an error", func(t *testing.T) {
+		m := newTestModel(t)
+		addMessage(m, "user", "hi")
+		addMessage(m, "assistant", "Hello!")
+
+		m.fixProse()
+		assert.Error(t, m.err)
+	})
+}
+
+func TestSafetyBlock(t *testing.T) {
+	t.Run("explains the block in the project language and retries softened", func(t *testing.T) {
+		proj := createTempProjectWithContext(t)
+		proj.Config.Language = "ko"
+		proj.Config.Writing.Rating = types.RatingYA
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{FinishReason: llm.FinishReasonContentFilter}},
+			{Response: adapters.ReplayResponse{Content: "The blade flashed, and the fight was over."}},
+		})
+		m := New(proj, provider, nil, "replay", "replay", "")
+		m.ready = true
+
+		addMessage(m, "user", "Write the duel")
+		m = driveStream(t, m, m.startStream("Write the duel"))
+
+		notice := m.messages[len(m.messages)-1]
+		assert.Equal(t, "system", notice.Role)
+		assert.Contains(t, notice.Content, "안전 필터")
+		assert.Contains(t, notice.Content, "프로젝트 콘텐츠 등급: ya")
+		assert.Contains(t, notice.Content, "/retry soften")
+		assert.True(t, m.toast.Visible)
+
+		model, cmd := m.handleCommand("/retry soften")
+		m = driveStream(t, model.(*Model), cmd)
+
+		assertNoError(t, m)
+		assertLastMessage(t, m, "assistant", "The blade flashed, and the fight was over.")
+	})
+
+	t.Run("defaults to English without a project", func(t *testing.T) {
+		m := newTestModel(t)
+		m.showSafetyNotice(false)
+
+		notice := m.messages[len(m.messages)-1]
+		assert.Contains(t, notice.Content, "No reply received")
+		assert.Contains(t, notice.Content, "No content rating is set")
+	})
+
+	t.Run("retry refuses when the last request has a reply", func(t *testing.T) {
+		m := New(nil, adapters.NewReplayProviderFromText(), nil, "replay", "replay", "")
+		addMessage(m, "user", "hi")
+		addMessage(m, "assistant", "Hello!")
+
+		m.retryLastRequest(false)
+		assert.Error(t, m.err)
+	})
+}
+
+func TestModelOverride(t *testing.T) {
+	var defaultRequests, overrideRequests []llm.ChatRequest
+	session := &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{Content: "Default reply."}},
+	}), requests: &defaultRequests}
+
+	var built []string
+	factory := func(name, model string) (llm.Provider, error) {
+		built = append(built, name+"/"+model)
+		return &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{Content: "Chapter 3 in brief."}},
+		}), requests: &overrideRequests}, nil
+	}
+
+	m := New(nil, session, nil, "gpt-4o", "openai", "")
+	m.ready = true
+	m.SetProviderSwitching([]string{"gemini", "openai"}, factory)
+
+	setTextareaValue(m, "@gpt-4o-mini summarize chapter 3")
+	_, cmd := m.handleSubmit()
+	m = driveStream(t, m, cmd)
+
+	require.NoError(t, m.err)
+	assert.Equal(t, []string{"openai/gpt-4o-mini"}, built)
+	require.Len(t, overrideRequests, 1)
+	assert.Empty(t, defaultRequests)
+	assert.Equal(t, "gpt-4o", m.modelName, "the session default is unchanged")
+
+	require.Len(t, m.messages, 2)
+	assert.Equal(t, "summarize chapter 3", m.messages[0].Content)
+	assert.Equal(t, "gpt-4o-mini", m.messages[0].Model)
+	assert.Equal(t, "gpt-4o-mini", m.messages[1].Model)
+	assert.Contains(t, m.renderChat(), "AI (gpt-4o-mini): Chapter 3 in brief.")
+
+	t.Run("next prompt uses the session default", func(t *testing.T) {
+		setTextareaValue(m, "and now chapter 4")
+		_, cmd := m.handleSubmit()
+		m = driveStream(t, m, cmd)
+
+		assert.Len(t, defaultRequests, 1)
+		assert.Len(t, overrideRequests, 1)
+		assert.Empty(t, m.messages[len(m.messages)-1].Model)
+	})
+
+	t.Run("resolves the provider", func(t *testing.T) {
+		assert.Equal(t, modelChoice{Provider: "gemini", Model: "gemini-2.5-flash"}, m.resolveModelOverride("gemini-2.5-flash"))
+		assert.Equal(t, modelChoice{Provider: "gemini", Model: "custom"}, m.resolveModelOverride("gemini/custom"))
+		assert.Equal(t, modelChoice{Provider: "openai", Model: "library/llama3"}, m.resolveModelOverride("library/llama3"))
+	})
+
+	t.Run("ignores a bare mention", func(t *testing.T) {
+		ref, prompt := parseModelOverride("@gpt-4o-mini")
+		assert.Empty(t, ref)
+		assert.Equal(t, "@gpt-4o-mini", prompt)
+	})
+
+	t.Run("keeps the draft without a factory", func(t *testing.T) {
+		m := New(nil, session, nil, "gpt-4o", "openai", "")
+		setTextareaValue(m, "@gpt-4o-mini hello")
+		m.handleSubmit()
+		require.Error(t, m.err)
+		assert.Contains(t, m.err.Error(), "not available")
+		assert.Equal(t, "@gpt-4o-mini hello", getTextareaValue(m))
+		assert.Empty(t, m.messages)
+	})
+}
+
+func TestPolish(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	proj.Config.LLM.PolishModel = "gemini/gemini-2.5-pro"
+
+	var polishRequests []llm.ChatRequest
+	var built []string
+	factory := func(name, model string) (llm.Provider, error) {
+		built = append(built, name+"/"+model)
+		return &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{Content: "The tide turned."}},
+		}), requests: &polishRequests}, nil
+	}
+	draft := adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{Content: "The tide, it was turning, slowly turning."}},
+	})
+
+	m := New(proj, draft, nil, "gpt-4o", "openai", "")
+	m.ready = true
+	m.SetProviderSwitching([]string{"gemini", "openai"}, factory)
+
+	_, cmd := m.polishLastPassage()
+	assert.Nil(t, cmd)
+	require.Error(t, m.err)
+	m.err = nil
+
+	setTextareaValue(m, "Write the harbor scene")
+	_, cmd = m.handleSubmit()
+	m = driveStream(t, m, cmd)
+
+	setTextareaValue(m, "/polish")
+	_, cmd = m.handleSubmit()
+	m = driveStream(t, m, cmd)
+
+	require.NoError(t, m.err)
+	assert.Equal(t, []string{"gemini/gemini-2.5-pro"}, built)
+	require.Len(t, polishRequests, 1)
+	sent := polishRequests[0].Messages
+	assert.Equal(t, "The tide, it was turning, slowly turning.", sent[len(sent)-2].Content)
+	assert.Equal(t, polishPrompt, sent[len(sent)-1].Content)
+	assert.Equal(t, "gpt-4o", m.modelName)
+
+	history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, "gpt-4o", history[1].Model)
+	assert.Equal(t, "The tide turned.", history[3].Content)
+	assert.Equal(t, "gemini-2.5-pro", history[3].Model)
+	assert.Contains(t, m.renderChat(), "AI (gemini-2.5-pro): The tide turned.")
+}
+
+func TestSelfCritique(t *testing.T) {
+	newModel := func(t *testing.T, critique string, requests *[]llm.ChatRequest) (*Model, *project.Project) {
+		proj := createTempProjectWithContext(t)
+		proj.Config.LLM.SelfCritique = true
+		provider := &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{Content: "Rain fell. It was wet."}},
+			{Response: adapters.ReplayResponse{Content: critique}},
+			{Response: adapters.ReplayResponse{Content: "Rain hammered the harbor."}},
+		})}
+		provider.requests = requests
+		m := New(proj, provider, nil, "gpt-4o", "openai", "")
+		m.ready = true
+		return m, proj
+	}
+
+	t.Run("shows the draft, the folded critique and the revision", func(t *testing.T) {
+		var requests []llm.ChatRequest
+		m, proj := newModel(t, "- \"It was wet\" tells instead of shows.", &requests)
+
+		setTextareaValue(m, "Write the storm")
+		_, cmd := m.handleSubmit()
+		m = driveStream(t, m, cmd)
+
+		require.NoError(t, m.err)
+		require.Len(t, m.messages, 4)
+		assert.True(t, m.messages[1].Draft)
+		assert.True(t, m.messages[2].Critique)
+		assert.True(t, m.messages[2].Folded)
+		assert.Equal(t, "Rain hammered the harbor.", m.messages[3].Content)
+		assert.False(t, m.critiquing)
+		assert.False(t, m.refining)
+
+		require.Len(t, requests, 3)
+		critiqueRequest := requests[1].Messages
+		assert.Equal(t, "Rain fell. It was wet.", critiqueRequest[len(critiqueRequest)-2].Content)
+		assert.Equal(t, critiquePrompt, critiqueRequest[len(critiqueRequest)-1].Content)
+		reviseRequest := requests[2].Messages
+		assert.Contains(t, reviseRequest[len(reviseRequest)-2].Content, "tells instead of shows")
+		assert.Equal(t, critiqueRevisePrompt, reviseRequest[len(reviseRequest)-1].Content)
+
+		chat := m.renderChat()
+		assert.Contains(t, chat, "AI (draft): Rain fell.")
+		assert.Contains(t, chat, "AI (critique)")
+		assert.Contains(t, chat, "AI: Rain hammered the harbor.")
+
+		history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 3, "the critique is not saved")
+		assert.True(t, history[1].Draft)
+		assert.Equal(t, "Rain hammered the harbor.", history[2].Content)
+
+		sent := convertTUIMessagesToLLM(m.messages)
+		require.Len(t, sent, 2, "the draft and critique are not sent again")
+		assert.Equal(t, "Rain hammered the harbor.", sent[1].Content)
+	})
+
+	t.Run("keeps the draft when the critique is empty", func(t *testing.T) {
+		var requests []llm.ChatRequest
+		m, proj := newModel(t, "", &requests)
+
+		setTextareaValue(m, "Write the storm")
+		_, cmd := m.handleSubmit()
+		m = driveStream(t, m, cmd)
+
+		require.Len(t, m.messages, 2)
+		assert.False(t, m.messages[1].Draft)
+		assert.Len(t, requests, 2, "no revision is asked for")
+
+		history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.False(t, history[1].Draft)
+	})
+
+	t.Run("can be turned off for the session", func(t *testing.T) {
+		var requests []llm.ChatRequest
+		m, _ := newModel(t, "Critique.", &requests)
+
+		m, _ = typeAndSubmit(m, "/critique")
+		assert.False(t, m.selfCritique)
+		setTextareaValue(m, "Write the storm")
+		_, cmd := m.handleSubmit()
+		m = driveStream(t, m, cmd)
+
+		assert.Len(t, requests, 1)
+		assert.Len(t, m.messages, 2)
+	})
+}
+
+func TestDraftThenRefine(t *testing.T) {
+	newModel := func(t *testing.T, refined string, requests *[]llm.ChatRequest) (*Model, *project.Project) {
+		proj := createTempProjectWithContext(t)
+		proj.Config.LLM.RefineModel = "gemini/gemini-2.5-pro"
+
+		factory := func(name, model string) (llm.Provider, error) {
+			return &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+				{Response: adapters.ReplayResponse{Content: refined}},
+			}), requests: requests}, nil
+		}
+		draft := adapters.NewReplayProvider([]adapters.ReplayEntry{
+			{Response: adapters.ReplayResponse{Content: "Rain fell. It was wet."}},
+			{Response: adapters.ReplayResponse{Content: "Dawn came."}},
+		})
+
+		m := New(proj, draft, nil, "llama3", "local", "")
+		m.ready = true
+		m.SetProviderSwitching([]string{"gemini", "local"}, factory)
+		return m, proj
+	}
+
+	t.Run("shows the draft and the revision", func(t *testing.T) {
+		var refineRequests []llm.ChatRequest
+		m, proj := newModel(t, "Rain hammered the harbor.", &refineRequests)
+
+		setTextareaValue(m, "Write the storm")
+		_, cmd := m.handleSubmit()
+		m = driveStream(t, m, cmd)
+
+		require.NoError(t, m.err)
+		require.Len(t, m.messages, 3)
+		assert.True(t, m.messages[1].Draft)
+		assert.Equal(t, "llama3", m.messages[1].Model)
+		assert.Equal(t, "Rain hammered the harbor.", m.messages[2].Content)
+		assert.Equal(t, "gemini-2.5-pro", m.messages[2].Model)
+		assert.False(t, m.refining)
+
+		require.Len(t, refineRequests, 1)
+		sent := refineRequests[0].Messages
+		assert.Equal(t, "Rain fell. It was wet.", sent[len(sent)-2].Content)
+		assert.Equal(t, refinePrompt, sent[len(sent)-1].Content)
+
+		chat := m.renderChat()
+		assert.Contains(t, chat, "AI (draft · llama3): Rain fell.")
+		assert.Contains(t, chat, "AI (gemini-2.5-pro): Rain hammered the harbor.")
+
+		history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.True(t, history[1].Draft)
+		assert.Equal(t, "llama3", history[1].Model)
+		assert.False(t, history[2].Draft)
+		assert.Equal(t, "gemini-2.5-pro", history[2].Model)
+
+		for _, msg := range convertTUIMessagesToLLM(m.messages) {
+			assert.NotEqual(t, "Rain fell. It was wet.", msg.Content, "drafts are not sent again")
+		}
+	})
+
+	t.Run("keeps the draft when the revision is empty", func(t *testing.T) {
+		var refineRequests []llm.ChatRequest
+		m, proj := newModel(t, "", &refineRequests)
+
+		setTextareaValue(m, "Write the storm")
+		_, cmd := m.handleSubmit()
+		m = driveStream(t, m, cmd)
+
+		require.Len(t, m.messages, 2)
+		assert.False(t, m.messages[1].Draft)
+		assert.Equal(t, "Rain fell. It was wet.", m.messages[1].Content)
+
+		history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.False(t, history[1].Draft)
+	})
+
+	t.Run("does not refine a prompt sent to a chosen model", func(t *testing.T) {
+		var refineRequests []llm.ChatRequest
+		m, _ := newModel(t, "Refined.", &refineRequests)
+
+		setTextareaValue(m, "@gemini/gemini-2.5-flash Write the storm")
+		_, cmd := m.handleSubmit()
+		m = driveStream(t, m, cmd)
+
+		require.Len(t, refineRequests, 1, "the override itself is served by the factory")
+		assert.Len(t, m.messages, 2)
+	})
+}
+
+func TestInsights(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := New(proj, nil, nil, "gpt-4o", "openai", "")
+	m.ready = true
+
+	m, _ = typeAndSubmit(m, "/insights")
+	require.NoError(t, m.err)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Insights are off")
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "nothing is sent anywhere")
+
+	m, _ = typeAndSubmit(m, "/insights on")
+	require.NoError(t, m.err)
+	assert.True(t, proj.Config.Insights)
+	assert.NotZero(t, m.session)
+
+	m.pendingSuggestion = &SuggestionResult{Title: "Add Mira"}
+	m.acceptSuggestion()
+	m.pendingSuggestion = &SuggestionResult{Title: "Rename Mira"}
+	m.rejectSuggestion()
+	m.toolCallAccumulator.AddDelta(&llm.ToolCallDelta{Index: 0, ID: "call_1", Function: &llm.FunctionCallDelta{Name: llm.ToolAskUserClarification, Arguments: `{"question":"Whose POV?"}`}})
+	m.processToolCalls()
+	m.quit()
+	assert.Zero(t, m.session)
+
+	m, _ = typeAndSubmit(m, "/insights")
+	require.NoError(t, m.err)
+	report := m.messages[len(m.messages)-1].Content
+	assert.Contains(t, report, "Sessions: 1, 0s long on average")
+	assert.Contains(t, report, "Words per session: 0 on average")
+	assert.Contains(t, report, "1 accepted, 1 rejected (50% accepted)")
+	assert.Contains(t, report, "Most-used tools: "+llm.ToolAskUserClarification+" ×1")
+	assert.Contains(t, report, "Most-used commands: /insights ×1")
+
+	m, _ = typeAndSubmit(m, "/insights off")
+	assert.False(t, proj.Config.Insights)
+	m, _ = typeAndSubmit(m, "/insights")
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Insights are off")
+}
+
+// chatRecordingProvider records the requests sent with Chat.
+type chatRecordingProvider struct {
+	llm.Provider
+	requests *[]llm.ChatRequest
+}
+
+func (p *chatRecordingProvider) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	*p.requests = append(*p.requests, req)
+	return p.Provider.Chat(ctx, req)
+}
+
+func TestPreferenceDigest(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	var requests []llm.ChatRequest
+	provider := &chatRecordingProvider{Provider: adapters.NewReplayProviderFromText("The fog rolls in.", "- The author dislikes sudden betrayals."), requests: &requests}
+	m := New(proj, provider, nil, "gpt-4o", "openai", "")
+	m.ready = true
+
+	suggest := func(title string) {
+		m.pendingSuggestion = &SuggestionResult{Type: SuggestionTypePlot, Title: title, Content: title + "."}
+		m.view = ViewSuggestion
+	}
+
+	// Modifying keeps the author's next message as what they asked for.
+	suggest("Jun betrays Mira")
+	model, cmd := m.handleSuggestionKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	m = model.(*Model)
+	assert.Nil(t, cmd, "one choice is not enough to learn from")
+	setTextareaValue(m, "No betrayal; make it a misunderstanding")
+	_, cmd = m.handleSubmit()
+	m = driveStream(t, m, cmd)
+	assert.Zero(t, m.modifiedSuggestion)
+
+	var refresh tea.Cmd
+	for i, title := range []string{"Mira betrays the crew", "The keeper lies", "A storm", "Jun leaves"} {
+		suggest(title)
+		if i%2 == 0 {
+			_, refresh = m.rejectSuggestion()
+		} else {
+			_, refresh = m.acceptSuggestion()
+		}
+	}
+	require.NotNil(t, refresh, "the fifth choice updates the digest")
+	assert.True(t, m.refreshingPreferences)
+
+	m.Update(refresh())
+	assert.False(t, m.refreshingPreferences)
+	digest, err := proj.DB.GetPreferenceDigest()
+	require.NoError(t, err)
+	require.NotNil(t, digest)
+	assert.Equal(t, "- The author dislikes sudden betrayals.", digest.Text)
+
+	sent := requests[len(requests)-1].Messages[1].Content
+	assert.Contains(t, sent, "[plot, modified] Jun betrays Mira")
+	assert.Contains(t, sent, "The author asked instead: No betrayal; make it a misunderstanding")
+	assert.Contains(t, sent, "[plot, rejected] Mira betrays the crew")
+
+	// The digest is sent with each request.
+	dry, err := AssembleDryRun(proj, stubProvider{caps: llm.Capabilities{MaxContextTokens: 128000, MaxOutputTokens: 4096}}, "stub", ContextEssential, nil, "Next scene")
+	require.NoError(t, err)
+	assert.Contains(t, dry.SystemPrompt, "## Author Preferences")
+	assert.Contains(t, dry.SystemPrompt, "dislikes sudden betrayals")
+
+	m, _ = typeAndSubmit(m, "/preferences")
+	require.NoError(t, m.err)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "2 accepted, 2 rejected, 1 modified")
+	m, _ = typeAndSubmit(m, "/preferences clear")
+	digest, err = proj.DB.GetPreferenceDigest()
+	require.NoError(t, err)
+	assert.Nil(t, digest)
+}
+
+func TestApplyPlotSuggestion(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+
+	h := NewSuggestionHandler(proj, nil)
+	result, err := h.HandleToolCall(mockToolCall(llm.ToolSuggestPlotDevelopment, `{"suggestions": [
+		{"title": "The Keeper Lies", "description": "The keeper hid the letter.", "impact": "Mira stops trusting him"},
+		{"title": "Storm Night", "description": "The ferry cannot cross."}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Add 2 to the outline", result.Actions[1].Label)
+
+	m.pendingSuggestion = result
+	m.view = ViewSuggestion
+	model, _ := m.handleSuggestionKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	m = model.(*Model)
+	require.NoError(t, m.err)
+	assert.Equal(t, ViewChat, m.view)
+	assert.Nil(t, m.pendingSuggestion)
+
+	path := filepath.Join("context", "plot", "the-keeper-lies.md")
+	content, err := proj.FS.ReadMarkdown(path)
+	require.NoError(t, err)
+	assert.Contains(t, content, "The keeper hid the letter.")
+	assert.Contains(t, content, "Impact: Mira stops trusting him")
+	assertLastMessage(t, m, "system", "Added to the outline: The Keeper Lies")
+	assert.Contains(t, m.textarea.Value(), "Draft the scene where this happens: The Keeper Lies.")
+
+	// A draft in the composer is left alone.
+	setTextareaValue(m, "My own note")
+	m.pendingSuggestion = result
+	m.view = ViewSuggestion
+	model, _ = m.handleSuggestionKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	m = model.(*Model)
+	assert.FileExists(t, filepath.Join(proj.Path(), "context", "plot", "storm-night.md"))
+	assert.Equal(t, "My own note", m.textarea.Value())
+}
+
+func TestAnswerClarification(t *testing.T) {
+	var requests []llm.ChatRequest
+	provider := &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{ToolCalls: []adapters.ReplayToolCall{{
+			Name:      llm.ToolAskUserClarification,
+			Arguments: `{"question":"Whose POV?","options":["Mira","Jun"]}`,
+		}}}},
+		{Response: adapters.ReplayResponse{Content: "Mira watched the storm roll in."}},
+	}), requests: &requests}
+	m := New(nil, provider, nil, "replay", "replay", "")
+	m.ready = true
+
+	m, cmd := typeAndSubmit(m, "continue the scene")
+	m = driveStream(t, m, cmd)
+	require.NotNil(t, m.pendingSuggestion)
+	assert.Equal(t, "1", m.pendingSuggestion.Actions[0].Key, "options do not clash with the accept key")
+
+	// Choosing an option answers the model instead of only logging it.
+	model, cmd := m.handleSuggestionKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	m = model.(*Model)
+	assert.Nil(t, m.pendingSuggestion)
+	require.NotNil(t, cmd)
+	m = driveStream(t, m, cmd)
+
+	require.Len(t, requests, 2)
+	sent := requests[1].Messages[len(requests[1].Messages)-1]
+	assert.Equal(t, llm.RoleUser, sent.Role)
+	assert.Contains(t, sent.Content, "You asked: Whose POV?\nMy answer: Mira")
+	assertLastMessage(t, m, "assistant", "Mira watched the storm roll in.")
+}
+
+func TestAutoApproveContextUpdates(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	proj.Config.AutoApprove = []types.AutoApproveRule{{FileType: "plot", Operation: "append", MaxTokens: 50}, {FileType: "character"}}
+	plotPath := filepath.Join(proj.Path(), "context", "plot", "main-arc.md")
+
+	suggest := func(args string) *Model {
+		provider := adapters.NewReplayProvider([]adapters.ReplayEntry{{Response: adapters.ReplayResponse{
+			ToolCalls: []adapters.ReplayToolCall{{Name: llm.ToolUpdateContext, Arguments: args}},
+		}}})
+		m := New(proj, provider, nil, "replay", "replay", "")
+		m.ready = true
+		m, cmd := typeAndSubmit(m, "what happens next")
+		return driveStream(t, m, cmd)
+	}
+
+	// A short append to a plot file is applied without asking.
+	m := suggest(`{"file_type": "plot", "file_name": "main-arc", "operation": "append", "content": "The ferry sinks.", "reason": "Next beat"}`)
+	require.NoError(t, m.err)
+	assert.Nil(t, m.pendingSuggestion)
+	assert.Equal(t, ViewChat, m.view)
+	assert.False(t, m.streaming)
+	assertLastMessage(t, m, "system", "approved automatically")
+	content, err := os.ReadFile(plotPath)
+	require.NoError(t, err)
+	assert.Equal(t, "The ferry sinks.", string(content))
+
+	m, _ = typeAndSubmit(m, "/undo")
+	require.NoError(t, m.err)
+	assert.NoFileExists(t, plotPath)
+	m, _ = typeAndSubmit(m, "/undo")
+	assert.Error(t, m.err, "there is nothing left to undo")
+
+	// Longer content, and new characters, still ask.
+	m = suggest(`{"file_type": "plot", "file_name": "main-arc", "operation": "append", "content": "` + strings.Repeat("The ferry sinks. ", 40) + `", "reason": "Next beat"}`)
+	require.NotNil(t, m.pendingSuggestion)
+	assert.Equal(t, ViewSuggestion, m.view)
+	m = suggest(`{"file_type": "character", "file_name": "jun", "operation": "create", "content": "Jun, the ferryman.", "reason": "New character"}`)
+	require.NotNil(t, m.pendingSuggestion)
+	assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "characters", "jun.md"))
+}
+
+func TestPendingReviewQueue(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	update := func(name, content string) adapters.ReplayEntry {
+		return adapters.ReplayEntry{Response: adapters.ReplayResponse{ToolCalls: []adapters.ReplayToolCall{{
+			Name:      llm.ToolUpdateContext,
+			Arguments: fmt.Sprintf(`{"file_type": "plot", "file_name": %q, "operation": "append", "content": %q, "reason": "Next beat"}`, name, content),
+		}}}}
+	}
+	provider := adapters.NewReplayProvider([]adapters.ReplayEntry{
+		update("storm", "The ferry sinks."),
+		update("keeper", "The keeper lies."),
+		update("letter", "Mira finds the letter."),
+	})
+	m := New(proj, provider, nil, "replay", "replay", "")
+	m.ready = true
+	m, _ = typeAndSubmit(m, "/pending on")
+	require.NoError(t, m.err)
+	assert.True(t, proj.Config.QueueContextUpdates)
+
+	for _, prompt := range []string{"next", "and then", "after that"} {
+		var cmd tea.Cmd
+		m, cmd = typeAndSubmit(m, prompt)
+		m = driveStream(t, m, cmd)
+		assert.Nil(t, m.pendingSuggestion, "updates are queued, not asked about")
+		assert.Equal(t, ViewChat, m.view)
+	}
+	require.Len(t, m.reviewQueue, 3)
+
+	// The review shows every diff; k closes it and keeps them queued.
+	m, _ = typeAndSubmit(m, "/pending")
+	require.NotNil(t, m.pendingSuggestion)
+	assert.Equal(t, ViewSuggestion, m.view)
+	assert.Contains(t, m.pendingSuggestion.Content, "The ferry sinks.")
+	assert.Contains(t, m.pendingSuggestion.Content, "Mira finds the letter.")
+	model, _ := m.handleSuggestionKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	m = model.(*Model)
+	assert.Len(t, m.reviewQueue, 3)
+
+	m, _ = typeAndSubmit(m, "/pending reject 2")
+	require.NoError(t, m.err)
+	m, _ = typeAndSubmit(m, "/pending approve 2")
+	require.NoError(t, m.err)
+	assert.FileExists(t, filepath.Join(proj.Path(), "context", "plot", "letter.md"))
+	require.Len(t, m.reviewQueue, 1)
+
+	// Approving the rest applies them together.
+	m, _ = typeAndSubmit(m, "/pending")
+	model, _ = m.handleSuggestionKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	m = model.(*Model)
+	require.NoError(t, m.err)
+	assert.Empty(t, m.reviewQueue)
+	assert.FileExists(t, filepath.Join(proj.Path(), "context", "plot", "storm.md"))
+	assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "plot", "keeper.md"))
+}
+
+func TestToolPermissions(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	proj.Config.Tools = types.ToolPermissions{Deny: []string{llm.ToolUpdateContext}}
+
+	var requests []llm.ChatRequest
+	provider := &recordingProvider{Provider: adapters.NewReplayProvider([]adapters.ReplayEntry{{Response: adapters.ReplayResponse{
+		ToolCalls: []adapters.ReplayToolCall{{
+			Name:      llm.ToolUpdateContext,
+			Arguments: `{"file_type": "plot", "file_name": "storm", "operation": "create", "content": "The ferry sinks.", "reason": "Next beat"}`,
+		}},
+	}}}), requests: &requests}
+	m := New(proj, provider, nil, "replay", "replay", "")
+	m.ready = true
+
+	m, cmd := typeAndSubmit(m, "what happens next")
+	m = driveStream(t, m, cmd)
+
+	require.Len(t, requests, 1)
+	require.NotEmpty(t, requests[0].Tools)
+	for _, tool := range requests[0].Tools {
+		assert.NotEqual(t, llm.ToolUpdateContext, tool.Function.Name)
+	}
+
+	// A call to a tool that was not offered is refused.
+	assert.Nil(t, m.pendingSuggestion)
+	assertLastMessage(t, m, "system", "do not allow update_context")
+	assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "plot", "storm.md"))
+}
@@ -122,6 +122,11 @@ var (
 			Foreground(TextMuted).
 			Italic(true)
 
+	// Token counter over budget
+	TokenWarning = lipgloss.NewStyle().
+			Foreground(Accent).
+			Bold(true)
+
 	// Chapter marker
 	ChapterMarker = lipgloss.NewStyle().
 			Foreground(Secondary).
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// handleSubplotsCommand handles /subplots [N | tag <id> [chapter] |
+// untag <id> [chapter]].
+func (m *Model) handleSubplotsCommand(args []string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	if len(args) == 0 {
+		m.showSubplotReport(0)
+		return
+	}
+	if n, err := strconv.Atoi(args[0]); err == nil {
+		m.showSubplotReport(n)
+		return
+	}
+
+	op := strings.ToLower(args[0])
+	if (op != "tag" && op != "untag") || len(args) < 2 || len(args) > 3 {
+		m.err = fmt.Errorf("usage: /subplots [N | tag <id> [chapter] | untag <id> [chapter]]")
+		return
+	}
+	chapter, err := m.chapterOrLatest(args[2:])
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	id := strings.ToLower(args[1])
+	if op == "tag" {
+		err = m.project.TagSubplot(chapter, id)
+		m.statusText = fmt.Sprintf("Chapter %d tagged with %s", chapter, id)
+	} else {
+		err = m.project.UntagSubplot(chapter, id)
+		m.statusText = fmt.Sprintf("Removed %s from chapter %d", id, chapter)
+	}
+	if err != nil {
+		m.statusText = ""
+		m.err = err
+		return
+	}
+	m.showSubplotReport(0)
+}
+
+// chapterOrLatest returns the chapter named in args, or the latest one.
+func (m *Model) chapterOrLatest(args []string) (int, error) {
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid chapter: %s", args[0])
+		}
+		return n, nil
+	}
+	chapters, err := m.project.LoadChapters()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	if len(chapters) == 0 {
+		return 0, fmt.Errorf("no chapters yet")
+	}
+	return chapters[len(chapters)-1].Number, nil
+}
+
+// showSubplotReport shows how the subplots interleave and which have gone
+// untouched for minGap chapters or more.
+func (m *Model) showSubplotReport(minGap int) {
+	report, err := m.project.SubplotReport(minGap)
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: report.String()})
+	m.updateViewport()
+}
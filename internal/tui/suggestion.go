@@ -2,9 +2,11 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/azyu/dreamteller/internal/llm"
@@ -23,6 +25,8 @@ const (
 	SuggestionTypeClarification   SuggestionType = "clarification"
 	SuggestionTypeContextUpdate   SuggestionType = "context_update"
 	SuggestionTypeSearch          SuggestionType = "search"
+	SuggestionTypeMemory          SuggestionType = "memory"
+	SuggestionTypeVoice           SuggestionType = "voice"
 )
 
 // SuggestionAction represents an action the user can take on a suggestion.
@@ -42,6 +46,14 @@ type SuggestionResult struct {
 	ToolCallID       string
 	ToolCall         llm.ToolCall
 	ParsedData       interface{}
+
+	// snapshots are the context files a context update changes, as they
+	// were when it was suggested.
+	snapshots map[string]contextSnapshot
+
+	// queued are the queued suggestions a /pending review combines, put
+	// back in the queue if applying them fails.
+	queued []*SuggestionResult
 }
 
 // SuggestionHandler processes AI tool calls and prepares them for display.
@@ -58,11 +70,19 @@ func NewSuggestionHandler(proj *project.Project, searchEngine *search.FTSEngine)
 	}
 }
 
+// errInvalidToolArguments marks a tool call whose arguments do not parse or
+// do not match the tool's schema, which the model can correct when asked.
+var errInvalidToolArguments = errors.New("invalid tool arguments")
+
 // HandleToolCall processes a tool call and returns a displayable result.
 func (h *SuggestionHandler) HandleToolCall(call llm.ToolCall) (*SuggestionResult, error) {
+	if err := llm.ValidateToolArguments(call); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidToolArguments, err)
+	}
+
 	parsed, err := llm.ParseToolCall(call)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse tool call: %w", err)
+		return nil, fmt.Errorf("%w: %w", errInvalidToolArguments, err)
 	}
 
 	switch call.Function.Name {
@@ -101,6 +121,48 @@ func (h *SuggestionHandler) HandleToolCall(call llm.ToolCall) (*SuggestionResult
 		}
 		return h.handleSearch(call, query)
 
+	case llm.ToolExpandSearchResult:
+		query, ok := parsed.(llm.ExpandQuery)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type for expand query")
+		}
+		return h.handleExpandSearchResult(call, query)
+
+	case llm.ToolRememberFact:
+		fact, ok := parsed.(llm.MemoryFact)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type for memory fact")
+		}
+		return h.handleRememberFact(call, fact)
+
+	case llm.ToolGetCharacterVoice:
+		query, ok := parsed.(llm.CharacterVoiceQuery)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type for character voice query")
+		}
+		return h.handleCharacterVoice(call, query)
+
+	case llm.ToolUpdateChapter:
+		edit, ok := parsed.(llm.ChapterEdit)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type for chapter edit")
+		}
+		return h.handleChapterEdit(call, edit)
+
+	case llm.ToolGetTravelTime:
+		query, ok := parsed.(llm.TravelQuery)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type for travel query")
+		}
+		return h.handleTravelTime(call, query)
+
+	case llm.ToolMoveItem:
+		move, ok := parsed.(llm.ItemMove)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type for item move")
+		}
+		return h.handleMoveItem(call, move)
+
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", call.Function.Name)
 	}
@@ -123,17 +185,12 @@ func (h *SuggestionHandler) handlePlotSuggestion(call llm.ToolCall, suggestions
 		sb.WriteString("\n")
 	}
 
+	// Choosing a suggestion adds it to the outline; see applyPlotSuggestion.
 	actions := make([]SuggestionAction, len(suggestions))
-	for i, sugg := range suggestions {
-		selectedSugg := sugg
+	for i := range suggestions {
 		actions[i] = SuggestionAction{
-			Label: fmt.Sprintf("Use suggestion %d", i+1),
+			Label: fmt.Sprintf("Add %d to the outline", i+1),
 			Key:   fmt.Sprintf("%d", i+1),
-			Handler: func() error {
-				// Handler returns nil; caller uses selectedSugg to continue
-				_ = selectedSugg
-				return nil
-			},
 		}
 	}
 
@@ -216,19 +273,13 @@ func (h *SuggestionHandler) handleClarification(call llm.ToolCall, question llm.
 		sb.WriteString(styles.Subtitle.Render("Options:"))
 		sb.WriteString("\n")
 
+		// Options are numbered so they do not clash with the accept,
+		// reject and modify keys. Choosing one answers the model; see
+		// answerClarification.
 		for i, opt := range question.Options {
-			key := string(rune('a' + i))
+			key := strconv.Itoa(i + 1)
 			sb.WriteString(fmt.Sprintf("  [%s] %s\n", key, opt))
-
-			selectedOpt := opt
-			actions = append(actions, SuggestionAction{
-				Label: selectedOpt,
-				Key:   key,
-				Handler: func() error {
-					_ = selectedOpt
-					return nil
-				},
-			})
+			actions = append(actions, SuggestionAction{Label: opt, Key: key})
 		}
 	}
 
@@ -247,8 +298,8 @@ func (h *SuggestionHandler) handleClarification(call llm.ToolCall, question llm.
 // handleContextUpdate validates and formats a context update for approval.
 func (h *SuggestionHandler) handleContextUpdate(call llm.ToolCall, update llm.ContextUpdate) (*SuggestionResult, error) {
 	// Validate the path for security
-	if err := llm.ValidateContextUpdatePath(update.FileType, update.FileName); err != nil {
-		return nil, fmt.Errorf("invalid context update path: %w", err)
+	if err := validateContextUpdate(update); err != nil {
+		return nil, err
 	}
 
 	var sb strings.Builder
@@ -264,7 +315,7 @@ func (h *SuggestionHandler) handleContextUpdate(call llm.ToolCall, update llm.Co
 	sb.WriteString("\n\n")
 
 	// Build the file path for diff preview
-	relativePath := filepath.Join("context", pluralizeFileType(update.FileType), update.FileName+".md")
+	relativePath := contextUpdatePath(update)
 
 	// Show diff preview based on operation
 	switch update.Operation {
@@ -292,6 +343,19 @@ func (h *SuggestionHandler) handleContextUpdate(call llm.ToolCall, update llm.Co
 		sb.WriteString(styles.MutedText.Render("Content to append:"))
 		sb.WriteString("\n")
 		sb.WriteString(formatContentPreview(update.Content, "+"))
+
+	case "rename":
+		sb.WriteString(styles.SuccessText.Render(fmt.Sprintf("→ %s", filepath.ToSlash(h.destinationPath(update)))))
+		sb.WriteString("\n")
+		sb.WriteString(styles.MutedText.Render("The content is kept as it is."))
+		sb.WriteString("\n")
+
+	case "archive":
+		sb.WriteString(styles.ErrorText.Render(fmt.Sprintf("- Moved to %s, out of the story bible and search", filepath.ToSlash(h.destinationPath(update)))))
+		sb.WriteString("\n\n")
+		if existingContent, err := h.readExistingContent(relativePath); err == nil {
+			sb.WriteString(formatContentPreview(existingContent, "-"))
+		}
 	}
 
 	updateCopy := update
@@ -319,7 +383,7 @@ func (h *SuggestionHandler) handleContextUpdate(call llm.ToolCall, update llm.Co
 		},
 	}
 
-	return &SuggestionResult{
+	result := &SuggestionResult{
 		Type:             SuggestionTypeContextUpdate,
 		Title:            fmt.Sprintf("Context Update: %s", update.FileName),
 		Content:          sb.String(),
@@ -328,7 +392,76 @@ func (h *SuggestionHandler) handleContextUpdate(call llm.ToolCall, update llm.Co
 		ToolCallID:       call.ID,
 		ToolCall:         call,
 		ParsedData:       update,
-	}, nil
+	}
+	if h.project != nil {
+		result.snapshots = map[string]contextSnapshot{relativePath: h.snapshotContextFile(relativePath)}
+	}
+	return result, nil
+}
+
+// HandleContextUpdates combines the update_context calls of one reply, such
+// as a new character with the setting and plot note that go with it, into
+// a single suggestion that is accepted or rejected as a whole. On error it
+// also returns the index of the call that failed.
+func (h *SuggestionHandler) HandleContextUpdates(calls []llm.ToolCall) (*SuggestionResult, int, error) {
+	updates := make([]llm.ContextUpdate, 0, len(calls))
+	sections := make([]string, 0, len(calls))
+	snapshots := make(map[string]contextSnapshot)
+	for i, call := range calls {
+		result, err := h.HandleToolCall(call)
+		if err != nil {
+			return nil, i, err
+		}
+		update, ok := result.ParsedData.(llm.ContextUpdate)
+		if !ok {
+			return nil, i, fmt.Errorf("unexpected type for context update")
+		}
+		updates = append(updates, update)
+		sections = append(sections, result.Content)
+		for path, snapshot := range result.snapshots {
+			if _, ok := snapshots[path]; !ok {
+				snapshots[path] = snapshot
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(styles.MutedText.Render("These changes are applied together: if one fails, none is kept."))
+	sb.WriteString("\n\n")
+	sb.WriteString(strings.Join(sections, "\n\n"))
+
+	return &SuggestionResult{
+		Type:    SuggestionTypeContextUpdate,
+		Title:   fmt.Sprintf("Context Updates: %d changes", len(updates)),
+		Content: sb.String(),
+		Actions: []SuggestionAction{
+			{
+				Label: "Accept all",
+				Key:   "a",
+				Handler: func() error {
+					_, err := h.ExecuteContextUpdates(updates)
+					return err
+				},
+			},
+			{Label: "Reject all", Key: "r", Handler: func() error { return nil }},
+			{Label: "Edit before saving", Key: "e", Handler: func() error { return nil }},
+		},
+		RequiresApproval: true,
+		ToolCallID:       calls[0].ID,
+		ToolCall:         calls[0],
+		ParsedData:       updates,
+		snapshots:        snapshots,
+	}, 0, nil
+}
+
+// allContextUpdates reports whether every call is an update_context call.
+func allContextUpdates(calls []llm.ToolCall) bool {
+	for _, call := range calls {
+		if call.Function.Name != llm.ToolUpdateContext {
+			return false
+		}
+	}
+	return true
 }
 
 // handleSearch executes a search query and formats the results.
@@ -337,16 +470,12 @@ func (h *SuggestionHandler) handleSearch(call llm.ToolCall, query llm.SearchQuer
 		return nil, fmt.Errorf("search engine not initialized")
 	}
 
-	var results []search.FTSSearchResult
-	var err error
-
-	// Execute search based on filter type
-	if query.FilterType != "" && query.FilterType != "all" {
-		results, err = h.searchEngine.SearchWithFilter(query.Query, query.FilterType, 10)
-	} else {
-		results, err = h.searchEngine.Search(query.Query, 10)
+	filter := search.SearchFilter{
+		SourceTypes: query.Types(),
+		ChapterFrom: query.ChapterFrom,
+		ChapterTo:   query.ChapterTo,
 	}
-
+	results, err := h.searchEngine.SearchWithFuzzyFallback(query.Query, filter, query.ResultLimit())
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
@@ -356,8 +485,8 @@ func (h *SuggestionHandler) handleSearch(call llm.ToolCall, query llm.SearchQuer
 	sb.WriteString(styles.Title.Render(fmt.Sprintf("Search: \"%s\"", query.Query)))
 	sb.WriteString("\n")
 
-	if query.FilterType != "" && query.FilterType != "all" {
-		sb.WriteString(styles.MutedText.Render(fmt.Sprintf("Filtered by: %s", query.FilterType)))
+	if desc := describeSearchFilter(filter); desc != "" {
+		sb.WriteString(styles.MutedText.Render(fmt.Sprintf("Filtered by: %s", desc)))
 		sb.WriteString("\n")
 	}
 	sb.WriteString("\n")
@@ -366,11 +495,15 @@ func (h *SuggestionHandler) handleSearch(call llm.ToolCall, query llm.SearchQuer
 		sb.WriteString(styles.MutedText.Render("No results found."))
 		sb.WriteString("\n")
 	} else {
-		sb.WriteString(styles.InfoText.Render(fmt.Sprintf("Found %d result(s):", len(results))))
+		found := fmt.Sprintf("Found %d result(s):", len(results))
+		if results[0].Fuzzy {
+			found = fmt.Sprintf("No exact matches. Found %d fuzzy match(es) with similar spellings:", len(results))
+		}
+		sb.WriteString(styles.InfoText.Render(found))
 		sb.WriteString("\n\n")
 
 		for i, result := range results {
-			sb.WriteString(styles.Subtitle.Render(fmt.Sprintf("%d. [%s] %s", i+1, result.SourceType, result.SourcePath)))
+			sb.WriteString(styles.Subtitle.Render(fmt.Sprintf("%d. [%s] %s", i+1, result.SourceType, result.Location())))
 			sb.WriteString("\n")
 
 			// Show a snippet of the content (first 200 chars)
@@ -392,90 +525,437 @@ func (h *SuggestionHandler) handleSearch(call llm.ToolCall, query llm.SearchQuer
 	}, nil
 }
 
-// ExecuteContextUpdate applies the context update after user approval.
-func (h *SuggestionHandler) ExecuteContextUpdate(update llm.ContextUpdate) error {
-	// Re-validate for safety
-	if err := llm.ValidateContextUpdatePath(update.FileType, update.FileName); err != nil {
-		return fmt.Errorf("invalid context update path: %w", err)
+// describeSearchFilter summarizes a search filter for display.
+func describeSearchFilter(filter search.SearchFilter) string {
+	var parts []string
+	if len(filter.SourceTypes) > 0 {
+		parts = append(parts, strings.Join(filter.SourceTypes, ", "))
 	}
-
-	if h.project == nil {
-		return fmt.Errorf("no project loaded")
+	switch {
+	case filter.ChapterFrom > 0 && filter.ChapterTo > 0:
+		parts = append(parts, fmt.Sprintf("chapters %d-%d", filter.ChapterFrom, filter.ChapterTo))
+	case filter.ChapterFrom > 0:
+		parts = append(parts, fmt.Sprintf("chapters %d+", filter.ChapterFrom))
+	case filter.ChapterTo > 0:
+		parts = append(parts, fmt.Sprintf("chapters up to %d", filter.ChapterTo))
 	}
+	return strings.Join(parts, "; ")
+}
 
-	category := pluralizeFileType(update.FileType)
-	relativePath := filepath.Join("context", category, update.FileName+".md")
+// maxSearchAnswerRunes caps each search result returned to the model.
+const maxSearchAnswerRunes = 1200
 
-	switch update.Operation {
-	case "create":
-		return h.createContextFile(relativePath, update.Content)
+// formatSearchAnswer renders search results as a plain-text tool result
+// for the model.
+func formatSearchAnswer(results []search.FTSSearchResult) string {
+	if len(results) == 0 {
+		return "No results found."
+	}
 
-	case "update":
-		return h.updateContextFile(relativePath, update.Content)
+	var sb strings.Builder
+	if results[0].Fuzzy {
+		sb.WriteString(fmt.Sprintf("No exact matches. Found %d fuzzy match(es): passages with words spelled like the query's, which may not be what was meant. ", len(results)))
+	} else {
+		sb.WriteString(fmt.Sprintf("Found %d result(s). ", len(results)))
+	}
+	sb.WriteString(fmt.Sprintf("Cite the ones you use by their tag; call %s with a tag's number to read more around a result.", llm.ToolExpandSearchResult))
+	for _, r := range results {
+		content := strings.TrimSpace(r.Content)
+		if runes := []rune(content); len(runes) > maxSearchAnswerRunes {
+			content = string(runes[:maxSearchAnswerRunes]) + "..."
+		}
+		sb.WriteString(fmt.Sprintf("\n\n%s %s (%s)\n%s", llm.CitationTag(r.ID), r.Location(), r.SourceType, llm.QuoteContext(content)))
+	}
+	return sb.String()
+}
 
-	case "append":
-		return h.appendToContextFile(relativePath, update.Content)
+// handleCharacterVoice looks up a character's voice profile. The result is
+// answered back to the model rather than approved by the user.
+func (h *SuggestionHandler) handleCharacterVoice(call llm.ToolCall, query llm.CharacterVoiceQuery) (*SuggestionResult, error) {
+	if h.project == nil {
+		return nil, fmt.Errorf("no project loaded")
+	}
 
+	var profile string
+	character, err := h.project.FindCharacter(query.Character)
+	switch {
+	case err != nil:
+		profile = fmt.Sprintf("No character named %q was found.", query.Character)
+	case !character.HasVoice():
+		profile = fmt.Sprintf("%s has no voice profile yet. Infer their voice from the character notes.", character.Name)
 	default:
-		return fmt.Errorf("unknown operation: %s", update.Operation)
+		profile = formatVoiceProfile(character)
 	}
+
+	return &SuggestionResult{
+		Type:             SuggestionTypeVoice,
+		Title:            fmt.Sprintf("Looking up %s's voice", query.Character),
+		Content:          styles.MutedText.Render(profile),
+		RequiresApproval: false,
+		ToolCallID:       call.ID,
+		ToolCall:         call,
+		ParsedData:       profile,
+	}, nil
 }
 
-// createContextFile creates a new context file.
-func (h *SuggestionHandler) createContextFile(relativePath, content string) error {
-	fullPath := filepath.Join(h.project.Path(), relativePath)
+// handleRememberFact formats a fact the model wants to remember for approval.
+func (h *SuggestionHandler) handleRememberFact(call llm.ToolCall, fact llm.MemoryFact) (*SuggestionResult, error) {
+	if strings.TrimSpace(fact.Fact) == "" {
+		return nil, fmt.Errorf("empty memory fact")
+	}
 
-	// Check if file already exists
-	if _, err := os.Stat(fullPath); err == nil {
-		return fmt.Errorf("file already exists: %s", relativePath)
+	var sb strings.Builder
+	sb.WriteString(styles.Quote.Render(fact.Fact))
+	sb.WriteString("\n")
+	if fact.Reason != "" {
+		sb.WriteString("\n")
+		sb.WriteString(styles.MutedText.Render(fmt.Sprintf("Reason: %s", fact.Reason)))
+		sb.WriteString("\n")
 	}
 
-	// Ensure directory exists
-	dir := filepath.Dir(fullPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	return &SuggestionResult{
+		Type:             SuggestionTypeMemory,
+		Title:            "Remember This Fact?",
+		Content:          sb.String(),
+		RequiresApproval: true,
+		ToolCallID:       call.ID,
+		ToolCall:         call,
+		ParsedData:       fact,
+	}, nil
+}
+
+// SaveMemory stores a fact in project memory.
+func (h *SuggestionHandler) SaveMemory(fact, source string) error {
+	if h.project == nil || h.project.DB == nil {
+		return fmt.Errorf("no project loaded")
 	}
+	_, err := h.project.DB.SaveMemory(strings.TrimSpace(fact), source)
+	return err
+}
 
-	// Write file atomically
-	return storage.AtomicWriteFile(fullPath, []byte(content))
+// validateContextUpdate checks the file an update names: a known context
+// type, and a file name that is safe on every platform.
+func validateContextUpdate(update llm.ContextUpdate) error {
+	if err := llm.ValidateContextUpdatePath(update.FileType, update.FileName); err != nil {
+		return fmt.Errorf("invalid context update path: %w", err)
+	}
+	if err := storage.ValidateFileName(update.FileName + ".md"); err != nil {
+		return fmt.Errorf("invalid context update path: %w", err)
+	}
+	if update.Operation != "rename" {
+		return nil
+	}
+	if update.NewName == "" || update.NewName == update.FileName {
+		return fmt.Errorf("a rename needs a new_name different from file_name")
+	}
+	if err := llm.ValidateContextUpdatePath(update.FileType, update.NewName); err != nil {
+		return fmt.Errorf("invalid rename target: %w", err)
+	}
+	if err := storage.ValidateFileName(update.NewName + ".md"); err != nil {
+		return fmt.Errorf("invalid rename target: %w", err)
+	}
+	return nil
+}
+
+// ExecuteContextUpdate applies the context update after user approval.
+func (h *SuggestionHandler) ExecuteContextUpdate(update llm.ContextUpdate) error {
+	_, err := h.ExecuteContextUpdates([]llm.ContextUpdate{update})
+	return err
 }
 
-// updateContextFile replaces the content of an existing context file.
-func (h *SuggestionHandler) updateContextFile(relativePath, content string) error {
-	fullPath := filepath.Join(h.project.Path(), relativePath)
+// writeContextFile writes a file applied from a context update. It is a
+// variable so tests can replace it.
+var writeContextFile = storage.AtomicWriteFile
+
+// removeContextFile removes a file a context update renamed or archived.
+var removeContextFile = os.Remove
 
-	// Check if file exists
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		return fmt.Errorf("file does not exist: %s", relativePath)
+// contextSnapshot is a context file as it was when a suggestion for it was
+// shown, so accepting can tell whether it changed on disk since.
+type contextSnapshot struct {
+	exists  bool
+	content string
+}
+
+// pendingFile is a file touched by a set of context updates: what it held
+// before, for rollback, and what it will hold after, unless it is deleted
+// because it was renamed or archived.
+type pendingFile struct {
+	path     string
+	existed  bool
+	original []byte
+	content  string
+	deleted  bool
+}
+
+// ExecuteContextUpdates applies related context updates, such as a new
+// character with the setting and plot note that go with it, as one
+// transaction: either every update is applied or none is. All updates are
+// checked before anything is written, and files already written are
+// restored if a later write fails. It returns the paths of the files
+// written, relative to the project root.
+func (h *SuggestionHandler) ExecuteContextUpdates(updates []llm.ContextUpdate) ([]string, error) {
+	return h.executeContextUpdates(updates, nil)
+}
+
+// executeContextUpdates is ExecuteContextUpdates for updates suggested
+// when the files were as snapshots records. If any of them has changed on
+// disk since, nothing is written and the error is a *contextConflictError
+// holding a three-way merge of each changed file.
+func (h *SuggestionHandler) executeContextUpdates(updates []llm.ContextUpdate, snapshots map[string]contextSnapshot) ([]string, error) {
+	// Re-validate for safety
+	for _, update := range updates {
+		if err := validateContextUpdate(update); err != nil {
+			return nil, err
+		}
 	}
 
-	// Write file atomically
-	return storage.AtomicWriteFile(fullPath, []byte(content))
+	if h.project == nil {
+		return nil, fmt.Errorf("no project loaded")
+	}
+
+	conflicts, err := h.findConflicts(updates, snapshots)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return nil, &contextConflictError{updates: updates, snapshots: snapshots, conflicts: conflicts}
+	}
+
+	files, err := h.planContextUpdates(updates, h.readContextFile)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range files {
+		fullPath := filepath.Join(h.project.Path(), f.path)
+		var err error
+		if !f.deleted {
+			err = writeContextFile(fullPath, []byte(f.content))
+		} else if f.existed {
+			err = removeContextFile(fullPath)
+		}
+		if err != nil {
+			if rbErr := h.rollback(files[:i]); rbErr != nil {
+				return nil, fmt.Errorf("failed to write %s: %w (rollback failed: %v)", f.path, err, rbErr)
+			}
+			return nil, fmt.Errorf("failed to write %s, no changes were made: %w", f.path, err)
+		}
+	}
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
 }
 
-// appendToContextFile appends content to an existing context file.
-func (h *SuggestionHandler) appendToContextFile(relativePath, content string) error {
-	fullPath := filepath.Join(h.project.Path(), relativePath)
+// contextUpdatePath returns the file an update writes, relative to the
+// project root. A rename or archive moves it to its destinationPath.
+func contextUpdatePath(update llm.ContextUpdate) string {
+	return filepath.Join("context", pluralizeFileType(update.FileType), update.FileName+".md")
+}
 
-	// Read existing content
-	existing, err := os.ReadFile(fullPath)
+// destinationPath returns where a rename or archive update moves its file,
+// relative to the project root, or "" for other updates. An archived file
+// takes a numbered name if one of that name was archived before.
+func (h *SuggestionHandler) destinationPath(update llm.ContextUpdate) string {
+	switch update.Operation {
+	case "rename":
+		return filepath.Join("context", pluralizeFileType(update.FileType), update.NewName+".md")
+	case "archive":
+		dir := filepath.Join(project.ArchiveDir, pluralizeFileType(update.FileType))
+		path := filepath.Join(dir, update.FileName+".md")
+		for i := 2; h.project != nil && h.project.FS.Exists(path); i++ {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d.md", update.FileName, i))
+		}
+		return path
+	}
+	return ""
+}
+
+// readContextFile reads a context file for a transaction: its state, and
+// the raw bytes to restore on rollback.
+func (h *SuggestionHandler) readContextFile(relativePath string) (contextSnapshot, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(h.project.Path(), relativePath))
+	if os.IsNotExist(err) {
+		return contextSnapshot{}, nil, nil
+	}
 	if err != nil {
-		if os.IsNotExist(err) {
-			// If file doesn't exist, create it
-			return h.createContextFile(relativePath, content)
+		return contextSnapshot{}, nil, fmt.Errorf("failed to read existing file: %w", err)
+	}
+	content, _ := storage.DecodeText(data)
+	return contextSnapshot{exists: true, content: content}, data, nil
+}
+
+// snapshotContextFile records the state of a context file a suggestion
+// will change.
+func (h *SuggestionHandler) snapshotContextFile(relativePath string) contextSnapshot {
+	snapshot, _, _ := h.readContextFile(relativePath)
+	return snapshot
+}
+
+// planContextUpdates works out the final content of every file the updates
+// touch, in order, so a later update sees the result of an earlier one on
+// the same file. It fails without writing anything if any update cannot be
+// applied.
+func (h *SuggestionHandler) planContextUpdates(updates []llm.ContextUpdate, read func(string) (contextSnapshot, []byte, error)) ([]*pendingFile, error) {
+	var files []*pendingFile
+	byPath := make(map[string]*pendingFile)
+	for _, update := range updates {
+		relativePath := contextUpdatePath(update)
+		f, ok := byPath[relativePath]
+		if !ok {
+			state, data, err := read(relativePath)
+			if err != nil {
+				return nil, err
+			}
+			f = &pendingFile{path: relativePath, existed: state.exists, original: data, content: state.content}
+		}
+		exists := (f.existed || ok) && !f.deleted
+
+		switch update.Operation {
+		case "create":
+			if exists {
+				return nil, fmt.Errorf("file already exists: %s", relativePath)
+			}
+			f.content = update.Content
+			f.deleted = false
+
+		case "update":
+			if !exists {
+				return nil, fmt.Errorf("file does not exist: %s", relativePath)
+			}
+			f.content = update.Content
+
+		case "append":
+			if !exists {
+				f.content = update.Content
+				f.deleted = false
+				break
+			}
+			// Append new content with a separator, in UTF-8 with LF line
+			// endings like the new content
+			if !strings.HasSuffix(f.content, "\n") {
+				f.content += "\n"
+			}
+			f.content += "\n" + update.Content
+
+		case "rename", "archive":
+			if !exists {
+				return nil, fmt.Errorf("file does not exist: %s", relativePath)
+			}
+			dest := h.destinationPath(update)
+			d, planned := byPath[dest]
+			if !planned {
+				state, data, err := read(dest)
+				if err != nil {
+					return nil, err
+				}
+				d = &pendingFile{path: dest, existed: state.exists, original: data, content: state.content}
+			}
+			if (d.existed || planned) && !d.deleted {
+				return nil, fmt.Errorf("file already exists: %s", dest)
+			}
+			d.content = f.content
+			d.deleted = false
+			f.deleted = true
+			if !planned {
+				byPath[dest] = d
+				files = append(files, d)
+			}
+
+		default:
+			return nil, fmt.Errorf("unknown operation: %s", update.Operation)
+		}
+
+		if !ok {
+			byPath[relativePath] = f
+			files = append(files, f)
 		}
-		return fmt.Errorf("failed to read existing file: %w", err)
 	}
+	return files, nil
+}
+
+// findConflicts compares the files the updates touch against their
+// snapshots and merges each one that changed on disk: the snapshot is the
+// common base, the file on disk one side, and the updates applied to the
+// snapshot the other.
+func (h *SuggestionHandler) findConflicts(updates []llm.ContextUpdate, snapshots map[string]contextSnapshot) ([]contextConflict, error) {
+	var conflicts []contextConflict
+	var suggested map[string]*pendingFile
+	seen := make(map[string]bool)
+	for _, update := range updates {
+		// A rename or archive moves the file as it is on disk.
+		if update.Operation == "rename" || update.Operation == "archive" {
+			continue
+		}
+		path := contextUpdatePath(update)
+		base, ok := snapshots[path]
+		if !ok || seen[path] {
+			continue
+		}
+		seen[path] = true
+		disk, _, err := h.readContextFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if disk == base {
+			continue
+		}
 
-	// Append new content with a separator
-	newContent := string(existing)
-	if !strings.HasSuffix(newContent, "\n") {
-		newContent += "\n"
+		if suggested == nil {
+			planned, err := h.planContextUpdates(updates, func(path string) (contextSnapshot, []byte, error) {
+				if snapshot, ok := snapshots[path]; ok {
+					return snapshot, nil, nil
+				}
+				return h.readContextFile(path)
+			})
+			if err != nil {
+				return nil, err
+			}
+			suggested = make(map[string]*pendingFile, len(planned))
+			for _, p := range planned {
+				suggested[p.path] = p
+			}
+		}
+		ours := suggested[path].content
+
+		merged, count := mergeLines(base.content, disk.content, ours)
+		conflicts = append(conflicts, contextConflict{
+			path:      path,
+			fileType:  update.FileType,
+			fileName:  update.FileName,
+			base:      base,
+			disk:      disk,
+			suggested: ours,
+			merged:    merged,
+			conflicts: count,
+		})
 	}
-	newContent += "\n" + content
+	return conflicts, nil
+}
 
-	// Write file atomically
-	return storage.AtomicWriteFile(fullPath, []byte(newContent))
+// rollback restores files written by a failed transaction: files that
+// existed get their original bytes back, new files are removed.
+func (h *SuggestionHandler) rollback(files []*pendingFile) error {
+	var errs []string
+	for _, f := range files {
+		fullPath := filepath.Join(h.project.Path(), f.path)
+		var err error
+		if f.existed {
+			err = storage.AtomicWriteFile(fullPath, f.original)
+		} else if err = os.Remove(fullPath); os.IsNotExist(err) {
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
 }
 
 // readExistingContent reads the content of an existing context file.
@@ -498,6 +978,10 @@ func formatOperation(op string) string {
 		return "Update"
 	case "append":
 		return "Append to"
+	case "rename":
+		return "Rename"
+	case "archive":
+		return "Archive"
 	default:
 		return strings.ToUpper(op[:1]) + op[1:]
 	}
@@ -613,6 +1097,19 @@ func (a *ToolCallAccumulator) AddDelta(delta *llm.ToolCallDelta) {
 	}
 }
 
+// AddCall adds a complete tool call, such as one parsed from text output.
+func (a *ToolCallAccumulator) AddCall(call llm.ToolCall) {
+	a.AddDelta(&llm.ToolCallDelta{
+		Index: len(a.calls),
+		ID:    call.ID,
+		Type:  call.Type,
+		Function: &llm.FunctionCallDelta{
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		},
+	})
+}
+
 // GetCompletedCalls returns all accumulated tool calls.
 func (a *ToolCallAccumulator) GetCompletedCalls() []llm.ToolCall {
 	result := make([]llm.ToolCall, 0, len(a.calls))
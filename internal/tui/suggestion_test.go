@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,7 +11,9 @@ import (
 	"github.com/azyu/dreamteller/internal/llm"
 	"github.com/azyu/dreamteller/internal/project"
 	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/storage"
 	"github.com/azyu/dreamteller/pkg/types"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -762,6 +765,8 @@ func TestFormatOperation(t *testing.T) {
 		{"create", "Create"},
 		{"update", "Update"},
 		{"append", "Append to"},
+		{"rename", "Rename"},
+		{"archive", "Archive"},
 		{"other", "Other"},
 	}
 
@@ -910,3 +915,348 @@ func (m *mockSearchEngine) Search(query string, limit int) ([]search.FTSSearchRe
 func (m *mockSearchEngine) SearchWithFilter(query, filterType string, limit int) ([]search.FTSSearchResult, error) {
 	return m.results, m.err
 }
+
+func TestExecuteContextUpdates_Transaction(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	h := NewSuggestionHandler(proj, nil)
+	hanaPath := filepath.Join(proj.Path(), "context", "characters", "hana.md")
+	original, err := os.ReadFile(hanaPath)
+	require.NoError(t, err)
+
+	t.Run("applies related updates together", func(t *testing.T) {
+		paths, err := h.ExecuteContextUpdates([]llm.ContextUpdate{
+			{FileType: "character", FileName: "mira", Operation: "create", Content: "# Mira\n"},
+			{FileType: "setting", FileName: "harbor", Operation: "create", Content: "# Harbor\n"},
+			{FileType: "setting", FileName: "harbor", Operation: "append", Content: "Mira's ship docks here."},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join("context", "characters", "mira.md"),
+			filepath.Join("context", "settings", "harbor.md"),
+		}, paths)
+		content, err := os.ReadFile(filepath.Join(proj.Path(), "context", "settings", "harbor.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Harbor\n\nMira's ship docks here.", string(content))
+	})
+
+	t.Run("writes nothing when an update cannot apply", func(t *testing.T) {
+		_, err := h.ExecuteContextUpdates([]llm.ContextUpdate{
+			{FileType: "character", FileName: "jun", Operation: "create", Content: "# Jun\n"},
+			{FileType: "plot", FileName: "missing", Operation: "update", Content: "x"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not exist")
+		assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "characters", "jun.md"))
+	})
+
+	t.Run("rolls back written files when a write fails", func(t *testing.T) {
+		writes := 0
+		writeContextFile = func(path string, data []byte) error {
+			if writes++; writes == 3 {
+				return fmt.Errorf("disk full")
+			}
+			return storage.AtomicWriteFile(path, data)
+		}
+		defer func() { writeContextFile = storage.AtomicWriteFile }()
+
+		_, err := h.ExecuteContextUpdates([]llm.ContextUpdate{
+			{FileType: "character", FileName: "hana", Operation: "append", Content: "She learned to sail."},
+			{FileType: "character", FileName: "jun", Operation: "create", Content: "# Jun\n"},
+			{FileType: "plot", FileName: "voyage", Operation: "create", Content: "# Voyage\n"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no changes were made")
+
+		content, err := os.ReadFile(hanaPath)
+		require.NoError(t, err)
+		assert.Equal(t, original, content)
+		assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "characters", "jun.md"))
+		assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "plot", "voyage.md"))
+	})
+}
+
+func TestAcceptContextUpdates(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.searchEngine = search.NewFTSEngine(proj.DB)
+	calls := []llm.ToolCall{
+		{ID: "1", Type: "function", Function: llm.FunctionCall{Name: llm.ToolUpdateContext,
+			Arguments: `{"file_type": "character", "file_name": "mira", "operation": "create", "content": "# Mira\n\nA harbor pilot.", "reason": "new character"}`}},
+		{ID: "2", Type: "function", Function: llm.FunctionCall{Name: llm.ToolUpdateContext,
+			Arguments: `{"file_type": "setting", "file_name": "harbor", "operation": "create", "content": "# Harbor\n\nWhere Mira works.", "reason": "her workplace"}`}},
+	}
+	suggestion, _, err := m.suggestionHandler.HandleContextUpdates(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "Context Updates: 2 changes", suggestion.Title)
+
+	m.pendingSuggestion = suggestion
+	m.acceptSuggestion()
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "context/characters/mira.md, context/settings/harbor.md")
+
+	results, err := m.searchEngine.Search("pilot", 5)
+	require.NoError(t, err)
+	require.NotEmpty(t, results, "the new file is indexed")
+}
+
+func TestRenameAndArchiveContext(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.searchEngine = search.NewFTSEngine(proj.DB)
+	require.NoError(t, m.reindexContextFile(filepath.Join("context", "settings", "seoul.md")))
+
+	accept := func(args string) {
+		t.Helper()
+		suggestion, err := m.suggestionHandler.HandleToolCall(mockToolCall(llm.ToolUpdateContext, args))
+		require.NoError(t, err)
+		m.pendingSuggestion = suggestion
+		m.acceptSuggestion()
+		assertNoError(t, m)
+	}
+
+	accept(`{"file_type": "setting", "file_name": "seoul", "operation": "rename", "new_name": "rainy-seoul", "reason": "clearer name"}`)
+	assertLastMessage(t, m, "system", "Rename applied: context/settings/seoul.md → context/settings/rainy-seoul.md")
+	assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "settings", "seoul.md"))
+	content, err := os.ReadFile(filepath.Join(proj.Path(), "context", "settings", "rainy-seoul.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "빗속의 네온")
+	results, err := m.searchEngine.Search("네온", 5)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, filepath.Join("context", "settings", "rainy-seoul.md"), results[0].SourcePath)
+
+	accept(`{"file_type": "setting", "file_name": "rainy-seoul", "operation": "archive", "reason": "the story left Seoul"}`)
+	assert.FileExists(t, filepath.Join(proj.Path(), "archive", "settings", "rainy-seoul.md"))
+	assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "settings", "rainy-seoul.md"))
+	results, err = m.searchEngine.Search("네온", 5)
+	require.NoError(t, err)
+	assert.Empty(t, results, "archived files leave the index")
+	assert.True(t, proj.ExcludeList().Excludes(filepath.Join("archive", "settings", "rainy-seoul.md")))
+
+	// A rename needs a new name that is free.
+	_, err = m.suggestionHandler.HandleToolCall(mockToolCall(llm.ToolUpdateContext,
+		`{"file_type": "character", "file_name": "hana", "operation": "rename", "reason": "no name"}`))
+	assert.Error(t, err)
+	_, err = m.suggestionHandler.ExecuteContextUpdates([]llm.ContextUpdate{
+		{FileType: "character", FileName: "mira", Operation: "create", Content: "# Mira\n"},
+		{FileType: "character", FileName: "mira", Operation: "rename", NewName: "hana"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+	assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "characters", "mira.md"))
+}
+
+func TestMergeLines(t *testing.T) {
+	base := "# Hana\n\n- Pilot\n- Calm\n\nNotes.\n"
+	tests := []struct {
+		name          string
+		disk, ours    string
+		wantMerged    string
+		wantConflicts int
+	}{
+		{
+			name:       "changes to different lines merge cleanly",
+			disk:       "# Hana\n\n- Harbor pilot\n- Calm\n\nNotes.\n",
+			ours:       "# Hana\n\n- Pilot\n- Calm\n\nNotes.\nAfraid of storms.\n",
+			wantMerged: "# Hana\n\n- Harbor pilot\n- Calm\n\nNotes.\nAfraid of storms.\n",
+		},
+		{
+			name:       "the same change on both sides",
+			disk:       "# Hana\n\n- Pilot\n- Cold\n\nNotes.\n",
+			ours:       "# Hana\n\n- Pilot\n- Cold\n\nNotes.\n",
+			wantMerged: "# Hana\n\n- Pilot\n- Cold\n\nNotes.\n",
+		},
+		{
+			name:          "different changes to the same line conflict",
+			disk:          "# Hana\n\n- Pilot\n- Cold\n\nNotes.\n",
+			ours:          "# Hana\n\n- Pilot\n- Warm\n\nNotes.\n",
+			wantMerged:    "# Hana\n\n- Pilot\n<<<<<<< on disk\n- Cold\n=======\n- Warm\n>>>>>>> suggested\n\nNotes.\n",
+			wantConflicts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := mergeLines(base, tt.disk, tt.ours)
+			assert.Equal(t, tt.wantConflicts, conflicts)
+			assert.Equal(t, tt.wantMerged, merged)
+		})
+	}
+}
+
+func TestAcceptContextUpdate_ChangedOnDisk(t *testing.T) {
+	newModel := func(t *testing.T) (*Model, string) {
+		proj := createTempProjectWithContext(t)
+		m := newTestModelWithProject(t, proj)
+		call := llm.ToolCall{ID: "1", Type: "function", Function: llm.FunctionCall{Name: llm.ToolUpdateContext,
+			Arguments: `{"file_type": "character", "file_name": "hana", "operation": "append", "content": "Afraid of storms.", "reason": "new trait"}`}}
+		suggestion, err := m.suggestionHandler.HandleToolCall(call)
+		require.NoError(t, err)
+		m.pendingSuggestion = suggestion
+		m.view = ViewSuggestion
+		return m, filepath.Join(proj.Path(), "context", "characters", "hana.md")
+	}
+
+	t.Run("shows a merge instead of overwriting", func(t *testing.T) {
+		m, path := newModel(t)
+		edited := "# 하나\n\n- 주인공\n- 냉정함\n\n추가 설명."
+		require.NoError(t, os.WriteFile(path, []byte(edited), 0644))
+
+		m.acceptSuggestion()
+		assertNoError(t, m)
+		assert.Equal(t, ViewSuggestion, m.view)
+		require.Equal(t, SuggestionTypeMerge, m.pendingSuggestion.Type)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, edited, string(data), "the edit on disk is kept")
+
+		m.acceptSuggestion()
+		assertNoError(t, m)
+		assert.Equal(t, ViewChat, m.view)
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "- 냉정함")
+		assert.Contains(t, string(data), "Afraid of storms.")
+	})
+
+	t.Run("refuses to apply a merge with conflicts", func(t *testing.T) {
+		m, path := newModel(t)
+		edited := "# 하나\n\n- 주인공\n- 냉정하지만 따뜻함\n\n다른 설명."
+		require.NoError(t, os.WriteFile(path, []byte(edited), 0644))
+
+		m.acceptSuggestion()
+		require.Equal(t, SuggestionTypeMerge, m.pendingSuggestion.Type)
+		m.acceptSuggestion()
+		require.Error(t, m.err)
+		assert.Equal(t, ViewSuggestion, m.view)
+
+		m.err = nil
+		m.handleSuggestionKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+		assertNoError(t, m)
+		assert.Equal(t, ViewContext, m.view)
+		require.NotNil(t, m.contextEdit)
+		assert.Contains(t, m.contextEdit.editor.Value(), conflictDisk)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, edited, string(data), "nothing is written until the editor saves")
+	})
+
+	t.Run("unchanged files apply directly", func(t *testing.T) {
+		m, path := newModel(t)
+		m.acceptSuggestion()
+		assertNoError(t, m)
+		assert.Equal(t, ViewChat, m.view)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "Afraid of storms.")
+	})
+}
+
+func TestChapterEditSuggestion(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	chapterPath := filepath.Join(proj.Path(), "chapters", "chapter-001.md")
+	require.NoError(t, os.WriteFile(chapterPath, []byte("# The Harbor\n\nHana ran to the pier. The rain fell.\nThe rain fell harder.\n"), 0644))
+
+	propose := func(args string) (*SuggestionResult, error) {
+		return m.suggestionHandler.HandleToolCall(mockToolCall(llm.ToolUpdateChapter, args))
+	}
+
+	_, err := propose(`{"chapter": 1, "edits": [{"find": "Hana walked", "replace": "Hana fled"}], "reason": "r"}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+	_, err = propose(`{"chapter": 1, "edits": [{"find": "The rain fell", "replace": "Rain hammered"}], "reason": "r"}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "appears 2 times")
+	_, err = propose(`{"chapter": 1, "edits": [{"find": "ran to the", "replace": "fled to the"}, {"find": "to the pier", "replace": "to the docks"}], "reason": "r"}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "overlap")
+	_, err = propose(`{"chapter": 2, "edits": [{"find": "a", "replace": "b"}], "reason": "r"}`)
+	assert.Error(t, err)
+
+	suggestion, err := propose(`{"chapter": 1, "edits": [{"find": "Hana ran", "replace": "Hana sprinted"}, {"find": "fell harder.", "replace": "fell harder still."}], "reason": "tension"}`)
+	require.NoError(t, err)
+	assert.Equal(t, SuggestionTypeChapterEdit, suggestion.Type)
+	assert.True(t, suggestion.RequiresApproval)
+	assert.Contains(t, suggestion.Content, "Line 3")
+	assert.Contains(t, suggestion.Content, "- Hana ran")
+	assert.Contains(t, suggestion.Content, "+ Hana sprinted")
+
+	// Text written while the edit was pending is kept.
+	require.NoError(t, os.WriteFile(chapterPath, []byte("# The Harbor\n\nHana ran to the pier. The rain fell.\nThe rain fell harder.\n\nA ship waited.\n"), 0644))
+	m.pendingSuggestion = suggestion
+	m.acceptSuggestion()
+	assertNoError(t, m)
+	content, err := os.ReadFile(chapterPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# The Harbor\n\nHana sprinted to the pier. The rain fell.\nThe rain fell harder still.\n\nA ship waited.\n", string(content))
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Chapter 1 edited (2 changes)")
+
+	// An edit that no longer applies is refused without writing.
+	m.pendingSuggestion = suggestion
+	m.acceptSuggestion()
+	require.Error(t, m.err)
+	assert.Contains(t, m.err.Error(), "changed since the edit was proposed")
+	after, err := os.ReadFile(chapterPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, after)
+}
+
+func TestTravelTimeLookup(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.FS.WriteMarkdown(filepath.Join("context", "settings", "busan.md"),
+		"# Busan\n\n## Location\n\n- Kind: city\n\n## Travel\n\n- [[Seoul]]: 3 hours by train\n"))
+	m := newTestModelWithProject(t, proj)
+	handler := NewSuggestionHandler(proj, nil)
+
+	suggestion, err := handler.HandleToolCall(mockToolCall(llm.ToolGetTravelTime, `{"from": "seoul", "to": "Busan"}`))
+	require.NoError(t, err)
+	assert.Equal(t, SuggestionTypeTravel, suggestion.Type)
+	assert.False(t, suggestion.RequiresApproval)
+	assert.Contains(t, suggestion.ParsedData, "3 hours (3 hours by train)")
+
+	suggestion, err = handler.HandleToolCall(mockToolCall(llm.ToolGetTravelTime, `{"from": "Seoul", "to": "Jeju"}`))
+	require.NoError(t, err)
+	assert.Contains(t, suggestion.ParsedData, "Do not invent a travel time")
+
+	m.textarea.SetValue("/world")
+	m.handleSubmit()
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "Busan (city)\n   → Seoul: 3 hours by train")
+
+	m.textarea.SetValue("/world Busan to Seoul")
+	m.handleSubmit()
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "Busan to 서울: 3 hours")
+}
+
+func TestMoveItemSuggestion(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# One\n\nHana found the Dawn Sword.\n"}))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "# Two\n\nThe Dawn Sword lay on the table.\n"}))
+	m := newTestModelWithProject(t, proj)
+
+	suggestion, err := m.suggestionHandler.HandleToolCall(mockToolCall(llm.ToolMoveItem, `{"item": "Dawn Sword", "chapter": 1, "holder": "Hana"}`))
+	require.NoError(t, err)
+	assert.Equal(t, SuggestionTypeItem, suggestion.Type)
+	assert.True(t, suggestion.RequiresApproval)
+	assert.Contains(t, suggestion.Content, "New item")
+	assert.Contains(t, suggestion.Content, "+ Chapter 1: held by Hana")
+
+	m.pendingSuggestion = suggestion
+	m.acceptSuggestion()
+	assertNoError(t, m)
+	content, err := os.ReadFile(filepath.Join(proj.Path(), "context", "items", "dawn-sword.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "- Chapter 1: holder: [[Hana]]")
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Dawn Sword, chapter 2: appears, but Hana, who has held it since chapter 1, does not")
+
+	suggestion, err = m.suggestionHandler.HandleToolCall(mockToolCall(llm.ToolMoveItem, `{"item": "dawn-sword", "chapter": 2, "place": "Seoul"}`))
+	require.NoError(t, err)
+	assert.Contains(t, suggestion.Content, "- Chapter 1: held by Hana")
+
+	m.textarea.SetValue("/items")
+	m.handleSubmit()
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "Dawn Sword: held by Hana (since chapter 1), last seen in chapter 2")
+}
@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyedTabs is how many tabs Alt+1 to Alt+9 reach.
+const keyedTabs = 9
+
+// maxTabNameLen bounds a tab's name, which the header shows.
+const maxTabNameLen = 24
+
+// chatTab is a chat tab: a conversation thread of the project, with the
+// conversation kept while another tab is shown.
+type chatTab struct {
+	thread         string
+	messages       []Message
+	historySummary *historySummary
+	// loaded is set once the tab's history was read from the database.
+	loaded bool
+	// librarian is set when the research librarian answers in the tab.
+	librarian bool
+}
+
+// loadTabs opens a tab for each conversation thread with saved messages
+// or a persona, the main thread first, and sets each tab's persona.
+func (m *Model) loadTabs() {
+	m.tabs = []chatTab{{thread: storage.MainThread}}
+	if m.project == nil || m.project.DB == nil {
+		return
+	}
+	threads, err := m.project.DB.ConversationThreads()
+	if err != nil {
+		return
+	}
+	personas, err := m.project.DB.ThreadPersonas()
+	if err != nil {
+		personas = nil
+	}
+	for _, thread := range threads {
+		if thread != storage.MainThread {
+			m.tabs = append(m.tabs, chatTab{thread: thread})
+		}
+	}
+	var unsaved []string
+	for thread := range personas {
+		if _, ok := m.findTab(thread); !ok {
+			unsaved = append(unsaved, thread)
+		}
+	}
+	sort.Strings(unsaved)
+	for _, thread := range unsaved {
+		m.tabs = append(m.tabs, chatTab{thread: thread})
+	}
+	for i := range m.tabs {
+		m.tabs[i].librarian = personas[m.tabs[i].thread] == personaLibrarian
+	}
+	m.librarian = m.tabs[m.currentTab()].librarian
+}
+
+// currentTab returns the index of the tab shown.
+func (m *Model) currentTab() int {
+	for i, tab := range m.tabs {
+		if tab.thread == m.thread {
+			return i
+		}
+	}
+	return 0
+}
+
+// findTab returns the index of the tab with the given number or name.
+func (m *Model) findTab(ref string) (int, bool) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		return n - 1, n >= 1 && n <= len(m.tabs)
+	}
+	for i, tab := range m.tabs {
+		if strings.EqualFold(tab.thread, ref) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// switchTab shows tab i, keeping the conversation of the tab it leaves.
+// A tab's history is read from the database the first time it is shown.
+func (m *Model) switchTab(i int) error {
+	if i < 0 || i >= len(m.tabs) {
+		return fmt.Errorf("no tab %d (open tabs: %d)", i+1, len(m.tabs))
+	}
+	current := m.currentTab()
+	if i == current {
+		return nil
+	}
+	if m.streaming {
+		return fmt.Errorf("wait for the reply to finish before switching tabs")
+	}
+	if m.offRecord() {
+		return fmt.Errorf("end the sandbox or interview before switching tabs")
+	}
+
+	left := &m.tabs[current]
+	left.messages, left.historySummary, left.loaded = m.messages, m.historySummary, true
+
+	tab := &m.tabs[i]
+	m.thread, m.librarian = tab.thread, tab.librarian
+	m.messages, m.historySummary = tab.messages, tab.historySummary
+	if !tab.loaded {
+		m.messages, m.historySummary = []Message{}, nil
+		m.loadHistory()
+	}
+	m.renderedMessages = nil
+	m.statusText = fmt.Sprintf("Tab %d: %s", i+1, tab.thread)
+	m.updateViewport()
+	m.viewport.GotoBottom()
+	return nil
+}
+
+// newTab opens an empty tab and switches to it. The tab's thread is saved
+// with its first message.
+func (m *Model) newTab(name string) error {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return fmt.Errorf("usage: /tab new <name>")
+	case len([]rune(name)) > maxTabNameLen:
+		return fmt.Errorf("tab names are at most %d characters", maxTabNameLen)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("tab names cannot be numbers, which select tabs")
+	}
+	if _, ok := m.findTab(name); ok {
+		return fmt.Errorf("tab %q is already open", name)
+	}
+
+	m.tabs = append(m.tabs, chatTab{thread: name, messages: []Message{}, loaded: true})
+	return m.switchTab(len(m.tabs) - 1)
+}
+
+// handleTabCommand handles /tab [new <name>|<number>|<name>].
+func (m *Model) handleTabCommand(args []string) {
+	if len(args) == 0 {
+		m.messages = append(m.messages, Message{Role: "system", Content: m.tabList()})
+		m.updateViewport()
+		return
+	}
+	if strings.ToLower(args[0]) == "new" {
+		if err := m.newTab(strings.Join(args[1:], " ")); err != nil {
+			m.err = err
+		}
+		return
+	}
+
+	ref := strings.Join(args, " ")
+	i, ok := m.findTab(ref)
+	if !ok {
+		m.err = fmt.Errorf("no tab %q (see /tab)", ref)
+		return
+	}
+	if err := m.switchTab(i); err != nil {
+		m.err = err
+	}
+}
+
+// tabList lists the open tabs for /tab.
+func (m *Model) tabList() string {
+	var sb strings.Builder
+	sb.WriteString("Chat tabs (Alt+1…9 or /tab <number|name> to switch, /tab new <name> to open one):\n")
+	current := m.currentTab()
+	for i, tab := range m.tabs {
+		fmt.Fprintf(&sb, "\n  %d. %s", i+1, tab.thread)
+		if tab.librarian {
+			sb.WriteString(" (librarian)")
+		}
+		if i == current {
+			sb.WriteString(" (current)")
+		}
+	}
+	return sb.String()
+}
+
+// handleTabKey switches tabs with Alt+1 to Alt+9. It reports whether it
+// used the key.
+func (m *Model) handleTabKey(msg tea.KeyMsg) bool {
+	if !msg.Alt || msg.Type != tea.KeyRunes || len(msg.Runes) != 1 || m.view != ViewChat || !m.inputMode {
+		return false
+	}
+	r := msg.Runes[0]
+	if r < '1' || r > '0'+keyedTabs {
+		return false
+	}
+	if err := m.switchTab(int(r - '1')); err != nil {
+		m.err = err
+	}
+	return true
+}
+
+// renderTabBar renders the tabs for the header once there is more than
+// one, the current one highlighted.
+func (m *Model) renderTabBar() string {
+	if len(m.tabs) < 2 {
+		return ""
+	}
+	current := m.currentTab()
+	parts := make([]string, len(m.tabs))
+	for i, tab := range m.tabs {
+		label := fmt.Sprintf("%d %s", i+1, tab.thread)
+		if tab.librarian {
+			label += " 📚"
+		}
+		if i == current {
+			parts[i] = styles.SelectedItem.Render("[" + label + "]")
+		} else {
+			parts[i] = styles.MutedText.Render(" " + label + " ")
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/azyu/dreamteller/internal/llm"
 	"github.com/azyu/dreamteller/internal/project"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -225,7 +226,7 @@ func resetTextarea(m *Model) {
 	m.textarea = textarea.New()
 	m.textarea.Placeholder = "Enter your message... (/help for commands)"
 	m.textarea.Focus()
-	m.textarea.CharLimit = 4000
+	m.textarea.CharLimit = composerCharLimit
 	m.textarea.SetWidth(testConfig.Width - 4)
 	m.textarea.SetHeight(3)
 	m.textarea.ShowLineNumbers = false
@@ -246,3 +247,38 @@ func makeTestMessages(pairs ...string) []Message {
 	}
 	return messages
 }
+
+// driveStream runs cmd and every follow-up command through the model until
+// the stream finishes. Spinner ticks are dropped to keep the loop finite.
+func driveStream(t *testing.T, m *Model, cmd tea.Cmd) *Model {
+	t.Helper()
+
+	queue := []tea.Cmd{cmd}
+	for steps := 0; len(queue) > 0; steps++ {
+		if steps > 1000 {
+			t.Fatal("stream did not finish")
+		}
+
+		next := queue[0]
+		queue = queue[1:]
+		if next == nil {
+			continue
+		}
+
+		switch msg := next().(type) {
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		case spinner.TickMsg:
+			// Drop spinner ticks.
+		case StreamDoneMsg:
+			model, _ := m.Update(msg)
+			return model.(*Model)
+		default:
+			model, follow := m.Update(msg)
+			m = model.(*Model)
+			queue = append(queue, follow)
+		}
+	}
+
+	return m
+}
@@ -3,16 +3,19 @@ package tui
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/azyu/dreamteller/internal/llm"
 	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/prose"
 	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/storage"
 	"github.com/azyu/dreamteller/internal/tui/styles"
+	"github.com/azyu/dreamteller/pkg/types"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -28,6 +31,8 @@ const (
 	ViewContext
 	ViewChapters
 	ViewSuggestion
+	ViewStats
+	ViewCompare
 )
 
 type ContextMode int
@@ -59,6 +64,39 @@ func (c ContextMode) Next() ContextMode {
 type Message struct {
 	Role    string
 	Content string
+
+	// Interrupted marks a partial assistant reply whose generation was
+	// cancelled or timed out. It can be resumed with /continue.
+	Interrupted bool
+
+	// Issues lists POV/tense deviations flagged by the prose guard.
+	Issues []prose.Issue
+
+	// Citations lists the context chunks the reply cites.
+	Citations []Citation
+
+	// Folded collapses the message to its first line in the chat view.
+	Folded bool
+
+	// Model names the model a message was sent to or generated by when it
+	// is not the session's default model, e.g. with @model or /polish.
+	Model string
+
+	// Draft marks a first draft revised by the reply after it. It stays
+	// visible but is not sent to the model.
+	Draft bool
+
+	// Retrieval lists the chunks retrieved into the reply's context, shown
+	// in explain mode.
+	Retrieval []RankedChunk
+
+	// Replay is the recorded request a reply re-ran with /replay. Replays
+	// are not saved or sent to the model.
+	Replay int64
+
+	// Critique marks the model's critique of the draft before it, written
+	// in a self-critique turn. It is not saved or sent to the model.
+	Critique bool
 }
 
 type Model struct {
@@ -84,26 +122,232 @@ type Model struct {
 
 	streaming        bool
 	inputMode        bool
+	streamConfig     StreamConfig
 	streamController *StreamController
-	streamChan       <-chan llm.StreamChunk
+	stream           *chunkStream
+
+	// assembling is set while the request for a reply is being put
+	// together, before it is sent.
+	assembling bool
+
+	// streamedContent records whether the current stream produced text.
+	streamedContent bool
+	// continuing marks a stream that extends the last assistant message.
+	continuing bool
+	// revising marks a stream that rewrites the last assistant message;
+	// revisedFrom holds the original so it can be restored.
+	revising    bool
+	revisedFrom string
+	// refining marks the stage of a turn that revises a first draft;
+	// newReply makes its text start a new message instead of extending the
+	// draft.
+	refining bool
+	newReply bool
+	// selfCritique has each reply critiqued and then revised by its model;
+	// critiquing marks the critique stage.
+	selfCritique bool
+	critiquing   bool
+
+	// session is the chat session recorded for insights, or 0.
+	session int64
+
+	// lastAutoApplied is the last context update applied without asking,
+	// for /undo.
+	lastAutoApplied *autoApplied
+
+	// reviewQueue holds the context updates queued for /pending.
+	reviewQueue []*SuggestionResult
+
+	// modifiedSuggestion is the recorded suggestion the author chose to
+	// modify, until their next message says how; refreshingPreferences is
+	// set while the preference digest is being updated.
+	modifiedSuggestion    int64
+	refreshingPreferences bool
+	// autoContinueLimit and autoContinues bound automatic continuation of
+	// replies cut off by the output token limit.
+	autoContinueLimit int
+	autoContinues     int
 
 	suggestionHandler   *SuggestionHandler
 	pendingSuggestion   *SuggestionResult
 	toolCallAccumulator *ToolCallAccumulator
 
 	modelSelectMode  bool
-	availableModels  []string
+	availableModels  []modelChoice
 	modelSelectIndex int
 
+	// thread is the conversation thread of the chat tab shown; tabs holds
+	// every open tab. librarian is set while the tab's persona is the
+	// research librarian.
+	thread    string
+	tabs      []chatTab
+	librarian bool
+
+	// quota is what the project spent on AI this month. quotaWarned is set
+	// once the author was warned the budget is running out; past it, the
+	// message in quotaPending waits to be sent again, after which
+	// quotaConfirmed lets the session's messages through.
+	quota          *project.QuotaStatus
+	quotaWarned    bool
+	quotaPending   string
+	quotaConfirmed bool
+
+	// projectLister lets /projects switch projects; nextProject is the
+	// path of the project chosen, opened once the program ends.
+	projectSelectMode  bool
+	availableProjects  []*types.Project
+	projectSelectIndex int
+	projectLister      ProjectLister
+	nextProject        string
+
+	// providerNames and providerFactory let /models switch providers.
+	providerNames   []string
+	providerFactory ProviderFactory
+
+	// turnProvider and turnModel serve the current turn when its prompt
+	// was prefixed with @model; nil uses the session default.
+	turnProvider     llm.Provider
+	turnModel        string
+	turnProviderName string
+
+	// turnGeneration is the recorded manifest of the request being
+	// answered, and generationReply the text generated for it so far.
+	turnGeneration  int64
+	generationReply string
+
+	// replayOf is the recorded request being replayed, if any.
+	replayOf int64
+
+	// comparison is the A/B comparison shown for a pick; comparing is set
+	// while its replies are generated.
+	comparison *abComparison
+	comparing  bool
+
+	offline bool
+
+	// multiline makes Enter add a line instead of sending.
+	multiline bool
+
+	// expandCitations shows cited sources with their snippets.
+	expandCitations bool
+
+	// lastSearch holds the results of the last /search, for /expand.
+	lastSearch []search.FTSSearchResult
+
+	// explainRanking shows, under each reply, why each retrieved chunk
+	// was put in its context.
+	explainRanking bool
+
+	// turnRetrieval is the retrieval of the request being answered.
+	turnRetrieval []RankedChunk
+
+	// attachments are sent with the next message.
+	attachments []Attachment
+
+	// selectMode moves the keyboard from the composer to the chat history,
+	// where selectedMessage indexes messages.
+	selectMode      bool
+	selectedMessage int
+
+	// messageLines holds the line each message starts on in the chat view.
+	messageLines []int
+
+	// renderedMessages caches each message as last rendered in the chat
+	// view, by index, so only messages that changed are rendered again.
+	renderedMessages []renderedMessage
+
+	// historySummary stands in for the start of a long chat in requests,
+	// when the project enables it; summarizingHistory is set while it is
+	// being updated.
+	historySummary     *historySummary
+	summarizingHistory bool
+
+	// reindexing is set while /reindex rebuilds the search index.
+	reindexing bool
+
+	// activeChapter is the chapter replies are appended to; 0 means the
+	// latest chapter.
+	activeChapter int
+
+	// crashReport is the path of the crash report, set when a panic was
+	// recovered.
+	crashReport string
+
+	// savedDraft is the composer text last written to the draft file.
+	savedDraft string
+
+	// fileStamps are the context and chapter files as last seen on disk,
+	// to notice changes made outside the TUI.
+	fileStamps map[string]fileStamp
+
+	// sprint is the running writing sprint, if any; sprints counts the
+	// sprints started this session.
+	sprint  *sprint
+	sprints int
+
+	// stats is what the stats view shows, gathered when it opened.
+	stats *statsSnapshot
+
+	// sandbox is the what-if conversation in progress, if any. The main
+	// thread waits in it until the sandbox ends.
+	sandbox *sandbox
+
+	// interview is the character interview in progress, if any. Like the
+	// sandbox, it sets the main thread aside.
+	interview *interview
+
+	// promptHistory holds the prompts sent in this project, oldest first.
+	// historyPos is how far back Up/Ctrl+P has recalled (0 when not
+	// recalling) and historyStash the text composed before recalling.
+	promptHistory []string
+	historyPos    int
+	historyStash  string
+
+	// contextIndex is the file selected in the context view; contextEdit
+	// is the file open in the context editor, if any.
+	contextIndex         int
+	contextEdit          *contextEdit
+	contextDeletePending bool
+
+	// charForm is the open /newchar form, if any.
+	charForm *characterForm
+
+	// historyPaletteMode shows the searchable prompt history.
+	historyPaletteMode  bool
+	historyQuery        string
+	historyPaletteIndex int
+
+	// toolRepairAttempts counts follow-ups sent for malformed tool arguments
+	// in the current turn.
+	toolRepairAttempts int
+
+	// toolLookups counts lookup tool results sent back to the model in the
+	// current turn.
+	toolLookups int
+
 	toast Toast
 }
 
+// maxToolRepairAttempts bounds how often malformed tool arguments are sent
+// back to the model for correction before giving up.
+const maxToolRepairAttempts = 2
+
+// maxToolLookups bounds how many lookup tool results (e.g. character voices)
+// are sent back to the model in one turn.
+const maxToolLookups = 3
+
+// continuePrompt asks the model to resume an interrupted reply.
+const continuePrompt = "Your previous reply was cut off. Continue exactly where it stopped, without repeating or summarizing what you already wrote."
+
+// offlineNotice is shown inline when an LLM feature is used in offline mode.
+const offlineNotice = "Offline mode: AI features are disabled. Context, chapters, and /search still work. Restart without --offline to chat."
+
 // New creates a new TUI model.
 func New(proj *project.Project, provider llm.Provider, searchEngine *search.FTSEngine, modelName, providerName, baseURL string) *Model {
 	ta := textarea.New()
 	ta.Placeholder = "Enter your message... (/help for commands)"
 	ta.Focus()
-	ta.CharLimit = 4000
+	ta.CharLimit = composerCharLimit
 	ta.SetWidth(80)
 	ta.SetHeight(1)
 	ta.ShowLineNumbers = false
@@ -130,21 +374,63 @@ func New(proj *project.Project, provider llm.Provider, searchEngine *search.FTSE
 		spinner:             sp,
 		messages:            []Message{},
 		inputMode:           true,
+		streamConfig:        DefaultStreamConfig(),
 		view:                ViewChat,
 		suggestionHandler:   NewSuggestionHandler(proj, searchEngine),
 		toolCallAccumulator: NewToolCallAccumulator(),
+		selfCritique:        proj != nil && proj.Config != nil && proj.Config.LLM.SelfCritique,
+		thread:              storage.MainThread,
+		tabs:                []chatTab{{thread: storage.MainThread}},
+	}
+}
+
+// SetOffline enables or disables offline mode. In offline mode no network
+// calls are made and LLM features show an inline notice instead.
+func (m *Model) SetOffline(offline bool) {
+	m.offline = offline
+}
+
+// SetStreamTimeout overrides the timeout for each generation, e.g. from the
+// provider's configuration. Non-positive values keep the default.
+func (m *Model) SetStreamTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		m.streamConfig.Timeout = timeout
 	}
 }
 
+// SetAutoContinue sets how many times a reply cut off by the output token
+// limit is continued without asking. Zero only offers /continue.
+func (m *Model) SetAutoContinue(limit int) {
+	m.autoContinueLimit = limit
+}
+
 func (m *Model) Init() tea.Cmd {
+	m.loadTabs()
 	m.loadHistory()
+	m.loadPromptHistory()
+	m.recordDailyWords()
+	m.startSession()
 
 	cmds := []tea.Cmd{
 		textarea.Blink,
 		m.spinner.Tick,
+		draftTick(),
+		fileWatchTick(),
+	}
+	m.fileStamps = m.scanProjectFiles()
+	if cmd := m.refreshQuota(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+
+	// A crashed session carries its own composer text, restored with
+	// /restore; otherwise pick up the autosaved draft.
+	if m.offerCrashRestore() {
+		m.savedDraft = m.loadDraft()
+	} else if cmd := m.restoreDraft(); cmd != nil {
+		cmds = append(cmds, cmd)
 	}
 
-	if m.isFirstOpen() && m.provider != nil {
+	if m.isFirstOpen() && m.provider != nil && !m.offline {
 		cmds = append(cmds, m.sendGreeting())
 	}
 
@@ -192,44 +478,192 @@ func (m *Model) buildGreetingPrompt() string {
 }
 
 func (m *Model) loadHistory() {
-	if m.project == nil || m.project.DB == nil {
+	msgs, err := savedHistory(m.project, m.thread, m.provider, m.modelName)
+	if err != nil {
 		return
 	}
+	for i := range msgs {
+		m.resolveCitations(&msgs[i])
+	}
+	m.messages = append(m.messages, msgs...)
+}
+
+// savedHistory returns the saved conversation of a thread of the project,
+// cut to the most recent messages that fit the history budget of provider.
+func savedHistory(proj *project.Project, thread string, provider llm.Provider, modelName string) ([]Message, error) {
+	if proj == nil || proj.DB == nil {
+		return nil, nil
+	}
 
-	history, err := m.project.DB.GetConversationHistory(defaultHistoryLoadLimit)
+	history, err := proj.DB.GetConversationHistory(thread, defaultHistoryLoadLimit)
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	msgs := make([]Message, 0, len(history))
 	for _, record := range history {
-		msgs = append(msgs, Message{Role: record.Role, Content: record.Content})
+		msg := Message{Role: record.Role, Content: record.Content, Interrupted: record.Interrupted, Draft: record.Draft}
+		if record.Model != modelName {
+			msg.Model = record.Model
+		}
+		msgs = append(msgs, msg)
 	}
 
 	// Budget-aware truncation for what we keep in memory.
 	// If provider is not available, keep the DB ordering as-is.
-	if m.provider != nil {
-		if env, err := newAssemblyEnv(m.project, m.provider, m.modelName); err == nil {
+	if provider != nil {
+		if env, err := newAssemblyEnv(proj, provider, modelName); err == nil {
 			msgs = truncateTUIMessagesToBudget(env.tokenizer, msgs, env.budget.History)
 		}
 	}
+	return msgs, nil
+}
 
-	m.messages = append(m.messages, msgs...)
+// offRecord reports whether the conversation is kept out of the history,
+// as in a sandbox or an interview.
+func (m *Model) offRecord() bool {
+	return m.sandbox != nil || m.interview != nil
 }
 
 func (m *Model) saveMessage(role, content string) {
-	if m.project == nil || m.project.DB == nil {
+	if m.project == nil || m.project.DB == nil || m.offRecord() {
+		return
+	}
+	_ = m.project.DB.SaveConversationMessage(m.thread, role, content)
+}
+
+// saveReply saves an assistant reply with the model that generated it.
+func (m *Model) saveReply(content string, interrupted bool) {
+	if m.project == nil || m.project.DB == nil || m.offRecord() {
+		return
+	}
+	_, model := m.activeProvider()
+	_ = m.project.DB.SaveGeneratedMessage(m.thread, content, model, interrupted)
+}
+
+// updateLastMessage rewrites the most recently saved message.
+func (m *Model) updateLastMessage(content string, interrupted bool) {
+	if m.project == nil || m.project.DB == nil || m.offRecord() {
+		return
+	}
+	_ = m.project.DB.UpdateLastConversationMessage(m.thread, content, interrupted)
+}
+
+// keepPartialOutput preserves the text streamed so far when a generation is
+// cancelled or fails, marking it as interrupted so it can be continued.
+func (m *Model) keepPartialOutput() {
+	defer func() {
+		m.streamedContent = false
+		m.continuing = false
+		m.revising = false
+		m.refining = false
+		m.newReply = false
+		m.replayOf = 0
+	}()
+	m.finishGeneration()
+
+	if m.critiquing {
+		// The draft stays the reply; a partial critique stays visible.
+		m.abandonCritique()
+		return
+	}
+
+	if !m.streamedContent || len(m.messages) == 0 {
+		m.restoreRevisedReply()
+		m.abandonRefinement()
+		return
+	}
+	last := &m.messages[len(m.messages)-1]
+	if last.Role != "assistant" || last.Content == "" || last.Replay != 0 {
+		return
+	}
+
+	last.Interrupted = true
+	if m.continuing || m.revising {
+		m.updateLastMessage(last.Content, true)
 		return
 	}
-	_ = m.project.DB.SaveConversationMessage(role, content)
+	m.saveReply(last.Content, true)
 }
 
-// Update handles messages.
-func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+// handleLengthLimit handles a reply cut off by the output token limit: the
+// partial text is kept as interrupted and continued automatically while the
+// auto-continue budget lasts, otherwise /continue is offered.
+func (m *Model) handleLengthLimit() (tea.Model, tea.Cmd) {
+	m.stream = nil
+	m.keepPartialOutput()
+
+	if m.provider != nil && m.autoContinues < m.autoContinueLimit {
+		m.autoContinues++
+		model, cmd := m.continueReply()
+		m.statusText = fmt.Sprintf("Reply hit the output limit, continuing (%d/%d)...", m.autoContinues, m.autoContinueLimit)
+		return model, cmd
+	}
+
+	m.autoContinues = 0
+	toast, toastCmd := showToast("Reply hit the output limit. Type /continue to resume.", ToastWarning, 5*time.Second)
+	m.toast = toast
+	return m, tea.Batch(func() tea.Msg { return StreamDoneMsg{} }, toastCmd)
+}
+
+// continueReply resumes the last assistant reply, stitching the new output
+// onto it.
+func (m *Model) continueReply() (tea.Model, tea.Cmd) {
+	if len(m.messages) == 0 || m.messages[len(m.messages)-1].Role != "assistant" {
+		m.err = fmt.Errorf("nothing to continue: the last message is not an AI reply")
+		return m, nil
+	}
+	if m.provider == nil {
+		m.err = fmt.Errorf("no LLM provider configured")
+		return m, nil
+	}
+
+	m.continuing = true
+	m.streaming = true
+	m.inputMode = false
+	m.statusText = "Continuing..."
+	m.updateViewport()
+
+	// The request ends at the last user turn, so the partial reply is sent
+	// back explicitly for the model to pick up from.
+	return m, tea.Batch(m.spinner.Tick, m.startStreamWithFollowUp([]llm.ChatMessage{
+		llm.NewAssistantMessage(m.messages[len(m.messages)-1].Content),
+		llm.NewUserMessage(continuePrompt),
+	}))
+}
+
+// update handles messages.
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// The character form gets every message; its own messages (field
+	// focus, cursor blink) are not handled below.
+	if m.charForm != nil {
+		cmd := m.updateCharacterForm(msg)
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return m, cmd
+		}
+		cmds = append(cmds, cmd)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.selectMode {
+			return m.handleSelectKey(msg)
+		}
+		if m.historyPaletteMode {
+			return m.handleHistoryPaletteKey(msg)
+		}
+		if m.contextEdit != nil {
+			return m.handleContextEditorKey(msg)
+		}
+		if m.view == ViewContext && m.handleContextKey(msg) {
+			return m, nil
+		}
+		if m.handleTabKey(msg) || m.handleHistoryKey(msg) {
+			return m, nil
+		}
+
 		// Handle special keys first
 		model, cmd := m.handleKeyMsg(msg)
 		if cmd != nil {
@@ -242,15 +676,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 
 		if !m.ready {
-			m.viewport = viewport.New(msg.Width, msg.Height-8)
+			m.viewport = viewport.New(msg.Width, msg.Height-chromeHeight)
 			m.viewport.YPosition = 2
 			m.ready = true
 		} else {
 			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - 8
 		}
 
 		m.textarea.SetWidth(msg.Width - 4)
+		m.resizeComposer()
 		m.updateViewport()
 
 	case spinner.TickMsg:
@@ -268,11 +702,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.inputMode = true
 		m.textarea.Focus()
 		m.updateViewport()
+		cmds = append(cmds, m.refreshQuota())
 
 	case StreamErrorMsg:
+		m.keepPartialOutput()
 		m.streaming = false
+		m.assembling = false
 		m.inputMode = true
 		m.textarea.Focus()
+		m.updateViewport()
 
 		errText := "API Error"
 		if msg.Err != nil {
@@ -299,21 +737,57 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateViewport()
 
 	case modelsListMsg:
-		if msg.err != nil {
-			m.err = msg.err
-			m.statusText = ""
-		} else {
-			m.availableModels = msg.models
-			m.modelSelectIndex = 0
-			m.modelSelectMode = true
-			m.inputMode = false
-			m.statusText = "Select a model (↑/↓ to navigate, Enter to select, Esc to cancel)"
-			m.updateViewport()
+		m.handleModelsList(msg)
+
+	case arcsExtractedMsg:
+		m.handleArcsExtracted(msg)
+
+	case historySummarizedMsg:
+		m.handleHistorySummarized(msg)
+
+	case preferencesUpdatedMsg:
+		m.handlePreferencesUpdated(msg)
+
+	case indexRebuiltMsg:
+		m.handleIndexRebuilt(msg)
+
+	case reviewDoneMsg:
+		m.handleReviewDone(msg)
+
+	case abDoneMsg:
+		m.handleABDone(msg)
+
+	case requestAssembledMsg:
+		// A request whose reply was interrupted or replaced is dropped.
+		if m.streamController == nil || msg.ctx != m.streamController.ctx || msg.ctx.Err() != nil {
+			return m, nil
 		}
+		m.assembling = false
+		m.turnRetrieval = msg.retrieval
+		m.recordGeneration(msg)
+		return m, openStream(msg)
 
 	case StreamReadyMsg:
-		m.streamChan = msg.StreamChan
+		m.stream = newChunkStream(msg.StreamChan)
 		return m, m.readNextChunk()
+
+	case editorFinishedMsg:
+		m.handleEditorFinished(msg)
+		return m, nil
+
+	case chapterEditedMsg:
+		m.handleChapterEdited(msg)
+		return m, nil
+
+	case draftTickMsg:
+		m.saveDraft()
+		return m, draftTick()
+
+	case fileWatchTickMsg:
+		return m, m.handleFileWatchTick()
+
+	case sprintTickMsg:
+		return m, m.handleSprintTick(msg)
 	}
 
 	// Update textarea if in input mode
@@ -321,6 +795,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.textarea, cmd = m.textarea.Update(msg)
 		cmds = append(cmds, cmd)
+		m.resizeComposer()
 	}
 
 	// Update viewport
@@ -338,19 +813,27 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.modelSelectMode {
 		return m.handleModelSelectKey(msg)
 	}
+	if m.projectSelectMode {
+		return m.handleProjectSelectKey(msg)
+	}
 
 	// Handle suggestion view keys
 	if m.view == ViewSuggestion {
 		return m.handleSuggestionKey(msg)
 	}
 
+	// Handle the A/B comparison picker
+	if m.view == ViewCompare && m.comparison != nil {
+		return m.handleCompareKey(msg)
+	}
+
 	switch msg.Type {
 	case tea.KeyCtrlC:
 		if m.streaming {
 			m.cancelStream()
 			return m, nil
 		}
-		return m, tea.Quit
+		return m, m.quit()
 
 	case tea.KeyEsc:
 		if m.view != ViewChat {
@@ -364,16 +847,41 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		// Let Esc pass through to textarea (clears input)
 
-	case tea.KeyEnter:
+	case tea.KeyEnter, tea.KeyCtrlJ:
 		if !m.streaming && m.inputMode {
+			return m.handleComposerEnter(msg)
+		}
+
+	case tea.KeyCtrlS:
+		if m.multiline && !m.streaming && m.inputMode {
 			return m.handleSubmit()
 		}
 
+	case tea.KeyCtrlE:
+		if m.inputMode && !m.streaming {
+			return m.openEditor()
+		}
+
 	case tea.KeyTab:
 		if m.inputMode && !m.streaming {
 			m.contextMode = m.contextMode.Next()
 			return m, nil
 		}
+
+	case tea.KeyCtrlF:
+		if m.inputMode && !m.streaming && !m.offline && len(m.lastReplyIssues()) > 0 && !m.aiLocked() {
+			return m.fixProse()
+		}
+
+	case tea.KeyCtrlO:
+		m.toggleCitations()
+		return m, nil
+
+	case tea.KeyCtrlUp:
+		if m.inputMode && !m.streaming && m.view == ViewChat {
+			m.enterSelectMode()
+			return m, nil
+		}
 	}
 
 	// Return nil cmd to let the key pass through to textarea
@@ -393,8 +901,12 @@ func (m *Model) handleSuggestionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "r", "n":
 			return m.rejectSuggestion()
 		case "m", "e":
+			if conflict, ok := m.pendingSuggestion.mergeConflict(); ok && key == "e" {
+				return m.editMerge(conflict)
+			}
 			// Modify - return to chat with suggestion context
 			if m.pendingSuggestion != nil {
+				m.noteSuggestionOutcome(outcomeModified)
 				m.messages = append(m.messages, Message{
 					Role:    "system",
 					Content: fmt.Sprintf("Suggestion pending modification: %s", m.pendingSuggestion.Title),
@@ -405,8 +917,21 @@ func (m *Model) handleSuggestionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.inputMode = true
 			m.textarea.Focus()
 			m.updateViewport()
-			return m, nil
+			return m, m.refreshPreferences(false)
 		default:
+			if m.pendingSuggestion != nil && m.pendingSuggestion.queued != nil && key == "k" {
+				return m.keepPending()
+			}
+			if m.pendingSuggestion != nil && m.pendingSuggestion.Type == SuggestionTypePlot {
+				if model, cmd, ok := m.applyPlotSuggestion(key); ok {
+					return model, cmd
+				}
+			}
+			if m.pendingSuggestion != nil && m.pendingSuggestion.Type == SuggestionTypeClarification {
+				if model, cmd, ok := m.answerClarification(key); ok {
+					return model, cmd
+				}
+			}
 			// Check if the key matches an action
 			if m.pendingSuggestion != nil {
 				for _, action := range m.pendingSuggestion.Actions {
@@ -445,8 +970,7 @@ func (m *Model) handleModelSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyEnter:
 		if len(m.availableModels) > 0 && m.modelSelectIndex < len(m.availableModels) {
-			m.modelName = m.availableModels[m.modelSelectIndex]
-			m.statusText = fmt.Sprintf("Switched to %s", m.modelName)
+			m.switchModel(m.availableModels[m.modelSelectIndex])
 		}
 		m.modelSelectMode = false
 		m.inputMode = true
@@ -477,17 +1001,51 @@ func (m *Model) acceptSuggestion() (tea.Model, tea.Cmd) {
 	if m.pendingSuggestion == nil {
 		return m.returnToChat()
 	}
+	m.recordUsage(usageSuggestion, "accepted")
+	m.noteSuggestionOutcome(outcomeAccepted)
 
 	// For context updates that require approval, execute the update
 	if m.pendingSuggestion.RequiresApproval && m.pendingSuggestion.Type == SuggestionTypeContextUpdate {
-		update, ok := m.pendingSuggestion.ParsedData.(llm.ContextUpdate)
-		if ok {
-			if err := m.suggestionHandler.ExecuteContextUpdate(update); err != nil {
+		var updates []llm.ContextUpdate
+		switch data := m.pendingSuggestion.ParsedData.(type) {
+		case llm.ContextUpdate:
+			updates = []llm.ContextUpdate{data}
+		case []llm.ContextUpdate:
+			updates = data
+		}
+		if err := m.applyContextUpdates(updates, m.pendingSuggestion.snapshots); err != nil {
+			// Files changed on disk since the suggestion: merge instead
+			// of overwriting them.
+			if conflict, ok := errContextConflict(err); ok {
+				m.showMerge(conflict)
+				return m, nil
+			}
+			m.err = err
+			// A /pending review goes back to the queue to try again.
+			m.reviewQueue = append(m.pendingSuggestion.queued, m.reviewQueue...)
+		}
+	} else if conflict, ok := m.pendingSuggestion.mergeConflict(); ok {
+		return m.acceptMerge(conflict)
+	} else if m.pendingSuggestion.Type == SuggestionTypeChapterEdit {
+		if edit, ok := m.pendingSuggestion.ParsedData.(llm.ChapterEdit); ok {
+			if err := m.applyChapterEdit(edit); err != nil {
+				m.err = err
+			}
+		}
+	} else if m.pendingSuggestion.Type == SuggestionTypeItem {
+		if move, ok := m.pendingSuggestion.ParsedData.(llm.ItemMove); ok {
+			if err := m.applyItemMove(move); err != nil {
+				m.err = err
+			}
+		}
+	} else if m.pendingSuggestion.Type == SuggestionTypeMemory {
+		if fact, ok := m.pendingSuggestion.ParsedData.(llm.MemoryFact); ok {
+			if err := m.suggestionHandler.SaveMemory(fact.Fact, "model"); err != nil {
 				m.err = err
 			} else {
 				m.messages = append(m.messages, Message{
 					Role:    "system",
-					Content: fmt.Sprintf("Context update applied: %s/%s.md", update.FileType, update.FileName),
+					Content: fmt.Sprintf("Remembered: %s", fact.Fact),
 				})
 			}
 		}
@@ -502,9 +1060,38 @@ func (m *Model) acceptSuggestion() (tea.Model, tea.Cmd) {
 	return m.returnToChat()
 }
 
+// applyContextUpdates applies approved context updates as one transaction
+// and reindexes only the files they wrote. Files with a snapshot must not
+// have changed on disk since it was taken.
+func (m *Model) applyContextUpdates(updates []llm.ContextUpdate, snapshots map[string]contextSnapshot) error {
+	paths, err := m.suggestionHandler.executeContextUpdates(updates, snapshots)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.ToSlash(path)
+		if err := m.reindexContextFile(path); err != nil {
+			m.statusText = fmt.Sprintf("Saved, but reindexing %s failed: %v", names[i], err)
+		}
+	}
+	content := fmt.Sprintf("Context update applied: %s/%s.md", updates[0].FileType, updates[0].FileName)
+	if op := updates[0].Operation; len(updates) == 1 && (op == "rename" || op == "archive") && len(names) == 2 {
+		// The file's new place is written before the old one is removed.
+		content = fmt.Sprintf("%s applied: %s → %s", formatOperation(op), names[1], names[0])
+	} else if len(updates) > 1 {
+		content = "Context updates applied: " + strings.Join(names, ", ")
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	return nil
+}
+
 // rejectSuggestion handles rejecting a pending suggestion.
 func (m *Model) rejectSuggestion() (tea.Model, tea.Cmd) {
 	if m.pendingSuggestion != nil {
+		m.recordUsage(usageSuggestion, "rejected")
+		m.noteSuggestionOutcome(outcomeRejected)
 		m.messages = append(m.messages, Message{
 			Role:    "system",
 			Content: fmt.Sprintf("Rejected: %s", m.pendingSuggestion.Title),
@@ -521,7 +1108,7 @@ func (m *Model) returnToChat() (tea.Model, tea.Cmd) {
 	m.inputMode = true
 	m.textarea.Focus()
 	m.updateViewport()
-	return m, nil
+	return m, m.refreshPreferences(false)
 }
 
 // handleStreamChunk handles incoming stream chunks.
@@ -531,24 +1118,33 @@ func (m *Model) handleStreamChunk(msg StreamChunkMsg) (tea.Model, tea.Cmd) {
 	}
 
 	if msg.Content != "" {
-		if len(m.messages) > 0 && m.messages[len(m.messages)-1].Role == "assistant" {
+		m.streamedContent = true
+		m.generationReply += msg.Content
+		if len(m.messages) > 0 && m.messages[len(m.messages)-1].Role == "assistant" && !m.newReply {
 			m.messages[len(m.messages)-1].Content += msg.Content
 		} else {
 			m.messages = append(m.messages, Message{
-				Role:    "assistant",
-				Content: msg.Content,
+				Role:     "assistant",
+				Content:  msg.Content,
+				Model:    m.turnModel,
+				Replay:   m.replayOf,
+				Critique: m.critiquing,
 			})
+			m.newReply = false
 		}
 		m.updateViewport()
 	}
 
 	if msg.Done {
 		var cmds []tea.Cmd
+		m.finishGeneration()
 
-		if msg.FinishReason == llm.FinishReasonContentFilter {
-			toast, toastCmd := showToast("응답이 안전 필터에 의해 차단되었습니다", ToastWarning, 5*time.Second)
-			m.toast = toast
-			cmds = append(cmds, toastCmd)
+		if !m.toolCallAccumulator.HasCalls() {
+			m.extractTextToolCalls()
+		}
+
+		if m.replayOf != 0 {
+			return m.finishReplay()
 		}
 
 		if m.toolCallAccumulator.HasCalls() {
@@ -559,26 +1155,169 @@ func (m *Model) handleStreamChunk(msg StreamChunkMsg) (tea.Model, tea.Cmd) {
 			return model, tea.Batch(cmds...)
 		}
 
+		if m.critiquing {
+			if !m.newReply {
+				return m, m.reviseAfterCritique()
+			}
+			// A critique stage that produced nothing leaves the draft as
+			// the reply.
+			m.abandonCritique()
+			m.stream = nil
+			m.streamedContent = false
+			m.newReply = false
+			toast, toastCmd := showToast("The critique came back empty; the draft was kept.", ToastWarning, 5*time.Second)
+			m.toast = toast
+			return m, tea.Batch(func() tea.Msg { return StreamDoneMsg{} }, toastCmd)
+		}
+
+		// A refine stage that produced nothing leaves the draft as the reply.
+		refineFailed := m.refining && m.newReply
+		m.abandonRefinement()
+
 		hasAssistantContent := len(m.messages) > 0 &&
 			m.messages[len(m.messages)-1].Role == "assistant" &&
-			m.messages[len(m.messages)-1].Content != ""
+			m.messages[len(m.messages)-1].Content != "" &&
+			!refineFailed
+
+		if hasAssistantContent && msg.FinishReason == llm.FinishReasonLength {
+			model, cmd := m.handleLengthLimit()
+			return model, tea.Batch(append(cmds, cmd)...)
+		}
+
+		if hasAssistantContent && (m.continuing || m.revising) {
+			last := &m.messages[len(m.messages)-1]
+			last.Interrupted = false
+			m.updateLastMessage(last.Content, false)
+			m.checkLastReply()
+			m.resolveCitations(last)
+		} else if hasAssistantContent {
+			m.saveReply(m.messages[len(m.messages)-1].Content, false)
+			m.checkLastReply()
+			m.resolveCitations(&m.messages[len(m.messages)-1])
+			m.messages[len(m.messages)-1].Retrieval = m.turnRetrieval
+			if cmd := m.summarizeHistory(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
 
-		if hasAssistantContent {
-			m.saveMessage("assistant", m.messages[len(m.messages)-1].Content)
-		} else if msg.FinishReason != llm.FinishReasonContentFilter {
-			toast, toastCmd := showToast("응답을 받지 못했습니다 (콘텐츠가 차단되었을 수 있음)", ToastWarning, 5*time.Second)
+			// Prompts sent to a chosen model with @model or /polish are
+			// not critiqued or refined.
+			if m.selfCritique && !m.refining && m.turnProvider == nil &&
+				msg.FinishReason != llm.FinishReasonContentFilter {
+				return m, tea.Batch(append(cmds, m.critiqueDraft())...)
+			}
+			if choice := m.refineModel(); choice != nil && !m.refining && m.turnProvider == nil &&
+				msg.FinishReason != llm.FinishReasonContentFilter {
+				if cmd := m.refineDraft(*choice); cmd != nil {
+					return m, tea.Batch(append(cmds, cmd)...)
+				}
+			}
+		}
+
+		m.restoreRevisedReply()
+
+		// Blocked replies may still carry partial text, which is kept above.
+		if msg.FinishReason == llm.FinishReasonContentFilter {
+			cmds = append(cmds, m.showSafetyNotice(true))
+		} else if refineFailed {
+			toast, toastCmd := showToast("The revision came back empty; the draft was kept.", ToastWarning, 5*time.Second)
 			m.toast = toast
 			cmds = append(cmds, toastCmd)
+		} else if !hasAssistantContent {
+			cmds = append(cmds, m.showSafetyNotice(false))
 		}
 
-		m.streamChan = nil
-		cmds = append(cmds, func() tea.Msg { return StreamDoneMsg{} })
-		return m, tea.Batch(cmds...)
+		m.stream = nil
+		m.streamedContent = false
+		m.continuing = false
+		m.refining = false
+		m.newReply = false
+		done := func() tea.Msg { return StreamDoneMsg{} }
+		return m, tea.Batch(append([]tea.Cmd{done}, cmds...)...)
 	}
 
 	return m, tea.Batch(m.spinner.Tick, m.readNextChunk())
 }
 
+// extractTextToolCalls recovers JSON-in-text tool calls from the last
+// assistant message when the provider lacks native function calling.
+func (m *Model) extractTextToolCalls() {
+	provider, _ := m.activeProvider()
+	if provider == nil || provider.Capabilities().SupportsTools {
+		return
+	}
+	if len(m.messages) == 0 || m.messages[len(m.messages)-1].Role != "assistant" {
+		return
+	}
+
+	last := len(m.messages) - 1
+	calls, remaining := llm.ExtractTextToolCalls(m.messages[last].Content, llm.ChatTools())
+	if len(calls) == 0 {
+		return
+	}
+
+	if remaining == "" {
+		m.messages = m.messages[:last]
+	} else {
+		m.messages[last].Content = remaining
+	}
+	for _, call := range calls {
+		m.toolCallAccumulator.AddCall(call)
+	}
+	m.updateViewport()
+}
+
+// toolRepairMessages builds the follow-up turn that reports a tool argument
+// error back to the model. Native tool providers get a tool result message;
+// JSON-in-text providers get a plain user message.
+func toolRepairMessages(call llm.ToolCall, cause error, nativeTools bool) []llm.ChatMessage {
+	feedback := fmt.Sprintf("Error: the arguments for %s were invalid (%v). Call %s again with corrected JSON arguments that match its schema.",
+		call.Function.Name, cause, call.Function.Name)
+	return toolResultMessages(call, feedback, nativeTools)
+}
+
+// toolResultMessages builds the follow-up turn that answers a tool call with
+// content, in the form the provider understands.
+func toolResultMessages(call llm.ToolCall, content string, nativeTools bool) []llm.ChatMessage {
+	if nativeTools {
+		return []llm.ChatMessage{
+			{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{call}},
+			llm.NewToolMessage(call.ID, call.Function.Name, content),
+		}
+	}
+
+	raw := fmt.Sprintf("```tool\n{\"name\": %q, \"arguments\": %s}\n```", call.Function.Name, call.Function.Arguments)
+	return []llm.ChatMessage{
+		llm.NewAssistantMessage(raw),
+		llm.NewUserMessage(content),
+	}
+}
+
+// answerToolCall sends a lookup tool's result back to the model so it can
+// finish its reply. Past maxToolLookups the result is shown to the user
+// instead, which stops a model that keeps calling lookups.
+func (m *Model) answerToolCall(call llm.ToolCall, suggestion *SuggestionResult) (tea.Model, tea.Cmd) {
+	var content string
+	switch data := suggestion.ParsedData.(type) {
+	case string:
+		content = data
+	case []search.FTSSearchResult:
+		content = formatSearchAnswer(data)
+	}
+	if m.provider == nil || m.toolLookups >= maxToolLookups {
+		return m, func() tea.Msg {
+			return SuggestionMsg{Suggestion: suggestion}
+		}
+	}
+
+	m.toolLookups++
+	m.statusText = suggestion.Title + "..."
+	m.streaming = true
+	m.inputMode = false
+	provider, _ := m.activeProvider()
+	followUp := toolResultMessages(call, content, provider.Capabilities().SupportsTools)
+	return m, tea.Batch(m.spinner.Tick, m.startStreamWithFollowUp(followUp))
+}
+
 // processToolCalls processes accumulated tool calls.
 func (m *Model) processToolCalls() (tea.Model, tea.Cmd) {
 	calls := m.toolCallAccumulator.GetCompletedCalls()
@@ -587,16 +1326,75 @@ func (m *Model) processToolCalls() (tea.Model, tea.Cmd) {
 	if len(calls) == 0 {
 		return m, nil
 	}
+	for _, call := range calls {
+		m.recordUsage(usageTool, call.Function.Name)
+	}
 
-	// Process the first tool call (support single tool call for now)
+	// Several context updates in one reply are shown and applied together;
+	// otherwise only the first tool call is processed.
 	call := calls[0]
-	suggestion, err := m.suggestionHandler.HandleToolCall(call)
+	if m.offRecord() && llm.WritesProject(call.Function.Name) {
+		return m.refuseOffRecordWrite(call)
+	}
+	for _, c := range calls {
+		if m.librarian && !llm.SearchesProject(c.Function.Name) {
+			return m.refuseLibrarianTool(c)
+		}
+		if policy, err := projectToolPolicy(m.project); err != nil || !policy.Allows(c.Function.Name) {
+			return m.refuseDisallowedTool(c)
+		}
+	}
+	var suggestion *SuggestionResult
+	var err error
+	if len(calls) > 1 && allContextUpdates(calls) {
+		var failed int
+		suggestion, failed, err = m.suggestionHandler.HandleContextUpdates(calls)
+		if err != nil {
+			// Repair the update that failed, not the first one.
+			call = calls[failed]
+		}
+	} else {
+		suggestion, err = m.suggestionHandler.HandleToolCall(call)
+	}
 	if err != nil {
-		m.err = err
+		// Only arguments the model got wrong are worth asking it to fix;
+		// anything else, like a missing search index, is shown as is.
+		if !errors.Is(err, errInvalidToolArguments) {
+			m.toolRepairAttempts = 0
+			m.streaming = false
+			m.inputMode = true
+			m.textarea.Focus()
+			m.err = err
+			return m, nil
+		}
+		if m.provider != nil && m.toolRepairAttempts < maxToolRepairAttempts {
+			m.toolRepairAttempts++
+			m.statusText = fmt.Sprintf("Asking the model to fix its tool arguments (%d/%d)...", m.toolRepairAttempts, maxToolRepairAttempts)
+			m.streaming = true
+			m.inputMode = false
+			provider, _ := m.activeProvider()
+			followUp := toolRepairMessages(call, err, provider.Capabilities().SupportsTools)
+			return m, tea.Batch(m.spinner.Tick, m.startStreamWithFollowUp(followUp))
+		}
+
+		m.toolRepairAttempts = 0
 		m.streaming = false
 		m.inputMode = true
 		m.textarea.Focus()
-		return m, nil
+		toast, cmd := showToast("The model's suggestion was malformed and could not be shown", ToastWarning, 5*time.Second)
+		m.toast = toast
+		return m, cmd
+	}
+	m.toolRepairAttempts = 0
+
+	if suggestion.Type == SuggestionTypeVoice || suggestion.Type == SuggestionTypeSearch || suggestion.Type == SuggestionTypeTravel {
+		return m.answerToolCall(call, suggestion)
+	}
+	if cmd, ok := m.autoApplyContextUpdates(suggestion); ok {
+		return m, cmd
+	}
+	if cmd, ok := m.queueContextUpdate(suggestion); ok {
+		return m, cmd
 	}
 
 	return m, func() tea.Msg {
@@ -611,15 +1409,55 @@ func (m *Model) handleSubmit() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	m.recordPrompt(input)
+
 	if strings.HasPrefix(input, "/") {
 		return m.handleCommand(input)
 	}
 
+	if m.offline {
+		// Keep the draft in the composer so nothing is lost.
+		m.showOfflineNotice()
+		return m, nil
+	}
+	if m.aiLocked() {
+		return m, nil
+	}
+	blocked, quotaCmd := m.quotaBlocked(input)
+	if blocked {
+		return m, quotaCmd
+	}
+	m.noteModification(input)
+
+	var override *modelChoice
+	if ref, prompt := parseModelOverride(input); ref != "" {
+		choice := m.resolveModelOverride(ref)
+		override = &choice
+		input = prompt
+	}
+	if err := m.beginTurn(override); err != nil {
+		m.err = err
+		return m, quotaCmd
+	}
+
+	input = withAttachments(input, m.attachments)
+	m.attachments = nil
+	model, cmd := m.submitPrompt(input)
+	return model, tea.Batch(quotaCmd, cmd)
+}
+
+// submitPrompt sends input as the next user turn to the model set up by
+// beginTurn.
+func (m *Model) submitPrompt(input string) (tea.Model, tea.Cmd) {
 	m.messages = append(m.messages, Message{
 		Role:    "user",
 		Content: input,
+		Model:   m.turnModel,
 	})
 	m.saveMessage("user", input)
+	m.toolRepairAttempts = 0
+	m.toolLookups = 0
+	m.autoContinues = 0
 
 	m.textarea.Reset()
 	m.updateViewport()
@@ -631,7 +1469,7 @@ func (m *Model) handleSubmit() (tea.Model, tea.Cmd) {
 	m.streaming = true
 	m.inputMode = false
 
-	if m.provider == nil {
+	if provider, _ := m.activeProvider(); provider == nil {
 		m.messages = append(m.messages, Message{
 			Role:    "assistant",
 			Content: "No LLM provider configured. Please set up a provider in your config.",
@@ -646,6 +1484,7 @@ func (m *Model) handleSubmit() (tea.Model, tea.Cmd) {
 func (m *Model) handleCommand(input string) (tea.Model, tea.Cmd) {
 	parts := strings.Fields(input)
 	cmd := strings.ToLower(parts[0])
+	m.recordUsage(usageCommand, cmd)
 
 	switch cmd {
 	case "/help":
@@ -653,15 +1492,16 @@ func (m *Model) handleCommand(input string) (tea.Model, tea.Cmd) {
 		m.updateViewport()
 
 	case "/quit", "/exit", "/q":
-		return m, tea.Quit
+		m.textarea.Reset()
+		return m, m.quit()
 
 	case "/clear":
 		m.messages = []Message{}
+		m.historySummary = nil
 		m.updateViewport()
 
 	case "/context":
-		m.view = ViewContext
-		m.updateViewport()
+		m.handleContextCommand(parts[1:])
 
 	case "/chapters":
 		m.view = ViewChapters
@@ -675,53 +1515,361 @@ func (m *Model) handleCommand(input string) (tea.Model, tea.Cmd) {
 		if len(parts) > 1 {
 			query := strings.Join(parts[1:], " ")
 			m.statusText = fmt.Sprintf("Searching: %s", query)
-			// TODO: Implement search
+			m.runSearch(query)
 		} else {
 			m.err = fmt.Errorf("usage: /search <query>")
 		}
 
-	case "/chapter":
-		if len(parts) > 1 {
-			m.statusText = fmt.Sprintf("Switching to chapter: %s", parts[1])
-			// TODO: Implement chapter switching
-		} else {
-			m.err = fmt.Errorf("usage: /chapter <number>")
-		}
+	case "/expand":
+		m.expandSearchResult(strings.Join(parts[1:], " "))
 
-	case "/reindex":
-		m.statusText = "Reindexing..."
-		// TODO: Implement reindex
+	case "/replay":
+		m.textarea.Reset()
+		return m.replayGeneration(parts[1:])
 
-	case "/models":
-		return m.showModelSelection()
+	case "/ab":
+		m.textarea.Reset()
+		return m, m.handleABCommand(input)
 
-	default:
-		m.err = fmt.Errorf("unknown command: %s", cmd)
+	case "/explain":
+		m.toggleExplainRanking()
+
+	case "/critique":
+		m.toggleSelfCritique()
+
+	case "/insights":
+		m.handleInsightsCommand(parts[1:])
+
+	case "/undo":
+		m.undoAutoApplied()
+
+	case "/pending":
+		m.textarea.Reset()
+		return m, m.handlePendingCommand(parts[1:])
+
+	case "/preferences":
+		m.textarea.Reset()
+		return m, m.handlePreferencesCommand(parts[1:])
+
+	case "/edit-chapter":
+		m.textarea.Reset()
+		return m, m.editChapter(parts[1:])
+
+	case "/chapter":
+		if len(parts) > 1 {
+			m.setActiveChapter(parts[1])
+		} else {
+			m.err = fmt.Errorf("usage: /chapter <number>")
+		}
+
+	case "/reindex":
+		return m, m.rebuildIndex()
+
+	case "/remember":
+		if len(parts) > 1 {
+			m.rememberFact(strings.TrimSpace(strings.TrimPrefix(input, parts[0])))
+		} else {
+			m.err = fmt.Errorf("usage: /remember <fact>")
+		}
+
+	case "/memories":
+		if len(parts) >= 3 && strings.ToLower(parts[1]) == "delete" {
+			m.deleteMemory(parts[2])
+		} else {
+			m.showMemories()
+		}
+
+	case "/pin", "/unpin":
+		m.handlePinCommand(cmd == "/pin", strings.TrimSpace(strings.TrimPrefix(input, parts[0])))
+
+	case "/sources":
+		m.toggleCitations()
+
+	case "/multiline":
+		m.toggleMultiline()
+
+	case "/attach":
+		switch {
+		case len(parts) == 1:
+			m.showAttachments()
+		case strings.ToLower(parts[1]) == "clear":
+			m.clearAttachments()
+		default:
+			m.attachFile(strings.TrimSpace(strings.TrimPrefix(input, parts[0])))
+		}
+
+	case "/paste":
+		m.attachClipboard()
+
+	case "/restore":
+		// Reset first: restoring refills the composer with the unsent text.
+		m.textarea.Reset()
+		m.restoreCrashSession(len(parts) > 1 && strings.ToLower(parts[1]) == "discard")
+		return m, nil
+
+	case "/select":
+		m.textarea.Reset()
+		m.enterSelectMode()
+		return m, nil
+
+	case "/stats":
+		m.openStats()
+
+	case "/event":
+		m.handleEventCommand(parts[1:])
+
+	case "/beats":
+		m.handleBeatsCommand(parts[1:])
+
+	case "/words":
+		m.showWordFrequency()
+
+	case "/subplots":
+		m.handleSubplotsCommand(parts[1:])
+
+	case "/series":
+		m.handleSeriesCommand(parts[1:])
+
+	case "/continuity":
+		m.handleContinuityCommand(parts[1:])
+
+	case "/sandbox":
+		return m.handleSandboxCommand(strings.TrimSpace(strings.TrimPrefix(input, parts[0])))
+
+	case "/librarian":
+		m.handleLibrarianCommand(parts[1:])
+
+	case "/interview":
+		m.handleInterviewCommand(strings.TrimSpace(strings.TrimPrefix(input, parts[0])))
+
+	case "/pitch":
+		return m.handlePitchCommand(parts[1:])
+
+	case "/arcs":
+		m.textarea.Reset()
+		return m, m.handleArcsCommand(parts[1:])
+
+	case "/world":
+		m.handleWorldCommand(strings.TrimSpace(strings.TrimPrefix(input, parts[0])))
+
+	case "/items":
+		m.handleItemsCommand(parts[1:])
+
+	case "/rules":
+		m.showRules()
+
+	case "/pacing":
+		m.handlePacingCommand(parts[1:])
+
+	case "/review":
+		m.textarea.Reset()
+		return m, m.handleReviewCommand(parts[1:])
+
+	case "/report":
+		m.showBibleReport(parts[1:])
+
+	case "/glossary":
+		if len(parts) > 1 && strings.ToLower(parts[1]) == "check" {
+			m.checkGlossary()
+		} else {
+			m.showGlossary()
+		}
+
+	case "/retry":
+		m.textarea.Reset()
+		if m.offline {
+			m.showOfflineNotice()
+			return m, nil
+		}
+		if m.aiLocked() {
+			return m, nil
+		}
+		return m.retryLastRequest(len(parts) > 1 && strings.ToLower(parts[1]) == "soften")
+
+	case "/fix":
+		m.textarea.Reset()
+		if m.offline {
+			m.showOfflineNotice()
+			return m, nil
+		}
+		if m.aiLocked() {
+			return m, nil
+		}
+		return m.fixProse()
+
+	case "/polish":
+		m.textarea.Reset()
+		if m.offline {
+			m.showOfflineNotice()
+			return m, nil
+		}
+		if m.aiLocked() {
+			return m, nil
+		}
+		return m.polishLastPassage()
+
+	case "/continue":
+		m.textarea.Reset()
+		if m.offline {
+			m.showOfflineNotice()
+			return m, nil
+		}
+		if m.aiLocked() {
+			return m, nil
+		}
+		return m.continueReply()
+
+	case "/history":
+		m.textarea.Reset()
+		m.openHistoryPalette(strings.TrimSpace(strings.TrimPrefix(input, parts[0])))
+		return m, nil
+
+	case "/newchar":
+		m.textarea.Reset()
+		return m, m.openCharacterForm()
+
+	case "/sprint":
+		m.textarea.Reset()
+		return m, m.handleSprintCommand(parts[1:])
+
+	case "/models":
+		if m.offline {
+			m.showOfflineNotice()
+			m.textarea.Reset()
+			return m, nil
+		}
+		filter := ""
+		if len(parts) > 1 {
+			filter = parts[1]
+		}
+		return m.showModelSelection(filter)
+
+	case "/projects":
+		return m.handleProjectsCommand(parts[1:])
+
+	case "/tab", "/tabs":
+		m.handleTabCommand(parts[1:])
+
+	default:
+		m.err = fmt.Errorf("unknown command: %s", cmd)
 	}
 
 	m.textarea.Reset()
 	return m, nil
 }
 
+// showOfflineNotice appends the offline notice to the chat.
+func (m *Model) showOfflineNotice() {
+	m.messages = append(m.messages, Message{Role: "system", Content: offlineNotice})
+	m.updateViewport()
+}
+
+// runSearch queries the local full-text index and shows the results inline,
+// falling back to fuzzy matches when nothing matches exactly.
+func (m *Model) runSearch(query string) {
+	if m.searchEngine == nil {
+		return
+	}
+
+	results, err := m.searchEngine.SearchWithFuzzyFallback(query, search.SearchFilter{}, 10)
+	if err != nil {
+		m.err = fmt.Errorf("search failed: %w", err)
+		return
+	}
+	m.lastSearch = results
+
+	var sb strings.Builder
+	if len(results) == 0 {
+		sb.WriteString(fmt.Sprintf("No results for %q.", query))
+	} else {
+		if results[0].Fuzzy {
+			sb.WriteString(fmt.Sprintf("No exact matches for %q. Fuzzy matches (similar spellings):\n", query))
+		} else {
+			sb.WriteString(fmt.Sprintf("Search results for %q:\n", query))
+		}
+		for i, r := range results {
+			sb.WriteString(fmt.Sprintf("\n%d. [%s] %s\n   %s", i+1, r.SourceType, r.Location(), truncateContent(r.Content, 150)))
+		}
+		sb.WriteString("\n\nUse /expand <number> to read a result with the passages around it.")
+	}
+
+	m.messages = append(m.messages, Message{Role: "system", Content: sb.String()})
+	m.updateViewport()
+}
+
 func (m *Model) startStream(userInput string) tea.Cmd {
-	provider := m.provider
+	return m.startStreamWithFollowUp(nil)
+}
+
+// startStreamWithFollowUp starts a stream whose request ends with the given
+// messages after the current user turn, e.g. a tool error for the model to fix.
+func (m *Model) startStreamWithFollowUp(followUp []llm.ChatMessage) tea.Cmd {
+	provider, modelName := m.activeProvider()
 	project := m.project
 	contextMode := m.contextMode
 	searchEngine := m.searchEngine
 	messages := make([]Message, len(m.messages))
 	copy(messages, m.messages)
+	messages = withHistorySummary(messages, m.historySummary)
+	sandboxed := m.sandbox != nil
+	librarian := m.librarian
+	var interviewPrompt string
+	if m.interview != nil {
+		interviewPrompt = m.interview.prompt
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultStreamConfig().Timeout)
-	m.streamController = &StreamController{ctx: ctx, cancel: cancel, config: DefaultStreamConfig()}
+	ctx, cancel := context.WithTimeout(context.Background(), m.streamConfig.Timeout)
+	m.streamController = &StreamController{ctx: ctx, cancel: cancel, config: m.streamConfig}
+	m.streamedContent = false
+	m.assembling = true
 
 	return func() tea.Msg {
-		assembled, err := assembleChatRequest(project, provider, m.modelName, contextMode, searchEngine, messages)
-		if err != nil {
-			return StreamErrorMsg{Err: err}
+		// Assembly reads context files and searches the index, which can
+		// take seconds on big projects; interrupting the reply stops
+		// waiting for it.
+		done := make(chan tea.Msg, 1)
+		go func() {
+			assembled, err := assembleChatRequest(project, provider, modelName, contextMode, searchEngine, messages)
+			if err != nil {
+				done <- StreamErrorMsg{Err: err}
+				return
+			}
+			req := assembled.Request
+			if librarian {
+				librarianRequest(&req)
+			}
+			if sandboxed {
+				sandboxRequest(&req)
+			}
+			if interviewPrompt != "" {
+				interviewRequest(&req, interviewPrompt)
+			}
+			req.Messages = append(req.Messages, followUp...)
+			withSeed(&req, provider)
+			done <- requestAssembledMsg{ctx: ctx, provider: provider, request: req, retrieval: assembled.Retrieval}
+		}()
+
+		select {
+		case msg := <-done:
+			return msg
+		case <-ctx.Done():
+			return nil
 		}
-		req := assembled.Request
+	}
+}
 
-		streamChan, err := provider.Stream(ctx, req)
+// openStream sends an assembled request to the provider.
+func openStream(msg requestAssembledMsg) tea.Cmd {
+	return func() tea.Msg {
+		// Providers that cannot stream are served through Chat and replayed
+		// as a single chunk so the rest of the pipeline stays unchanged.
+		var streamChan <-chan llm.StreamChunk
+		var err error
+		if msg.provider.Capabilities().SupportsStreaming {
+			streamChan, err = msg.provider.Stream(msg.ctx, msg.request)
+		} else {
+			streamChan, err = chatAsStream(msg.ctx, msg.provider, msg.request)
+		}
 		if err != nil {
 			return StreamErrorMsg{Err: err}
 		}
@@ -745,7 +1893,7 @@ func buildSystemPromptAsync(proj *project.Project, contextMode ContextMode, sear
 	case ContextHybrid:
 		builder.AddContext(buildEssentialContextAsync(proj))
 		if searchEngine != nil && userInput != "" {
-			if searchContext := buildSearchContextAsync(searchEngine, userInput); searchContext != "" {
+			if searchContext := buildSearchContextAsync(proj, searchEngine, userInput); searchContext != "" {
 				builder.AddContext("\n### Additional Search Results\n" + searchContext)
 			}
 		}
@@ -758,57 +1906,69 @@ func buildSystemPromptAsync(proj *project.Project, contextMode ContextMode, sear
 }
 
 func buildEssentialContextAsync(proj *project.Project) string {
+	return renderEssentialContext(proj, "Plot Points")
+}
+
+// renderEssentialContext lists each character, setting and plot file by
+// title and first line, from the project's cache so only files changed
+// since the last request are read. Each list is quoted as project material.
+func renderEssentialContext(proj *project.Project, plotHeading string) string {
 	if proj == nil {
 		return ""
 	}
 
 	var sb strings.Builder
 	sb.WriteString("\n## Story Context\n\n")
-
-	if characters, err := proj.LoadCharacters(); err == nil && len(characters) > 0 {
-		sb.WriteString("### Characters\n")
-		for _, c := range characters {
-			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", c.Name, truncateForEssential(c.Description, 200)))
-		}
-		sb.WriteString("\n")
-	}
-
-	if settings, err := proj.LoadSettings(); err == nil && len(settings) > 0 {
-		sb.WriteString("### Settings\n")
-		for _, s := range settings {
-			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", s.Name, truncateForEssential(s.Description, 200)))
+	for _, section := range []struct{ category, heading string }{
+		{"characters", "Characters"},
+		{"settings", "Settings"},
+		{"plot", plotHeading},
+	} {
+		summaries, err := proj.ContextSummaries(section.category)
+		if err != nil || len(summaries) == 0 {
+			continue
 		}
-		sb.WriteString("\n")
-	}
-
-	if plots, err := proj.LoadPlots(); err == nil && len(plots) > 0 {
-		sb.WriteString("### Plot Points\n")
-		for _, p := range plots {
-			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", p.Title, truncateForEssential(p.Description, 200)))
+		var list strings.Builder
+		for _, s := range summaries {
+			list.WriteString(fmt.Sprintf("- **%s**: %s\n", s.Title, truncateForEssential(s.FirstLine, 200)))
 		}
-		sb.WriteString("\n")
+		writeQuotedSection(&sb, section.heading, list.String())
 	}
 
 	return sb.String()
 }
 
-func buildSearchContextAsync(searchEngine *search.FTSEngine, query string) string {
+// writeQuotedSection writes a heading followed by body quoted as project
+// material, so text in the project's files cannot pass for instructions.
+func writeQuotedSection(sb *strings.Builder, heading, body string) {
+	sb.WriteString("### " + heading + "\n")
+	sb.WriteString(llm.QuoteContext(strings.TrimSpace(body)))
+	sb.WriteString("\n\n")
+}
+
+func buildSearchContextAsync(proj *project.Project, searchEngine *search.FTSEngine, query string) string {
 	if searchEngine == nil {
 		return ""
 	}
 
 	results, err := searchEngine.Search(query, 5)
-	if err != nil || len(results) == 0 {
+	if err != nil {
+		return ""
+	}
+	results = proj.AutoContext(results)
+	if len(results) == 0 {
 		return ""
 	}
 
 	var sb strings.Builder
 	for _, r := range results {
-		sb.WriteString(fmt.Sprintf("**%s** (score: %.2f):\n%s\n\n", r.SourcePath, r.Score, r.Content))
+		sb.WriteString(fmt.Sprintf("**%s** (score: %.2f):\n%s\n\n", r.Location(), r.Score, r.Content))
 	}
 	return sb.String()
 }
 
+// buildFullContextAsync includes every character, setting and plot file in
+// full, each section quoted as project material.
 func buildFullContextAsync(proj *project.Project) string {
 	if proj == nil {
 		return ""
@@ -818,24 +1978,27 @@ func buildFullContextAsync(proj *project.Project) string {
 	sb.WriteString("\n## Complete Story Context\n\n")
 
 	if characters, err := proj.LoadCharacters(); err == nil && len(characters) > 0 {
-		sb.WriteString("### Characters\n\n")
+		var body strings.Builder
 		for _, c := range characters {
-			sb.WriteString(fmt.Sprintf("#### %s\n%s\n\n", c.Name, c.Description))
+			body.WriteString(fmt.Sprintf("#### %s\n%s\n\n", c.Name, c.Description))
 		}
+		writeQuotedSection(&sb, "Characters", body.String())
 	}
 
 	if settings, err := proj.LoadSettings(); err == nil && len(settings) > 0 {
-		sb.WriteString("### Settings\n\n")
+		var body strings.Builder
 		for _, s := range settings {
-			sb.WriteString(fmt.Sprintf("#### %s\n%s\n\n", s.Name, s.Description))
+			body.WriteString(fmt.Sprintf("#### %s\n%s\n\n", s.Name, s.Description))
 		}
+		writeQuotedSection(&sb, "Settings", body.String())
 	}
 
 	if plots, err := proj.LoadPlots(); err == nil && len(plots) > 0 {
-		sb.WriteString("### Plot\n\n")
+		var body strings.Builder
 		for _, p := range plots {
-			sb.WriteString(fmt.Sprintf("#### %s\n%s\n\n", p.Title, p.Description))
+			body.WriteString(fmt.Sprintf("#### %s\n%s\n\n", p.Title, p.Description))
 		}
+		writeQuotedSection(&sb, "Plot", body.String())
 	}
 
 	return sb.String()
@@ -858,27 +2021,14 @@ func buildChatMessagesAsync(systemPrompt string, messages []Message) []llm.ChatM
 	return chatMessages
 }
 
+// readNextChunk waits for the next update from the current stream.
 func (m *Model) readNextChunk() tea.Cmd {
+	stream := m.stream
 	return func() tea.Msg {
-		if m.streamChan == nil {
+		if stream == nil {
 			return StreamDoneMsg{}
 		}
-
-		chunk, ok := <-m.streamChan
-		if !ok {
-			return StreamChunkMsg{Done: true}
-		}
-
-		if chunk.Error != nil {
-			return StreamErrorMsg{Err: chunk.Error}
-		}
-
-		return StreamChunkMsg{
-			Content:      chunk.Delta,
-			ToolCall:     chunk.ToolCall,
-			Done:         chunk.Done,
-			FinishReason: chunk.FinishReason,
-		}
+		return stream.next()
 	}
 }
 
@@ -911,70 +2061,11 @@ func (m *Model) buildSystemPrompt(userInput string) string {
 }
 
 func (m *Model) buildEssentialContext() string {
-	if m.project == nil {
-		return ""
-	}
-
-	var sb strings.Builder
-	sb.WriteString("\n## Story Context\n\n")
-
-	if characters, err := m.project.LoadCharacters(); err == nil && len(characters) > 0 {
-		sb.WriteString("### Characters\n")
-		for _, c := range characters {
-			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", c.Name, truncateForEssential(c.Description, 200)))
-		}
-		sb.WriteString("\n")
-	}
-
-	if settings, err := m.project.LoadSettings(); err == nil && len(settings) > 0 {
-		sb.WriteString("### Settings\n")
-		for _, s := range settings {
-			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", s.Name, truncateForEssential(s.Description, 200)))
-		}
-		sb.WriteString("\n")
-	}
-
-	if plots, err := m.project.LoadPlots(); err == nil && len(plots) > 0 {
-		sb.WriteString("### Plot\n")
-		for _, p := range plots {
-			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", p.Title, truncateForEssential(p.Description, 200)))
-		}
-		sb.WriteString("\n")
-	}
-
-	return sb.String()
+	return renderEssentialContext(m.project, "Plot")
 }
 
 func (m *Model) buildFullContext() string {
-	if m.project == nil {
-		return ""
-	}
-
-	var sb strings.Builder
-	sb.WriteString("\n## Complete Story Context\n\n")
-
-	if characters, err := m.project.LoadCharacters(); err == nil && len(characters) > 0 {
-		sb.WriteString("### Characters\n\n")
-		for _, c := range characters {
-			sb.WriteString(fmt.Sprintf("#### %s\n%s\n\n", c.Name, c.Description))
-		}
-	}
-
-	if settings, err := m.project.LoadSettings(); err == nil && len(settings) > 0 {
-		sb.WriteString("### Settings\n\n")
-		for _, s := range settings {
-			sb.WriteString(fmt.Sprintf("#### %s\n%s\n\n", s.Name, s.Description))
-		}
-	}
-
-	if plots, err := m.project.LoadPlots(); err == nil && len(plots) > 0 {
-		sb.WriteString("### Plot\n\n")
-		for _, p := range plots {
-			sb.WriteString(fmt.Sprintf("#### %s\n%s\n\n", p.Title, p.Description))
-		}
-	}
-
-	return sb.String()
+	return buildFullContextAsync(m.project)
 }
 
 func (m *Model) buildSearchContext(userInput string) string {
@@ -983,17 +2074,23 @@ func (m *Model) buildSearchContext(userInput string) string {
 	}
 
 	results, err := m.searchEngine.Search(userInput, 8)
-	if err != nil || len(results) == 0 {
+	if err != nil {
+		return ""
+	}
+	results = m.project.AutoContext(results)
+	if len(results) == 0 {
 		return ""
 	}
 
 	chunks := make([]llm.ContextChunk, 0, len(results))
 	for _, r := range results {
 		chunks = append(chunks, llm.ContextChunk{
+			ID:         r.ID,
 			Content:    r.Content,
 			SourceType: r.SourceType,
 			SourcePath: r.SourcePath,
 			Score:      r.Score,
+			Section:    r.Section,
 		})
 	}
 	return (&llm.ContextManager{}).BuildContextPrompt(chunks)
@@ -1034,9 +2131,11 @@ func (m *Model) cancelStream() {
 	if m.streamController != nil {
 		m.streamController.Cancel()
 	}
+	m.keepPartialOutput()
 	m.streaming = false
+	m.assembling = false
 	m.inputMode = true
-	m.streamChan = nil
+	m.stream = nil
 	m.textarea.Focus()
 }
 
@@ -1049,6 +2148,15 @@ func (m *Model) updateViewport() {
 		m.viewport.SetContent(content)
 		return
 	}
+	if m.projectSelectMode {
+		m.viewport.SetContent(m.renderProjectSelect())
+		return
+	}
+	if m.historyPaletteMode {
+		m.viewport.SetContent(m.renderHistoryPalette())
+		m.viewport.GotoTop()
+		return
+	}
 
 	switch m.view {
 	case ViewChat:
@@ -1061,31 +2169,105 @@ func (m *Model) updateViewport() {
 		content = m.renderChapters()
 	case ViewSuggestion:
 		content = m.renderSuggestion()
+	case ViewStats:
+		content = m.renderStats()
+	case ViewCompare:
+		content = m.renderComparison()
 	}
 
 	m.viewport.SetContent(content)
+	if m.selectMode && m.view == ViewChat && m.selectedMessage < len(m.messageLines) {
+		m.viewport.SetYOffset(m.messageLines[m.selectedMessage])
+		return
+	}
 	m.viewport.GotoBottom()
 }
 
 // renderChat renders the chat view.
 func (m *Model) renderChat() string {
 	var sb strings.Builder
+	m.messageLines = m.messageLines[:0]
+	if len(m.renderedMessages) > len(m.messages) {
+		m.renderedMessages = m.renderedMessages[:len(m.messages)]
+	}
 
-	for _, msg := range m.messages {
-		switch msg.Role {
-		case "user":
-			sb.WriteString(styles.UserMessage.Render("You: " + msg.Content))
-		case "assistant":
-			sb.WriteString(styles.AssistantMessage.Render("AI: " + msg.Content))
-		case "system":
-			sb.WriteString(styles.SystemMessage.Render(msg.Content))
-		}
-		sb.WriteString("\n\n")
+	line := 0
+	for i := range m.messages {
+		m.messageLines = append(m.messageLines, line)
+		rendered := m.renderMessageCached(i)
+		sb.WriteString(rendered.text)
+		line += rendered.lines
 	}
 
 	return sb.String()
 }
 
+// renderMessage renders one message of the chat view with the blank line
+// that follows it.
+func (m *Model) renderMessage(msg Message, selected bool) string {
+	var sb strings.Builder
+	if selected {
+		sb.WriteString(styles.SelectedItem.Render("▶ selected — " + m.selectionHint()))
+		sb.WriteString("\n")
+	}
+
+	switch msg.Role {
+	case "user":
+		content := collapseAttachments(msg.Content)
+		if msg.Folded {
+			content = foldedPreview(content)
+		}
+		label := "You: "
+		if msg.Model != "" {
+			label = "You (@" + msg.Model + "): "
+		}
+		sb.WriteString(styles.UserMessage.Render(label + content))
+	case "assistant":
+		content := msg.Content
+		if msg.Folded {
+			content = foldedPreview(content)
+		}
+		label := "AI: "
+		switch {
+		case msg.Replay != 0 && msg.Model != "":
+			label = fmt.Sprintf("AI (replay of #%d · %s): ", msg.Replay, msg.Model)
+		case msg.Replay != 0:
+			label = fmt.Sprintf("AI (replay of #%d): ", msg.Replay)
+		case msg.Critique:
+			label = "AI (critique): "
+		case msg.Draft && msg.Model != "":
+			label = "AI (draft · " + msg.Model + "): "
+		case msg.Draft:
+			label = "AI (draft): "
+		case msg.Model != "":
+			label = "AI (" + msg.Model + "): "
+		}
+		sb.WriteString(styles.AssistantMessage.Render(label + content))
+		if msg.Interrupted {
+			sb.WriteString("\n")
+			sb.WriteString(styles.MutedText.Render("⏸ interrupted — /continue to resume"))
+		}
+		for _, issue := range msg.Issues {
+			sb.WriteString("\n")
+			sb.WriteString(styles.InfoText.Render(fmt.Sprintf("⚠ %s — Ctrl+F or /fix to rewrite", issue.Message)))
+		}
+		if citations := m.renderCitations(msg.Citations); citations != "" {
+			sb.WriteString("\n")
+			sb.WriteString(citations)
+		}
+		if m.explainRanking {
+			if explanation := renderRankingExplanation(msg.Retrieval); explanation != "" {
+				sb.WriteString("\n")
+				sb.WriteString(explanation)
+			}
+		}
+	case "system":
+		sb.WriteString(styles.SystemMessage.Render(msg.Content))
+	}
+	sb.WriteString("\n\n")
+	return sb.String()
+}
+
 // renderHelp renders the help view.
 func (m *Model) renderHelp() string {
 	help := `
@@ -1095,107 +2277,84 @@ Commands:
   /help      - Show this help
   /quit      - Exit the application
   /clear     - Clear chat history
-  /context   - View/manage context files
+  /context   - View and edit context files (/context new <category>, edit <name>, delete <name>)
+               In the list, 1-9 jump to files linked with [[Name]]
   /chapters  - View/manage chapters
+  /newchar   - Create a character from a form (name, role, age, goals, flaw, voice)
   /search    - Search context (usage: /search <query>)
-  /chapter   - Switch chapter (usage: /chapter <number>)
+  /expand    - Read a search result in fuller context (usage: /expand <number> or /expand c<id>)
+  /explain   - Toggle showing why each context chunk was retrieved for a reply (Hybrid mode)
+  /critique  - Toggle self-critique: each reply is critiqued against the style and outline, then revised
+  /insights  - Summarize local usage: sessions, words, suggestions, tools (on|off|clear|<days>)
+  /pending   - Review queued context updates (approve|reject [<n>], on|off to queue them)
+  /undo      - Revert the last context update applied automatically
+  /preferences - Show the preference digest learned from suggestion choices (refresh|clear)
+  /replay    - List recent requests, or send one again exactly (usage: /replay <id> [@model])
+  /ab        - Send a prompt to two models or temperatures and keep one reply (usage: /ab <@model|temp> <@model|temp> <prompt>)
+  /chapter   - Pick the chapter replies are appended to (usage: /chapter <number>)
+  /edit-chapter - Open a chapter in $EDITOR and reindex it on return (usage: /edit-chapter [number])
   /reindex   - Rebuild search index
+  /continue  - Resume an interrupted reply
+  /fix       - Rewrite the last reply to fix flagged POV/tense drift
+  /polish    - Line-edit the last reply with the editing model (llm.polish_model)
+  /retry     - Resend a blocked request (/retry soften to tone it down)
+  /sprint    - Start a writing sprint (usage: /sprint 25m [lock]; /sprint stop ends it)
+  /remember  - Save a fact to project memory (usage: /remember <fact>)
+  /memories  - List memories (/memories delete <id> to remove one)
+  /pin       - Let research be added to context (usage: /pin research/<file or dir>; /unpin <path>; /pin lists)
+  /stats     - Show word counts, sprints and the writing event calendar
+  /event     - Start or end a writing event (usage: /event start 50k 30 [name]; /event stop)
+  /beats     - Show beat sheet progress (/beats apply <template>, done <beat>, undo <beat>)
+  /arcs      - Chart character arcs and long absences (/arcs set <character> <chapter> <state>, extract [chapter])
+  /world     - Show locations as a tree with travel times (/world <from> to <to> for a journey)
+  /items     - List objects, who holds them and where they turn up impossibly (/items move <item> <chapter> <holder>)
+  /rules     - List the hard rules of the world's magic or technology
+  /pacing    - Chart scene lengths and action vs. reflection across the book (/pacing svg to export)
+  /review    - Ask a reviewer persona to critique a chapter (/review <persona> [chapter], notes [chapter], export [chapter] [md|csv])
+  /subplots  - Show subplot coverage and gaps (/subplots tag <id> [chapter], untag <id> [chapter])
+  /series    - Show the book's series (/series join <name>, leave, search <query>, check)
+  /continuity - Snapshot facts and timeline before a rewrite (/continuity snapshot [name], compare [name])
+  /sandbox   - Explore what-ifs off the record (/sandbox [idea], promote, end)
+  /interview - Interview a character in their own voice (/interview <character>, save, end)
+  /pitch     - Draft pitch material from the outline (/pitch blurb, synopsis or query [notes])
+  /words     - Count crutch words and repeated phrases per chapter
+  /report    - Find unused, stale or empty context files (usage: /report [recent chapters])
+  /glossary  - List glossary terms (/glossary check to find misspellings)
+  /sources   - Expand or collapse cited sources
+  /multiline - Toggle multi-line composing (Enter adds a line)
+  /models    - Switch provider and model (/models <provider> to list one provider)
+  /projects  - Switch to another project (/projects <name> to open one directly)
+  /tab       - List chat tabs (/tab new <name>, /tab <number|name>; also Alt+1…9)
+  /librarian - Answer this tab's questions about the manuscript with cited sources, no prose (/librarian [on|off])
+  /attach    - Attach a project file to the next message (usage: /attach <path>)
+  /paste     - Attach the clipboard text to the next message
+  /select    - Select a message to fold, quote, delete, copy, append to a chapter or save as a note
+  /history   - Search sent prompts (usage: /history [query]; also Ctrl+R)
+  /restore   - Restore the session saved by a crash (/restore discard to drop it)
   /back      - Return to chat view
 
 Keyboard Shortcuts:
   Ctrl+C     - Cancel current operation / Quit
   Esc        - Cancel / Return to chat
   Enter      - Submit message
+  Alt+Enter  - New line (Ctrl+J also works; sends in multi-line mode)
+  Ctrl+S     - Submit message in multi-line mode
+  Ctrl+E     - Edit the message in $EDITOR
+  Ctrl+P/N   - Recall the previous/next sent prompt (Up/Down in an empty composer)
+  Ctrl+R     - Search sent prompts
+  Ctrl+F     - Fix flagged POV/tense drift in the last reply
+  Ctrl+O     - Expand or collapse cited sources
+  Ctrl+Up    - Select a message (j/k move, Enter fold, q quote, d delete,
+               c copy, a append to chapter, n save as note)
+
+Prefix a prompt with @model (or @provider/model) to send that one prompt to
+another model, e.g. "@gpt-4o-mini summarize chapter 3".
 
 Press /back or Esc to return to chat.
 `
 	return styles.InfoText.Render(help)
 }
 
-func (m *Model) renderModelSelect() string {
-	var sb strings.Builder
-	sb.WriteString(styles.Title.Render("Select Model"))
-	sb.WriteString("\n\n")
-
-	if len(m.availableModels) == 0 {
-		sb.WriteString(styles.MutedText.Render("No models available"))
-		return sb.String()
-	}
-
-	for i, model := range m.availableModels {
-		prefix := "  "
-		style := styles.MutedText
-		if i == m.modelSelectIndex {
-			prefix = "> "
-			style = styles.SelectedItem
-		}
-		if model == m.modelName {
-			sb.WriteString(style.Render(fmt.Sprintf("%s%s (current)\n", prefix, model)))
-		} else {
-			sb.WriteString(style.Render(fmt.Sprintf("%s%s\n", prefix, model)))
-		}
-	}
-
-	sb.WriteString("\n")
-	sb.WriteString(styles.HelpDesc.Render("↑/↓ Navigate • Enter Select • Esc Cancel"))
-	return sb.String()
-}
-
-// renderContext renders the context management view.
-func (m *Model) renderContext() string {
-	var sb strings.Builder
-	sb.WriteString(styles.Title.Render("Context Files"))
-	sb.WriteString("\n\n")
-
-	if m.project == nil {
-		sb.WriteString(styles.ErrorText.Render("No project loaded"))
-		return sb.String()
-	}
-
-	// Characters
-	sb.WriteString(styles.Subtitle.Render("Characters:"))
-	sb.WriteString("\n")
-	characters, _ := m.project.LoadCharacters()
-	if len(characters) == 0 {
-		sb.WriteString(styles.MutedText.Render("  No characters defined\n"))
-	} else {
-		for _, c := range characters {
-			sb.WriteString(styles.ListItem.Render("  - " + c.Name + "\n"))
-		}
-	}
-
-	// Settings
-	sb.WriteString("\n")
-	sb.WriteString(styles.Subtitle.Render("Settings:"))
-	sb.WriteString("\n")
-	settings, _ := m.project.LoadSettings()
-	if len(settings) == 0 {
-		sb.WriteString(styles.MutedText.Render("  No settings defined\n"))
-	} else {
-		for _, s := range settings {
-			sb.WriteString(styles.ListItem.Render("  - " + s.Name + "\n"))
-		}
-	}
-
-	// Plots
-	sb.WriteString("\n")
-	sb.WriteString(styles.Subtitle.Render("Plot Points:"))
-	sb.WriteString("\n")
-	plots, _ := m.project.LoadPlots()
-	if len(plots) == 0 {
-		sb.WriteString(styles.MutedText.Render("  No plot points defined\n"))
-	} else {
-		for _, p := range plots {
-			sb.WriteString(styles.ListItem.Render(fmt.Sprintf("  %d. %s\n", p.Order, p.Title)))
-		}
-	}
-
-	sb.WriteString("\n")
-	sb.WriteString(styles.MutedText.Render("Press /back or Esc to return to chat."))
-
-	return sb.String()
-}
-
 // renderChapters renders the chapters view.
 func (m *Model) renderChapters() string {
 	var sb strings.Builder
@@ -1267,8 +2426,8 @@ func (m *Model) renderSuggestion() string {
 	return sb.String()
 }
 
-// View renders the TUI.
-func (m *Model) View() string {
+// render renders the TUI.
+func (m *Model) render() string {
 	if !m.ready {
 		return "Initializing..."
 	}
@@ -1281,11 +2440,20 @@ func (m *Model) View() string {
 		projectName = m.project.Info.Name
 	}
 	header := styles.Header.Render(fmt.Sprintf("DREAMTELLER - %s", projectName))
+	if tabs := m.renderTabBar(); tabs != "" {
+		header += "  " + tabs
+	}
 	sb.WriteString(header)
 	sb.WriteString("\n")
 
 	// Main content
-	sb.WriteString(m.viewport.View())
+	if m.charForm != nil {
+		sb.WriteString(m.charForm.form.View())
+	} else if m.contextEdit != nil {
+		sb.WriteString(m.renderContextEditor())
+	} else {
+		sb.WriteString(m.viewport.View())
+	}
 	sb.WriteString("\n")
 
 	// Error display
@@ -1305,17 +2473,41 @@ func (m *Model) View() string {
 		sb.WriteString("\n")
 		sb.WriteString(m.textarea.View())
 		sb.WriteString("\n")
-		sb.WriteString(styles.MutedText.Render(strings.Repeat("─", m.width)))
+		sb.WriteString(m.renderComposerRule())
 	}
 
 	modelInfo := styles.StatusBar.Render("🤖 " + m.modelName)
+	if m.streaming && m.turnModel != "" {
+		modelInfo = styles.StatusBar.Render("🤖 " + m.turnModel + " (this reply)")
+	}
+	if m.offline {
+		modelInfo = styles.StatusBar.Render("⚡ offline")
+	}
 	contextInfo := styles.HelpKey.Render("[Tab]") + styles.HelpDesc.Render(" "+m.contextMode.String())
 	helpHint := styles.HelpKey.Render("/help") + styles.HelpDesc.Render(" for commands")
 
 	leftPart := modelInfo + "  " + contextInfo
+	if m.sandbox != nil {
+		leftPart += "  " + styles.TokenWarning.Render("🧪 sandbox")
+	}
+	if m.interview != nil {
+		leftPart += "  " + styles.TokenWarning.Render("🎭 "+m.interview.character)
+	}
+	if m.librarian {
+		leftPart += "  " + styles.InfoText.Render("📚 librarian")
+	}
+	if sprint := m.sprintStatus(); sprint != "" {
+		leftPart += "  " + styles.StatusBar.Render(sprint)
+	}
+	if quota := m.quotaStatus(); quota != "" {
+		leftPart += "  " + quota
+	}
 
 	if m.streaming {
 		spinnerPart := m.spinner.View() + " " + styles.HelpKey.Render("[esc]") + styles.HelpDesc.Render(" interrupt")
+		if m.assembling {
+			spinnerPart = m.spinner.View() + " " + styles.HelpDesc.Render("assembling context… ") + styles.HelpKey.Render("[esc]") + styles.HelpDesc.Render(" cancel")
+		}
 		gap := m.width - lipgloss.Width(leftPart) - lipgloss.Width(spinnerPart)
 		if gap < 0 {
 			gap = 0
@@ -1360,63 +2552,15 @@ type StreamReadyMsg struct {
 	StreamChan <-chan llm.StreamChunk
 }
 
-type errMsg struct {
-	err error
-}
-
-func (m *Model) showModelSelection() (tea.Model, tea.Cmd) {
-	m.statusText = "Fetching models..."
-	m.textarea.Reset()
-
-	return m, m.fetchModelsCmd()
-}
-
-type modelsListMsg struct {
-	models []string
-	err    error
-}
-
-func (m *Model) fetchModelsCmd() tea.Cmd {
-	return func() tea.Msg {
-		if m.providerName != "local" {
-			return modelsListMsg{err: fmt.Errorf("/models only supported for local provider")}
-		}
-
-		models, err := fetchLocalModelsForTUI(m.baseURL)
-		return modelsListMsg{models: models, err: err}
-	}
+// requestAssembledMsg carries a chat request ready to send, for the stream
+// started with ctx.
+type requestAssembledMsg struct {
+	ctx       context.Context
+	provider  llm.Provider
+	request   llm.ChatRequest
+	retrieval []RankedChunk
 }
 
-func fetchLocalModelsForTUI(baseURL string) ([]string, error) {
-	if baseURL == "" {
-		return nil, fmt.Errorf("no base URL configured")
-	}
-
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(strings.TrimSuffix(baseURL, "/") + "/api/tags")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Models []struct {
-			Name string `json:"name"`
-		} `json:"models"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
-	}
-
-	models := make([]string, len(result.Models))
-	for i, m := range result.Models {
-		models[i] = m.Name
-	}
-
-	return models, nil
+type errMsg struct {
+	err error
 }
@@ -1,10 +1,20 @@
 package tui
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/llm/adapters"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/internal/search"
+	"github.com/azyu/dreamteller/internal/storage"
+	"github.com/azyu/dreamteller/pkg/types"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
@@ -31,7 +41,7 @@ func TestNew(t *testing.T) {
 	t.Run("initializes textarea correctly", func(t *testing.T) {
 		m := New(nil, nil, nil, "test-model", "", "")
 
-		assert.Equal(t, 4000, m.textarea.CharLimit)
+		assert.Equal(t, composerCharLimit, m.textarea.CharLimit)
 		assert.Contains(t, m.textarea.Placeholder, "/help")
 		assert.False(t, m.textarea.ShowLineNumbers)
 	})
@@ -60,13 +70,14 @@ func TestInit(t *testing.T) {
 
 func TestModelsListMsg(t *testing.T) {
 	m := newTestModel(t)
-	msg := modelsListMsg{models: []string{"alpha", "beta"}}
+	choices := []modelChoice{{Provider: "local", Model: "alpha"}, {Provider: "local", Model: "beta"}}
+	msg := modelsListMsg{models: choices}
 
 	model, _ := m.Update(msg)
 	m = model.(*Model)
 
 	assert.True(t, m.modelSelectMode)
-	assert.Equal(t, []string{"alpha", "beta"}, m.availableModels)
+	assert.Equal(t, choices, m.availableModels)
 	assert.Equal(t, 0, m.modelSelectIndex)
 	assert.False(t, m.inputMode)
 }
@@ -74,7 +85,7 @@ func TestModelsListMsg(t *testing.T) {
 func TestModelSelectKeyEnter(t *testing.T) {
 	m := newTestModel(t)
 	m.modelSelectMode = true
-	m.availableModels = []string{"alpha", "beta"}
+	m.availableModels = []modelChoice{{Model: "alpha"}, {Model: "beta"}}
 	m.modelSelectIndex = 1
 
 	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
@@ -84,6 +95,83 @@ func TestModelSelectKeyEnter(t *testing.T) {
 	assert.Equal(t, "beta", m.modelName)
 }
 
+// listingProvider is a provider that lists a fixed set of models.
+type listingProvider struct {
+	stubProvider
+	models []string
+}
+
+func (p listingProvider) ListModels(ctx context.Context) ([]string, error) {
+	return p.models, nil
+}
+
+func TestModelSwitching(t *testing.T) {
+	providers := map[string]listingProvider{
+		"openai": {stubProvider{llm.Capabilities{MaxContextTokens: 128000}}, []string{"gpt-4o", "gpt-4o-mini"}},
+		"gemini": {stubProvider{llm.Capabilities{MaxContextTokens: 1000000}}, []string{"gemini-2.5-flash"}},
+	}
+	var built []string
+	factory := func(name, model string) (llm.Provider, error) {
+		p, ok := providers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %s", name)
+		}
+		built = append(built, name+"/"+model)
+		return p, nil
+	}
+
+	newModel := func(t *testing.T) *Model {
+		m := newTestModel(t)
+		m.provider = providers["openai"]
+		m.providerName = "openai"
+		m.modelName = "gpt-4o"
+		m.SetProviderSwitching([]string{"gemini", "openai", "anthropic"}, factory)
+		return m
+	}
+
+	t.Run("lists every configured provider", func(t *testing.T) {
+		m := newModel(t)
+		msg := m.fetchModelsCmd("")().(modelsListMsg)
+
+		assert.Equal(t, []modelChoice{
+			{Provider: "openai", Model: "gpt-4o"},
+			{Provider: "openai", Model: "gpt-4o-mini"},
+			{Provider: "gemini", Model: "gemini-2.5-flash"},
+		}, msg.models)
+		require.Error(t, msg.err)
+		assert.Contains(t, msg.err.Error(), "anthropic")
+
+		m.handleModelsList(msg)
+		assert.True(t, m.modelSelectMode)
+		assert.Contains(t, m.renderModelSelect(), "gpt-4o (current)")
+	})
+
+	t.Run("filters by provider", func(t *testing.T) {
+		m := newModel(t)
+		msg := m.fetchModelsCmd("gemini")().(modelsListMsg)
+		assert.Equal(t, []modelChoice{{Provider: "gemini", Model: "gemini-2.5-flash"}}, msg.models)
+		assert.NoError(t, msg.err)
+
+		msg = m.fetchModelsCmd("nope")().(modelsListMsg)
+		assert.Error(t, msg.err)
+	})
+
+	t.Run("switching rebuilds the provider and budget", func(t *testing.T) {
+		m := newModel(t)
+		before := m.composerHistoryBudget()
+		built = nil
+
+		m.switchModel(modelChoice{Provider: "gemini", Model: "gemini-2.5-flash"})
+		require.NoError(t, m.err)
+		assert.Equal(t, []string{"gemini/gemini-2.5-flash"}, built)
+		assert.Equal(t, "gemini", m.providerName)
+		assert.Equal(t, "gemini-2.5-flash", m.modelName)
+		assert.Equal(t, 1000000, m.provider.Capabilities().MaxContextTokens)
+		assert.Contains(t, m.statusText, "1000000-token context")
+		assert.Greater(t, m.composerHistoryBudget(), before)
+	})
+}
+
 // ============================================================================
 // Window Size Tests
 // ============================================================================
@@ -334,6 +422,18 @@ func TestHandleCommand_Search(t *testing.T) {
 		assert.Contains(t, m.statusText, "dragon")
 	})
 
+	t.Run("falls back to fuzzy matches", func(t *testing.T) {
+		proj := createTempProjectWithContext(t)
+		m := newTestModelWithProject(t, proj)
+		m.searchEngine = search.NewFTSEngine(proj.DB)
+		require.NoError(t, m.searchEngine.Index("The lighthouse keeper vanished.", search.SourceTypeChapter, "chapters/chapter-001.md", 5, time.Now(), "{}"))
+
+		m, _ = typeAndSubmit(m, "/search lighthose")
+
+		assertLastMessage(t, m, "system", "Fuzzy matches")
+		assertLastMessage(t, m, "system", "chapters/chapter-001.md")
+	})
+
 	t.Run("without query shows error", func(t *testing.T) {
 		m := newTestModel(t)
 		setTextareaValue(m, "/search")
@@ -373,6 +473,49 @@ func TestHandleCommand_Reindex(t *testing.T) {
 	assert.Contains(t, m.statusText, "index")
 }
 
+func TestHandleCommand_ReindexRebuilds(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.searchEngine = search.NewFTSEngine(proj.DB)
+	setTextareaValue(m, "/reindex")
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(*Model)
+	require.NotNil(t, cmd)
+	assert.True(t, m.reindexing)
+
+	model, _ = m.Update(cmd())
+	m = model.(*Model)
+	assert.False(t, m.reindexing)
+	assert.Contains(t, m.statusText, "Reindex complete")
+
+	changes, err := proj.IndexFormatChanges()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestHandleCommand_Expand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.searchEngine = search.NewFTSEngine(proj.DB)
+	for _, part := range []string{"The ship left port.", "A gale tore the mainsail.", "They limped into harbor."} {
+		require.NoError(t, m.searchEngine.Index(part, search.SourceTypeChapter, "chapters/chapter-001.md", 5, time.Now(), "{}"))
+	}
+
+	m, _ = typeAndSubmit(m, "/expand 1")
+	assertError(t, m)
+
+	m.err = nil
+	m, _ = typeAndSubmit(m, "/search gale")
+	assertLastMessage(t, m, "system", "/expand")
+
+	m, _ = typeAndSubmit(m, "/expand 1")
+	assertNoError(t, m)
+	last := m.messages[len(m.messages)-1].Content
+	assert.Contains(t, last, "The ship left port.")
+	assert.Contains(t, last, "They limped into harbor.")
+}
+
 func TestHandleCommand_Unknown(t *testing.T) {
 	m := newTestModel(t)
 	setTextareaValue(m, "/unknowncommand")
@@ -696,6 +839,41 @@ func TestRenderHelp(t *testing.T) {
 	assert.Contains(t, content, "Enter")
 }
 
+func TestRenderChat_Cache(t *testing.T) {
+	m := newTestModel(t)
+	for i := 0; i < 3; i++ {
+		addMessage(m, "user", fmt.Sprintf("prompt %d", i))
+		addMessage(m, "assistant", fmt.Sprintf("line one\nline two %d", i))
+	}
+	m.renderChat()
+	require.Len(t, m.renderedMessages, 6)
+	assert.Equal(t, []int{0, 2, 5, 7, 10, 12}, m.messageLines)
+
+	// Unchanged messages come from the cache.
+	m.renderedMessages[0].text = "cached\n\n"
+	assert.Contains(t, m.renderChat(), "cached")
+
+	// Changed messages and view state render again.
+	m.renderedMessages[0].text = "stale\n\n"
+	m.messages[5].Content += " and three"
+	m.messages[0].Folded = true
+	out := m.renderChat()
+	assert.NotContains(t, out, "stale")
+	assert.Contains(t, out, "line two 2 and three")
+
+	m.messages[0].Folded = false
+	before := m.renderChat()
+	m.selectMode, m.selectedMessage = true, 2
+	assert.Contains(t, m.renderChat(), "▶ selected")
+	m.selectMode = false
+	assert.Equal(t, before, m.renderChat(), "deselecting restores the view")
+
+	m.messages = m.messages[:2]
+	m.renderChat()
+	assert.Len(t, m.renderedMessages, 2)
+	assert.Equal(t, []int{0, 2}, m.messageLines)
+}
+
 func TestRenderContext_NoProject(t *testing.T) {
 	m := newTestModel(t)
 	m.project = nil
@@ -1037,3 +1215,1465 @@ func TestToolCallAccumulatorReset(t *testing.T) {
 	m.toolCallAccumulator.Reset()
 	assert.False(t, m.toolCallAccumulator.HasCalls())
 }
+
+func TestOfflineMode(t *testing.T) {
+	t.Run("submit shows notice and keeps draft", func(t *testing.T) {
+		m := newTestModel(t)
+		m.SetOffline(true)
+		setTextareaValue(m, "write the next scene")
+
+		m = sendKeyMsg(m, tea.KeyEnter)
+
+		assertStreaming(t, m, false)
+		assertLastMessage(t, m, "system", "Offline mode")
+		assert.Equal(t, "write the next scene", getTextareaValue(m))
+	})
+
+	t.Run("models command shows notice", func(t *testing.T) {
+		m := newTestModel(t)
+		m.SetOffline(true)
+		setTextareaValue(m, "/models")
+
+		m = sendKeyMsg(m, tea.KeyEnter)
+
+		assert.False(t, m.modelSelectMode)
+		assertLastMessage(t, m, "system", "Offline mode")
+	})
+
+	t.Run("local commands still work", func(t *testing.T) {
+		m := newTestModel(t)
+		m.SetOffline(true)
+		setTextareaValue(m, "/context")
+
+		m = sendKeyMsg(m, tea.KeyEnter)
+
+		assertViewState(t, m, ViewContext)
+	})
+
+	t.Run("status line shows offline", func(t *testing.T) {
+		m := newTestModel(t)
+		m.SetOffline(true)
+
+		assert.Contains(t, m.View(), "offline")
+	})
+}
+
+func TestComposerTokenHUD(t *testing.T) {
+	m := newTestModel(t)
+	assert.Empty(t, m.renderComposerTokens())
+
+	m.textarea.SetValue("Write the storm scene")
+	assert.Contains(t, m.renderComposerTokens(), "~6 tokens")
+	assert.Contains(t, m.View(), "~6 tokens")
+
+	m.textarea.SetValue("/help")
+	assert.Empty(t, m.renderComposerTokens(), "commands are not sent to the model")
+
+	m.provider = stubProvider{caps: llm.Capabilities{MaxContextTokens: 1000}}
+	m.textarea.SetValue(strings.Repeat("storm ", 400))
+	assert.Contains(t, m.renderComposerTokens(), "over the")
+
+	m.textarea.SetValue("Write the storm scene")
+	assert.NotContains(t, m.renderComposerTokens(), "over the")
+}
+
+func TestMultilineComposer(t *testing.T) {
+	t.Run("alt+enter adds a line in single-line mode", func(t *testing.T) {
+		m := newTestModel(t)
+		m = sendRunesMsg(m, "first")
+		model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+		m = model.(*Model)
+		m = sendRunesMsg(m, "second")
+
+		assert.Equal(t, "first\nsecond", getTextareaValue(m))
+		assert.Equal(t, 2, m.textarea.Height())
+		assert.Equal(t, testConfig.Height-chromeHeight-1, m.viewport.Height)
+	})
+
+	t.Run("enter adds a line in multi-line mode and ctrl+s sends", func(t *testing.T) {
+		m := newTestModel(t)
+		m, _ = typeAndSubmit(m, "/multiline")
+		require.True(t, m.multiline)
+		assert.Equal(t, multilineComposerHeight, m.textarea.Height())
+
+		m = sendRunesMsg(m, "first")
+		m = sendKeyMsg(m, tea.KeyEnter)
+		m = sendRunesMsg(m, "second")
+		assert.Equal(t, "first\nsecond", getTextareaValue(m))
+		assertMessageCount(t, m, 0)
+
+		m = sendKeyMsg(m, tea.KeyCtrlS)
+		assert.Equal(t, "user", m.messages[0].Role)
+		assert.Equal(t, "first\nsecond", m.messages[0].Content)
+		assert.Empty(t, getTextareaValue(m))
+	})
+
+	t.Run("accepts messages longer than the old limit", func(t *testing.T) {
+		m := newTestModel(t)
+		m.textarea.SetValue(strings.Repeat("a", 10000))
+		assert.Len(t, getTextareaValue(m), 10000)
+	})
+}
+
+func TestExternalEditor(t *testing.T) {
+	t.Run("editor command prefers VISUAL", func(t *testing.T) {
+		t.Setenv("VISUAL", "code --wait")
+		t.Setenv("EDITOR", "nano")
+		assert.Equal(t, []string{"code", "--wait"}, editorCommand())
+
+		t.Setenv("VISUAL", "")
+		assert.Equal(t, []string{"nano"}, editorCommand())
+
+		t.Setenv("EDITOR", "")
+		assert.Equal(t, []string{"vi"}, editorCommand())
+	})
+
+	t.Run("edited text replaces the composer", func(t *testing.T) {
+		m := newTestModel(t)
+		m = sendRunesMsg(m, "draft")
+
+		path := filepath.Join(t.TempDir(), "message.md")
+		require.NoError(t, os.WriteFile(path, []byte("line one\nline two\n"), 0644))
+
+		model, _ := m.Update(editorFinishedMsg{path: path})
+		m = model.(*Model)
+
+		assertNoError(t, m)
+		assert.Equal(t, "line one\nline two", getTextareaValue(m))
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("editor errors keep the draft", func(t *testing.T) {
+		m := newTestModel(t)
+		m = sendRunesMsg(m, "draft")
+
+		model, _ := m.Update(editorFinishedMsg{path: filepath.Join(t.TempDir(), "missing.md"), err: fmt.Errorf("exit status 1")})
+		m = model.(*Model)
+
+		require.Error(t, m.err)
+		assert.Equal(t, "draft", getTextareaValue(m))
+	})
+}
+
+func TestFileWatch(t *testing.T) {
+	t.Run("changes on disk refresh the open view", func(t *testing.T) {
+		proj := createTempProjectWithContext(t)
+		m := newTestModelWithProject(t, proj)
+		m.searchEngine = search.NewFTSEngine(proj.DB)
+		m.view = ViewContext
+		m.handleFileWatchTick()
+		m.updateViewport()
+		assert.NotContains(t, m.viewport.View(), "Mira")
+
+		require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), "context", "characters", "mira.md"), []byte("# Mira\n\nA harbor pilot."), 0644))
+		m.handleFileWatchTick()
+
+		assert.Contains(t, m.viewport.View(), "Mira")
+		results, err := m.searchEngine.Search("pilot", 5)
+		require.NoError(t, err)
+		assert.NotEmpty(t, results, "the new file is indexed")
+	})
+
+	t.Run("reports added, changed and removed files", func(t *testing.T) {
+		now := time.Now()
+		before := map[string]fileStamp{
+			"context/a.md": {modTime: now, size: 10},
+			"context/b.md": {modTime: now, size: 10},
+			"context/c.md": {modTime: now, size: 10},
+		}
+		after := map[string]fileStamp{
+			"context/a.md": {modTime: now, size: 10},
+			"context/b.md": {modTime: now.Add(time.Second), size: 10},
+			"context/d.md": {modTime: now, size: 10},
+		}
+		assert.Equal(t, []string{"context/b.md", "context/c.md", "context/d.md"}, changedFiles(before, after))
+		assert.Empty(t, changedFiles(after, after))
+	})
+
+	t.Run("files open in the editor are not reloaded", func(t *testing.T) {
+		proj := createTempProjectWithContext(t)
+		m := newTestModelWithProject(t, proj)
+		m.view = ViewContext
+		m.handleFileWatchTick()
+		path := filepath.Join("context", "characters", "hana.md")
+		m.openContextEditor("characters", path, "# 하나\n\nmy edit")
+
+		require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), path), []byte("# 하나\n\nchanged elsewhere, longer"), 0644))
+		m.handleFileWatchTick()
+
+		assert.Equal(t, "# 하나\n\nmy edit", m.contextEdit.editor.Value())
+		assert.Contains(t, m.statusText, "changed on disk")
+	})
+}
+
+func TestEditChapter(t *testing.T) {
+	newModel := func(t *testing.T) (*Model, string) {
+		proj := createTempProjectWithContext(t)
+		path := filepath.Join("chapters", "chapter-001.md")
+		require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), path), []byte("# One\n\nThe rain fell."), 0644))
+		m := newTestModelWithProject(t, proj)
+		m.searchEngine = search.NewFTSEngine(proj.DB)
+		return m, path
+	}
+
+	t.Run("unknown chapters are refused", func(t *testing.T) {
+		m, _ := newModel(t)
+		m, _ = typeAndSubmit(m, "/edit-chapter 9")
+		require.Error(t, m.err)
+		assert.Contains(t, m.err.Error(), "chapter 9 not found")
+	})
+
+	t.Run("edits are reindexed and counted", func(t *testing.T) {
+		m, path := newModel(t)
+		require.NoError(t, os.WriteFile(filepath.Join(m.project.Path(), path), []byte("# One\r\n\r\nThe rain fell on the lighthouse.\r\n"), 0644))
+
+		model, _ := m.Update(chapterEditedMsg{number: 1, path: path, original: "# One\n\nThe rain fell.", words: 5})
+		m = model.(*Model)
+
+		assertNoError(t, m)
+		assertLastMessage(t, m, "system", "Chapter 1 updated: the manuscript has 8 words (+3).")
+		results, err := m.searchEngine.Search("lighthouse", 5)
+		require.NoError(t, err)
+		assert.NotEmpty(t, results, "the edited chapter is indexed")
+	})
+
+	t.Run("unchanged chapters are left alone", func(t *testing.T) {
+		m, path := newModel(t)
+		messages := len(m.messages)
+
+		model, _ := m.Update(chapterEditedMsg{number: 1, path: path, original: "# One\n\nThe rain fell.", words: 5})
+		m = model.(*Model)
+
+		assertNoError(t, m)
+		assert.Len(t, m.messages, messages)
+		assert.Equal(t, "Chapter 1 unchanged", m.statusText)
+	})
+}
+
+func TestAttachments(t *testing.T) {
+	t.Run("attached file is sent with the next message", func(t *testing.T) {
+		proj := createTempProjectWithContext(t)
+		m := newTestModelWithProject(t, proj)
+
+		m, _ = typeAndSubmit(m, "/attach context/characters/hana.md")
+		require.NoError(t, m.err)
+		require.Len(t, m.attachments, 1)
+		assert.Equal(t, "context/characters/hana.md", m.attachments[0].Name)
+		assertLastMessage(t, m, "system", "Attached context/characters/hana.md")
+
+		m = sendRunesMsg(m, "Rewrite this")
+		assert.Contains(t, m.renderComposerTokens(), "📎 1")
+
+		m, _ = typeAndSubmit(m, "")
+		assert.Empty(t, m.attachments)
+
+		var sent string
+		for _, msg := range m.messages {
+			if msg.Role == "user" {
+				sent = msg.Content
+			}
+		}
+		assert.Contains(t, sent, "Rewrite this")
+		assert.Contains(t, sent, "[Attached: context/characters/hana.md]\n# 하나")
+		assert.Equal(t, "Rewrite this", stripAttachments(sent))
+
+		chat := m.renderChat()
+		assert.Contains(t, chat, "📎 context/characters/hana.md")
+		assert.NotContains(t, chat, "냉정하지만")
+	})
+
+	t.Run("paths outside the project are rejected", func(t *testing.T) {
+		proj := createTempProjectWithContext(t)
+		m := newTestModelWithProject(t, proj)
+
+		m, _ = typeAndSubmit(m, "/attach ../secret.txt")
+		require.Error(t, m.err)
+		assert.Empty(t, m.attachments)
+	})
+
+	t.Run("paste attaches the clipboard and clear removes it", func(t *testing.T) {
+		orig := readClipboard
+		readClipboard = func() (string, error) { return "The rain fell sideways.", nil }
+		t.Cleanup(func() { readClipboard = orig })
+
+		m := newTestModel(t)
+		m, _ = typeAndSubmit(m, "/paste")
+		require.Len(t, m.attachments, 1)
+		assert.Equal(t, "clipboard", m.attachments[0].Name)
+
+		m, _ = typeAndSubmit(m, "/attach")
+		assertLastMessage(t, m, "system", "📎 clipboard")
+
+		m, _ = typeAndSubmit(m, "/attach clear")
+		assert.Empty(t, m.attachments)
+	})
+}
+
+func TestMessageActions(t *testing.T) {
+	setup := func(t *testing.T) *Model {
+		proj := createTempProjectWithContext(t)
+		m := newTestModelWithProject(t, proj)
+		m.messages = []Message{
+			{Role: "user", Content: "Write the storm"},
+			{Role: "assistant", Content: "The storm broke over the harbor [c4]."},
+			{Role: "system", Content: "Saved."},
+		}
+		return m
+	}
+
+	t.Run("ctrl+up selects the newest chat message", func(t *testing.T) {
+		m := setup(t)
+		m = sendKeyMsg(m, tea.KeyCtrlUp)
+		require.True(t, m.selectMode)
+		assert.Equal(t, 1, m.selectedMessage)
+		assert.Contains(t, m.renderChat(), "▶ selected")
+
+		m = sendRunesMsg(m, "k")
+		assert.Equal(t, 0, m.selectedMessage)
+		m = sendRunesMsg(m, "j")
+		m = sendRunesMsg(m, "j")
+		assert.Equal(t, 1, m.selectedMessage, "system notices are skipped")
+		assert.Empty(t, getTextareaValue(m))
+
+		m = sendKeyMsg(m, tea.KeyEsc)
+		assert.False(t, m.selectMode)
+		assert.True(t, m.inputMode)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		var copied string
+		orig := writeClipboard
+		writeClipboard = func(text string) error { copied = text; return nil }
+		t.Cleanup(func() { writeClipboard = orig })
+
+		m := setup(t)
+		m = sendKeyMsg(m, tea.KeyCtrlUp)
+		m = sendRunesMsg(m, "c")
+		assert.Equal(t, "The storm broke over the harbor.", copied)
+		assert.True(t, m.toast.Visible)
+	})
+
+	t.Run("append to chapter", func(t *testing.T) {
+		m := setup(t)
+		m, _ = typeAndSubmit(m, "/chapter 3")
+		assert.Equal(t, 3, m.activeChapter)
+
+		m = sendKeyMsg(m, tea.KeyCtrlUp)
+		m = sendRunesMsg(m, "a")
+		require.NoError(t, m.err)
+
+		content, err := os.ReadFile(filepath.Join(m.project.Path(), "chapters", "chapter-003.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "The storm broke over the harbor.\n", string(content))
+
+		m = sendRunesMsg(m, "k")
+		m = sendRunesMsg(m, "a")
+		assert.Error(t, m.err, "user messages are not appended")
+	})
+
+	t.Run("save as note", func(t *testing.T) {
+		m := setup(t)
+		m = sendKeyMsg(m, tea.KeyCtrlUp)
+		m = sendRunesMsg(m, "n")
+		require.NoError(t, m.err)
+
+		notes, err := filepath.Glob(filepath.Join(m.project.Path(), "context", "notes", "note-*.md"))
+		require.NoError(t, err)
+		require.Len(t, notes, 1)
+		content, err := os.ReadFile(notes[0])
+		require.NoError(t, err)
+		assert.Equal(t, "The storm broke over the harbor.\n", string(content))
+	})
+
+	t.Run("enter folds and unfolds a reply", func(t *testing.T) {
+		m := setup(t)
+		m.messages[1].Content = "First line of the reply.\nSecond line.\nThird line."
+		m = sendKeyMsg(m, tea.KeyCtrlUp)
+
+		m = sendKeyMsg(m, tea.KeyEnter)
+		require.True(t, m.messages[1].Folded)
+		chat := m.renderChat()
+		assert.Contains(t, chat, "First line of the reply. ▸ (2 more lines folded)")
+		assert.NotContains(t, chat, "Third line.")
+
+		m = sendKeyMsg(m, tea.KeyEnter)
+		assert.False(t, m.messages[1].Folded)
+		assert.Contains(t, m.renderChat(), "Third line.")
+	})
+
+	t.Run("quote inserts the message into the composer", func(t *testing.T) {
+		m := setup(t)
+		m = sendKeyMsg(m, tea.KeyCtrlUp)
+		m = sendRunesMsg(m, "q")
+
+		assert.False(t, m.selectMode)
+		assert.Equal(t, "> The storm broke over the harbor.\n\n", getTextareaValue(m))
+	})
+
+	t.Run("delete removes the message from the working history", func(t *testing.T) {
+		m := setup(t)
+		m = sendKeyMsg(m, tea.KeyCtrlUp)
+		m = sendRunesMsg(m, "d")
+
+		require.Len(t, m.messages, 2)
+		assert.Equal(t, "user", m.messages[0].Role)
+		assert.Equal(t, 0, m.selectedMessage)
+
+		m = sendRunesMsg(m, "d")
+		assert.False(t, m.selectMode, "nothing left to select")
+		assertMessageCount(t, m, 1)
+	})
+}
+
+func TestCrashRecovery(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.messages = []Message{
+		{Role: "user", Content: "Write the storm"},
+		{Role: "assistant", Content: "The storm broke", Interrupted: true},
+	}
+	m = sendRunesMsg(m, "unsent draft")
+
+	// Corrupt the selection so the next key panics.
+	m.selectMode = true
+	m.selectedMessage = 5
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(*Model)
+
+	require.NotNil(t, cmd)
+	assert.IsType(t, tea.QuitMsg{}, cmd())
+	require.NotEmpty(t, m.CrashReport())
+	report, err := os.ReadFile(m.CrashReport())
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "panic: ")
+
+	t.Run("next open offers to restore the session", func(t *testing.T) {
+		reopened := newTestModelWithProject(t, proj)
+		reopened.Init()
+		assertLastMessage(t, reopened, "system", "/restore")
+
+		reopened, _ = typeAndSubmit(reopened, "/restore")
+		require.NoError(t, reopened.err)
+		require.Len(t, reopened.messages, 3)
+		assert.Equal(t, "The storm broke", reopened.messages[1].Content)
+		assert.True(t, reopened.messages[1].Interrupted)
+		assert.Equal(t, "unsent draft", getTextareaValue(reopened))
+
+		setTextareaValue(reopened, "")
+		reopened, _ = typeAndSubmit(reopened, "/restore")
+		assert.Error(t, reopened.err, "the session is restored only once")
+	})
+}
+
+func TestSaveCrashSession(t *testing.T) {
+	// A panic in a command's goroutine never reaches Update, so the caller
+	// saves the session after the program exits.
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.messages = []Message{{Role: "user", Content: "Write the storm"}}
+	m = sendRunesMsg(m, "unsent draft")
+	require.NoError(t, m.SaveCrashSession())
+
+	reopened := newTestModelWithProject(t, proj)
+	reopened.Init()
+	assertLastMessage(t, reopened, "system", "/restore")
+
+	reopened, _ = typeAndSubmit(reopened, "/restore")
+	require.NoError(t, reopened.err)
+	assert.Equal(t, "Write the storm", reopened.messages[0].Content)
+	assert.Equal(t, "unsent draft", getTextareaValue(reopened))
+
+	assert.NoError(t, New(nil, nil, nil, "", "", "").SaveCrashSession(), "nothing is saved without a project")
+}
+
+func TestDraftAutosave(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	draftPath := filepath.Join(proj.Path(), ".dreamteller", draftFile)
+
+	m := newTestModelWithProject(t, proj)
+	m = sendRunesMsg(m, "half-written prompt")
+	model, cmd := m.Update(draftTickMsg{})
+	m = model.(*Model)
+	require.NotNil(t, cmd, "the next autosave is scheduled")
+
+	content, err := os.ReadFile(draftPath)
+	require.NoError(t, err)
+	assert.Equal(t, "half-written prompt", string(content))
+
+	m = sendRunesMsg(m, " more")
+	m = sendKeyMsg(m, tea.KeyCtrlC)
+	content, err = os.ReadFile(draftPath)
+	require.NoError(t, err)
+	assert.Equal(t, "half-written prompt more", string(content), "quitting saves the draft")
+
+	reopened := newTestModelWithProject(t, proj)
+	reopened.Init()
+	assert.Equal(t, "half-written prompt more", getTextareaValue(reopened))
+	assert.True(t, reopened.toast.Visible)
+
+	setTextareaValue(reopened, "")
+	reopened.Update(draftTickMsg{})
+	assert.NoFileExists(t, draftPath, "an empty composer removes the draft")
+}
+
+func TestMemoryCommands(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/remember The lighthouse has no keeper")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "Remembered: The lighthouse has no keeper")
+
+	memories, err := proj.DB.ListMemories()
+	require.NoError(t, err)
+	require.Len(t, memories, 1)
+	assert.Equal(t, "user", memories[0].Source)
+
+	m, _ = typeAndSubmit(m, "/memories")
+	assertLastMessage(t, m, "system", "The lighthouse has no keeper")
+
+	m, _ = typeAndSubmit(m, fmt.Sprintf("/memories delete %d", memories[0].ID))
+	assertNoError(t, m)
+	memories, err = proj.DB.ListMemories()
+	require.NoError(t, err)
+	assert.Empty(t, memories)
+}
+
+func TestGlossaryCommands(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/glossary")
+	assertLastMessage(t, m, "system", "No glossary terms yet")
+
+	require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), "context", "glossary", "terms.md"), []byte("- Aethermoor: The drowned continent.\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), "chapters", "001.md"), []byte("They sailed for Aethermor."), 0644))
+
+	m, _ = typeAndSubmit(m, "/glossary")
+	assertLastMessage(t, m, "system", "- Aethermoor: The drowned continent.")
+
+	m, _ = typeAndSubmit(m, "/glossary check")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", `"Aethermor" → did you mean "Aethermoor"?`)
+}
+
+func TestAcceptMemorySuggestion(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+
+	suggestion, err := m.suggestionHandler.HandleToolCall(mockToolCall(llm.ToolRememberFact, `{"fact":"Jun fears deep water","reason":"confirmed by the author"}`))
+	require.NoError(t, err)
+	assert.True(t, suggestion.RequiresApproval)
+
+	m.pendingSuggestion = suggestion
+	m.view = ViewSuggestion
+	m = sendRunesMsg(m, "a")
+
+	memories, err := proj.DB.ListMemories()
+	require.NoError(t, err)
+	require.Len(t, memories, 1)
+	assert.Equal(t, "Jun fears deep water", memories[0].Content)
+	assert.Equal(t, "model", memories[0].Source)
+}
+
+func TestSprint(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "It began.\n"}))
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/sprint soon")
+	assert.Error(t, m.err)
+	m.err = nil
+
+	m, _ = typeAndSubmit(m, "/sprint 25m lock")
+	assertNoError(t, m)
+	require.NotNil(t, m.sprint)
+	assert.Equal(t, 25*time.Minute, m.sprint.duration)
+	assert.Contains(t, m.render(), "⏱ 2", "the timer shows in the status bar")
+
+	m, _ = typeAndSubmit(m, "Write the next scene")
+	require.Error(t, m.err, "AI requests are locked")
+	assert.False(t, m.streaming)
+	assert.Equal(t, "Write the next scene", getTextareaValue(m), "the prompt is kept")
+	setTextareaValue(m, "")
+	m.err = nil
+
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "It began. The rain came down hard.\n"}))
+	m.sprint.start = time.Now().Add(-26 * time.Minute)
+	model, _ := m.Update(sprintTickMsg{id: m.sprint.id})
+	m = model.(*Model)
+	assert.Nil(t, m.sprint)
+	assertLastMessage(t, m, "system", "+5 words")
+
+	sprints, err := proj.DB.ListSprints(10)
+	require.NoError(t, err)
+	require.Len(t, sprints, 1)
+	assert.Equal(t, 25*time.Minute, sprints[0].Actual)
+	assert.True(t, sprints[0].AILocked)
+
+	m, _ = typeAndSubmit(m, "/sprint")
+	assertLastMessage(t, m, "system", "+5 words  🔒")
+
+	m, _ = typeAndSubmit(m, "/sprint 10")
+	assertNoError(t, m)
+	staleID := m.sprint.id - 1
+	model, cmd := m.Update(sprintTickMsg{id: staleID})
+	m = model.(*Model)
+	assert.Nil(t, cmd, "ticks from an earlier sprint are ignored")
+
+	m, _ = typeAndSubmit(m, "/sprint stop")
+	assert.Nil(t, m.sprint)
+	sprints, err = proj.DB.ListSprints(10)
+	require.NoError(t, err)
+	assert.Len(t, sprints, 2)
+}
+
+func TestPromptHistory(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+
+	for _, command := range []string{"/remember The lighthouse has no keeper", "/glossary", "/memories"} {
+		m, _ = typeAndSubmit(m, command)
+	}
+
+	m = sendRunesMsg(m, "half")
+	m = sendKeyMsg(m, tea.KeyUp)
+	assert.Equal(t, "half", getTextareaValue(m), "Up does not replace text being composed")
+
+	m = sendKeyMsg(m, tea.KeyCtrlP)
+	assert.Equal(t, "/memories", getTextareaValue(m))
+	m = sendKeyMsg(m, tea.KeyUp)
+	assert.Equal(t, "/glossary", getTextareaValue(m))
+	m = sendKeyMsg(m, tea.KeyDown)
+	m = sendKeyMsg(m, tea.KeyDown)
+	assert.Equal(t, "half", getTextareaValue(m), "stepping past the newest prompt restores the draft")
+
+	t.Run("persisted per project", func(t *testing.T) {
+		reopened := newTestModelWithProject(t, proj)
+		reopened.Init()
+		setTextareaValue(reopened, "")
+		reopened = sendKeyMsg(reopened, tea.KeyUp)
+		assert.Equal(t, "/memories", getTextareaValue(reopened))
+	})
+
+	t.Run("palette searches fuzzily", func(t *testing.T) {
+		setTextareaValue(m, "")
+		m = sendKeyMsg(m, tea.KeyCtrlR)
+		require.True(t, m.historyPaletteMode)
+		assert.Contains(t, m.viewport.View(), "/glossary")
+
+		m = sendRunesMsg(m, "lhk")
+		matches := m.historyMatches()
+		require.Len(t, matches, 1)
+		assert.Equal(t, "/remember The lighthouse has no keeper", matches[0].prompt)
+
+		m = sendKeyMsg(m, tea.KeyEnter)
+		assert.False(t, m.historyPaletteMode)
+		assert.Equal(t, "/remember The lighthouse has no keeper", getTextareaValue(m))
+	})
+}
+
+func TestFuzzyScore(t *testing.T) {
+	_, ok := fuzzyScore("rain", "The rain came")
+	assert.True(t, ok)
+	_, ok = fuzzyScore("nair", "The rain came")
+	assert.False(t, ok)
+
+	tight, _ := fuzzyScore("rain", "The rain came")
+	loose, _ := fuzzyScore("rain", "red apples in nets")
+	assert.Greater(t, tight, loose)
+}
+
+func TestContextEditor(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.searchEngine = search.NewFTSEngine(proj.DB)
+
+	m, _ = typeAndSubmit(m, "/context")
+	require.Equal(t, ViewContext, m.view)
+	assert.Contains(t, m.renderContext(), "> 하나", "the first file is selected")
+
+	t.Run("edits and reindexes a file", func(t *testing.T) {
+		m = sendKeyMsg(m, tea.KeyEnter)
+		require.NotNil(t, m.contextEdit)
+		assert.Contains(t, m.View(), "Editing context/characters/hana.md")
+
+		m.contextEdit.editor.SetValue("# 하나\n\n- 주인공\n- 등대지기의 딸\n")
+		m = sendKeyMsg(m, tea.KeyCtrlS)
+		require.NoError(t, m.err)
+		assert.Nil(t, m.contextEdit)
+
+		content, err := os.ReadFile(filepath.Join(proj.Path(), "context", "characters", "hana.md"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "등대지기의 딸")
+
+		results, err := m.searchEngine.Search("등대지기의", 5)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, filepath.Join("context", "characters", "hana.md"), results[0].SourcePath)
+	})
+
+	t.Run("rejects invalid frontmatter", func(t *testing.T) {
+		m = sendKeyMsg(m, tea.KeyEnter)
+		m.contextEdit.editor.SetValue("---\nrole: [lead\n---\n# 하나\n")
+		m = sendKeyMsg(m, tea.KeyCtrlS)
+		require.Error(t, m.err)
+		require.NotNil(t, m.contextEdit, "the editor stays open")
+		m.err = nil
+
+		m = sendKeyMsg(m, tea.KeyEsc)
+		require.NotNil(t, m.contextEdit, "the first Esc warns about unsaved changes")
+		m = sendKeyMsg(m, tea.KeyEsc)
+		assert.Nil(t, m.contextEdit)
+	})
+
+	t.Run("creates a file named after its title", func(t *testing.T) {
+		m, _ = typeAndSubmit(m, "/context new settings")
+		require.NotNil(t, m.contextEdit)
+		m.contextEdit.editor.SetValue("# Lighthouse\n\nA tower on the cliffs.\n")
+		m = sendKeyMsg(m, tea.KeyCtrlS)
+		require.NoError(t, m.err)
+		assert.FileExists(t, filepath.Join(proj.Path(), "context", "settings", "lighthouse.md"))
+	})
+
+	t.Run("deletes a file after confirmation", func(t *testing.T) {
+		entries := m.contextEntries()
+		for i, e := range entries {
+			if e.name == "Lighthouse" {
+				m.contextIndex = i
+			}
+		}
+		m = sendRunesMsg(m, "d")
+		m = sendRunesMsg(m, "n")
+		assert.FileExists(t, filepath.Join(proj.Path(), "context", "settings", "lighthouse.md"))
+
+		m = sendRunesMsg(m, "d")
+		m = sendRunesMsg(m, "y")
+		assert.NoFileExists(t, filepath.Join(proj.Path(), "context", "settings", "lighthouse.md"))
+		results, err := m.searchEngine.Search("cliffs", 5)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+// sendFormMsg sends msg to the model and feeds back the messages its
+// commands produce right away; slower commands such as cursor blinks are
+// dropped.
+func sendFormMsg(m *Model, msg tea.Msg) *Model {
+	queue := []tea.Msg{msg}
+	for len(queue) > 0 && len(queue) < 100 {
+		next := queue[0]
+		queue = queue[1:]
+		if batch, ok := next.(tea.BatchMsg); ok {
+			for _, cmd := range batch {
+				if result := runQuickCmd(cmd); result != nil {
+					queue = append(queue, result)
+				}
+			}
+			continue
+		}
+		model, cmd := m.Update(next)
+		m = model.(*Model)
+		if result := runQuickCmd(cmd); result != nil {
+			queue = append(queue, result)
+		}
+	}
+	return m
+}
+
+// runQuickCmd runs cmd and returns its message if it arrives within 20ms.
+func runQuickCmd(cmd tea.Cmd) tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	result := make(chan tea.Msg, 1)
+	go func() { result <- cmd() }()
+	select {
+	case msg := <-result:
+		return msg
+	case <-time.After(20 * time.Millisecond):
+		return nil
+	}
+}
+
+func TestContextLinkNavigation(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, os.WriteFile(filepath.Join(proj.Path(), "context", "characters", "hana.md"), []byte(
+		"# 하나\n\n[[서울]]에서 자랐다.\n",
+	), 0644))
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/context")
+	require.Equal(t, ViewContext, m.view)
+	assert.Contains(t, m.renderContext(), "[1] 서울")
+
+	m = sendRunesMsg(m, "1")
+	entry, ok := m.selectedContextEntry()
+	require.True(t, ok)
+	assert.Equal(t, "서울", entry.name, "the link jumps to the linked file")
+	assert.Contains(t, m.renderContext(), "[1] 하나", "the target lists its backlink")
+
+	m = sendRunesMsg(m, "2")
+	entry, _ = m.selectedContextEntry()
+	assert.Equal(t, "서울", entry.name)
+	assert.Contains(t, m.statusText, "no link 2")
+}
+
+func TestBibleReportCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1장\n\n하나가 걷는다."}))
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/report")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "context/settings/seoul.md")
+
+	m, _ = typeAndSubmit(m, "/report soon")
+	require.Error(t, m.err)
+}
+
+func TestNewCharacterForm(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.searchEngine = search.NewFTSEngine(proj.DB)
+
+	typeField := func(text string) {
+		for _, r := range text {
+			m = sendFormMsg(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		m = sendFormMsg(m, tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	setTextareaValue(m, "/newchar")
+	m = sendFormMsg(m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, m.charForm)
+	assert.Contains(t, m.View(), "Voice")
+
+	typeField("하나")
+	assert.Contains(t, m.View(), "already exists", "names already in use are rejected")
+	for range "하나" {
+		m = sendFormMsg(m, tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+
+	typeField("Captain Mora")
+	typeField("smuggler")
+	typeField("41")
+	typeField("Pay off the ship")
+	typeField("Trusts no one")
+	typeField("Clipped nautical slang")
+
+	require.Nil(t, m.charForm)
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "context/characters/captain-mora.md")
+
+	character, err := proj.FindCharacter("Captain Mora")
+	require.NoError(t, err)
+	assert.Contains(t, character.Description, "**Age:** 41")
+	assert.Equal(t, "Clipped nautical slang", character.Voice)
+
+	results, err := m.searchEngine.Search("smuggler", 5)
+	require.NoError(t, err)
+	assert.Len(t, results, 1, "the new character is indexed")
+
+	t.Run("Esc cancels", func(t *testing.T) {
+		setTextareaValue(m, "/newchar")
+		m = sendFormMsg(m, tea.KeyMsg{Type: tea.KeyEnter})
+		require.NotNil(t, m.charForm)
+		m = sendFormMsg(m, tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Nil(t, m.charForm)
+		assert.True(t, m.inputMode)
+	})
+}
+
+func TestWordFrequency(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1장\n\n하나는 그냥 웃었다. 그냥 걸었다. 갑자기 비가 왔다."}))
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/words")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "| 그냥")
+	assertLastMessage(t, m, "system", "writing.avoid_crutch_words")
+
+	assert.Empty(t, m.avoidWordsInstruction(), "editing prompts are unchanged by default")
+	proj.Config.Writing.AvoidCrutchWords = true
+	assert.Contains(t, m.avoidWordsInstruction(), "avoid them: 그냥, 갑자기.")
+}
+
+func TestPacingCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1장\n\n배가 불탔다.\n\n***\n\n하나는 폭풍을 떠올렸다."}))
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/pacing")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "Pacing across 1 chapters")
+	assertLastMessage(t, m, "system", "batch --op pacing")
+
+	m, _ = typeAndSubmit(m, "/pacing svg")
+	assertNoError(t, m)
+	assert.Equal(t, "Exported the pacing chart to exports/pacing.svg", m.statusText)
+	assert.FileExists(t, filepath.Join(proj.Path(), "exports", "pacing.svg"))
+}
+
+func TestProjectsCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	other := t.TempDir()
+	projects := []*types.Project{
+		{Name: "current", Path: proj.Path(), Genre: "fantasy"},
+		{Name: "Lighthouse", Path: other, Genre: "mystery"},
+	}
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/projects")
+	assert.Error(t, m.err)
+
+	m = newTestModelWithProject(t, proj)
+	m.SetProjectSwitching(func() ([]*types.Project, error) { return projects, nil })
+
+	m, _ = typeAndSubmit(m, "/projects")
+	assertNoError(t, m)
+	require.True(t, m.projectSelectMode)
+	assert.Equal(t, 0, m.projectSelectIndex)
+	assert.Contains(t, m.renderProjectSelect(), "current (fantasy) (current)")
+
+	m = sendKeyMsg(m, tea.KeyEsc)
+	assert.False(t, m.projectSelectMode)
+	assert.Empty(t, m.NextProject())
+
+	m, _ = typeAndSubmit(m, "/projects current")
+	assert.Equal(t, "Already in current", m.statusText)
+	assert.Empty(t, m.NextProject())
+
+	m, _ = typeAndSubmit(m, "/projects nope")
+	assert.EqualError(t, m.err, `project "nope" not found`)
+
+	m, _ = typeAndSubmit(m, "/projects")
+	m = sendKeyMsg(m, tea.KeyDown)
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(*Model)
+	assert.Equal(t, other, m.NextProject())
+	require.NotNil(t, cmd)
+	assert.IsType(t, tea.QuitMsg{}, cmd())
+
+	m = newTestModelWithProject(t, proj)
+	m.SetProjectSwitching(func() ([]*types.Project, error) { return projects, nil })
+	m, cmd = typeAndSubmit(m, "/projects lighthouse")
+	assert.Equal(t, other, m.NextProject())
+	require.NotNil(t, cmd)
+	assert.IsType(t, tea.QuitMsg{}, cmd())
+}
+
+func TestChatTabs(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.DB.SaveConversationMessage(storage.MainThread, "user", "Draft chapter 3"))
+	require.NoError(t, proj.DB.SaveConversationMessage("worldbuilding", "user", "How does the tide magic work?"))
+	m := newTestModelWithProject(t, proj)
+	m.loadTabs()
+	m.loadHistory()
+
+	require.Len(t, m.tabs, 2)
+	assert.Contains(t, m.renderTabBar(), "[1 main]")
+	assertLastMessage(t, m, "user", "Draft chapter 3")
+
+	// Alt+2 shows the second tab's own history.
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2"), Alt: true})
+	m = model.(*Model)
+	assertNoError(t, m)
+	assert.Equal(t, "worldbuilding", m.thread)
+	require.Len(t, m.messages, 1)
+	assertLastMessage(t, m, "user", "How does the tide magic work?")
+
+	// Messages are saved to the tab's thread.
+	m.saveMessage("assistant", "It follows the moon.")
+	world, err := proj.DB.GetConversationHistory("worldbuilding", 10)
+	require.NoError(t, err)
+	assert.Len(t, world, 2)
+
+	m, _ = typeAndSubmit(m, "/tab new Names")
+	assertNoError(t, m)
+	assert.Equal(t, "Names", m.thread)
+	assert.Empty(t, m.messages)
+
+	m, _ = typeAndSubmit(m, "/tab new names")
+	assert.EqualError(t, m.err, `tab "names" is already open`)
+	m.err = nil
+
+	m, _ = typeAndSubmit(m, "/tab 1")
+	assertNoError(t, m)
+	assert.Equal(t, storage.MainThread, m.thread)
+	require.Len(t, m.messages, 1)
+	assertLastMessage(t, m, "user", "Draft chapter 3")
+
+	m, _ = typeAndSubmit(m, "/tab")
+	assertLastMessage(t, m, "system", "1. main (current)")
+	assertLastMessage(t, m, "system", "3. Names")
+
+	m, _ = typeAndSubmit(m, "/tab 7")
+	assert.EqualError(t, m.err, `no tab "7" (see /tab)`)
+
+	m.streaming = true
+	assert.Error(t, m.switchTab(1))
+}
+
+func TestLibrarianPersona(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.DB.SaveConversationMessage("research", "user", "When did Mara learn the truth?"))
+	m := newTestModelWithProject(t, proj)
+	m.loadTabs()
+
+	m, _ = typeAndSubmit(m, "/tab research")
+	m, _ = typeAndSubmit(m, "/librarian")
+	assertNoError(t, m)
+	assert.True(t, m.librarian)
+	assertLastMessage(t, m, "system", "The research librarian answers in this tab")
+	assert.Contains(t, m.renderTabBar(), "2 research 📚")
+
+	// The persona belongs to the tab.
+	m, _ = typeAndSubmit(m, "/tab main")
+	assert.False(t, m.librarian)
+	m, _ = typeAndSubmit(m, "/tab research")
+	assert.True(t, m.librarian)
+
+	// and is saved with it.
+	reopened := newTestModelWithProject(t, proj)
+	reopened.loadTabs()
+	i, ok := reopened.findTab("research")
+	require.True(t, ok)
+	assert.True(t, reopened.tabs[i].librarian)
+	assert.False(t, reopened.librarian)
+
+	model, _ := m.refuseLibrarianTool(llm.ToolCall{Function: llm.FunctionCall{Name: llm.ToolUpdateChapter}})
+	m = model.(*Model)
+	assertLastMessage(t, m, "system", "ignored the model's update_chapter request")
+
+	m, _ = typeAndSubmit(m, "/librarian off")
+	assertNoError(t, m)
+	assert.False(t, m.librarian)
+	personas, err := proj.DB.ThreadPersonas()
+	require.NoError(t, err)
+	assert.Empty(t, personas)
+
+	m, _ = typeAndSubmit(m, "/librarian maybe")
+	assert.EqualError(t, m.err, "usage: /librarian [on|off]")
+}
+
+func TestQuotaGuardrails(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	proj.Config.Quota = types.QuotaConfig{MonthlyTokens: 1000}
+	require.NoError(t, proj.RecordTokenUsage("gpt-4o", 700, 150, time.Now()))
+	m := newTestModelWithProject(t, proj)
+
+	// Warned once at 80%.
+	assert.NotNil(t, m.refreshQuota())
+	assert.True(t, m.toast.Visible)
+	assert.Contains(t, m.toast.Message, "85% of this month's AI budget used")
+	assert.Contains(t, m.quotaStatus(), "💰 85%")
+	assert.Nil(t, m.refreshQuota())
+
+	// Past 100%, a message waits to be sent again.
+	require.NoError(t, proj.RecordTokenUsage("gpt-4o", 200, 0, time.Now()))
+	m, _ = typeAndSubmit(m, "Write the storm")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "spent its AI budget")
+	assert.Equal(t, "Write the storm", getTextareaValue(m))
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(*Model)
+	assert.True(t, m.quotaConfirmed)
+	assert.Equal(t, "user", m.messages[len(m.messages)-2].Role)
+	assert.Equal(t, "Write the storm", m.messages[len(m.messages)-2].Content)
+
+	m.openStats()
+	stats := m.renderStats()
+	assert.Contains(t, stats, "AI Usage")
+	assert.Contains(t, stats, "1.1k/1k tokens (105%)")
+	assert.Contains(t, stats, "over budget")
+	assert.Contains(t, stats, "gpt-4o")
+
+	// A session that starts over budget still clears its warning.
+	fresh := newTestModelWithProject(t, proj)
+	fresh.quotaWarned = false
+	fresh, cmd := typeAndSubmit(fresh, "Write the storm")
+	assertLastMessage(t, fresh, "system", "spent its AI budget")
+	assert.True(t, fresh.toast.Visible)
+	assert.NotNil(t, cmd, "the warning toast is cleared by its tick")
+}
+
+func TestLibrarianRequest(t *testing.T) {
+	req := llm.ChatRequest{
+		Messages:    []llm.ChatMessage{llm.NewSystemMessage("## Story Context\n\n" + llm.DefaultNovelWritingPrompt())},
+		Tools:       llm.ChatTools(),
+		Temperature: 0.7,
+	}
+	librarianRequest(&req)
+	assert.Contains(t, req.Messages[0].Content, "## Story Context")
+	assert.Contains(t, req.Messages[0].Content, "research librarian")
+	assert.NotContains(t, req.Messages[0].Content, llm.DefaultNovelWritingPrompt())
+	assert.Equal(t, llm.SearchTools(), req.Tools)
+	assert.Equal(t, librarianTemperature, req.Temperature)
+}
+
+func TestBeatsCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	proj.Config.Writing.TargetWords = 100
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: strings.Repeat("단어 ", 12)}))
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/beats")
+	assertLastMessage(t, m, "system", "/beats apply <save-the-cat|heros-journey|romance>")
+
+	m, _ = typeAndSubmit(m, "/beats apply romance")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "Added 10 Romance Arc beats")
+
+	m, _ = typeAndSubmit(m, "/beats done setup")
+	assertNoError(t, m)
+	assert.Equal(t, "Beat done: Setup", m.statusText)
+	assertLastMessage(t, m, "system", "Beats: 1/10 done • 12/100 words (12%)")
+	assertLastMessage(t, m, "system", "✓   0%  Setup")
+	assertLastMessage(t, m, "system", "⚠  10%  Meet Cute (due by 10 words)")
+
+	m, _ = typeAndSubmit(m, "/beats apply sonnet")
+	require.Error(t, m.err)
+}
+
+func TestWritingEvent(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: strings.Repeat("단어 ", 40)}))
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/stats")
+	assertNoError(t, m)
+	require.Equal(t, ViewStats, m.view)
+	assert.Contains(t, m.renderStats(), "Manuscript: 40 words, 1 chapters")
+	assert.Contains(t, m.renderStats(), "No writing event")
+
+	m, _ = typeAndSubmit(m, "/event start 1k 10 NaNoWriMo")
+	assertNoError(t, m)
+	require.NotNil(t, proj.Config.Event)
+	assert.Equal(t, 1000, proj.Config.Event.TargetWords)
+	assert.Equal(t, 40, proj.Config.Event.StartWords)
+
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: strings.Repeat("단어 ", 190)}))
+	m, _ = typeAndSubmit(m, "/event")
+	stats := m.renderStats()
+	assert.Contains(t, stats, "NaNoWriMo")
+	assert.Contains(t, stats, "day 1 of 10")
+	assert.Contains(t, stats, "150/1000 words (15%)")
+	assert.Contains(t, stats, "needed 85/day", "850 words over the 10 days left")
+	assert.Contains(t, stats, "50 words ahead of pace")
+	assert.Contains(t, stats, "Mo Tu We Th Fr Sa Su")
+	assert.Contains(t, stats, calendarCells[4], "today's 150 words are past 150% of the 100-word pace")
+
+	m, _ = typeAndSubmit(m, "/event stop")
+	assertNoError(t, m)
+	assert.Nil(t, proj.Config.Event)
+	assert.Contains(t, m.renderStats(), "No writing event")
+
+	m, _ = typeAndSubmit(m, "/event start lots 30")
+	require.Error(t, m.err)
+}
+
+func TestParseWordTarget(t *testing.T) {
+	for input, want := range map[string]int{"50000": 50000, "50k": 50000, "1.5K": 1500, "50,000": 50000} {
+		got, err := parseWordTarget(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	_, err := parseWordTarget("-5")
+	assert.Error(t, err)
+}
+
+func TestSeriesCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.searchEngine = search.NewFTSEngine(proj.DB)
+
+	m, _ = typeAndSubmit(m, "/series")
+	require.Error(t, m.err)
+	m.err = nil
+
+	// A series bible made by an earlier book.
+	shared := filepath.Join(project.SeriesDir(filepath.Dir(proj.Path()), "saga"), "characters")
+	require.NoError(t, os.MkdirAll(shared, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(shared, "hana.md"), []byte("# 하나\n\n등대지기."), 0644))
+
+	m, _ = typeAndSubmit(m, "/series join saga")
+	assertNoError(t, m)
+	assert.Equal(t, "saga", proj.Config.Series)
+	assertLastMessage(t, m, "system", "Series saga: 1 book(s)")
+
+	m, _ = typeAndSubmit(m, "/series search 등대지기")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "[saga] series/characters/hana.md")
+
+	m, _ = typeAndSubmit(m, "/series check")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "하나 is also in the series bible")
+
+	m, _ = typeAndSubmit(m, "/series leave")
+	assertNoError(t, m)
+	assert.Equal(t, "Left series saga", m.statusText)
+	assert.Empty(t, proj.Config.Series)
+	results, err := m.searchEngine.Search("등대지기", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestArcsCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: i, Content: fmt.Sprintf("# %d\n\n하나가 걷는다.", i)}))
+	}
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/arcs")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "하나 (context/characters/hana.md)\n  |●●●|")
+
+	m, _ = typeAndSubmit(m, "/arcs set 하나 2 슬픔 goal: 복수")
+	assertNoError(t, m)
+	assert.Equal(t, "Arc updated: 하나, chapter 2", m.statusText)
+	assertLastMessage(t, m, "system", "  |●◆●|\n  Chapter 2: 슬픔 — goal: 복수")
+
+	m, _ = typeAndSubmit(m, "/arcs set 하나 슬픔")
+	require.Error(t, m.err)
+	m.err = nil
+
+	m.provider = adapters.NewReplayProvider([]adapters.ReplayEntry{
+		{Response: adapters.ReplayResponse{Content: "```json\n{\"characters\": [{\"name\": \"하나\", \"state\": \"분노\", \"goal\": \"진실\"}, {\"name\": \"유령\", \"state\": \"?\"}]}\n```"}},
+	})
+	m.textarea.SetValue("/arcs extract 3")
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(*Model)
+	require.NotNil(t, cmd)
+	model, _ = m.Update(cmd())
+	m = model.(*Model)
+	assertNoError(t, m)
+	assert.Equal(t, "Recorded 1 arc state(s) from chapter 3", m.statusText)
+	assertLastMessage(t, m, "system", "  |●◆◆|")
+	assertLastMessage(t, m, "system", "Chapter 3: 분노 — goal: 진실")
+}
+
+func TestSubplotsCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, proj.SaveChapter(&types.Chapter{Number: i, Content: fmt.Sprintf("# %d\n\n본문.", i)}))
+	}
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/subplots")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "No subplots tagged yet")
+
+	m, _ = typeAndSubmit(m, "/subplots tag romance 1")
+	assertNoError(t, m)
+	m, _ = typeAndSubmit(m, "/subplots tag heist")
+	assertNoError(t, m)
+	assert.Equal(t, "Chapter 2 tagged with heist", m.statusText)
+	assertLastMessage(t, m, "system", "  |█·|  50%  romance (1 chapters, 1 scenes)")
+	assertLastMessage(t, m, "system", "  |·█|  50%  heist (1 chapters, 1 scenes)")
+
+	m, _ = typeAndSubmit(m, "/subplots untag heist 1")
+	require.Error(t, m.err)
+}
+
+func TestSandboxCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.messages = []Message{{Role: "user", Content: "main question"}}
+
+	m, _ = typeAndSubmit(m, "/sandbox")
+	assertNoError(t, m)
+	require.NotNil(t, m.sandbox)
+	assert.Len(t, m.messages, 1)
+	assertLastMessage(t, m, "system", "What-if sandbox")
+
+	// Sandbox turns stay out of the conversation history.
+	m.messages = append(m.messages, Message{Role: "user", Content: "what if Jun lied?"}, Message{Role: "assistant", Content: "Jun hides the letter."})
+	m.saveMessage("user", "what if Jun lied?")
+	m.saveReply("Jun hides the letter.", false)
+	history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	m, _ = typeAndSubmit(m, "/sandbox promote")
+	assertNoError(t, m)
+	require.Len(t, m.sandbox.promoted, 1)
+
+	m, _ = typeAndSubmit(m, "/sandbox end")
+	assertNoError(t, m)
+	assert.Nil(t, m.sandbox)
+	assert.Equal(t, "main question", m.messages[0].Content)
+	assertLastMessage(t, m, "assistant", "Jun hides the letter.")
+	history, err = proj.DB.GetConversationHistory(storage.MainThread, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "Jun hides the letter.", history[0].Content)
+
+	m, _ = typeAndSubmit(m, "/sandbox end")
+	assert.Error(t, m.err)
+}
+
+func TestSandboxRequest(t *testing.T) {
+	req := llm.ChatRequest{
+		Messages: []llm.ChatMessage{llm.NewSystemMessage("You are a writer.")},
+		Tools:    llm.ChatTools(),
+	}
+	sandboxRequest(&req)
+	assert.Contains(t, req.Messages[0].Content, "what-if sandbox")
+	for _, tool := range req.Tools {
+		assert.False(t, llm.WritesProject(tool.Function.Name), tool.Function.Name)
+	}
+}
+
+func TestInterviewCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1\n\n하나가 등대에 오른다."}))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "# 2\n\n서울의 밤."}))
+	m := newTestModelWithProject(t, proj)
+	m.messages = []Message{{Role: "user", Content: "main question"}}
+
+	m, _ = typeAndSubmit(m, "/interview 두리")
+	require.Error(t, m.err)
+	m.err = nil
+
+	m, _ = typeAndSubmit(m, "/interview 하나")
+	assertNoError(t, m)
+	require.NotNil(t, m.interview)
+	assertLastMessage(t, m, "system", "Interviewing 하나 (1 recent scene(s))")
+	assert.Contains(t, m.interview.prompt, "You are 하나")
+	assert.Contains(t, m.interview.prompt, "등대에 오른다")
+	assert.NotContains(t, m.interview.prompt, "서울의 밤")
+
+	req := llm.ChatRequest{Messages: []llm.ChatMessage{llm.NewSystemMessage("You are a writer.")}, Tools: llm.ChatTools()}
+	interviewRequest(&req, m.interview.prompt)
+	assert.Equal(t, m.interview.prompt, req.Messages[0].Content)
+	assert.Nil(t, req.Tools)
+
+	m, _ = typeAndSubmit(m, "/interview save")
+	require.Error(t, m.err)
+	m.err = nil
+
+	m.messages = append(m.messages, Message{Role: "user", Content: "What do you fear?"}, Message{Role: "assistant", Content: "The dark sea."})
+	m.saveMessage("user", "What do you fear?")
+	m, _ = typeAndSubmit(m, "/interview save")
+	assertNoError(t, m)
+	notes, err := proj.FS.ListMarkdownFiles("context/notes")
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	transcript, err := proj.FS.ReadMarkdown(notes[0].Path)
+	require.NoError(t, err)
+	assert.Contains(t, transcript, "# Interview with 하나")
+	assert.Contains(t, transcript, "**Q:** What do you fear?")
+	assert.Contains(t, transcript, "**하나:** The dark sea.")
+
+	m, _ = typeAndSubmit(m, "/interview end")
+	assertNoError(t, m)
+	assert.Nil(t, m.interview)
+	require.Len(t, m.messages, 1)
+	assert.Equal(t, "main question", m.messages[0].Content)
+	history, err := proj.DB.GetConversationHistory(storage.MainThread, 10)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestPitchCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	m := newTestModelWithProject(t, proj)
+	m.provider = adapters.NewReplayProvider([]adapters.ReplayEntry{{Response: adapters.ReplayResponse{Content: "A lighthouse keeper..."}}})
+
+	m, _ = typeAndSubmit(m, "/pitch")
+	require.Error(t, m.err)
+	m.err = nil
+
+	proj.Config.Genre = "판타지 로맨스"
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1\n\n하나가 등대에 오른다."}))
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 2, Content: "# 2\n\n서울의 밤."}))
+	require.NoError(t, proj.FS.WriteMarkdown("batch/summarize/chapter-002.md", "하나는 서울로 떠난다.\n"))
+
+	m, _ = typeAndSubmit(m, "/pitch query keep it under 400 words")
+	assertNoError(t, m)
+	assert.True(t, m.streaming)
+	last := m.messages[len(m.messages)-1]
+	assert.Equal(t, "user", last.Role)
+	assert.Contains(t, last.Content, "query letter")
+	assert.Contains(t, last.Content, "Fantasy pitches")
+	assert.Contains(t, last.Content, "Romance pitches")
+	assert.Contains(t, last.Content, "Also: keep it under 400 words")
+	assert.Contains(t, last.Content, "### Chapter 1 (opening)\n\n# 1\n\n하나가 등대에 오른다.")
+	assert.Contains(t, last.Content, "### Chapter 2 (summary)\n\n하나는 서울로 떠난다.")
+}
+
+func TestContinuityCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1\n\n하나는 스물일곱 살이다."}))
+	require.NoError(t, proj.WriteContextContent("characters", "hana", "# 하나\n\n- 나이: 스물일곱\n", "update"))
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/continuity")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "No continuity snapshots yet")
+
+	m, _ = typeAndSubmit(m, "/continuity compare")
+	require.Error(t, m.err)
+	m.err = nil
+
+	m, _ = typeAndSubmit(m, "/continuity snapshot draft1")
+	assertNoError(t, m)
+	assert.Contains(t, m.statusText, "Continuity snapshot draft1 saved: 1 fact(s)")
+
+	require.NoError(t, proj.WriteContextContent("characters", "hana", "# 하나\n\n- 나이: 스물아홉\n", "update"))
+	m, _ = typeAndSubmit(m, "/continuity compare")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "- 하나 / 나이: 스물일곱 → 스물아홉")
+	assertLastMessage(t, m, "system", "- Chapter 1: \"스물일곱\"")
+
+	m, _ = typeAndSubmit(m, "/continuity")
+	assertLastMessage(t, m, "system", "draft1 (1 facts, 0 timeline events)")
+}
+
+func TestReviewCommand(t *testing.T) {
+	proj := createTempProjectWithContext(t)
+	require.NoError(t, proj.SaveChapter(&types.Chapter{Number: 1, Content: "# 1\n\n하나가 등대에 오른다."}))
+	proj.Config.Reviewers = []types.ReviewerConfig{{Name: "historian", Description: "period accuracy", Prompt: "You check historical accuracy."}}
+	m := newTestModelWithProject(t, proj)
+
+	m, _ = typeAndSubmit(m, "/review")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "copy-editor — grammar")
+	assertLastMessage(t, m, "system", "historian — period accuracy")
+
+	m, _ = typeAndSubmit(m, "/review critic")
+	require.Error(t, m.err)
+	m.err = nil
+
+	review := "```json\n{\"summary\": \"Clean prose.\", \"notes\": [{\"quote\": \"등대에 오른다\", \"category\": \"grammar\", \"severity\": \"Minor\", \"comment\": \"Tense shift\", \"suggestion\": \"올랐다\"}, {\"comment\": \"\"}]}\n```"
+	for i := 0; i < 2; i++ {
+		m.provider = adapters.NewReplayProvider([]adapters.ReplayEntry{{Response: adapters.ReplayResponse{Content: review}}})
+		m.textarea.SetValue("/review copy-editor 1")
+		model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = model.(*Model)
+		require.NotNil(t, cmd)
+		model, _ = m.Update(cmd())
+		m = model.(*Model)
+		assertNoError(t, m)
+	}
+	assert.Equal(t, "copy-editor left 1 note(s) on chapter 1", m.statusText)
+	assertLastMessage(t, m, "system", "Chapter 1 — copy-editor:\nClean prose.\n- [minor grammar] \"등대에 오른다\": Tense shift → 올랐다")
+
+	// Reviewing again replaces the persona's earlier feedback.
+	annotations, err := proj.DB.ListAnnotations(1)
+	require.NoError(t, err)
+	assert.Len(t, annotations, 2)
+
+	m, _ = typeAndSubmit(m, "/review notes")
+	assertNoError(t, m)
+	assertLastMessage(t, m, "system", "Chapter 1 — copy-editor:")
+
+	m, _ = typeAndSubmit(m, "/review export 1 csv")
+	assertNoError(t, m)
+	assert.Equal(t, "Exported 2 feedback item(s) to exports/feedback-chapter-001.csv", m.statusText)
+	assert.FileExists(t, filepath.Join(proj.Path(), "exports", "feedback-chapter-001.csv"))
+}
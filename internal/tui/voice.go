@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/project"
+	"github.com/azyu/dreamteller/pkg/types"
+)
+
+// maxVoiceTokens caps how much of the system prompt voice profiles may use.
+const maxVoiceTokens = 600
+
+// formatVoiceProfile renders a character's voice notes and sample lines.
+func formatVoiceProfile(c *types.Character) string {
+	var sb strings.Builder
+	sb.WriteString("### " + c.Name)
+	if c.Voice != "" {
+		sb.WriteString("\nVoice: " + c.Voice)
+	}
+	if len(c.SampleLines) > 0 {
+		sb.WriteString("\nSample lines:")
+		for _, line := range c.SampleLines {
+			sb.WriteString("\n- " + line)
+		}
+	}
+	return sb.String()
+}
+
+// mentionedCharacters returns the characters with voice profiles whose name
+// or file name appears in text.
+func mentionedCharacters(characters []*types.Character, text string) []*types.Character {
+	lower := strings.ToLower(text)
+
+	var mentioned []*types.Character
+	for _, c := range characters {
+		if !c.HasVoice() {
+			continue
+		}
+		base := strings.TrimSuffix(filepath.Base(c.FilePath), ".md")
+		if strings.Contains(lower, strings.ToLower(c.Name)) || (base != "" && strings.Contains(lower, strings.ToLower(base))) {
+			mentioned = append(mentioned, c)
+		}
+	}
+	return mentioned
+}
+
+// buildVoiceSection renders the voice profiles of characters mentioned in
+// userInput for the system prompt, in file order, within maxTokens.
+func buildVoiceSection(proj *project.Project, userInput string, tokenizer llm.TokenCounter, maxTokens int) string {
+	if proj == nil || userInput == "" {
+		return ""
+	}
+
+	characters, err := proj.LoadCharacters()
+	if err != nil {
+		return ""
+	}
+
+	header := "## Character Voices (keep their dialogue consistent)"
+	used := tokenizer.Count(header)
+	var profiles []string
+	for _, c := range mentionedCharacters(characters, userInput) {
+		profile := formatVoiceProfile(c)
+		t := tokenizer.Count(profile)
+		if used+t > maxTokens {
+			continue
+		}
+		profiles = append(profiles, profile)
+		used += t
+	}
+
+	if len(profiles) == 0 {
+		return ""
+	}
+
+	return header + "\n\n" + strings.Join(profiles, "\n\n")
+}
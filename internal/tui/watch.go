@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fileWatchInterval is how often the context and chapter files are checked
+// for changes made outside the TUI.
+const fileWatchInterval = 2 * time.Second
+
+// watchedDirs are the project directories whose files the views show.
+var watchedDirs = []string{"context", "chapters"}
+
+// fileWatchTickMsg triggers a check for changed project files.
+type fileWatchTickMsg struct{}
+
+// fileWatchTick schedules the next check for changed project files.
+func fileWatchTick() tea.Cmd {
+	return tea.Tick(fileWatchInterval, func(time.Time) tea.Msg {
+		return fileWatchTickMsg{}
+	})
+}
+
+// fileStamp identifies one version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// scanProjectFiles stamps every context and chapter file.
+func (m *Model) scanProjectFiles() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	if m.project == nil || m.project.FS == nil {
+		return stamps
+	}
+	for _, dir := range watchedDirs {
+		files, _ := m.project.FS.ListMarkdownFiles(dir)
+		for _, f := range files {
+			stamps[f.Path] = fileStamp{modTime: f.ModTime, size: f.Size}
+		}
+	}
+	return stamps
+}
+
+// changedFiles returns the files added, changed or removed between two
+// scans, sorted.
+func changedFiles(before, after map[string]fileStamp) []string {
+	var changed []string
+	for path, stamp := range after {
+		if old, ok := before[path]; !ok || !old.modTime.Equal(stamp.modTime) || old.size != stamp.size {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// handleFileWatchTick picks up files changed on disk, by an external
+// editor or another tool, and schedules the next check.
+func (m *Model) handleFileWatchTick() tea.Cmd {
+	stamps := m.scanProjectFiles()
+	if m.fileStamps == nil {
+		m.fileStamps = stamps
+		return fileWatchTick()
+	}
+	changed := changedFiles(m.fileStamps, stamps)
+	m.fileStamps = stamps
+	if len(changed) > 0 {
+		m.handleFilesChanged(changed)
+	}
+	return fileWatchTick()
+}
+
+// handleFilesChanged reindexes changed files, drops their cached
+// summaries and refreshes the views that list them. A file open in the context editor is left alone, with a
+// warning that saving replaces the version on disk.
+func (m *Model) handleFilesChanged(paths []string) {
+	m.project.ForgetContextSummaries(paths)
+	for _, path := range paths {
+		if err := m.reindexContextFile(path); err != nil {
+			m.statusText = fmt.Sprintf("Reindexing %s failed: %v", filepath.ToSlash(path), err)
+		}
+		if m.contextEdit != nil && m.contextEdit.path == path {
+			m.statusText = fmt.Sprintf("%s changed on disk; saving replaces that version", filepath.ToSlash(path))
+		}
+	}
+
+	if m.contextEdit != nil {
+		return
+	}
+	switch m.view {
+	case ViewContext:
+		if n := len(m.contextEntries()); m.contextIndex >= n {
+			m.contextIndex = max(n-1, 0)
+		}
+		m.updateViewport()
+	case ViewChapters:
+		m.updateViewport()
+	}
+}
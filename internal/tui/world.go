@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azyu/dreamteller/internal/llm"
+	"github.com/azyu/dreamteller/internal/tui/styles"
+)
+
+// SuggestionTypeTravel is a travel time the model looked up, sent back to
+// it like a search result.
+const SuggestionTypeTravel SuggestionType = "travel"
+
+// handleTravelTime looks up the travel time between two places for the
+// model. Unknown places and missing routes are answered in the result, so
+// the model can ask the author instead of guessing.
+func (h *SuggestionHandler) handleTravelTime(call llm.ToolCall, query llm.TravelQuery) (*SuggestionResult, error) {
+	if h.project == nil {
+		return nil, fmt.Errorf("no project loaded")
+	}
+	world, err := h.project.World()
+	if err != nil {
+		return nil, err
+	}
+
+	var answer string
+	if journey, err := world.Travel(query.From, query.To); err != nil {
+		answer = fmt.Sprintf("Not found: %v. Do not invent a travel time; ask the author if it matters.", err)
+	} else {
+		answer = journey.String()
+	}
+
+	return &SuggestionResult{
+		Type:             SuggestionTypeTravel,
+		Title:            fmt.Sprintf("Looking up travel from %s to %s", query.From, query.To),
+		Content:          styles.MutedText.Render(answer),
+		RequiresApproval: false,
+		ToolCallID:       call.ID,
+		ToolCall:         call,
+		ParsedData:       answer,
+	}, nil
+}
+
+// handleWorldCommand handles /world, which shows the locations as a tree,
+// and /world <from> to <to>, which shows the travel time between two.
+func (m *Model) handleWorldCommand(args string) {
+	if m.project == nil {
+		m.err = fmt.Errorf("no project loaded")
+		return
+	}
+	world, err := m.project.World()
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	content := world.String()
+	if args != "" {
+		from, to, ok := strings.Cut(args, " to ")
+		if !ok {
+			m.err = fmt.Errorf("usage: /world <from> to <to>")
+			return
+		}
+		journey, err := world.Travel(strings.TrimSpace(from), strings.TrimSpace(to))
+		if err != nil {
+			m.err = err
+			return
+		}
+		content = journey.String()
+	}
+	m.messages = append(m.messages, Message{Role: "system", Content: content})
+	m.updateViewport()
+}
@@ -2,6 +2,8 @@
 package types
 
 import (
+	"net"
+	"net/url"
 	"time"
 )
 
@@ -24,12 +26,160 @@ type ProjectConfig struct {
 	Context   ContextConfig `yaml:"context"`
 	Budget    BudgetConfig  `yaml:"token_budget"`
 	Writing   WritingConfig `yaml:"writing"`
+
+	// Language is the author's language ("en", "ko", "ja") for messages
+	// shown in the TUI. Empty means English.
+	Language string `yaml:"language,omitempty"`
+
+	// Series names the series the book belongs to; its shared world bible
+	// appears under series/ in the project.
+	Series string `yaml:"series,omitempty"`
+
+	// Event is the writing challenge in progress, such as NaNoWriMo.
+	Event *EventConfig `yaml:"event,omitempty"`
+
+	// Reviewers adds reviewer personas, or replaces the built-in ones of
+	// the same name.
+	Reviewers []ReviewerConfig `yaml:"reviewers,omitempty"`
+
+	// Insights records usage, such as sessions and accepted suggestions,
+	// in the project's database for /insights. Nothing is sent anywhere.
+	Insights bool `yaml:"insights,omitempty"`
+
+	// Redaction hides sensitive strings from cloud providers.
+	Redaction RedactionConfig `yaml:"redaction,omitempty"`
+
+	// AutoApprove lists the context updates applied without asking.
+	AutoApprove []AutoApproveRule `yaml:"auto_approve,omitempty"`
+
+	// Tools limits the tools the AI may call in this project.
+	Tools ToolPermissions `yaml:"tools,omitempty"`
+
+	// QueueContextUpdates collects the context updates the AI suggests for
+	// review with /pending instead of asking about each one as it comes.
+	QueueContextUpdates bool `yaml:"queue_context_updates,omitempty"`
+
+	// Quota caps what the project spends on AI each calendar month.
+	Quota QuotaConfig `yaml:"quota,omitempty"`
+}
+
+// QuotaConfig is a project's monthly AI budget. The TUI warns at 80% of a
+// limit and asks before sending past 100%. Zero limits are not enforced.
+type QuotaConfig struct {
+	// MonthlyTokens caps the prompt and completion tokens used per month.
+	MonthlyTokens int `yaml:"monthly_tokens,omitempty"`
+
+	// MonthlyCost caps the estimated cost per month, in the currency of
+	// Prices.
+	MonthlyCost float64 `yaml:"monthly_cost,omitempty"`
+
+	// Prices maps model names to their prices, used to estimate cost.
+	// Models without a price count toward MonthlyTokens only.
+	Prices map[string]ModelPrice `yaml:"prices,omitempty"`
+}
+
+// ModelPrice is what a model charges per million tokens.
+type ModelPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// ToolPermissions limits the tools offered to the AI. A tool is offered if
+// the profile includes it, Allow (when set) lists it and Deny does not.
+type ToolPermissions struct {
+	// Profile is "all" (the default), "read-only" (no tool that changes
+	// files or memories), "search-only" or "none".
+	Profile string `yaml:"profile,omitempty"`
+
+	// Allow, when set, lists the only tools that may be offered.
+	Allow []string `yaml:"allow,omitempty"`
+
+	// Deny lists tools never offered, e.g. update_context.
+	Deny []string `yaml:"deny,omitempty"`
+}
+
+// AutoApproveRule lets context updates the AI suggests be applied without
+// asking when they match it; /undo reverts the last one. Creating or
+// replacing a character file always asks.
+type AutoApproveRule struct {
+	// FileType is the kind of file, e.g. "plot"; empty matches any.
+	FileType string `yaml:"file_type,omitempty"`
+
+	// Operation is "create", "update" or "append"; empty matches any.
+	Operation string `yaml:"operation,omitempty"`
+
+	// MaxTokens caps the estimated size of the new content; 0 means no
+	// cap.
+	MaxTokens int `yaml:"max_tokens,omitempty"`
+}
+
+// RedactionConfig pseudonymizes sensitive strings, such as real names and
+// addresses, in everything sent to a cloud provider; replies show the
+// original strings again.
+type RedactionConfig struct {
+	Rules []RedactionRule `yaml:"rules,omitempty"`
+
+	// Local applies the rules to local providers too.
+	Local bool `yaml:"local,omitempty"`
+}
+
+// RedactionRule is one string or pattern to hide.
+type RedactionRule struct {
+	// Text is the exact string to hide, e.g. a real name.
+	Text string `yaml:"text,omitempty"`
+
+	// Pattern is a regular expression to hide instead of Text, e.g. for
+	// phone numbers.
+	Pattern string `yaml:"pattern,omitempty"`
+
+	// Alias is sent in place of Text. Pattern matches, and Text without an
+	// alias, are numbered instead: Alias-1, Alias-2 and so on, with
+	// "REDACTED" when Alias is empty.
+	Alias string `yaml:"alias,omitempty"`
+}
+
+// ReviewerConfig is a reviewer persona asked to critique chapters.
+type ReviewerConfig struct {
+	// Name identifies the persona, e.g. "copy-editor".
+	Name string `yaml:"name"`
+
+	// Description says what the persona looks for.
+	Description string `yaml:"description,omitempty"`
+
+	// Prompt is the persona's system prompt. "{genre}" is replaced with the
+	// project's genre.
+	Prompt string `yaml:"prompt"`
+}
+
+// EventConfig is a writing challenge: TargetWords new words in Days days
+// from Start (YYYY-MM-DD). StartWords is the manuscript's word count when
+// the event began; only words past it count.
+type EventConfig struct {
+	Name        string `yaml:"name"`
+	TargetWords int    `yaml:"target_words"`
+	Start       string `yaml:"start"`
+	Days        int    `yaml:"days"`
+	StartWords  int    `yaml:"start_words"`
 }
 
 // LLMConfig specifies the LLM provider settings.
 type LLMConfig struct {
 	Provider string `yaml:"provider"`
 	Model    string `yaml:"model"`
+
+	// DraftModel writes new prose and PolishModel line-edits it with
+	// /polish, each as "model" or "provider/model". Empty uses the
+	// session's model.
+	DraftModel  string `yaml:"draft_model,omitempty"`
+	PolishModel string `yaml:"polish_model,omitempty"`
+
+	// RefineModel, when set, revises every drafted reply in the same turn,
+	// so a cheap or local draft model can do the first pass.
+	RefineModel string `yaml:"refine_model,omitempty"`
+
+	// SelfCritique has the model critique each drafted reply against the
+	// project's style and outline, then revise it, in the same turn.
+	SelfCritique bool `yaml:"self_critique,omitempty"`
 }
 
 // ContextConfig controls semantic search and context injection.
@@ -37,6 +187,22 @@ type ContextConfig struct {
 	MaxChunks    int     `yaml:"max_chunks"`
 	ChunkSize    int     `yaml:"chunk_size"`
 	ChunkOverlap float64 `yaml:"chunk_overlap"`
+	// MaxChunksPerSource caps chunks taken from one file before other files
+	// get a turn. Zero uses the default.
+	MaxChunksPerSource int `yaml:"max_chunks_per_source,omitempty"`
+	// Chunking is how files are cut into chunks for the search index:
+	// "semantic" at headings, paragraphs and sentences, or "tokens" (the
+	// default) every ChunkSize tokens.
+	Chunking string `yaml:"chunking,omitempty"`
+	// HistorySummary has the model keep a running summary of chat history
+	// that no longer fits the history budget, instead of dropping it.
+	HistorySummary bool `yaml:"history_summary,omitempty"`
+	// Exclude lists glob patterns of files kept out of the search index and
+	// context, added to those in the project's .dreamtellerignore.
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Pinned lists research files and directories whose chunks may be added
+	// to context automatically; other research is only searched on request.
+	Pinned []string `yaml:"pinned,omitempty"`
 }
 
 // BudgetConfig defines token budget allocation ratios.
@@ -52,8 +218,29 @@ type WritingConfig struct {
 	Style string `yaml:"style"`
 	POV   string `yaml:"pov"`
 	Tense string `yaml:"tense"`
+
+	// Rating is the content rating generated prose must respect
+	// (see RatingYA, RatingAdult, RatingNoGraphicViolence).
+	Rating string `yaml:"rating,omitempty"`
+
+	// CrutchWords adds words and phrases to the built-in crutch word list.
+	// With AvoidCrutchWords, the most overused ones are named in editing
+	// prompts so the model cuts them.
+	CrutchWords      []string `yaml:"crutch_words,omitempty"`
+	AvoidCrutchWords bool     `yaml:"avoid_crutch_words,omitempty"`
+
+	// TargetWords is the planned length of the book, used to tell when a
+	// beat is overdue. Zero means no target.
+	TargetWords int `yaml:"target_words,omitempty"`
 }
 
+// Content ratings for WritingConfig.Rating.
+const (
+	RatingYA                = "ya"
+	RatingAdult             = "adult"
+	RatingNoGraphicViolence = "no-graphic-violence"
+)
+
 // GlobalConfig is the user-wide configuration at ~/.config/dreamteller/config.yaml.
 type GlobalConfig struct {
 	Version     int                        `yaml:"version"`
@@ -61,6 +248,10 @@ type GlobalConfig struct {
 	Providers   map[string]*ProviderConfig `yaml:"providers"`
 	Defaults    DefaultsConfig             `yaml:"defaults"`
 	Logging     LoggingConfig              `yaml:"logging"`
+
+	// Language is the language of the command line's help, messages and
+	// prompts: "en", "ko" or "ja". Empty is English.
+	Language string `yaml:"language,omitempty"`
 }
 
 // ProviderConfig holds API configuration for an LLM provider.
@@ -69,11 +260,45 @@ type ProviderConfig struct {
 	DefaultModel string `yaml:"default_model"`
 	BaseURL      string `yaml:"base_url,omitempty"`
 	Protocol     string `yaml:"protocol,omitempty"`
+	Preset       string `yaml:"preset,omitempty"`
+
+	// Timeout bounds each generation request (e.g. "5m"). Zero uses the default.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// KeepAlive and NumCtx tune the native Ollama protocol.
+	KeepAlive string `yaml:"keep_alive,omitempty"`
+	NumCtx    int    `yaml:"num_ctx,omitempty"`
+}
+
+// IsLocalProvider reports whether the provider named name runs on this
+// machine: the local provider, or any provider whose base URL is a
+// loopback address, such as Ollama's OpenAI-compatible endpoint.
+func IsLocalProvider(name string, config *ProviderConfig) bool {
+	if name == "local" {
+		return true
+	}
+	if config == nil || config.BaseURL == "" {
+		return false
+	}
+	u, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // DefaultsConfig specifies default settings.
 type DefaultsConfig struct {
 	Provider string `yaml:"provider"`
+
+	// AutoContinue is how many times a reply cut off by the output token
+	// limit is continued automatically. Zero only offers /continue.
+	AutoContinue int `yaml:"auto_continue,omitempty"`
 }
 
 // LoggingConfig specifies logging settings.
@@ -86,9 +311,16 @@ type Character struct {
 	Name        string            `yaml:"name" json:"name"`
 	Description string            `yaml:"description" json:"description"`
 	Traits      map[string]string `yaml:"traits" json:"traits"`
+	Voice       string            `yaml:"voice,omitempty" json:"voice,omitempty"`
+	SampleLines []string          `yaml:"sample_lines,omitempty" json:"sample_lines,omitempty"`
 	FilePath    string            `yaml:"-" json:"file_path"`
 }
 
+// HasVoice reports whether the character has a voice profile.
+func (c *Character) HasVoice() bool {
+	return c.Voice != "" || len(c.SampleLines) > 0
+}
+
 // Setting represents a world/location setting.
 type Setting struct {
 	Name        string `yaml:"name" json:"name"`
@@ -104,6 +336,13 @@ type PlotPoint struct {
 	FilePath    string `yaml:"-" json:"file_path"`
 }
 
+// GlossaryEntry is an invented term with its canonical spelling.
+type GlossaryEntry struct {
+	Term       string `yaml:"term" json:"term"`
+	Definition string `yaml:"definition" json:"definition"`
+	FilePath   string `yaml:"-" json:"file_path"`
+}
+
 // Chapter represents a written chapter.
 type Chapter struct {
 	Number    int       `yaml:"number" json:"number"`
@@ -190,6 +429,7 @@ func DefaultProjectConfig(name, genre string) *ProjectConfig {
 			MaxChunks:    5,
 			ChunkSize:    800,
 			ChunkOverlap: 0.15,
+			Chunking:     "semantic",
 		},
 		Budget: BudgetConfig{
 			SystemPrompt: 0.20,
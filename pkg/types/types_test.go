@@ -163,3 +163,16 @@ func TestDefaultGlobalConfig_ProvidersMapInitialized(t *testing.T) {
 	assert.Equal(t, "test-key", cfg.Providers["openai"].APIKey)
 	assert.Equal(t, "gpt-4", cfg.Providers["openai"].DefaultModel)
 }
+
+func TestIsLocalProvider(t *testing.T) {
+	assert.True(t, IsLocalProvider("local", nil))
+	assert.True(t, IsLocalProvider("local", &ProviderConfig{BaseURL: "http://192.168.0.5:11434", Protocol: "ollama"}))
+	assert.True(t, IsLocalProvider("openai", &ProviderConfig{BaseURL: "http://localhost:11434/v1"}))
+	assert.True(t, IsLocalProvider("openai", &ProviderConfig{BaseURL: "http://127.0.0.1:1234/v1"}))
+	assert.True(t, IsLocalProvider("openai", &ProviderConfig{BaseURL: "http://[::1]:8000/v1"}))
+
+	assert.False(t, IsLocalProvider("openai", nil))
+	assert.False(t, IsLocalProvider("openai", &ProviderConfig{}))
+	assert.False(t, IsLocalProvider("gemini", &ProviderConfig{APIKey: "key"}))
+	assert.False(t, IsLocalProvider("openai", &ProviderConfig{BaseURL: "https://api.openai.com/v1"}))
+}